@@ -1,116 +1,759 @@
 package build
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/WhatsApp-Platform/typegen/diagnostics"
 	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
 	"github.com/WhatsApp-Platform/typegen/parser"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
 	"github.com/WhatsApp-Platform/typegen/validator"
+	"github.com/WhatsApp-Platform/typegen/version"
 )
 
+// Task errors wrap one of these, or the parser's error, to tell apart why a
+// task failed
+var (
+	// ErrValidation is wrapped by the errors of tasks whose input fails validation
+	ErrValidation = errors.New("validation failed")
+	// ErrGeneration is wrapped by the errors of tasks whose generator fails
+	ErrGeneration = errors.New("code generation failed")
+	// ErrHook is wrapped by the errors of tasks whose hook fails
+	ErrHook = errors.New("hook failed")
+	// ErrTimeout is wrapped by the errors of tasks that run longer than
+	// their timeout
+	ErrTimeout = errors.New("task timed out")
+	// ErrInterrupted is wrapped, with the context's error, by the error of
+	// the task running when the build's context is cancelled
+	ErrInterrupted = errors.New("build interrupted")
+)
+
+// BuildError is returned by Build when tasks fail. It unwraps to the task
+// errors, so errors.Is and errors.As see each of them.
+type BuildError struct {
+	Errors []error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build failed with %d errors", len(e.Errors))
+}
+
+func (e *BuildError) Unwrap() []error {
+	return e.Errors
+}
+
 // Builder orchestrates the build process
 type Builder struct {
 	config          *Config
+	logger          *slog.Logger
 	moduleCache     map[string]*ast.Module                 // Cache parsed modules
 	validationCache map[string]*validator.ValidationResult // Cache validation results
+	written         map[int]generators.Manifest            // What each task's last successful run wrote
+	validated       map[int]ValidationStatus               // Outcome of each task's last validation
+	diagnostics     map[int][]Diagnostic                   // Parse errors, or validation errors and warnings, of each task's last run
+	result          *BuildResult                           // Outcome of the last BuildTasks
+	progress        Progress
+	cache           *buildCache // Loaded on first use when the configuration enables it
+	cacheChanged    bool
+	fetched         map[string]bool // Remote inputs fetched or found in the remote cache, by key
+	force           bool
+	update          bool
+	skipHooks       bool
+	cleanAll        bool
+	dryRun          bool
 }
 
-// NewBuilder creates a new builder with the given configuration
+// NewBuilder creates a new builder with the given configuration. It prints
+// nothing: the outcome of a build is its BuildResult, progress is reported
+// to a Progress, and log lines to the logger set with SetLogger.
 func NewBuilder(config *Config) *Builder {
 	return &Builder{
 		config:          config,
+		logger:          logging.Discard(),
 		moduleCache:     make(map[string]*ast.Module),
 		validationCache: make(map[string]*validator.ValidationResult),
+		written:         make(map[int]generators.Manifest),
+		validated:       make(map[int]ValidationStatus),
+		diagnostics:     make(map[int][]Diagnostic),
+		fetched:         make(map[string]bool),
 	}
 }
 
-// Build executes all generation tasks defined in the configuration
-func (b *Builder) Build(ctx context.Context) error {
+// SetLogger sets the logger for build progress. Task summaries are logged at
+// info level, per-file lines and timing at debug level, and task configs and
+// cache hits at logging.LevelTrace. Generators receive the logger through
+// the context passed to Generate.
+func (b *Builder) SetLogger(logger *slog.Logger) {
+	b.logger = logger
+}
+
+// SetClean sets how stale generated files are cleaned up. With all, every
+// task cleans its output as if it set clean in the configuration; with
+// dryRun, the files that would be removed are only logged.
+func (b *Builder) SetClean(all, dryRun bool) {
+	b.cleanAll = all
+	b.dryRun = dryRun
+}
+
+// SetForce makes every task run even when the build cache finds that its
+// inputs haven't changed. The cache is still updated.
+func (b *Builder) SetForce(force bool) {
+	b.force = force
+}
+
+// SetUpdate makes remote inputs whose ref is a branch be fetched again,
+// instead of used from the remote cache. Tags and commits don't move, so
+// they are only fetched once.
+func (b *Builder) SetUpdate(update bool) {
+	b.update = update
+}
+
+// SetSkipHooks makes tasks generate code without running their hooks
+func (b *Builder) SetSkipHooks(skip bool) {
+	b.skipHooks = skip
+}
+
+// Build executes all generation tasks defined in the configuration; see
+// BuildTasks
+func (b *Builder) Build(ctx context.Context) (*BuildResult, error) {
+	return b.BuildTasks(ctx, TaskFilter{})
+}
+
+// TaskFilter selects the tasks run by BuildTasks. A task is selected when it
+// matches every non-empty list; the empty filter selects every task.
+type TaskFilter struct {
+	// Tasks are task names, or task numbers as shown in build output
+	// starting at 1
+	Tasks []string
+	// Generators are generator names
+	Generators []string
+}
+
+// Empty reports whether the filter selects every task
+func (f TaskFilter) Empty() bool {
+	return len(f.Tasks) == 0 && len(f.Generators) == 0
+}
+
+// Selected returns the indexes of the tasks of the configuration that the
+// filter selects, or an error listing the available tasks if a task of the
+// filter doesn't exist or no task is selected
+func (f TaskFilter) Selected(config *Config) ([]int, error) {
+	names := make(map[string]int)
+	for i := range config.Generate {
+		names[config.TaskName(i)] = i
+		names[strconv.Itoa(i+1)] = i
+	}
+	tasks := make(map[int]bool)
+	for _, task := range f.Tasks {
+		i, ok := names[task]
+		if !ok {
+			return nil, fmt.Errorf("no task named %q; available tasks: %s", task, availableTasks(config))
+		}
+		tasks[i] = true
+	}
+
+	var selected []int
+	for i, task := range config.Generate {
+		if len(f.Tasks) > 0 && !tasks[i] {
+			continue
+		}
+		if len(f.Generators) > 0 && !slices.Contains(f.Generators, task.Generator) {
+			continue
+		}
+		selected = append(selected, i)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no tasks match the filter; available tasks: %s", availableTasks(config))
+	}
+	return selected, nil
+}
+
+// availableTasks lists the tasks of the configuration for error messages
+func availableTasks(config *Config) string {
+	var available []string
+	for i, task := range config.Generate {
+		available = append(available, fmt.Sprintf("%d %s (%s)", i+1, config.TaskName(i), task.Generator))
+	}
+	return strings.Join(available, ", ")
+}
+
+// Result returns the outcome of the last call to BuildTasks or Build, or nil
+// if none ran tasks; it is the result they returned
+func (b *Builder) Result() *BuildResult {
+	return b.result
+}
+
+// BuildTasks executes the generation tasks selected by filter. Tasks that are
+// not selected are reported as skipped. Each task runs after the tasks it
+// depends on, and doesn't run if one of them fails; a dependency that is not
+// selected doesn't run either, and is assumed to be up to date. The outcome
+// of every task is in the returned result, in configuration order, which is
+// nil only when no task ran because the filter or configuration is invalid.
+// The error is a *BuildError when tasks or cleaning failed.
+//
+// Cancelling ctx stops the build between tasks and phases, and is passed
+// to generators and hooks: the running task is reported as interrupted,
+// the tasks after it as not run, and the tasks before it keep their
+// outcome.
+func (b *Builder) BuildTasks(ctx context.Context, filter TaskFilter) (*BuildResult, error) {
 	if b.config == nil {
-		return fmt.Errorf("no configuration provided")
+		return nil, fmt.Errorf("no configuration provided")
 	}
 
-	fmt.Printf("Starting build with %d generation tasks...\n", len(b.config.Generate))
+	selected, err := filter.Selected(b.config)
+	if err != nil {
+		return nil, err
+	}
+	order, err := b.config.taskOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result := &BuildResult{
+		Version:   version.Version,
+		StartedAt: start,
+		Warnings:  append([]string{}, b.config.Warnings()...),
+		Tasks:     make([]TaskResult, len(b.config.Generate)),
+		Removed:   []string{},
+		Errors:    []string{},
+	}
+	b.result = result
+
+	// Blank lines separate the tasks, the summary and the errors, as in
+	// the progress output before leveled logging
+	b.logger.Info(fmt.Sprintf("Starting build with %d generation tasks...", len(selected)))
 
 	// Track errors but continue processing all tasks
 	var buildErrors []error
 	successCount := 0
 
-	for i, task := range b.config.Generate {
-		fmt.Printf("\n[%d/%d] Generating %s code from %s to %s...\n",
-			i+1, len(b.config.Generate), task.Generator, task.Input, task.Output)
+	failed := func(i int) bool {
+		status := result.Tasks[i].Status
+		return status == TaskFailed || status == TaskDependencyFailed
+	}
+	for _, i := range order {
+		task := b.config.Generate[i]
+		name := b.config.TaskName(i)
+		if !slices.Contains(selected, i) {
+			b.logger.Info(fmt.Sprintf("\n[%d/%d %s] ⏭️  Skipped %s code to %s",
+				i+1, len(b.config.Generate), name, task.Generator, task.Output))
+			result.Tasks[i] = b.newTaskResult(i, TaskSkipped)
+			continue
+		}
+		if ctx.Err() != nil {
+			b.logger.Info(fmt.Sprintf("\n[%d/%d %s] ⏭️  Not run: build interrupted",
+				i+1, len(b.config.Generate), name))
+			result.Tasks[i] = b.newTaskResult(i, TaskNotRun)
+			continue
+		}
+		if dependency := b.config.failedDependency(i, failed); dependency != "" {
+			b.logger.Info(fmt.Sprintf("\n[%d/%d %s] ⏭️  Skipped (dependency failed): %s did not succeed",
+				i+1, len(b.config.Generate), name, dependency))
+			result.Tasks[i] = b.newTaskResult(i, TaskDependencyFailed)
+			result.Tasks[i].Error = fmt.Sprintf("dependency %s did not succeed", dependency)
+			continue
+		}
+
+		b.logger.Info(fmt.Sprintf("\n[%d/%d %s] Generating %s code from %s to %s...",
+			i+1, len(b.config.Generate), name, task.Generator, task.describeInput(), task.Output))
+		if task.Description != "" {
+			b.logger.Debug(fmt.Sprintf("[%s] %s", name, task.Description))
+		}
 
-		if err := b.executeTask(ctx, task, i); err != nil {
-			buildErrors = append(buildErrors, fmt.Errorf("task %d (%s): %w", i+1, task.Generator, err))
-			fmt.Printf("❌ Failed: %v\n", err)
+		taskResult, err := b.runTask(ctx, i)
+		if taskResult.Status == TaskInterrupted {
+			buildErrors = append(buildErrors, fmt.Errorf("task %s: %w", name, err))
+			b.logger.Error(fmt.Sprintf("[%s] ⛔ Interrupted: %v", name, err))
+		} else if err != nil {
+			buildErrors = append(buildErrors, fmt.Errorf("task %s: %w", name, err))
+			b.logger.Error(fmt.Sprintf("[%s] ❌ Failed: %v", name, err))
+		} else if taskResult.Status == TaskCached {
+			successCount++
+			b.logger.Info(fmt.Sprintf("[%s] ✅ Cached: inputs unchanged since the last build", name))
 		} else {
 			successCount++
-			fmt.Printf("✅ Success\n")
+			b.logger.Info(fmt.Sprintf("[%s] ✅ Success", name))
+		}
+		result.Tasks[i] = taskResult
+	}
+	b.saveCache()
+
+	// Clean each output once, after all of its tasks have run
+	cleaned := make(map[string]bool)
+	for _, i := range selected {
+		output := b.config.Generate[i].Output
+		if cleaned[output] {
+			continue
+		}
+		cleaned[output] = true
+		removed, err := b.cleanOutput(output)
+		result.Removed = append(result.Removed, removed...)
+		if err != nil {
+			buildErrors = append(buildErrors, err)
+			result.Errors = append(result.Errors, err.Error())
+			b.logger.Error(fmt.Sprintf("❌ %v", err))
 		}
 	}
+	result.DurationMS = milliseconds(time.Since(start))
+	result.summarize()
 
 	// Report results
-	fmt.Printf("\nBuild completed: %d/%d tasks succeeded\n", successCount, len(b.config.Generate))
+	summary := fmt.Sprintf("Build completed: %d/%d tasks succeeded", successCount, len(selected))
+	if ctx.Err() != nil {
+		summary = fmt.Sprintf("Build interrupted: %d/%d tasks succeeded", successCount, len(selected))
+	}
+	if result.Summary.Cached > 0 {
+		summary += fmt.Sprintf(", %d cached", result.Summary.Cached)
+	}
+	if result.Summary.DependencyFailed > 0 {
+		summary += fmt.Sprintf(", %d skipped (dependency failed)", result.Summary.DependencyFailed)
+	}
+	if result.Summary.Interrupted > 0 {
+		summary += fmt.Sprintf(", %d interrupted", result.Summary.Interrupted)
+	}
+	if result.Summary.NotRun > 0 {
+		summary += fmt.Sprintf(", %d not run", result.Summary.NotRun)
+	}
+	if skipped := len(b.config.Generate) - len(selected); skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	b.logger.Info("\n" + summary)
 
 	if len(buildErrors) > 0 {
-		fmt.Printf("\nErrors encountered:\n")
+		b.logger.Error("\nErrors encountered:")
 		for _, err := range buildErrors {
-			fmt.Printf("  - %v\n", err)
+			b.logger.Error(fmt.Sprintf("  - %v", err))
 		}
-		return fmt.Errorf("build failed with %d errors", len(buildErrors))
+		return result, &BuildError{Errors: buildErrors}
 	}
 
-	return nil
+	return result, nil
+}
+
+// BuildTask executes the generation task at index in the configuration
+func (b *Builder) BuildTask(ctx context.Context, index int) error {
+	if b.config == nil {
+		return fmt.Errorf("no configuration provided")
+	}
+	if index < 0 || index >= len(b.config.Generate) {
+		return fmt.Errorf("task %d does not exist", index+1)
+	}
+
+	task := b.config.Generate[index]
+	_, err := b.runTask(ctx, index)
+	b.saveCache()
+	if err != nil {
+		return fmt.Errorf("task %s: %w", b.config.TaskName(index), err)
+	}
+	_, err = b.cleanOutput(task.Output)
+	return err
+}
+
+// newTaskResult returns the result of a task before its outcome is known
+func (b *Builder) newTaskResult(taskIndex int, status TaskStatus) TaskResult {
+	task := b.config.Generate[taskIndex]
+	return TaskResult{
+		Task:        taskIndex + 1,
+		Name:        b.config.TaskName(taskIndex),
+		Description: task.Description,
+		Generator:   task.Generator,
+		Input:       task.describeInput(),
+		Output:      task.Output,
+		Status:      status,
+		Files:       []generators.WrittenFile{},
+		Dirs:        []string{},
+	}
 }
 
-// executeTask executes a single generation task
+// runTask fetches the remote inputs of the task at taskIndex and executes it
+// unless the build cache has it up to date, within the task's timeout,
+// timing it and reporting it to the progress receiver. The task is
+// interrupted when ctx is cancelled before it completes.
+func (b *Builder) runTask(ctx context.Context, taskIndex int) (TaskResult, error) {
+	task := b.config.Generate[taskIndex]
+	result := b.newTaskResult(taskIndex, TaskSucceeded)
+
+	start := time.Now()
+	taskCtx, cancel := b.taskContext(ctx, taskIndex)
+	defer cancel()
+
+	hash, cached := "", false
+	err := b.fetchInputs(taskCtx, taskIndex)
+	if err == nil {
+		hash, cached = b.lookupCache(taskIndex)
+	}
+	if cached {
+		result.Status = TaskCached
+	} else if err == nil {
+		err = b.executeTask(taskCtx, task, taskIndex)
+		b.updateCache(taskIndex, hash, err)
+		result.Validation = b.validated[taskIndex]
+		result.Diagnostics = b.diagnostics[taskIndex]
+	}
+	result.DurationMS = milliseconds(time.Since(start))
+
+	// Whatever failed once the context was done failed because of it
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%w: %w", ErrInterrupted, ctx.Err())
+	case taskCtx.Err() != nil:
+		err = fmt.Errorf("%w after %s", ErrTimeout, task.Timeout)
+	}
+	if err != nil {
+		result.Status = TaskFailed
+		if errors.Is(err, ErrInterrupted) {
+			result.Status = TaskInterrupted
+		}
+		result.Error = err.Error()
+	} else {
+		result.Files = b.written[taskIndex].Files
+		result.Dirs = b.written[taskIndex].Dirs
+	}
+
+	if b.progress != nil {
+		b.progress.TaskDone(result)
+	}
+	return result, err
+}
+
+// taskContext returns the context of the task at taskIndex: ctx, with the
+// task's timeout if it has one
+func (b *Builder) taskContext(ctx context.Context, taskIndex int) (context.Context, context.CancelFunc) {
+	if timeout := b.config.Generate[taskIndex].Timeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// executeTask executes a single generation task with its hooks, recording
+// the files it writes and the directories it creates
 func (b *Builder) executeTask(ctx context.Context, task GenerateTask, taskIndex int) error {
+	// Create filesystem for output
+	fs := generators.NewTrackingFS(generators.NewOSFS(task.Output))
+	if b.progress != nil {
+		fs.OnWrite = func(name string, count int) {
+			b.progress.FileWritten(taskIndex+1, filepath.ToSlash(name), count)
+		}
+	}
+
+	delete(b.written, taskIndex)
+	delete(b.validated, taskIndex)
+	delete(b.diagnostics, taskIndex)
+	if err := b.runHooks(ctx, taskIndex, hookPre, task.Hooks.Pre); err != nil {
+		return err
+	}
+	if err := b.generateTask(ctx, task, taskIndex, fs, b.phaseReporter(taskIndex)); err != nil {
+		return err
+	}
+	if err := b.runHooks(ctx, taskIndex, hookPost, task.Hooks.Post); err != nil {
+		return err
+	}
+	b.written[taskIndex] = fs.Manifest()
+	return nil
+}
+
+// cleanOutput removes the stale generated files of an output directory when
+// one of its tasks asks for it, and returns their paths. Every task
+// generating into the output must have succeeded, or the files of the others
+// would look stale. Files under the output directory of another task belong
+// to that task and are kept.
+func (b *Builder) cleanOutput(output string) ([]string, error) {
+	clean := b.cleanAll
+	var tasks []int
+	for i, task := range b.config.Generate {
+		if task.Output == output {
+			tasks = append(tasks, i)
+			clean = clean || task.Clean
+		}
+	}
+	if !clean {
+		return nil, nil
+	}
+
+	keep := make(map[string]bool)
+	for _, i := range tasks {
+		written, ok := b.written[i]
+		if !ok {
+			b.logger.Debug(fmt.Sprintf("not cleaning %s: task %s has not succeeded", output, b.config.TaskName(i)))
+			return nil, nil
+		}
+		for _, file := range written.Files {
+			keep[file.Path] = true
+		}
+	}
+
+	var nested []string
+	for _, dir := range b.config.outputsWithin(output) {
+		rel, err := filepath.Rel(output, dir)
+		if err == nil {
+			nested = append(nested, filepath.ToSlash(rel)+"/")
+		}
+	}
+
+	fs, ok := generators.NewOSFS(output).(generators.RemoveFS)
+	if !ok {
+		return nil, fmt.Errorf("cannot clean output directory %s", output)
+	}
+	removed, err := generators.Clean(fs, func(name string) bool {
+		if keep[name] {
+			return true
+		}
+		for _, prefix := range nested {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}, b.dryRun)
+	paths := make([]string, len(removed))
+	for i, name := range removed {
+		paths[i] = filepath.Join(output, name)
+		if b.dryRun {
+			b.logger.Info(fmt.Sprintf("Would remove stale %s", paths[i]))
+		} else {
+			b.logger.Info(fmt.Sprintf("🧹 Removed stale %s", paths[i]))
+		}
+	}
+	if err != nil {
+		return paths, fmt.Errorf("failed to clean %s: %w", output, err)
+	}
+	return paths, nil
+}
+
+// generateTask parses and validates a task's input and generates code into
+// fs, calling phase as it moves through the phases
+func (b *Builder) generateTask(ctx context.Context, task GenerateTask, taskIndex int, fs generators.FS, phase func(Phase)) error {
+	phase(PhaseParsing)
+
 	// Get the generator for the specified language
 	generator, err := generators.Get(task.Generator)
 	if err != nil {
 		return fmt.Errorf("generator not found: %w", err)
 	}
+	if warning := generators.DeprecationWarning(task.Generator); warning != "" {
+		b.logger.Warn(fmt.Sprintf("[%s] ⚠️  %s", b.config.TaskName(taskIndex), warning))
+	}
+
+	if err := generators.ValidateConfig(generator, b.config.generatorConfig(taskIndex)); err != nil {
+		return fmt.Errorf("invalid %s config: %w", task.Generator, err)
+	}
 
 	// Get merged configuration for this task
 	mergedConfig := b.config.MergedConfig(taskIndex)
 
-	// Set configuration on the generator
-	generator.SetConfig(mergedConfig)
+	// Set configuration on the generator, typed if it takes typed values
+	generators.Configure(generator, b.config.MergedTypedConfig(taskIndex))
+	if b.logger.Enabled(ctx, logging.LevelTrace) {
+		keys := make([]string, 0, len(mergedConfig))
+		for key := range mergedConfig {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		attrs := []any{"generator", task.Generator}
+		for _, key := range keys {
+			attrs = append(attrs, slog.String("config."+key, mergedConfig[key]))
+		}
+		b.trace(fmt.Sprintf("task %s config", b.config.TaskName(taskIndex)), attrs...)
+	}
 
 	// Parse the input module (cached)
-	module, err := b.getOrParseModule(task.Input)
+	dirs, err := b.config.inputDirs(taskIndex)
 	if err != nil {
 		return err
 	}
-
-	// Validate the module before generation (cached)
-	result, err := b.getOrValidateModule(module, task.Input)
+	modulePath := strings.Join(dirs, ", ")
+	if task.merged() {
+		modulePath = "[" + modulePath + "]"
+	}
+	if len(task.Exclude) > 0 {
+		modulePath += " excluding " + strings.Join(task.Exclude, " ")
+	}
+	module, err := b.getOrParseModule(modulePath, dirs, task)
 	if err != nil {
+		b.diagnostics[taskIndex] = diagnostics.FromParseError(err)
 		return err
 	}
-
-	if result != nil && result.HasErrors() {
-		return fmt.Errorf("validation failed with %d errors:\n%s", result.ErrorCount(), result.String())
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Create filesystem for output
-	fs := generators.NewOSFS(task.Output)
+	// Validate the module before generation (cached)
+	if err := b.validateTask(taskIndex, module, modulePath, phase); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Generate code
-	if err := generator.Generate(ctx, module, fs); err != nil {
-		return fmt.Errorf("code generation failed: %w", err)
+	phase(PhaseGenerating)
+	start := time.Now()
+	ctx = logging.WithLogger(ctx, b.logger)
+	// Generators see the fields of embedded structs as fields of their own
+	module = semantic.Flatten(module)
+	dest := generators.NewProcessingFS(generators.NewLoggingFS(fs, b.logger), b.processors(taskIndex, module)...)
+	if err := generator.Generate(ctx, module, dest); err != nil {
+		return fmt.Errorf("%w: %w", ErrGeneration, err)
 	}
+	b.logger.Debug(fmt.Sprintf("generated %s code in %s", task.Generator, roundElapsed(time.Since(start))))
 
 	return nil
 }
 
-// ValidateGenerators checks if all generators specified in the config are available
+// processors returns the post-processors of the task at taskIndex, which
+// generates module
+func (b *Builder) processors(taskIndex int, module *ast.Module) []generators.FileProcessor {
+	task := b.config.Generate[taskIndex]
+	if len(task.PostProcess) == 0 {
+		return nil
+	}
+	env := b.taskEnv(taskIndex)
+	processors := make([]generators.FileProcessor, len(task.PostProcess))
+	for i, process := range task.PostProcess {
+		processors[i] = process.processor(env, module)
+	}
+	return processors
+}
+
+// trace logs at logging.LevelTrace
+func (b *Builder) trace(msg string, args ...any) {
+	b.logger.Log(context.Background(), logging.LevelTrace, msg, args...)
+}
+
+// roundElapsed rounds a duration for display: to milliseconds, or to
+// microseconds when shorter than a millisecond
+func roundElapsed(elapsed time.Duration) time.Duration {
+	if elapsed >= time.Millisecond {
+		return elapsed.Round(time.Millisecond)
+	}
+	return elapsed.Round(time.Microsecond)
+}
+
+// ChangeKind describes how a file on disk differs from the generated code
+type ChangeKind string
+
+const (
+	// ChangeAdded marks a generated file that is missing on disk
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved marks a file on disk that is no longer generated
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeChanged marks a file whose content on disk differs from the generated code
+	ChangeChanged ChangeKind = "changed"
+)
+
+// FileChange is a difference found by Check
+type FileChange struct {
+	// Path is the file path, including the task's output directory
+	Path string
+	Kind ChangeKind
+}
+
+// Check generates every task into memory and compares the result with the
+// files under each task's output directory, without writing anything. It
+// returns the differences ordered by output directory and path; an empty
+// result means the generated code is up to date. Every file under an output
+// directory is expected to be generated, so unrelated files there are
+// reported as removed.
+func (b *Builder) Check(ctx context.Context) ([]FileChange, error) {
+	if b.config == nil {
+		return nil, fmt.Errorf("no configuration provided")
+	}
+
+	// Tasks sharing an output directory generate into the same filesystem
+	var outputs []string
+	generated := make(map[string]*generators.InMemoryFS)
+	for i, task := range b.config.Generate {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInterrupted, err)
+		}
+		fs, exists := generated[task.Output]
+		if !exists {
+			fs = generators.NewInMemoryFS()
+			generated[task.Output] = fs
+			outputs = append(outputs, task.Output)
+		}
+		if err := b.fetchInputs(ctx, i); err != nil {
+			return nil, fmt.Errorf("task %s: %w", b.config.TaskName(i), err)
+		}
+		if err := b.generateTask(ctx, task, i, fs, func(Phase) {}); err != nil {
+			return nil, fmt.Errorf("task %s: %w", b.config.TaskName(i), err)
+		}
+	}
+
+	var changes []FileChange
+	for _, output := range outputs {
+		outputChanges, err := compareOutput(output, generated[output])
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, outputChanges...)
+	}
+
+	return changes, nil
+}
+
+// compareOutput compares generated files with the files under an output directory
+func compareOutput(output string, generated *generators.InMemoryFS) ([]FileChange, error) {
+	disk, ok := generators.NewOSFS(output).(generators.ReadFS)
+	if !ok {
+		return nil, fmt.Errorf("cannot read output directory %s", output)
+	}
+
+	existing, err := disk.Files()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", output, err)
+	}
+	onDisk := make(map[string]bool, len(existing))
+	for _, path := range existing {
+		onDisk[path] = true
+	}
+
+	var changes []FileChange
+	for _, path := range generated.ListFiles() {
+		if !onDisk[path] {
+			changes = append(changes, FileChange{Path: filepath.Join(output, path), Kind: ChangeAdded})
+			continue
+		}
+		want, _ := generated.GetFile(path)
+		got, err := disk.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(output, path), err)
+		}
+		if !bytes.Equal(got, want) {
+			changes = append(changes, FileChange{Path: filepath.Join(output, path), Kind: ChangeChanged})
+		}
+	}
+	for _, path := range existing {
+		if !generated.FileExists(path) {
+			changes = append(changes, FileChange{Path: filepath.Join(output, path), Kind: ChangeRemoved})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// ValidateGenerators checks if all generators specified in the config are
+// available, and that they accept the config of their tasks
 func (b *Builder) ValidateGenerators() error {
 	availableGenerators := generators.List()
 	generatorSet := make(map[string]bool)
-	for _, gen := range availableGenerators {
+	for _, gen := range generators.Names(true) {
 		generatorSet[gen] = true
 	}
 
@@ -118,7 +761,7 @@ func (b *Builder) ValidateGenerators() error {
 	for i, task := range b.config.Generate {
 		if !generatorSet[task.Generator] {
 			missingGenerators = append(missingGenerators,
-				fmt.Sprintf("task %d: %s", i+1, task.Generator))
+				fmt.Sprintf("task %s: %s", b.config.TaskName(i), task.Generator))
 		}
 	}
 
@@ -127,20 +770,57 @@ func (b *Builder) ValidateGenerators() error {
 			missingGenerators, availableGenerators)
 	}
 
+	// Unknown config keys and malformed values fail the build up front
+	var invalid []string
+	for i, task := range b.config.Generate {
+		generator, err := generators.Get(task.Generator)
+		if err != nil {
+			return err
+		}
+		if err := generators.ValidateConfig(generator, b.config.generatorConfig(i)); err != nil {
+			invalid = append(invalid, fmt.Sprintf("task %s: %s", b.config.TaskName(i),
+				strings.ReplaceAll(err.Error(), "\n", "\n  ")))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid generator config:\n  %s", strings.Join(invalid, "\n  "))
+	}
+
 	return nil
 }
 
-// getOrParseModule gets a module from cache or parses it if not cached
-func (b *Builder) getOrParseModule(modulePath string) (*ast.Module, error) {
+// getOrParseModule gets a module from cache or parses the input directories
+// of a task if not cached. The module of merged input directories has each
+// of them as a submodule.
+func (b *Builder) getOrParseModule(modulePath string, dirs []string, task GenerateTask) (*ast.Module, error) {
+	merged := task.merged()
 	// Check cache first
 	if module, exists := b.moduleCache[modulePath]; exists {
+		b.trace("module cache hit", "path", modulePath)
 		return module, nil
 	}
+	b.trace("module cache miss", "path", modulePath)
 
 	// Parse the module
-	module, err := parser.ParseModuleToAST(modulePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse module: %w", err)
+	start := time.Now()
+	var module *ast.Module
+	if merged {
+		module = ast.NewModule(commonDir(dirs), make(map[string]*ast.ProgramNode))
+	}
+	for _, dir := range dirs {
+		parsed, err := parser.ParseModuleWithOptions(dir, b.config.parseOptions(dir, task.Exclude))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse module: %w", err)
+		}
+		if !merged {
+			module = parsed
+		} else if len(parsed.Files) > 0 || len(parsed.SubModules) > 0 {
+			module.SubModules[filepath.Base(dir)] = parsed
+		}
+	}
+	if b.logger.Enabled(context.Background(), slog.LevelDebug) {
+		b.logParsedFiles(module)
+		b.logger.Debug(fmt.Sprintf("parsed module %s in %s", modulePath, roundElapsed(time.Since(start))))
 	}
 
 	// Cache the result
@@ -148,18 +828,102 @@ func (b *Builder) getOrParseModule(modulePath string) (*ast.Module, error) {
 	return module, nil
 }
 
-// getOrValidateModule gets validation result from cache or validates if not cached
-func (b *Builder) getOrValidateModule(module *ast.Module, modulePath string) (*validator.ValidationResult, error) {
+// validateTask validates the module of the task at index with the task's
+// validation settings, recording the outcome
+func (b *Builder) validateTask(taskIndex int, module *ast.Module, modulePath string, phase func(Phase)) error {
+	settings := b.config.MergedValidation(taskIndex)
+	if *settings.Skip {
+		b.validated[taskIndex] = ValidationSkipped
+		b.logger.Info(fmt.Sprintf("[%s] ⚠️  Validation skipped", b.config.TaskName(taskIndex)))
+		return nil
+	}
+
+	phase(PhaseValidating)
+	rules, err := validator.ParseRules(settings.Rules)
+	if err != nil {
+		return err
+	}
+	// Names reserved by the task's target language are checked too
+	generator := b.config.Generate[taskIndex].Generator
+	if name, err := generators.Resolve(generator); err == nil {
+		generator = name
+	}
+	v := validator.NewValidator()
+	v.SetRules(rules)
+	v.SetUnusedTypeScope(validator.UnusedTypeScope(settings.UnusedTypes))
+	v.SetGenerators([]string{generator})
+	result := b.getOrValidateModule(module, modulePath, v, strings.TrimSpace(validationKey(settings)+" generator="+generator))
+	b.diagnostics[taskIndex] = diagnostics.FromValidation(result)
+
+	if result.HasErrors() || (settings.FailOn == FailOnWarning && result.HasWarnings()) {
+		b.validated[taskIndex] = ValidationFailed
+		if settings.FailOn == FailOnWarning {
+			return fmt.Errorf("%w with %d errors and %d warnings:\n%s", ErrValidation, result.ErrorCount(), result.WarningCount(), result.String())
+		}
+		return fmt.Errorf("%w with %d errors:\n%s", ErrValidation, result.ErrorCount(), result.String())
+	}
+	b.validated[taskIndex] = ValidationPassed
+	if result.HasWarnings() {
+		b.logger.Warn(fmt.Sprintf("[%s] ⚠️  %s", b.config.TaskName(taskIndex), result.WarningsString()))
+	}
+	return nil
+}
+
+// getOrValidateModule gets validation result from cache or validates it
+// with v if not cached. Results are cached by module and settingsKey, which
+// identifies the settings of v.
+func (b *Builder) getOrValidateModule(module *ast.Module, modulePath string, v *validator.Validator, settingsKey string) *validator.ValidationResult {
+	key := modulePath
+	if settingsKey != "" {
+		key += " with " + settingsKey
+	}
+
 	// Check cache first
-	if _, exists := b.validationCache[modulePath]; exists {
-		return nil, nil
+	if result, exists := b.validationCache[key]; exists {
+		b.trace("validation cache hit", "path", key)
+		return result
 	}
+	b.trace("validation cache miss", "path", key)
 
 	// Validate the module
-	v := validator.NewValidator()
+	start := time.Now()
 	result := v.Validate(module)
+	b.logger.Debug(fmt.Sprintf("validated module %s in %s", modulePath, roundElapsed(time.Since(start))))
 
 	// Cache the result
-	b.validationCache[modulePath] = result
-	return result, nil
+	b.validationCache[key] = result
+	return result
+}
+
+// commonDir returns the deepest directory containing every one of dirs
+func commonDir(dirs []string) string {
+	common := filepath.Dir(dirs[0])
+	for _, dir := range dirs[1:] {
+		for !within(common, dir) {
+			common = filepath.Dir(common)
+		}
+	}
+	return common
+}
+
+// logParsedFiles logs a line per parsed file of a module and its submodules
+func (b *Builder) logParsedFiles(module *ast.Module) {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		b.logger.Debug(fmt.Sprintf("parsed %s (%d declarations)",
+			filepath.Join(module.Path, filename), len(module.Files[filename].Declarations)))
+	}
+
+	names := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.logParsedFiles(module.SubModules[name])
+	}
 }
@@ -1,12 +1,21 @@
 package build
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+	"github.com/WhatsApp-Platform/typegen/parser"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
 )
 
@@ -35,13 +44,18 @@ func (g *MockGenerator) Generate(ctx context.Context, module *ast.Module, dest g
 	return nil
 }
 
+// registerGenerator registers a generator in the global registry until the
+// end of the test
+func registerGenerator(t *testing.T, name string, constructor func() generators.Generator) {
+	t.Helper()
+	generators.Register(name, constructor)
+	t.Cleanup(func() { generators.Unregister(name) })
+}
+
 func TestBuilder(t *testing.T) {
-	// Register mock generator
+	// Register mock generator in a registry of the test's own
+	defer generators.UseRegistry(generators.NewRegistry())()
 	generators.Register("mock", NewMockGenerator)
-	defer func() {
-		// Note: There's no unregister function, so this test affects global state
-		// In a real scenario, you might want to use a separate registry for tests
-	}()
 
 	tests := []struct {
 		name        string
@@ -170,4 +184,1068 @@ func TestValidateGenerators(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestBuildGeneratorAliases(t *testing.T) {
+	defer generators.UseRegistry(generators.NewRegistry())()
+	generators.Register("files", func() generators.Generator { return &FileWritingGenerator{} })
+	generators.RegisterAlias("f", "files")
+	generators.RegisterAlias("legacy-files", "files")
+	generators.Deprecate("legacy-files", "")
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	output := t.TempDir()
+	config := &Config{
+		Version: 1,
+		Config:  map[string]string{},
+		Generate: []GenerateTask{
+			{Generator: "f", Input: input, Output: filepath.Join(output, "f"), Config: map[string]string{}},
+			{Generator: "legacy-files", Input: input, Output: filepath.Join(output, "legacy1"), Config: map[string]string{}},
+			{Generator: "legacy-files", Input: input, Output: filepath.Join(output, "legacy2"), Config: map[string]string{}},
+		},
+	}
+	var logs bytes.Buffer
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&logs, slog.LevelInfo))
+
+	if err := builder.ValidateGenerators(); err != nil {
+		t.Fatalf("expected aliases to be valid generators, got %v", err)
+	}
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, dir := range []string{"f", "legacy1", "legacy2"} {
+		if _, err := os.Stat(filepath.Join(output, dir, "types", "User.txt")); err != nil {
+			t.Errorf("expected %s to be generated: %v", dir, err)
+		}
+	}
+
+	// The deprecated name warns once, not once per task
+	warning := `generator name "legacy-files" is deprecated; use "files" instead`
+	if count := strings.Count(logs.String(), warning); count != 1 {
+		t.Errorf("expected the deprecation warning once, got %d times in:\n%s", count, logs.String())
+	}
+}
+
+// DescribedGenerator documents its options, so its config is validated
+type DescribedGenerator struct {
+	FileWritingGenerator
+}
+
+func (g *DescribedGenerator) Name() string        { return "described" }
+func (g *DescribedGenerator) Description() string { return "Described output" }
+
+func (g *DescribedGenerator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "package", Type: "string", Description: "Package name"},
+		{Key: "testdata", Type: "bool", Default: "false", Description: "Also write test data"},
+	}
+}
+
+func TestValidateGeneratorsConfig(t *testing.T) {
+	registerGenerator(t, "described", func() generators.Generator { return &DescribedGenerator{} })
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "schemas", "user.tg"), "struct User {\n  id: int64\n}\n")
+
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"valid", "config:\n  package: models\ngenerate:\n  - generator: described\n    config:\n      testdata: true\n", ""},
+		// The global config is shared by every generator's tasks
+		{"global key of another generator", "config:\n  module-name: example.com/models\ngenerate:\n  - generator: described\n", ""},
+		{"unknown task key", "generate:\n  - name: api\n    generator: described\n    config:\n      packge: models\n", `task api: unknown option "packge"; options are package, testdata`},
+		{"unknown generators block key", "version: 2\ngenerators:\n  described:\n    packge: models\ngenerate:\n  - generator: described\n", `unknown option "packge"`},
+		{"malformed value", "version: 2\ngenerate:\n  - generator: described\n    config:\n      testdata: 1\n", `invalid testdata "1" (expected true or false)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(root, "typegen.yaml")
+			task := "    input: ./schemas\n    output: ./gen\n"
+			writeFile(t, configPath, strings.Replace(tt.yaml, "generator: described\n", "generator: described\n"+task, 1))
+			config, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+
+			builder := NewBuilder(config)
+			err = builder.ValidateGenerators()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+
+			// Builds without ValidateGenerators fail the task before generating
+			result, err := builder.Build(context.Background())
+			if err == nil || result.Tasks[0].Status != TaskFailed || !strings.Contains(result.Tasks[0].Error, "invalid described config") {
+				t.Errorf("expected the task to fail on its config, got %v", err)
+			}
+			if _, statErr := os.Stat(filepath.Join(root, "gen")); !os.IsNotExist(statErr) {
+				t.Errorf("expected no generated code, got %v", statErr)
+			}
+		})
+	}
+}
+
+// FileWritingGenerator writes one file per declaration, for testing Check
+type FileWritingGenerator struct{}
+
+func (g *FileWritingGenerator) SetConfig(config map[string]string) {}
+
+func (g *FileWritingGenerator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	for _, decl := range module.AllDeclarations() {
+		var name string
+		switch d := decl.(type) {
+		case *ast.StructNode:
+			name = d.Name
+		case *ast.EnumNode:
+			name = d.Name
+		default:
+			continue
+		}
+		content := []byte("// " + generators.GeneratedHeader + "\n" + decl.String() + "\n")
+		if err := dest.WriteFile(dest.Join("types", name+".txt"), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	tests := []struct {
+		name     string
+		modify   func(t *testing.T, output string)
+		expected []FileChange
+	}{
+		{
+			name:   "up to date",
+			modify: func(t *testing.T, output string) {},
+		},
+		{
+			name: "modified file",
+			modify: func(t *testing.T, output string) {
+				writeFile(t, filepath.Join(output, "types", "User.txt"), "struct User {}\n")
+			},
+			expected: []FileChange{{Path: filepath.Join("types", "User.txt"), Kind: ChangeChanged}},
+		},
+		{
+			name: "missing file",
+			modify: func(t *testing.T, output string) {
+				if err := os.Remove(filepath.Join(output, "types", "Status.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expected: []FileChange{{Path: filepath.Join("types", "Status.txt"), Kind: ChangeAdded}},
+		},
+		{
+			name: "extra file",
+			modify: func(t *testing.T, output string) {
+				writeFile(t, filepath.Join(output, "types", "Old.txt"), "struct Old {}\n")
+			},
+			expected: []FileChange{{Path: filepath.Join("types", "Old.txt"), Kind: ChangeRemoved}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := t.TempDir()
+			output := filepath.Join(t.TempDir(), "generated")
+			writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n\nenum Status {\n  active\n}\n")
+
+			config := &Config{
+				Version:  1,
+				Generate: []GenerateTask{{Generator: "files", Input: input, Output: output}},
+			}
+			if _, err := NewBuilder(config).Build(context.Background()); err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+			tt.modify(t, output)
+
+			changes, err := NewBuilder(config).Check(context.Background())
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+
+			for i := range tt.expected {
+				tt.expected[i].Path = filepath.Join(output, tt.expected[i].Path)
+			}
+			if len(changes) != len(tt.expected) {
+				t.Fatalf("expected changes %v, got %v", tt.expected, changes)
+			}
+			for i := range changes {
+				if changes[i] != tt.expected[i] {
+					t.Errorf("expected change %v, got %v", tt.expected[i], changes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDoesNotWrite(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "generated")
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: output}},
+	}
+	changes, err := NewBuilder(config).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Kind != ChangeAdded {
+		t.Errorf("expected one added file, got %v", changes)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("Check should not create the output directory, got %v", err)
+	}
+}
+
+func TestBuildLogLevels(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	writeFile(t, filepath.Join(input, "auth", "token.tg"), "struct Token {\n  value: string\n}\n")
+
+	// Both tasks read the same input, so the second one hits the caches
+	config := &Config{
+		Version: 1,
+		Config:  map[string]string{"style": "compact"},
+		Generate: []GenerateTask{
+			{Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "a")},
+			{Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "b")},
+		},
+	}
+
+	summary := "Build completed: 2/2 tasks succeeded"
+	perFile := "parsed " + filepath.Join(input, "auth", "token.tg") + " (1 declarations)"
+	written := "wrote " + filepath.Join("types", "User.txt")
+	timing := "generated files code in "
+	configDump := "task files-1 config generator=files config.style=compact"
+	cacheHit := "module cache hit path=" + input
+
+	tests := []struct {
+		name        string
+		level       slog.Level
+		contains    []string
+		notContains []string
+	}{
+		{"quiet", logging.Level(true, 0), nil, []string{summary, perFile}},
+		{"default", logging.Level(false, 0), []string{"\n\n[1/2 files-1] Generating files code", "[files-1] ✅ Success\n\n[2/2 files-2]", "\n\n" + summary}, []string{perFile, written, timing}},
+		{"verbose", logging.Level(false, 1), []string{summary, perFile, written, timing}, []string{configDump, cacheHit}},
+		{"very verbose", logging.Level(false, 2), []string{summary, perFile, configDump, cacheHit, "validation cache hit"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Files already generated are logged as unchanged, not written
+			for i := range config.Generate {
+				config.Generate[i].Output = filepath.Join(t.TempDir(), "gen")
+			}
+			var out bytes.Buffer
+			builder := NewBuilder(config)
+			builder.SetLogger(logging.New(&out, tt.level))
+			if _, err := builder.Build(context.Background()); err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+
+			if tt.contains == nil && out.Len() != 0 {
+				t.Errorf("expected no output, got:\n%s", out.String())
+			}
+			for _, expected := range tt.contains {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+			for _, unexpected := range tt.notContains {
+				if strings.Contains(out.String(), unexpected) {
+					t.Errorf("expected output not to contain %q, got:\n%s", unexpected, out.String())
+				}
+			}
+		})
+	}
+
+	// Errors are still reported when quiet
+	failing := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: filepath.Join(input, "missing"), Output: t.TempDir()}},
+	}
+	var out bytes.Buffer
+	builder := NewBuilder(failing)
+	builder.SetLogger(logging.New(&out, logging.Level(true, 0)))
+	if _, err := builder.Build(context.Background()); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+	if !strings.Contains(out.String(), "❌ Failed") || strings.Contains(out.String(), "Starting build") {
+		t.Errorf("expected only errors when quiet, got:\n%s", out.String())
+	}
+}
+
+func TestBuildErrorKinds(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "mock-failing", func() generators.Generator { return &MockGenerator{shouldErr: true} })
+
+	valid := t.TempDir()
+	writeFile(t, filepath.Join(valid, "types.tg"), "struct User {\n  id: int64\n}\n")
+	invalid := t.TempDir()
+	writeFile(t, filepath.Join(invalid, "types.tg"), "struct User {\n  role: Missing\n}\n")
+	broken := t.TempDir()
+	writeFile(t, filepath.Join(broken, "types.tg"), "struct {\n")
+
+	tests := []struct {
+		name      string
+		task      GenerateTask
+		generator string
+		target    error
+	}{
+		{"validation", GenerateTask{Input: invalid}, "files", ErrValidation},
+		{"generation", GenerateTask{Input: valid}, "mock-failing", ErrGeneration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.Generator = tt.generator
+			tt.task.Output = t.TempDir()
+			builder := NewBuilder(&Config{Version: 1, Generate: []GenerateTask{tt.task}})
+			builder.SetLogger(logging.Discard())
+
+			_, err := builder.Build(context.Background())
+			var buildErr *BuildError
+			if !errors.As(err, &buildErr) || len(buildErr.Errors) != 1 {
+				t.Fatalf("expected a BuildError with one task error, got %v", err)
+			}
+			if !errors.Is(err, tt.target) {
+				t.Errorf("expected the error to wrap %v, got %v", tt.target, err)
+			}
+		})
+	}
+
+	// Parse errors unwrap to the parser's error
+	builder := NewBuilder(&Config{Version: 1, Generate: []GenerateTask{{Generator: "files", Input: broken, Output: t.TempDir()}}})
+	builder.SetLogger(logging.Discard())
+	var parseErr *parser.ParseError
+	if _, err := builder.Build(context.Background()); !errors.As(err, &parseErr) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
+
+func TestTaskFilter(t *testing.T) {
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Generator: "go", Output: "a"},
+			{Generator: "python+pydantic", Output: "b"},
+			{Name: "legacy-go", Generator: "go", Output: "c"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   TaskFilter
+		expected []int
+	}{
+		{"empty", TaskFilter{}, []int{0, 1, 2}},
+		{"task numbers", TaskFilter{Tasks: []string{"3", "1"}}, []int{0, 2}},
+		{"task names", TaskFilter{Tasks: []string{"legacy-go", "python+pydantic-2"}}, []int{1, 2}},
+		{"names and numbers", TaskFilter{Tasks: []string{"go-1", "3"}}, []int{0, 2}},
+		{"generator", TaskFilter{Generators: []string{"go"}}, []int{0, 2}},
+		{"generators", TaskFilter{Generators: []string{"go", "python+pydantic"}}, []int{0, 1, 2}},
+		{"both", TaskFilter{Tasks: []string{"1", "2"}, Generators: []string{"python+pydantic"}}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := tt.filter.Selected(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(selected) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected tasks %v, got %v", tt.expected, selected)
+			}
+		})
+	}
+
+	filters := []TaskFilter{
+		{Tasks: []string{"4"}},
+		{Tasks: []string{"0"}},
+		{Tasks: []string{"go-3"}},
+		{Tasks: []string{"1", "payments"}},
+		{Generators: []string{"dart"}},
+		{Tasks: []string{"2"}, Generators: []string{"go"}},
+	}
+	for _, filter := range filters {
+		_, err := filter.Selected(config)
+		if err == nil || !strings.Contains(err.Error(), "available tasks: 1 go-1 (go), 2 python+pydantic-2 (python+pydantic), 3 legacy-go (go)") {
+			t.Errorf("%+v: expected an error listing the available tasks, got %v", filter, err)
+		}
+	}
+}
+
+func TestBuildTaskNames(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "mock-failing", func() generators.Generator { return &MockGenerator{shouldErr: true} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Name: "payments", Description: "Models for the payments service", Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "a")},
+			{Generator: "mock-failing", Input: input, Output: filepath.Join(t.TempDir(), "b")},
+		},
+	}
+
+	var out bytes.Buffer
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&out, slog.LevelDebug))
+	if _, err := builder.Build(context.Background()); err == nil {
+		t.Error("expected the build to fail")
+	}
+
+	for _, expected := range []string{
+		"[1/2 payments] Generating files code from " + input,
+		"[payments] Models for the payments service",
+		"[payments] ✅ Success",
+		"[2/2 mock-failing-2] Generating mock-failing code",
+		"[mock-failing-2] ❌ Failed: ",
+		"  - task mock-failing-2: ",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	tasks := builder.Result().Tasks
+	if tasks[0].Name != "payments" || tasks[0].Description != "Models for the payments service" {
+		t.Errorf("expected the task's name and description in its result, got %+v", tasks[0])
+	}
+	if tasks[1].Name != "mock-failing-2" || tasks[1].Description != "" {
+		t.Errorf("expected the automatic name in the result, got %+v", tasks[1])
+	}
+}
+
+func TestBuildValidationSettings(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	legacy := t.TempDir()
+	writeFile(t, filepath.Join(legacy, "types.tg"), "struct User {\n  userID: int64\n}\n")
+	skip := true
+	task := func(name string, validation ValidationConfig) GenerateTask {
+		return GenerateTask{Name: name, Generator: "files", Input: legacy, Output: filepath.Join(t.TempDir(), name), Validation: validation}
+	}
+	naming := map[string]string{"naming_convention": "warning"}
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			task("skipped", ValidationConfig{Skip: &skip}),
+			task("strict", ValidationConfig{}),
+			task("lenient", ValidationConfig{Rules: naming}),
+			task("fail-on-warning", ValidationConfig{FailOn: FailOnWarning, Rules: naming}),
+		},
+	}
+
+	var out bytes.Buffer
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&out, slog.LevelInfo))
+	if _, err := builder.Build(context.Background()); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+
+	// Skipping validation only affects its task, even though the module is
+	// shared and validation results are cached
+	expected := []struct {
+		status     TaskStatus
+		validation ValidationStatus
+	}{
+		{TaskSucceeded, ValidationSkipped},
+		{TaskFailed, ValidationFailed},
+		{TaskSucceeded, ValidationPassed},
+		{TaskFailed, ValidationFailed},
+	}
+	for i, task := range builder.Result().Tasks {
+		if task.Status != expected[i].status || task.Validation != expected[i].validation {
+			t.Errorf("%s: expected status %s and validation %s, got %s and %s",
+				task.Name, expected[i].status, expected[i].validation, task.Status, task.Validation)
+		}
+	}
+	if failed := builder.Result().Tasks[3]; !strings.Contains(failed.Error, "validation failed with 0 errors and 1 warnings") {
+		t.Errorf("expected the warning to fail the task, got %q", failed.Error)
+	}
+
+	for _, expected := range []string{
+		"[skipped] ⚠️  Validation skipped",
+		"[lenient] ⚠️  Validation warnings found (1):",
+		"field name 'userID' should follow snake_case convention",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestBuildTasksSkipsUnselected(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	outputs := []string{filepath.Join(t.TempDir(), "a"), filepath.Join(t.TempDir(), "b")}
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Generator: "files", Input: input, Output: outputs[0]},
+			{Generator: "files", Input: input, Output: outputs[1]},
+		},
+	}
+
+	var out bytes.Buffer
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&out, slog.LevelInfo))
+	if _, err := builder.BuildTasks(context.Background(), TaskFilter{Tasks: []string{"2"}}); err != nil {
+		t.Fatalf("BuildTasks failed: %v", err)
+	}
+
+	if _, err := os.Stat(outputs[0]); !os.IsNotExist(err) {
+		t.Errorf("expected the skipped task not to write %s", outputs[0])
+	}
+	if _, err := os.Stat(filepath.Join(outputs[1], "types", "User.txt")); err != nil {
+		t.Errorf("expected the selected task to run: %v", err)
+	}
+	for _, expected := range []string{"[1/2 files-1] ⏭️  Skipped files code to " + outputs[0], "[2/2 files-2] Generating files code", "Build completed: 1/1 tasks succeeded, 1 skipped"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestBuildClean(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	tests := []struct {
+		name    string
+		dryRun  bool
+		removed bool
+		log     string
+	}{
+		{name: "removes stale files", removed: true, log: "🧹 Removed stale "},
+		{name: "dry run", dryRun: true, log: "Would remove stale "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := t.TempDir()
+			output := filepath.Join(t.TempDir(), "generated")
+			writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+			stale := filepath.Join(output, "types", "old", "Old.txt")
+			handWritten := filepath.Join(output, "types", "notes.txt")
+			writeFile(t, stale, "// "+generators.GeneratedHeader+"\nstruct Old {}\n")
+			writeFile(t, handWritten, "struct Notes {}\n")
+
+			config := &Config{
+				Version:  1,
+				Generate: []GenerateTask{{Generator: "files", Input: input, Output: output, Clean: true}},
+			}
+			var out bytes.Buffer
+			builder := NewBuilder(config)
+			builder.SetLogger(logging.New(&out, slog.LevelInfo))
+			builder.SetClean(false, tt.dryRun)
+			if _, err := builder.Build(context.Background()); err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+
+			if _, err := os.Stat(stale); os.IsNotExist(err) != tt.removed {
+				t.Errorf("expected stale file removed to be %v, got %v", tt.removed, err)
+			}
+			if _, err := os.Stat(filepath.Dir(stale)); os.IsNotExist(err) != tt.removed {
+				t.Errorf("expected the emptied directory removed to be %v, got %v", tt.removed, err)
+			}
+			if _, err := os.Stat(handWritten); err != nil {
+				t.Errorf("expected the file without the generated header to be kept: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(output, "types", "User.txt")); err != nil {
+				t.Errorf("expected the generated file to be kept: %v", err)
+			}
+			if expected := tt.log + stale; !strings.Contains(out.String(), expected) {
+				t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+			}
+		})
+	}
+}
+
+func TestBuildCleanSharedAndNestedOutputs(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	users := t.TempDir()
+	writeFile(t, filepath.Join(users, "users.tg"), "struct User {\n  id: int64\n}\n")
+	orders := t.TempDir()
+	writeFile(t, filepath.Join(orders, "orders.tg"), "struct Order {\n  id: int64\n}\n")
+	output := t.TempDir()
+	nested := filepath.Join(output, "nested")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Generator: "files", Input: users, Output: output},
+			{Generator: "files", Input: orders, Output: output},
+			{Generator: "files", Input: users, Output: nested},
+		},
+	}
+
+	// The tasks sharing an output keep each other's files, and the nested
+	// output belongs to its own task
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	builder.SetClean(true, false)
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	for _, path := range []string{"types/User.txt", "types/Order.txt", "nested/types/User.txt"} {
+		if _, err := os.Stat(filepath.Join(output, path)); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+
+	// Without all the tasks of an output, its files can't be told apart from stale ones
+	stale := filepath.Join(output, "types", "Old.txt")
+	writeFile(t, stale, "// "+generators.GeneratedHeader+"\nstruct Old {}\n")
+	builder = NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	builder.SetClean(true, false)
+	if _, err := builder.BuildTasks(context.Background(), TaskFilter{Tasks: []string{"1"}}); err != nil {
+		t.Fatalf("BuildTasks failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "types", "Order.txt")); err != nil {
+		t.Errorf("expected the other task's file to be kept: %v", err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Errorf("expected no cleaning without every task of the output: %v", err)
+	}
+}
+
+func TestBuildSkipsOutputInsideInput(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	output := filepath.Join(input, "gen")
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	// Not a valid schema, so the build fails if the output is parsed
+	writeFile(t, filepath.Join(output, "copy.tg"), "struct Broken {\n")
+
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: output}},
+	}
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "types", "User.txt")); err != nil {
+		t.Errorf("expected the task to generate into its output: %v", err)
+	}
+}
+
+func TestBuildResult(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "mock-failing", func() generators.Generator { return &MockGenerator{shouldErr: true} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n\nenum Status {\n  active\n}\n")
+	outputs := []string{filepath.Join(t.TempDir(), "files"), filepath.Join(t.TempDir(), "failing"), filepath.Join(t.TempDir(), "skipped")}
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Generator: "files", Input: input, Output: outputs[0]},
+			{Generator: "mock-failing", Input: input, Output: outputs[1]},
+			{Generator: "files", Input: input, Output: outputs[2]},
+		},
+	}
+
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	if builder.Result() != nil {
+		t.Error("expected no result before building")
+	}
+	if _, err := builder.BuildTasks(context.Background(), TaskFilter{Tasks: []string{"1", "2"}}); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+
+	result := builder.Result()
+	if result == nil {
+		t.Fatal("expected a result")
+	}
+	if len(result.Tasks) != 3 {
+		t.Fatalf("expected 3 task results, got %d", len(result.Tasks))
+	}
+
+	passed := result.Tasks[0]
+	if passed.Task != 1 || passed.Generator != "files" || passed.Input != input || passed.Output != outputs[0] || passed.Status != TaskSucceeded || passed.Error != "" {
+		t.Errorf("unexpected result for the passing task: %+v", passed)
+	}
+	user := "// " + generators.GeneratedHeader + "\n" + "struct User {\n  id: int64\n}\n"
+	if len(passed.Files) != 2 || passed.Files[1] != (generators.WrittenFile{Path: "types/User.txt", Bytes: len(user)}) {
+		t.Errorf("unexpected files for the passing task: %+v", passed.Files)
+	}
+	if !reflect.DeepEqual(passed.Dirs, []string{"types"}) {
+		t.Errorf("unexpected directories for the passing task: %v", passed.Dirs)
+	}
+	if passed.DurationMS <= 0 {
+		t.Errorf("expected a duration for the passing task, got %v", passed.DurationMS)
+	}
+
+	failed := result.Tasks[1]
+	if failed.Status != TaskFailed || !strings.Contains(failed.Error, "mock generation error") || len(failed.Files) != 0 {
+		t.Errorf("unexpected result for the failing task: %+v", failed)
+	}
+	if skipped := result.Tasks[2]; skipped.Status != TaskSkipped || skipped.Files == nil || skipped.Dirs == nil {
+		t.Errorf("unexpected result for the skipped task: %+v", skipped)
+	}
+
+	expected := BuildSummary{Tasks: 3, Succeeded: 1, Failed: 1, Skipped: 1, Files: 2, Bytes: passed.Files[0].Bytes + passed.Files[1].Bytes}
+	if result.Summary != expected {
+		t.Errorf("expected summary %+v, got %+v", expected, result.Summary)
+	}
+	if result.Version == "" || result.StartedAt.IsZero() {
+		t.Errorf("expected the version and start time, got %q and %v", result.Version, result.StartedAt)
+	}
+}
+
+func TestBuildFlattensEmbeds(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  ...Audit\n  id: int64\n}\n\nstruct Audit {\n  created: datetime\n}\n")
+	output := t.TempDir()
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: output}},
+	}
+
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(output, "types", "User.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "struct User {\n  created: datetime\n  id: int64\n}\n"; !strings.HasSuffix(string(data), expected) {
+		t.Errorf("expected the generator to see the embedded fields, got:\n%s", data)
+	}
+}
+
+func TestBuildUnchangedFiles(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n\nstruct Order {\n  id: int64\n}\n")
+	output := t.TempDir()
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: output}},
+	}
+	if _, err := NewBuilder(config).Build(context.Background()); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	// Only the file whose schema changed is written again
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n  name: string\n}\n\nstruct Order {\n  id: int64\n}\n")
+	result, err := NewBuilder(config).Build(context.Background())
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	unchanged := make(map[string]bool)
+	for _, file := range result.Tasks[0].Files {
+		unchanged[file.Path] = file.Unchanged
+	}
+	expected := map[string]bool{"types/Order.txt": true, "types/User.txt": false}
+	if !reflect.DeepEqual(unchanged, expected) {
+		t.Errorf("expected unchanged files %v, got %v", expected, unchanged)
+	}
+	if result.Summary.Files != 2 || result.Summary.Unchanged != 1 {
+		t.Errorf("expected 2 files with 1 unchanged, got %+v", result.Summary)
+	}
+}
+
+func TestBuildReturnsResult(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  userID: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Name: "lenient", Generator: "files", Input: input, Output: t.TempDir(),
+				Validation: ValidationConfig{Rules: map[string]string{"naming_convention": "warning"}}},
+		},
+	}
+
+	// Without a logger, the builder writes nothing to stdout or stderr
+	output, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = output, output
+	result, err := NewBuilder(config).Build(context.Background())
+	os.Stdout, os.Stderr = stdout, stderr
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if printed, _ := os.ReadFile(output.Name()); len(printed) != 0 {
+		t.Errorf("expected the build to print nothing, got:\n%s", printed)
+	}
+
+	if result == nil || len(result.Tasks) != 1 || result.Tasks[0].Status != TaskSucceeded {
+		t.Fatalf("expected a succeeded task, got %+v", result)
+	}
+	expected := []Diagnostic{{
+		Severity:   "warning",
+		Rule:       "naming_convention",
+		File:       "types.tg",
+		Line:       2,
+		Column:     3,
+		Message:    "field name 'userID' should follow snake_case convention",
+		Suggestion: "use 'user_id'",
+	}}
+	if diagnostics := result.Tasks[0].Diagnostics; !reflect.DeepEqual(diagnostics, expected) {
+		t.Errorf("expected diagnostics %+v, got %+v", expected, diagnostics)
+	}
+}
+
+func TestBuildParseDiagnostics(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "user.tg"), "struct User {\n  id int64\n}\n")
+	writeFile(t, filepath.Join(input, "auth", "token.tg"), "struct Token {\n  value: string\n  $\n}\n")
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Name: "api", Generator: "files", Input: input, Output: t.TempDir()}},
+	}
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+
+	result, err := builder.Build(context.Background())
+	if err == nil || result == nil || len(result.Tasks) != 1 || result.Tasks[0].Status != TaskFailed {
+		t.Fatalf("expected a failed task, got %v", err)
+	}
+	expected := []Diagnostic{
+		{Severity: "error", Rule: "syntax", File: filepath.Join(input, "auth", "token.tg"), Line: 3, Column: 3, Message: "unexpected character: $"},
+		{Severity: "error", Rule: "syntax", File: filepath.Join(input, "user.tg"), Line: 2, Column: 6, Message: "unexpected 'int64', expected ':'", Got: "int64", Expected: []string{"':'"}},
+	}
+	if diagnostics := result.Tasks[0].Diagnostics; !reflect.DeepEqual(diagnostics, expected) {
+		t.Errorf("expected diagnostics %+v, got %+v", expected, diagnostics)
+	}
+}
+
+func TestBuildExclude(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "gen")
+	writeFile(t, filepath.Join(input, "user.tg"), "struct User {\n  id: int64\n}\n")
+	writeFile(t, filepath.Join(input, "user_draft.tg"), "struct Draft {\n  id: int64\n}\n")
+	writeFile(t, filepath.Join(input, "billing", "experimental", "credit.tg"), "struct Credit {\n  id: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{{
+			Generator: "files", Input: input, Output: output,
+			Exclude: []string{"experimental/", "*_draft.tg", "experimantal/"},
+		}},
+	}
+
+	expected := []string{`task files-1: exclude pattern "experimantal/" matches nothing in the input`}
+	if warnings := config.Warnings(); !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected warnings %q, got %q", expected, warnings)
+	}
+
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if files := builder.Result().Tasks[0].Files; len(files) != 1 || files[0].Path != "types/User.txt" {
+		t.Errorf("expected only the User type to be generated, got %+v", files)
+	}
+
+	// The cache hashes the same files as the parser parses
+	files, err := config.inputFiles(input, config.Generate[0].Exclude)
+	if err != nil || !reflect.DeepEqual(files, []string{filepath.Join(input, "user.tg")}) {
+		t.Errorf("expected only user.tg as input, got %v (%v)", files, err)
+	}
+}
+
+// recordingProgress records progress events as strings
+type recordingProgress struct {
+	events []string
+}
+
+func (p *recordingProgress) TaskPhase(task int, phase Phase) {
+	p.events = append(p.events, fmt.Sprintf("%d %s", task, phase))
+}
+
+func (p *recordingProgress) FileWritten(task int, path string, count int) {
+	p.events = append(p.events, fmt.Sprintf("%d wrote %s (%d)", task, path, count))
+}
+
+func (p *recordingProgress) TaskDone(result TaskResult) {
+	p.events = append(p.events, fmt.Sprintf("%d %s", result.Task, result.Status))
+}
+
+func TestBuildProgress(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "mock-failing", func() generators.Generator { return &MockGenerator{shouldErr: true} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "files")},
+			{Generator: "mock-failing", Input: input, Output: filepath.Join(t.TempDir(), "failing")},
+			{Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "skipped")},
+		},
+	}
+
+	progress := &recordingProgress{}
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	builder.SetProgress(progress)
+	if _, err := builder.BuildTasks(context.Background(), TaskFilter{Tasks: []string{"1", "2"}}); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+
+	expected := []string{
+		"1 parsing", "1 validating", "1 generating", "1 wrote types/User.txt (1)", "1 succeeded",
+		"2 parsing", "2 validating", "2 generating", "2 failed",
+	}
+	if !reflect.DeepEqual(progress.events, expected) {
+		t.Errorf("expected events %v, got %v", expected, progress.events)
+	}
+}
+
+// BlockingGenerator blocks until its context is done, closing started
+// when it starts
+type BlockingGenerator struct {
+	started chan struct{}
+}
+
+func (g *BlockingGenerator) SetConfig(config map[string]string) {}
+
+func (g *BlockingGenerator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	close(g.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestBuildCancellation(t *testing.T) {
+	blocking := &BlockingGenerator{started: make(chan struct{})}
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "blocking", func() generators.Generator { return blocking })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Name: "first", Generator: "files", Input: input, Output: t.TempDir()},
+			{Name: "blocked", Generator: "blocking", Input: input, Output: t.TempDir()},
+			{Name: "last", Generator: "files", Input: input, Output: t.TempDir()},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-blocking.started
+		cancel()
+	}()
+
+	var out bytes.Buffer
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&out, slog.LevelInfo))
+	result, err := builder.Build(ctx)
+	if !errors.Is(err, ErrInterrupted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the build to be interrupted, got %v", err)
+	}
+
+	expected := []TaskStatus{TaskSucceeded, TaskInterrupted, TaskNotRun}
+	for i, task := range result.Tasks {
+		if task.Status != expected[i] {
+			t.Errorf("%s: expected status %s, got %s", task.Name, expected[i], task.Status)
+		}
+	}
+	if message := result.Tasks[1].Error; message != "build interrupted: context canceled" {
+		t.Errorf("expected the interruption as the task's error, got %q", message)
+	}
+	if result.Summary.Succeeded != 1 || result.Summary.Interrupted != 1 || result.Summary.NotRun != 1 {
+		t.Errorf("unexpected summary %+v", result.Summary)
+	}
+	for _, expected := range []string{
+		"[blocked] ⛔ Interrupted: build interrupted: context canceled",
+		"[3/3 last] ⏭️  Not run: build interrupted",
+		"Build interrupted: 1/3 tasks succeeded, 1 interrupted, 1 not run",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestBuildTaskTimeout(t *testing.T) {
+	blocking := &BlockingGenerator{started: make(chan struct{})}
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "blocking", func() generators.Generator { return blocking })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Name: "slow", Generator: "blocking", Input: input, Output: t.TempDir(), Timeout: 50 * time.Millisecond},
+			{Name: "dependent", Generator: "files", Input: input, Output: t.TempDir(), DependsOn: []string{"slow"}},
+			{Name: "other", Generator: "files", Input: input, Output: t.TempDir()},
+		},
+	}
+
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	result, err := builder.Build(context.Background())
+	if !errors.Is(err, ErrTimeout) || errors.Is(err, ErrInterrupted) {
+		t.Fatalf("expected the task to time out, got %v", err)
+	}
+
+	// A task that times out fails, and the build goes on
+	expected := []TaskStatus{TaskFailed, TaskDependencyFailed, TaskSucceeded}
+	for i, task := range result.Tasks {
+		if task.Status != expected[i] {
+			t.Errorf("%s: expected status %s, got %s", task.Name, expected[i], task.Status)
+		}
+	}
+	if message := result.Tasks[0].Error; message != "task timed out after 50ms" {
+		t.Errorf("expected the timeout as the task's error, got %q", message)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,305 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/version"
+)
+
+// DefaultCacheDir is where the build cache is kept when the configuration
+// doesn't set cache_dir
+const DefaultCacheDir = ".typegen-cache"
+
+const (
+	// cacheFile is the name of the cache file in the cache directory
+	cacheFile = "build.json"
+	// cacheVersion is the version of the cache file format. A cache file of
+	// another version is ignored, and replaced by the next build.
+	cacheVersion = 1
+)
+
+// buildCache is the content of the cache file: for each task, the hash of
+// everything its output depends on and the files it wrote.
+//
+//	{
+//	  "version": 1,
+//	  "tasks": {
+//	    "go /project/schemas -> /project/gen/go": {
+//	      "hash": "3f2a...",
+//	      "files": [{"path": "user.go", "bytes": 812, "sha256": "9c1e..."}]
+//	    }
+//	  }
+//	}
+type buildCache struct {
+	Version int                   `json:"version"`
+	Tasks   map[string]cacheEntry `json:"tasks"`
+}
+
+// cacheEntry records the last successful run of a task
+type cacheEntry struct {
+	Hash  string       `json:"hash"`
+	Files []cachedFile `json:"files"`
+}
+
+// cachedFile is a file written by a task, with the hash of its content to
+// tell whether it was changed or removed since
+type cachedFile struct {
+	Path   string `json:"path"`
+	Bytes  int    `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// loadCache reads the cache file of dir. A missing, unreadable or outdated
+// cache file gives an empty cache, so every task runs.
+func loadCache(dir string) (*buildCache, error) {
+	cache := &buildCache{Version: cacheVersion, Tasks: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(filepath.Join(dir, cacheFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, fmt.Errorf("failed to read build cache: %w", err)
+	}
+
+	var stored buildCache
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return cache, fmt.Errorf("failed to parse build cache %s: %w", filepath.Join(dir, cacheFile), err)
+	}
+	if stored.Version != cacheVersion || stored.Tasks == nil {
+		return cache, nil
+	}
+	return &stored, nil
+}
+
+// save writes the cache file of dir, replacing the previous one at once so
+// an interrupted build can't leave a partial file
+func (c *buildCache) save(dir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, cacheFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write build cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write build cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write build cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, cacheFile)); err != nil {
+		return fmt.Errorf("failed to write build cache: %w", err)
+	}
+	return nil
+}
+
+// cacheKey identifies a task in the cache; task numbers change as tasks are
+// added and removed from the configuration
+func cacheKey(task GenerateTask) string {
+	return fmt.Sprintf("%s %s -> %s", task.Generator, task.describeInput(), task.Output)
+}
+
+// lookupCache reports whether the cache has the task at taskIndex up to
+// date, in which case the files it wrote are recorded as if it ran. It also
+// returns the task's hash for updateCache, or "" when the cache is disabled
+// or the inputs can't be hashed.
+func (b *Builder) lookupCache(taskIndex int) (string, bool) {
+	if !b.config.Cache {
+		return "", false
+	}
+	if b.cache == nil {
+		cache, err := loadCache(b.cacheDir())
+		if err != nil {
+			b.logger.Warn(fmt.Sprintf("⚠️  %v; rebuilding every task", err))
+		}
+		b.cache = cache
+	}
+
+	task := b.config.Generate[taskIndex]
+	hash, err := b.taskHash(taskIndex)
+	if err != nil {
+		b.logger.Debug(fmt.Sprintf("not caching task %s: failed to hash its input: %v", b.config.TaskName(taskIndex), err))
+		return "", false
+	}
+	if b.force {
+		b.trace("build cache bypassed", "task", taskIndex+1)
+		return hash, false
+	}
+
+	entry, exists := b.cache.Tasks[cacheKey(task)]
+	reason := "not cached"
+	if exists && entry.Hash != hash {
+		reason = "input, config or typegen version changed"
+	} else if exists {
+		reason = cachedOutput(task.Output, entry.Files)
+	}
+	if reason != "" {
+		b.trace("build cache miss", "task", taskIndex+1, "reason", reason)
+		return hash, false
+	}
+
+	b.trace("build cache hit", "task", taskIndex+1)
+	written := make([]generators.WrittenFile, len(entry.Files))
+	for i, file := range entry.Files {
+		written[i] = generators.WrittenFile{Path: file.Path, Bytes: file.Bytes}
+	}
+	b.written[taskIndex] = generators.NewManifest(written)
+	return hash, true
+}
+
+// updateCache records the outcome of running the task at taskIndex, whose
+// hash lookupCache returned. A failed task is removed from the cache.
+func (b *Builder) updateCache(taskIndex int, hash string, taskErr error) {
+	if hash == "" {
+		return
+	}
+	task := b.config.Generate[taskIndex]
+	key := cacheKey(task)
+	b.cacheChanged = true
+
+	if taskErr != nil {
+		delete(b.cache.Tasks, key)
+		return
+	}
+	files, err := cacheFiles(task.Output, b.written[taskIndex].Files)
+	if err != nil {
+		b.logger.Debug(fmt.Sprintf("not caching task %s: %v", b.config.TaskName(taskIndex), err))
+		delete(b.cache.Tasks, key)
+		return
+	}
+	b.cache.Tasks[key] = cacheEntry{Hash: hash, Files: files}
+}
+
+// saveCache writes the cache if tasks ran since it was loaded. Failing to
+// write it doesn't fail the build; the next build just runs every task.
+func (b *Builder) saveCache() {
+	if b.cache == nil || !b.cacheChanged {
+		return
+	}
+	if err := b.cache.save(b.cacheDir()); err != nil {
+		b.logger.Warn(fmt.Sprintf("⚠️  %v", err))
+		return
+	}
+	b.cacheChanged = false
+}
+
+// cacheDir returns the directory of the cache file
+func (b *Builder) cacheDir() string {
+	if b.config.CacheDir == "" {
+		return DefaultCacheDir
+	}
+	return b.config.CacheDir
+}
+
+// taskHash hashes everything the output of a task depends on: the typegen
+// version, the generator, the merged config, the hooks that run, the
+// post-processors, the input
+// directories and the path and content of every .tg file in them
+func (b *Builder) taskHash(taskIndex int) (string, error) {
+	task := b.config.Generate[taskIndex]
+	h := sha256.New()
+	fmt.Fprintf(h, "typegen %s %s\n", version.Version, version.Commit)
+	fmt.Fprintf(h, "generator %s\n", task.Generator)
+
+	config := b.config.MergedConfig(taskIndex)
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "config %q %q\n", key, config[key])
+	}
+	validation := b.config.MergedValidation(taskIndex)
+	fmt.Fprintf(h, "validation %v %s %q\n", *validation.Skip, validation.FailOn, validationKey(validation))
+	// Post-hooks such as formatters change the output, and whether they ran
+	if !b.skipHooks {
+		fmt.Fprintf(h, "hooks %q %q\n", task.Hooks.Pre, task.Hooks.Post)
+	}
+	for _, process := range task.PostProcess {
+		fmt.Fprintf(h, "post_process %s\n", process)
+	}
+
+	dirs, err := b.config.inputDirs(taskIndex)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		files, err := b.config.inputFiles(dir, task.Exclude)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "input %q\n", dir)
+		for _, path := range files {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "file %q %d\n", filepath.ToSlash(rel), len(content))
+			h.Write(content)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedOutput reports why the files of a cache entry are not in the output
+// directory as they were written, or "" if they all are
+func cachedOutput(output string, files []cachedFile) string {
+	disk, ok := generators.NewOSFS(output).(generators.ReadFS)
+	if !ok {
+		return "cannot read output directory"
+	}
+	for _, file := range files {
+		content, err := disk.ReadFile(file.Path)
+		if err != nil {
+			return fmt.Sprintf("%s is missing", file.Path)
+		}
+		if len(content) != file.Bytes || hashContent(content) != file.SHA256 {
+			return fmt.Sprintf("%s was changed", file.Path)
+		}
+	}
+	return ""
+}
+
+// cacheFiles reads back the files written by a task to record them
+func cacheFiles(output string, written []generators.WrittenFile) ([]cachedFile, error) {
+	disk, ok := generators.NewOSFS(output).(generators.ReadFS)
+	if !ok {
+		return nil, fmt.Errorf("cannot read output directory %s", output)
+	}
+	files := make([]cachedFile, len(written))
+	for i, file := range written {
+		content, err := disk.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = cachedFile{Path: file.Path, Bytes: len(content), SHA256: hashContent(content)}
+	}
+	return files, nil
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,138 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+)
+
+func TestBuildCache(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	output := filepath.Join(t.TempDir(), "gen")
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	config := &Config{
+		Version:  1,
+		Config:   map[string]string{},
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: output, Config: map[string]string{}}},
+		Cache:    true,
+		CacheDir: filepath.Join(t.TempDir(), "cache"),
+	}
+
+	// Each build uses a new builder, like each run of typegen build
+	build := func(force bool) TaskStatus {
+		t.Helper()
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+		builder.SetForce(force)
+		if _, err := builder.Build(context.Background()); err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+		task := builder.Result().Tasks[0]
+		if len(task.Files) != 1 || task.Files[0].Path != "types/User.txt" {
+			t.Errorf("expected the task's files, got %+v", task.Files)
+		}
+		return task.Status
+	}
+
+	steps := []struct {
+		name     string
+		change   func()
+		force    bool
+		expected TaskStatus
+	}{
+		{"first build", func() {}, false, TaskSucceeded},
+		{"unchanged", func() {}, false, TaskCached},
+		{"schema edit", func() {
+			writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n  name: string\n}\n")
+		}, false, TaskSucceeded},
+		{"unchanged after schema edit", func() {}, false, TaskCached},
+		{"config edit", func() { config.Config["package"] = "models" }, false, TaskSucceeded},
+		{"missing output", func() {
+			if err := os.Remove(filepath.Join(output, "types", "User.txt")); err != nil {
+				t.Fatal(err)
+			}
+		}, false, TaskSucceeded},
+		{"changed output", func() {
+			writeFile(t, filepath.Join(output, "types", "User.txt"), "edited\n")
+		}, false, TaskSucceeded},
+		{"forced", func() {}, true, TaskSucceeded},
+		{"unchanged after forced build", func() {}, false, TaskCached},
+	}
+
+	for _, step := range steps {
+		step.change()
+		if status := build(step.force); status != step.expected {
+			t.Fatalf("%s: expected status %s, got %s", step.name, step.expected, status)
+		}
+	}
+
+	// The file removed by the "missing output" step was generated again
+	content, err := os.ReadFile(filepath.Join(output, "types", "User.txt"))
+	if err != nil || !bytes.Contains(content, []byte("name: string")) {
+		t.Errorf("expected the output to be regenerated, got %q (%v)", content, err)
+	}
+}
+
+func TestBuildCacheFailedTask(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  role: Missing\n}\n")
+	config := &Config{
+		Version:  1,
+		Generate: []GenerateTask{{Generator: "files", Input: input, Output: filepath.Join(t.TempDir(), "gen")}},
+		Cache:    true,
+		CacheDir: filepath.Join(t.TempDir(), "cache"),
+	}
+
+	// A failed task is never cached, so it fails again
+	for range 2 {
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+		if _, err := builder.Build(context.Background()); err == nil {
+			t.Fatal("expected the build to fail")
+		}
+		if status := builder.Result().Tasks[0].Status; status != TaskFailed {
+			t.Errorf("expected the task to fail, got %s", status)
+		}
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing cache is empty
+	cache, err := loadCache(dir)
+	if err != nil || len(cache.Tasks) != 0 {
+		t.Fatalf("expected an empty cache, got %+v (%v)", cache, err)
+	}
+
+	cache.Tasks["go /in -> /out"] = cacheEntry{Hash: "abc", Files: []cachedFile{{Path: "user.go", Bytes: 3, SHA256: "def"}}}
+	if err := cache.save(dir); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded, err := loadCache(dir)
+	if err != nil || loaded.Tasks["go /in -> /out"].Hash != "abc" {
+		t.Errorf("expected the saved cache, got %+v (%v)", loaded, err)
+	}
+
+	// A cache of another format version is ignored
+	writeFile(t, filepath.Join(dir, cacheFile), `{"version": 99, "tasks": {"go /in -> /out": {"hash": "abc"}}}`)
+	if cache, err := loadCache(dir); err != nil || len(cache.Tasks) != 0 {
+		t.Errorf("expected an empty cache, got %+v (%v)", cache, err)
+	}
+
+	// A corrupt cache is reported, and empty
+	writeFile(t, filepath.Join(dir, cacheFile), "{")
+	if cache, err := loadCache(dir); err == nil || len(cache.Tasks) != 0 {
+		t.Errorf("expected an error and an empty cache, got %+v (%v)", cache, err)
+	}
+}
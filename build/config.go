@@ -2,25 +2,210 @@ package build
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the structure of typegen.yaml
 type Config struct {
 	Version  int                    `yaml:"version"`
-	Config   map[string]string      `yaml:"config"`
+	// Config are the global config values, as strings; see TypedConfig
+	Config   map[string]string      `yaml:"-"`
+	// TypedConfig are the global config values of a version 2 file, which
+	// can be any YAML value; Config has them as strings. It is nil for
+	// version 1 files.
+	TypedConfig map[string]any `yaml:"-"`
+	// Generators are config blocks by generator name, for the tasks of that
+	// generator; only in version 2 files. See MergedTypedConfig.
+	Generators map[string]map[string]any `yaml:"-"`
 	Generate []GenerateTask         `yaml:"generate"`
+	// Cache skips the tasks whose inputs haven't changed since they last succeeded
+	Cache    bool                   `yaml:"cache"`
+	// CacheDir is where the cache is kept, by default DefaultCacheDir
+	CacheDir string                 `yaml:"cache_dir"`
+	// RemoteCacheDir is where remote inputs are fetched, by default the
+	// remotes directory of CacheDir
+	RemoteCacheDir string `yaml:"remote_cache_dir"`
+	// Validation configures the validation of every task's input
+	Validation ValidationConfig `yaml:"validation"`
+	
+	// extended are the absolute paths of the files the configuration extends
+	extended []string
 }
 
 // GenerateTask represents a single generation task
 type GenerateTask struct {
+	// Name identifies the task in build output, in reports and for the -t
+	// flag of typegen build. Unnamed tasks are named after their generator
+	// and position, as in go-2; see Config.TaskName.
+	Name string `yaml:"name"`
+	// Description says what the task is for, for readers of the configuration
+	// and of build reports
+	Description string `yaml:"description"`
 	Generator string            `yaml:"generator"`
-	Input     string            `yaml:"input"`
+	// Input is the input directory, a glob pattern matching input
+	// directories to merge, or a directory of a git repository written
+	// git::<repository>[//<subdirectory>][?ref=<ref>]; see Inputs
+	Input     string            `yaml:"-"`
+	// Inputs are input directories or glob patterns, given as a list for
+	// input. Their directories are merged into one module, each as a
+	// submodule named after the directory.
+	Inputs    []string          `yaml:"-"`
+	// Output is the output directory. It may hold the placeholders
+	// {generator}, {task} and {module}, the name of the input directory.
 	Output    string            `yaml:"output"`
-	Config    map[string]string `yaml:"config"`
+	// Config are the task's config values, as strings; see TypedConfig
+	Config    map[string]string `yaml:"-"`
+	// TypedConfig are the task's config values in a version 2 file, which
+	// can be any YAML value; Config has them as strings
+	TypedConfig map[string]any `yaml:"-"`
+	// Exclude are gitignore-style patterns of files and directories to skip
+	// when parsing, relative to each input directory; see parser.Exclusions
+	Exclude []string `yaml:"exclude"`
+	// Clean removes stale generated files from the output after the task succeeds
+	Clean bool `yaml:"clean"`
+	// DependsOn are the names of the tasks that must succeed before this one
+	// runs; see Builder.BuildTasks
+	DependsOn []string `yaml:"depends_on"`
+	// Validation configures the validation of the task's input, over the
+	// global settings; see Config.MergedValidation
+	Validation ValidationConfig `yaml:"validation"`
+	// Strict fails the task on validation warnings too, like
+	// validation.fail_on: warning
+	Strict bool `yaml:"strict"`
+	// Hooks are commands run before and after the task generates code
+	Hooks TaskHooks `yaml:"hooks"`
+	// PostProcess are the processors applied in order to each generated
+	// file before it's written, such as formatters
+	PostProcess []PostProcess `yaml:"post_process"`
+	// Timeout is how long the task may run, from fetching its inputs to its
+	// last hook; none when zero. A task that runs out of time fails with
+	// ErrTimeout and the build goes on.
+	Timeout time.Duration `yaml:"timeout"`
+	
+	// configNode is the config block as written, decoded by Config once the
+	// file's version is known
+	configNode yaml.Node
+}
+
+// UnmarshalYAML decodes the config blocks by the version of the file: as
+// strings in version 1, and as any YAML value from version 2
+func (c *Config) UnmarshalYAML(node *yaml.Node) error {
+	type plain Config
+	var raw struct {
+		plain      `yaml:",inline"`
+		Config     yaml.Node            `yaml:"config"`
+		Generators map[string]yaml.Node `yaml:"generators"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*c = Config(raw.plain)
+	
+	var err error
+	if c.Config, c.TypedConfig, err = decodeConfigValues(&raw.Config, c.Version); err != nil {
+		return err
+	}
+	if len(raw.Generators) > 0 && c.Version < typedConfigVersion {
+		return fmt.Errorf("generators blocks require version: %d", typedConfigVersion)
+	}
+	for name, block := range raw.Generators {
+		_, typed, err := decodeConfigValues(&block, c.Version)
+		if err != nil {
+			return fmt.Errorf("generators.%s: %w", name, err)
+		}
+		if c.Generators == nil {
+			c.Generators = make(map[string]map[string]any)
+		}
+		c.Generators[name] = typed
+	}
+	for i := range c.Generate {
+		task := &c.Generate[i]
+		if task.Config, task.TypedConfig, err = decodeConfigValues(&task.configNode, c.Version); err != nil {
+			return fmt.Errorf("generate task %d: %w", i+1, err)
+		}
+		task.configNode = yaml.Node{}
+	}
+	return nil
+}
+
+// UnmarshalYAML reads input as a single directory or pattern into Input, or
+// as a list of them into Inputs. The config block is decoded by Config.
+func (t *GenerateTask) UnmarshalYAML(node *yaml.Node) error {
+	type plain GenerateTask
+	var raw struct {
+		plain `yaml:",inline"`
+		Input yaml.Node `yaml:"input"`
+		Config yaml.Node `yaml:"config"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*t = GenerateTask(raw.plain)
+	t.configNode = raw.Config
+	
+	switch raw.Input.Kind {
+	case 0:
+		// No input, which defaults to the current directory
+	case yaml.ScalarNode:
+		return raw.Input.Decode(&t.Input)
+	case yaml.SequenceNode:
+		if err := raw.Input.Decode(&t.Inputs); err != nil {
+			return err
+		}
+		if len(t.Inputs) == 0 {
+			return fmt.Errorf("line %d: input list is empty", raw.Input.Line)
+		}
+	default:
+		return fmt.Errorf("line %d: input must be a directory or a list of directories", raw.Input.Line)
+	}
+	return nil
+}
+
+// merged reports whether the task merges its input directories into one
+// module, each as a submodule: when it lists its inputs or uses a glob
+// pattern, even one matching a single directory, so the module layout
+// doesn't depend on how many directories match
+func (t GenerateTask) merged() bool {
+	return len(t.Inputs) > 0 || isGlob(t.Input)
+}
+
+// describeInput returns the task's input for messages: Input, or Inputs
+// separated by commas
+func (t GenerateTask) describeInput() string {
+	if len(t.Inputs) > 0 {
+		return strings.Join(t.Inputs, ", ")
+	}
+	return t.Input
+}
+
+// inputRoots returns the directories holding the task's inputs: each input
+// directory, or for a glob pattern, the directory before its first wildcard
+func (t GenerateTask) inputRoots() []string {
+	patterns := t.Inputs
+	if len(patterns) == 0 {
+		patterns = []string{t.Input}
+	}
+	roots := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		for isGlob(pattern) {
+			pattern = filepath.Dir(pattern)
+		}
+		roots[i] = pattern
+	}
+	return roots
+}
+
+func isGlob(pattern string) bool {
+	return !isRemote(pattern) && strings.ContainsAny(pattern, "*?[")
 }
 
 // LoadConfig loads and validates the typegen.yaml configuration
@@ -30,21 +215,21 @@ func LoadConfig(configPath string) (*Config, error) {
 		configPath = "typegen.yaml"
 	}
 	
-	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("config file not found: %s", configPath)
-	}
-	
-	// Read the file
-	data, err := os.ReadFile(configPath)
+	// Read the file, and those it extends
+	file, err := readConfigFile(configPath, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
+	config := *file.config
+	config.extended = file.extended
 	
-	// Parse YAML
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// The default cache is kept next to the configuration, like its paths
+	if config.CacheDir == "" {
+		absPath, err := filepath.Abs(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve config file %s: %w", configPath, err)
+		}
+		config.CacheDir = filepath.Join(filepath.Dir(absPath), DefaultCacheDir)
 	}
 	
 	// Apply defaults and validate
@@ -71,12 +256,35 @@ func (c *Config) applyDefaults() error {
 		c.Config = make(map[string]string)
 	}
 	
+	if c.CacheDir == "" {
+		c.CacheDir = DefaultCacheDir
+	}
+	if !filepath.IsAbs(c.CacheDir) {
+		absCacheDir, err := filepath.Abs(c.CacheDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve cache directory %s: %w", c.CacheDir, err)
+		}
+		c.CacheDir = absCacheDir
+	}
+	if c.RemoteCacheDir == "" {
+		c.RemoteCacheDir = filepath.Join(c.CacheDir, remotesDir)
+	}
+	if !filepath.IsAbs(c.RemoteCacheDir) {
+		absRemoteCacheDir, err := filepath.Abs(c.RemoteCacheDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve remote cache directory %s: %w", c.RemoteCacheDir, err)
+		}
+		c.RemoteCacheDir = absRemoteCacheDir
+	}
+	
 	// Apply defaults to generate tasks
 	for i := range c.Generate {
 		task := &c.Generate[i]
 		
+		task.Name = c.TaskName(i)
+		
 		// Default input to current directory
-		if task.Input == "" {
+		if task.Input == "" && len(task.Inputs) == 0 {
 			task.Input = "."
 		}
 		
@@ -86,14 +294,31 @@ func (c *Config) applyDefaults() error {
 		}
 		
 		// Convert relative paths to absolute paths
-		if !filepath.IsAbs(task.Input) {
+		if task.Input != "" && !isRemote(task.Input) && !filepath.IsAbs(task.Input) {
 			absInput, err := filepath.Abs(task.Input)
 			if err != nil {
 				return fmt.Errorf("failed to resolve input path %s: %w", task.Input, err)
 			}
 			task.Input = absInput
 		}
-		
+		for j, input := range task.Inputs {
+			if !isRemote(input) && !filepath.IsAbs(input) {
+				absInput, err := filepath.Abs(input)
+				if err != nil {
+					return fmt.Errorf("failed to resolve input path %s: %w", input, err)
+				}
+				task.Inputs[j] = absInput
+			}
+		}
+
+		// Expand placeholders once the name and input are known, so that
+		// {module} is the name of the absolute input directory
+		output, err := c.expandOutput(i)
+		if err != nil {
+			return fmt.Errorf("generate task %d: output: %w", i+1, err)
+		}
+		task.Output = output
+
 		if task.Output != "" && !filepath.IsAbs(task.Output) {
 			absOutput, err := filepath.Abs(task.Output)
 			if err != nil {
@@ -109,8 +334,8 @@ func (c *Config) applyDefaults() error {
 // validate validates the configuration
 func (c *Config) validate() error {
 	// Validate version
-	if c.Version != 1 {
-		return fmt.Errorf("unsupported config version: %d (supported: 1)", c.Version)
+	if c.Version != 1 && c.Version != typedConfigVersion {
+		return fmt.Errorf("unsupported config version: %d (supported: 1, %d)", c.Version, typedConfigVersion)
 	}
 	
 	// Validate generate tasks
@@ -118,6 +343,13 @@ func (c *Config) validate() error {
 		return fmt.Errorf("no generate tasks defined")
 	}
 	
+	if err := c.Validation.check(); err != nil {
+		return fmt.Errorf("validation: %w", err)
+	}
+	if len(c.Generators) > 0 && c.Version < typedConfigVersion {
+		return fmt.Errorf("generators blocks require version: %d", typedConfigVersion)
+	}
+	
 	for i, task := range c.Generate {
 		if task.Generator == "" {
 			return fmt.Errorf("generate task %d: generator is required", i)
@@ -127,23 +359,370 @@ func (c *Config) validate() error {
 			return fmt.Errorf("generate task %d: output is required", i)
 		}
 		
-		// Validate input directory exists
-		if info, err := os.Stat(task.Input); os.IsNotExist(err) {
-			return fmt.Errorf("generate task %d: input directory does not exist: %s", i, task.Input)
-		} else if !info.IsDir() {
-			return fmt.Errorf("generate task %d: input path is not a directory: %s", i, task.Input)
+		if name := c.TaskName(i); !validTaskName(name) {
+			return fmt.Errorf("generate task %d: invalid name %q; names are letters, digits, '-', '_', '.' and '+', and can't be a number", i+1, name)
+		}
+		for j := range i {
+			if c.TaskName(j) == c.TaskName(i) {
+				return fmt.Errorf("generate task %d: name %s is already used by task %d; task names must be unique", i+1, c.TaskName(i), j+1)
+			}
+		}
+		
+		if err := task.Validation.check(); err != nil {
+			return fmt.Errorf("generate task %d: validation: %w", i+1, err)
+		}
+		if task.Strict && task.Validation.FailOn == FailOnError {
+			return fmt.Errorf("generate task %d: strict fails on warnings, but validation.fail_on is %s", i+1, FailOnError)
+		}
+		if err := task.Hooks.check(); err != nil {
+			return fmt.Errorf("generate task %d: hooks: %w", i+1, err)
+		}
+		for j, process := range task.PostProcess {
+			if err := process.check(); err != nil {
+				return fmt.Errorf("generate task %d: post_process %d: %w", i+1, j+1, err)
+			}
+		}
+		if task.Timeout < 0 {
+			return fmt.Errorf("generate task %d: timeout must be positive, got %s", i+1, task.Timeout)
 		}
+		
+		if task.Input != "" && len(task.Inputs) > 0 {
+			return fmt.Errorf("generate task %d: set either Input or Inputs, not both", i+1)
+		}
+		
+		dirs, err := c.inputDirs(i)
+		if err != nil {
+			return fmt.Errorf("generate task %d: %w", i+1, err)
+		}
+		// Remote inputs are fetched by the Builder, so their directories may
+		// not exist yet
+		remote := make(map[string]bool)
+		for _, dir := range c.remoteDirs(i) {
+			remote[dir] = true
+		}
+		if _, err := (parser.ParseOptions{Exclude: task.Exclude}).Exclusions(dirs[0]); err != nil {
+			return fmt.Errorf("generate task %d: %w", i+1, err)
+		}
+		for _, dir := range dirs {
+			if remote[dir] {
+				continue
+			}
+			
+			// Validate input directory exists
+			if info, err := os.Stat(dir); os.IsNotExist(err) {
+				return fmt.Errorf("generate task %d: input directory does not exist: %s", i, dir)
+			} else if !info.IsDir() {
+				return fmt.Errorf("generate task %d: input path is not a directory: %s", i, dir)
+			}
+			
+			// Generated files would be mixed with the schemas, and parsed with them
+			if filepath.Clean(task.Output) == filepath.Clean(dir) {
+				return fmt.Errorf("generate task %d: output directory is the same as the input directory: %s; use a separate directory for generated code", i+1, task.Output)
+			}
+		}
+	}
+	
+	if _, err := c.taskOrder(); err != nil {
+		return err
 	}
 	
 	return nil
 }
 
+// Warnings returns the problems of the configuration that don't stop a build:
+// output directories inside an input directory, which are skipped when
+// parsing that input, output directories shared by tasks of different
+// generators, whose files may overwrite each other, and exclude patterns
+// that match nothing, which are likely typos
+func (c *Config) Warnings() []string {
+	var warnings []string
+	
+	var inputs []string
+	for j := range c.Generate {
+		dirs, _ := c.inputDirs(j)
+		for _, dir := range dirs {
+			if !slices.Contains(inputs, dir) {
+				inputs = append(inputs, dir)
+			}
+		}
+	}
+	for i, task := range c.Generate {
+		for _, input := range inputs {
+			if !within(input, task.Output) || filepath.Clean(input) == filepath.Clean(task.Output) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("task %s: output directory %s is inside the input directory %s; it is skipped when parsing the input", c.TaskName(i), task.Output, input))
+		}
+	}
+	
+	first := make(map[string]int)
+	for i, task := range c.Generate {
+		j, shared := first[task.Output]
+		if !shared {
+			first[task.Output] = i
+			continue
+		}
+		if other := c.Generate[j]; other.Generator != task.Generator {
+			warnings = append(warnings, fmt.Sprintf("tasks %s and %s share the output directory %s; their files may overwrite each other", c.TaskName(j), c.TaskName(i), task.Output))
+		}
+	}
+	
+	for i := range c.Generate {
+		for _, pattern := range c.unmatchedExcludes(i) {
+			warnings = append(warnings, fmt.Sprintf("task %s: exclude pattern %q matches nothing in the input", c.TaskName(i), pattern))
+		}
+	}
+	
+	return warnings
+}
+
+// TaskName returns the name of the task at index: its name, or for an
+// unnamed task its generator and number, as in go-2
+func (c *Config) TaskName(index int) string {
+	task := c.Generate[index]
+	if task.Name != "" {
+		return task.Name
+	}
+	return fmt.Sprintf("%s-%d", task.Generator, index+1)
+}
+
+// validTaskName reports whether name can name a task. Names that are numbers
+// would be ambiguous with task numbers in typegen build -t.
+func validTaskName(name string) bool {
+	if name == "" {
+		return false
+	}
+	number := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-', r == '_', r == '.', r == '+':
+			number = false
+		default:
+			return false
+		}
+	}
+	return !number
+}
+
+// outputsWithin returns the output directories of the tasks that are inside
+// dir, which the parser must skip when parsing dir as an input
+func (c *Config) outputsWithin(dir string) []string {
+	var outputs []string
+	for _, task := range c.Generate {
+		if within(dir, task.Output) && filepath.Clean(dir) != filepath.Clean(task.Output) {
+			outputs = append(outputs, task.Output)
+		}
+	}
+	return outputs
+}
+
+// inputDirs returns the input directories of the task at taskIndex: its
+// Input, or the directories of its Inputs, expanding glob patterns. Glob
+// matches that the parser would skip, or that are the output directory of a
+// task, are left out. Merged directories must have different names, since
+// they become submodules named after them.
+func (c *Config) inputDirs(taskIndex int) ([]string, error) {
+	task := c.Generate[taskIndex]
+	if !task.merged() {
+		dir, err := c.localInput(task.Input)
+		if err != nil {
+			return nil, err
+		}
+		return []string{dir}, nil
+	}
+	
+	patterns := task.Inputs
+	if len(patterns) == 0 {
+		patterns = []string{task.Input}
+	}
+	outputs := make(map[string]bool)
+	for _, other := range c.Generate {
+		outputs[filepath.Clean(other.Output)] = true
+	}
+	
+	var dirs []string
+	for _, pattern := range patterns {
+		if !isGlob(pattern) {
+			dir, err := c.localInput(pattern)
+			if err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, dir)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %s: %w", pattern, err)
+		}
+		matched := false
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || !info.IsDir() || parser.ShouldSkipDirectory(filepath.Base(match)) || outputs[filepath.Clean(match)] {
+				continue
+			}
+			if !slices.Contains(dirs, match) {
+				dirs = append(dirs, match)
+			}
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("input pattern %s matches no directories", pattern)
+		}
+	}
+	
+	names := make(map[string]string)
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		if other, exists := names[name]; exists {
+			return nil, fmt.Errorf("input directories %s and %s are both named %q; merged inputs become submodules named after their directory", other, dir, name)
+		}
+		names[name] = dir
+	}
+	return dirs, nil
+}
+
+// localInput returns the directory of an input: the input itself, or where a
+// remote input is fetched in the remote cache
+func (c *Config) localInput(input string) (string, error) {
+	if !isRemote(input) {
+		return input, nil
+	}
+	remote, err := parseRemote(input)
+	if err != nil {
+		return "", err
+	}
+	return remote.dir(c.RemoteCacheDir), nil
+}
+
+// remoteDirs returns the directories of the remote inputs of the task at
+// taskIndex in the remote cache
+func (c *Config) remoteDirs(taskIndex int) []string {
+	remotes, _ := c.Generate[taskIndex].remoteInputs()
+	dirs := make([]string, len(remotes))
+	for i, remote := range remotes {
+		dirs[i] = remote.dir(c.RemoteCacheDir)
+	}
+	return dirs
+}
+
+// unmatchedExcludes returns the exclude patterns of the task at taskIndex
+// that match nothing in any of its input directories
+func (c *Config) unmatchedExcludes(taskIndex int) []string {
+	task := c.Generate[taskIndex]
+	if len(task.Exclude) == 0 {
+		return nil
+	}
+	dirs, err := c.inputDirs(taskIndex)
+	if err != nil {
+		return nil
+	}
+	
+	unmatched := make(map[string]int)
+	for _, dir := range dirs {
+		exclusions, err := c.parseOptions(dir, task.Exclude).Exclusions(dir)
+		if err != nil {
+			return nil
+		}
+		walkInput(dir, exclusions, func(string, fs.DirEntry, error) error { return nil })
+		for _, pattern := range exclusions.Unmatched() {
+			unmatched[pattern]++
+		}
+	}
+	
+	var patterns []string
+	for _, pattern := range task.Exclude {
+		if unmatched[pattern] >= len(dirs) && !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// parseOptions returns the options for parsing an input directory of a task
+// excluding exclude: output directories inside the input hold generated
+// code, not schemas
+func (c *Config) parseOptions(input string, exclude []string) parser.ParseOptions {
+	return parser.ParseOptions{ExcludedDirs: c.outputsWithin(input), Exclude: exclude}
+}
+
+// inputFiles returns the paths of the .tg files of an input directory and its
+// subdirectories that the parser parses, in the order they are walked
+func (c *Config) inputFiles(input string, exclude []string) ([]string, error) {
+	exclusions, err := c.parseOptions(input, exclude).Exclusions(input)
+	if err != nil {
+		return nil, err
+	}
+	
+	var files []string
+	err = walkInput(input, exclusions, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil {
+			files = append(files, path)
+		}
+		return err
+	})
+	return files, err
+}
+
+// walkInput calls visit for each .tg file under an input directory that
+// exclusions doesn't skip. Errors reading the directory are passed to visit
+// too, which returns nil to skip the unreadable entry and carry on.
+func walkInput(input string, exclusions *parser.Exclusions, visit func(path string, entry fs.DirEntry, err error) error) error {
+	return filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return visit(path, entry, err)
+		}
+		if entry.IsDir() {
+			if path != input && exclusions.Skip(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(entry.Name(), ".tg") || exclusions.Skip(path, false) {
+			return nil
+		}
+		return visit(path, entry, nil)
+	})
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// generatorConfig returns the configuration of a task that its generator
+// must accept: the merged values of the keys set in the task and in the
+// generators block of its generator. The global config is shared by the
+// tasks of every generator, so its keys may be meant for other generators.
+func (c *Config) generatorConfig(taskIndex int) map[string]string {
+	merged := c.MergedConfig(taskIndex)
+	if merged == nil {
+		return nil
+	}
+	task := c.Generate[taskIndex]
+	config := make(map[string]string)
+	for key := range c.Generators[task.Generator] {
+		config[key] = merged[key]
+	}
+	for key := range task.TypedConfig {
+		config[key] = merged[key]
+	}
+	for key := range task.Config {
+		config[key] = merged[key]
+	}
+	return config
+}
+
 // MergedConfig returns the merged configuration for a specific task
-// Task configs take precedence over global configs
+// Task configs take precedence over global configs. From version 2, it is
+// MergedTypedConfig with its values as strings.
 func (c *Config) MergedConfig(taskIndex int) map[string]string {
 	if taskIndex < 0 || taskIndex >= len(c.Generate) {
 		return nil
 	}
+	if c.Version >= typedConfigVersion {
+		return generators.StringifyConfig(c.MergedTypedConfig(taskIndex))
+	}
 	
 	merged := make(map[string]string)
 	
@@ -3,7 +3,10 @@ package build
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -44,7 +47,7 @@ generate:
 		},
 		{
 			name: "invalid version",
-			yamlContent: `version: 2
+			yamlContent: `version: 3
 generate:
   - generator: go
     output: ./output
@@ -121,6 +124,11 @@ config:
 			if len(config.Generate) != tt.expectedTasks {
 				t.Errorf("Expected %d tasks, got %d", tt.expectedTasks, len(config.Generate))
 			}
+
+			// The cache directory defaults relative to the working directory, like task paths
+			if wd, _ := os.Getwd(); config.CacheDir != filepath.Join(wd, DefaultCacheDir) {
+				t.Errorf("Expected cache directory %s, got %s", filepath.Join(wd, DefaultCacheDir), config.CacheDir)
+			}
 		})
 	}
 }
@@ -207,4 +215,344 @@ func TestLoadConfigDefaultPath(t *testing.T) {
 	if len(config.Generate) != 1 {
 		t.Errorf("Expected 1 task, got %d", len(config.Generate))
 	}
-}
\ No newline at end of file
+}
+func TestLoadConfigRelativeToConfigDir(t *testing.T) {
+	root := t.TempDir()
+	service := filepath.Join(root, "services", "payments")
+	shared := filepath.Join(root, "shared")
+	for _, dir := range []string{filepath.Join(service, "schemas"), filepath.Join(shared, "schemas")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(root, "base.yaml"), `generate:
+  - name: shared
+    generator: go
+    input: ./shared/schemas
+    output: ./gen/shared
+`)
+	writeFile(t, filepath.Join(service, "typegen.yaml"), `extends: ../../base.yaml
+remote_cache_dir: ./remotes
+generate:
+  - name: api
+    generator: go
+    input: ./schemas
+    output: ./gen/go
+  - name: default-input
+    generator: go
+    output: `+filepath.Join(root, "absolute")+`
+`)
+
+	// Load the configuration from the root of the repository, as in
+	// typegen build -f services/payments/typegen.yaml
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(root)
+
+	config, err := LoadConfig(filepath.Join("services", "payments", "typegen.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct{ input, output string }{
+		{filepath.Join(shared, "schemas"), filepath.Join(root, "gen", "shared")},
+		{filepath.Join(service, "schemas"), filepath.Join(service, "gen", "go")},
+		{service, filepath.Join(root, "absolute")},
+	}
+	for i, task := range config.Generate {
+		if task.Input != expected[i].input || task.Output != expected[i].output {
+			t.Errorf("%s: expected input %s and output %s, got %s and %s",
+				task.Name, expected[i].input, expected[i].output, task.Input, task.Output)
+		}
+	}
+	if expected := filepath.Join(service, DefaultCacheDir); config.CacheDir != expected {
+		t.Errorf("expected cache directory %s, got %s", expected, config.CacheDir)
+	}
+	if expected := filepath.Join(service, "remotes"); config.RemoteCacheDir != expected {
+		t.Errorf("expected remote cache directory %s, got %s", expected, config.RemoteCacheDir)
+	}
+}
+
+func TestConfigOutputPlacement(t *testing.T) {
+	root := t.TempDir()
+	schemas := filepath.Join(root, "schemas")
+	api := filepath.Join(root, "api")
+	for _, dir := range []string{schemas, api} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tasks    []GenerateTask
+		err      string
+		warnings []string
+	}{
+		{
+			name:  "sibling",
+			tasks: []GenerateTask{{Generator: "go", Input: schemas, Output: filepath.Join(root, "gen")}},
+		},
+		{
+			name:  "equal",
+			tasks: []GenerateTask{{Generator: "go", Input: schemas, Output: schemas}},
+			err:   "generate task 1: output directory is the same as the input directory",
+		},
+		{
+			name:  "invalid exclude pattern",
+			tasks: []GenerateTask{{Generator: "go", Input: schemas, Output: filepath.Join(root, "gen"), Exclude: []string{"[a-"}}},
+			err:   "generate task 1: invalid exclude pattern \"[a-\"",
+		},
+		{
+			name:  "nested",
+			tasks: []GenerateTask{{Generator: "go", Input: schemas, Output: filepath.Join(schemas, "gen")}},
+			warnings: []string{
+				"task go-1: output directory " + filepath.Join(schemas, "gen") + " is inside the input directory " + schemas + "; it is skipped when parsing the input",
+			},
+		},
+		{
+			name: "nested in another task's input",
+			tasks: []GenerateTask{
+				{Generator: "go", Input: schemas, Output: filepath.Join(root, "gen")},
+				{Generator: "python+pydantic", Input: api, Output: filepath.Join(schemas, "python")},
+			},
+			warnings: []string{
+				"task python+pydantic-2: output directory " + filepath.Join(schemas, "python") + " is inside the input directory " + schemas + "; it is skipped when parsing the input",
+			},
+		},
+		{
+			name: "shared output",
+			tasks: []GenerateTask{
+				{Generator: "go", Input: schemas, Output: filepath.Join(root, "gen")},
+				{Generator: "go", Input: api, Output: filepath.Join(root, "gen")},
+				{Generator: "python+pydantic", Input: api, Output: filepath.Join(root, "gen")},
+			},
+			warnings: []string{
+				"tasks go-1 and python+pydantic-3 share the output directory " + filepath.Join(root, "gen") + "; their files may overwrite each other",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Version: 1, Generate: tt.tasks}
+			err := config.validate()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if warnings := config.Warnings(); !reflect.DeepEqual(warnings, tt.warnings) {
+				t.Errorf("expected warnings %q, got %q", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfigInputs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"schemas/core", "schemas/payments", "schemas/node_modules", "legacy/core", "empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(root, "schemas", "README.md"), "not a directory\n")
+
+	tests := []struct {
+		name   string
+		input  string
+		merged bool
+		dirs   []string
+		err    string
+	}{
+		{"single directory", "./schemas/core", false, []string{"schemas/core"}, ""},
+		{"list", "[./schemas/core, ./schemas/payments]", true, []string{"schemas/core", "schemas/payments"}, ""},
+		{"list of one", "[./schemas/core]", true, []string{"schemas/core"}, ""},
+		{"glob", "./schemas/*", true, []string{"schemas/core", "schemas/payments"}, ""},
+		{"glob and directory", "[./schemas/pay*, ./legacy/core]", true, []string{"schemas/payments", "legacy/core"}, ""},
+		{"collision", "[./schemas/core, ./legacy/core]", false, nil, "are both named \"core\""},
+		{"glob collision", "[./schemas/*, ./legacy/*]", false, nil, "are both named \"core\""},
+		{"no matches", "./empty/*", false, nil, "input pattern " + filepath.Join(root, "empty", "*") + " matches no directories"},
+		{"missing directory", "[./schemas/core, ./missing]", false, nil, "input directory does not exist"},
+		{"empty list", "[]", false, nil, "input list is empty"},
+		{"mapping", "{dir: ./schemas}", false, nil, "input must be a directory or a list of directories"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(root, "typegen.yaml")
+			writeFile(t, configPath, "generate:\n  - generator: go\n    input: "+tt.input+"\n    output: ${CONFIG_DIR}/gen\n")
+
+			oldWd, _ := os.Getwd()
+			defer os.Chdir(oldWd)
+			os.Chdir(root)
+
+			config, err := LoadConfig(configPath)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if merged := config.Generate[0].merged(); merged != tt.merged {
+				t.Errorf("expected merged %v, got %v", tt.merged, merged)
+			}
+			dirs, err := config.inputDirs(0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var expected []string
+			for _, dir := range tt.dirs {
+				expected = append(expected, filepath.Join(root, dir))
+			}
+			if !reflect.DeepEqual(dirs, expected) {
+				t.Errorf("expected input directories %v, got %v", expected, dirs)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTaskNames(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	load := func(tasks string) (*Config, error) {
+		writeFile(t, configPath, "generate:\n"+tasks)
+		return LoadConfig(configPath)
+	}
+	task := func(fields string) string {
+		return "  - generator: go\n    input: " + dir + "\n    output: " + filepath.Join(dir, "gen") + "\n" + fields
+	}
+
+	config, err := load(task("    name: payments-go\n    description: Go models for the payments service\n") + task("") + task(""))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	for i, expected := range []string{"payments-go", "go-2", "go-3"} {
+		if name := config.Generate[i].Name; name != expected {
+			t.Errorf("task %d: expected name %s, got %s", i+1, expected, name)
+		}
+	}
+	if description := config.Generate[0].Description; description != "Go models for the payments service" {
+		t.Errorf("expected the task description, got %q", description)
+	}
+
+	tests := []struct {
+		name  string
+		tasks string
+		err   string
+	}{
+		{"duplicate names", task("    name: models\n") + task("    name: models\n"), "generate task 2: name models is already used by task 1"},
+		{"automatic name taken", task("    name: go-2\n") + task(""), "generate task 2: name go-2 is already used by task 1"},
+		{"number", task("    name: \"12\"\n"), "generate task 1: invalid name \"12\""},
+		{"space", task("    name: payments go\n"), "generate task 1: invalid name \"payments go\""},
+		{"comma", task("    name: go,python\n"), "generate task 1: invalid name \"go,python\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(tt.tasks); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	task := func(name, validation string) string {
+		return "  - name: " + name + "\n    generator: go\n    input: " + dir + "\n    output: " + filepath.Join(dir, name) + "\n" + validation
+	}
+	writeFile(t, configPath, `validation:
+  fail_on: warning
+  unused_types: all
+  rules:
+    naming_convention: warning
+generate:
+`+task("legacy", "    validation:\n      skip: true\n")+
+		task("lenient", "    validation:\n      fail_on: error\n      unused_types: submodules\n      rules:\n        naming_convention: off\n        duplicate_field: warning\n")+
+		task("default", ""))
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		skip        bool
+		failOn      string
+		rules       map[string]string
+		unusedTypes string
+	}{
+		{true, FailOnWarning, map[string]string{"naming_convention": "warning"}, "all"},
+		{false, FailOnError, map[string]string{"naming_convention": "off", "duplicate_field": "warning"}, "submodules"},
+		{false, FailOnWarning, map[string]string{"naming_convention": "warning"}, "all"},
+	}
+	for i, tt := range tests {
+		merged := config.MergedValidation(i)
+		if *merged.Skip != tt.skip || merged.FailOn != tt.failOn || !reflect.DeepEqual(merged.Rules, tt.rules) || merged.UnusedTypes != tt.unusedTypes {
+			t.Errorf("task %d: expected skip %v, fail_on %s, rules %v and unused_types %s, got skip %v, fail_on %s, rules %v and unused_types %s",
+				i+1, tt.skip, tt.failOn, tt.rules, tt.unusedTypes, *merged.Skip, merged.FailOn, merged.Rules, merged.UnusedTypes)
+		}
+	}
+
+	// The global settings are not changed by merging
+	if len(config.Validation.Rules) != 1 || config.Validation.Skip != nil {
+		t.Errorf("expected the global settings unchanged, got %+v", config.Validation)
+	}
+
+	// Strict tasks fail on warnings
+	writeFile(t, configPath, "generate:\n"+task("strict", "    strict: true\n")+task("default", ""))
+	config, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if strict, other := config.MergedValidation(0).FailOn, config.MergedValidation(1).FailOn; strict != FailOnWarning || other != FailOnError {
+		t.Errorf("expected fail_on warning for the strict task only, got %s and %s", strict, other)
+	}
+
+	errorTests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"global fail_on", "validation:\n  fail_on: never\ngenerate:\n" + task("go", ""), `validation: fail_on must be error or warning, got "never"`},
+		{"unknown rule", "generate:\n" + task("go", "    validation:\n      rules:\n        naming: warning\n"), `generate task 1: validation: rules: unknown validation rule "naming"`},
+		{"unknown severity", "generate:\n" + task("go", "    validation:\n      rules:\n        naming_convention: ignore\n"), `rule naming_convention: unknown severity "ignore"`},
+		{"unknown unused_types", "generate:\n" + task("go", "    validation:\n      unused_types: root\n"), `generate task 1: validation: unused_types: unknown unused type scope "root"`},
+		{"strict with fail_on error", "generate:\n" + task("go", "    strict: true\n    validation:\n      fail_on: error\n"), `generate task 1: strict fails on warnings, but validation.fail_on is error`},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, configPath, tt.config)
+			if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTimeout(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	writeFile(t, configPath, "generate:\n  - generator: go\n    output: ./gen\n    timeout: 1m30s\n")
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if timeout := config.Generate[0].Timeout; timeout != 90*time.Second {
+		t.Errorf("expected timeout 1m30s, got %s", timeout)
+	}
+
+	writeFile(t, configPath, "generate:\n  - generator: go\n    output: ./gen\n    timeout: -5s\n")
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), "generate task 1: timeout must be positive, got -5s") {
+		t.Errorf("expected a negative timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package build
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// taskIndex returns the index of the task named name, or -1
+func (c *Config) taskIndex(name string) int {
+	for i := range c.Generate {
+		if c.TaskName(i) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// dependencies returns the indexes of the tasks that the task at index
+// depends on, in the order of its depends_on list
+func (c *Config) dependencies(index int) ([]int, error) {
+	var deps []int
+	for _, name := range c.Generate[index].DependsOn {
+		j := c.taskIndex(name)
+		if j < 0 {
+			return nil, fmt.Errorf("generate task %d: depends_on: no task named %q", index+1, name)
+		}
+		if !slices.Contains(deps, j) {
+			deps = append(deps, j)
+		}
+	}
+	return deps, nil
+}
+
+// taskOrder returns the indexes of the tasks in the order they run: each task
+// after the tasks it depends on, and otherwise in the order of the
+// configuration. It fails on unknown task names and dependency cycles.
+func (c *Config) taskOrder() ([]int, error) {
+	deps := make([][]int, len(c.Generate))
+	for i := range c.Generate {
+		taskDeps, err := c.dependencies(i)
+		if err != nil {
+			return nil, err
+		}
+		deps[i] = taskDeps
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(c.Generate))
+	var order, path []int
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for _, j := range path[slices.Index(path, i):] {
+				cycle = append(cycle, c.TaskName(j))
+			}
+			cycle = append(cycle, c.TaskName(i))
+			return fmt.Errorf("generate tasks have a dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, i)
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+
+	for i := range c.Generate {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// withDependents returns the tasks and every task depending on them, directly
+// or not, in the order they run
+func (c *Config) withDependents(tasks []int) []int {
+	order, err := c.taskOrder()
+	if err != nil {
+		return tasks
+	}
+
+	included := make(map[int]bool)
+	for _, i := range tasks {
+		included[i] = true
+	}
+	// Dependencies run first, so one pass in run order reaches every dependent
+	var result []int
+	for _, i := range order {
+		deps, _ := c.dependencies(i)
+		for _, j := range deps {
+			if included[j] {
+				included[i] = true
+			}
+		}
+		if included[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// failedDependency returns the name of a dependency of the task at index
+// that failed, according to failed, or "" if none did
+func (c *Config) failedDependency(index int, failed func(int) bool) string {
+	deps, _ := c.dependencies(index)
+	for _, j := range deps {
+		if failed(j) {
+			return c.TaskName(j)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+)
+
+func TestTaskOrder(t *testing.T) {
+	task := func(name string, dependsOn ...string) GenerateTask {
+		return GenerateTask{Name: name, Generator: "go", DependsOn: dependsOn}
+	}
+
+	tests := []struct {
+		name     string
+		tasks    []GenerateTask
+		expected []int
+		err      string
+	}{
+		{"no dependencies", []GenerateTask{task("a"), task("b"), task("c")}, []int{0, 1, 2}, ""},
+		{"chain", []GenerateTask{task("docs", "python"), task("python", "proto"), task("proto")}, []int{2, 1, 0}, ""},
+		{"diamond", []GenerateTask{task("docs", "go", "python"), task("go", "proto"), task("python", "proto"), task("proto")}, []int{3, 1, 2, 0}, ""},
+		{"independent tasks keep their order", []GenerateTask{task("a"), task("b", "d"), task("c"), task("d")}, []int{0, 3, 1, 2}, ""},
+		{"repeated dependency", []GenerateTask{task("a", "b", "b"), task("b")}, []int{1, 0}, ""},
+		{"automatic names", []GenerateTask{{Generator: "docs", DependsOn: []string{"go-2"}}, {Generator: "go"}}, []int{1, 0}, ""},
+		{"unknown task", []GenerateTask{task("a"), task("b", "proto")}, nil, `generate task 2: depends_on: no task named "proto"`},
+		{"cycle", []GenerateTask{task("a", "b"), task("b", "c"), task("c", "a")}, nil, "dependency cycle: a -> b -> c -> a"},
+		{"cycle after a chain", []GenerateTask{task("a", "b"), task("b", "c"), task("c", "b")}, nil, "dependency cycle: b -> c -> b"},
+		{"self", []GenerateTask{task("a", "a")}, nil, "dependency cycle: a -> a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Version: 1, Generate: tt.tasks}
+			order, err := config.taskOrder()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(order, tt.expected) {
+				t.Errorf("expected order %v, got %v", tt.expected, order)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDependencyCycle(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	writeFile(t, configPath, fmt.Sprintf(`generate:
+  - name: go-models
+    generator: go
+    input: %[1]s
+    output: %[1]s/go
+    depends_on: [docs]
+  - name: docs
+    generator: go
+    input: %[1]s
+    output: %[1]s/docs
+    depends_on: [go-models]
+`, dir))
+
+	_, err := LoadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: go-models -> docs -> go-models") {
+		t.Errorf("expected a dependency cycle error, got %v", err)
+	}
+}
+
+func TestWithDependents(t *testing.T) {
+	config := &Config{Version: 1, Generate: []GenerateTask{
+		{Name: "docs", Generator: "go", DependsOn: []string{"go", "python"}},
+		{Name: "go", Generator: "go", DependsOn: []string{"proto"}},
+		{Name: "python", Generator: "go", DependsOn: []string{"proto"}},
+		{Name: "proto", Generator: "go"},
+		{Name: "other", Generator: "go"},
+	}}
+
+	if tasks := config.withDependents([]int{3}); !reflect.DeepEqual(tasks, []int{3, 1, 2, 0}) {
+		t.Errorf("expected proto and its dependents, got %v", tasks)
+	}
+	if tasks := config.withDependents([]int{4, 2}); !reflect.DeepEqual(tasks, []int{2, 0, 4}) {
+		t.Errorf("expected python, docs and other, got %v", tasks)
+	}
+}
+
+func TestBuildTaskDependencies(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	registerGenerator(t, "mock-failing", func() generators.Generator { return &MockGenerator{shouldErr: true} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	output := func(name string) string {
+		return filepath.Join(t.TempDir(), name)
+	}
+
+	t.Run("order", func(t *testing.T) {
+		config := &Config{Version: 1, Generate: []GenerateTask{
+			{Name: "docs", Generator: "files", Input: input, Output: output("docs"), DependsOn: []string{"go", "python"}},
+			{Name: "go", Generator: "files", Input: input, Output: output("go"), DependsOn: []string{"proto"}},
+			{Name: "python", Generator: "files", Input: input, Output: output("python"), DependsOn: []string{"proto"}},
+			{Name: "proto", Generator: "files", Input: input, Output: output("proto")},
+		}}
+
+		progress := &recordingProgress{}
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+		builder.SetProgress(progress)
+		if _, err := builder.Build(context.Background()); err != nil {
+			t.Fatalf("build failed: %v", err)
+		}
+
+		var done []string
+		for _, event := range progress.events {
+			if strings.HasSuffix(event, string(TaskSucceeded)) {
+				done = append(done, event)
+			}
+		}
+		expected := []string{"4 succeeded", "2 succeeded", "3 succeeded", "1 succeeded"}
+		if !reflect.DeepEqual(done, expected) {
+			t.Errorf("expected tasks to run after their dependencies %v, got %v", expected, done)
+		}
+
+		// Results stay in configuration order
+		for i, task := range builder.Result().Tasks {
+			if task.Task != i+1 {
+				t.Errorf("expected task %d at position %d, got task %d", i+1, i, task.Task)
+			}
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		config := &Config{Version: 1, Generate: []GenerateTask{
+			{Name: "docs", Generator: "files", Input: input, Output: output("docs"), DependsOn: []string{"models", "api"}},
+			{Name: "models", Generator: "files", Input: input, Output: output("models")},
+			{Name: "api", Generator: "mock-failing", Input: input, Output: output("api"), DependsOn: []string{"models"}},
+			{Name: "site", Generator: "files", Input: input, Output: output("site"), DependsOn: []string{"docs"}},
+			{Name: "other", Generator: "files", Input: input, Output: output("other")},
+		}}
+
+		var out bytes.Buffer
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&out, slog.LevelInfo))
+		if _, err := builder.Build(context.Background()); err == nil {
+			t.Fatal("expected the build to fail")
+		}
+
+		result := builder.Result()
+		statuses := make([]TaskStatus, len(result.Tasks))
+		for i, task := range result.Tasks {
+			statuses[i] = task.Status
+		}
+		expected := []TaskStatus{TaskDependencyFailed, TaskSucceeded, TaskFailed, TaskDependencyFailed, TaskSucceeded}
+		if !reflect.DeepEqual(statuses, expected) {
+			t.Errorf("expected statuses %v, got %v", expected, statuses)
+		}
+		if result.Tasks[3].Error != "dependency docs did not succeed" {
+			t.Errorf("expected the failed dependency in the error, got %q", result.Tasks[3].Error)
+		}
+		if summary := result.Summary; summary.Failed != 1 || summary.DependencyFailed != 2 || summary.Skipped != 0 {
+			t.Errorf("expected 1 failed and 2 dependency failed tasks, got %+v", summary)
+		}
+
+		for _, expected := range []string{
+			"[1/5 docs] ⏭️  Skipped (dependency failed): api did not succeed",
+			"[4/5 site] ⏭️  Skipped (dependency failed): docs did not succeed",
+			"Build completed: 2/5 tasks succeeded, 2 skipped (dependency failed)",
+		} {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+			}
+		}
+	})
+
+	t.Run("unselected dependency", func(t *testing.T) {
+		config := &Config{Version: 1, Generate: []GenerateTask{
+			{Name: "api", Generator: "mock-failing", Input: input, Output: output("api")},
+			{Name: "docs", Generator: "files", Input: input, Output: output("docs"), DependsOn: []string{"api"}},
+		}}
+
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+		if _, err := builder.BuildTasks(context.Background(), TaskFilter{Tasks: []string{"docs"}}); err != nil {
+			t.Fatalf("BuildTasks failed: %v", err)
+		}
+		if status := builder.Result().Tasks[1].Status; status != TaskSucceeded {
+			t.Errorf("expected the task to run without its unselected dependency, got %s", status)
+		}
+	})
+}
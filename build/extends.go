@@ -0,0 +1,210 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a typegen.yaml file as written, before the files it extends
+// are merged into it
+type configFile struct {
+	config *Config
+	// extends are the paths of the files it extends, as written
+	extends []string
+	// extended are the absolute paths of the files it extends, directly or not
+	extended []string
+	// setsCache reports whether the file, or a file it extends, sets cache
+	setsCache bool
+}
+
+// readConfigFile reads the configuration at configPath, with the files it
+// extends merged into it. chain holds the absolute paths of the files
+// extending it, to detect cycles. Relative paths in every file, extended or
+// not, are resolved against its directory.
+func readConfigFile(configPath string, chain []string) (*configFile, error) {
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config file %s: %w", configPath, err)
+	}
+	for i, path := range chain {
+		if path == absPath {
+			cycle := append(append([]string{}, chain[i:]...), absPath)
+			return nil, fmt.Errorf("config files extend each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	// Check if config file exists
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file not found: %s", configPath)
+	}
+
+	// Read the file
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file, err := parseConfigFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	// Expand variables before relative paths are resolved
+	if err := file.config.interpolateConfig(configPath); err != nil {
+		return nil, err
+	}
+	file.config.resolvePaths(filepath.Dir(absPath))
+
+	if len(file.extends) == 0 {
+		return file, nil
+	}
+	base := &configFile{config: &Config{}}
+	for _, parent := range file.extends {
+		parentPath := parent
+		if !filepath.IsAbs(parentPath) {
+			parentPath = filepath.Join(filepath.Dir(absPath), parentPath)
+		}
+		parentFile, err := readConfigFile(parentPath, append(chain, absPath))
+		if err != nil {
+			return nil, fmt.Errorf("%s: extends %s: %w", configPath, parent, err)
+		}
+		base.extendWith(parentFile)
+		parentAbs, _ := filepath.Abs(parentPath)
+		base.extended = append(base.extended, parentAbs)
+		base.extended = append(base.extended, parentFile.extended...)
+	}
+	base.extendWith(file)
+	return base, nil
+}
+
+// parseConfigFile parses the YAML of a configuration file
+func parseConfigFile(data []byte) (*configFile, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	file := &configFile{config: &Config{}}
+	if len(node.Content) == 0 {
+		return file, nil
+	}
+	root := node.Content[0]
+	if err := root.Decode(file.config); err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		Extends yaml.Node `yaml:"extends"`
+		Cache   yaml.Node `yaml:"cache"`
+	}
+	if err := root.Decode(&raw); err != nil {
+		return nil, err
+	}
+	file.setsCache = raw.Cache.Kind != 0
+	switch raw.Extends.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		var parent string
+		if err := raw.Extends.Decode(&parent); err != nil {
+			return nil, err
+		}
+		file.extends = []string{parent}
+	case yaml.SequenceNode:
+		if err := raw.Extends.Decode(&file.extends); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("line %d: extends must be a file or a list of files", raw.Extends.Line)
+	}
+	for _, parent := range file.extends {
+		if parent == "" {
+			return nil, fmt.Errorf("line %d: extends has an empty file name", raw.Extends.Line)
+		}
+	}
+	return file, nil
+}
+
+// extendWith merges file on top of the configuration: its version, cache
+// settings, global config values, generators blocks and validation settings
+// replace those set before, and each of its tasks replaces the task of the same name, or is
+// appended
+func (f *configFile) extendWith(file *configFile) {
+	c, child := f.config, file.config
+	if child.Version != 0 {
+		c.Version = child.Version
+	}
+	if file.setsCache {
+		c.Cache = child.Cache
+		f.setsCache = true
+	}
+	if child.CacheDir != "" {
+		c.CacheDir = child.CacheDir
+	}
+	if child.RemoteCacheDir != "" {
+		c.RemoteCacheDir = child.RemoteCacheDir
+	}
+	c.Validation.merge(child.Validation)
+
+	// Typed config values are deep-merged, like the config of a task over
+	// the global one
+	if c.TypedConfig != nil || child.TypedConfig != nil {
+		c.TypedConfig = mergeValues(c.globalTypedConfig(), child.globalTypedConfig())
+		c.Config = generators.StringifyConfig(c.TypedConfig)
+	} else {
+		if len(child.Config) > 0 && c.Config == nil {
+			c.Config = make(map[string]string)
+		}
+		for key, value := range child.Config {
+			c.Config[key] = value
+		}
+	}
+	for name, block := range child.Generators {
+		if c.Generators == nil {
+			c.Generators = make(map[string]map[string]any)
+		}
+		c.Generators[name] = mergeValues(c.Generators[name], block)
+	}
+
+	for _, task := range child.Generate {
+		// Unnamed tasks are named once merged, by their final position
+		index := -1
+		if task.Name != "" {
+			index = c.taskIndex(task.Name)
+		}
+		if index >= 0 {
+			c.Generate[index] = task
+		} else {
+			c.Generate = append(c.Generate, task)
+		}
+	}
+}
+
+// resolvePaths makes the relative input, output, cache_dir and
+// remote_cache_dir paths of the configuration absolute, relative to dir.
+// Inputs default to dir; remote inputs are kept as they are.
+func (c *Config) resolvePaths(dir string) {
+	resolve := func(path string) string {
+		if path == "" || isRemote(path) || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	c.CacheDir = resolve(c.CacheDir)
+	c.RemoteCacheDir = resolve(c.RemoteCacheDir)
+	for i := range c.Generate {
+		task := &c.Generate[i]
+		if task.Input == "" && len(task.Inputs) == 0 {
+			task.Input = dir
+		}
+		task.Input = resolve(task.Input)
+		for j := range task.Inputs {
+			task.Inputs[j] = resolve(task.Inputs[j])
+		}
+		task.Output = resolve(task.Output)
+	}
+}
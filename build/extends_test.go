@@ -0,0 +1,157 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigExtends(t *testing.T) {
+	root := t.TempDir()
+	service := filepath.Join(root, "services", "payments")
+	for _, dir := range []string{"schemas", "services/payments/schemas"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(root, "typegen.base.yaml"), `cache: true
+cache_dir: .typegen-cache
+validation:
+  rules:
+    naming_convention: warning
+config:
+  module-name: example.com/shared
+  style: compact
+generate:
+  - name: go
+    generator: go
+    input: ./schemas
+    output: ./gen/go
+  - generator: python+pydantic
+    input: ${CONFIG_DIR}/schemas
+    output: gen/python
+    config:
+      module-name: shared
+`)
+	writeFile(t, filepath.Join(root, "typegen.strict.yaml"), "config:\n  style: strict\n  strict: \"true\"\n")
+	configPath := filepath.Join(service, "typegen.yaml")
+	writeFile(t, configPath, `extends:
+  - ../../typegen.base.yaml
+  - ../../typegen.strict.yaml
+cache: false
+validation:
+  fail_on: warning
+config:
+  module-name: example.com/payments
+generate:
+  - name: go
+    generator: go
+    input: ./schemas
+    output: ./gen/go
+  - name: fixtures
+    generator: fixtures
+    input: ./schemas
+    output: ./testdata
+`)
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(service)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	// The go task is replaced, the python task kept and the fixtures task appended
+	expected := []GenerateTask{
+		{Name: "go", Generator: "go", Input: filepath.Join(service, "schemas"), Output: filepath.Join(service, "gen", "go")},
+		{Name: "python+pydantic-2", Generator: "python+pydantic", Input: filepath.Join(root, "schemas"), Output: filepath.Join(root, "gen", "python")},
+		{Name: "fixtures", Generator: "fixtures", Input: filepath.Join(service, "schemas"), Output: filepath.Join(service, "testdata")},
+	}
+	if len(config.Generate) != len(expected) {
+		t.Fatalf("expected %d tasks, got %+v", len(expected), config.Generate)
+	}
+	for i, task := range config.Generate {
+		if task.Name != expected[i].Name || task.Generator != expected[i].Generator || task.Input != expected[i].Input || task.Output != expected[i].Output {
+			t.Errorf("task %d: expected %+v, got %+v", i+1, expected[i], task)
+		}
+	}
+
+	// Global config values are merged in order, then with each task's config
+	merged := config.MergedConfig(1)
+	expectedConfig := map[string]string{"module-name": "shared", "style": "strict", "strict": "true"}
+	if !reflect.DeepEqual(merged, expectedConfig) {
+		t.Errorf("expected config %v, got %v", expectedConfig, merged)
+	}
+	if module := config.MergedConfig(0)["module-name"]; module != "example.com/payments" {
+		t.Errorf("expected the child's module-name, got %s", module)
+	}
+
+	if config.Cache {
+		t.Error("expected the child to turn the cache off")
+	}
+	if config.CacheDir != filepath.Join(root, ".typegen-cache") {
+		t.Errorf("expected the cache directory of the base file, got %s", config.CacheDir)
+	}
+
+	if validation := config.MergedValidation(0); validation.FailOn != FailOnWarning || validation.Rules["naming_convention"] != "warning" {
+		t.Errorf("expected the validation settings of both files, got %+v", validation)
+	}
+
+	extended := []string{filepath.Join(root, "typegen.base.yaml"), filepath.Join(root, "typegen.strict.yaml")}
+	if !reflect.DeepEqual(config.extended, extended) {
+		t.Errorf("expected the extended files %v, got %v", extended, config.extended)
+	}
+}
+
+func TestLoadConfigExtendsErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			"cycle",
+			map[string]string{
+				"typegen.yaml": "extends: a.yaml\n",
+				"a.yaml":       "extends: b.yaml\n",
+				"b.yaml":       "extends: ./a.yaml\n",
+			},
+			"config files extend each other in a cycle: " + filepath.Join(dir, "a.yaml") + " -> " + filepath.Join(dir, "b.yaml") + " -> " + filepath.Join(dir, "a.yaml"),
+		},
+		{
+			"self",
+			map[string]string{"typegen.yaml": "extends: typegen.yaml\n"},
+			"config files extend each other in a cycle",
+		},
+		{
+			"missing file",
+			map[string]string{"typegen.yaml": "extends: base.yaml\n"},
+			"extends base.yaml: config file not found",
+		},
+		{
+			"mapping",
+			map[string]string{"typegen.yaml": "extends: {file: base.yaml}\n"},
+			"extends must be a file or a list of files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"typegen.yaml", "a.yaml", "b.yaml", "base.yaml"} {
+				os.Remove(filepath.Join(dir, name))
+			}
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(dir, name), content)
+			}
+			_, err := LoadConfig(filepath.Join(dir, "typegen.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
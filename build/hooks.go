@@ -0,0 +1,146 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout is how long each hook may run when the task's hooks
+// don't set a timeout
+const DefaultHookTimeout = 5 * time.Minute
+
+// TaskHooks are shell commands run around the generation of a task, in its
+// output directory
+type TaskHooks struct {
+	// Pre are run in order before the task parses its input
+	Pre []string `yaml:"pre"`
+	// Post are run in order after the task generates code successfully, such
+	// as formatters
+	Post []string `yaml:"post"`
+	// Timeout is how long each hook may run, by default DefaultHookTimeout
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Stages of the hooks, as set in TYPEGEN_HOOK
+const (
+	hookPre  = "pre"
+	hookPost = "post"
+)
+
+// check reports invalid hooks
+func (h TaskHooks) check() error {
+	if h.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive, got %s", h.Timeout)
+	}
+	for i, command := range h.Pre {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("%s hook %d is empty", hookPre, i+1)
+		}
+	}
+	for i, command := range h.Post {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("%s hook %d is empty", hookPost, i+1)
+		}
+	}
+	return nil
+}
+
+// timeout returns how long each hook may run
+func (h TaskHooks) timeout() time.Duration {
+	if h.Timeout == 0 {
+		return DefaultHookTimeout
+	}
+	return h.Timeout
+}
+
+// runHooks runs the hooks of a stage of the task at taskIndex in order,
+// stopping at the first that fails. Hooks run with the shell in the output
+// directory, which is created first, and with the environment of typegen
+// plus TYPEGEN_TASK, TYPEGEN_GENERATOR, TYPEGEN_INPUT, TYPEGEN_OUTPUT and
+// TYPEGEN_HOOK. The output of a failed hook is part of the error.
+func (b *Builder) runHooks(ctx context.Context, taskIndex int, stage string, commands []string) error {
+	if len(commands) == 0 || b.skipHooks {
+		return nil
+	}
+	task := b.config.Generate[taskIndex]
+	b.phaseReporter(taskIndex)(PhaseHooks)
+
+	if err := os.MkdirAll(task.Output, 0755); err != nil {
+		return fmt.Errorf("%w: failed to create output directory: %w", ErrHook, err)
+	}
+	env := append(b.taskEnv(taskIndex), "TYPEGEN_HOOK="+stage)
+	timeout := task.Hooks.timeout()
+
+	for _, command := range commands {
+		start := time.Now()
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		cmd := shellCommand(hookCtx, command)
+		cmd.Dir = task.Output
+		cmd.Env = env
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		// Processes started by the hook may keep its output open after it exits
+		cmd.WaitDelay = time.Second
+
+		err := cmd.Run()
+		timedOut := errors.Is(hookCtx.Err(), context.DeadlineExceeded)
+		cancel()
+		switch {
+		case timedOut:
+			return fmt.Errorf("%w: %s hook %q timed out after %s%s", ErrHook, stage, command, timeout, hookOutput(output.String()))
+		case err != nil:
+			return fmt.Errorf("%w: %s hook %q: %w%s", ErrHook, stage, command, err, hookOutput(output.String()))
+		}
+
+		b.logger.Debug(fmt.Sprintf("ran %s hook %q in %s", stage, command, roundElapsed(time.Since(start))))
+		if text := strings.TrimSpace(output.String()); text != "" {
+			b.trace(fmt.Sprintf("%s hook %q output", stage, command), "output", text)
+		}
+	}
+	return nil
+}
+
+// taskEnv returns the environment of the commands run for the task at
+// taskIndex: the environment of typegen plus TYPEGEN_TASK,
+// TYPEGEN_GENERATOR, TYPEGEN_INPUT and TYPEGEN_OUTPUT
+func (b *Builder) taskEnv(taskIndex int) []string {
+	task := b.config.Generate[taskIndex]
+	return append(os.Environ(),
+		"TYPEGEN_TASK="+b.config.TaskName(taskIndex),
+		"TYPEGEN_GENERATOR="+task.Generator,
+		"TYPEGEN_INPUT="+task.describeInput(),
+		"TYPEGEN_OUTPUT="+task.Output,
+	)
+}
+
+// shellCommand returns the command running a command line with the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	args := shellArgs(command)
+	return exec.CommandContext(ctx, args[0], args[1:]...)
+}
+
+// shellArgs returns the arguments running a command line with the shell
+func shellArgs(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// hookOutput formats the output of a failed hook for its error, indented
+// under it
+func hookOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ""
+	}
+	return "\n    " + strings.ReplaceAll(output, "\n", "\n    ")
+}
@@ -0,0 +1,169 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+)
+
+// hookScript writes a shell script that appends its arguments, working
+// directory and TYPEGEN_ variables to a log file, and fails when its first
+// argument is "fail", and returns the script's path and the log's
+func hookScript(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests use a shell script")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "hooks.log")
+	script := filepath.Join(dir, "hook.sh")
+	content := `#!/bin/sh
+echo "$* cwd=$(pwd) task=$TYPEGEN_TASK generator=$TYPEGEN_GENERATOR output=$TYPEGEN_OUTPUT hook=$TYPEGEN_HOOK" >> "` + log + `"
+if [ "$1" = fail ]; then
+  echo "something went wrong" >&2
+  exit 3
+fi
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return script, log
+}
+
+func TestBuildHooks(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	script, log := hookScript(t)
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n")
+	output := filepath.Join(t.TempDir(), "gen")
+	invalid := t.TempDir()
+	writeFile(t, filepath.Join(invalid, "types.tg"), "struct User {\n  id: Missing\n}\n")
+
+	tests := []struct {
+		name      string
+		input     string
+		hooks     TaskHooks
+		skipHooks bool
+		err       string
+		log       []string
+		generated bool
+	}{
+		{
+			name:  "pre and post hooks",
+			input: input,
+			hooks: TaskHooks{Pre: []string{script + " pre1", script + " pre2"}, Post: []string{script + " post"}},
+			log: []string{
+				"pre1 cwd=" + output + " task=api generator=files output=" + output + " hook=pre",
+				"pre2 cwd=" + output + " task=api generator=files output=" + output + " hook=pre",
+				"post cwd=" + output + " task=api generator=files output=" + output + " hook=post",
+			},
+			generated: true,
+		},
+		{
+			name:  "failed pre hook",
+			input: input,
+			hooks: TaskHooks{Pre: []string{script + " fail", script + " pre2"}, Post: []string{script + " post"}},
+			err:   `hook failed: pre hook "` + script + ` fail": exit status 3` + "\n    something went wrong",
+			log:   []string{"fail cwd=" + output + " task=api generator=files output=" + output + " hook=pre"},
+		},
+		{
+			name:      "failed post hook",
+			input:     input,
+			hooks:     TaskHooks{Post: []string{script + " fail"}},
+			err:       `hook failed: post hook "` + script + ` fail": exit status 3`,
+			log:       []string{"fail cwd=" + output + " task=api generator=files output=" + output + " hook=post"},
+			generated: true,
+		},
+		{
+			name:  "failed generation",
+			input: invalid,
+			hooks: TaskHooks{Pre: []string{script + " pre"}, Post: []string{script + " post"}},
+			err:   "validation failed",
+			log:   []string{"pre cwd=" + output + " task=api generator=files output=" + output + " hook=pre"},
+		},
+		{
+			name:      "skipped hooks",
+			input:     input,
+			hooks:     TaskHooks{Pre: []string{script + " fail"}, Post: []string{script + " fail"}},
+			skipHooks: true,
+			generated: true,
+		},
+		{
+			name:      "timeout",
+			input:     input,
+			hooks:     TaskHooks{Post: []string{"sleep 5"}, Timeout: 50 * time.Millisecond},
+			err:       `hook failed: post hook "sleep 5" timed out after 50ms`,
+			generated: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Remove(log)
+			os.RemoveAll(output)
+			config := &Config{
+				Version:  1,
+				Config:   map[string]string{},
+				Generate: []GenerateTask{{Name: "api", Generator: "files", Input: test.input, Output: output, Config: map[string]string{}, Hooks: test.hooks}},
+			}
+			builder := NewBuilder(config)
+			builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+			builder.SetSkipHooks(test.skipHooks)
+
+			_, err := builder.Build(context.Background())
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.err != "" {
+				if !errors.Is(err, ErrHook) && !errors.Is(err, ErrValidation) {
+					t.Errorf("expected a hook or validation error, got %v", err)
+				}
+				if message := builder.Result().Tasks[0].Error; !strings.Contains(message, test.err) {
+					t.Errorf("expected error containing %q, got %q", test.err, message)
+				}
+			}
+
+			var lines []string
+			if data, err := os.ReadFile(log); err == nil {
+				lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+			}
+			if strings.Join(lines, "\n") != strings.Join(test.log, "\n") {
+				t.Errorf("expected hooks:\n%s\ngot:\n%s", strings.Join(test.log, "\n"), strings.Join(lines, "\n"))
+			}
+			if _, err := os.Stat(filepath.Join(output, "types", "User.txt")); (err == nil) != test.generated {
+				t.Errorf("expected generated %v, got error %v", test.generated, err)
+			}
+		})
+	}
+}
+
+func TestHooksCheck(t *testing.T) {
+	tests := []struct {
+		hooks TaskHooks
+		err   string
+	}{
+		{TaskHooks{Pre: []string{"make schemas"}, Post: []string{"black ."}, Timeout: time.Minute}, ""},
+		{TaskHooks{Post: []string{"black .", " "}}, "post hook 2 is empty"},
+		{TaskHooks{Timeout: -time.Second}, "timeout must be positive"},
+	}
+	for _, test := range tests {
+		err := test.hooks.check()
+		if test.err == "" && err != nil {
+			t.Errorf("unexpected error for %+v: %v", test.hooks, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("expected error containing %q for %+v, got %v", test.err, test.hooks, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+)
+
+// InitOptions control the project created by Init
+type InitOptions struct {
+	// Generator pre-fills a working task for this generator; when empty,
+	// every example task is commented out
+	Generator string
+	// Output is the output directory of the pre-filled task; empty means ./gen/<generator>
+	Output string
+	// Force overwrites existing files
+	Force bool
+}
+
+// SchemasDir is the module directory created by Init
+const SchemasDir = "schemas"
+
+// exampleSchema demonstrates every kind of declaration
+const exampleSchema = `// Example TypeGen schema. Check it with "typegen validate schemas" and
+// generate code for the tasks in typegen.yaml with "typegen build".
+
+// Types from the common submodule (schemas/common) are used as common.Name
+import common
+
+// Constants are shared with every generated language
+const MAX_NAME_LENGTH = 100
+const API_VERSION = "v1"
+
+// Type aliases give a primitive type a domain meaning
+type Email = string
+
+// Enums list the allowed values; variants can carry a payload
+enum Role {
+  admin
+  member
+  guest: common.ID
+}
+
+// Structs group named fields; ?T marks an optional field
+struct User {
+  id: common.ID
+  name: string
+  email: ?Email
+  role: Role
+  tags: []string
+  created_at: datetime
+}
+`
+
+// exampleCommonSchema is imported by exampleSchema
+const exampleCommonSchema = `// Identifier shared by the types of the schemas module
+type ID = int64
+`
+
+// TemplatesDir is the template directory created by Init for the template
+// generator
+const TemplatesDir = "templates"
+
+// exampleTemplate is a starter file.tmpl for the template generator
+const exampleTemplate = `<!-- {{ .Header }} -->
+# {{ .Name }}
+{{ range .Declarations }}
+- {{ .Kind }} ` + "`{{ .Name }}`" + `
+{{- end }}
+`
+
+// Init scaffolds a project in dir: a typegen.yaml with an example task per
+// registered generator, and a schemas module with example declarations, plus
+// a starter template when the template generator is pre-filled. It
+// refuses to overwrite existing files unless options.Force is set, and
+// returns the paths it created.
+func Init(dir string, options InitOptions) ([]string, error) {
+	if options.Generator != "" {
+		// Aliases are written as the generator they name
+		canonical, err := generators.Resolve(options.Generator)
+		if err != nil {
+			return nil, fmt.Errorf("%w\nAvailable generators: %v", err, generators.List())
+		}
+		options.Generator = canonical
+	}
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{filepath.Join(dir, "typegen.yaml"), initConfig(options)},
+		{filepath.Join(dir, SchemasDir, "example.tg"), exampleSchema},
+		{filepath.Join(dir, SchemasDir, "common", "types.tg"), exampleCommonSchema},
+	}
+	if options.Generator == "template" {
+		files = append(files, struct {
+			path    string
+			content string
+		}{filepath.Join(dir, TemplatesDir, "file.tmpl"), exampleTemplate})
+	}
+
+	if !options.Force {
+		var existing []string
+		for _, file := range files {
+			if _, err := os.Stat(file.path); err == nil {
+				existing = append(existing, file.path)
+			}
+		}
+		if len(existing) > 0 {
+			return nil, fmt.Errorf("refusing to overwrite existing files (use -force): %s", strings.Join(existing, ", "))
+		}
+	}
+
+	var created []string
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return created, fmt.Errorf("failed to create directory for %s: %w", file.path, err)
+		}
+		if err := os.WriteFile(file.path, []byte(file.content), 0644); err != nil {
+			return created, fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+		created = append(created, file.path)
+	}
+	return created, nil
+}
+
+// initConfig renders typegen.yaml: the pre-filled task first, then a
+// commented-out example for every other registered generator
+func initConfig(options InitOptions) string {
+	var b strings.Builder
+	b.WriteString("# TypeGen build configuration. Run \"typegen build\" to generate code for every task.\n")
+	b.WriteString("version: 1\n\n")
+	b.WriteString("# Options shared by every task; task options override them\n")
+	b.WriteString("config: {}\n\n")
+	b.WriteString("generate:\n")
+
+	if options.Generator != "" {
+		output := options.Output
+		if output == "" {
+			output = defaultOutput(options.Generator)
+		}
+		b.WriteString(initTask(options.Generator, output, ""))
+	} else {
+		b.WriteString("  # Uncomment a task below, or rerun init with -generator, then run typegen build\n")
+	}
+
+	names := generators.List()
+	sort.Strings(names)
+	for _, name := range names {
+		if name == options.Generator {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(initTask(name, defaultOutput(name), "# "))
+	}
+	return b.String()
+}
+
+// initTask renders one task of the generate list, with every line prefixed by comment
+func initTask(generator, output, comment string) string {
+	lines := []string{
+		"- generator: " + generator,
+		"  input: ./" + SchemasDir,
+		"  output: " + output,
+	}
+
+	config := exampleConfig(generator, output)
+	if len(config) > 0 {
+		lines = append(lines, "  config:")
+		keys := make([]string, 0, len(config))
+		for key := range config {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = append(lines, fmt.Sprintf("    %s: %q", key, config[key]))
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&b, "  %s%s\n", comment, line)
+	}
+	return b.String()
+}
+
+// defaultOutput names the output directory of a generator: ./gen/<generator>
+func defaultOutput(generator string) string {
+	return "./gen/" + strings.NewReplacer("+", "_", "-", "_").Replace(generator)
+}
+
+// exampleConfig returns the options a generator needs to build the example
+// schema. Generators not listed here need none.
+func exampleConfig(generator, output string) map[string]string {
+	// The output directory as a slash-separated path relative to the project
+	rel := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(output)), "/")
+
+	switch generator {
+	case "go":
+		return map[string]string{"module-name": "example.com/project/" + rel}
+	case "python+pydantic":
+		return map[string]string{"module-name": strings.ReplaceAll(strings.Trim(rel, "./"), "/", ".")}
+	case "hack":
+		return map[string]string{"namespace": "Example"}
+	case "cpp":
+		return map[string]string{"namespace": "example"}
+	case "dart":
+		return map[string]string{"package": "example"}
+	case "template":
+		return map[string]string{"template-dir": "./" + TemplatesDir, "file-name": "{{ .Name }}.md"}
+	}
+	return nil
+}
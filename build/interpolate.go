@@ -0,0 +1,220 @@
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+)
+
+// Built-in variables of typegen.yaml. They take precedence over environment
+// variables of the same name.
+const (
+	// VarConfigDir is the absolute path of the directory containing typegen.yaml
+	VarConfigDir = "CONFIG_DIR"
+	// VarModuleName is the module path declared by the go.mod file in the
+	// configuration's directory or its nearest parent
+	VarModuleName = "MODULE_NAME"
+)
+
+// interpolateConfig expands variables in the input, output, cache_dir and
+// remote_cache_dir paths and in the config values, at any depth, of a
+// configuration loaded from configPath
+func (c *Config) interpolateConfig(configPath string) error {
+	configDir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return fmt.Errorf("failed to resolve config directory: %w", err)
+	}
+	lookup := configVariables(configDir)
+
+	if c.TypedConfig != nil {
+		if err := interpolateTyped(c.TypedConfig, "config", lookup); err != nil {
+			return err
+		}
+		c.Config = generators.StringifyConfig(c.TypedConfig)
+	} else if err := interpolateValues(c.Config, "config", lookup); err != nil {
+		return err
+	}
+	for name, block := range c.Generators {
+		if err := interpolateTyped(block, "generators."+name, lookup); err != nil {
+			return err
+		}
+	}
+	if c.CacheDir, err = interpolate(c.CacheDir, lookup); err != nil {
+		return fmt.Errorf("cache_dir: %w", err)
+	}
+	if c.RemoteCacheDir, err = interpolate(c.RemoteCacheDir, lookup); err != nil {
+		return fmt.Errorf("remote_cache_dir: %w", err)
+	}
+
+	for i := range c.Generate {
+		task := &c.Generate[i]
+		if task.Input, err = interpolate(task.Input, lookup); err != nil {
+			return fmt.Errorf("generate task %d: input: %w", i+1, err)
+		}
+		for j := range task.Inputs {
+			if task.Inputs[j], err = interpolate(task.Inputs[j], lookup); err != nil {
+				return fmt.Errorf("generate task %d: input %d: %w", i+1, j+1, err)
+			}
+		}
+		if task.Output, err = interpolate(task.Output, lookup); err != nil {
+			return fmt.Errorf("generate task %d: output: %w", i+1, err)
+		}
+		if task.TypedConfig != nil {
+			if err := interpolateTyped(task.TypedConfig, fmt.Sprintf("generate task %d: config", i+1), lookup); err != nil {
+				return err
+			}
+			task.Config = generators.StringifyConfig(task.TypedConfig)
+		} else if err := interpolateValues(task.Config, fmt.Sprintf("generate task %d: config", i+1), lookup); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// interpolateValues expands variables in the values of a config map, in key
+// order so the first error is always the same
+func interpolateValues(values map[string]string, context string, lookup func(string) (string, bool, error)) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := interpolate(values[key], lookup)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", context, key, err)
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+// configVariables returns the lookup of the variables of a configuration in
+// configDir: the built-in variables, then the environment
+func configVariables(configDir string) func(string) (string, bool, error) {
+	return func(name string) (string, bool, error) {
+		switch name {
+		case VarConfigDir:
+			return configDir, true, nil
+		case VarModuleName:
+			module, err := goModulePath(configDir)
+			if err != nil {
+				return "", false, err
+			}
+			return module, true, nil
+		}
+		value, ok := os.LookupEnv(name)
+		return value, ok, nil
+	}
+}
+
+// interpolate expands the variables of s:
+//
+//   - ${NAME} is the value of NAME, which must be set
+//   - ${NAME:-default} is the value of NAME, or default when NAME is unset or empty
+//   - $$ is a literal $
+//
+// Any other $ is kept as it is, so values without ${ are never changed.
+// Defaults are literal text and are not expanded.
+func interpolate(s string, lookup func(string) (string, bool, error)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			value, err := expandVariable(s[i+2:i+end], lookup)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expandVariable expands the inside of a ${...} reference
+func expandVariable(ref string, lookup func(string) (string, bool, error)) (string, error) {
+	name, fallback, hasDefault := strings.Cut(ref, ":-")
+	if !validVariableName(name) {
+		return "", fmt.Errorf("invalid variable reference ${%s}; names are letters, digits and underscores", ref)
+	}
+
+	// A built-in variable that can't be resolved is unset, for its default
+	value, ok, err := lookup(name)
+	if err != nil && !hasDefault {
+		return "", fmt.Errorf("${%s}: %w", name, err)
+	}
+	if hasDefault && value == "" {
+		return fallback, nil
+	}
+	if !ok {
+		return "", fmt.Errorf("variable %s is not set; set it, or give a default with ${%s:-default}", name, name)
+	}
+	return value, nil
+}
+
+// validVariableName reports whether name is a variable name like those of
+// the shell
+func validVariableName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// goModulePath returns the module path of the go.mod in dir or its nearest
+// parent
+func goModulePath(dir string) (string, error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		file, err := os.Open(filepath.Join(current, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+					module, _, _ := strings.Cut(rest, "//")
+					module = strings.TrimSpace(module)
+					if unquoted, err := strconv.Unquote(module); err == nil {
+						module = unquoted
+					}
+					return module, nil
+				}
+			}
+			return "", fmt.Errorf("no module directive in %s", filepath.Join(current, "go.mod"))
+		}
+		if filepath.Dir(current) == current {
+			return "", fmt.Errorf("no go.mod found in %s or its parents", dir)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]string{"OUT": "/tmp/out", "EMPTY": "", "TAG_1": "v1"}
+	lookup := func(name string) (string, bool, error) {
+		if name == "BROKEN" {
+			return "", false, errors.New("cannot resolve")
+		}
+		value, ok := variables[name]
+		return value, ok, nil
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"./gen/go", "./gen/go", ""},
+		{"${OUT}/go", "/tmp/out/go", ""},
+		{"${OUT}${TAG_1}", "/tmp/outv1", ""},
+		{"${MISSING:-./gen}/go", "./gen/go", ""},
+		{"${EMPTY:-fallback}", "fallback", ""},
+		{"${OUT:-fallback}", "/tmp/out", ""},
+		{"${MISSING:-}", "", ""},
+		{"${EMPTY}", "", ""},
+		{"${BROKEN:-fallback}", "fallback", ""},
+		{"$${OUT}", "${OUT}", ""},
+		{"cost: $$5", "cost: $5", ""},
+		{"^[a-z]+$", "^[a-z]+$", ""},
+		{"$OUT", "$OUT", ""},
+		{"${MISSING}", "", "variable MISSING is not set"},
+		{"${BROKEN}", "", "${BROKEN}: cannot resolve"},
+		{"${OUT", "", "unterminated variable reference"},
+		{"${}", "", "invalid variable reference ${}"},
+		{"${1X}", "", "invalid variable reference ${1X}"},
+		{"${OUT-x}", "", "invalid variable reference ${OUT-x}"},
+	}
+
+	for _, tt := range tests {
+		got, err := interpolate(tt.input, lookup)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("interpolate(%q): expected error containing %q, got %v", tt.input, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("interpolate(%q): unexpected error: %v", tt.input, err)
+		} else if got != tt.expected {
+			t.Errorf("interpolate(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadConfigInterpolation(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/project // the project\n\ngo 1.24\n")
+	if err := os.MkdirAll(filepath.Join(dir, "schemas"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TYPEGEN_TEST_OUT", "/ci/out")
+
+	configPath := filepath.Join(dir, "typegen.yaml")
+	writeFile(t, configPath, `config:
+  package: ${TYPEGEN_TEST_PACKAGE:-models}
+generate:
+  - generator: go
+    input: ${CONFIG_DIR}/schemas
+    output: ${TYPEGEN_TEST_OUT}/go
+    config:
+      module-name: ${MODULE_NAME}/gen/go
+      pattern: ^[a-z]+$$
+`)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	task := config.Generate[0]
+	if task.Input != filepath.Join(dir, "schemas") {
+		t.Errorf("expected input in the config directory, got %s", task.Input)
+	}
+	if task.Output != "/ci/out/go" {
+		t.Errorf("expected output from the environment, got %s", task.Output)
+	}
+	merged := config.MergedConfig(0)
+	expected := map[string]string{"package": "models", "module-name": "example.com/project/gen/go", "pattern": "^[a-z]+$"}
+	for key, value := range expected {
+		if merged[key] != value {
+			t.Errorf("expected config %s=%q, got %q", key, value, merged[key])
+		}
+	}
+}
+
+func TestLoadConfigUndefinedVariable(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	writeFile(t, configPath, "generate:\n  - generator: go\n    output: ${TYPEGEN_TEST_UNDEFINED}/go\n")
+
+	_, err := LoadConfig(configPath)
+	expected := "generate task 1: output: variable TYPEGEN_TEST_UNDEFINED is not set"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error containing %q, got %v", expected, err)
+	}
+}
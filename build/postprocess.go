@@ -0,0 +1,111 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinProcessors are the processors a post_process entry can name, given
+// the module being generated
+var builtinProcessors = map[string]func(module *ast.Module) generators.FileProcessor{
+	"fingerprint": generators.FingerprintProcessor,
+	"gofmt":       func(*ast.Module) generators.FileProcessor { return generators.GofmtProcessor() },
+	"newlines":    func(*ast.Module) generators.FileProcessor { return generators.NewlinesProcessor() },
+}
+
+// PostProcess is an entry of a task's post_process list: a built-in
+// processor, written as its name, or a shell command each generated file
+// is piped through. Entries apply in order, to each file before it's
+// written, so unlike post hooks they also apply to -check.
+type PostProcess struct {
+	// Name is a built-in processor: gofmt, which formats .go files
+	// in-process, newlines, which normalizes line endings, or fingerprint,
+	// which adds the schema fingerprint to the generated header
+	Name string `yaml:"name"`
+	// Exec is a shell command reading a file on its standard input and
+	// writing the processed file on its standard output, such as goimports
+	Exec string `yaml:"exec"`
+	// Match is a glob pattern of the files processed, see
+	// generators.MatchPattern. By default gofmt processes .go files and the
+	// others every file.
+	Match string `yaml:"match"`
+}
+
+// UnmarshalYAML decodes an entry written as the name of a built-in
+// processor, or as a mapping
+func (p *PostProcess) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Name)
+	}
+	type plain PostProcess
+	return node.Decode((*plain)(p))
+}
+
+// String describes the entry in errors and cache keys
+func (p PostProcess) String() string {
+	s := p.Name
+	if p.Exec != "" {
+		s = fmt.Sprintf("exec %q", p.Exec)
+	}
+	if p.Match != "" {
+		s += fmt.Sprintf(" matching %q", p.Match)
+	}
+	return s
+}
+
+// check reports invalid entries
+func (p PostProcess) check() error {
+	switch {
+	case p.Name != "" && p.Exec != "":
+		return fmt.Errorf("set either name or exec, not both")
+	case p.Exec != "":
+		if strings.TrimSpace(p.Exec) == "" {
+			return fmt.Errorf("exec command is empty")
+		}
+	case p.Name == "":
+		return fmt.Errorf("name or exec is required")
+	case builtinProcessors[p.Name] == nil:
+		names := make([]string, 0, len(builtinProcessors))
+		for name := range builtinProcessors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown processor %q; built-in processors are %s, and exec runs a command", p.Name, strings.Join(names, ", "))
+	}
+	return generators.CheckPattern(p.Match)
+}
+
+// processor returns the entry's file processor for the module being
+// generated. env is the environment of exec commands.
+func (p PostProcess) processor(env []string, module *ast.Module) generators.FileProcessor {
+	if p.Exec != "" {
+		return &generators.ExecProcessor{
+			Args:    shellArgs(p.Exec),
+			Pattern: p.Match,
+			Env:     env,
+			Label:   fmt.Sprintf("%q", p.Exec),
+		}
+	}
+	processor := builtinProcessors[p.Name](module)
+	if p.Match != "" {
+		return matchingProcessor{FileProcessor: processor, pattern: p.Match}
+	}
+	return processor
+}
+
+// matchingProcessor applies a built-in processor to the files matching a
+// pattern instead of its own
+type matchingProcessor struct {
+	generators.FileProcessor
+	pattern string
+}
+
+// Match implements generators.FileProcessor.Match
+func (p matchingProcessor) Match(name string) bool {
+	return generators.MatchPattern(p.pattern, name)
+}
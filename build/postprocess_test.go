@@ -0,0 +1,138 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/printer"
+)
+
+func TestLoadConfigPostProcess(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "typegen.yaml")
+	task := func(postProcess string) string {
+		return "generate:\n  - generator: go\n    output: ./gen\n    post_process:\n" + postProcess
+	}
+
+	writeFile(t, configPath, task("      - gofmt\n      - name: newlines\n        match: \"*.md\"\n      - exec: goimports\n"))
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	expected := []PostProcess{{Name: "gofmt"}, {Name: "newlines", Match: "*.md"}, {Exec: "goimports"}}
+	if got := config.Generate[0].PostProcess; len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] || got[2] != expected[2] {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	errorTests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"unknown processor", task("      - gofmt\n      - black\n"), `generate task 1: post_process 2: unknown processor "black"; built-in processors are fingerprint, gofmt, newlines, and exec runs a command`},
+		{"name and exec", task("      - name: gofmt\n        exec: gofmt\n"), "post_process 1: set either name or exec, not both"},
+		{"neither", task("      - match: \"*.go\"\n"), "post_process 1: name or exec is required"},
+		{"invalid pattern", task("      - name: newlines\n        match: \"[a-\"\n"), `post_process 1: invalid pattern "[a-"`},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, configPath, tt.config)
+			if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBuildPostProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("post-processing tests use a shell")
+	}
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	input := t.TempDir()
+	writeFile(t, filepath.Join(input, "types.tg"), "struct User {\n  id: int64\n}\n\nstruct Order {\n  id: int64\n}\n")
+	output := filepath.Join(t.TempDir(), "gen")
+	module, err := parser.ParseModuleToAST(input)
+	if err != nil {
+		t.Fatalf("failed to parse input: %v", err)
+	}
+	fingerprint, err := printer.Fingerprint(module)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		postProcess []PostProcess
+		expected    string
+		err         string
+	}{
+		{
+			name:        "built-in and exec",
+			postProcess: []PostProcess{{Name: "newlines"}, {Exec: `sed "s/int64/$TYPEGEN_GENERATOR/"`, Match: "User.txt"}},
+			expected:    "struct User {\n  id: files\n}\n",
+		},
+		{
+			name:        "fingerprint",
+			postProcess: []PostProcess{{Name: "fingerprint"}},
+			expected:    "// Schema fingerprint: " + fingerprint + "\nstruct User {\n  id: int64\n}\n",
+		},
+		{
+			name:        "not matching",
+			postProcess: []PostProcess{{Exec: "tr a-z A-Z", Match: "*.go"}},
+			expected:    "struct User {\n  id: int64\n}\n",
+		},
+		{
+			name:        "failing processor",
+			postProcess: []PostProcess{{Name: "newlines"}, {Exec: "echo bad >&2; exit 1"}},
+			err:         `post-processing types/User.txt with "echo bad >&2; exit 1": exit status 1` + "\n    bad",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.RemoveAll(output)
+			config := &Config{
+				Version:  1,
+				Config:   map[string]string{},
+				Generate: []GenerateTask{{Name: "api", Generator: "files", Input: input, Output: output, Config: map[string]string{}, PostProcess: test.postProcess}},
+			}
+			builder := NewBuilder(config)
+			builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+
+			_, err := builder.Build(context.Background())
+			if test.err != "" {
+				if !errors.Is(err, ErrGeneration) {
+					t.Errorf("expected a generation error, got %v", err)
+				}
+				if message := builder.Result().Tasks[0].Error; !strings.Contains(message, test.err) {
+					t.Errorf("expected error containing %q, got %q", test.err, message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(output, "types", "User.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "// " + generators.GeneratedHeader + "\n" + test.expected
+			if string(content) != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package build
+
+// Phase is a step of a task, reported to Progress as the task runs
+type Phase string
+
+const (
+	PhaseParsing    Phase = "parsing"
+	PhaseValidating Phase = "validating"
+	PhaseGenerating Phase = "generating"
+	// PhaseHooks is entered by the tasks with hooks when they run them,
+	// before parsing and after generating
+	PhaseHooks Phase = "running hooks"
+)
+
+// Progress receives the progress of the tasks run by BuildTasks and
+// BuildTask, as they run. Tasks are numbered as in build output, starting
+// at 1. The methods are called from the goroutine running the build.
+type Progress interface {
+	// TaskPhase is called when a task enters a phase. Every task starts with
+	// PhaseParsing, even when its module was already parsed, unless it has
+	// pre-hooks, which run in PhaseHooks first.
+	TaskPhase(task int, phase Phase)
+	// FileWritten is called after the task's generator writes a file, with
+	// the number of files written by the task so far
+	FileWritten(task int, path string, count int)
+	// TaskDone is called when a task succeeds or fails
+	TaskDone(result TaskResult)
+}
+
+// SetProgress sets the receiver of task progress, in addition to the log
+func (b *Builder) SetProgress(progress Progress) {
+	b.progress = progress
+}
+
+// phaseReporter returns a function reporting the phases of a task, which
+// does nothing without a Progress
+func (b *Builder) phaseReporter(taskIndex int) func(Phase) {
+	return func(phase Phase) {
+		if b.progress != nil {
+			b.progress.TaskPhase(taskIndex+1, phase)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remotePrefix starts the inputs fetched from a git repository
+const remotePrefix = "git::"
+
+// remotesDir is the directory of the remote cache in the cache directory,
+// when the configuration doesn't set remote_cache_dir
+const remotesDir = "remotes"
+
+// remoteStateFile is the name of the file recording what a checkout of the
+// remote cache holds, next to the checkout
+const remoteStateFile = "remote.json"
+
+// remoteInput is a task input fetched from a git repository, written
+//
+//	git::<repository>[//<subdirectory>][?ref=<ref>]
+//
+// as in git::https://github.com/acme/schemas.git//payments?ref=v1.4.0
+type remoteInput struct {
+	// Repository is the URL or path of the repository, as given to git
+	Repository string
+	// Subdir is the directory of the input in the repository, with slashes,
+	// or "" for its root
+	Subdir string
+	// Ref is the branch, tag or commit to fetch, or "" for the default branch
+	Ref string
+}
+
+// remoteState records the checkout of a remote input, to tell whether it can
+// be updated: only branches move
+type remoteState struct {
+	Repository string    `json:"repository"`
+	Ref        string    `json:"ref"`
+	Commit     string    `json:"commit"`
+	Branch     bool      `json:"branch"`
+	FetchedAt  time.Time `json:"fetched_at"`
+}
+
+// isRemote reports whether input is fetched from a git repository
+func isRemote(input string) bool {
+	return strings.HasPrefix(input, remotePrefix)
+}
+
+// parseRemote parses a remote input
+func parseRemote(input string) (remoteInput, error) {
+	rest := strings.TrimPrefix(input, remotePrefix)
+	var remote remoteInput
+
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		query, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return remoteInput{}, fmt.Errorf("invalid remote input %s: %w", input, err)
+		}
+		for key := range query {
+			if key != "ref" {
+				return remoteInput{}, fmt.Errorf("invalid remote input %s: unknown parameter %q; only ref is supported", input, key)
+			}
+		}
+		remote.Ref = query.Get("ref")
+		rest = rest[:i]
+	}
+
+	// The subdirectory follows a double slash after the scheme's, if any
+	start := 0
+	if i := strings.Index(rest, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(rest[start:], "//"); i >= 0 {
+		remote.Subdir = strings.Trim(rest[start+i+2:], "/")
+		rest = rest[:start+i]
+	}
+	remote.Repository = rest
+
+	if remote.Repository == "" {
+		return remoteInput{}, fmt.Errorf("invalid remote input %s: missing repository URL", input)
+	}
+	if remote.Subdir != "" {
+		clean := path.Clean(remote.Subdir)
+		if clean == ".." || strings.HasPrefix(clean, "../") {
+			return remoteInput{}, fmt.Errorf("invalid remote input %s: subdirectory %s is outside the repository", input, remote.Subdir)
+		}
+		if clean == "." {
+			clean = ""
+		}
+		remote.Subdir = clean
+	}
+	return remote, nil
+}
+
+// key identifies the checkout of the repository at the ref in the remote
+// cache; inputs of different subdirectories share it
+func (r remoteInput) key() string {
+	sum := sha256.Sum256([]byte(r.Repository + "\x00" + r.Ref))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkoutDir returns the directory of the checkout of the repository in the
+// remote cache dir. The checkout is named after the repository, so merged
+// inputs of a repository's root are named after it.
+func (r remoteInput) checkoutDir(cacheDir string) string {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(r.Repository)), ".git")
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "repository"
+	}
+	return filepath.Join(cacheDir, r.key(), name)
+}
+
+// dir returns the input directory in the remote cache dir
+func (r remoteInput) dir(cacheDir string) string {
+	return filepath.Join(r.checkoutDir(cacheDir), filepath.FromSlash(r.Subdir))
+}
+
+// describe returns the repository and ref for messages
+func (r remoteInput) describe() string {
+	if r.Ref == "" {
+		return r.Repository
+	}
+	return r.Repository + "@" + r.Ref
+}
+
+// remoteInputs returns the remote inputs of a task
+func (t GenerateTask) remoteInputs() ([]remoteInput, error) {
+	inputs := t.Inputs
+	if len(inputs) == 0 {
+		inputs = []string{t.Input}
+	}
+	var remotes []remoteInput
+	for _, input := range inputs {
+		if !isRemote(input) {
+			continue
+		}
+		remote, err := parseRemote(input)
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, remote)
+	}
+	return remotes, nil
+}
+
+// fetchInputs fetches the remote inputs of the task at taskIndex into the
+// remote cache, unless they are there already. With update, refs that are
+// branches are fetched again, once per build.
+func (b *Builder) fetchInputs(ctx context.Context, taskIndex int) error {
+	remotes, err := b.config.Generate[taskIndex].remoteInputs()
+	if err != nil {
+		return err
+	}
+	for _, remote := range remotes {
+		if b.fetched[remote.key()] {
+			continue
+		}
+		state, err := readRemoteState(b.config.RemoteCacheDir, remote)
+		switch {
+		case err != nil:
+			b.logger.Info(fmt.Sprintf("Fetching %s...", remote.describe()))
+		case b.update && state.Branch:
+			b.logger.Info(fmt.Sprintf("Updating %s...", remote.describe()))
+		default:
+			b.trace("remote cache hit", "repository", remote.Repository, "ref", remote.Ref, "commit", state.Commit)
+			b.fetched[remote.key()] = true
+			continue
+		}
+
+		start := time.Now()
+		state, err = fetchRemote(ctx, b.config.RemoteCacheDir, remote)
+		if err != nil {
+			return err
+		}
+		b.logger.Debug(fmt.Sprintf("fetched %s at %s in %s", remote.describe(), shortCommit(state.Commit), roundElapsed(time.Since(start))))
+		b.fetched[remote.key()] = true
+	}
+
+	for _, remote := range remotes {
+		if info, err := os.Stat(remote.dir(b.config.RemoteCacheDir)); err != nil || !info.IsDir() {
+			return fmt.Errorf("remote input %s has no directory %s", remote.describe(), remote.Subdir)
+		}
+	}
+	return nil
+}
+
+// readRemoteState reads what the remote cache holds for a remote input. It
+// fails when the checkout is missing or incomplete.
+func readRemoteState(cacheDir string, remote remoteInput) (*remoteState, error) {
+	data, err := os.ReadFile(filepath.Join(cacheDir, remote.key(), remoteStateFile))
+	if err != nil {
+		return nil, err
+	}
+	var state remoteState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(remote.checkoutDir(cacheDir)); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// fetchRemote fetches the ref of a remote input's repository into the remote
+// cache, replacing a previous checkout at once so an interrupted fetch can't
+// leave a partial one. Only the files of the ref's commit are fetched, without
+// history.
+func fetchRemote(ctx context.Context, cacheDir string, remote remoteInput) (*remoteState, error) {
+	state := &remoteState{Repository: remote.Repository, Ref: remote.Ref, FetchedAt: time.Now().UTC()}
+
+	// Branches are told apart from tags and commits by the refs the
+	// repository advertises
+	refspec := remote.Ref
+	if remote.Ref == "" {
+		refspec = "HEAD"
+		state.Branch = true
+	} else {
+		refs, err := runGit(ctx, "", remote, "ls-remote", "--heads", "--tags", remote.Repository, remote.Ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(refs, "\n") {
+			_, name, _ := strings.Cut(line, "\t")
+			switch name {
+			case "refs/heads/" + remote.Ref:
+				refspec = name
+				state.Branch = true
+			case "refs/tags/" + remote.Ref:
+				if !state.Branch {
+					refspec = name
+				}
+			}
+		}
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create remote cache directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(cacheDir, remote.key()+".*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote cache directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	checkout := filepath.Join(tmp, filepath.Base(remote.checkoutDir(cacheDir)))
+	if _, err := runGit(ctx, "", remote, "init", "--quiet", checkout); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, checkout, remote, "fetch", "--quiet", "--depth", "1", remote.Repository, refspec); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, checkout, remote, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return nil, err
+	}
+	commit, err := runGit(ctx, checkout, remote, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	state.Commit = strings.TrimSpace(commit)
+	if err := os.RemoveAll(filepath.Join(checkout, ".git")); err != nil {
+		return nil, fmt.Errorf("failed to write remote cache: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, remoteStateFile), append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write remote cache: %w", err)
+	}
+
+	dir := filepath.Join(cacheDir, remote.key())
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to replace %s in the remote cache: %w", remote.describe(), err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, fmt.Errorf("failed to write remote cache: %w", err)
+	}
+	return state, nil
+}
+
+// runGit runs git in dir, or the working directory when dir is "", and
+// returns its output. Git never prompts for credentials, which would hang
+// the build; its error message is part of the returned error.
+func runGit(ctx context.Context, dir string, remote remoteInput, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("fetching remote input %s requires git, which was not found in PATH; install git or copy the schemas into a local directory", remote.describe())
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("failed to fetch remote input %s: git %s: %s\ncheck the repository URL and ref, and that the repository is reachable with your network access and git credentials", remote.describe(), args[0], message)
+	}
+	return stdout.String(), nil
+}
+
+// shortCommit abbreviates a commit hash for messages
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
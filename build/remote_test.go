@@ -0,0 +1,250 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected remoteInput
+		err      string
+	}{
+		{
+			input:    "git::https://github.com/acme/schemas.git//payments?ref=v1.4.0",
+			expected: remoteInput{Repository: "https://github.com/acme/schemas.git", Subdir: "payments", Ref: "v1.4.0"},
+		},
+		{
+			input:    "git::https://github.com/acme/schemas.git",
+			expected: remoteInput{Repository: "https://github.com/acme/schemas.git"},
+		},
+		{
+			input:    "git::git@github.com:acme/schemas.git//api/v2/?ref=main",
+			expected: remoteInput{Repository: "git@github.com:acme/schemas.git", Subdir: "api/v2", Ref: "main"},
+		},
+		{
+			input:    "git::/srv/git/schemas.git//payments",
+			expected: remoteInput{Repository: "/srv/git/schemas.git", Subdir: "payments"},
+		},
+		{input: "git::?ref=main", err: "missing repository URL"},
+		{input: "git::https://github.com/acme/schemas.git?tag=v1", err: `unknown parameter "tag"`},
+		{input: "git::https://github.com/acme/schemas.git//../other", err: "outside the repository"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			remote, err := parseRemote(test.input)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if remote != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, remote)
+			}
+		})
+	}
+}
+
+func TestRemoteCheckoutDir(t *testing.T) {
+	a, _ := parseRemote("git::https://github.com/acme/schemas.git//payments?ref=v1")
+	b, _ := parseRemote("git::https://github.com/acme/schemas.git//billing?ref=v1")
+	c, _ := parseRemote("git::https://github.com/acme/schemas.git//payments?ref=v2")
+
+	if a.checkoutDir("/cache") != b.checkoutDir("/cache") {
+		t.Errorf("expected subdirectories of a ref to share a checkout, got %s and %s", a.checkoutDir("/cache"), b.checkoutDir("/cache"))
+	}
+	if a.checkoutDir("/cache") == c.checkoutDir("/cache") {
+		t.Errorf("expected refs to have their own checkout, got %s for both", a.checkoutDir("/cache"))
+	}
+	if dir := a.dir("/cache"); filepath.Base(dir) != "payments" || filepath.Base(filepath.Dir(dir)) != "schemas" {
+		t.Errorf("expected the input in schemas/payments of the checkout, got %s", dir)
+	}
+}
+
+func TestLoadConfigRemoteInput(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "schemas", "core"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	remote := "git::https://github.com/acme/schemas.git//payments?ref=v1.4.0"
+	configPath := filepath.Join(root, "typegen.yaml")
+	writeFile(t, configPath, "generate:\n  - generator: go\n    input:\n      - "+remote+"\n      - ./schemas/core\n    output: ./gen\n")
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(root)
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Generate[0].Inputs[0] != remote {
+		t.Errorf("expected the remote input to be kept as is, got %s", config.Generate[0].Inputs[0])
+	}
+	if expected := filepath.Join(root, DefaultCacheDir, "remotes"); config.RemoteCacheDir != expected {
+		t.Errorf("expected remote cache directory %s, got %s", expected, config.RemoteCacheDir)
+	}
+
+	dirs, err := config.inputDirs(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, _ := parseRemote(remote)
+	expected := []string{parsed.dir(config.RemoteCacheDir), filepath.Join(root, "schemas", "core")}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected input directories %v, got %v", expected, dirs)
+	}
+}
+
+// gitRepository creates a bare repository with a payments directory of
+// schemas, tagged v1 and on branch main, and returns its path and a function
+// committing a schema to main
+func gitRepository(t *testing.T) (string, func(name, content string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	work := t.TempDir()
+	bare := filepath.Join(t.TempDir(), "schemas.git")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(name, content string) {
+		t.Helper()
+		writeFile(t, filepath.Join(work, "payments", name), content)
+		git(work, "add", ".")
+		git(work, "commit", "--quiet", "-m", "add "+name)
+		git(work, "push", "--quiet", bare, "main")
+	}
+
+	git(work, "init", "--quiet", "--initial-branch", "main")
+	git(work, "init", "--quiet", "--bare", bare)
+	commit("payment.tg", "struct Payment {\n  id: int64\n}\n")
+	git(work, "tag", "v1")
+	git(work, "push", "--quiet", bare, "v1")
+	return bare, commit
+}
+
+func TestBuildRemoteInput(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	repository, commit := gitRepository(t)
+
+	output := t.TempDir()
+	config := &Config{
+		Version: 1,
+		Generate: []GenerateTask{
+			{Name: "tag", Generator: "files", Input: "git::" + repository + "//payments?ref=v1", Output: filepath.Join(output, "tag")},
+			{Name: "branch", Generator: "files", Input: "git::" + repository + "//payments?ref=main", Output: filepath.Join(output, "branch")},
+		},
+		RemoteCacheDir: filepath.Join(t.TempDir(), "remotes"),
+	}
+	if err := config.applyDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.validate(); err != nil {
+		t.Fatalf("expected remote inputs to be valid before they are fetched, got %v", err)
+	}
+
+	build := func(update bool) string {
+		t.Helper()
+		var logs bytes.Buffer
+		builder := NewBuilder(config)
+		builder.SetLogger(logging.New(&logs, slog.LevelInfo))
+		builder.SetUpdate(update)
+		if _, err := builder.Build(context.Background()); err != nil {
+			t.Fatalf("build failed: %v\n%s", err, logs.String())
+		}
+		return logs.String()
+	}
+	exists := func(task, name string) bool {
+		_, err := os.Stat(filepath.Join(output, task, "types", name+".txt"))
+		return err == nil
+	}
+
+	logs := build(false)
+	if strings.Count(logs, "Fetching ") != 2 {
+		t.Errorf("expected both refs to be fetched, got:\n%s", logs)
+	}
+	if !exists("tag", "Payment") || !exists("branch", "Payment") {
+		t.Fatalf("expected both tasks to generate Payment")
+	}
+
+	// The remote cache is used until the branch is updated
+	commit("refund.tg", "struct Refund {\n  id: int64\n}\n")
+	if logs := build(false); strings.Contains(logs, "Fetching ") || strings.Contains(logs, "Updating ") {
+		t.Errorf("expected the remote cache to be used, got:\n%s", logs)
+	}
+	if exists("branch", "Refund") {
+		t.Errorf("expected the branch not to be updated without update")
+	}
+
+	logs = build(true)
+	if !strings.Contains(logs, "Updating "+repository+"@main") || strings.Contains(logs, "@v1") {
+		t.Errorf("expected only the branch to be updated, got:\n%s", logs)
+	}
+	if !exists("branch", "Refund") {
+		t.Errorf("expected the updated branch to generate Refund")
+	}
+	if exists("tag", "Refund") {
+		t.Errorf("expected the tag not to move")
+	}
+}
+
+func TestBuildRemoteInputErrors(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+	repository, _ := gitRepository(t)
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"missing repository", "git::" + filepath.Join(t.TempDir(), "missing.git"), "check the repository URL and ref"},
+		{"missing ref", "git::" + repository + "?ref=v9", "failed to fetch remote input " + repository + "@v9"},
+		{"missing directory", "git::" + repository + "//billing?ref=v1", "has no directory billing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &Config{
+				Version:        1,
+				Generate:       []GenerateTask{{Generator: "files", Input: test.input, Output: t.TempDir()}},
+				RemoteCacheDir: t.TempDir(),
+			}
+			if err := config.applyDefaults(); err != nil {
+				t.Fatal(err)
+			}
+			builder := NewBuilder(config)
+			builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+			_, err := builder.Build(context.Background())
+			if err == nil {
+				t.Fatal("expected the build to fail")
+			}
+			if message := builder.Result().Tasks[0].Error; !strings.Contains(message, test.err) {
+				t.Errorf("expected error containing %q, got %q", test.err, message)
+			}
+		})
+	}
+}
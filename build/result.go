@@ -0,0 +1,174 @@
+package build
+
+import (
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/diagnostics"
+	"github.com/WhatsApp-Platform/typegen/generators"
+)
+
+// TaskStatus is the outcome of a task
+type TaskStatus string
+
+const (
+	TaskSucceeded TaskStatus = "succeeded"
+	TaskFailed    TaskStatus = "failed"
+	// TaskSkipped marks a task that a TaskFilter didn't select
+	TaskSkipped TaskStatus = "skipped"
+	// TaskCached marks a task that didn't run because the build cache found
+	// its inputs and output unchanged since it last succeeded
+	TaskCached TaskStatus = "cached"
+	// TaskDependencyFailed marks a selected task that didn't run because a
+	// task it depends on failed, or didn't run for the same reason
+	TaskDependencyFailed TaskStatus = "dependency_failed"
+	// TaskInterrupted marks the task that was running when the build's
+	// context was cancelled
+	TaskInterrupted TaskStatus = "interrupted"
+	// TaskNotRun marks a selected task that didn't start because the build's
+	// context was cancelled before its turn
+	TaskNotRun TaskStatus = "not_run"
+)
+
+// ValidationStatus is the outcome of the validation of a task's input
+type ValidationStatus string
+
+const (
+	ValidationPassed ValidationStatus = "passed"
+	ValidationFailed ValidationStatus = "failed"
+	// ValidationSkipped marks a task whose validation settings skip validation
+	ValidationSkipped ValidationStatus = "skipped"
+)
+
+// BuildResult is the outcome of BuildTasks. It is written as JSON by
+// typegen build -report, so its fields are the report schema:
+//
+//	{
+//	  "version": "v1.2.0",
+//	  "started_at": "2026-01-02T15:04:05Z",
+//	  "duration_ms": 48.2,
+//	  "warnings": ["tasks payments-go and hack-2 share the output directory ..."],
+//	  "tasks": [
+//	    {
+//	      "task": 1,
+//	      "name": "payments-go",
+//	      "description": "Go models for the payments service",
+//	      "generator": "go",
+//	      "input": "/project/schemas",
+//	      "output": "/project/gen/go",
+//	      "status": "succeeded",
+//	      "validation": "passed",
+//	      "diagnostics": [{"severity": "warning", "rule": "naming_convention", "file": "user.tg", "line": 3, "column": 3, "message": "..."}],
+//	      "duration_ms": 12.5,
+//	      "files": [{"path": "models/order.go", "bytes": 640, "unchanged": true}, {"path": "models/user.go", "bytes": 812}],
+//	      "dirs": ["models"]
+//	    },
+//	    {"task": 2, ..., "status": "failed", "error": "validation failed with 1 errors: ...", "files": [], "dirs": []}
+//	  ],
+//	  "removed": ["/project/gen/go/legacy.go"],
+//	  "errors": [],
+//	  "summary": {"tasks": 2, "succeeded": 1, "failed": 1, "skipped": 0, "cached": 0, "dependency_failed": 0, "interrupted": 0, "not_run": 0, "files": 2, "unchanged": 1, "bytes": 1452}
+//	}
+//
+// Lists are never null, so consumers can iterate them without checks.
+type BuildResult struct {
+	// Version is the typegen version that ran the build
+	Version    string    `json:"version"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS float64   `json:"duration_ms"`
+	// Warnings are the configuration's warnings, see Config.Warnings
+	Warnings []string     `json:"warnings"`
+	Tasks    []TaskResult `json:"tasks"`
+	// Removed are the stale files removed by cleaning, or that would be with a dry run
+	Removed []string `json:"removed"`
+	// Errors are the failures that don't belong to a task, such as cleaning errors
+	Errors  []string     `json:"errors"`
+	Summary BuildSummary `json:"summary"`
+}
+
+// TaskResult is the outcome of one task of the configuration
+type TaskResult struct {
+	// Task is the task number as shown in build output, starting at 1
+	Task int `json:"task"`
+	// Name is the task's name, see Config.TaskName
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Generator   string     `json:"generator"`
+	Input       string     `json:"input"`
+	Output      string     `json:"output"`
+	Status      TaskStatus `json:"status"`
+	DurationMS  float64    `json:"duration_ms"`
+	// Validation is the outcome of validating the task's input, empty if the
+	// task didn't get that far
+	Validation ValidationStatus `json:"validation,omitempty"`
+	// Error is the text of the task's error, including validation errors
+	Error string `json:"error,omitempty"`
+	// Diagnostics are the validation errors of the task's input, then its
+	// warnings, each ordered by file and position. When the input fails to
+	// parse, they are the syntax errors of every file instead.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Files are the files written, relative to Output and ordered by path;
+	// those whose content didn't change are marked unchanged. For a cached
+	// task, they are the files written when it last ran.
+	Files []generators.WrittenFile `json:"files"`
+	// Dirs are the directories holding the files or created by the
+	// generator, and their parents, relative to Output and ordered by path.
+	// For a cached task, they are the directories of its files.
+	Dirs []string `json:"dirs"`
+}
+
+// Diagnostic is a syntax error, or a validation error or warning, of a
+// task's input
+type Diagnostic = diagnostics.Diagnostic
+
+// BuildSummary totals the task results
+type BuildSummary struct {
+	Tasks     int `json:"tasks"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+	Cached    int `json:"cached"`
+	// DependencyFailed counts the tasks that didn't run because a
+	// dependency failed
+	DependencyFailed int `json:"dependency_failed"`
+	Interrupted      int `json:"interrupted"`
+	NotRun           int `json:"not_run"`
+	Files            int `json:"files"`
+	// Unchanged counts the files written with the content they already had
+	Unchanged int `json:"unchanged"`
+	Bytes     int `json:"bytes"`
+}
+
+// summarize totals the task results into the summary
+func (r *BuildResult) summarize() {
+	r.Summary = BuildSummary{Tasks: len(r.Tasks)}
+	for _, task := range r.Tasks {
+		switch task.Status {
+		case TaskSucceeded:
+			r.Summary.Succeeded++
+		case TaskFailed:
+			r.Summary.Failed++
+		case TaskSkipped:
+			r.Summary.Skipped++
+		case TaskCached:
+			r.Summary.Cached++
+		case TaskDependencyFailed:
+			r.Summary.DependencyFailed++
+		case TaskInterrupted:
+			r.Summary.Interrupted++
+		case TaskNotRun:
+			r.Summary.NotRun++
+		}
+		r.Summary.Files += len(task.Files)
+		for _, file := range task.Files {
+			r.Summary.Bytes += file.Bytes
+			if file.Unchanged {
+				r.Summary.Unchanged++
+			}
+		}
+	}
+}
+
+// milliseconds converts a duration for the report
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
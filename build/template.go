@@ -0,0 +1,64 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Placeholders of output paths, expanded for each task by applyDefaults
+const (
+	// placeholderGenerator is the task's generator
+	placeholderGenerator = "generator"
+	// placeholderTask is the task's name, given or automatic
+	placeholderTask = "task"
+	// placeholderModule is the name of the task's input directory
+	placeholderModule = "module"
+)
+
+// expandOutput returns the output path of the task at taskIndex with its
+// placeholders expanded: {generator}, {task} and {module}. {module} needs a
+// single input directory, so tasks merging several inputs can't use it. The
+// task's name and input must already have their defaults.
+func (c *Config) expandOutput(taskIndex int) (string, error) {
+	task := c.Generate[taskIndex]
+	if !strings.Contains(task.Output, "{") {
+		return task.Output, nil
+	}
+
+	var b strings.Builder
+	rest := task.Output
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", task.Output)
+		}
+		b.WriteString(rest[:start])
+
+		name := rest[start+1 : start+end]
+		switch name {
+		case placeholderGenerator:
+			b.WriteString(task.Generator)
+		case placeholderTask:
+			b.WriteString(task.Name)
+		case placeholderModule:
+			if task.merged() {
+				return "", fmt.Errorf("{%s} needs a single input directory, but the task merges %s", placeholderModule, task.describeInput())
+			}
+			dir, err := c.localInput(task.Input)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(filepath.Base(dir))
+		default:
+			return "", fmt.Errorf("unknown placeholder {%s} in %q; placeholders are {%s}, {%s} and {%s}",
+				name, task.Output, placeholderGenerator, placeholderTask, placeholderModule)
+		}
+		rest = rest[start+end+1:]
+	}
+}
@@ -0,0 +1,69 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigOutputPlaceholders(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"schemas/payments", "schemas/users", "base"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		yaml   string
+		output string
+		err    string
+	}{
+		{"generator", "  - generator: go\n    input: ./schemas/payments\n    output: ./gen/{generator}\n", "gen/go", ""},
+		{"task", "  - name: api\n    generator: go\n    input: ./schemas/payments\n    output: ./gen/{task}\n", "gen/api", ""},
+		{"automatic task name", "  - generator: go\n    input: ./schemas/payments\n    output: ./gen/{task}\n", "gen/go-1", ""},
+		{"module", "  - generator: go\n    input: ./schemas/payments\n    output: ./gen/{generator}/{module}\n", "gen/go/payments", ""},
+		{"module of the default input", "  - generator: go\n    output: ./gen/{module}\n", "gen/" + filepath.Base(root), ""},
+		{"module of a remote input", "  - generator: go\n    input: git::https://example.com/org/payments.git//schemas/v1\n    output: ./gen/{module}\n", "gen/v1", ""},
+		{"module of a repository", "  - generator: go\n    input: git::https://example.com/org/payments.git\n    output: ./gen/{module}\n", "gen/payments", ""},
+		{"absolute output", "  - generator: go\n    input: ./schemas/users\n    output: ${CONFIG_DIR}/gen/{module}\n", "gen/users", ""},
+		{"extended output", "  - generator: go\n    input: ../schemas/payments\n    output: ./gen/{generator}/{module}\n", "base/gen/go/payments", ""},
+		{"unknown placeholder", "  - generator: go\n    input: ./schemas/payments\n    output: ./gen/{language}\n", "", "generate task 1: output: unknown placeholder {language} in \"" + filepath.Join(root, "gen", "{language}") + "\"; placeholders are {generator}, {task} and {module}"},
+		{"unterminated placeholder", "  - generator: go\n    input: ./schemas/payments\n    output: ./gen/{module\n", "", "unterminated placeholder in \"" + filepath.Join(root, "gen", "{module") + "\""},
+		{"module of merged inputs", "  - generator: go\n    input: ./schemas/*\n    output: ./gen/{module}\n", "", "{module} needs a single input directory, but the task merges"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(root, "typegen.yaml")
+			content := "generate:\n" + tt.yaml
+			if tt.name == "extended output" {
+				// Relative outputs of extended files are relative to them,
+				// placeholders included
+				writeFile(t, filepath.Join(root, "base", "typegen.yaml"), content)
+				content = "extends: base/typegen.yaml\n"
+			}
+			writeFile(t, configPath, content)
+
+			oldWd, _ := os.Getwd()
+			defer os.Chdir(oldWd)
+			os.Chdir(root)
+
+			config, err := LoadConfig(configPath)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expected := filepath.Join(root, tt.output); config.Generate[0].Output != expected {
+				t.Errorf("expected output %s, got %s", expected, config.Generate[0].Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"gopkg.in/yaml.v3"
+)
+
+// typedConfigVersion is the first configuration version whose config values
+// can be any YAML value, and which has generators blocks
+const typedConfigVersion = 2
+
+// decodeConfigValues decodes a config block of a file of the given version.
+// Version 1 values are strings; from version 2 they are any YAML value,
+// returned both as they are and turned into strings.
+func decodeConfigValues(node *yaml.Node, version int) (map[string]string, map[string]any, error) {
+	if node == nil || node.Kind == 0 {
+		return nil, nil, nil
+	}
+	if version < typedConfigVersion {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if value := node.Content[i+1]; value.Kind != yaml.ScalarNode {
+					return nil, nil, fmt.Errorf("line %d: config %s: lists and maps require version: %d", value.Line, node.Content[i].Value, typedConfigVersion)
+				}
+			}
+		}
+		var values map[string]string
+		if err := node.Decode(&values); err != nil {
+			return nil, nil, err
+		}
+		return values, nil, nil
+	}
+
+	var raw map[string]any
+	if err := node.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+	typed, err := normalizeValue(raw, fmt.Sprintf("line %d: config", node.Line))
+	if err != nil {
+		return nil, nil, err
+	}
+	values, _ := typed.(map[string]any)
+	return generators.StringifyConfig(values), values, nil
+}
+
+// normalizeValue returns a decoded YAML value with its maps as
+// map[string]any, so they can be merged and written as JSON. Maps with keys
+// that aren't strings are an error.
+func normalizeValue(value any, path string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			item, err := normalizeValue(item, path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			normalized[key] = item
+		}
+		return normalized, nil
+	case map[any]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: key %v is not a string", path, key)
+			}
+			item, err := normalizeValue(item, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			normalized[name] = item
+		}
+		return normalized, nil
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			item, err := normalizeValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			normalized[i] = item
+		}
+		return normalized, nil
+	}
+	return value, nil
+}
+
+// typedValues returns string config values as typed ones
+func typedValues(values map[string]string) map[string]any {
+	typed := make(map[string]any, len(values))
+	for key, value := range values {
+		typed[key] = value
+	}
+	return typed
+}
+
+// mergeValues deep-merges over into a copy of base: maps are merged key by
+// key, and any other value of over, lists included, replaces the one of base
+func mergeValues(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range over {
+		overMap, overIsMap := value.(map[string]any)
+		baseMap, baseIsMap := merged[key].(map[string]any)
+		if overIsMap && baseIsMap {
+			merged[key] = mergeValues(baseMap, overMap)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// interpolateTyped expands variables in the strings of typed config values,
+// at any depth, in key order so the first error is always the same
+func interpolateTyped(values map[string]any, context string, lookup func(string) (string, bool, error)) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := interpolateValue(values[key], context+"."+key, lookup)
+		if err != nil {
+			return err
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+func interpolateValue(value any, context string, lookup func(string) (string, bool, error)) (any, error) {
+	switch v := value.(type) {
+	case string:
+		expanded, err := interpolate(v, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", context, err)
+		}
+		return expanded, nil
+	case map[string]any:
+		return v, interpolateTyped(v, context, lookup)
+	case []any:
+		for i, item := range v {
+			expanded, err := interpolateValue(item, fmt.Sprintf("%s[%d]", context, i), lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+// globalTypedConfig returns the global config values as typed ones
+func (c *Config) globalTypedConfig() map[string]any {
+	if c.TypedConfig != nil {
+		return c.TypedConfig
+	}
+	return typedValues(c.Config)
+}
+
+// MergedTypedConfig returns the typed configuration of the task at
+// taskIndex: the global config, the generators block of the task's
+// generator and the task's config, deep-merged in that order. Maps are
+// merged key by key, so a task can set one key of a nested map; any other
+// value, lists included, replaces the one before. The values of version 1
+// configurations are strings.
+func (c *Config) MergedTypedConfig(taskIndex int) map[string]any {
+	if taskIndex < 0 || taskIndex >= len(c.Generate) {
+		return nil
+	}
+	task := c.Generate[taskIndex]
+
+	merged := mergeValues(nil, c.globalTypedConfig())
+	merged = mergeValues(merged, c.Generators[task.Generator])
+	if task.TypedConfig != nil {
+		return mergeValues(merged, task.TypedConfig)
+	}
+	return mergeValues(merged, typedValues(task.Config))
+}
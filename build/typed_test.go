@@ -0,0 +1,221 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/logging"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// loadConfigIn writes the given files into a temporary directory and loads
+// its typegen.yaml from there
+func loadConfigIn(t *testing.T, files map[string]string) (*Config, error) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "schemas"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		writeFile(t, filepath.Join(root, name), content)
+	}
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	os.Chdir(root)
+	return LoadConfig(filepath.Join(root, "typegen.yaml"))
+}
+
+func TestLoadConfigTypedConfig(t *testing.T) {
+	config, err := loadConfigIn(t, map[string]string{"typegen.yaml": `version: 2
+config:
+  strict: true
+  imports:
+    time: example.com/time
+    uuid: example.com/uuid
+generators:
+  go:
+    tags: [json, db]
+    imports:
+      uuid: example.com/go/uuid
+generate:
+  - generator: go
+    input: ./schemas
+    output: ./gen/go
+    config:
+      package: models
+      max-depth: 3
+      tags: [json]
+      imports:
+        decimal: example.com/decimal
+  - generator: python+pydantic
+    input: ./schemas
+    output: ./gen/python
+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]any{
+		"strict":    true,
+		"package":   "models",
+		"max-depth": 3,
+		"tags":      []any{"json"},
+		"imports": map[string]any{
+			"time":    "example.com/time",
+			"uuid":    "example.com/go/uuid",
+			"decimal": "example.com/decimal",
+		},
+	}
+	if got := config.MergedTypedConfig(0); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected merged typed config %v, got %v", expected, got)
+	}
+
+	// The generators block only applies to the tasks of its generator
+	expected = map[string]any{
+		"strict":  true,
+		"imports": map[string]any{"time": "example.com/time", "uuid": "example.com/uuid"},
+	}
+	if got := config.MergedTypedConfig(1); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected merged typed config %v, got %v", expected, got)
+	}
+
+	merged := config.MergedConfig(0)
+	if merged["strict"] != "true" || merged["max-depth"] != "3" || merged["tags"] != `["json"]` {
+		t.Errorf("expected the merged config as strings, got %v", merged)
+	}
+	if config.Config["strict"] != "true" {
+		t.Errorf("expected the global config as strings, got %v", config.Config)
+	}
+}
+
+func TestLoadConfigVersion1Config(t *testing.T) {
+	config, err := loadConfigIn(t, map[string]string{"typegen.yaml": `config:
+  ratio: 1.0
+  strict: yes
+generate:
+  - generator: go
+    input: ./schemas
+    output: ./gen
+    config:
+      seed: 042
+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"ratio": "1.0", "strict": "yes", "seed": "042"}
+	if got := config.MergedConfig(0); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected values as written, got %v", got)
+	}
+	if config.TypedConfig != nil || config.Generate[0].TypedConfig != nil {
+		t.Errorf("expected no typed config in version 1")
+	}
+
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"nested value", "config:\n  imports:\n    time: example.com/time\ngenerate:\n  - generator: go\n    input: ./schemas\n    output: ./gen\n", "config imports: lists and maps require version: 2"},
+		{"task list", "generate:\n  - generator: go\n    input: ./schemas\n    output: ./gen\n    config:\n      tags: [json]\n", "config tags: lists and maps require version: 2"},
+		{"generators block", "generators:\n  go:\n    package: models\ngenerate:\n  - generator: go\n    input: ./schemas\n    output: ./gen\n", "generators blocks require version: 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadConfigIn(t, map[string]string{"typegen.yaml": test.yaml})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExtendsTypedConfig(t *testing.T) {
+	config, err := loadConfigIn(t, map[string]string{
+		"base.yaml": `version: 2
+config:
+  imports:
+    time: example.com/time
+    uuid: example.com/uuid
+generators:
+  go:
+    tags: [json]
+`,
+		"typegen.yaml": `version: 2
+extends: base.yaml
+config:
+  imports:
+    uuid: ${CONFIG_DIR}/uuid
+generators:
+  go:
+    strict: true
+generate:
+  - generator: go
+    input: ./schemas
+    output: ./gen
+`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	merged := config.MergedTypedConfig(0)
+	imports, _ := merged["imports"].(map[string]any)
+	if imports["time"] != "example.com/time" || !strings.HasSuffix(imports["uuid"].(string), "/uuid") || strings.Contains(imports["uuid"].(string), "$") {
+		t.Errorf("expected the nested imports to be merged and expanded, got %v", imports)
+	}
+	if !reflect.DeepEqual(merged["tags"], []any{"json"}) || merged["strict"] != true {
+		t.Errorf("expected the generators blocks to be merged, got %v", merged)
+	}
+}
+
+// TypedMockGenerator records the typed config it receives
+type TypedMockGenerator struct {
+	MockGenerator
+	typed map[string]any
+}
+
+func (g *TypedMockGenerator) SetConfigTyped(config map[string]any) {
+	g.typed = config
+}
+
+func (g *TypedMockGenerator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	return nil
+}
+
+func TestBuildTypedConfig(t *testing.T) {
+	typed := &TypedMockGenerator{}
+	plain := &MockGenerator{}
+	registerGenerator(t, "typed-mock", func() generators.Generator { return typed })
+	registerGenerator(t, "plain-mock", func() generators.Generator { return plain })
+
+	input := t.TempDir()
+	config := &Config{
+		Version:     2,
+		Config:      map[string]string{"strict": "true"},
+		TypedConfig: map[string]any{"strict": true},
+		Generate: []GenerateTask{
+			{Generator: "typed-mock", Input: input, Output: t.TempDir(), TypedConfig: map[string]any{"tags": []any{"json"}}},
+			{Generator: "plain-mock", Input: input, Output: t.TempDir(), TypedConfig: map[string]any{"tags": []any{"json"}}},
+		},
+	}
+	builder := NewBuilder(config)
+	builder.SetLogger(logging.New(&bytes.Buffer{}, slog.LevelInfo))
+	if _, err := builder.Build(context.Background()); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+
+	if expected := map[string]any{"strict": true, "tags": []any{"json"}}; !reflect.DeepEqual(typed.typed, expected) {
+		t.Errorf("expected typed config %v, got %v", expected, typed.typed)
+	}
+	if expected := map[string]string{"strict": "true", "tags": `["json"]`}; !reflect.DeepEqual(plain.config, expected) {
+		t.Errorf("expected config as strings %v, got %v", expected, plain.config)
+	}
+}
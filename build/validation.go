@@ -0,0 +1,95 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// ValidationConfig configures the validation of task inputs. The validation
+// block of a task is merged over the global one.
+type ValidationConfig struct {
+	// Skip generates code without validating the input
+	Skip *bool `yaml:"skip"`
+	// FailOn is the severity that fails the task: error, the default, or
+	// warning to fail on warnings too
+	FailOn string `yaml:"fail_on"`
+	// Rules set the severity of validation rules by name: error, warning or
+	// off; see validator.AllRules
+	Rules map[string]string `yaml:"rules"`
+	// UnusedTypes is where the unused_type rule, when on, reports
+	// unreferenced types: submodules, the default, or all
+	UnusedTypes string `yaml:"unused_types"`
+}
+
+// Values of ValidationConfig.FailOn
+const (
+	FailOnError   = "error"
+	FailOnWarning = "warning"
+)
+
+// MergedValidation returns the effective validation settings of the task at
+// index: its own settings merged over the global ones, with Skip and FailOn
+// always set. FailOn is warning for strict tasks.
+func (c *Config) MergedValidation(index int) ValidationConfig {
+	skip := false
+	merged := ValidationConfig{Skip: &skip, FailOn: FailOnError, Rules: make(map[string]string)}
+	merged.merge(c.Validation)
+	merged.merge(c.Generate[index].Validation)
+	if c.Generate[index].Strict {
+		merged.FailOn = FailOnWarning
+	}
+	return merged
+}
+
+// merge sets the settings that other sets
+func (v *ValidationConfig) merge(other ValidationConfig) {
+	if other.Skip != nil {
+		skip := *other.Skip
+		v.Skip = &skip
+	}
+	if other.FailOn != "" {
+		v.FailOn = other.FailOn
+	}
+	if other.UnusedTypes != "" {
+		v.UnusedTypes = other.UnusedTypes
+	}
+	if len(other.Rules) > 0 && v.Rules == nil {
+		v.Rules = make(map[string]string)
+	}
+	for rule, severity := range other.Rules {
+		v.Rules[rule] = severity
+	}
+}
+
+// check reports invalid settings
+func (v ValidationConfig) check() error {
+	if v.FailOn != "" && v.FailOn != FailOnError && v.FailOn != FailOnWarning {
+		return fmt.Errorf("fail_on must be %s or %s, got %q", FailOnError, FailOnWarning, v.FailOn)
+	}
+	if _, err := validator.ParseRules(v.Rules); err != nil {
+		return fmt.Errorf("rules: %w", err)
+	}
+	if v.UnusedTypes != "" {
+		if _, err := validator.ParseUnusedTypeScope(v.UnusedTypes); err != nil {
+			return fmt.Errorf("unused_types: %w", err)
+		}
+	}
+	return nil
+}
+
+// validationKey identifies the rule severities and the unused type scope of
+// a validation in the validation cache
+func validationKey(settings ValidationConfig) string {
+	keys := make([]string, 0, len(settings.Rules)+1)
+	for rule, severity := range settings.Rules {
+		keys = append(keys, rule+"="+severity)
+	}
+	sort.Strings(keys)
+	if settings.UnusedTypes != "" {
+		keys = append(keys, "unused_types="+settings.UnusedTypes)
+	}
+	return strings.Join(keys, " ")
+}
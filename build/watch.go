@@ -0,0 +1,294 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/logging"
+)
+
+const (
+	// DefaultDebounce is how long the watcher waits after the last change before rebuilding
+	DefaultDebounce = 250 * time.Millisecond
+	// DefaultPollInterval is how often the watcher scans the input directories
+	DefaultPollInterval = 100 * time.Millisecond
+)
+
+// Watcher rebuilds the tasks of a configuration whenever their input changes.
+//
+// Changes are detected by scanning the .tg files of every task input (skipping
+// the directories the parser skips) plus the configuration file itself. Bursts
+// of changes are debounced, and only the tasks whose input changed are
+// rebuilt; a change to the configuration reloads it and rebuilds every task.
+type Watcher struct {
+	// Debounce is the quiet period after the last change before rebuilding
+	Debounce time.Duration
+	// PollInterval is the time between scans of the watched files
+	PollInterval time.Duration
+	// Logger receives the rebuild summaries, at info level or error level
+	// for failures, and is passed to the builders
+	Logger *slog.Logger
+	// SkipHooks makes the rebuilds generate code without running the tasks'
+	// hooks
+	SkipHooks bool
+
+	configPath string
+	config     *Config
+
+	files     map[string]fileState // last scan of the watched files
+	pending   map[string]bool      // changed files waiting for the debounce to expire
+	lastEvent time.Time
+}
+
+// fileState identifies the version of a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewWatcher loads the configuration and records the current state of the
+// watched files. Progress is written to out, unless Logger is replaced.
+func NewWatcher(configPath string, out io.Writer) (*Watcher, error) {
+	if configPath == "" {
+		configPath = "typegen.yaml"
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		Debounce:     DefaultDebounce,
+		PollInterval: DefaultPollInterval,
+		Logger:       logging.New(out, slog.LevelInfo),
+		configPath:   configPath,
+		config:       config,
+		pending:      make(map[string]bool),
+	}
+	w.files = w.scan()
+	return w, nil
+}
+
+// Run builds every task once, then rebuilds on changes until ctx is done.
+// Failed builds are reported and watching continues.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.warn()
+	w.rebuild(ctx, w.allTasks(), "initial build")
+	w.Logger.Info("Watching for changes (press Ctrl-C to stop)...")
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			w.poll(now)
+			w.flush(ctx, now)
+		}
+	}
+}
+
+// poll scans the watched files and queues the ones that changed since the last scan
+func (w *Watcher) poll(now time.Time) {
+	files := w.scan()
+
+	changed := false
+	for path, state := range files {
+		if previous, exists := w.files[path]; !exists || previous != state {
+			w.pending[path] = true
+			changed = true
+		}
+	}
+	for path := range w.files {
+		if _, exists := files[path]; !exists {
+			w.pending[path] = true
+			changed = true
+		}
+	}
+
+	w.files = files
+	if changed {
+		w.lastEvent = now
+	}
+}
+
+// flush rebuilds the tasks affected by the queued changes once no change has
+// been seen for the debounce period. It reports whether a rebuild ran.
+func (w *Watcher) flush(ctx context.Context, now time.Time) bool {
+	if len(w.pending) == 0 || now.Sub(w.lastEvent) < w.Debounce {
+		return false
+	}
+
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	w.pending = make(map[string]bool)
+
+	reason := describeChanges(paths)
+
+	if w.pendingConfig(paths) {
+		config, err := LoadConfig(w.configPath)
+		if err == nil {
+			w.config = config
+			w.warn()
+			// The inputs may have changed, so start watching the new set of files
+			w.files = w.scan()
+			w.rebuild(ctx, w.allTasks(), reason)
+			return true
+		}
+		// Keep the previous configuration and rebuild any input changes with it
+		w.Logger.Error(fmt.Sprintf("[%s] ❌ failed to reload %s: %v", now.Format("15:04:05"), w.configPath, err))
+	}
+
+	tasks := w.affectedTasks(paths)
+	if len(tasks) == 0 {
+		return false
+	}
+	w.rebuild(ctx, tasks, reason)
+	return true
+}
+
+// rebuild runs the given tasks with a fresh builder, so modules are re-parsed,
+// and prints a one-line summary followed by any errors
+func (w *Watcher) rebuild(ctx context.Context, tasks []int, reason string) {
+	start := time.Now()
+	builder := NewBuilder(w.config)
+	builder.SetLogger(w.Logger)
+	builder.SetSkipHooks(w.SkipHooks)
+
+	// Tasks depending on a rebuilt task are rebuilt after it
+	tasks = w.config.withDependents(tasks)
+	failed := make(map[int]bool)
+	var errs []error
+	for _, task := range tasks {
+		if dependency := w.config.failedDependency(task, func(i int) bool { return failed[i] }); dependency != "" {
+			failed[task] = true
+			errs = append(errs, fmt.Errorf("task %s: skipped (dependency failed): %s did not succeed", w.config.TaskName(task), dependency))
+			continue
+		}
+		if err := builder.BuildTask(ctx, task); err != nil {
+			failed[task] = true
+			errs = append(errs, err)
+		}
+	}
+
+	elapsed := roundElapsed(time.Since(start))
+	timestamp := start.Format("15:04:05")
+	if len(errs) == 0 {
+		w.Logger.Info(fmt.Sprintf("[%s] ✅ %s: rebuilt %d/%d tasks in %s",
+			timestamp, reason, len(tasks), len(w.config.Generate), elapsed))
+		return
+	}
+
+	w.Logger.Error(fmt.Sprintf("[%s] ❌ %s: %d of %d tasks failed in %s",
+		timestamp, reason, len(errs), len(tasks), elapsed))
+	for _, err := range errs {
+		w.Logger.Error(fmt.Sprintf("  - %v", err))
+	}
+}
+
+// scan returns the state of the configuration file, of the files it
+// extends and of every .tg file under the task inputs
+func (w *Watcher) scan() map[string]fileState {
+	files := make(map[string]fileState)
+
+	for _, path := range append([]string{w.configPath}, w.config.extended...) {
+		if info, err := os.Stat(path); err == nil {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	for i := range w.config.Generate {
+		// Glob patterns are expanded again, so new matching directories are watched
+		dirs, _ := w.config.inputDirs(i)
+		for _, dir := range dirs {
+			w.scanInput(dir, w.config.Generate[i].Exclude, files)
+		}
+	}
+
+	return files
+}
+
+// scanInput adds the state of the .tg files of an input directory that the
+// parser parses to files
+func (w *Watcher) scanInput(input string, exclude []string, files map[string]fileState) {
+	exclusions, err := w.config.parseOptions(input, exclude).Exclusions(input)
+	if err != nil {
+		return
+	}
+
+	// Unreadable entries are skipped; they are reported by the next build
+	walkInput(input, exclusions, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+}
+
+// warn logs the warnings of the configuration
+func (w *Watcher) warn() {
+	for _, warning := range w.config.Warnings() {
+		w.Logger.Warn("⚠️  " + warning)
+	}
+}
+
+// pendingConfig reports whether the configuration file, or a file it
+// extends, is among the changed paths
+func (w *Watcher) pendingConfig(paths []string) bool {
+	for _, path := range paths {
+		if path == w.configPath || slices.Contains(w.config.extended, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// affectedTasks returns the indexes of the tasks whose input contains any of the paths
+func (w *Watcher) affectedTasks(paths []string) []int {
+	var tasks []int
+	for i, task := range w.config.Generate {
+		// Directories matched by a pattern come and go, so any path under the
+		// fixed part of the pattern counts
+		roots := task.inputRoots()
+		if slices.ContainsFunc(paths, func(path string) bool {
+			return slices.ContainsFunc(roots, func(root string) bool { return within(root, path) })
+		}) {
+			tasks = append(tasks, i)
+		}
+	}
+	return tasks
+}
+
+// allTasks returns the indexes of every task
+func (w *Watcher) allTasks() []int {
+	tasks := make([]int, len(w.config.Generate))
+	for i := range tasks {
+		tasks[i] = i
+	}
+	return tasks
+}
+
+// describeChanges summarizes the changed paths for the rebuild summary
+func describeChanges(paths []string) string {
+	if len(paths) == 1 {
+		return filepath.Base(paths[0]) + " changed"
+	}
+	return fmt.Sprintf("%d files changed", len(paths))
+}
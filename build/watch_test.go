@@ -0,0 +1,241 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+)
+
+// watchProject creates two modules, each generated by its own task, and
+// returns the project root and a watcher for it
+func watchProject(t *testing.T) (string, *Watcher, *bytes.Buffer) {
+	t.Helper()
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a", "user.tg"), "struct User {\n  id: int64\n}\n")
+	writeFile(t, filepath.Join(root, "b", "order.tg"), "struct Order {\n  id: int64\n}\n")
+	writeWatchConfig(t, root, "a", "b")
+
+	var out bytes.Buffer
+	w, err := NewWatcher(filepath.Join(root, "typegen.yaml"), &out)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	return root, w, &out
+}
+
+// writeWatchConfig writes a typegen.yaml with one task per input directory
+func writeWatchConfig(t *testing.T, root string, inputs ...string) {
+	t.Helper()
+	var config strings.Builder
+	config.WriteString("generate:\n")
+	for _, input := range inputs {
+		fmt.Fprintf(&config, "  - generator: files\n    input: %s\n    output: %s\n",
+			filepath.Join(root, input), filepath.Join(root, "out-"+input))
+	}
+	writeFile(t, filepath.Join(root, "typegen.yaml"), config.String())
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	root, w, _ := watchProject(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	writeFile(t, filepath.Join(root, "a", "user.tg"), "struct User {\n  id: int64\n  name: string\n}\n")
+	w.poll(start)
+	if w.flush(ctx, start.Add(100*time.Millisecond)) {
+		t.Fatal("rebuilt before the debounce period expired")
+	}
+
+	// A second change restarts the debounce period
+	writeFile(t, filepath.Join(root, "a", "group.tg"), "struct Group {\n  id: int64\n}\n")
+	w.poll(start.Add(200 * time.Millisecond))
+	if w.flush(ctx, start.Add(400*time.Millisecond)) {
+		t.Fatal("rebuilt before the debounce period of the second change expired")
+	}
+	if !w.flush(ctx, start.Add(450*time.Millisecond)) {
+		t.Fatal("expected a rebuild once the debounce period expired")
+	}
+	if w.flush(ctx, start.Add(time.Second)) {
+		t.Error("expected a single rebuild for the burst of changes")
+	}
+
+	// Scans without changes queue nothing
+	w.poll(start.Add(2 * time.Second))
+	if w.flush(ctx, start.Add(3*time.Second)) {
+		t.Error("rebuilt without changes")
+	}
+}
+
+func TestWatcherRebuildsAffectedTasks(t *testing.T) {
+	root, w, out := watchProject(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	writeFile(t, filepath.Join(root, "a", "user.tg"), "struct User {\n  id: int64\n  name: string\n}\n")
+	// Files that are not .tg and skipped directories are not watched
+	writeFile(t, filepath.Join(root, "b", "notes.txt"), "notes")
+	writeFile(t, filepath.Join(root, "b", "node_modules", "dep.tg"), "struct Dep {}\n")
+	w.poll(start)
+	if !w.flush(ctx, start.Add(time.Second)) {
+		t.Fatal("expected a rebuild")
+	}
+
+	if !exists(filepath.Join(root, "out-a", "types", "User.txt")) {
+		t.Error("expected the task of the changed input to be rebuilt")
+	}
+	if exists(filepath.Join(root, "out-b")) {
+		t.Error("expected the task of the unchanged input not to be rebuilt")
+	}
+	if !strings.Contains(out.String(), "user.tg changed: rebuilt 1/2 tasks") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestWatcherSkipsOutputInsideInput(t *testing.T) {
+	registerGenerator(t, "files", func() generators.Generator { return &FileWritingGenerator{} })
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "schemas", "user.tg"), "struct User {\n  id: int64\n}\n")
+	writeFile(t, filepath.Join(root, "typegen.yaml"), fmt.Sprintf("generate:\n  - generator: files\n    input: %s\n    output: %s\n",
+		filepath.Join(root, "schemas"), filepath.Join(root, "schemas", "gen")))
+
+	var out bytes.Buffer
+	w, err := NewWatcher(filepath.Join(root, "typegen.yaml"), &out)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+
+	// A .tg file written to the output doesn't trigger a rebuild
+	writeFile(t, filepath.Join(root, "schemas", "gen", "copy.tg"), "struct Copy {}\n")
+	w.poll(time.Now())
+	if paths := pendingPaths(w); len(paths) != 0 {
+		t.Errorf("expected changes in the output directory to be ignored, got %v", paths)
+	}
+
+	w.warn()
+	if !strings.Contains(out.String(), "is inside the input directory") {
+		t.Errorf("expected a warning, got:\n%s", out.String())
+	}
+}
+
+func TestWatcherFailures(t *testing.T) {
+	root, w, out := watchProject(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	writeFile(t, filepath.Join(root, "b", "order.tg"), "struct Order {\n")
+	w.poll(start)
+	if !w.flush(ctx, start.Add(time.Second)) {
+		t.Fatal("expected a rebuild")
+	}
+	if !strings.Contains(out.String(), "❌") || !strings.Contains(out.String(), "task files-2: ") {
+		t.Errorf("expected the failure to be reported:\n%s", out.String())
+	}
+
+	// The watcher keeps going after a failure
+	out.Reset()
+	writeFile(t, filepath.Join(root, "b", "order.tg"), "struct Order {\n  id: int64\n}\n")
+	w.poll(start.Add(2 * time.Second))
+	if !w.flush(ctx, start.Add(3*time.Second)) {
+		t.Fatal("expected a rebuild after fixing the input")
+	}
+	if !strings.Contains(out.String(), "✅") || !exists(filepath.Join(root, "out-b", "types", "Order.txt")) {
+		t.Errorf("expected the fixed task to build:\n%s", out.String())
+	}
+}
+
+func TestWatcherReloadsConfig(t *testing.T) {
+	root, w, out := watchProject(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	writeFile(t, filepath.Join(root, "c", "item.tg"), "struct Item {\n  id: int64\n}\n")
+	writeWatchConfig(t, root, "a", "b", "c")
+	w.poll(start)
+	if !w.flush(ctx, start.Add(time.Second)) {
+		t.Fatal("expected a rebuild")
+	}
+	for _, output := range []string{"out-a", "out-b", "out-c"} {
+		if !exists(filepath.Join(root, output)) {
+			t.Errorf("expected %s to be generated after the config reload", output)
+		}
+	}
+	if !strings.Contains(out.String(), "rebuilt 3/3 tasks") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+
+	// The new task's input is watched
+	writeFile(t, filepath.Join(root, "c", "item.tg"), "struct Item {\n  id: int64\n  name: string\n}\n")
+	w.poll(start.Add(2 * time.Second))
+	if got := w.affectedTasks(pendingPaths(w)); len(got) != 1 || got[0] != 2 {
+		t.Errorf("expected only task 3 to be affected, got %v", got)
+	}
+	w.flush(ctx, start.Add(3*time.Second))
+
+	// An invalid config is reported and the previous one is kept
+	out.Reset()
+	writeFile(t, filepath.Join(root, "typegen.yaml"), "generate: [")
+	w.poll(start.Add(4 * time.Second))
+	if w.flush(ctx, start.Add(5*time.Second)) {
+		t.Error("expected no rebuild with an invalid config")
+	}
+	if !strings.Contains(out.String(), "failed to reload") || len(w.config.Generate) != 3 {
+		t.Errorf("expected the reload failure to be reported and the old config kept:\n%s", out.String())
+	}
+}
+
+func TestWatcherReloadsExtendedConfig(t *testing.T) {
+	root, w, out := watchProject(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	// The tasks move to a base file, which is watched once the config extends it
+	writeBase := func(inputs ...string) {
+		var config strings.Builder
+		config.WriteString("generate:\n")
+		for _, input := range inputs {
+			fmt.Fprintf(&config, "  - generator: files\n    input: %s\n    output: %s\n",
+				filepath.Join(root, input), filepath.Join(root, "out-"+input))
+		}
+		writeFile(t, filepath.Join(root, "typegen.base.yaml"), config.String())
+	}
+	writeBase("a", "b")
+	writeFile(t, filepath.Join(root, "typegen.yaml"), "extends: typegen.base.yaml\n")
+	w.poll(start)
+	if !w.flush(ctx, start.Add(time.Second)) {
+		t.Fatal("expected a rebuild")
+	}
+
+	out.Reset()
+	writeFile(t, filepath.Join(root, "c", "item.tg"), "struct Item {\n  id: int64\n}\n")
+	writeBase("a", "b", "c")
+	w.poll(start.Add(2 * time.Second))
+	if !w.flush(ctx, start.Add(3*time.Second)) {
+		t.Fatal("expected a rebuild after the base file changed")
+	}
+	if !exists(filepath.Join(root, "out-c")) || !strings.Contains(out.String(), "rebuilt 3/3 tasks") {
+		t.Errorf("expected the task added to the base file to run:\n%s", out.String())
+	}
+}
+
+func pendingPaths(w *Watcher) []string {
+	var paths []string
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -1,20 +1,54 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 	
 	"github.com/WhatsApp-Platform/typegen/build"
+	"github.com/WhatsApp-Platform/typegen/diagnostics"
+	schemadiff "github.com/WhatsApp-Platform/typegen/diff"
+	"github.com/WhatsApp-Platform/typegen/format"
 	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/graph"
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/importers/golang"
+	"github.com/WhatsApp-Platform/typegen/importers/infer"
+	"github.com/WhatsApp-Platform/typegen/importers/jsonschema"
+	"github.com/WhatsApp-Platform/typegen/importers/proto"
+	"github.com/WhatsApp-Platform/typegen/internal/diff"
+	"github.com/WhatsApp-Platform/typegen/logging"
 	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+	"github.com/WhatsApp-Platform/typegen/stats"
 	"github.com/WhatsApp-Platform/typegen/validator"
+	"github.com/WhatsApp-Platform/typegen/version"
 	
 	// Import generators to register them
 	_ "github.com/WhatsApp-Platform/typegen/generators/python/pydantic"
 	_ "github.com/WhatsApp-Platform/typegen/generators/go"
+	_ "github.com/WhatsApp-Platform/typegen/generators/bigquery"
+	_ "github.com/WhatsApp-Platform/typegen/generators/cpp"
+	_ "github.com/WhatsApp-Platform/typegen/generators/dart"
+	_ "github.com/WhatsApp-Platform/typegen/generators/fixtures"
+	_ "github.com/WhatsApp-Platform/typegen/generators/hack"
+	_ "github.com/WhatsApp-Platform/typegen/generators/template"
 )
 
 // configFlags implements flag.Value for collecting multiple key=value config options
@@ -42,290 +76,1948 @@ func (c configFlags) Set(value string) error {
 	return nil
 }
 
+// listFlags implements flag.Value for options that can be repeated or given
+// as a comma-separated list
+type listFlags []string
+
+func (l *listFlags) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlags) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
 const usage = `TypeGen - generate types from a common definition language
 
 Usage:
   typegen <command> [flags] [arguments]
 
 Commands:
-  parse     Parse and validate a TypeGen file
-  module    Parse all TypeGen files in a module directory  
-  generate  Generate code for entire module
-  build     Build all targets defined in typegen.yaml
+  init        Create a typegen.yaml and an example schemas module
+  parse       Parse and validate TypeGen files
+  module      Parse all TypeGen files in a module directory  
+  generate    Generate code for entire module
+  build       Build all targets defined in typegen.yaml
+  import      Convert schemas from other formats into .tg files
+  infer       Infer a starting schema from sample JSON payloads
+  fmt         Rewrite .tg files in canonical style
+  validate    Validate a module or file without generating code
+  diff        Compare two versions of a module for breaking changes
+  graph       Print the type dependency graph of a module
+  stats       Summarize the declarations and references of a module
+  resolve     Locate a type and list what it uses and what uses it
+  generators  List generators and their config options
+  version     Print the typegen version
 
 Use "typegen <command> -h" for more information about a command.
+Use "typegen --version" to print the version.
 
 Examples:
+  typegen init -generator go
   typegen parse user.tg
+  typegen parse -quiet schemas/*.tg
   typegen module ./api/auth
+  typegen module -format json ./schemas
   typegen generate -generator python+pydantic -o ./generated/python ./schemas
   typegen build
+  typegen import jsonschema -o ./schemas api.json
+  typegen import go -o ./schemas ./pkg/models
+  typegen infer -root Response -o api.tg samples/*.json
+  typegen fmt -l ./schemas
+  typegen validate ./schemas
+  typegen diff ./schemas-v1 ./schemas
+  typegen graph -focus User ./schemas
+  typegen stats ./schemas
+  typegen resolve ./schemas billing.PaymentMethod
+  typegen generators
+
+Exit codes:
+  0  success
+  1  error, usage error or failed check
+  2  parse error
+  3  validation error
+  4  code generation error, failed hook or task timeout
+  5  configuration error
 `
 
+// Exit codes shared by every command. Commands that check something, like
+// build -check, diff and fmt -l, also exit with exitError when the check fails.
+const (
+	exitOK         = 0
+	exitError      = 1 // generic and usage errors
+	exitParse      = 2
+	exitValidation = 3
+	exitGeneration = 4
+	exitConfig     = 5
+)
+
+// exitCode classifies an error returned while parsing, validating or
+// generating a module
+func exitCode(err error) int {
+	var parseErr *parser.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return exitParse
+	case errors.Is(err, build.ErrValidation):
+		return exitValidation
+	case errors.Is(err, build.ErrGeneration), errors.Is(err, build.ErrHook), errors.Is(err, build.ErrTimeout):
+		return exitGeneration
+	}
+	return exitError
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Print(usage)
-		os.Exit(1)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command line and returns its exit code. Results are written
+// to stdout, and errors and progress to stderr.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return exitError
 	}
 	
-	command := os.Args[1]
+	commands := map[string]func(args []string, stdout, stderr io.Writer) int{
+		"parse":      runParse,
+		"module":     runModule,
+		"generate":   runGenerate,
+		"build":      runBuild,
+		"import":     runImport,
+		"infer":      runInfer,
+		"fmt":        runFmt,
+		"validate":   runValidate,
+		"diff":       runDiff,
+		"graph":      runGraph,
+		"stats":      runStats,
+		"resolve":    runResolve,
+		"init":       runInit,
+		"generators": runGenerators,
+		"version":    runVersion,
+		"-version":   runVersion,
+		"--version":  runVersion,
+	}
 	
+	command := args[0]
 	switch command {
-	case "parse":
-		handleParse(os.Args[2:])
-	case "module":
-		handleModule(os.Args[2:])
-	case "generate":
-		handleGenerate(os.Args[2:])
-	case "build":
-		handleBuild(os.Args[2:])
 	case "help", "-h", "--help":
-		fmt.Print(usage)
-	default:
-		fmt.Printf("Unknown command: %s\n\n", command)
-		fmt.Print(usage)
-		os.Exit(1)
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	
+	runCommand, exists := commands[command]
+	if !exists {
+		fmt.Fprintf(stderr, "Unknown command: %s\n\n", command)
+		fmt.Fprint(stderr, usage)
+		return exitError
 	}
+	return runCommand(args[1:], stdout, stderr)
 }
 
-func handleParse(args []string) {
-	parseCmd := flag.NewFlagSet("parse", flag.ExitOnError)
+func runParse(args []string, stdout, stderr io.Writer) int {
+	parseCmd := flag.NewFlagSet("parse", flag.ContinueOnError)
+	parseCmd.SetOutput(stderr)
+	noColor := addColorFlag(parseCmd)
+	
+	format := parseCmd.String("format", "text", "Output format: text or json")
+	quiet := parseCmd.Bool("quiet", false, "Print an ok line per file instead of its AST")
+	
 	parseCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: typegen parse [flags] <file>\n\n")
-		fmt.Fprintf(os.Stderr, "Parse and validate a TypeGen file\n\n")
-		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fmt.Fprintf(stderr, "Usage: typegen parse [flags] <files...>\n\n")
+		fmt.Fprintf(stderr, "Parse and validate TypeGen files\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
 		parseCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nArguments:\n")
-		fmt.Fprintf(os.Stderr, "  <file>  Path to the TypeGen file to parse\n")
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <files...>  TypeGen files to parse; glob patterns like schemas/*.tg are expanded\n")
+		fmt.Fprintf(stderr, "\nEvery file is parsed even when some fail. With more than one file, a summary line\n")
+		fmt.Fprintf(stderr, "follows the results, and with -format json the ASTs are printed as an array.\n")
 	}
 	
-	parseCmd.Parse(args)
+	if err := parseCmd.Parse(args); err != nil {
+		return exitError
+	}
 	
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return exitError
+	}
 	if parseCmd.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Error: parse command requires a file argument\n\n")
+		fmt.Fprintf(stderr, "Error: parse command requires at least one file argument\n\n")
 		parseCmd.Usage()
-		os.Exit(1)
+		return exitError
 	}
 	
-	filename := parseCmd.Arg(0)
+	// Every failure is reported; the exit code is the highest of them, so a
+	// parse error wins over a missing file
+	status, passed, failed := exitOK, 0, 0
+	fail := func(prefix string, err error) {
+		failed++
+		if *format == "json" {
+			status = max(status, writeJSONError(stderr, err))
+			return
+		}
+		status = max(status, exitCode(err))
+		fmt.Fprintf(stderr, "%s%s\n", prefix, renderer(stderr, *noColor).Error(err))
+	}
 	
-	// Check if file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		fmt.Printf("Error: file '%s' does not exist\n", filename)
-		os.Exit(1)
+	var filenames []string
+	for _, arg := range parseCmd.Args() {
+		matches, err := expandGlob(arg)
+		if err != nil {
+			fail("Error: ", err)
+		}
+		filenames = append(filenames, matches...)
 	}
 	
-	// Parse the file
-	program, err := parser.ParseFile(filename)
-	if err != nil {
-		fmt.Printf("Parse error in %s:\n%v\n", filename, err)
-		os.Exit(1)
+	var programs []*ast.ProgramNode
+	for _, filename := range filenames {
+		// Check if file exists
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			fail("Error: ", fmt.Errorf("file '%s' does not exist", filename))
+			continue
+		}
+		
+		// Parse the file
+		program, err := parser.ParseFile(filename)
+		if err != nil {
+			fail(fmt.Sprintf("Parse error in %s:\n", filename), err)
+			continue
+		}
+		passed++
+		
+		switch {
+		case *format == "json":
+			programs = append(programs, program)
+		case *quiet:
+			fmt.Fprintf(stdout, "ok  %s\n", filename)
+		default:
+			// Print the parsed AST
+			fmt.Fprintf(stdout, "Successfully parsed %s:\n\n", filename)
+			fmt.Fprintln(stdout, program.String())
+		}
 	}
 	
-	// Print the parsed AST
-	fmt.Printf("Successfully parsed %s:\n\n", filename)
-	fmt.Println(program.String())
+	single := passed+failed == 1
+	if *format == "json" {
+		if single && passed == 0 {
+			return status
+		}
+		var value any = programs
+		if single {
+			value = programs[0]
+		}
+		if code := writeJSON(stdout, stderr, value); code != exitOK {
+			return code
+		}
+	} else if !single {
+		fmt.Fprintf(stdout, "Parse summary: %d passed, %d failed\n", passed, failed)
+	}
+	return status
+}
+
+// expandGlob returns the files matching a glob pattern, for shells that don't
+// expand them. Arguments naming an existing file, or without glob
+// characters, are returned as they are.
+func expandGlob(arg string) ([]string, error) {
+	if _, err := os.Stat(arg); err == nil || !strings.ContainsAny(arg, "*?[") {
+		return []string{arg}, nil
+	}
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match %q", arg)
+	}
+	return matches, nil
 }
 
-func handleModule(args []string) {
-	moduleCmd := flag.NewFlagSet("module", flag.ExitOnError)
+func runModule(args []string, stdout, stderr io.Writer) int {
+	moduleCmd := flag.NewFlagSet("module", flag.ContinueOnError)
+	moduleCmd.SetOutput(stderr)
+	noColor := addColorFlag(moduleCmd)
+	
+	format := moduleCmd.String("format", "text", "Output format: text or json (json includes submodules)")
+	
 	moduleCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: typegen module [flags] <directory>\n\n")
-		fmt.Fprintf(os.Stderr, "Parse all TypeGen files in a module directory\n\n")
-		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fmt.Fprintf(stderr, "Usage: typegen module [flags] <directory>\n\n")
+		fmt.Fprintf(stderr, "Parse all TypeGen files in a module directory\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
 		moduleCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nArguments:\n")
-		fmt.Fprintf(os.Stderr, "  <directory>  Path to the module directory to parse\n")
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <directory>  Path to the module directory to parse\n")
 	}
 	
-	moduleCmd.Parse(args)
+	if err := moduleCmd.Parse(args); err != nil {
+		return 1
+	}
 	
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return 1
+	}
 	if moduleCmd.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Error: module command requires a directory argument\n\n")
+		fmt.Fprintf(stderr, "Error: module command requires a directory argument\n\n")
 		moduleCmd.Usage()
-		os.Exit(1)
+		return 1
 	}
 	
 	modulePath := moduleCmd.Arg(0)
 	
 	// Check if directory exists
+	var statErr error
 	if info, err := os.Stat(modulePath); os.IsNotExist(err) {
-		fmt.Printf("Error: directory '%s' does not exist\n", modulePath)
-		os.Exit(1)
-	} else if !info.IsDir() {
-		fmt.Printf("Error: '%s' is not a directory\n", modulePath)
-		os.Exit(1)
+		statErr = fmt.Errorf("directory '%s' does not exist", modulePath)
+	} else if err == nil && !info.IsDir() {
+		statErr = fmt.Errorf("'%s' is not a directory", modulePath)
+	}
+	if statErr != nil {
+		if *format == "json" {
+			return writeJSONError(stderr, statErr)
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", statErr)
+		return exitError
+	}
+	
+	if *format == "json" {
+		module, err := parser.ParseModuleToAST(modulePath)
+		if err != nil {
+			return writeJSONError(stderr, err)
+		}
+		return writeJSON(stdout, stderr, module)
 	}
 	
 	// Parse the module
 	programs, err := parser.ParseModule(modulePath)
 	if err != nil {
-		fmt.Printf("Module parse error in %s:\n%v\n", modulePath, err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", modulePath, renderer(stderr, *noColor).Error(err))
+		return exitCode(err)
 	}
 	
 	// Print results
-	fmt.Printf("Successfully parsed module %s:\n\n", modulePath)
+	fmt.Fprintf(stdout, "Successfully parsed module %s:\n\n", modulePath)
 	
 	if len(programs) == 0 {
-		fmt.Println("No .tg files found in the module directory.")
-		return
+		fmt.Fprintln(stdout, "No .tg files found in the module directory.")
+		return 0
 	}
 	
 	for filename, program := range programs {
-		fmt.Printf("=== %s ===\n", filename)
-		fmt.Println(program.String())
-		fmt.Println()
+		fmt.Fprintf(stdout, "=== %s ===\n", filename)
+		fmt.Fprintln(stdout, program.String())
+		fmt.Fprintln(stdout)
 	}
 	
-	fmt.Printf("Total files parsed: %d\n", len(programs))
+	fmt.Fprintf(stdout, "Total files parsed: %d\n", len(programs))
+	return 0
 }
 
+// jsonError is an error printed to stderr in -format json mode. Parse
+// errors carry one diagnostic per syntax error, of every file that failed.
+type jsonError struct {
+	Error       string                   `json:"error"`
+	Diagnostics []parser.ParseDiagnostic `json:"diagnostics,omitempty"`
+}
 
-func handleGenerate(args []string) {
-	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
+// writeJSONError prints err as a jsonError and returns its exit code
+func writeJSONError(stderr io.Writer, err error) int {
+	output := jsonError{Error: err.Error(), Diagnostics: parser.Diagnostics(err)}
+	data, _ := json.Marshal(output)
+	fmt.Fprintf(stderr, "%s\n", data)
+	return exitCode(err)
+}
+
+// writeJSON prints value as indented JSON
+func writeJSON(stdout, stderr io.Writer, value any) int {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return writeJSONError(stderr, err)
+	}
+	fmt.Fprintf(stdout, "%s\n", data)
+	return 0
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	generateCmd := flag.NewFlagSet("generate", flag.ContinueOnError)
+	generateCmd.SetOutput(stderr)
+	noColor := addColorFlag(generateCmd)
 	
 	// Define flags
 	generator := generateCmd.String("generator", "", "Target generator for code generation")
-	outputDir := generateCmd.String("o", "", "Output directory for generated code")
+	outputDir := generateCmd.String("o", "", "Output directory for generated code, or - to print the generated file to stdout")
+	toStdout := generateCmd.Bool("stdout", false, "Print the generated file to stdout instead of writing it; the input must generate exactly one file (same as -o -)")
 	config := make(configFlags)
 	generateCmd.Var(config, "c", "Configuration option in format key=value (can be used multiple times)")
 	skipValidation := generateCmd.Bool("skip-validation", false, "Skip validation before generation (emergency bypass)")
+	clean := generateCmd.Bool("clean", false, "Remove generated files in the output directory that this run didn't write")
+	dryRun := generateCmd.Bool("dry-run", false, "With -clean, list the files that would be removed without removing them")
+	verbosity := addLogFlags(generateCmd)
 	
 	generateCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: typegen generate [flags] <module-directory>\n\n")
-		fmt.Fprintf(os.Stderr, "Generate code for entire module\n\n")
-		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fmt.Fprintf(stderr, "Usage: typegen generate [flags] <module-directory | file>\n\n")
+		fmt.Fprintf(stderr, "Generate code for entire module\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
 		generateCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nArguments:\n")
-		fmt.Fprintf(os.Stderr, "  <module-directory>  Path to the module directory to generate from\n")
-		fmt.Fprintf(os.Stderr, "\nAvailable generators: %v\n", generators.List())
-		fmt.Fprintf(os.Stderr, "\nExample:\n")
-		fmt.Fprintf(os.Stderr, "  typegen generate -generator python+pydantic -o ./output -c indent=4 -c package=myapp ./schemas\n")
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <module-directory>  Path to the module directory to generate from\n")
+		fmt.Fprintf(stderr, "  <file>              Single .tg file, generated as a module named after the file\n")
+		fmt.Fprintf(stderr, "\nAvailable generators:\n\n")
+		writeGeneratorInfos(stderr, generators.Infos())
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen generate -generator python+pydantic -o ./output -c module-name=myapp.models -c testdata=true ./schemas\n")
+		fmt.Fprintf(stderr, "  typegen generate -generator go -o ./output -clean ./schemas\n")
+		fmt.Fprintf(stderr, "  typegen generate -generator go -o - user.tg\n")
 	}
 	
-	generateCmd.Parse(args)
+	if err := generateCmd.Parse(args); err != nil {
+		return exitError
+	}
 	
 	if generateCmd.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "Error: generate command requires a module directory argument\n\n")
+		fmt.Fprintf(stderr, "Error: generate command requires a module directory argument\n\n")
 		generateCmd.Usage()
-		os.Exit(1)
+		return 1
 	}
 	
 	if *generator == "" {
-		fmt.Fprintf(os.Stderr, "Error: -generator flag is required\n\n")
+		fmt.Fprintf(stderr, "Error: -generator flag is required\n\n")
 		generateCmd.Usage()
-		os.Exit(1)
+		return 1
+	}
+	
+	if *toStdout {
+		if *outputDir != "" && *outputDir != "-" {
+			fmt.Fprintf(stderr, "Error: -stdout and -o cannot be used together\n\n")
+			generateCmd.Usage()
+			return exitError
+		}
+		*outputDir = "-"
 	}
 	
 	if *outputDir == "" {
-		fmt.Fprintf(os.Stderr, "Error: -o flag is required\n\n")
+		fmt.Fprintf(stderr, "Error: -o flag is required\n\n")
+		generateCmd.Usage()
+		return 1
+	}
+	
+	if *outputDir == "-" && *clean {
+		fmt.Fprintf(stderr, "Error: -clean cannot be used when printing to stdout\n\n")
+		generateCmd.Usage()
+		return exitError
+	}
+	
+	if *dryRun && !*clean {
+		fmt.Fprintf(stderr, "Error: -dry-run requires -clean\n\n")
 		generateCmd.Usage()
-		os.Exit(1)
+		return exitError
 	}
 	
 	modulePath := generateCmd.Arg(0)
+	logger := verbosity.logger(stderr)
 	
-	// Display config options if any were provided
+	// Dump config options if any were provided
 	if len(config) > 0 {
-		fmt.Printf("Using config options: %v\n", map[string]string(config))
+		logger.Log(context.Background(), logging.LevelTrace, fmt.Sprintf("Using config options: %v", map[string]string(config)))
 	}
 	
-	// Check if module directory exists
-	if info, err := os.Stat(modulePath); os.IsNotExist(err) {
-		fmt.Printf("Error: module directory '%s' does not exist\n", modulePath)
-		os.Exit(1)
-	} else if !info.IsDir() {
-		fmt.Printf("Error: '%s' is not a directory\n", modulePath)
-		os.Exit(1)
+	// Get the generator for the specified name, and check its config up front
+	gen, err := generators.Get(*generator)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		logger.Error(fmt.Sprintf("Available generators: %v", generators.List()))
+		return 1
+	}
+	if warning := generators.DeprecationWarning(*generator); warning != "" {
+		logger.Warn("⚠️  " + warning)
+	}
+	if err := generators.ValidateConfig(gen, config); err != nil {
+		logger.Error(fmt.Sprintf("Error: invalid %s config: %v", *generator, err))
+		logger.Error(fmt.Sprintf("Run 'typegen generators %s' for its options", *generator))
+		return exitConfig
 	}
 	
-	// Parse the module
-	module, err := parser.ParseModuleToAST(modulePath)
+	// Check if the module directory or file exists
+	info, err := os.Stat(modulePath)
+	if os.IsNotExist(err) {
+		logger.Error(fmt.Sprintf("Error: module directory '%s' does not exist", modulePath))
+		return exitError
+	} else if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		return exitError
+	}
+	
+	// Parse the module; a single file is a module of its own, named after the file
+	start := time.Now()
+	var module *ast.Module
+	if info.IsDir() {
+		module, err = parser.ParseModuleToAST(modulePath)
+	} else {
+		module, err = parser.ParseFileToModule(modulePath)
+		if err == nil && !validator.IsValidSnakeCase(module.Name) {
+			parts := strings.FieldsFunc(module.Name, func(r rune) bool { return r == '-' || r == '.' || r == ' ' })
+			for i, part := range parts {
+				parts[i] = validator.SuggestSnakeCase(part)
+			}
+			suggestion := strings.Join(parts, "_")
+			logger.Error(fmt.Sprintf("Error: module name '%s' (from file %s) should follow snake_case convention; rename the file to '%s.tg'", module.Name, filepath.Base(modulePath), suggestion))
+			return exitValidation
+		}
+	}
 	if err != nil {
-		fmt.Printf("Module parse error in %s:\n%v\n", modulePath, err)
-		os.Exit(1)
+		logger.Error(fmt.Sprintf("Module parse error in %s:\n%s", modulePath, renderer(stderr, *noColor).Error(err)))
+		return exitCode(err)
 	}
+	logger.Debug(fmt.Sprintf("parsed module %s in %s", modulePath, time.Since(start).Round(time.Microsecond)))
 	
 	// Validate the module before generation (unless skipped)
 	if !*skipValidation {
-		fmt.Printf("Validating module %s...\n", module.Name)
+		logger.Info(fmt.Sprintf("Validating module %s...", module.Name))
 		v := validator.NewValidator()
+		if name, err := generators.Resolve(*generator); err == nil {
+			v.SetGenerators([]string{name})
+		}
 		result := v.Validate(module)
 		
 		if result.HasErrors() {
-			fmt.Fprintf(os.Stderr, "\n%s\n", result.String())
-			fmt.Fprintf(os.Stderr, "\nGeneration aborted due to validation errors.\n")
-			fmt.Fprintf(os.Stderr, "Use --skip-validation to bypass validation (not recommended).\n")
-			os.Exit(1)
+			logger.Error(renderer(stderr, *noColor).ValidationResult(result))
+			logger.Error("Generation aborted due to validation errors.")
+			logger.Error("Use --skip-validation to bypass validation (not recommended).")
+			return exitValidation
 		}
-		fmt.Printf("✅ Module validation passed\n\n")
+		logger.Info("✅ Module validation passed")
 	} else {
-		fmt.Printf("⚠️  Skipping validation as requested\n\n")
-	}
-	
-	// Get the generator for the specified name
-	gen, err := generators.Get(*generator)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		fmt.Printf("Available generators: %v\n", generators.List())
-		os.Exit(1)
+		logger.Warn(renderer(stderr, *noColor).Warning("Skipping validation as requested"))
 	}
 	
 	// Set config on the generator
 	gen.SetConfig(map[string]string(config))
 	
-	// Create filesystem for output
-	fs := generators.NewOSFS(*outputDir)
+	// Create filesystem for output, recording the files written for -clean.
+	// For stdout, the files are captured and printed after generation.
+	var output generators.FS = generators.NewOSFS(*outputDir)
+	captured := generators.NewInMemoryFS()
+	if *outputDir == "-" {
+		output = captured
+	}
+	written := generators.NewTrackingFS(output)
+	fs := generators.NewLoggingFS(written, logger)
 	
 	// Generate code
-	ctx := context.Background()
-	if err := gen.Generate(ctx, module, fs); err != nil {
-		fmt.Printf("Generation error: %v\n", err)
-		os.Exit(1)
+	start = time.Now()
+	ctx := logging.WithLogger(context.Background(), logger)
+	// Generators see the fields of embedded structs as fields of their own
+	if err := gen.Generate(ctx, semantic.Flatten(module), fs); err != nil {
+		logger.Error(fmt.Sprintf("Generation error: %v", err))
+		return exitGeneration
 	}
+	logger.Debug(fmt.Sprintf("generated %s code in %s", *generator, time.Since(start).Round(time.Microsecond)))
 	
-	fmt.Printf("Generated %s code for module %s in %s\n", *generator, module.Name, *outputDir)
+	if *outputDir == "-" {
+		files := captured.ListFiles()
+		if len(files) != 1 {
+			logger.Error(fmt.Sprintf("Error: printing to stdout needs exactly one generated file, but %s generates %d:\n  %s\nUse -o <dir> to write them", *generator, len(files), strings.Join(files, "\n  ")))
+			return exitError
+		}
+		content, _ := captured.GetFile(files[0])
+		stdout.Write(content)
+		return exitOK
+	}
+	
+	logger.Info(fmt.Sprintf("Generated %s code for module %s in %s", *generator, module.Name, *outputDir))
+	
+	if *clean {
+		keep := make(map[string]bool)
+		for _, name := range written.Written() {
+			keep[name] = true
+		}
+		output := generators.NewOSFS(*outputDir).(generators.RemoveFS)
+		removed, err := generators.Clean(output, func(name string) bool { return keep[name] }, *dryRun)
+		for _, name := range removed {
+			if *dryRun {
+				logger.Info(fmt.Sprintf("Would remove stale %s", filepath.Join(*outputDir, name)))
+			} else {
+				logger.Info(fmt.Sprintf("🧹 Removed stale %s", filepath.Join(*outputDir, name)))
+			}
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error cleaning %s: %v", *outputDir, err))
+			return exitError
+		}
+	}
+	return exitOK
 }
 
-func handleBuild(args []string) {
-	buildCmd := flag.NewFlagSet("build", flag.ExitOnError)
+func runBuild(args []string, stdout, stderr io.Writer) int {
+	buildCmd := flag.NewFlagSet("build", flag.ContinueOnError)
+	buildCmd.SetOutput(stderr)
 	
 	// Define flags
 	configPath := buildCmd.String("f", "", "Path to typegen.yaml configuration file (default: ./typegen.yaml)")
+	check := buildCmd.Bool("check", false, "Check that generated code is up to date without writing files; exit 1 on differences")
+	watch := buildCmd.Bool("watch", false, "Rebuild tasks whenever their input or the configuration changes")
+	var tasks, taskGenerators listFlags
+	buildCmd.Var(&tasks, "t", "Only run these tasks, by name or by number as shown in build output (repeatable or comma-separated)")
+	buildCmd.Var(&taskGenerators, "generator", "Only run the tasks of these generators (repeatable or comma-separated)")
+	clean := buildCmd.Bool("clean", false, "Remove generated files in each output directory that the build didn't write, for every task")
+	dryRun := buildCmd.Bool("dry-run", false, "List the stale files that cleaning would remove without removing them")
+	report := buildCmd.String("report", "", "Write a JSON report of the tasks, their timing and the files written to this file")
+	format := buildCmd.String("format", "text", "Output format: text, or json to print the report to stdout")
+	cacheDir := buildCmd.String("cache-dir", "", "Skip the tasks whose inputs haven't changed since the last build, keeping the cache in this directory")
+	force := buildCmd.Bool("force", false, "Run every task even when the build cache has it up to date")
+	noHooks := buildCmd.Bool("no-hooks", false, "Generate code without running the tasks' pre and post hooks")
+	update := buildCmd.Bool("update", false, "Fetch remote inputs whose ref is a branch again instead of using the remote cache")
+	verbosity := addLogFlags(buildCmd)
 	
 	buildCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: typegen build [flags]\n\n")
-		fmt.Fprintf(os.Stderr, "Build all targets defined in typegen.yaml\n\n")
-		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fmt.Fprintf(stderr, "Usage: typegen build [flags]\n\n")
+		fmt.Fprintf(stderr, "Build all targets defined in typegen.yaml\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
 		buildCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nExample:\n")
-		fmt.Fprintf(os.Stderr, "  typegen build\n")
-		fmt.Fprintf(os.Stderr, "  typegen build -f custom-config.yaml\n")
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen build\n")
+		fmt.Fprintf(stderr, "  typegen build -f custom-config.yaml\n")
+		fmt.Fprintf(stderr, "  typegen build -check\n")
+		fmt.Fprintf(stderr, "  typegen build -watch\n")
+		fmt.Fprintf(stderr, "  typegen build -generator python+pydantic\n")
+		fmt.Fprintf(stderr, "  typegen build -t payments-go,3\n")
+		fmt.Fprintf(stderr, "  typegen build -clean -dry-run\n")
+		fmt.Fprintf(stderr, "  typegen build -report report.json\n")
+		fmt.Fprintf(stderr, "  typegen build -format json\n")
+		fmt.Fprintf(stderr, "  typegen build -cache-dir .typegen-cache\n")
+		fmt.Fprintf(stderr, "  typegen build -update\n")
+		fmt.Fprintf(stderr, "  typegen build -no-hooks\n")
+		fmt.Fprintf(stderr, "  typegen build -v\n")
+	}
+	
+	if err := buildCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return exitError
+	}
+	if *check && *watch {
+		fmt.Fprintf(stderr, "Error: -check and -watch cannot be used together\n\n")
+		buildCmd.Usage()
+		return exitError
 	}
 	
-	buildCmd.Parse(args)
+	filter := build.TaskFilter{Tasks: tasks, Generators: taskGenerators}
+	if !filter.Empty() && (*check || *watch) {
+		fmt.Fprintf(stderr, "Error: -t and -generator cannot be used with -check or -watch\n\n")
+		buildCmd.Usage()
+		return exitError
+	}
+	if *update && *watch {
+		fmt.Fprintf(stderr, "Error: -update cannot be used with -watch\n\n")
+		buildCmd.Usage()
+		return exitError
+	}
+	if (*clean || *dryRun || *report != "" || *format == "json" || *cacheDir != "" || *force) && (*check || *watch) {
+		fmt.Fprintf(stderr, "Error: -clean, -dry-run, -report, -format json, -cache-dir and -force cannot be used with -check or -watch\n\n")
+		buildCmd.Usage()
+		return exitError
+	}
+	
+	logger := verbosity.logger(stderr)
+	
+	if *watch {
+		watcher, err := build.NewWatcher(*configPath, stderr)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
+			return exitConfig
+		}
+		watcher.Logger = logger
+		watcher.SkipHooks = *noHooks
+		
+		// Stop watching cleanly on Ctrl-C
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		
+		if err := watcher.Run(ctx); err != nil {
+			logger.Error(fmt.Sprintf("Watch failed: %v", err))
+			return 1
+		}
+		return 0
+	}
+	
+	// On a terminal, a status line shows what the running task is doing
+	var progress *liveProgress
+	if !*check && !*verbosity.quiet && isTerminal(stderr) {
+		progress = &liveProgress{out: stderr}
+		logger = verbosity.logger(progress)
+	}
 	
 	// Load configuration
 	config, err := build.LoadConfig(*configPath)
 	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
-		os.Exit(1)
+		logger.Error(fmt.Sprintf("Error loading configuration: %v", err))
+		return exitConfig
+	}
+	for _, warning := range config.Warnings() {
+		logger.Warn("⚠️  " + warning)
+	}
+	if *cacheDir != "" {
+		dir, err := filepath.Abs(*cacheDir)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error resolving cache directory: %v", err))
+			return exitConfig
+		}
+		config.Cache = true
+		config.CacheDir = dir
 	}
 	
 	// Create builder
 	builder := build.NewBuilder(config)
+	builder.SetLogger(logger)
+	builder.SetClean(*clean, *dryRun)
+	builder.SetForce(*force)
+	builder.SetUpdate(*update)
+	builder.SetSkipHooks(*noHooks)
+	if progress != nil {
+		for i := range config.Generate {
+			progress.names = append(progress.names, config.TaskName(i))
+		}
+		builder.SetProgress(progress)
+	}
 	
 	// Validate generators before starting build
 	if err := builder.ValidateGenerators(); err != nil {
-		fmt.Printf("Configuration validation error: %v\n", err)
-		os.Exit(1)
+		logger.Error(fmt.Sprintf("Configuration validation error: %v", err))
+		return exitConfig
+	}
+	
+	// Ctrl-C and SIGTERM, as sent by CI timeouts, interrupt the build
+	// between tasks and phases, and the report says which tasks completed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	if *check {
+		changes, err := builder.Check(ctx)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Check failed: %v", err))
+			return exitCode(err)
+		}
+		if len(changes) == 0 {
+			logger.Info("Generated code is up to date")
+			return 0
+		}
+		
+		// The list of files is the command's result, so it goes to stdout
+		wd, _ := os.Getwd()
+		for _, change := range changes {
+			path := change.Path
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+			fmt.Fprintf(stdout, "%-8s %s\n", change.Kind, path)
+		}
+		logger.Error(fmt.Sprintf("Generated code is out of date: %d files differ; run typegen build", len(changes)))
+		return 1
 	}
 	
 	// Execute build
-	ctx := context.Background()
-	if err := builder.Build(ctx); err != nil {
-		fmt.Printf("Build failed: %v\n", err)
-		os.Exit(1)
+	status := exitOK
+	result, err := builder.BuildTasks(ctx, filter)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Build failed: %v", err))
+		status = exitCode(err)
+	}
+	
+	// The report covers failed builds too, as long as tasks ran
+	if *report != "" && result != nil {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*report, append(data, '\n'), 0644)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error writing report: %v", err))
+			return max(status, exitError)
+		}
+		logger.Debug(fmt.Sprintf("wrote build report to %s", *report))
+	}
+	if *format == "json" && result != nil {
+		if code := writeJSON(stdout, stderr, result); code != exitOK {
+			return max(status, code)
+		}
+	}
+	return status
+}
+
+// logFlags are the verbosity flags of commands that report progress
+type logFlags struct {
+	quiet       *bool
+	verbose     *bool
+	veryVerbose *bool
+}
+
+func addLogFlags(flags *flag.FlagSet) *logFlags {
+	return &logFlags{
+		quiet:       flags.Bool("quiet", false, "Only print errors"),
+		verbose:     flags.Bool("v", false, "Also print per-file progress and timing"),
+		veryVerbose: flags.Bool("vv", false, "Also print config dumps and cache hits and misses (implies -v)"),
+	}
+}
+
+// logger returns a logger printing to w at the selected verbosity
+func (f *logFlags) logger(w io.Writer) *slog.Logger {
+	verbosity := 0
+	if *f.verbose {
+		verbosity = 1
+	}
+	if *f.veryVerbose {
+		verbosity = 2
+	}
+	return logging.New(w, logging.Level(*f.quiet, verbosity))
+}
+
+// liveProgress draws a status line for the running build task at the bottom
+// of a terminal. Log lines are written through it: it clears the status line
+// before each one and draws it again after, so the log scrolls above it.
+type liveProgress struct {
+	out   io.Writer
+	names []string
+
+	mu     sync.Mutex
+	status string
+}
+
+// Write implements io.Writer for the logger
+func (p *liveProgress) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.out.Write(data)
+	p.draw()
+	return n, err
+}
+
+func (p *liveProgress) TaskPhase(task int, phase build.Phase) {
+	p.set(fmt.Sprintf("⏳ [%d/%d %s] %s...", task, len(p.names), p.names[task-1], phase))
+}
+
+func (p *liveProgress) FileWritten(task int, path string, count int) {
+	p.set(fmt.Sprintf("⏳ [%d/%d %s] %s... %d files written", task, len(p.names), p.names[task-1], build.PhaseGenerating, count))
+}
+
+func (p *liveProgress) TaskDone(result build.TaskResult) {
+	p.set("")
+}
+
+// set replaces the status line
+func (p *liveProgress) set(status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	p.status = status
+	p.draw()
+}
+
+// clear erases the status line, leaving the cursor at its start
+func (p *liveProgress) clear() {
+	if p.status != "" {
+		fmt.Fprint(p.out, "\r\x1b[K")
+	}
+}
+
+func (p *liveProgress) draw() {
+	fmt.Fprint(p.out, p.status)
+}
+
+// isTerminal reports whether diagnostics are written to a terminal; tests replace it
+var isTerminal = diagnostics.IsTerminal
+
+// addColorFlag adds -no-color to commands that print diagnostics
+func addColorFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("no-color", false, "Disable colored diagnostics; they are also disabled by NO_COLOR and when stderr is not a terminal")
+}
+
+// renderer returns the renderer for diagnostics written to stderr
+func renderer(stderr io.Writer, noColor bool) diagnostics.Renderer {
+	return diagnostics.New(diagnostics.Enabled(isTerminal(stderr), noColor))
+}
+
+const importUsage = `Usage: typegen import <format> [flags] <files...>
+
+Convert schemas from other formats into .tg files
+
+Formats:
+  jsonschema  JSON Schema documents
+  go          Exported types of a Go package
+  proto       Protocol Buffers .proto files
+
+Use "typegen import <format> -h" for more information about a format.
+`
+
+func runImport(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, importUsage)
+		return 1
+	}
+	
+	switch args[0] {
+	case "jsonschema":
+		return runImportJSONSchema(args[1:], stdout, stderr)
+	case "go":
+		return runImportGo(args[1:], stdout, stderr)
+	case "proto":
+		return runImportProto(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, importUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "Unknown import format: %s\n\n", args[0])
+		fmt.Fprint(stderr, importUsage)
+		return exitError
+	}
+}
+
+func runImportJSONSchema(args []string, stdout, stderr io.Writer) int {
+	importCmd := flag.NewFlagSet("import jsonschema", flag.ContinueOnError)
+	importCmd.SetOutput(stderr)
+	noColor := addColorFlag(importCmd)
+	
+	// Define flags
+	outputDir := importCmd.String("o", "", "Output directory for the .tg files")
+	
+	importCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen import jsonschema [flags] <files...>\n\n")
+		fmt.Fprintf(stderr, "Convert JSON Schema documents into .tg files\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		importCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <files...>  JSON Schema documents; $refs between them resolve by file name\n")
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen import jsonschema -o ./schemas api.json common.json\n")
+	}
+	
+	if err := importCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if importCmd.NArg() < 1 {
+		fmt.Fprintf(stderr, "Error: import jsonschema requires at least one file argument\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	if *outputDir == "" {
+		fmt.Fprintf(stderr, "Error: -o flag is required\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	// Read the documents, keyed by file name so $refs like "common.json#/$defs/X" resolve
+	documents := make(map[string][]byte)
+	for _, path := range importCmd.Args() {
+		name := filepath.Base(path)
+		if _, exists := documents[name]; exists {
+			fmt.Fprintf(stderr, "Error: more than one document is named %s\n", name)
+			return 1
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		documents[name] = data
+	}
+	
+	result, err := jsonschema.Import(documents)
+	if err != nil {
+		fmt.Fprintf(stderr, "Import error: %v\n", err)
+		return 1
+	}
+	
+	// Unsupported constructs don't stop the import; report where they were
+	for _, issue := range result.Issues {
+		fmt.Fprintln(stderr, renderer(stderr, *noColor).Warning(issue.String()))
+	}
+	
+	if err := importers.WriteFiles(result.Files, nil, generators.NewOSFS(*outputDir)); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	fmt.Fprintf(stdout, "Imported %d documents into %d .tg files in %s\n", len(documents), len(result.Files), *outputDir)
+	return exitOK
+}
+
+func runImportProto(args []string, stdout, stderr io.Writer) int {
+	importCmd := flag.NewFlagSet("import proto", flag.ContinueOnError)
+	importCmd.SetOutput(stderr)
+	noColor := addColorFlag(importCmd)
+	
+	// Define flags
+	outputDir := importCmd.String("o", "", "Output directory for the .tg files")
+	
+	importCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen import proto [flags] <files...>\n\n")
+		fmt.Fprintf(stderr, "Convert Protocol Buffers .proto files into .tg files\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		importCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <files...>  .proto files; types resolve across them by package-qualified name\n")
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen import proto -o ./schemas order.proto common.proto\n")
+	}
+	
+	if err := importCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if importCmd.NArg() < 1 {
+		fmt.Fprintf(stderr, "Error: import proto requires at least one file argument\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	if *outputDir == "" {
+		fmt.Fprintf(stderr, "Error: -o flag is required\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	files := make(map[string][]byte)
+	for _, path := range importCmd.Args() {
+		name := filepath.Base(path)
+		if _, exists := files[name]; exists {
+			fmt.Fprintf(stderr, "Error: more than one file is named %s\n", name)
+			return 1
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		files[name] = data
+	}
+	
+	result, err := proto.Import(files)
+	if err != nil {
+		fmt.Fprintf(stderr, "Import error: %v\n", err)
+		return 1
+	}
+	
+	// Skipped services and lossy mappings don't stop the import; report where they were
+	for _, issue := range result.Issues {
+		fmt.Fprintln(stderr, renderer(stderr, *noColor).Warning(issue.String()))
+	}
+	
+	if err := importers.WriteFiles(result.Files, result.Comments, generators.NewOSFS(*outputDir)); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	fmt.Fprintf(stdout, "Imported %d .proto files into %d .tg files in %s\n", len(files), len(result.Files), *outputDir)
+	return exitOK
+}
+
+func runImportGo(args []string, stdout, stderr io.Writer) int {
+	importCmd := flag.NewFlagSet("import go", flag.ContinueOnError)
+	importCmd.SetOutput(stderr)
+	noColor := addColorFlag(importCmd)
+	
+	// Define flags
+	outputDir := importCmd.String("o", "", "Output directory for the .tg files")
+	enums := importCmd.String("enums", "", "Comma-separated types to convert to enums regardless of constant naming")
+	
+	importCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen import go [flags] <package-dir>\n\n")
+		fmt.Fprintf(stderr, "Convert the exported types of a Go package into .tg files, one per Go source file\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		importCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <package-dir>  Directory of the Go package to import\n")
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen import go -o ./schemas -enums Currency ./pkg/models\n")
+	}
+	
+	if err := importCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if importCmd.NArg() != 1 {
+		fmt.Fprintf(stderr, "Error: import go requires exactly one package directory\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	if *outputDir == "" {
+		fmt.Fprintf(stderr, "Error: -o flag is required\n\n")
+		importCmd.Usage()
+		return 1
+	}
+	
+	var options golang.Options
+	if *enums != "" {
+		for _, name := range strings.Split(*enums, ",") {
+			options.Enums = append(options.Enums, strings.TrimSpace(name))
+		}
+	}
+	
+	result, err := golang.Import(importCmd.Arg(0), options)
+	if err != nil {
+		fmt.Fprintf(stderr, "Import error: %v\n", err)
+		return 1
+	}
+	
+	// Dropped fields and changed wire names don't stop the import; report where they were
+	for _, issue := range result.Issues {
+		fmt.Fprintln(stderr, renderer(stderr, *noColor).Warning(issue.String()))
 	}
-}
\ No newline at end of file
+	
+	if err := importers.WriteFiles(result.Files, result.Comments, generators.NewOSFS(*outputDir)); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	fmt.Fprintf(stdout, "Imported %s into %d .tg files in %s\n", importCmd.Arg(0), len(result.Files), *outputDir)
+	return exitOK
+}
+
+func runInfer(args []string, stdout, stderr io.Writer) int {
+	inferCmd := flag.NewFlagSet("infer", flag.ContinueOnError)
+	inferCmd.SetOutput(stderr)
+	noColor := addColorFlag(inferCmd)
+	
+	// Define flags
+	output := inferCmd.String("o", "", "Output .tg file (default: stdout)")
+	root := inferCmd.String("root", "Root", "Name of the top-level declaration")
+	datetimes := inferCmd.Bool("datetimes", false, "Infer datetime for strings that are RFC 3339 timestamps in every sample")
+	
+	inferCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen infer [flags] <samples...>\n\n")
+		fmt.Fprintf(stderr, "Infer a starting schema from sample JSON payloads of the same type\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		inferCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <samples...>  JSON files, each holding one payload\n")
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen infer samples/*.json -o api.tg -root Response\n")
+	}
+	
+	// Allow flags after the sample files, since they are usually a shell glob
+	var paths []string
+	for rest := args; ; {
+		if err := inferCmd.Parse(rest); err != nil {
+			return exitError
+		}
+		if inferCmd.NArg() == 0 {
+			break
+		}
+		paths = append(paths, inferCmd.Arg(0))
+		rest = inferCmd.Args()[1:]
+	}
+	
+	if len(paths) < 1 {
+		fmt.Fprintf(stderr, "Error: infer requires at least one sample file\n\n")
+		inferCmd.Usage()
+		return 1
+	}
+	
+	var samples [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		samples = append(samples, data)
+	}
+	
+	result, err := infer.Infer(paths, samples, infer.Options{Root: *root, Datetimes: *datetimes})
+	if err != nil {
+		fmt.Fprintf(stderr, "Infer error: %v\n", err)
+		return 1
+	}
+	
+	// Guesses don't stop the inference; report where they were made
+	for _, issue := range result.Issues {
+		fmt.Fprintln(stderr, renderer(stderr, *noColor).Warning(issue.String()))
+	}
+	
+	source := importers.Format(result.Program, result.Comments)
+	if *output == "" {
+		fmt.Fprint(stdout, source)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(source), 0644); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	fmt.Fprintf(stdout, "Inferred %s from %d samples into %s\n", *root, len(samples), *output)
+	return exitOK
+}
+
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	fmtCmd := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fmtCmd.SetOutput(stderr)
+	noColor := addColorFlag(fmtCmd)
+	
+	// Define flags
+	list := fmtCmd.Bool("l", false, "List files whose formatting differs")
+	showDiff := fmtCmd.Bool("d", false, "Print diffs instead of the formatted source")
+	write := fmtCmd.Bool("w", false, "Write the formatted source back to the files")
+	indent := fmtCmd.Int("indent", format.DefaultIndent, "Number of spaces that indent fields and variants")
+	
+	fmtCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen fmt [flags] [paths...]\n\n")
+		fmt.Fprintf(stderr, "Rewrite .tg files in canonical style. Without paths, formats stdin to stdout.\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		fmtCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  [paths...]  .tg files, or directories to format recursively\n")
+		fmt.Fprintf(stderr, "\nExit status is 1 when -l or -d find unformatted files or on errors, 2 on parse errors.\n")
+		fmt.Fprintf(stderr, "\nExamples:\n")
+		fmt.Fprintf(stderr, "  typegen fmt -w ./schemas\n")
+		fmt.Fprintf(stderr, "  typegen fmt -l ./schemas  # in CI\n")
+	}
+	
+	if err := fmtCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	options := format.Options{Indent: *indent}
+	
+	if fmtCmd.NArg() == 0 {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitError
+		}
+		formatted, err := format.Source(src, "<standard input>", options)
+		if err != nil {
+			fmt.Fprintf(stderr, "<standard input>: %s\n", renderer(stderr, *noColor).Error(err))
+			return exitCode(err)
+		}
+		switch {
+		case *list:
+			if !bytes.Equal(src, formatted) {
+				fmt.Fprintln(stdout, "<standard input>")
+				return 1
+			}
+		case *showDiff:
+			if d := diff.Unified("<standard input>.orig", src, "<standard input>", formatted); d != nil {
+				stdout.Write(d)
+				return 1
+			}
+		default:
+			stdout.Write(formatted)
+		}
+		return 0
+	}
+	
+	// Collect the .tg files, walking directories recursively
+	var paths []string
+	for _, arg := range fmtCmd.Args() {
+		err := filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Files named explicitly are formatted whatever their extension
+			if !entry.IsDir() && (path == arg || strings.HasSuffix(path, ".tg")) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitError
+		}
+	}
+	
+	// A parse error takes precedence over I/O errors in the exit code
+	status, unformatted := exitOK, false
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			status = max(status, exitError)
+			continue
+		}
+		formatted, err := format.Source(src, path, options)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", path, renderer(stderr, *noColor).Error(err))
+			status = max(status, exitCode(err))
+			continue
+		}
+		
+		changed := !bytes.Equal(src, formatted)
+		if changed {
+			unformatted = true
+		}
+		if *list && changed {
+			fmt.Fprintln(stdout, path)
+		}
+		if *showDiff && changed {
+			stdout.Write(diff.Unified(path+".orig", src, path, formatted))
+		}
+		if *write && changed {
+			info, err := os.Stat(path)
+			if err == nil {
+				err = os.WriteFile(path, formatted, info.Mode().Perm())
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				status = max(status, exitError)
+			}
+		}
+		if !*list && !*showDiff && !*write {
+			stdout.Write(formatted)
+		}
+	}
+	
+	if status != exitOK {
+		return status
+	}
+	if unformatted && (*list || *showDiff) {
+		return exitError
+	}
+	return exitOK
+}
+
+// runValidate validates a module directory, or a single file in the context
+// of its directory, and returns the exit code: 0 when valid, 2 on parse errors,
+// 3 on validation errors and 1 for usage errors
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	validateCmd := flag.NewFlagSet("validate", flag.ContinueOnError)
+	validateCmd.SetOutput(stderr)
+	noColor := addColorFlag(validateCmd)
+	format := validateCmd.String("format", "text", "Output format: text, json or sarif")
+	unusedTypes := validateCmd.String("unused-types", "", "Warn about structs, enums and aliases nothing references: in submodules, or all to include the root module")
+	var targetGenerators listFlags
+	validateCmd.Var(&targetGenerators, "generator", "Report names that are reserved words in the target language of a generator (can be repeated or comma-separated)")
+	var ruleFlags listFlags
+	validateCmd.Var(&ruleFlags, "rule", "Set the severity of a validation rule, as rule=error, warning or off, like validation.rules in typegen.yaml (can be repeated or comma-separated)")
+	failOn := validateCmd.String("fail-on", build.FailOnWarning, "Severity that fails validation: warning, or error to let warnings pass, like validation.fail_on in typegen.yaml")
+	
+	validateCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen validate [flags] <module-directory | file>\n\n")
+		fmt.Fprintf(stderr, "Parse and validate a module without generating code\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		validateCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nArguments:\n")
+		fmt.Fprintf(stderr, "  <module-directory>  Module to validate, including submodules\n")
+		fmt.Fprintf(stderr, "  <file>              Single .tg file, validated against the rest of its directory\n")
+		fmt.Fprintf(stderr, "\nExit status is 0 when valid, 1 on warnings, 2 on parse errors and 3 on validation\n")
+		fmt.Fprintf(stderr, "errors. With -fail-on error, warnings without errors exit with status 0.\n")
+		fmt.Fprintf(stderr, "With -format json or sarif, the errors and warnings are printed to stdout as JSON\n")
+		fmt.Fprintf(stderr, "or as a SARIF 2.1.0 log.\n")
+	}
+	
+	if err := validateCmd.Parse(args); err != nil {
+		return 1
+	}
+	
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text, json or sarif)\n", *format)
+		return 1
+	}
+	if *failOn != build.FailOnError && *failOn != build.FailOnWarning {
+		fmt.Fprintf(stderr, "Error: -fail-on must be %s or %s, got %q\n", build.FailOnError, build.FailOnWarning, *failOn)
+		return 1
+	}
+	severities := make(map[string]string)
+	for _, rule := range ruleFlags {
+		name, severity, ok := strings.Cut(rule, "=")
+		if !ok {
+			fmt.Fprintf(stderr, "Error: -rule %q must be rule=severity\n", rule)
+			return 1
+		}
+		severities[strings.TrimSpace(name)] = strings.TrimSpace(severity)
+	}
+	rules, err := validator.ParseRules(severities)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	v := validator.NewValidator()
+	if *unusedTypes != "" {
+		scope, err := validator.ParseUnusedTypeScope(*unusedTypes)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		// -unused-types turns the rule on, unless -rule sets its severity
+		if _, ok := rules[validator.UnusedTypeError]; !ok {
+			rules[validator.UnusedTypeError] = validator.SeverityWarning
+		}
+		v.SetUnusedTypeScope(scope)
+	}
+	v.SetRules(rules)
+	var names []string
+	for _, generator := range targetGenerators {
+		name, err := generators.Resolve(generator)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		names = append(names, name)
+	}
+	v.SetGenerators(names)
+	if validateCmd.NArg() != 1 {
+		fmt.Fprintf(stderr, "Error: validate requires a module directory or file argument\n\n")
+		validateCmd.Usage()
+		return 1
+	}
+	
+	target := validateCmd.Arg(0)
+	info, err := os.Stat(target)
+	if err != nil {
+		if *format == "json" {
+			return writeJSONError(stderr, err)
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	// A single file is validated with its siblings so references between files resolve
+	modulePath, onlyFile := target, ""
+	if !info.IsDir() {
+		modulePath, onlyFile = filepath.Dir(target), filepath.Base(target)
+		if _, err := parser.ParseFile(target); err != nil {
+			if *format == "json" {
+				return writeParseFailure(stdout, stderr, err)
+			}
+			fmt.Fprintf(stderr, "Parse error in %s:\n%s\n", target, renderer(stderr, *noColor).Error(err))
+			return exitCode(err)
+		}
+	}
+	
+	module, err := parser.ParseModuleToAST(modulePath)
+	if err != nil {
+		if *format == "json" {
+			return writeParseFailure(stdout, stderr, err)
+		}
+		fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", modulePath, renderer(stderr, *noColor).Error(err))
+		return exitCode(err)
+	}
+	
+	result := v.Validate(module)
+	if onlyFile != "" {
+		result.Errors = inFile(result.Errors, onlyFile)
+		result.Warnings = inFile(result.Warnings, onlyFile)
+	}
+	
+	// Errors exit with exitValidation, and warnings with exitError unless
+	// -fail-on error lets them pass
+	code := exitOK
+	if result.HasErrors() {
+		code = exitValidation
+	} else if *failOn == build.FailOnWarning && result.HasWarnings() {
+		code = exitError
+	}
+	
+	if *format == "json" {
+		output := validationOutput{Valid: code == exitOK, Diagnostics: diagnostics.FromValidation(result)}
+		if output.Diagnostics == nil {
+			output.Diagnostics = []diagnostics.Diagnostic{}
+		}
+		if writeCode := writeJSON(stdout, stderr, output); writeCode != exitOK {
+			return writeCode
+		}
+		return code
+	}
+	if *format == "sarif" {
+		data, err := result.ToSARIF(version.Version)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s\n", data)
+		return code
+	}
+	
+	if result.HasErrors() {
+		fmt.Fprintf(stderr, "%s\n", renderer(stderr, *noColor).ValidationResult(result))
+		return exitValidation
+	}
+	if result.HasWarnings() {
+		fmt.Fprintf(stderr, "%s\n", result.WarningsString())
+	}
+	if code != exitOK {
+		fmt.Fprintf(stderr, "❌ %s has warnings (use -fail-on error to allow them)\n", target)
+		return code
+	}
+	
+	fmt.Fprintf(stdout, "✅ %s is valid\n", target)
+	return 0
+}
+
+// validationOutput is what validate prints with -format json: whether the
+// target is valid, and its syntax errors, or its validation errors then its
+// warnings
+type validationOutput struct {
+	Valid       bool                     `json:"valid"`
+	Diagnostics []diagnostics.Diagnostic `json:"diagnostics"`
+}
+
+// writeParseFailure prints the syntax errors of a parse error as a
+// validationOutput, or any other error as a jsonError, and returns the exit
+// code
+func writeParseFailure(stdout, stderr io.Writer, err error) int {
+	if parser.Diagnostics(err) == nil {
+		return writeJSONError(stderr, err)
+	}
+	if code := writeJSON(stdout, stderr, validationOutput{Diagnostics: diagnostics.FromParseError(err)}); code != exitOK {
+		return code
+	}
+	return exitCode(err)
+}
+
+// inFile returns the errors of a file
+func inFile(errs []validator.ValidationError, file string) []validator.ValidationError {
+	var matching []validator.ValidationError
+	for _, err := range errs {
+		if err.File == file {
+			matching = append(matching, err)
+		}
+	}
+	return matching
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	diffCmd := flag.NewFlagSet("diff", flag.ContinueOnError)
+	diffCmd.SetOutput(stderr)
+	noColor := addColorFlag(diffCmd)
+	
+	format := diffCmd.String("format", "text", "Output format: text or json")
+	level := diffCmd.String("level", "breaking", "Lowest severity that fails: breaking, warning or compatible")
+	renames := diffCmd.Bool("renames", true, "Report a removed and an added declaration with the same body as a rename")
+	
+	diffCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen diff [flags] <old-module> <new-module>\n\n")
+		fmt.Fprintf(stderr, "Compare two versions of a module and classify the changes\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		diffCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nSeverities:\n")
+		fmt.Fprintf(stderr, "  breaking    Data written by one version cannot be read by the other\n")
+		fmt.Fprintf(stderr, "  warning     Wire-compatible, but code built on the old version may break\n")
+		fmt.Fprintf(stderr, "  compatible  Safe in both directions\n")
+		fmt.Fprintf(stderr, "\nExit status is 1 when a change at or above -level is found or on usage errors,\n2 on parse errors.\n")
+	}
+	
+	if err := diffCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	failLevel, err := schemadiff.ParseSeverity(*level)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return exitError
+	}
+	if diffCmd.NArg() != 2 {
+		fmt.Fprintf(stderr, "Error: diff requires an old and a new module directory\n\n")
+		diffCmd.Usage()
+		return exitError
+	}
+	
+	var modules [2]*ast.Module
+	for i, path := range diffCmd.Args() {
+		module, err := parser.ParseModuleToAST(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", path, renderer(stderr, *noColor).Error(err))
+			return exitCode(err)
+		}
+		modules[i] = module
+	}
+	
+	report := schemadiff.CompareWith(modules[0], modules[1], schemadiff.Options{DetectRenames: *renames})
+	
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s\n", data)
+	} else {
+		fmt.Fprint(stdout, report.String())
+	}
+	
+	if report.Count(failLevel) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runGraph(args []string, stdout, stderr io.Writer) int {
+	graphCmd := flag.NewFlagSet("graph", flag.ContinueOnError)
+	graphCmd.SetOutput(stderr)
+	noColor := addColorFlag(graphCmd)
+	
+	format := graphCmd.String("format", "dot", "Output format: dot or json")
+	focus := graphCmd.String("focus", "", "Only show the declarations reachable from this type, e.g. User or auth.Token")
+	depth := graphCmd.Int("depth", 0, "With -focus, follow at most this many references (0 means no limit)")
+	reverse := graphCmd.Bool("reverse", false, "Show dependents instead of dependencies")
+	
+	graphCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen graph [flags] <module-directory>\n\n")
+		fmt.Fprintf(stderr, "Print the type dependency graph of a module\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		graphCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nExamples:\n")
+		fmt.Fprintf(stderr, "  typegen graph ./schemas | dot -Tsvg -o schemas.svg\n")
+		fmt.Fprintf(stderr, "  typegen graph -focus User -depth 2 ./schemas\n")
+		fmt.Fprintf(stderr, "  typegen graph -focus User -reverse ./schemas\n")
+	}
+	
+	if err := graphCmd.Parse(args); err != nil {
+		return 1
+	}
+	
+	if *format != "dot" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected dot or json)\n", *format)
+		return 1
+	}
+	if graphCmd.NArg() != 1 {
+		fmt.Fprintf(stderr, "Error: graph requires a module directory argument\n\n")
+		graphCmd.Usage()
+		return 1
+	}
+	
+	module, err := parser.ParseModuleToAST(graphCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", graphCmd.Arg(0), renderer(stderr, *noColor).Error(err))
+		return exitCode(err)
+	}
+	
+	g := graph.Build(module)
+	if *reverse {
+		g = g.Reverse()
+	}
+	if *focus != "" {
+		node, err := g.Find(*focus)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		g = g.Focus(node.ID, *depth)
+	}
+	
+	if *format == "json" {
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s\n", data)
+		return 0
+	}
+	
+	stdout.Write(g.DOT())
+	return 0
+}
+
+func runStats(args []string, stdout, stderr io.Writer) int {
+	statsCmd := flag.NewFlagSet("stats", flag.ContinueOnError)
+	statsCmd.SetOutput(stderr)
+	noColor := addColorFlag(statsCmd)
+	
+	format := statsCmd.String("format", "text", "Output format: text or json")
+	
+	statsCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen stats [flags] <module-directory>\n\n")
+		fmt.Fprintf(stderr, "Summarize the declarations and references of a module and its submodules\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		statsCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nExamples:\n")
+		fmt.Fprintf(stderr, "  typegen stats ./schemas\n")
+		fmt.Fprintf(stderr, "  typegen stats -format json ./schemas\n")
+	}
+	
+	if err := statsCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return exitError
+	}
+	if statsCmd.NArg() != 1 {
+		fmt.Fprintf(stderr, "Error: stats requires a module directory argument\n\n")
+		statsCmd.Usage()
+		return exitError
+	}
+	
+	module, err := parser.ParseModuleToAST(statsCmd.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", statsCmd.Arg(0), renderer(stderr, *noColor).Error(err))
+		return exitCode(err)
+	}
+	
+	summary := stats.Compute(module)
+	if *format == "json" {
+		return writeJSON(stdout, stderr, summary)
+	}
+	fmt.Fprint(stdout, summary.String())
+	return exitOK
+}
+
+func runResolve(args []string, stdout, stderr io.Writer) int {
+	resolveCmd := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	resolveCmd.SetOutput(stderr)
+	noColor := addColorFlag(resolveCmd)
+	
+	format := resolveCmd.String("format", "text", "Output format: text or json")
+	transitive := resolveCmd.Bool("transitive", false, "List every dependency and dependent, not only the direct ones")
+	
+	resolveCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen resolve [flags] <module-directory> <type>\n\n")
+		fmt.Fprintf(stderr, "Print where a type is defined, the types it references and the types referencing it.\n")
+		fmt.Fprintf(stderr, "The type is a name qualified by its submodule, e.g. billing.PaymentMethod, or a bare\n")
+		fmt.Fprintf(stderr, "name when only one submodule declares it.\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		resolveCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nExamples:\n")
+		fmt.Fprintf(stderr, "  typegen resolve ./schemas User\n")
+		fmt.Fprintf(stderr, "  typegen resolve -transitive ./schemas billing.PaymentMethod\n")
+	}
+	
+	if err := resolveCmd.Parse(args); err != nil {
+		return exitError
+	}
+	
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return exitError
+	}
+	if resolveCmd.NArg() != 2 {
+		fmt.Fprintf(stderr, "Error: resolve requires a module directory and a type name\n\n")
+		resolveCmd.Usage()
+		return exitError
+	}
+	dir, name := resolveCmd.Arg(0), resolveCmd.Arg(1)
+	
+	module, err := parser.ParseModuleToAST(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "Module parse error in %s:\n%s\n", dir, renderer(stderr, *noColor).Error(err))
+		return exitCode(err)
+	}
+	
+	g := graph.Build(module)
+	node, err := g.Find(name)
+	var ambiguous *graph.AmbiguousError
+	if errors.As(err, &ambiguous) {
+		fmt.Fprintf(stderr, "Error: %q is declared in several submodules; use a qualified name:\n", name)
+		writeNodes(stderr, dir, ambiguous.Candidates)
+		return exitError
+	} else if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return exitError
+	}
+	
+	relations := g.Relations(node.ID, *transitive)
+	if *format == "json" {
+		return writeJSON(stdout, stderr, relations)
+	}
+	
+	fmt.Fprintf(stdout, "%s (%s)\n", node.ID, node.Kind)
+	fmt.Fprintf(stdout, "  defined at %s:%d:%d\n", filepath.Join(dir, node.File), node.Line, node.Column)
+	scope := "Direct"
+	if *transitive {
+		scope = "All"
+	}
+	fmt.Fprintf(stdout, "\n%s dependencies (%d):\n", scope, len(relations.Dependencies))
+	writeNodes(stdout, dir, relations.Dependencies)
+	fmt.Fprintf(stdout, "\n%s dependents (%d):\n", scope, len(relations.Dependents))
+	writeNodes(stdout, dir, relations.Dependents)
+	return exitOK
+}
+
+// writeNodes prints a line per graph node with its kind and position, or
+// "none" when there are no nodes
+func writeNodes(w io.Writer, dir string, nodes []*graph.Node) {
+	if len(nodes) == 0 {
+		fmt.Fprintf(w, "  none\n")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, node := range nodes {
+		fmt.Fprintf(tw, "  %s\t%s\t%s:%d:%d\n", node.ID, node.Kind, filepath.Join(dir, node.File), node.Line, node.Column)
+	}
+	tw.Flush()
+}
+
+func runInit(args []string, stdout, stderr io.Writer) int {
+	initCmd := flag.NewFlagSet("init", flag.ContinueOnError)
+	initCmd.SetOutput(stderr)
+	
+	generator := initCmd.String("generator", "", "Pre-fill a working task for this generator")
+	output := initCmd.String("output", "", "Output directory of the pre-filled task (default: ./gen/<generator>)")
+	force := initCmd.Bool("force", false, "Overwrite existing files")
+	
+	initCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen init [flags] [directory]\n\n")
+		fmt.Fprintf(stderr, "Create a typegen.yaml and an example schemas module (default directory: .)\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		initCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nAvailable generators: %s\n", strings.Join(generators.List(), ", "))
+		fmt.Fprintf(stderr, "\nExample:\n")
+		fmt.Fprintf(stderr, "  typegen init -generator go -output ./gen/go\n")
+	}
+	
+	if err := initCmd.Parse(args); err != nil {
+		return 1
+	}
+	if initCmd.NArg() > 1 {
+		fmt.Fprintf(stderr, "Error: init takes at most one directory argument\n\n")
+		initCmd.Usage()
+		return 1
+	}
+	if *output != "" && *generator == "" {
+		fmt.Fprintf(stderr, "Error: -output requires -generator\n\n")
+		initCmd.Usage()
+		return 1
+	}
+	
+	dir := "."
+	if initCmd.NArg() == 1 {
+		dir = initCmd.Arg(0)
+	}
+	
+	created, err := build.Init(dir, build.InitOptions{
+		Generator: *generator,
+		Output:    *output,
+		Force:     *force,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	
+	for _, path := range created {
+		fmt.Fprintf(stdout, "Created %s\n", path)
+	}
+	if *generator != "" {
+		fmt.Fprintf(stdout, "\nRun \"typegen build\" to generate %s code.\n", *generator)
+	} else {
+		fmt.Fprintf(stdout, "\nUncomment a task in typegen.yaml, then run \"typegen build\".\n")
+	}
+	return 0
+}
+
+func runGenerators(args []string, stdout, stderr io.Writer) int {
+	generatorsCmd := flag.NewFlagSet("generators", flag.ContinueOnError)
+	generatorsCmd.SetOutput(stderr)
+	
+	format := generatorsCmd.String("format", "text", "Output format: text or json")
+	
+	generatorsCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen generators [flags] [generator...]\n\n")
+		fmt.Fprintf(stderr, "List generators with their description and config options (default: all)\n\n")
+		fmt.Fprintf(stderr, "Flags:\n")
+		generatorsCmd.PrintDefaults()
+		fmt.Fprintf(stderr, "\nExamples:\n")
+		fmt.Fprintf(stderr, "  typegen generators\n")
+		fmt.Fprintf(stderr, "  typegen generators -format json go\n")
+	}
+	
+	if err := generatorsCmd.Parse(args); err != nil {
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "Error: unknown format %q (expected text or json)\n", *format)
+		return 1
+	}
+	
+	infos := generators.Infos()
+	if generatorsCmd.NArg() > 0 {
+		infos = nil
+		for _, name := range generatorsCmd.Args() {
+			info, err := generators.GetInfo(name)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\nAvailable generators: %s\n", err, strings.Join(generators.List(), ", "))
+				return 1
+			}
+			infos = append(infos, info)
+		}
+	}
+	
+	if *format == "json" {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s\n", data)
+		return 0
+	}
+	
+	writeGeneratorInfos(stdout, infos)
+	return 0
+}
+
+// writeGeneratorInfos lists generators with their description and an aligned
+// table of config options
+func writeGeneratorInfos(w io.Writer, infos []generators.Info) {
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if info.Description != "" {
+			fmt.Fprintf(w, "  %s - %s\n", info.Name, info.Description)
+		} else {
+			fmt.Fprintf(w, "  %s\n", info.Name)
+		}
+		if len(info.Aliases) > 0 {
+			aliases := make([]string, len(info.Aliases))
+			for i, alias := range info.Aliases {
+				aliases[i] = alias
+				if slices.Contains(info.Deprecated, alias) {
+					aliases[i] += " (deprecated)"
+				}
+			}
+			fmt.Fprintf(w, "      aliases: %s\n", strings.Join(aliases, ", "))
+		}
+		if len(info.Options) == 0 {
+			fmt.Fprintf(w, "      (no config options)\n")
+			continue
+		}
+		
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, option := range info.Options {
+			defaultValue := option.Default
+			if defaultValue == "" {
+				defaultValue = "-"
+			}
+			fmt.Fprintf(table, "      %s\t%s\tdefault: %s\t%s\n", option.Key, option.Type, defaultValue, option.Description)
+		}
+		table.Flush()
+	}
+}
+
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	versionCmd := flag.NewFlagSet("version", flag.ContinueOnError)
+	versionCmd.SetOutput(stderr)
+	
+	versionCmd.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: typegen version\n\n")
+		fmt.Fprintf(stderr, "Print the typegen version, git commit and build date\n")
+	}
+	
+	if err := versionCmd.Parse(args); err != nil {
+		return 1
+	}
+	if versionCmd.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: version takes no arguments\n\n")
+		versionCmd.Usage()
+		return 1
+	}
+	
+	fmt.Fprintln(stdout, version.String())
+	return 0
+}
@@ -0,0 +1,1487 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/build"
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/graph"
+	"github.com/WhatsApp-Platform/typegen/version"
+)
+
+// writeModule creates a temporary module from filename -> source
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidateValidModule(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":       "struct User {\n  id: int64\n  role: Role\n}\n",
+		"role.tg":       "enum Role {\n  admin\n  member\n}\n",
+		"auth/token.tg": "struct Token {\n  value: string\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstdout: %s\nstderr: %s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "is valid") {
+		t.Errorf("expected a success message, got %q", stdout.String())
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":  "struct User {\n  userName: string\n  role: Role\n}\n",
+		"other.tg": "struct Other {\n  id: int64\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != 3 {
+		t.Fatalf("expected exit code 3, got %d\nstderr: %s", code, stderr.String())
+	}
+
+	output := stderr.String()
+	for _, expected := range []string{
+		"user.tg:",
+		"field name 'userName' should follow snake_case convention",
+		"undefined type 'Role'",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+}
+
+func TestValidateSingleFile(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":   "struct User {\n  role: Role\n}\n",
+		"role.tg":   "enum Role {\n  admin\n}\n",
+		"broken.tg": "struct Broken {\n  bad: Missing\n}\n",
+	})
+
+	// References to sibling files resolve, and errors elsewhere are not reported
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{filepath.Join(dir, "user.tg")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstdout: %s", code, stdout.String())
+	}
+
+	if code := runValidate([]string{filepath.Join(dir, "broken.tg")}, &stdout, &stderr); code != 3 {
+		t.Fatalf("expected exit code 3, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "undefined type 'Missing'") {
+		t.Errorf("expected the undefined type in output:\n%s", stderr.String())
+	}
+}
+
+func TestValidateUnusedTypes(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":       "struct User {\n  id: int64\n}\n",
+		"auth/token.tg": "struct Token {\n  value: string\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != 0 || stderr.Len() != 0 {
+		t.Fatalf("expected no warnings by default, got exit code %d and %q", code, stderr.String())
+	}
+
+	tests := []struct {
+		scope    string
+		expected []string
+		absent   string
+	}{
+		{"submodules", []string{"auth/token.tg:", "struct 'Token' is never referenced"}, "'User'"},
+		{"all", []string{"struct 'Token' is never referenced", "struct 'User' is never referenced"}, ""},
+	}
+	for _, tt := range tests {
+		stdout.Reset()
+		stderr.Reset()
+		if code := runValidate([]string{"-unused-types", tt.scope, dir}, &stdout, &stderr); code != exitError {
+			t.Fatalf("%s: expected exit code %d for warnings, got %d\nstderr: %s", tt.scope, exitError, code, stderr.String())
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(stderr.String(), expected) {
+				t.Errorf("%s: expected %q in output:\n%s", tt.scope, expected, stderr.String())
+			}
+		}
+		if tt.absent != "" && strings.Contains(stderr.String(), tt.absent) {
+			t.Errorf("%s: expected no %s in output:\n%s", tt.scope, tt.absent, stderr.String())
+		}
+	}
+
+	if code := runValidate([]string{"-unused-types", "root", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown scope, got %d", code)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":       "struct User {\n  userName: string\n}\n",
+		"auth/token.tg": "struct Token {\n  value: string\n}\n",
+	})
+
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"-rule", "naming_convention=warning"}, exitError, "field name 'userName' should follow snake_case convention"},
+		{[]string{"-rule", "naming_convention=warning"}, exitError, "use -fail-on error to allow them"},
+		{[]string{"-rule", "naming_convention=warning", "-fail-on", "error"}, exitOK, "field name 'userName' should follow snake_case convention"},
+		{[]string{"-rule", "naming_convention=off"}, exitOK, ""},
+		{[]string{"-rule", "naming_convention=off", "-fail-on", "error"}, exitOK, ""},
+		{[]string{"-rule", "naming_convention=off,unused_type=error", "-unused-types", "submodules"}, exitValidation, "struct 'Token' is never referenced"},
+		{[]string{"-rule", "naming_convention=off", "-unused-types", "submodules"}, exitError, "struct 'Token' is never referenced"},
+		{[]string{"-rule", "naming=off"}, exitError, `unknown validation rule "naming"`},
+		{[]string{"-rule", "naming_convention"}, exitError, "must be rule=severity"},
+		{[]string{"-rule", "naming_convention=fatal"}, exitError, "rule naming_convention"},
+		{[]string{"-fail-on", "info"}, exitError, "-fail-on must be error or warning"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runValidate(append(tt.args, dir), &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d\nstderr: %s", tt.args, tt.code, code, stderr.String())
+		}
+		if tt.expected == "" && stderr.Len() != 0 {
+			t.Errorf("%v: expected no output, got:\n%s", tt.args, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), tt.expected) {
+			t.Errorf("%v: expected %q in output:\n%s", tt.args, tt.expected, stderr.String())
+		}
+	}
+
+	// Warnings make the JSON output invalid too
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{"-format", "json", "-rule", "naming_convention=warning", dir}, &stdout, &stderr); code != exitError {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitError, code, stderr.String())
+	}
+	if output := decodeValidateContract(t, stdout.Bytes()); output.Valid || len(output.Diagnostics) != 1 || output.Diagnostics[0].Severity != "warning" {
+		t.Errorf("expected an invalid result with one warning, got %+v", output)
+	}
+}
+
+func TestValidateReservedWords(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"route.tg": "struct Route {\n  from: string\n  func: string\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0 without generators, got %d\nstderr: %s", code, stderr.String())
+	}
+
+	stderr.Reset()
+	if code := runValidate([]string{"-generator", "go,python", dir}, &stdout, &stderr); code != 3 {
+		t.Fatalf("expected exit code 3, got %d\nstderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "field 'from' of struct 'Route' is a reserved word in Python") || strings.Contains(stderr.String(), "'func'") {
+		t.Errorf("expected only from to be reserved, got:\n%s", stderr.String())
+	}
+
+	if code := runValidate([]string{"-generator", "cobol", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown generator, got %d", code)
+	}
+}
+
+func TestValidateParseError(t *testing.T) {
+	dir := writeModule(t, map[string]string{"broken.tg": "struct {\n"})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{dir}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "parse error") {
+		t.Errorf("expected a parse error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestValidateUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runValidate(nil, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 without arguments, got %d", code)
+	}
+	if code := runValidate([]string{filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for a missing path, got %d", code)
+	}
+}
+
+// validateContract is the documented output of validate -format json.
+// Decoding with unknown fields disallowed catches changes to the format.
+type validateContract struct {
+	Valid       bool `json:"valid"`
+	Diagnostics []struct {
+		Severity   string   `json:"severity"`
+		Rule       string   `json:"rule"`
+		File       string   `json:"file"`
+		Line       int      `json:"line"`
+		Column     int      `json:"column"`
+		Message    string   `json:"message"`
+		Suggestion string   `json:"suggestion"`
+		Got        string   `json:"got"`
+		Expected   []string `json:"expected"`
+	} `json:"diagnostics"`
+}
+
+func decodeValidateContract(t *testing.T, data []byte) validateContract {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var output validateContract
+	if err := decoder.Decode(&output); err != nil {
+		t.Fatalf("output doesn't match the documented format: %v\n%s", err, data)
+	}
+	return output
+}
+
+func TestValidateJSON(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":  "struct User {\n  userName: string\n  role: Role\n}\n",
+		"other.tg": "struct Other {\n  id: int64\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{"-format", "json", dir}, &stdout, &stderr); code != exitValidation {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitValidation, code, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected nothing on stderr, got %q", stderr.String())
+	}
+	output := decodeValidateContract(t, stdout.Bytes())
+	if output.Valid || len(output.Diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics of an invalid module, got %+v", output)
+	}
+	naming, undefined := output.Diagnostics[0], output.Diagnostics[1]
+	if naming.Severity != "error" || naming.Rule != "naming_convention" || naming.File != "user.tg" || naming.Line != 2 || naming.Column != 3 || naming.Suggestion != "use 'user_name'" {
+		t.Errorf("unexpected naming diagnostic: %+v", naming)
+	}
+	if undefined.Rule != "undefined_type" || undefined.Line != 3 || undefined.Message != "undefined type 'Role'" {
+		t.Errorf("unexpected undefined type diagnostic: %+v", undefined)
+	}
+
+	// Syntax errors have the same format, with what the parser expected
+	broken := writeModule(t, map[string]string{"user.tg": "struct User {\n  id int64\n}\n"})
+	stdout.Reset()
+	if code := runValidate([]string{"-format", "json", broken}, &stdout, &stderr); code != exitParse {
+		t.Fatalf("expected exit code %d, got %d", exitParse, code)
+	}
+	output = decodeValidateContract(t, stdout.Bytes())
+	if len(output.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", output)
+	}
+	if syntax := output.Diagnostics[0]; syntax.Rule != "syntax" || syntax.Line != 2 || syntax.Column != 6 || syntax.Got != "int64" || len(syntax.Expected) != 1 {
+		t.Errorf("unexpected syntax diagnostic: %+v", syntax)
+	}
+
+	// A valid module has an empty list of diagnostics
+	valid := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+	stdout.Reset()
+	if code := runValidate([]string{"-format", "json", valid}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), `"diagnostics": []`) || !decodeValidateContract(t, stdout.Bytes()).Valid {
+		t.Errorf("expected a valid result without diagnostics, got %s", stdout.String())
+	}
+}
+
+func TestValidateSARIF(t *testing.T) {
+	dir := writeModule(t, map[string]string{"auth/token.tg": "struct Token {\n  value: Secret\n}\n"})
+
+	var stdout, stderr bytes.Buffer
+	if code := runValidate([]string{"-format", "sarif", dir}, &stdout, &stderr); code != exitValidation {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitValidation, code, stderr.String())
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Fatalf("expected a SARIF log on stdout: %v\n%s", err, stdout.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected SARIF log:\n%s", stdout.String())
+	}
+	result := log.Runs[0].Results[0]
+	if result.RuleID != "undefined_type" || result.Locations[0].PhysicalLocation.ArtifactLocation.URI != "auth/token.tg" {
+		t.Errorf("expected an undefined type in auth/token.tg, got %+v", result)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: int64\n}\n\nenum Role {\n  admin\n}\n",
+	})
+	compatible := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: int64\n  email: ?string\n}\n\nenum Role {\n  admin\n  member\n}\n",
+	})
+	breaking := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: string\n}\n\nenum Role {\n  admin\n}\n",
+	})
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{"no breaking changes", []string{old, compatible}, 0, "Compatible changes (1):"},
+		{"warnings fail at warning level", []string{"-level", "warning", old, compatible}, 1, "Warning changes (1):"},
+		{"breaking change", []string{old, breaking}, 1, "User.id: type changed from int64 to string"},
+		{"json", []string{"-format", "json", old, breaking}, 1, `"kind": "field-type-changed"`},
+		{"identical", []string{old, old}, 0, "No changes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runDiff(tt.args, &stdout, &stderr); code != tt.code {
+				t.Fatalf("expected exit code %d, got %d\nstdout: %s\nstderr: %s", tt.code, code, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.contains) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.contains, stdout.String())
+			}
+		})
+	}
+}
+
+func TestDiffUsage(t *testing.T) {
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+
+	for _, args := range [][]string{
+		{dir},
+		{"-level", "fatal", dir, dir},
+		{"-format", "yaml", dir, dir},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := runDiff(args, &stdout, &stderr); code != 1 {
+			t.Errorf("runDiff(%v): expected exit code 1, got %d", args, code)
+		}
+		if stderr.Len() == 0 {
+			t.Errorf("runDiff(%v): expected an error message", args)
+		}
+	}
+}
+
+func TestFmt(t *testing.T) {
+	messy := "// Users\nstruct User{ // the user\n  id:int64   // primary key\n}\n"
+	formatted := "// Users\nstruct User { // the user\n  id: int64 // primary key\n}\n"
+	dir := writeModule(t, map[string]string{"user.tg": messy})
+	path := filepath.Join(dir, "user.tg")
+
+	var stdout, stderr bytes.Buffer
+	if code := runFmt([]string{"-l", dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1 for an unformatted file, got %d\nstderr: %s", code, stderr.String())
+	}
+	if stdout.String() != path+"\n" {
+		t.Errorf("expected -l to list %s, got %q", path, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runFmt([]string{"-w", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != formatted {
+		t.Errorf("expected -w to write:\n%s\ngot:\n%s", formatted, data)
+	}
+
+	stdout.Reset()
+	if code := runFmt([]string{"-l", dir}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("expected a formatted file to pass -l, got exit code %d and %q", code, stdout.String())
+	}
+}
+
+func TestGraph(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: int64\n  role: Role\n}\n\nenum Role {\n  admin\n}\n\nstruct Group {\n  owner: User\n}\n",
+	})
+
+	tests := []struct {
+		name        string
+		args        []string
+		contains    []string
+		notContains []string
+	}{
+		{"dot", []string{dir}, []string{"digraph typegen {", `"User" -> "Role"`, `"Group" -> "User"`}, nil},
+		{"focus", []string{"-focus", "User", dir}, []string{`"User" -> "Role"`}, []string{`"Group"`}},
+		{"reverse", []string{"-focus", "User", "-reverse", dir}, []string{`"User" -> "Group"`}, []string{`"Role"`}},
+		{"json", []string{"-format", "json", "-focus", "Group", "-depth", "1", dir}, []string{`"from": "Group"`}, []string{`"Role"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runGraph(tt.args, &stdout, &stderr); code != 0 {
+				t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+			}
+			for _, expected := range tt.contains {
+				if !strings.Contains(stdout.String(), expected) {
+					t.Errorf("expected output to contain %s, got:\n%s", expected, stdout.String())
+				}
+			}
+			for _, unexpected := range tt.notContains {
+				if strings.Contains(stdout.String(), unexpected) {
+					t.Errorf("expected output not to contain %s, got:\n%s", unexpected, stdout.String())
+				}
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runGraph([]string{"-focus", "Missing", dir}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "no declaration named") {
+		t.Errorf("expected an error for an unknown focus, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestInitBuildsForEveryGenerator(t *testing.T) {
+	for _, generator := range generators.List() {
+		t.Run(generator, func(t *testing.T) {
+			dir := t.TempDir()
+			// typegen.yaml paths are resolved from the working directory
+			t.Chdir(dir)
+
+			var stdout, stderr bytes.Buffer
+			if code := runInit([]string{"-generator", generator}, &stdout, &stderr); code != 0 {
+				t.Fatalf("init failed with exit code %d: %s", code, stderr.String())
+			}
+
+			if code := runValidate([]string{build.SchemasDir}, &stdout, &stderr); code != 0 {
+				t.Fatalf("example schema is invalid: %s", stdout.String())
+			}
+
+			config, err := build.LoadConfig("typegen.yaml")
+			if err != nil {
+				t.Fatalf("failed to load the generated config: %v", err)
+			}
+			if len(config.Generate) != 1 || config.Generate[0].Generator != generator {
+				t.Fatalf("expected a single %s task, got %+v", generator, config.Generate)
+			}
+			if _, err := build.NewBuilder(config).Build(context.Background()); err != nil {
+				t.Fatalf("build failed: %v", err)
+			}
+			if files, _ := filepath.Glob(filepath.Join(config.Generate[0].Output, "*")); len(files) == 0 {
+				t.Errorf("expected generated files in %s", config.Generate[0].Output)
+			}
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	if code := runInit([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("init failed with exit code %d: %s", code, stderr.String())
+	}
+	config, err := os.ReadFile(filepath.Join(dir, "typegen.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without -generator every task is an example to uncomment
+	for _, generator := range generators.List() {
+		if !strings.Contains(string(config), "  # - generator: "+generator+"\n") {
+			t.Errorf("expected a commented task for %s, got:\n%s", generator, config)
+		}
+	}
+
+	// Existing files are not overwritten without -force
+	stdout.Reset()
+	stderr.Reset()
+	if code := runInit([]string{"-generator", "go", dir}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "refusing to overwrite") {
+		t.Errorf("expected init to refuse to overwrite, got %d: %s", code, stderr.String())
+	}
+	if unchanged, _ := os.ReadFile(filepath.Join(dir, "typegen.yaml")); string(unchanged) != string(config) {
+		t.Error("typegen.yaml was modified without -force")
+	}
+
+	stderr.Reset()
+	if code := runInit([]string{"-force", "-generator", "go", "-output", "./out", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("init -force failed with exit code %d: %s", code, stderr.String())
+	}
+	forced, _ := os.ReadFile(filepath.Join(dir, "typegen.yaml"))
+	if !strings.Contains(string(forced), "  - generator: go\n    input: ./schemas\n    output: ./out\n    config:\n      module-name: \"example.com/project/out\"\n") {
+		t.Errorf("expected a pre-filled go task, got:\n%s", forced)
+	}
+
+	stderr.Reset()
+	if code := runInit([]string{"-force", "-generator", "cobol", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected an unknown generator to fail, got %d", code)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runGenerators([]string{"-format", "json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	var infos []generators.Info
+	if err := json.Unmarshal(stdout.Bytes(), &infos); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, stdout.String())
+	}
+
+	byName := make(map[string]generators.Info)
+	for _, info := range infos {
+		if info.Description == "" {
+			t.Errorf("generator %s has no description", info.Name)
+		}
+		generator, _ := generators.Get(info.Name)
+		if describer, ok := generator.(generators.Describer); !ok || describer.Name() != info.Name {
+			t.Errorf("generator %s doesn't describe itself under its registered name", info.Name)
+		}
+		byName[info.Name] = info
+	}
+	if len(byName) != len(generators.List()) {
+		t.Errorf("expected %d generators, got %d", len(generators.List()), len(byName))
+	}
+	for _, name := range []string{"go", "python+pydantic"} {
+		info, exists := byName[name]
+		if !exists {
+			t.Errorf("generator %s is not listed", name)
+			continue
+		}
+		if len(info.Options) == 0 {
+			t.Errorf("generator %s documents no options", name)
+		}
+		for _, option := range info.Options {
+			if option.Key == "" || option.Type == "" || option.Description == "" {
+				t.Errorf("generator %s has an incomplete option: %+v", name, option)
+			}
+		}
+	}
+
+	stdout.Reset()
+	if code := runGenerators([]string{"go"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	for _, expected := range []string{"go - ", "module-name", "default: false"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "pydantic") {
+		t.Errorf("expected only the go generator, got:\n%s", stdout.String())
+	}
+
+	// Aliases show the generator they name, and are listed with it
+	stdout.Reset()
+	if code := runGenerators([]string{"python"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "  python+pydantic - ") || !strings.Contains(stdout.String(), "aliases: python\n") {
+		t.Errorf("expected the python+pydantic generator with its alias, got:\n%s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := runGenerators([]string{"missing"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "not found") {
+		t.Errorf("expected an error for an unknown generator, got %d: %s", code, stderr.String())
+	}
+}
+
+func TestVersion(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runVersion(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "typegen "+version.Version+" (commit ") {
+		t.Errorf("unexpected version output: %q", stdout.String())
+	}
+
+	if code := runVersion([]string{"extra"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for an extra argument, got %d", code)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg": "import auth\n\nconst MAX = 10\n\nstruct User {\n  id: int64\n  tags: ?[]string\n  token: auth.Token\n}\n\nenum Role {\n  admin\n  guest: [string]int32\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runParse([]string{"-format", "json", filepath.Join(dir, "user.tg")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+
+	var program map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &program); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, stdout.String())
+	}
+	if program["kind"] != "program" {
+		t.Errorf("expected a program, got %v", program["kind"])
+	}
+	imports := program["imports"].([]any)
+	if len(imports) != 1 || imports[0].(map[string]any)["path"] != "auth" {
+		t.Errorf("unexpected imports: %v", imports)
+	}
+
+	declarations := program["declarations"].([]any)
+	var kinds []string
+	for _, decl := range declarations {
+		kinds = append(kinds, decl.(map[string]any)["kind"].(string))
+	}
+	if strings.Join(kinds, " ") != "constant struct enum" {
+		t.Fatalf("unexpected declaration kinds: %v", kinds)
+	}
+
+	constant := declarations[0].(map[string]any)
+	if value := constant["value"].(map[string]any); value["kind"] != "int" || value["value"] != float64(10) {
+		t.Errorf("unexpected constant value: %v", value)
+	}
+
+	fields := declarations[1].(map[string]any)["fields"].([]any)
+	tags := fields[1].(map[string]any)
+	if tags["name"] != "tags" || tags["optional"] != true {
+		t.Errorf("unexpected field: %v", tags)
+	}
+	array := tags["type"].(map[string]any)
+	if array["kind"] != "array" || array["element"].(map[string]any)["name"] != "string" {
+		t.Errorf("unexpected field type: %v", array)
+	}
+	pos := tags["pos"].(map[string]any)
+	if pos["line"] != float64(7) || !strings.HasSuffix(pos["file"].(string), "user.tg") {
+		t.Errorf("unexpected position: %v", pos)
+	}
+	if named := fields[2].(map[string]any)["type"].(map[string]any); named["kind"] != "named" || named["name"] != "auth.Token" {
+		t.Errorf("unexpected named type: %v", named)
+	}
+
+	variants := declarations[2].(map[string]any)["variants"].([]any)
+	if _, exists := variants[0].(map[string]any)["payload"]; exists {
+		t.Errorf("expected no payload for a simple variant: %v", variants[0])
+	}
+	if payload := variants[1].(map[string]any)["payload"].(map[string]any); payload["kind"] != "map" {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+}
+
+func TestModuleJSON(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":          "struct User {\n  id: int64\n}\n",
+		"auth/token.tg":    "type Token = string\n",
+		"auth/oauth/id.tg": "const ISSUER = \"typegen\"\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runModule([]string{"-format", "json", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+
+	var module map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &module); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, stdout.String())
+	}
+	if _, exists := module["files"].(map[string]any)["user.tg"]; !exists {
+		t.Errorf("expected user.tg in the module files: %v", module["files"])
+	}
+
+	auth := module["submodules"].(map[string]any)["auth"].(map[string]any)
+	if auth["name"] != "auth" {
+		t.Errorf("unexpected submodule: %v", auth)
+	}
+	token := auth["files"].(map[string]any)["token.tg"].(map[string]any)
+	alias := token["declarations"].([]any)[0].(map[string]any)
+	if alias["kind"] != "alias" || alias["type"].(map[string]any)["kind"] != "primitive" {
+		t.Errorf("unexpected alias: %v", alias)
+	}
+
+	oauth := auth["submodules"].(map[string]any)["oauth"].(map[string]any)
+	if _, exists := oauth["files"].(map[string]any)["id.tg"]; !exists {
+		t.Errorf("expected a nested submodule with id.tg: %v", oauth)
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"broken.tg":     "struct User {\n  id int64\n}\n",
+		"bad/broken.tg": "struct User {\n  id int64\n}\n",
+	})
+
+	tests := []struct {
+		name        string
+		run         func(args []string, stdout, stderr io.Writer) int
+		args        []string
+		diagnostics int
+	}{
+		{"parse", runParse, []string{"-format", "json", filepath.Join(dir, "broken.tg")}, 1},
+		{"module", runModule, []string{"-format", "json", filepath.Join(dir, "bad")}, 1},
+		// Every broken file of the module is reported
+		{"nested module", runModule, []string{"-format", "json", dir}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := tt.run(tt.args, &stdout, &stderr); code != 2 {
+				t.Fatalf("expected exit code 2, got %d", code)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("expected nothing on stdout, got %s", stdout.String())
+			}
+
+			var output struct {
+				Error       string
+				Diagnostics []struct {
+					File    string
+					Line    int
+					Column  int
+					Message string
+				}
+			}
+			if err := json.Unmarshal(stderr.Bytes(), &output); err != nil {
+				t.Fatalf("failed to decode JSON error: %v\n%s", err, stderr.String())
+			}
+			if output.Error == "" || len(output.Diagnostics) != tt.diagnostics {
+				t.Fatalf("expected an error with %d diagnostics, got %+v", tt.diagnostics, output)
+			}
+			for _, diagnostic := range output.Diagnostics {
+				if !strings.HasSuffix(diagnostic.File, "broken.tg") || diagnostic.Line != 2 || diagnostic.Column == 0 || diagnostic.Message == "" {
+					t.Errorf("unexpected diagnostic: %+v", diagnostic)
+				}
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runParse([]string{"-format", "json", filepath.Join(dir, "missing.tg")}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), `"error":"file`) {
+		t.Errorf("expected a JSON error for a missing file, got %d: %s", code, stderr.String())
+	}
+
+	// The text output groups the errors by file
+	stderr.Reset()
+	if code := runStats([]string{"-no-color", dir}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2, got %d: %s", code, stderr.String())
+	}
+	for _, path := range []string{filepath.Join(dir, "bad", "broken.tg"), filepath.Join(dir, "broken.tg")} {
+		if !strings.Contains(stderr.String(), "failed to parse "+path+": parse errors occurred:\n"+path+":2:") {
+			t.Errorf("expected the errors of %s, got:\n%s", path, stderr.String())
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"valid/user.tg":   "struct User {\n  id: int64\n}\n",
+		"broken/user.tg":  "struct {\n",
+		"invalid/user.tg": "struct User {\n  role: Missing\n}\n",
+		"not-a-directory": "",
+		"bad-config.yaml": "version: 1\ngenerate:\n  - generator: cobol\n    output: out\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+	for name, input := range map[string]string{"parse.yaml": "broken", "validate.yaml": "invalid"} {
+		config := "generate:\n  - generator: go\n    input: " + path(input) + "\n    output: " + path("out") + "\n"
+		if err := os.WriteFile(path(name), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", nil, exitError},
+		{"unknown command", []string{"frobnicate"}, exitError},
+		{"unknown flag", []string{"validate", "-frobnicate", path("valid")}, exitError},
+		{"missing argument", []string{"generate", "-generator", "go", "-o", path("out")}, exitError},
+		{"unknown generator", []string{"generate", "-generator", "cobol", "-o", path("out"), path("valid")}, exitError},
+		{"parse error", []string{"parse", path("broken/user.tg")}, exitParse},
+		{"module parse error", []string{"module", path("broken")}, exitParse},
+		{"generate parse error", []string{"generate", "-generator", "go", "-o", path("out"), path("broken")}, exitParse},
+		{"validation error", []string{"validate", path("invalid")}, exitValidation},
+		{"generate validation error", []string{"generate", "-generator", "go", "-o", path("out"), path("invalid")}, exitValidation},
+		{"generation error", []string{"generate", "-generator", "go", "-o", path("not-a-directory"), path("valid")}, exitGeneration},
+		{"config error", []string{"build", "-f", path("bad-config.yaml")}, exitConfig},
+		{"missing config", []string{"build", "-f", path("missing.yaml")}, exitConfig},
+		{"build parse error", []string{"build", "-f", path("parse.yaml")}, exitParse},
+		{"build validation error", []string{"build", "-f", path("validate.yaml")}, exitValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.code {
+				t.Fatalf("expected exit code %d, got %d\nstderr: %s", tt.code, code, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("expected errors on stderr only, got stdout: %s", stdout.String())
+			}
+			if stderr.Len() == 0 {
+				t.Error("expected an error message on stderr")
+			}
+		})
+	}
+}
+
+func TestResultsGoToStdout(t *testing.T) {
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+
+	for _, args := range [][]string{
+		{"help"},
+		{"parse", filepath.Join(dir, "user.tg")},
+		{"module", dir},
+		{"validate", dir},
+		{"graph", dir},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, code, stderr.String())
+		}
+		if stdout.Len() == 0 {
+			t.Errorf("%v: expected the result on stdout", args)
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("%v: expected nothing on stderr, got %s", args, stderr.String())
+		}
+	}
+}
+
+func TestParseMultipleFiles(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":   "struct User {\n  id: int64\n}\n",
+		"role.tg":   "enum Role {\n  admin\n}\n",
+		"broken.tg": "struct {\n",
+		"bad.tg":    "enum {\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	var stdout, stderr bytes.Buffer
+	args := []string{path("user.tg"), path("broken.tg"), path("role.tg"), path("bad.tg"), path("missing.tg")}
+	if code := runParse(args, &stdout, &stderr); code != exitParse {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitParse, code, stderr.String())
+	}
+
+	// Files after a failure are still parsed, and every failure is reported
+	for _, expected := range []string{"Successfully parsed " + path("user.tg"), "Successfully parsed " + path("role.tg"), "Parse summary: 2 passed, 3 failed"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected stdout to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	for _, expected := range []string{"Parse error in " + path("broken.tg"), "Parse error in " + path("bad.tg"), path("missing.tg") + "' does not exist"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("expected stderr to contain %q, got:\n%s", expected, stderr.String())
+		}
+	}
+}
+
+func TestParseQuietAndGlobs(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: int64\n}\n",
+		"role.tg": "enum Role {\n  admin\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runParse([]string{"-quiet", filepath.Join(dir, "*.tg")}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	expected := "ok  " + filepath.Join(dir, "role.tg") + "\nok  " + filepath.Join(dir, "user.tg") + "\nParse summary: 2 passed, 0 failed\n"
+	if stdout.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	// A pattern matching nothing is a failure
+	stdout.Reset()
+	stderr.Reset()
+	if code := runParse([]string{filepath.Join(dir, "*.proto"), filepath.Join(dir, "user.tg")}, &stdout, &stderr); code != exitError {
+		t.Fatalf("expected exit code %d, got %d", exitError, code)
+	}
+	if !strings.Contains(stderr.String(), "no files match") || !strings.Contains(stdout.String(), "1 passed, 1 failed") {
+		t.Errorf("expected the unmatched pattern to be reported, got:\nstdout: %s\nstderr: %s", stdout.String(), stderr.String())
+	}
+
+	// With several files, -format json prints an array
+	stdout.Reset()
+	if code := runParse([]string{"-format", "json", filepath.Join(dir, "*.tg")}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	var programs []map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &programs); err != nil || len(programs) != 2 {
+		t.Errorf("expected an array of 2 programs, got %v: %s", err, stdout.String())
+	}
+}
+
+func TestGenerateSingleFile(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":         "struct User {\n  id: int64\n}\n",
+		"other.tg":        "struct Other {\n  id: int64\n}\n",
+		"User-Profile.tg": "struct Profile {\n  id: int64\n}\n",
+		"invalid.tg":      "struct Invalid {\n  role: Missing\n}\n",
+	})
+
+	// Only the file is generated, not its siblings
+	for generator, expected := range map[string][]string{
+		"go":              {"user.go"},
+		"python+pydantic": {"__init__.py", "user.py"},
+	} {
+		output := t.TempDir()
+		var stdout, stderr bytes.Buffer
+		if code := runGenerate([]string{"-generator", generator, "-o", output, filepath.Join(dir, "user.tg")}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%s: expected exit code 0, got %d\nstderr: %s", generator, code, stderr.String())
+		}
+		entries, err := os.ReadDir(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, entry := range entries {
+			files = append(files, entry.Name())
+		}
+		if strings.Join(files, " ") != strings.Join(expected, " ") {
+			t.Errorf("%s: expected files %v, got %v", generator, expected, files)
+		}
+	}
+
+	// The file is validated like a module
+	var stdout, stderr bytes.Buffer
+	if code := runGenerate([]string{"-generator", "go", "-o", t.TempDir(), filepath.Join(dir, "invalid.tg")}, &stdout, &stderr); code != exitValidation {
+		t.Errorf("expected exit code %d for an invalid file, got %d", exitValidation, code)
+	}
+
+	// The module name comes from the file name, so it must be snake_case
+	stderr.Reset()
+	if code := runGenerate([]string{"-generator", "go", "-o", t.TempDir(), filepath.Join(dir, "User-Profile.tg")}, &stdout, &stderr); code != exitValidation {
+		t.Errorf("expected exit code %d for an invalid module name, got %d", exitValidation, code)
+	}
+	if !strings.Contains(stderr.String(), "rename the file to 'user_profile.tg'") {
+		t.Errorf("expected a snake_case suggestion, got: %s", stderr.String())
+	}
+}
+
+func TestGenerateConfigValidation(t *testing.T) {
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+	output := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	if code := runGenerate([]string{"-generator", "dart", "-c", "packge=models", "-o", output, dir}, &stdout, &stderr); code != exitConfig {
+		t.Errorf("expected exit code %d for an unknown option, got %d", exitConfig, code)
+	}
+	if !strings.Contains(stderr.String(), `unknown option "packge"; options are package`) {
+		t.Errorf("expected the unknown option to be reported, got: %s", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := runGenerate([]string{"-generator", "go", "-c", "testdata=yes", "-o", output, dir}, &stdout, &stderr); code != exitConfig {
+		t.Errorf("expected exit code %d for a malformed value, got %d", exitConfig, code)
+	}
+	if !strings.Contains(stderr.String(), `invalid testdata "yes" (expected true or false)`) {
+		t.Errorf("expected the malformed value to be reported, got: %s", stderr.String())
+	}
+
+	// Nothing is generated
+	if entries, _ := os.ReadDir(output); len(entries) != 0 {
+		t.Errorf("expected no generated files, got %d", len(entries))
+	}
+}
+
+func TestGenerateDeprecatedName(t *testing.T) {
+	generators.RegisterAlias("golang-legacy", "go")
+	generators.Deprecate("golang-legacy", "")
+	defer generators.Unregister("golang-legacy")
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+
+	var stdout, stderr bytes.Buffer
+	if code := runGenerate([]string{"-generator", "golang-legacy", "-o", t.TempDir(), dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `generator name "golang-legacy" is deprecated; use "go" instead`) {
+		t.Errorf("expected a deprecation warning, got: %s", stderr.String())
+	}
+}
+
+func TestGenerateClean(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":       "struct User {\n  id: int64\n}\n",
+		"auth/token.tg": "struct Token {\n  value: string\n}\n",
+	})
+	output := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	if code := runGenerate([]string{"-generator", "go", "-o", output, dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	stale := filepath.Join(output, "auth", "token.go")
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("expected the submodule to be generated: %v", err)
+	}
+	handWritten := filepath.Join(output, "user_helpers.go")
+	if err := os.WriteFile(handWritten, []byte("package test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The submodule is deleted, so its generated file is stale
+	if err := os.RemoveAll(filepath.Join(dir, "auth")); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr.Reset()
+	if code := runGenerate([]string{"-generator", "go", "-o", output, "-clean", "-dry-run", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Would remove stale "+stale) {
+		t.Errorf("expected the dry run to list %s, got: %s", stale, stderr.String())
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Errorf("expected the dry run not to remove anything: %v", err)
+	}
+
+	stderr.Reset()
+	if code := runGenerate([]string{"-generator", "go", "-o", output, "-clean", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Dir(stale)); !os.IsNotExist(err) {
+		t.Errorf("expected the stale submodule output to be removed, got %v", err)
+	}
+	for _, path := range []string{filepath.Join(output, "user.go"), handWritten} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+
+	stderr.Reset()
+	if code := runGenerate([]string{"-generator", "go", "-o", output, "-dry-run", dir}, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for -dry-run without -clean, got %d", exitError, code)
+	}
+}
+
+func TestGenerateToStdout(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg": "struct User {\n  id: int64\n}\n",
+	})
+	file := filepath.Join(dir, "user.tg")
+
+	for _, args := range [][]string{{"-o", "-"}, {"-stdout"}} {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-generator", "go"}, append(args, file)...)
+		if code := runGenerate(args, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, code, stderr.String())
+		}
+		if !strings.HasPrefix(stdout.String(), "// Code generated by TypeGen. DO NOT EDIT.\n\npackage user\n") || !strings.Contains(stdout.String(), "type User struct") {
+			t.Errorf("%v: expected the generated file on stdout, got:\n%s", args, stdout.String())
+		}
+	}
+
+	// Several files can't be printed, so they are listed instead
+	var stdout, stderr bytes.Buffer
+	if code := runGenerate([]string{"-generator", "python+pydantic", "-o", "-", file}, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for several files, got %d", exitError, code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got: %s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "python+pydantic generates 2:\n  __init__.py\n  user.py\n") {
+		t.Errorf("expected the list of generated files, got: %s", stderr.String())
+	}
+
+	for _, args := range [][]string{{"-stdout", "-o", t.TempDir()}, {"-stdout", "-clean"}} {
+		args = append([]string{"-generator", "go"}, append(args, file)...)
+		if code := runGenerate(args, &stdout, &stderr); code != exitError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitError, code)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"order.tg":           "import billing\n\nstruct Order {\n  id: int64\n  payment: billing.PaymentMethod\n}\n",
+		"billing/payment.tg": "struct Card {\n  number: string\n}\n\nenum PaymentMethod {\n  card: Card\n  cash\n}\n",
+		"wallet/payment.tg":  "struct PaymentMethod {\n  balance: int64\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runResolve([]string{dir, "billing.PaymentMethod"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	for _, expected := range []string{
+		"billing.PaymentMethod (enum)\n  defined at " + filepath.Join(dir, "billing", "payment.tg") + ":",
+		"Direct dependencies (1):\n  billing.Card  struct",
+		"Direct dependents (1):\n  Order  struct  " + filepath.Join(dir, "order.tg") + ":",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+
+	// The bare name is declared twice, so the candidates are listed
+	stdout.Reset()
+	stderr.Reset()
+	if code := runResolve([]string{dir, "PaymentMethod"}, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for an ambiguous name, got %d", exitError, code)
+	}
+	for _, expected := range []string{"billing.PaymentMethod  enum", "wallet.PaymentMethod   struct"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("expected the candidates to include %q, got:\n%s", expected, stderr.String())
+		}
+	}
+
+	// Transitive dependencies go through Card, and dependents through Order
+	stdout.Reset()
+	if code := runResolve([]string{"-transitive", "-format", "json", dir, "Card"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	var relations graph.Relations
+	if err := json.Unmarshal(stdout.Bytes(), &relations); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	var dependents []string
+	for _, node := range relations.Dependents {
+		dependents = append(dependents, node.ID)
+	}
+	if relations.Node.ID != "billing.Card" || len(relations.Dependencies) != 0 || strings.Join(dependents, " ") != "Order billing.PaymentMethod" {
+		t.Errorf("unexpected relations: %s", stdout.String())
+	}
+
+	if code := runResolve([]string{dir, "Missing"}, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for a missing type, got %d", exitError, code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"user.tg":       "import auth\n\nstruct User {\n  id: int64\n  token: ?auth.Token\n}\n",
+		"auth/token.tg": "struct Token {\n  value: string\n}\n\nenum Scope {\n  read\n  write\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := runStats([]string{"-format", "json", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	var output struct {
+		Structs             int `json:"structs"`
+		Enums               int `json:"enums"`
+		CrossFileReferences int `json:"cross_file_references"`
+		Submodules          []struct {
+			Module string `json:"module"`
+		} `json:"submodules"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("failed to decode JSON output: %v\n%s", err, stdout.String())
+	}
+	if output.Structs != 2 || output.Enums != 1 || output.CrossFileReferences != 1 || len(output.Submodules) != 2 {
+		t.Errorf("unexpected statistics: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := runStats([]string{dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "cross-file references  1") {
+		t.Errorf("expected a text report, got:\n%s", stdout.String())
+	}
+
+	if code := runStats(nil, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d without arguments, got %d", exitError, code)
+	}
+}
+
+func TestColoredDiagnostics(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"invalid/user.tg": "struct User {\n  role: Missing\n}\n",
+		"broken.tg":       "struct {\n",
+	})
+
+	tests := []struct {
+		name     string
+		terminal bool
+		env      string
+		args     []string
+		colored  bool
+	}{
+		{"terminal", true, "", []string{"validate", filepath.Join(dir, "invalid")}, true},
+		{"parse error on a terminal", true, "", []string{"parse", filepath.Join(dir, "broken.tg")}, true},
+		{"not a terminal", false, "", []string{"validate", filepath.Join(dir, "invalid")}, false},
+		{"-no-color", true, "", []string{"validate", "-no-color", filepath.Join(dir, "invalid")}, false},
+		{"NO_COLOR", true, "1", []string{"validate", filepath.Join(dir, "invalid")}, false},
+		{"json", true, "", []string{"parse", "-format", "json", filepath.Join(dir, "broken.tg")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := isTerminal
+			isTerminal = func(io.Writer) bool { return tt.terminal }
+			t.Cleanup(func() { isTerminal = original })
+			t.Setenv("NO_COLOR", tt.env)
+
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code == exitOK {
+				t.Fatal("expected the command to fail")
+			}
+			if colored := strings.Contains(stderr.String(), "\x1b["); colored != tt.colored {
+				t.Errorf("expected colored output %v, got:\n%q", tt.colored, stderr.String())
+			}
+		})
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"valid/user.tg":     "struct User {\n  id: int64\n}\n",
+		"invalid/broken.tg": "struct Broken {\n  role: Missing\n}\n",
+	})
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := fmt.Sprintf("generate:\n  - generator: go\n    input: %s\n    output: %s\n  - generator: go\n    input: %s\n    output: %s\n",
+		filepath.Join(dir, "valid"), filepath.Join(dir, "gen", "valid"), filepath.Join(dir, "invalid"), filepath.Join(dir, "gen", "invalid"))
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reportPath := filepath.Join(dir, "report.json")
+	var stdout, stderr bytes.Buffer
+	if code := runBuild([]string{"-f", configPath, "-report", reportPath}, &stdout, &stderr); code != exitValidation {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitValidation, code, stderr.String())
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("expected a report for the failed build: %v", err)
+	}
+	var report build.BuildResult
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, data)
+	}
+
+	if report.Version != version.Version || len(report.Tasks) != 2 {
+		t.Fatalf("unexpected report:\n%s", data)
+	}
+	passed, failed := report.Tasks[0], report.Tasks[1]
+	if passed.Status != build.TaskSucceeded || passed.Output != filepath.Join(dir, "gen", "valid") || len(passed.Files) != 1 || passed.Files[0].Path != "user.go" || passed.Files[0].Bytes == 0 {
+		t.Errorf("unexpected passing task: %+v", passed)
+	}
+	if failed.Status != build.TaskFailed || !strings.Contains(failed.Error, "Missing") || len(failed.Files) != 0 {
+		t.Errorf("unexpected failing task: %+v", failed)
+	}
+	if report.Summary.Succeeded != 1 || report.Summary.Failed != 1 || report.Summary.Files != 1 {
+		t.Errorf("unexpected summary: %+v", report.Summary)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got: %s", stdout.String())
+	}
+
+	if code := runBuild([]string{"-f", configPath, "-report", reportPath, "-check"}, &stdout, &stderr); code != exitError {
+		t.Errorf("expected exit code %d for -report with -check, got %d", exitError, code)
+	}
+}
+
+func TestBuildJSON(t *testing.T) {
+	dir := writeModule(t, map[string]string{"invalid/broken.tg": "struct Broken {\n  role: Missing\n}\n"})
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := fmt.Sprintf("generate:\n  - generator: go\n    input: %s\n    output: %s\n", filepath.Join(dir, "invalid"), filepath.Join(dir, "gen"))
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runBuild([]string{"-f", configPath, "-format", "json"}, &stdout, &stderr); code != exitValidation {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitValidation, code, stderr.String())
+	}
+	var report build.BuildResult
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("expected the report on stdout: %v\n%s", err, stdout.String())
+	}
+	if len(report.Tasks) != 1 || len(report.Tasks[0].Diagnostics) != 1 || report.Tasks[0].Diagnostics[0].Rule != "undefined_type" {
+		t.Errorf("expected the undefined type in the report, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Missing") {
+		t.Errorf("expected the human-readable error on stderr, got %q", stderr.String())
+	}
+}
+
+func TestBuildProgress(t *testing.T) {
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := fmt.Sprintf("generate:\n  - generator: go\n    input: %s\n    output: %s\n", dir, filepath.Join(t.TempDir(), "gen"))
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		terminal bool
+		live     bool
+	}{
+		{"terminal", nil, true, true},
+		{"quiet terminal", []string{"-quiet"}, true, false},
+		{"pipe", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := isTerminal
+			isTerminal = func(io.Writer) bool { return tt.terminal }
+			t.Cleanup(func() { isTerminal = original })
+
+			var stdout, stderr bytes.Buffer
+			if code := runBuild(append([]string{"-f", configPath}, tt.args...), &stdout, &stderr); code != exitOK {
+				t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitOK, code, stderr.String())
+			}
+
+			output := stderr.String()
+			if live := strings.Contains(output, "\r\x1b[K"); live != tt.live {
+				t.Errorf("expected live progress %v, got:\n%q", tt.live, output)
+			}
+			if tt.live {
+				if !strings.Contains(output, "[1/1 go-1] generating... 1 files written") {
+					t.Errorf("expected the status line to count written files, got:\n%q", output)
+				}
+				// The status line is cleared once the task is done
+				if !strings.HasSuffix(output, "\n") {
+					t.Errorf("expected the output to end with a log line, got:\n%q", output)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCacheFlags(t *testing.T) {
+	dir := writeModule(t, map[string]string{"user.tg": "struct User {\n  id: int64\n}\n"})
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := fmt.Sprintf("generate:\n  - generator: go\n    input: %s\n    output: %s\n", dir, filepath.Join(t.TempDir(), "gen"))
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-cache-dir", cacheDir}, "1/1 tasks succeeded\n"},
+		{[]string{"-cache-dir", cacheDir}, "1/1 tasks succeeded, 1 cached"},
+		{[]string{"-cache-dir", cacheDir, "-force"}, "1/1 tasks succeeded\n"},
+		{[]string{"-cache-dir", cacheDir, "-check"}, "cannot be used with -check"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		runBuild(append([]string{"-f", configPath}, tt.args...), &stdout, &stderr)
+		if !strings.Contains(stderr.String(), tt.expected) {
+			t.Errorf("%v: expected stderr to contain %q, got:\n%s", tt.args, tt.expected, stderr.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "build.json")); err != nil {
+		t.Errorf("expected a cache file: %v", err)
+	}
+}
+
+func TestBuildMergedInputs(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"schemas/core/order.tg":       "import payments\n\nstruct Order {\n  id: int64\n  invoice: payments.Invoice\n}\n",
+		"schemas/payments/invoice.tg": "struct Invoice {\n  amount: int64\n}\n",
+		"legacy/payments/refund.tg":   "struct Refund {\n  amount: int64\n}\n",
+	})
+	output := filepath.Join(dir, "gen")
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := "generate:\n  - generator: python+pydantic\n    input:\n      - ${CONFIG_DIR}/schemas/core\n      - ${CONFIG_DIR}/schemas/payments\n    output: ${CONFIG_DIR}/gen\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runBuild([]string{"-f", configPath}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitOK, code, stderr.String())
+	}
+
+	// Each input is a subpackage of one package, named after its directory
+	var files []string
+	filepath.WalkDir(output, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			rel, _ := filepath.Rel(output, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	expected := []string{"__init__.py", "core/__init__.py", "core/order.py", "payments/__init__.py", "payments/invoice.py"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+	order, _ := os.ReadFile(filepath.Join(output, "core", "order.py"))
+	if !strings.Contains(string(order), "invoice: payments.Invoice") {
+		t.Errorf("expected the reference across inputs, got:\n%s", order)
+	}
+
+	// Inputs with the same directory name would be the same subpackage
+	config = "generate:\n  - generator: python+pydantic\n    input:\n      - ${CONFIG_DIR}/schemas/*\n      - ${CONFIG_DIR}/legacy/*\n    output: ${CONFIG_DIR}/gen\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if code := runBuild([]string{"-f", configPath}, &stdout, &stderr); code != exitConfig {
+		t.Fatalf("expected exit code %d, got %d\nstderr: %s", exitConfig, code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `are both named "payments"`) {
+		t.Errorf("expected a collision error, got:\n%s", stderr.String())
+	}
+}
+
+func TestBuildTaskFilters(t *testing.T) {
+	dir := writeModule(t, map[string]string{"schemas/user.tg": "struct User {\n  id: int64\n}\n"})
+	configPath := filepath.Join(dir, "typegen.yaml")
+	config := fmt.Sprintf("generate:\n  - generator: go\n    input: %[1]s\n    output: %[2]s\n  - generator: python+pydantic\n    input: %[1]s\n    output: %[3]s\n",
+		filepath.Join(dir, "schemas"), filepath.Join(dir, "go"), filepath.Join(dir, "python"))
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runBuild([]string{"-f", configPath, "-generator", "python+pydantic"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go")); !os.IsNotExist(err) {
+		t.Error("expected the go task to be skipped")
+	}
+	if !strings.Contains(stderr.String(), "1/1 tasks succeeded, 1 skipped") {
+		t.Errorf("expected skipped tasks in the summary, got:\n%s", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := runBuild([]string{"-f", configPath, "-t", "go-1"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go")); err != nil {
+		t.Errorf("expected the task named go-1 to run: %v", err)
+	}
+
+	for _, args := range [][]string{{"-t", "3"}, {"-generator", "dart"}} {
+		stderr.Reset()
+		if code := runBuild(append([]string{"-f", configPath}, args...), &stdout, &stderr); code != exitError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitError, code)
+		}
+		if !strings.Contains(stderr.String(), "available tasks: 1 go-1 (go), 2 python+pydantic-2 (python+pydantic)") {
+			t.Errorf("%v: expected the available tasks, got:\n%s", args, stderr.String())
+		}
+	}
+
+	for _, args := range [][]string{{"-t", "first"}, {"-t", "1", "-check"}} {
+		if code := runBuild(append([]string{"-f", configPath}, args...), &stdout, &stderr); code != exitError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitError, code)
+		}
+	}
+}
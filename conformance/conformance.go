@@ -0,0 +1,167 @@
+// Package conformance holds the fixtures that check that the code of every
+// generator reads and writes the same JSON. Each fixture is a schema and a
+// set of JSON documents, each valid or invalid for one of the schema's
+// types. Every generator must decode the valid documents and encode them
+// back unchanged, and must reject the invalid ones.
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// SchemaFile is the name of the schema file of a fixture, and of the only
+// file of its module
+const SchemaFile = "schema.tg"
+
+// Fixture is a schema and the documents to check against it
+type Fixture struct {
+	// Name is the fixture's directory name, also the module name
+	Name string `json:"-"`
+	// Schema is the TypeGen source of the fixture
+	Schema string `json:"-"`
+	// Known maps a target to the reason it doesn't conform to this fixture
+	// yet. Targets listed here skip the fixture.
+	Known     map[string]string `json:"known"`
+	Documents []*Document       `json:"documents"`
+}
+
+// Document is a JSON document for one of the types of a fixture
+type Document struct {
+	Name string `json:"name"`
+	// Type is the name of the declared type the document is decoded as
+	Type string `json:"type"`
+	// Valid reports whether decoding must succeed
+	Valid bool            `json:"valid"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Fixtures returns every fixture, ordered by name
+func Fixtures() ([]*Fixture, error) {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		return nil, err
+	}
+	var result []*Fixture
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		fixture, err := load(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", entry.Name(), err)
+		}
+		result = append(result, fixture)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func load(name string) (*Fixture, error) {
+	dir := path.Join("fixtures", name)
+	schema, err := fixtures.ReadFile(path.Join(dir, SchemaFile))
+	if err != nil {
+		return nil, err
+	}
+	data, err := fixtures.ReadFile(path.Join(dir, "documents.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{Name: name, Schema: string(schema)}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(fixture); err != nil {
+		return nil, fmt.Errorf("invalid documents.json: %w", err)
+	}
+	for _, doc := range fixture.Documents {
+		if doc.Name == "" || doc.Type == "" || doc.Value == nil {
+			return nil, fmt.Errorf("document %q needs a name, a type and a value", doc.Name)
+		}
+	}
+	return fixture, nil
+}
+
+// Module parses the fixture's schema into a module named after the fixture
+func (f *Fixture) Module() (*ast.Module, error) {
+	program, err := parser.Parse(strings.NewReader(f.Schema), SchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NewModule(f.Name, map[string]*ast.ProgramNode{SchemaFile: program}), nil
+}
+
+// Canonical returns the canonical form of a JSON document, in which two
+// documents that mean the same thing are equal: object keys are sorted,
+// members set to null are removed since TypeGen treats them as absent, and
+// numbers with a fraction or exponent are written the way encoding/json
+// writes a float64, so 2.0 and 2 are equal. Integers are kept as written, so
+// no precision is lost on 64-bit values.
+func Canonical(data []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	value, err := canonical(value)
+	if err != nil {
+		return "", err
+	}
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func canonical(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, member := range v {
+			if member == nil {
+				delete(v, key)
+				continue
+			}
+			member, err := canonical(member)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = member
+		}
+	case []any:
+		for i, element := range v {
+			element, err := canonical(element)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = element
+		}
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			return v, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		return json.Number(data), nil
+	}
+	return value, nil
+}
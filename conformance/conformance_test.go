@@ -0,0 +1,353 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	_ "github.com/WhatsApp-Platform/typegen/generators/go"
+	_ "github.com/WhatsApp-Platform/typegen/generators/python/pydantic"
+)
+
+// request is a document for a driver to decode and encode back
+type request struct {
+	Fixture string          `json:"fixture"`
+	Type    string          `json:"type"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// response is the encoded document, or the error decoding it
+type response struct {
+	Value json.RawMessage `json:"value"`
+	Error string          `json:"error"`
+}
+
+// target is a generator checked against the fixtures. Its code for every
+// fixture is generated into a subdirectory of dir named after the fixture,
+// then run decodes and encodes back every request.
+type target struct {
+	name   string
+	config func(fixture *Fixture) map[string]any
+	run    func(t *testing.T, dir string, fixtures []*Fixture, requests []request) []response
+}
+
+var targets = []target{
+	{
+		name: "go",
+		config: func(fixture *Fixture) map[string]any {
+			return map[string]any{"module-name": "conformance.test/" + fixture.Name}
+		},
+		run: runGo,
+	},
+	{
+		name:   "python+pydantic",
+		config: func(fixture *Fixture) map[string]any { return nil },
+		run:    runPython,
+	},
+}
+
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping execution of generated code in short mode")
+	}
+	fixtures, err := Fixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range targets {
+		t.Run(target.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var checked []*Fixture
+			var requests []request
+			for _, fixture := range fixtures {
+				if reason, ok := fixture.Known[target.name]; ok {
+					t.Logf("skipping %s: %s", fixture.Name, reason)
+					continue
+				}
+				generate(t, target, fixture, dir)
+				checked = append(checked, fixture)
+				for _, doc := range fixture.Documents {
+					requests = append(requests, request{Fixture: fixture.Name, Type: doc.Type, Value: doc.Value})
+				}
+			}
+
+			responses := target.run(t, dir, checked, requests)
+			if len(responses) != len(requests) {
+				t.Fatalf("expected %d responses, got %d", len(requests), len(responses))
+			}
+			i := 0
+			for _, fixture := range checked {
+				for _, doc := range fixture.Documents {
+					resp := responses[i]
+					i++
+					t.Run(fixture.Name+"/"+doc.Name, func(t *testing.T) {
+						check(t, doc, resp)
+					})
+				}
+			}
+		})
+	}
+}
+
+// generate writes the target's code for a fixture
+func generate(t *testing.T, target target, fixture *Fixture, dir string) {
+	t.Helper()
+	module, err := fixture.Module()
+	if err != nil {
+		t.Fatalf("%s: %v", fixture.Name, err)
+	}
+	generator, err := generators.Get(target.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generators.Configure(generator, target.config(fixture))
+	dest := generators.NewOSFS(filepath.Join(dir, fixture.Name))
+	if err := generator.Generate(context.Background(), module, dest); err != nil {
+		t.Fatalf("%s: %v", fixture.Name, err)
+	}
+}
+
+// check compares a response with what the document expects
+func check(t *testing.T, doc *Document, resp response) {
+	if !doc.Valid {
+		if resp.Error == "" {
+			t.Errorf("expected an error decoding %s as %s, got %s", doc.Value, doc.Type, resp.Value)
+		}
+		return
+	}
+	if resp.Error != "" {
+		t.Fatalf("decoding %s as %s: %s", doc.Value, doc.Type, resp.Error)
+	}
+	expected, err := Canonical(doc.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Canonical(resp.Value)
+	if err != nil {
+		t.Fatalf("invalid JSON %s: %v", resp.Value, err)
+	}
+	if got != expected {
+		t.Errorf("round trip changed the document:\nexpected: %s\ngot:      %s", expected, got)
+	}
+}
+
+// runGo builds a program that imports the package of every fixture and
+// decodes each request with encoding/json
+func runGo(t *testing.T, dir string, fixtures []*Fixture, requests []request) []response {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	var imports, decoders []string
+	for _, fixture := range fixtures {
+		imports = append(imports, fmt.Sprintf("\t%q", "conformance.test/"+fixture.Name))
+		for _, name := range documentTypes(fixture) {
+			decoders = append(decoders, fmt.Sprintf("\t%q: decode[%s.%s],", fixture.Name+"."+name, fixture.Name, name))
+		}
+	}
+	driver := `package main
+
+import (
+	"encoding/json"
+	"os"
+
+` + strings.Join(imports, "\n") + `
+)
+
+func decode[T any](data []byte) (any, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return &value, err
+}
+
+var decoders = map[string]func([]byte) (any, error){
+` + strings.Join(decoders, "\n") + `
+}
+
+type request struct {
+	Fixture string          ` + "`json:\"fixture\"`" + `
+	Type    string          ` + "`json:\"type\"`" + `
+	Value   json.RawMessage ` + "`json:\"value\"`" + `
+}
+
+type response struct {
+	Value any    ` + "`json:\"value,omitempty\"`" + `
+	Error string ` + "`json:\"error,omitempty\"`" + `
+}
+
+func main() {
+	var requests []request
+	if err := json.NewDecoder(os.Stdin).Decode(&requests); err != nil {
+		os.Exit(1)
+	}
+	responses := make([]response, len(requests))
+	for i, req := range requests {
+		value, err := decoders[req.Fixture+"."+req.Type](req.Value)
+		if err != nil {
+			responses[i].Error = err.Error()
+			continue
+		}
+		responses[i].Value = value
+	}
+	json.NewEncoder(os.Stdout).Encode(responses)
+}
+`
+	writeFiles(t, dir, map[string]string{
+		"go.mod":         "module conformance.test\n\ngo 1.21\n",
+		"driver/main.go": driver,
+	})
+
+	cmd := exec.Command(goBin, "run", "./driver")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	return drive(t, cmd, requests)
+}
+
+// runPython imports the package of every fixture and decodes each request
+// with a pydantic TypeAdapter
+func runPython(t *testing.T, dir string, fixtures []*Fixture, requests []request) []response {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"driver.py": `import importlib
+import json
+import sys
+
+from pydantic import TypeAdapter
+
+
+responses = []
+for request in json.load(sys.stdin):
+    try:
+        package = importlib.import_module(request["fixture"])
+        adapter = TypeAdapter(getattr(package, request["type"]))
+        value = adapter.validate_json(json.dumps(request["value"]))
+        responses.append({"value": json.loads(adapter.dump_json(value, by_alias=True, exclude_none=True))})
+    except Exception as e:
+        responses.append({"error": str(e) or type(e).__name__})
+json.dump(responses, sys.stdout)
+`,
+	})
+
+	cmd := exec.Command(python, "driver.py")
+	cmd.Dir = dir
+	return drive(t, cmd, requests)
+}
+
+// drive runs a driver with the requests on its standard input and reads
+// the responses from its standard output
+func drive(t *testing.T, cmd *exec.Cmd, requests []request) []response {
+	input, err := json.Marshal(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "No module named 'pydantic'") {
+			t.Skip("pydantic not installed")
+		}
+		t.Fatalf("driver failed: %v\n%s", err, stderr.String())
+	}
+	var responses []response
+	if err := json.Unmarshal(stdout.Bytes(), &responses); err != nil {
+		t.Fatalf("invalid driver output: %v\n%s", err, stdout.String())
+	}
+	return responses
+}
+
+// documentTypes returns the types the documents of a fixture are decoded as
+func documentTypes(fixture *Fixture) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, doc := range fixture.Documents {
+		if !seen[doc.Type] {
+			seen[doc.Type] = true
+			names = append(names, doc.Type)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures, err := Fixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("expected fixtures")
+	}
+	for _, fixture := range fixtures {
+		module, err := fixture.Module()
+		if err != nil {
+			t.Errorf("%s: %v", fixture.Name, err)
+			continue
+		}
+		declared := make(map[string]bool)
+		for _, decl := range module.AllDeclarations() {
+			declared[decl.DeclName()] = true
+		}
+		for _, name := range documentTypes(fixture) {
+			if !declared[name] {
+				t.Errorf("%s: documents use undeclared type %s", fixture.Name, name)
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`{"b": 1, "a": [2.0, 1e2]}`, `{"a":[2,100],"b":1}`, true},
+		{`{"a": null, "b": {"c": null}}`, `{"b":{}}`, true},
+		{`[null, 1.50]`, `[null,1.5]`, true},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`123456789.0`, `123456789`, true},
+		{`"2.0"`, `"2"`, false},
+		{`{"a": 1}`, `{"a": 2}`, false},
+	}
+	for _, tt := range tests {
+		a, err := Canonical([]byte(tt.a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Canonical([]byte(tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (a == b) != tt.equal {
+			t.Errorf("Canonical(%s) = %s, Canonical(%s) = %s, expected equal: %v", tt.a, a, tt.b, b, tt.equal)
+		}
+	}
+}
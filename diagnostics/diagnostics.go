@@ -0,0 +1,200 @@
+// Package diagnostics renders parse errors, validation results and warnings
+// for people reading them in a terminal. The plain renderer prints the same
+// text as the errors' own String and Error methods; the color renderer
+// highlights positions, messages and suggestions with ANSI escape sequences.
+// Both show the source line of each parse error, with a caret under its
+// column, when its file can be read. Diagnostic is their JSON form, for
+// tools.
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// Renderer formats diagnostics as text
+type Renderer interface {
+	// ValidationResult renders every error of a validation result, grouped by file
+	ValidationResult(result *validator.ValidationResult) string
+	// Error renders an error, highlighting the diagnostics of a parse error
+	// anywhere in its chain
+	Error(err error) string
+	// Warning renders a message that doesn't stop the command
+	Warning(message string) string
+}
+
+// New returns the color renderer when color is true, and the plain renderer otherwise
+func New(color bool) Renderer {
+	if color {
+		return Color{}
+	}
+	return Plain{}
+}
+
+// Enabled reports whether to use color: only on a terminal, and unless
+// disabled by a -no-color flag or the NO_COLOR environment variable
+// (https://no-color.org)
+func Enabled(terminal, noColor bool) bool {
+	return terminal && !noColor && os.Getenv("NO_COLOR") == ""
+}
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Plain renders diagnostics without escape sequences
+type Plain struct{}
+
+func (Plain) ValidationResult(result *validator.ValidationResult) string {
+	return result.String()
+}
+
+func (Plain) Error(err error) string {
+	return annotate(err.Error(), parser.Diagnostics(err), parser.ParseDiagnostic.String, func(caret string) string {
+		return caret
+	})
+}
+
+func (Plain) Warning(message string) string {
+	return "⚠️  " + message
+}
+
+// ANSI escape sequences used by Color
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	dim    = "\x1b[2m"
+	red    = "\x1b[31m"
+	yellow = "\x1b[33m"
+)
+
+func paint(style, s string) string {
+	return style + s + reset
+}
+
+// Color renders diagnostics with ANSI colors: positions in bold, errors in
+// red, warnings in yellow, and error codes and suggestions dimmed
+type Color struct{}
+
+func (Color) ValidationResult(result *validator.ValidationResult) string {
+	if len(result.Errors) == 0 {
+		return "No validation errors"
+	}
+
+	result.SortErrors()
+	groups := result.GroupedErrors()
+	files := make([]string, 0, len(groups))
+	for file := range groups {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	parts := []string{paint(bold, fmt.Sprintf("Validation errors found (%d):", len(result.Errors))), ""}
+	for i, file := range files {
+		if i > 0 {
+			parts = append(parts, "")
+		}
+		parts = append(parts, paint(bold, file+":"))
+		for _, err := range groups[file] {
+			parts = append(parts, fmt.Sprintf("  %s: %s %s",
+				paint(bold, fmt.Sprintf("%d:%d", err.Line, err.Column)),
+				paint(red, err.Message),
+				paint(dim, "["+string(err.Type)+"]")))
+			if err.Suggestion != "" {
+				parts = append(parts, paint(dim, "    Suggestion: "+err.Suggestion))
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (Color) Error(err error) string {
+	text := err.Error()
+
+	diagnostics := parser.Diagnostics(err)
+	if len(diagnostics) == 0 {
+		return paint(red, text)
+	}
+
+	return annotate(text, diagnostics, func(diagnostic parser.ParseDiagnostic) string {
+		return paint(bold, diagnostic.Position().String()) + ": " + paint(red, diagnostic.Message)
+	}, func(caret string) string {
+		return paint(red, caret)
+	})
+}
+
+func (Color) Warning(message string) string {
+	return paint(yellow, "⚠️  "+message)
+}
+
+// annotate renders the diagnostics of an error text, each of which is a
+// "file:line:column: message" line, and follows each with its source line
+// and a caret under its column when its file can be read
+func annotate(text string, diagnostics []parser.ParseDiagnostic, render func(parser.ParseDiagnostic) string, paintCaret func(string) string) string {
+	files := sources{}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		for _, diagnostic := range diagnostics {
+			if line != diagnostic.String() {
+				continue
+			}
+			lines[i] = render(diagnostic)
+			if source, caret, ok := files.excerpt(diagnostic); ok {
+				lines[i] += "\n  " + source + "\n  " + paintCaret(caret)
+			}
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sources holds the lines of the files read for excerpts, by file; a file
+// that can't be read has none
+type sources map[string][]string
+
+// excerpt returns the source line of a diagnostic and a caret under its
+// column, or false if the file can't be read or the line is blank
+func (s sources) excerpt(diagnostic parser.ParseDiagnostic) (string, string, bool) {
+	lines, read := s[diagnostic.File]
+	if !read {
+		if data, err := os.ReadFile(diagnostic.File); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		s[diagnostic.File] = lines
+	}
+	if diagnostic.Line < 1 || diagnostic.Line > len(lines) {
+		return "", "", false
+	}
+	line := strings.TrimRight(lines[diagnostic.Line-1], "\r")
+	if strings.TrimSpace(line) == "" {
+		return "", "", false
+	}
+
+	// Columns count characters; the caret keeps the tabs before the column
+	// so it lines up under it
+	var caret strings.Builder
+	for i, r := range []rune(line) {
+		if i >= diagnostic.Column-1 {
+			break
+		}
+		if r == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+	return line, caret.String(), true
+}
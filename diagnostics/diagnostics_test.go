@@ -0,0 +1,156 @@
+package diagnostics
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+func validationResult() *validator.ValidationResult {
+	result := validator.NewValidationResult()
+	result.AddError(validator.UndefinedTypeError, "undefined type 'Role'", "user.tg", 3, 9, "")
+	result.AddError(validator.NamingConventionError, "field name 'userName' should follow snake_case convention", "user.tg", 2, 3, "use 'user_name'")
+	return result
+}
+
+func parseError(t *testing.T) error {
+	t.Helper()
+	_, err := parser.Parse(strings.NewReader("struct {\n"), "broken.tg")
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	// Wrapped like the errors of parser.ParseModuleToAST
+	return fmt.Errorf("failed to parse broken.tg: %w", err)
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		noColor  bool
+		env      string
+		expected bool
+	}{
+		{"terminal", true, false, "", true},
+		{"not a terminal", false, false, "", false},
+		{"-no-color", true, true, "", false},
+		{"NO_COLOR", true, false, "1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.env)
+			if actual := Enabled(tt.terminal, tt.noColor); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Error("a buffer is not a terminal")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	result := validationResult()
+	if actual := New(false).ValidationResult(result); actual != result.String() {
+		t.Errorf("expected the result's own text, got:\n%s", actual)
+	}
+
+	err := parseError(t)
+	if actual := New(false).Error(err); actual != err.Error() {
+		t.Errorf("expected the error's own text, got:\n%s", actual)
+	}
+
+	if actual := New(false).Warning("lossy mapping"); strings.Contains(actual, "\x1b[") {
+		t.Errorf("expected no escape sequences, got %q", actual)
+	}
+}
+
+func TestColor(t *testing.T) {
+	output := New(true).ValidationResult(validationResult())
+	for _, expected := range []string{
+		bold + "3:9" + reset + ": " + red + "undefined type 'Role'" + reset + " " + dim + "[undefined_type]" + reset,
+		dim + "    Suggestion: use 'user_name'" + reset,
+		bold + "user.tg:" + reset,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%q", expected, output)
+		}
+	}
+
+	output = New(true).Error(parseError(t))
+	expected := "failed to parse broken.tg: parse errors occurred:\n" + bold + "broken.tg:1:8" + reset + ": " + red + "unexpected '{', expected name" + reset
+	if output != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, output)
+	}
+
+	if output := New(true).Error(fmt.Errorf("disk full")); output != red+"disk full"+reset {
+		t.Errorf("expected other errors in red, got %q", output)
+	}
+
+	if output := New(true).Warning("lossy mapping"); output != yellow+"⚠️  lossy mapping"+reset {
+		t.Errorf("expected a yellow warning, got %q", output)
+	}
+}
+
+func TestSourceExcerpts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.tg")
+	if err := os.WriteFile(path, []byte("struct User {\n\tid int64\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := parser.ParseFile(path)
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+
+	excerpt := "\n  \tid int64\n  "
+	expected := "parse errors occurred:\n" + path + ":2:5: unexpected 'int64', expected ':'" + excerpt + "\t   ^"
+	if actual := New(false).Error(err); actual != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, actual)
+	}
+
+	expected = "parse errors occurred:\n" + bold + path + ":2:5" + reset + ": " + red + "unexpected 'int64', expected ':'" + reset + excerpt + red + "\t   ^" + reset
+	if actual := New(true).Error(err); actual != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, actual)
+	}
+}
+
+func TestFromValidation(t *testing.T) {
+	result := validationResult()
+	result.AddWarning(validator.NamingConventionError, "type name 'user' should follow PascalCase convention", "a.tg", 1, 8, "")
+
+	got := FromValidation(result)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %+v", got)
+	}
+	// Errors come first, ordered by position, then warnings
+	if got[0].Rule != "naming_convention" || got[0].Line != 2 || got[0].Suggestion != "use 'user_name'" || got[1].Rule != "undefined_type" {
+		t.Errorf("expected the errors ordered by position, got %+v", got[:2])
+	}
+	if got[2].Severity != "warning" || got[2].File != "a.tg" {
+		t.Errorf("expected the warning last, got %+v", got[2])
+	}
+	if FromValidation(validator.NewValidationResult()) != nil {
+		t.Error("expected no diagnostics for a valid result")
+	}
+}
+
+func TestFromParseError(t *testing.T) {
+	_, err := parser.Parse(strings.NewReader("struct User {\n  id int64\n}\n"), "user.tg")
+	got := FromParseError(err)
+	if len(got) != 1 || got[0].Rule != "syntax" || got[0].Severity != "error" || got[0].Got != "int64" || got[0].Line != 2 || got[0].Column != 6 {
+		t.Errorf("unexpected diagnostics %+v", got)
+	}
+	if FromParseError(fmt.Errorf("not a parse error")) != nil {
+		t.Error("expected no diagnostics for other errors")
+	}
+}
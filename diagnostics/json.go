@@ -0,0 +1,67 @@
+package diagnostics
+
+import (
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// Diagnostic is a syntax error, or a validation error or warning, as
+// encoded in the JSON output of the CLI and in build reports
+type Diagnostic struct {
+	// Severity is "error" or "warning"
+	Severity string `json:"severity"`
+	// Rule is the validation rule, see validator.AllRules, or "syntax" for
+	// parse errors
+	Rule       string `json:"rule"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	// Got and Expected are the unexpected token of a syntax error and what
+	// the parser would have accepted instead, see parser.ParseDiagnostic
+	Got      string   `json:"got,omitempty"`
+	Expected []string `json:"expected,omitempty"`
+}
+
+// FromValidation returns the errors of a validation result, then its
+// warnings, each ordered by file and position, or nil if there are none
+func FromValidation(result *validator.ValidationResult) []Diagnostic {
+	result.SortErrors()
+	var diagnostics []Diagnostic
+	add := func(severity string, errs []validator.ValidationError) {
+		for _, err := range errs {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity:   severity,
+				Rule:       string(err.Type),
+				File:       err.File,
+				Line:       err.Line,
+				Column:     err.Column,
+				Message:    err.Message,
+				Suggestion: err.Suggestion,
+			})
+		}
+	}
+	add("error", result.Errors)
+	add("warning", result.Warnings)
+	return diagnostics
+}
+
+// FromParseError returns the syntax errors of a parse error, of every file
+// of a module, or nil if err isn't one
+func FromParseError(err error) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, d := range parser.Diagnostics(err) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: "error",
+			Rule:     "syntax",
+			File:     d.File,
+			Line:     d.Line,
+			Column:   d.Column,
+			Message:  d.Message,
+			Got:      d.Got,
+			Expected: d.Expected,
+		})
+	}
+	return diagnostics
+}
@@ -0,0 +1,571 @@
+// Package diff compares two versions of a TypeGen module and classifies every
+// change by how it affects compatibility between readers and writers of the
+// two versions.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+)
+
+// Severity classifies how a change affects existing readers and writers
+type Severity int
+
+const (
+	// Compatible changes keep data flowing in both directions between versions
+	Compatible Severity = iota
+	// Warning changes keep the wire format compatible but can break code built
+	// against the old version, e.g. a renamed type or a new enum variant
+	Warning
+	// Breaking changes make data written by one version unreadable by the other
+	Breaking
+)
+
+var severityNames = []string{"compatible", "warning", "breaking"}
+
+func (s Severity) String() string {
+	if s < Compatible || s > Breaking {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalJSON encodes the severity by name
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity parses a severity name as printed by Severity.String
+func ParseSeverity(name string) (Severity, error) {
+	for i, severityName := range severityNames {
+		if name == severityName {
+			return Severity(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q (expected one of %s)", name, strings.Join(severityNames, ", "))
+}
+
+// Compatibility tells in which direction a change breaks the exchange of data
+// between the two versions
+type Compatibility string
+
+const (
+	// FullyCompatible changes keep data readable in both directions
+	FullyCompatible Compatibility = "compatible"
+	// ForwardBreaking changes make data written by the new version unreadable
+	// by readers of the old version, e.g. a new enum variant
+	ForwardBreaking Compatibility = "forward-breaking"
+	// BackwardBreaking changes make data written by the old version unreadable
+	// by readers of the new version, e.g. a new required field
+	BackwardBreaking Compatibility = "backward-breaking"
+	// FullyBreaking changes break both directions, e.g. a changed field type
+	FullyBreaking Compatibility = "breaking"
+)
+
+// Kind identifies the class of a change
+type Kind string
+
+const (
+	DeclarationAdded       Kind = "declaration-added"
+	DeclarationRemoved     Kind = "declaration-removed"
+	DeclarationRenamed     Kind = "declaration-renamed"
+	DeclarationKindChanged Kind = "declaration-kind-changed"
+	AliasTypeChanged       Kind = "alias-type-changed"
+	FieldAdded             Kind = "field-added"
+	RequiredFieldAdded     Kind = "required-field-added"
+	FieldRemoved           Kind = "field-removed"
+	FieldTypeChanged       Kind = "field-type-changed"
+	FieldMadeOptional      Kind = "field-made-optional"
+	FieldMadeRequired      Kind = "field-made-required"
+	VariantAdded           Kind = "variant-added"
+	VariantRemoved         Kind = "variant-removed"
+	VariantPayloadChanged  Kind = "variant-payload-changed"
+	ConstantAdded          Kind = "constant-added"
+	ConstantRemoved        Kind = "constant-removed"
+	ConstantChanged        Kind = "constant-changed"
+	SubmoduleAdded         Kind = "submodule-added"
+	SubmoduleRemoved       Kind = "submodule-removed"
+)
+
+// Change is a single difference between the old and new module
+type Change struct {
+	Kind          Kind          `json:"kind"`
+	Severity      Severity      `json:"severity"`
+	Compatibility Compatibility `json:"compatibility"`
+	// Path locates the change: the declaration name, qualified by its
+	// submodule path, followed by the field or variant name, e.g. "auth.User.email"
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Old and New hold the values before and after the change, when relevant
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+// Report holds the changes between two modules, ordered by path
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// Max returns the highest severity in the report; an empty report is Compatible
+func (r *Report) Max() Severity {
+	max := Compatible
+	for _, change := range r.Changes {
+		if change.Severity > max {
+			max = change.Severity
+		}
+	}
+	return max
+}
+
+// Count returns the number of changes with at least the given severity
+func (r *Report) Count(level Severity) int {
+	count := 0
+	for _, change := range r.Changes {
+		if change.Severity >= level {
+			count++
+		}
+	}
+	return count
+}
+
+// String renders the report grouped by severity, most severe first
+func (r *Report) String() string {
+	if len(r.Changes) == 0 {
+		return "No changes\n"
+	}
+
+	var b strings.Builder
+	for severity := Breaking; severity >= Compatible; severity-- {
+		var changes []Change
+		for _, change := range r.Changes {
+			if change.Severity == severity {
+				changes = append(changes, change)
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s changes (%d):\n", strings.ToUpper(severity.String()[:1])+severity.String()[1:], len(changes))
+		for _, change := range changes {
+			fmt.Fprintf(&b, "  %s\n", change)
+		}
+	}
+	return b.String()
+}
+
+// Options control how modules are compared
+type Options struct {
+	// DetectRenames reports a removed and an added declaration with the same
+	// body as a rename instead of a removal and an addition
+	DetectRenames bool
+}
+
+// Compare returns the changes needed to turn the old module into the new
+// one, detecting renamed declarations
+func Compare(old, new *ast.Module) *Report {
+	return CompareWith(old, new, Options{DetectRenames: true})
+}
+
+// CompareWith returns the changes needed to turn the old module into the
+// new one. Structs are compared with their embeddings expanded, as they go
+// on the wire.
+func CompareWith(old, new *ast.Module, options Options) *Report {
+	c := &comparer{
+		options:  options,
+		oldDecls: collect(semantic.Flatten(old), ""),
+		newDecls: collect(semantic.Flatten(new), ""),
+		renames:  make(map[string]string),
+	}
+	c.compareSubmodules(submodules(old, ""), submodules(new, ""))
+	c.compare()
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		return c.changes[i].Path < c.changes[j].Path
+	})
+	return &Report{Changes: c.changes}
+}
+
+// declaration is a declaration together with the submodule it belongs to
+type declaration struct {
+	module string // dotted submodule path, "" for the root module
+	name   string
+	node   ast.Declaration
+}
+
+// key identifies the declaration across the module tree
+func (d *declaration) key() string {
+	return qualify(d.module, d.name)
+}
+
+func qualify(module, name string) string {
+	if module == "" {
+		return name
+	}
+	return module + "." + name
+}
+
+// collect returns the declarations of a module and its submodules by key
+func collect(module *ast.Module, prefix string) map[string]*declaration {
+	decls := make(map[string]*declaration)
+	if module == nil {
+		return decls
+	}
+
+	for _, filename := range module.FileNames() {
+		for _, node := range module.Files[filename].Declarations {
+			decl := &declaration{module: prefix, name: node.DeclName(), node: node}
+			decls[decl.key()] = decl
+		}
+	}
+	for _, name := range module.SubModuleNames() {
+		for key, decl := range collect(module.SubModules[name], qualify(prefix, name)) {
+			decls[key] = decl
+		}
+	}
+	return decls
+}
+
+// submodules returns the dotted paths of a module's submodules, recursively
+func submodules(module *ast.Module, prefix string) map[string]bool {
+	paths := make(map[string]bool)
+	if module == nil {
+		return paths
+	}
+	for name, subModule := range module.SubModules {
+		path := qualify(prefix, name)
+		paths[path] = true
+		for subPath := range submodules(subModule, path) {
+			paths[subPath] = true
+		}
+	}
+	return paths
+}
+
+func declarationKind(node ast.Declaration) string {
+	switch node.(type) {
+	case *ast.StructNode:
+		return "struct"
+	case *ast.EnumNode:
+		return "enum"
+	case *ast.TypeAliasNode:
+		return "type alias"
+	case *ast.ConstantNode:
+		return "constant"
+	}
+	return "declaration"
+}
+
+// comparer holds the state of one Compare call
+type comparer struct {
+	options  Options
+	oldDecls map[string]*declaration
+	newDecls map[string]*declaration
+	// renames maps the keys of renamed declarations to their new names, so
+	// references to a renamed type are not reported as type changes
+	renames map[string]string
+	changes []Change
+}
+
+// compareSubmodules reports the submodules only one of the versions has. The
+// declarations of a removed submodule are also reported as removed.
+func (c *comparer) compareSubmodules(oldPaths, newPaths map[string]bool) {
+	for path := range oldPaths {
+		if !newPaths[path] {
+			c.report(SubmoduleRemoved, Breaking, FullyBreaking, path, "submodule removed", "", "")
+		}
+	}
+	for path := range newPaths {
+		if !oldPaths[path] {
+			c.report(SubmoduleAdded, Compatible, FullyCompatible, path, "submodule added", "", "")
+		}
+	}
+}
+
+func (c *comparer) compare() {
+	var removed, added []string
+	for key := range c.oldDecls {
+		if _, exists := c.newDecls[key]; !exists {
+			removed = append(removed, key)
+		}
+	}
+	for key := range c.newDecls {
+		if _, exists := c.oldDecls[key]; !exists {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	if c.options.DetectRenames {
+		added = c.detectRenames(removed, added)
+	}
+
+	for _, key := range removed {
+		if _, renamed := c.renames[key]; renamed {
+			continue
+		}
+		decl := c.oldDecls[key]
+		if _, isConst := decl.node.(*ast.ConstantNode); isConst {
+			c.report(ConstantRemoved, Breaking, FullyBreaking, key, "constant removed", "", "")
+		} else {
+			c.report(DeclarationRemoved, Breaking, FullyBreaking, key, declarationKind(decl.node)+" removed", "", "")
+		}
+	}
+	for _, key := range added {
+		decl := c.newDecls[key]
+		if _, isConst := decl.node.(*ast.ConstantNode); isConst {
+			c.report(ConstantAdded, Compatible, FullyCompatible, key, "constant added", "", "")
+		} else {
+			c.report(DeclarationAdded, Compatible, FullyCompatible, key, declarationKind(decl.node)+" added", "", "")
+		}
+	}
+
+	var keys []string
+	for key := range c.oldDecls {
+		if _, exists := c.newDecls[key]; exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c.compareDeclaration(key, c.oldDecls[key], c.newDecls[key])
+	}
+}
+
+// detectRenames pairs removed and added declarations of the same kind and
+// module whose bodies are identical, and returns the added keys that were
+// not part of a rename. A removed declaration is only treated as renamed
+// when exactly one added declaration matches it, and vice versa.
+func (c *comparer) detectRenames(removed, added []string) []string {
+	matches := make(map[string][]string)
+	matchedBy := make(map[string]int)
+	for _, oldKey := range removed {
+		oldDecl := c.oldDecls[oldKey]
+		if _, isConst := oldDecl.node.(*ast.ConstantNode); isConst {
+			continue
+		}
+		for _, newKey := range added {
+			newDecl := c.newDecls[newKey]
+			if newDecl.module == oldDecl.module && body(oldDecl.node) == body(newDecl.node) {
+				matches[oldKey] = append(matches[oldKey], newKey)
+				matchedBy[newKey]++
+			}
+		}
+	}
+
+	renamedTo := make(map[string]bool)
+	for _, oldKey := range removed {
+		candidates := matches[oldKey]
+		if len(candidates) != 1 || matchedBy[candidates[0]] != 1 {
+			continue
+		}
+		newDecl := c.newDecls[candidates[0]]
+		c.renames[oldKey] = newDecl.name
+		renamedTo[candidates[0]] = true
+		c.report(DeclarationRenamed, Warning, FullyCompatible, oldKey,
+			fmt.Sprintf("%s renamed to %s", declarationKind(newDecl.node), newDecl.name),
+			c.oldDecls[oldKey].name, newDecl.name)
+	}
+
+	var remaining []string
+	for _, key := range added {
+		if !renamedTo[key] {
+			remaining = append(remaining, key)
+		}
+	}
+	return remaining
+}
+
+// body renders a declaration without its name, to recognize renames
+func body(node ast.Declaration) string {
+	switch d := node.(type) {
+	case *ast.StructNode:
+		unnamed := *d
+		unnamed.Name = ""
+		return unnamed.String()
+	case *ast.EnumNode:
+		unnamed := *d
+		unnamed.Name = ""
+		return unnamed.String()
+	case *ast.TypeAliasNode:
+		return "type = " + d.Type.String()
+	}
+	return node.String()
+}
+
+func (c *comparer) compareDeclaration(key string, oldDecl, newDecl *declaration) {
+	if declarationKind(oldDecl.node) != declarationKind(newDecl.node) {
+		c.report(DeclarationKindChanged, Breaking, FullyBreaking, key,
+			fmt.Sprintf("changed from %s to %s", declarationKind(oldDecl.node), declarationKind(newDecl.node)),
+			declarationKind(oldDecl.node), declarationKind(newDecl.node))
+		return
+	}
+
+	switch o := oldDecl.node.(type) {
+	case *ast.StructNode:
+		c.compareStruct(key, oldDecl.module, o, newDecl.node.(*ast.StructNode))
+	case *ast.EnumNode:
+		c.compareEnum(key, oldDecl.module, o, newDecl.node.(*ast.EnumNode))
+	case *ast.TypeAliasNode:
+		n := newDecl.node.(*ast.TypeAliasNode)
+		if oldType, newType := c.typeString(o.Type, oldDecl.module), n.Type.String(); oldType != newType {
+			c.report(AliasTypeChanged, Breaking, FullyBreaking, key, fmt.Sprintf("aliased type changed from %s to %s", o.Type, newType), o.Type.String(), newType)
+		}
+	case *ast.ConstantNode:
+		n := newDecl.node.(*ast.ConstantNode)
+		if oldValue, newValue := constantString(o.Value), constantString(n.Value); oldValue != newValue {
+			c.report(ConstantChanged, Warning, FullyCompatible, key, fmt.Sprintf("value changed from %s to %s", oldValue, newValue), oldValue, newValue)
+		}
+	}
+}
+
+func (c *comparer) compareStruct(key, module string, oldStruct, newStruct *ast.StructNode) {
+	newFields := make(map[string]*ast.FieldNode)
+	for _, field := range newStruct.Fields {
+		newFields[field.Name] = field
+	}
+	oldFields := make(map[string]bool)
+
+	for _, oldField := range oldStruct.Fields {
+		oldFields[oldField.Name] = true
+		path := key + "." + oldField.Name
+
+		newField, exists := newFields[oldField.Name]
+		if !exists {
+			if oldField.Optional {
+				// Old readers already handle the field being absent
+				c.report(FieldRemoved, Warning, FullyCompatible, path, "optional field removed", oldField.Type.String(), "")
+			} else {
+				c.report(FieldRemoved, Breaking, ForwardBreaking, path, "required field removed", oldField.Type.String(), "")
+			}
+			continue
+		}
+
+		if oldType, newType := c.typeString(oldField.Type, module), newField.Type.String(); oldType != newType {
+			c.report(FieldTypeChanged, Breaking, FullyBreaking, path,
+				fmt.Sprintf("type changed from %s to %s", oldField.Type, newType), oldField.Type.String(), newType)
+		}
+		switch {
+		case !oldField.Optional && newField.Optional:
+			c.report(FieldMadeOptional, Breaking, ForwardBreaking, path, "field made optional; old readers require it", "", "")
+		case oldField.Optional && !newField.Optional:
+			c.report(FieldMadeRequired, Breaking, BackwardBreaking, path, "field made required; old writers may omit it", "", "")
+		}
+	}
+
+	for _, newField := range newStruct.Fields {
+		if oldFields[newField.Name] {
+			continue
+		}
+		path := key + "." + newField.Name
+		if newField.Optional {
+			c.report(FieldAdded, Compatible, FullyCompatible, path, "optional field added", "", newField.Type.String())
+		} else {
+			c.report(RequiredFieldAdded, Breaking, BackwardBreaking, path, "required field added; old writers do not set it", "", newField.Type.String())
+		}
+	}
+}
+
+func (c *comparer) compareEnum(key, module string, oldEnum, newEnum *ast.EnumNode) {
+	newVariants := make(map[string]*ast.EnumVariantNode)
+	for _, variant := range newEnum.Variants {
+		newVariants[variant.Name] = variant
+	}
+	oldVariants := make(map[string]bool)
+
+	for _, oldVariant := range oldEnum.Variants {
+		oldVariants[oldVariant.Name] = true
+		path := key + "." + oldVariant.Name
+
+		newVariant, exists := newVariants[oldVariant.Name]
+		if !exists {
+			c.report(VariantRemoved, Breaking, BackwardBreaking, path, "variant removed", "", "")
+			continue
+		}
+
+		oldPayload, newPayload := "", ""
+		if oldVariant.Payload != nil {
+			oldPayload = c.typeString(oldVariant.Payload, module)
+		}
+		if newVariant.Payload != nil {
+			newPayload = newVariant.Payload.String()
+		}
+		if oldPayload != newPayload {
+			c.report(VariantPayloadChanged, Breaking, FullyBreaking, path,
+				fmt.Sprintf("payload changed from %s to %s", payloadString(oldVariant.Payload), payloadString(newVariant.Payload)),
+				payloadString(oldVariant.Payload), payloadString(newVariant.Payload))
+		}
+	}
+
+	for _, newVariant := range newEnum.Variants {
+		if !oldVariants[newVariant.Name] {
+			// Old writers never produce it, but old readers may not handle it
+			c.report(VariantAdded, Warning, ForwardBreaking, key+"."+newVariant.Name, "variant added; old readers may not handle it", "", "")
+		}
+	}
+}
+
+// typeString renders an old type with references to renamed declarations
+// replaced by their new names, so it can be compared with a new type
+func (c *comparer) typeString(t ast.Type, module string) string {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		key := t.Name
+		if !strings.Contains(t.Name, ".") {
+			key = qualify(module, t.Name)
+		}
+		if newName, renamed := c.renames[key]; renamed {
+			if i := strings.LastIndex(t.Name, "."); i >= 0 {
+				return t.Name[:i+1] + newName
+			}
+			return newName
+		}
+		return t.Name
+	case *ast.ArrayType:
+		return "[]" + c.typeString(t.ElementType, module)
+	case *ast.MapType:
+		return "[" + c.typeString(t.KeyType, module) + "]" + c.typeString(t.ValueType, module)
+	case *ast.OptionalType:
+		return "?" + c.typeString(t.ElementType, module)
+	}
+	return t.String()
+}
+
+func payloadString(t ast.Type) string {
+	if t == nil {
+		return "none"
+	}
+	return t.String()
+}
+
+func constantString(value ast.ConstantValue) string {
+	if s, ok := value.(*ast.StringConstant); ok {
+		return fmt.Sprintf("%q", s.Value)
+	}
+	return value.String()
+}
+
+func (c *comparer) report(kind Kind, severity Severity, compatibility Compatibility, path, message, old, new string) {
+	c.changes = append(c.changes, Change{
+		Kind:          kind,
+		Severity:      severity,
+		Compatibility: compatibility,
+		Path:          path,
+		Message:       message,
+		Old:           old,
+		New:           new,
+	})
+}
@@ -0,0 +1,376 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// parseModule builds a module from source; keys with a slash go into submodules
+func parseModule(t *testing.T, files map[string]string) *ast.Module {
+	t.Helper()
+	module := ast.NewModule("test", make(map[string]*ast.ProgramNode))
+	for name, src := range files {
+		program, err := parser.Parse(strings.NewReader(src), name)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", name, err)
+		}
+
+		target := module
+		parts := strings.Split(name, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			sub, exists := target.SubModules[dir]
+			if !exists {
+				sub = ast.NewModule(dir, make(map[string]*ast.ProgramNode))
+				target.SubModules[dir] = sub
+			}
+			target = sub
+		}
+		target.Files[parts[len(parts)-1]] = program
+	}
+	return module
+}
+
+func compareSources(t *testing.T, old, new string) *Report {
+	t.Helper()
+	return Compare(
+		parseModule(t, map[string]string{"types.tg": old}),
+		parseModule(t, map[string]string{"types.tg": new}),
+	)
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected []Change
+	}{
+		{
+			name: "no changes",
+			old:  "struct User {\n  id: int64\n}\n",
+			new:  "struct User {\n  id: int64\n}\n",
+		},
+		{
+			name: "field order is not a change",
+			old:  "struct User {\n  id: int64\n  name: string\n}\n",
+			new:  "struct User {\n  name: string\n  id: int64\n}\n",
+		},
+		{
+			name: "optional field added",
+			old:  "struct User {\n  id: int64\n}\n",
+			new:  "struct User {\n  id: int64\n  email: ?string\n}\n",
+			expected: []Change{
+				{Kind: FieldAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "User.email", New: "string"},
+			},
+		},
+		{
+			name: "required field added",
+			old:  "struct User {\n  id: int64\n}\n",
+			new:  "struct User {\n  id: int64\n  email: string\n}\n",
+			expected: []Change{
+				{Kind: RequiredFieldAdded, Severity: Breaking, Compatibility: BackwardBreaking, Path: "User.email", New: "string"},
+			},
+		},
+		{
+			name: "required field removed",
+			old:  "struct User {\n  id: int64\n  name: string\n}\n",
+			new:  "struct User {\n  id: int64\n}\n",
+			expected: []Change{
+				{Kind: FieldRemoved, Severity: Breaking, Compatibility: ForwardBreaking, Path: "User.name", Old: "string"},
+			},
+		},
+		{
+			name: "optional field removed",
+			old:  "struct User {\n  id: int64\n  name: ?string\n}\n",
+			new:  "struct User {\n  id: int64\n}\n",
+			expected: []Change{
+				{Kind: FieldRemoved, Severity: Warning, Compatibility: FullyCompatible, Path: "User.name", Old: "string"},
+			},
+		},
+		{
+			name: "field type changed",
+			old:  "struct User {\n  id: int64\n  tags: []string\n}\n",
+			new:  "struct User {\n  id: string\n  tags: [string]bool\n}\n",
+			expected: []Change{
+				{Kind: FieldTypeChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "User.id", Old: "int64", New: "string"},
+				{Kind: FieldTypeChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "User.tags", Old: "[]string", New: "[string]bool"},
+			},
+		},
+		{
+			name: "field optionality changed",
+			old:  "struct User {\n  email: string\n  phone: ?string\n}\n",
+			new:  "struct User {\n  email: ?string\n  phone: string\n}\n",
+			expected: []Change{
+				{Kind: FieldMadeOptional, Severity: Breaking, Compatibility: ForwardBreaking, Path: "User.email"},
+				{Kind: FieldMadeRequired, Severity: Breaking, Compatibility: BackwardBreaking, Path: "User.phone"},
+			},
+		},
+		{
+			name: "variant added",
+			old:  "enum Status {\n  active\n}\n",
+			new:  "enum Status {\n  active\n  banned\n}\n",
+			expected: []Change{
+				{Kind: VariantAdded, Severity: Warning, Compatibility: ForwardBreaking, Path: "Status.banned"},
+			},
+		},
+		{
+			name: "variant removed",
+			old:  "enum Status {\n  active\n  banned\n}\n",
+			new:  "enum Status {\n  active\n}\n",
+			expected: []Change{
+				{Kind: VariantRemoved, Severity: Breaking, Compatibility: BackwardBreaking, Path: "Status.banned"},
+			},
+		},
+		{
+			name: "variant payload changed",
+			old:  "enum Result {\n  ok: string\n  failed\n}\n",
+			new:  "enum Result {\n  ok: int64\n  failed: string\n}\n",
+			expected: []Change{
+				{Kind: VariantPayloadChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "Result.failed", Old: "none", New: "string"},
+				{Kind: VariantPayloadChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "Result.ok", Old: "string", New: "int64"},
+			},
+		},
+		{
+			name: "renamed type and its references",
+			old:  "struct User {\n  id: int64\n}\n\nstruct Order {\n  buyer: User\n  others: []User\n}\n",
+			new:  "struct Account {\n  id: int64\n}\n\nstruct Order {\n  buyer: Account\n  others: []Account\n}\n",
+			expected: []Change{
+				{Kind: DeclarationRenamed, Severity: Warning, Compatibility: FullyCompatible, Path: "User", Old: "User", New: "Account"},
+			},
+		},
+		{
+			name: "ambiguous rename is a removal and additions",
+			old:  "struct User {\n  id: int64\n}\n",
+			new:  "struct Account {\n  id: int64\n}\n\nstruct Member {\n  id: int64\n}\n",
+			expected: []Change{
+				{Kind: DeclarationAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "Account"},
+				{Kind: DeclarationAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "Member"},
+				{Kind: DeclarationRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "User"},
+			},
+		},
+		{
+			name: "declaration kind changed",
+			old:  "struct Status {\n  code: int32\n}\n",
+			new:  "enum Status {\n  active\n}\n",
+			expected: []Change{
+				{Kind: DeclarationKindChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "Status", Old: "struct", New: "enum"},
+			},
+		},
+		{
+			name: "alias type changed",
+			old:  "type UserID = int64\n",
+			new:  "type UserID = string\n",
+			expected: []Change{
+				{Kind: AliasTypeChanged, Severity: Breaking, Compatibility: FullyBreaking, Path: "UserID", Old: "int64", New: "string"},
+			},
+		},
+		{
+			name: "constants",
+			old:  "const MAX_USERS = 10\nconst VERSION = \"1.0\"\nconst OLD = 1\n",
+			new:  "const MAX_USERS = 20\nconst VERSION = \"1.0\"\nconst NEW = 1\n",
+			expected: []Change{
+				{Kind: ConstantChanged, Severity: Warning, Compatibility: FullyCompatible, Path: "MAX_USERS", Old: "10", New: "20"},
+				{Kind: ConstantAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "NEW"},
+				{Kind: ConstantRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "OLD"},
+			},
+		},
+		{
+			name: "declarations added and removed",
+			old:  "struct User {\n  id: int64\n}\n",
+			new:  "enum Status {\n  active\n}\n",
+			expected: []Change{
+				{Kind: DeclarationAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "Status"},
+				{Kind: DeclarationRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "User"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := compareSources(t, tt.old, tt.new)
+
+			if len(report.Changes) != len(tt.expected) {
+				t.Fatalf("expected %d changes, got:\n%s", len(tt.expected), report)
+			}
+			for i, expected := range tt.expected {
+				got := report.Changes[i]
+				if got.Kind != expected.Kind || got.Severity != expected.Severity || got.Compatibility != expected.Compatibility ||
+					got.Path != expected.Path || got.Old != expected.Old || got.New != expected.New {
+					t.Errorf("change %d: expected %+v, got %+v", i, expected, got)
+				}
+				if got.Message == "" {
+					t.Errorf("change %d has no message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareSubmodules(t *testing.T) {
+	old := parseModule(t, map[string]string{
+		"main.tg":      "struct Order {\n  buyer: auth.User\n}\n",
+		"auth/user.tg": "struct User {\n  id: int64\n}\n",
+	})
+	new := parseModule(t, map[string]string{
+		"main.tg":         "struct Order {\n  buyer: auth.Account\n}\n",
+		"auth/user.tg":    "struct Account {\n  id: int64\n}\n",
+		"auth/session.tg": "struct Session {\n  token: string\n}\n",
+		// The same name in another submodule is a different declaration
+		"billing/user.tg": "struct User {\n  id: int64\n}\n",
+	})
+
+	report := Compare(old, new)
+
+	expected := []struct {
+		kind Kind
+		path string
+	}{
+		{DeclarationRenamed, "auth.User"},
+		{DeclarationAdded, "auth.Session"},
+		{SubmoduleAdded, "billing"},
+		{DeclarationAdded, "billing.User"},
+	}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got:\n%s", len(expected), report)
+	}
+	kinds := make(map[string]Kind)
+	for _, change := range report.Changes {
+		kinds[change.Path] = change.Kind
+	}
+	for _, e := range expected {
+		if kinds[e.path] != e.kind {
+			t.Errorf("expected %s at %s, got:\n%s", e.kind, e.path, report)
+		}
+	}
+}
+
+func TestCompareSubmoduleChanges(t *testing.T) {
+	old := parseModule(t, map[string]string{
+		"main.tg":             "struct Order {\n  id: int64\n}\n",
+		"legacy/old.tg":       "struct Old {\n  id: int64\n}\n",
+		"legacy/deep/more.tg": "const LIMIT = 1\n",
+	})
+	new := parseModule(t, map[string]string{
+		"main.tg":          "struct Order {\n  id: int64\n}\n",
+		"billing/bill.tg":  "struct Bill {\n  id: int64\n}\n",
+		"billing/tax/x.tg": "const RATE = 1\n",
+	})
+
+	expected := []Change{
+		{Kind: SubmoduleAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "billing"},
+		{Kind: DeclarationAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "billing.Bill"},
+		{Kind: SubmoduleAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "billing.tax"},
+		{Kind: ConstantAdded, Severity: Compatible, Compatibility: FullyCompatible, Path: "billing.tax.RATE"},
+		{Kind: SubmoduleRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "legacy"},
+		{Kind: DeclarationRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "legacy.Old"},
+		{Kind: SubmoduleRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "legacy.deep"},
+		{Kind: ConstantRemoved, Severity: Breaking, Compatibility: FullyBreaking, Path: "legacy.deep.LIMIT"},
+	}
+
+	report := Compare(old, new)
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got:\n%s", len(expected), report)
+	}
+	for i, e := range expected {
+		got := report.Changes[i]
+		if got.Kind != e.Kind || got.Severity != e.Severity || got.Compatibility != e.Compatibility || got.Path != e.Path {
+			t.Errorf("change %d: expected %+v, got %+v", i, e, got)
+		}
+	}
+}
+
+func TestCompareWithoutRenames(t *testing.T) {
+	old := parseModule(t, map[string]string{"types.tg": "struct User {\n  id: int64\n}\n\nstruct Order {\n  buyer: User\n}\n"})
+	new := parseModule(t, map[string]string{"types.tg": "struct Account {\n  id: int64\n}\n\nstruct Order {\n  buyer: Account\n}\n"})
+
+	report := CompareWith(old, new, Options{})
+
+	expected := []Kind{DeclarationAdded, FieldTypeChanged, DeclarationRemoved}
+	if len(report.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got:\n%s", len(expected), report)
+	}
+	for i, kind := range expected {
+		if report.Changes[i].Kind != kind {
+			t.Errorf("change %d: expected %s, got %+v", i, kind, report.Changes[i])
+		}
+	}
+}
+
+func TestCompareEmbeds(t *testing.T) {
+	// Moving fields into an embedded struct doesn't change the wire format
+	report := compareSources(t,
+		"struct Order {\n  id: int64\n  created: datetime\n}\n\nstruct Audit {\n  created: datetime\n}\n",
+		"struct Order {\n  id: int64\n  ...Audit\n}\n\nstruct Audit {\n  created: datetime\n}\n",
+	)
+	if len(report.Changes) != 0 {
+		t.Errorf("expected no changes, got:\n%s", report)
+	}
+
+	// Changing an embedded struct changes the structs embedding it
+	report = compareSources(t,
+		"struct Order {\n  ...Audit\n}\n\nstruct Audit {\n  created: datetime\n}\n",
+		"struct Order {\n  ...Audit\n}\n\nstruct Audit {\n  created: date\n}\n",
+	)
+	if len(report.Changes) != 2 || report.Changes[0].Path != "Audit.created" || report.Changes[1].Path != "Order.created" {
+		t.Errorf("expected Audit.created and Order.created to change, got:\n%s", report)
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := compareSources(t,
+		"struct User {\n  id: int64\n}\n\nenum Status {\n  active\n}\n",
+		"struct User {\n  id: string\n  email: ?string\n}\n\nenum Status {\n  active\n  banned\n}\n",
+	)
+
+	if report.Max() != Breaking {
+		t.Errorf("expected max severity breaking, got %s", report.Max())
+	}
+	if count := report.Count(Warning); count != 2 {
+		t.Errorf("expected 2 changes at warning or above, got %d", count)
+	}
+	if count := report.Count(Compatible); count != 3 {
+		t.Errorf("expected 3 changes in total, got %d", count)
+	}
+
+	expected := `Breaking changes (1):
+  User.id: type changed from int64 to string
+
+Warning changes (1):
+  Status.banned: variant added; old readers may not handle it
+
+Compatible changes (1):
+  User.email: optional field added
+`
+	if got := report.String(); got != expected {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expected, got)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("failed to marshal report: %v", err)
+	}
+	if !strings.Contains(string(data), `{"kind":"field-type-changed","severity":"breaking","compatibility":"breaking","path":"User.id","message":"type changed from int64 to string","old":"int64","new":"string"}`) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	empty := compareSources(t, "struct A {\n  id: int64\n}\n", "struct A {\n  id: int64\n}\n")
+	if empty.String() != "No changes\n" || empty.Max() != Compatible {
+		t.Errorf("unexpected empty report: %q", empty.String())
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, severity := range []Severity{Compatible, Warning, Breaking} {
+		parsed, err := ParseSeverity(severity.String())
+		if err != nil || parsed != severity {
+			t.Errorf("ParseSeverity(%q) = %v, %v", severity.String(), parsed, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
@@ -0,0 +1,40 @@
+// Package format rewrites TypeGen source in canonical style, like gofmt does for Go.
+package format
+
+import (
+	"bytes"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/parser/printer"
+)
+
+// DefaultIndent is the number of spaces that indent fields and variants
+const DefaultIndent = printer.DefaultIndent
+
+// Options control the canonical style
+type Options struct {
+	// Indent is the number of spaces before fields and variants; 0 means DefaultIndent
+	Indent int
+}
+
+// Source parses TypeGen source and returns it in canonical style, with its
+// comments
+func Source(src []byte, filename string, options Options) ([]byte, error) {
+	program, err := parser.Parse(bytes.NewReader(src), filename)
+	if err != nil {
+		return nil, err
+	}
+	return Program(program, options)
+}
+
+// Program prints a parsed program in canonical style: sorted imports first,
+// then the declarations in their original order, separated by blank lines
+func Program(program *ast.ProgramNode, options Options) ([]byte, error) {
+	var b bytes.Buffer
+	config := printer.Config{Indent: options.Indent}
+	if err := config.Fprint(&b, program); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,237 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+)
+
+// corpus covers every construct, written with inconsistent spacing and layout
+var corpus = map[string]string{
+	"messy.tg": `import   zeta
+import alpha.beta
+const MAX_RETRIES=5
+const   GREETING =   "hello \"world\""
+struct User{
+      id:int64
+  name :   ?string
+	tags:[]string
+  scores: [string]float64
+  nested: [][int32]User
+}
+
+
+enum Status {
+active
+  inactive
+}
+enum Event { created: User   deleted:string
+}
+type   UserList=[]User
+`,
+	"canonical.tg": `import auth
+
+struct Token {
+  value: string
+  expires: ?datetime
+}
+
+type Tokens = [string]Token
+`,
+	"single.tg":   `struct Empty {}`,
+	"comments.tg": commented,
+}
+
+// commented has comments everywhere they may be written
+const commented = `// Shop schema
+
+// for the customer
+import zeta // zeta last
+import alpha
+// A user
+struct User { // users
+  // the id
+  id: int64 // primary key
+  /* legacy */ name: string
+
+  // contact details
+
+  email: ?string
+  // more to come
+} // User
+
+enum Status {
+active // default
+  inactive }
+const MAX_USERS=5 // per shop
+// the end
+`
+
+const commentedFormatted = `// Shop schema
+
+import alpha
+// for the customer
+import zeta // zeta last
+
+// A user
+struct User { // users
+  // the id
+  id: int64 // primary key
+  /* legacy */
+  name: string
+
+  // contact details
+
+  email: ?string
+  // more to come
+} // User
+
+enum Status {
+  active // default
+  inactive
+}
+
+const MAX_USERS = 5 // per shop
+// the end
+`
+
+const messyFormatted = `import alpha.beta
+import zeta
+
+const MAX_RETRIES = 5
+
+const GREETING = "hello \"world\""
+
+struct User {
+  id: int64
+  name: ?string
+  tags: []string
+  scores: [string]float64
+  nested: [][int32]User
+}
+
+enum Status {
+  active
+  inactive
+}
+
+enum Event {
+  created: User
+  deleted: string
+}
+
+type UserList = []User
+`
+
+func TestSource(t *testing.T) {
+	formatted, err := Source([]byte(corpus["messy.tg"]), "messy.tg", Options{})
+	if err != nil {
+		t.Fatalf("Source error: %v", err)
+	}
+	if string(formatted) != messyFormatted {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", formatted, messyFormatted)
+	}
+}
+
+func TestSourceCanonicalUnchanged(t *testing.T) {
+	formatted, err := Source([]byte(corpus["canonical.tg"]), "canonical.tg", Options{})
+	if err != nil {
+		t.Fatalf("Source error: %v", err)
+	}
+	if string(formatted) != corpus["canonical.tg"] {
+		t.Errorf("canonical source changed:\n%s", formatted)
+	}
+}
+
+func TestSourceIdempotent(t *testing.T) {
+	for name, src := range corpus {
+		t.Run(name, func(t *testing.T) {
+			once, err := Source([]byte(src), name, Options{})
+			if err != nil {
+				t.Fatalf("Source error: %v", err)
+			}
+			twice, err := Source(once, name, Options{})
+			if err != nil {
+				t.Fatalf("Source error on formatted output: %v", err)
+			}
+			if string(once) != string(twice) {
+				t.Errorf("fmt is not idempotent:\n--- once ---\n%s\n--- twice ---\n%s", once, twice)
+			}
+		})
+	}
+}
+
+func TestSourcePreservesDeclarations(t *testing.T) {
+	for name, src := range corpus {
+		t.Run(name, func(t *testing.T) {
+			original, err := parser.Parse(strings.NewReader(src), name)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			formatted, err := Source([]byte(src), name, Options{})
+			if err != nil {
+				t.Fatalf("Source error: %v", err)
+			}
+			reparsed, err := parser.Parse(strings.NewReader(string(formatted)), name)
+			if err != nil {
+				t.Fatalf("formatted output does not parse: %v\n%s", err, formatted)
+			}
+
+			if len(reparsed.Declarations) != len(original.Declarations) {
+				t.Fatalf("expected %d declarations, got %d", len(original.Declarations), len(reparsed.Declarations))
+			}
+			for i, decl := range original.Declarations {
+				if reparsed.Declarations[i].String() != decl.String() {
+					t.Errorf("declaration %d changed:\n%s\nbecame:\n%s", i, decl, reparsed.Declarations[i])
+				}
+			}
+			if len(reparsed.Imports) != len(original.Imports) {
+				t.Errorf("expected %d imports, got %d", len(original.Imports), len(reparsed.Imports))
+			}
+			if len(reparsed.Comments) != len(original.Comments) {
+				t.Fatalf("expected %d comments, got %d", len(original.Comments), len(reparsed.Comments))
+			}
+			for _, comment := range original.Comments {
+				if !strings.Contains(string(formatted), comment.Text) {
+					t.Errorf("comment %q was lost", comment.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceIndent(t *testing.T) {
+	formatted, err := Source([]byte("enum Status { active }"), "status.tg", Options{Indent: 4})
+	if err != nil {
+		t.Fatalf("Source error: %v", err)
+	}
+	if expected := "enum Status {\n    active\n}\n"; string(formatted) != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	formatted, err := Source([]byte(commented), "comments.tg", Options{})
+	if err != nil {
+		t.Fatalf("Source error: %v", err)
+	}
+	if string(formatted) != commentedFormatted {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", formatted, commentedFormatted)
+	}
+
+	// Comment markers inside strings are not comments
+	formatted, err = Source([]byte(`const URL="http://example.com"`), "url.tg", Options{})
+	if err != nil {
+		t.Fatalf("Source error: %v", err)
+	}
+	if expected := "const URL = \"http://example.com\"\n"; string(formatted) != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	if _, err := Source([]byte("struct {"), "broken.tg", Options{}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,374 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Field is a column in a BigQuery table schema, in the format accepted by
+// `bq mk --schema` and the tables API
+type Field struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Mode        string  `json:"mode"`
+	Description string  `json:"description,omitempty"`
+	Fields      []Field `json:"fields,omitempty"`
+}
+
+// Field modes
+const (
+	ModeRequired = "REQUIRED"
+	ModeNullable = "NULLABLE"
+	ModeRepeated = "REPEATED"
+)
+
+// Generator generates BigQuery table schemas from TypeGen AST
+type Generator struct {
+	config map[string]string // Configuration options
+	root   *ast.Module       // Root module, used to resolve qualified types
+}
+
+// scope is the lexical context a type expression is resolved in
+type scope struct {
+	module  *ast.Module       // Module declaring the type expression
+	imports map[string]string // Import alias -> TypeGen import path of the declaring file
+}
+
+// NewGenerator creates a new BigQuery schema generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config: make(map[string]string),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "bigquery"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "BigQuery table schemas for every struct"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return nil
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	g.root = module
+	return g.generateModuleRecursive(ctx, module, dest, "")
+}
+
+// generateModuleRecursive recursively generates schemas for a module and its submodules
+func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath string) error {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if err := g.generateProgram(module, module.Files[filename], dest, basePath); err != nil {
+			return fmt.Errorf("failed to generate schemas for %s: %w", filename, err)
+		}
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+
+	for _, subModuleName := range subModuleNames {
+		subModulePath := dest.Join(basePath, subModuleName)
+		if err := g.generateModuleRecursive(ctx, module.SubModules[subModuleName], dest, subModulePath); err != nil {
+			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
+		}
+	}
+
+	return nil
+}
+
+// generateProgram writes one schema file per struct declared in the program.
+// Enums, aliases and constants have no table of their own and are skipped.
+func (g *Generator) generateProgram(module *ast.Module, program *ast.ProgramNode, dest generators.FS, basePath string) error {
+	sc := scope{module: module, imports: importMap(program)}
+
+	for _, decl := range program.Declarations {
+		s, ok := decl.(*ast.StructNode)
+		if !ok {
+			continue
+		}
+
+		fields, err := g.structFields(s, sc, map[ast.Declaration]bool{})
+		if err != nil {
+			return fmt.Errorf("struct %s: %w", s.Name, err)
+		}
+
+		data, err := json.MarshalIndent(fields, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schema for %s: %w", s.Name, err)
+		}
+
+		schemaPath := dest.Join(basePath, naming.SnakeCase(s.Name)+".json")
+		if err := dest.WriteFile(schemaPath, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", schemaPath, err)
+		}
+	}
+
+	return nil
+}
+
+// structFields converts the fields of a struct declared in sc to BigQuery fields. visiting
+// holds the structs and unions being expanded, since BigQuery schemas cannot represent
+// recursive types.
+func (g *Generator) structFields(s *ast.StructNode, sc scope, visiting map[ast.Declaration]bool) ([]Field, error) {
+	visiting[s] = true
+	defer delete(visiting, s)
+
+	fields := make([]Field, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		field, err := g.field(f.JSONName(), f.Type, f.Optional, sc, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// field converts a named value of type t, written in sc, to a BigQuery field
+func (g *Generator) field(name string, t ast.Type, optional bool, sc scope, visiting map[ast.Declaration]bool) (Field, error) {
+	mode := ModeRequired
+	if optional {
+		mode = ModeNullable
+	}
+
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		bqType, err := mapPrimitiveType(typ.Name)
+		if err != nil {
+			return Field{}, err
+		}
+		return Field{Name: name, Type: bqType, Mode: mode}, nil
+	case *ast.NamedType:
+		return g.namedField(name, typ, mode, sc, visiting)
+	case *ast.ArrayType:
+		element, err := g.field(name, typ.ElementType, false, sc, visiting)
+		if err != nil {
+			return Field{}, err
+		}
+		if element.Mode == ModeRepeated {
+			// BigQuery has no arrays of arrays, so each inner array is wrapped in a record
+			element.Name = "value"
+			return Field{Name: name, Type: "RECORD", Mode: ModeRepeated, Fields: []Field{element}}, nil
+		}
+		// Arrays can't hold NULL, so element optionality is dropped
+		element.Mode = ModeRepeated
+		return element, nil
+	case *ast.MapType:
+		key, err := g.field("key", typ.KeyType, false, sc, visiting)
+		if err != nil {
+			return Field{}, err
+		}
+		value, err := g.field("value", typ.ValueType, false, sc, visiting)
+		if err != nil {
+			return Field{}, err
+		}
+		return Field{Name: name, Type: "RECORD", Mode: ModeRepeated, Fields: []Field{key, value}}, nil
+	case *ast.OptionalType:
+		return g.field(name, typ.ElementType, true, sc, visiting)
+	default:
+		return Field{}, fmt.Errorf("unknown type: %T", t)
+	}
+}
+
+// namedField converts a field whose type refers to a declaration. A recursive reference
+// can't be expanded, so an optional one is cut off as a JSON column holding the nested
+// value, and a required one, which has no finite value, is an error.
+func (g *Generator) namedField(name string, t *ast.NamedType, mode string, sc scope, visiting map[ast.Declaration]bool) (Field, error) {
+	decl, declScope, ok := g.resolve(t.Name, sc)
+	if !ok {
+		return Field{}, fmt.Errorf("unknown type %s", t.Name)
+	}
+	if visiting[decl] {
+		if mode != ModeNullable {
+			return Field{}, fmt.Errorf("recursive type %s cannot be represented in a BigQuery schema unless the reference is optional", t.Name)
+		}
+		return Field{Name: name, Type: "JSON", Mode: mode, Description: fmt.Sprintf("recursive %s, stored as JSON", t.Name)}, nil
+	}
+
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		fields, err := g.structFields(d, declScope, visiting)
+		if err != nil {
+			return Field{}, err
+		}
+		return Field{Name: name, Type: "RECORD", Mode: mode, Fields: fields}, nil
+	case *ast.TypeAliasNode:
+		return g.field(name, d.Type, mode == ModeNullable, declScope, visiting)
+	case *ast.EnumNode:
+		if !hasPayloads(d) {
+			return Field{Name: name, Type: "STRING", Mode: mode, Description: enumDescription(d)}, nil
+		}
+		return g.unionField(name, d, mode, declScope, visiting)
+	default:
+		return Field{}, fmt.Errorf("type %s is not a type declaration", t.Name)
+	}
+}
+
+// unionField represents a tagged union declared in sc as a record holding the variant tag
+// in `type` plus one nullable field per variant payload, named after the variant
+func (g *Generator) unionField(name string, e *ast.EnumNode, mode string, sc scope, visiting map[ast.Declaration]bool) (Field, error) {
+	visiting[e] = true
+	defer delete(visiting, e)
+
+	fields := []Field{{Name: "type", Type: "STRING", Mode: ModeRequired, Description: enumDescription(e)}}
+
+	for _, variant := range e.Variants {
+		if variant.Payload == nil {
+			continue
+		}
+		payload, err := g.field(variant.Name, variant.Payload, true, sc, visiting)
+		if err != nil {
+			return Field{}, fmt.Errorf("variant %s: %w", variant.Name, err)
+		}
+		fields = append(fields, payload)
+	}
+
+	return Field{Name: name, Type: "RECORD", Mode: mode, Fields: fields}, nil
+}
+
+// resolve finds the declaration a named type refers to from the given scope, following
+// the file's imports for qualified names, and returns the scope of the file declaring it
+func (g *Generator) resolve(name string, sc scope) (ast.Declaration, scope, bool) {
+	module := sc.module
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		importPath, ok := sc.imports[name[:idx]]
+		if !ok {
+			return nil, scope{}, false
+		}
+		module = g.root
+		for _, part := range strings.Split(importPath, ".") {
+			if module == nil {
+				return nil, scope{}, false
+			}
+			module = module.SubModules[part]
+		}
+		name = name[idx+1:]
+	}
+
+	if module == nil {
+		return nil, scope{}, false
+	}
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		program := module.Files[filename]
+		for _, decl := range program.Declarations {
+			if declName(decl) == name {
+				return decl, scope{module: module, imports: importMap(program)}, true
+			}
+		}
+	}
+	return nil, scope{}, false
+}
+
+// declName returns the name of a type declaration, or "" for constants
+func declName(decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return d.Name
+	case *ast.EnumNode:
+		return d.Name
+	case *ast.TypeAliasNode:
+		return d.Name
+	default:
+		return ""
+	}
+}
+
+// importMap maps each import's alias to its full TypeGen import path
+func importMap(program *ast.ProgramNode) map[string]string {
+	imports := make(map[string]string)
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		imports[parts[len(parts)-1]] = imp.Path
+	}
+	return imports
+}
+
+// mapPrimitiveType maps TypeGen primitive types to BigQuery column types
+func mapPrimitiveType(typeName string) (string, error) {
+	switch typeName {
+	case "bool":
+		return "BOOLEAN", nil
+	case "string":
+		return "STRING", nil
+	case "int", "int8", "int16", "int32", "int64", "nat", "nat8", "nat16", "nat32", "nat64":
+		return "INTEGER", nil
+	case "float32", "float64":
+		return "FLOAT64", nil
+	case "decimal", "bigint", "bignat":
+		return "BIGNUMERIC", nil
+	case "uuid":
+		return "STRING", nil
+	case "json":
+		return "JSON", nil
+	case "datetime", "datetimetz":
+		return "TIMESTAMP", nil
+	case "date", "datetz":
+		return "DATE", nil
+	case "time", "timetz":
+		return "TIME", nil
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// enumDescription lists the values an enum column may hold
+func enumDescription(e *ast.EnumNode) string {
+	values := make([]string, 0, len(e.Variants))
+	for _, variant := range e.Variants {
+		values = append(values, variant.JSONName())
+	}
+	return fmt.Sprintf("%s: one of %s", e.Name, strings.Join(values, ", "))
+}
+
+// hasPayloads reports whether any variant of the enum carries a payload
+func hasPayloads(e *ast.EnumNode) bool {
+	for _, variant := range e.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	// Register the BigQuery generator globally
+	generators.Register("bigquery", func() generators.Generator {
+		return NewGenerator()
+	})
+}
@@ -0,0 +1,289 @@
+package bigquery
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// generateSchemas parses input as a single-file module and returns the generated in-memory files
+func generateSchemas(t *testing.T, input string) *generators.InMemoryFS {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	return fs
+}
+
+// assertGolden compares the schema at path against testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, fs *generators.InMemoryFS, path, name string) {
+	t.Helper()
+
+	content, exists := fs.GetFileString(path)
+	if !exists {
+		t.Fatalf("%s should have been generated", path)
+	}
+
+	goldenPath := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(goldenPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if string(expected) != content {
+		t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", goldenPath, expected, content)
+	}
+}
+
+func TestGeneratePrimitives(t *testing.T) {
+	input := `struct Event {
+		id: int64
+		count: nat32
+		sequence: int
+		size: nat
+		labels: [int]string
+		flags: [nat]bool
+		tiny: int8
+		small: int16
+		medium: int32
+		tiny_count: nat8
+		small_count: nat16
+		large_count: nat64
+		ratio: float32
+		score: float64
+		active: bool
+		name: string
+		note: ?string
+		trace_id: uuid
+		owners: [uuid]string
+		payload: json
+		occurred_at: datetime
+		day: date
+		start_time: time
+		occurred_at_tz: datetimetz
+		day_tz: datetz
+		start_time_tz: timetz
+		total: decimal
+		balance: bigint
+		population: bignat
+	}`
+
+	fs := generateSchemas(t, input)
+	assertGolden(t, fs, "event.json", "primitives")
+}
+
+func TestGenerateNestedAndCollections(t *testing.T) {
+	input := `struct Address {
+		city: string
+		zip: ?string
+	}
+
+	type Tags = []string
+
+	struct Customer {
+		home: Address
+		previous: ?Address
+		addresses: []Address
+		tags: Tags
+		attributes: [string]int64
+		matrix: [][]float64
+	}`
+
+	fs := generateSchemas(t, input)
+	assertGolden(t, fs, "customer.json", "nested_collections")
+}
+
+func TestGenerateEnums(t *testing.T) {
+	input := `enum Status {
+		active
+		inactive
+	}
+
+	struct Failure {
+		code: int32
+	}
+
+	enum Result {
+		success: string
+		error: Failure
+		pending
+	}
+
+	struct Job {
+		status: Status
+		result: ?Result
+	}`
+
+	fs := generateSchemas(t, input)
+	assertGolden(t, fs, "job.json", "enums")
+}
+
+func TestGenerateOneFilePerStruct(t *testing.T) {
+	input := `struct UserProfile {
+		id: int64
+	}
+
+	struct HTTPRequest {
+		path: string
+	}
+
+	enum Status {
+		active
+	}
+
+	type UserID = int64
+	const MAX_USERS = 100`
+
+	fs := generateSchemas(t, input)
+
+	files := fs.ListFiles()
+	sort.Strings(files)
+	expected := []string{"http_request.json", "user_profile.json"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected files %v, got %v", expected, files)
+	}
+}
+
+func TestGenerateRecursiveTypeError(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader(`struct Node {
+		children: []Node
+	}`), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	err = NewGenerator().Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "recursive type Node") {
+		t.Errorf("Expected recursive type error, got: %v", err)
+	}
+}
+
+func TestGenerateQualifiedType(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`import auth
+
+	struct Session {
+		token: auth.Token
+	}`), "session.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	sub, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"session.tg": root})
+	module.SubModules["auth"] = ast.NewModule("api/auth", map[string]*ast.ProgramNode{"token.tg": sub})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	session, exists := fs.GetFileString("session.json")
+	if !exists || !strings.Contains(session, `"name": "value"`) {
+		t.Errorf("Expected session.json to inline auth.Token, got:\n%s", session)
+	}
+	if _, exists := fs.GetFileString("auth/token.json"); !exists {
+		t.Error("auth/token.json should have been generated")
+	}
+}
+
+func TestGenerateNestedCrossModule(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`import shipping
+
+	struct Order {
+		id: int64
+		address: shipping.Address
+	}`), "order.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	// Address refers to a type of its own module and to one it imports
+	address, err := parser.Parse(strings.NewReader(`import shipping.geo
+
+	struct Address {
+		country: Country
+		location: ?geo.Point
+	}`), "address.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	country, err := parser.Parse(strings.NewReader(`enum Country {
+		fr
+		us
+	}`), "country.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	point, err := parser.Parse(strings.NewReader(`struct Point {
+		lat: float64
+		lng: float64
+	}`), "point.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"order.tg": root})
+	shipping := ast.NewModule("api/shipping", map[string]*ast.ProgramNode{"address.tg": address, "country.tg": country})
+	shipping.SubModules["geo"] = ast.NewModule("api/shipping/geo", map[string]*ast.ProgramNode{"point.tg": point})
+	module.SubModules["shipping"] = shipping
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	assertGolden(t, fs, "order.json", "cross_module")
+}
+
+func TestGenerateOptionalRecursion(t *testing.T) {
+	fs := generateSchemas(t, `struct Address {
+		street: string
+		next: ?Address
+	}
+
+	enum Expr {
+		literal: int64
+		negate: Expr
+	}
+
+	struct Formula {
+		expr: Expr
+	}`)
+
+	assertGolden(t, fs, "address.json", "optional_recursion")
+
+	formula, _ := fs.GetFileString("formula.json")
+	if !strings.Contains(formula, `"description": "recursive Expr, stored as JSON"`) {
+		t.Errorf("Expected the recursive payload to be a JSON column, got:\n%s", formula)
+	}
+}
@@ -0,0 +1,226 @@
+package generators
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GeneratedHeader is the comment every generator writes at the top of the
+// source files it generates, after the language's comment marker
+const GeneratedHeader = "Code generated by TypeGen. DO NOT EDIT."
+
+// IsGenerated reports whether data starts with the GeneratedHeader comment,
+// within its first few lines
+func IsGenerated(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for i := 0; i < 5 && scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		for _, marker := range []string{"//", "#", "--"} {
+			if strings.HasPrefix(line, marker) && strings.TrimSpace(line[len(marker):]) == GeneratedHeader {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RemoveFS is implemented by filesystems that can delete files, to clean up
+// stale generated code
+type RemoveFS interface {
+	ReadFS
+
+	// Remove deletes a file, and the directories it leaves empty
+	Remove(name string) error
+}
+
+// Remove implements RemoveFS.Remove. Directories are removed up to, but not
+// including, the root.
+func (fs *osFS) Remove(name string) error {
+	fullPath := filepath.Join(fs.root, name)
+	if err := os.Remove(fullPath); err != nil {
+		return err
+	}
+
+	root := filepath.Clean(fs.root)
+	for dir := filepath.Dir(fullPath); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		// Fails, and stops, at the first directory that isn't empty
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// TrackingFS records the files written and the directories created through
+// it; see Manifest. It is safe for concurrent use if the wrapped filesystem is.
+type TrackingFS struct {
+	FS
+	// OnWrite, if set, is called after each write with the number of
+	// distinct files written so far
+	OnWrite func(name string, count int)
+
+	mu      sync.Mutex
+	written map[string]WrittenFile
+	dirs    map[string]bool // directories created with MkdirAll
+}
+
+// WrittenFile is a file written through a TrackingFS
+type WrittenFile struct {
+	// Path is slash-separated and relative to the filesystem root
+	Path string `json:"path"`
+	// Bytes is the size of the last write to the file
+	Bytes int `json:"bytes"`
+	// Unchanged reports that the file already had this content, so it was
+	// left as it was; see ChangeFS
+	Unchanged bool `json:"unchanged,omitempty"`
+}
+
+// Manifest is what a generator wrote through a TrackingFS, ordered by path
+type Manifest struct {
+	// Files are the files written, each once with its last write
+	Files []WrittenFile `json:"files"`
+	// Dirs are the slash-separated paths of the directories holding the
+	// files or created with MkdirAll, and their parents, relative to the
+	// filesystem root
+	Dirs []string `json:"dirs"`
+}
+
+// NewManifest returns the manifest of files, with the directories holding
+// them, for files recorded without their directories
+func NewManifest(files []WrittenFile) Manifest {
+	return newManifest(files, nil)
+}
+
+// newManifest returns the manifest of files and of the directories created
+// with MkdirAll
+func newManifest(files []WrittenFile, created []string) Manifest {
+	manifest := Manifest{Files: append([]WrittenFile{}, files...), Dirs: []string{}}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		for ; dir != "." && dir != "/" && dir != "" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			manifest.Dirs = append(manifest.Dirs, dir)
+		}
+	}
+	for _, file := range manifest.Files {
+		add(path.Dir(file.Path))
+	}
+	for _, dir := range created {
+		add(dir)
+	}
+	sort.Strings(manifest.Dirs)
+	return manifest
+}
+
+// NewTrackingFS wraps fs to record the files written through it
+func NewTrackingFS(fs FS) *TrackingFS {
+	return &TrackingFS{FS: fs, written: make(map[string]WrittenFile), dirs: make(map[string]bool)}
+}
+
+// MkdirAll implements FS.MkdirAll
+func (fs *TrackingFS) MkdirAll(name string, perm os.FileMode) error {
+	if err := fs.FS.MkdirAll(name, perm); err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	fs.dirs[filepath.ToSlash(filepath.Clean(name))] = true
+	fs.mu.Unlock()
+	return nil
+}
+
+// WriteFile implements FS.WriteFile
+func (fs *TrackingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	_, err := fs.WriteFileChanged(name, data, perm)
+	return err
+}
+
+// WriteFileChanged implements ChangeFS.WriteFileChanged. Files are changed
+// unless the wrapped filesystem implements ChangeFS and reports otherwise.
+func (fs *TrackingFS) WriteFileChanged(name string, data []byte, perm os.FileMode) (bool, error) {
+	changed, err := writeFileChanged(fs.FS, name, data, perm)
+	if err != nil {
+		return false, err
+	}
+	path := filepath.ToSlash(filepath.Clean(name))
+	fs.mu.Lock()
+	fs.written[path] = WrittenFile{Path: path, Bytes: len(data), Unchanged: !changed}
+	count := len(fs.written)
+	fs.mu.Unlock()
+
+	if fs.OnWrite != nil {
+		fs.OnWrite(name, count)
+	}
+	return changed, nil
+}
+
+// Written returns the slash-separated paths of the files written, in sorted order
+func (fs *TrackingFS) Written() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	files := make([]string, 0, len(fs.written))
+	for name := range fs.written {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// WrittenFiles returns the files written with their size, ordered by path
+func (fs *TrackingFS) WrittenFiles() []WrittenFile {
+	return fs.Manifest().Files
+}
+
+// Manifest returns the files written and the directories created so far
+func (fs *TrackingFS) Manifest() Manifest {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	files := make([]WrittenFile, 0, len(fs.written))
+	for _, file := range fs.written {
+		files = append(files, file)
+	}
+	dirs := make([]string, 0, len(fs.dirs))
+	for dir := range fs.dirs {
+		dirs = append(dirs, dir)
+	}
+	return newManifest(files, dirs)
+}
+
+// Clean removes the files of fs that carry the GeneratedHeader and for which
+// keep returns false, and returns their paths. Files without the header are
+// never removed, so code written by hand next to generated code is safe. With
+// dryRun, nothing is removed and the files that would be are returned.
+func Clean(fs RemoveFS, keep func(name string) bool, dryRun bool) ([]string, error) {
+	files, err := fs.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, name := range files {
+		if keep(name) {
+			continue
+		}
+		data, err := fs.ReadFile(name)
+		if err != nil {
+			return removed, err
+		}
+		if !IsGenerated(data) {
+			continue
+		}
+		if !dryRun {
+			if err := fs.Remove(name); err != nil {
+				return removed, err
+			}
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,196 @@
+package generators
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"// Code generated by TypeGen. DO NOT EDIT.\n\npackage user\n", true},
+		{"#!/usr/bin/env python3\n\n# Code generated by TypeGen. DO NOT EDIT.\n", true},
+		{"-- Code generated by TypeGen. DO NOT EDIT.\nCREATE TABLE users ();\n", true},
+		{"<?hh\n// Code generated by TypeGen. DO NOT EDIT.\n", true},
+		{"package user\n\n// Code generated by TypeGen. DO NOT EDIT.\n", true},
+		{"package user\n", false},
+		{"// Code generated by another tool. DO NOT EDIT.\n", false},
+		{"a\nb\nc\nd\ne\n// Code generated by TypeGen. DO NOT EDIT.\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGenerated([]byte(tt.content)); got != tt.expected {
+			t.Errorf("IsGenerated(%q) = %v, expected %v", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestTrackingFS(t *testing.T) {
+	memory := NewInMemoryFS()
+	fs := NewTrackingFS(memory)
+
+	for _, write := range []struct{ name, content string }{
+		{"b.go", "x"},
+		{fs.Join("sub", "a.go"), "ab"},
+		{"b.go", "xyz"},
+	} {
+		if err := fs.WriteFile(write.name, []byte(write.content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	if expected := []string{"b.go", "sub/a.go"}; !reflect.DeepEqual(fs.Written(), expected) {
+		t.Errorf("expected %v, got %v", expected, fs.Written())
+	}
+	// The last write to a file gives its size
+	if expected := []WrittenFile{{Path: "b.go", Bytes: 3}, {Path: "sub/a.go", Bytes: 2}}; !reflect.DeepEqual(fs.WrittenFiles(), expected) {
+		t.Errorf("expected %v, got %v", expected, fs.WrittenFiles())
+	}
+	if !memory.FileExists("sub/a.go") {
+		t.Error("expected writes to reach the wrapped filesystem")
+	}
+}
+
+func TestTrackingFSUnchanged(t *testing.T) {
+	root := t.TempDir()
+	if err := NewOSFS(root).WriteFile("a.go", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewTrackingFS(NewOSFS(root))
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := fs.WriteFile(name, []byte("a"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	expected := []WrittenFile{{Path: "a.go", Bytes: 1, Unchanged: true}, {Path: "b.go", Bytes: 1}}
+	if !reflect.DeepEqual(fs.WrittenFiles(), expected) {
+		t.Errorf("expected %v, got %v", expected, fs.WrittenFiles())
+	}
+}
+
+func TestTrackingFSManifest(t *testing.T) {
+	fs := NewTrackingFS(NewOSFS(t.TempDir()))
+	writes := []struct{ name, content string }{
+		{"z.go", "z"},
+		{fs.Join("models", "billing", "invoice.go"), "invoice"},
+		{fs.Join("models", "user.go"), "u"},
+		{fs.Join("models", "user.go"), "user"},
+		{fs.Join("api", "v1", "routes.go"), "routes"},
+	}
+
+	// Concurrent writes, in any order, give the same manifest
+	var wg sync.WaitGroup
+	for _, write := range writes[:3] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fs.WriteFile(write.name, []byte(write.content), 0644); err != nil {
+				t.Errorf("WriteFile failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, write := range writes[3:] {
+		if err := fs.WriteFile(write.name, []byte(write.content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	if err := fs.MkdirAll(fs.Join("assets", "empty"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	expected := Manifest{
+		Files: []WrittenFile{
+			{Path: "api/v1/routes.go", Bytes: 6},
+			{Path: "models/billing/invoice.go", Bytes: 7},
+			{Path: "models/user.go", Bytes: 4},
+			{Path: "z.go", Bytes: 1},
+		},
+		Dirs: []string{"api", "api/v1", "assets", "assets/empty", "models", "models/billing"},
+	}
+	if manifest := fs.Manifest(); !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected manifest %+v, got %+v", expected, manifest)
+	}
+
+	// The manifest of recorded files has the directories holding them
+	expected.Dirs = []string{"api", "api/v1", "models", "models/billing"}
+	if manifest := NewManifest(fs.WrittenFiles()); !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("expected manifest %+v, got %+v", expected, manifest)
+	}
+}
+
+func TestClean(t *testing.T) {
+	generated := "// " + GeneratedHeader + "\n"
+
+	for _, dryRun := range []bool{false, true} {
+		root := t.TempDir()
+		fs := NewOSFS(root).(RemoveFS)
+		for name, content := range map[string]string{
+			"user.go":            generated,
+			"stale.go":           generated,
+			"notes.go":           "package user\n",
+			"auth/old/token.go":  generated,
+			"auth/session.go":    generated,
+			"auth/old/README.md": "hand-written\n",
+			"billing/invoice.py": "# " + GeneratedHeader + "\n",
+		} {
+			if err := fs.WriteFile(name, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keep := map[string]bool{"user.go": true, "auth/session.go": true}
+		removed, err := Clean(fs, func(name string) bool { return keep[name] }, dryRun)
+		if err != nil {
+			t.Fatalf("Clean failed: %v", err)
+		}
+
+		expected := []string{"auth/old/token.go", "billing/invoice.py", "stale.go"}
+		if !reflect.DeepEqual(removed, expected) {
+			t.Errorf("dry run %v: expected %v, got %v", dryRun, expected, removed)
+		}
+
+		files, err := fs.Files()
+		if err != nil {
+			t.Fatal(err)
+		}
+		remaining := []string{"auth/old/README.md", "auth/session.go", "notes.go", "user.go"}
+		if dryRun {
+			remaining = []string{"auth/old/README.md", "auth/old/token.go", "auth/session.go", "billing/invoice.py", "notes.go", "stale.go", "user.go"}
+		}
+		if !reflect.DeepEqual(files, remaining) {
+			t.Errorf("dry run %v: expected files %v, got %v", dryRun, remaining, files)
+		}
+
+		// Directories left empty are removed, but never the root
+		_, err = os.Stat(filepath.Join(root, "billing"))
+		if os.IsNotExist(err) == dryRun {
+			t.Errorf("dry run %v: unexpected state of the emptied directory: %v", dryRun, err)
+		}
+		if _, err := os.Stat(root); err != nil {
+			t.Errorf("expected the root to be kept: %v", err)
+		}
+	}
+}
+
+func TestCleanKeepsRoot(t *testing.T) {
+	root := t.TempDir()
+	fs := NewOSFS(root).(RemoveFS)
+	if err := fs.WriteFile("stale.go", []byte("// "+GeneratedHeader+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Clean(fs, func(string) bool { return false }, false); err != nil {
+		t.Fatalf("Clean failed: %v", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("expected the empty root to be kept: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package generators
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Configure sets the configuration of a generator: with SetConfigTyped if it
+// implements TypedConfigurable, or else with SetConfig and the values turned
+// into strings by StringifyConfig
+func Configure(generator Generator, config map[string]any) {
+	if typed, ok := generator.(TypedConfigurable); ok {
+		typed.SetConfigTyped(config)
+		return
+	}
+	generator.SetConfig(StringifyConfig(config))
+}
+
+// StringifyConfig turns typed configuration values into the strings taken by
+// SetConfig. Strings are kept, bools and numbers are written as in YAML, null
+// is the empty string, and lists and maps are written as JSON.
+func StringifyConfig(config map[string]any) map[string]string {
+	if config == nil {
+		return nil
+	}
+	values := make(map[string]string, len(config))
+	for key, value := range config {
+		values[key] = StringifyValue(value)
+	}
+	return values
+}
+
+// StringifyValue turns a typed configuration value into a string; see
+// StringifyConfig
+func StringifyValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []any, map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// ValidateConfig checks the configuration of a generator before it's set:
+// with its own ValidateConfig if it implements ConfigValidator, or else
+// against the options it documents with ValidateOptions. Generators that
+// don't implement Describer accept any configuration.
+func ValidateConfig(generator Generator, config map[string]string) error {
+	if validator, ok := generator.(ConfigValidator); ok {
+		return validator.ValidateConfig(config)
+	}
+	if describer, ok := generator.(Describer); ok {
+		return ValidateOptions(describer.Options(), config)
+	}
+	return nil
+}
+
+// ValidateOptions checks configuration values against option specs: keys
+// must be documented, and values must match the option's type. Bools are
+// true or false, ints are decimal integers, and a type listing values such
+// as "class|shape" takes one of them; other types take any value. Empty
+// values are unset and always accepted.
+func ValidateOptions(options []OptionSpec, config map[string]string) error {
+	specs := make(map[string]OptionSpec, len(options))
+	for _, option := range options {
+		specs[option.Key] = option
+	}
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		spec, known := specs[key]
+		if !known {
+			errs = append(errs, unknownOption(key, options))
+			continue
+		}
+		if err := spec.check(config[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unknownOption returns the error of a key that isn't one of options
+func unknownOption(key string, options []OptionSpec) error {
+	if len(options) == 0 {
+		return fmt.Errorf("unknown option %q; the generator takes no options", key)
+	}
+	keys := make([]string, len(options))
+	for i, option := range options {
+		keys[i] = option.Key
+	}
+	sort.Strings(keys)
+	return fmt.Errorf("unknown option %q; options are %s", key, strings.Join(keys, ", "))
+}
+
+// check returns an error if value doesn't match the option's type
+func (o OptionSpec) check(value string) error {
+	if value == "" {
+		return nil
+	}
+	switch {
+	case o.Type == "bool":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("invalid %s %q (expected true or false)", o.Key, value)
+		}
+	case o.Type == "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("invalid %s %q (expected an integer)", o.Key, value)
+		}
+	case strings.Contains(o.Type, "|"):
+		values := strings.Split(o.Type, "|")
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid %s %q (expected %s)", o.Key, value, strings.Join(values, " or "))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package generators
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+func TestStringifyConfig(t *testing.T) {
+	config := map[string]any{
+		"package":  "models",
+		"testdata": true,
+		"seed":     42,
+		"ratio":    0.5,
+		"unset":    nil,
+		"tags":     []any{"json", "db"},
+		"imports":  map[string]any{"time": "github.com/acme/time", "strict": false},
+	}
+	expected := map[string]string{
+		"package":  "models",
+		"testdata": "true",
+		"seed":     "42",
+		"ratio":    "0.5",
+		"unset":    "",
+		"tags":     `["json","db"]`,
+		"imports":  `{"strict":false,"time":"github.com/acme/time"}`,
+	}
+	if got := StringifyConfig(config); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// stringConfigGenerator only implements SetConfig
+type stringConfigGenerator struct {
+	config map[string]string
+}
+
+func (g *stringConfigGenerator) SetConfig(config map[string]string) { g.config = config }
+
+func (g *stringConfigGenerator) Generate(ctx context.Context, module *ast.Module, dest FS) error {
+	return nil
+}
+
+// typedConfigGenerator also implements TypedConfigurable
+type typedConfigGenerator struct {
+	stringConfigGenerator
+	typed map[string]any
+}
+
+func (g *typedConfigGenerator) SetConfigTyped(config map[string]any) { g.typed = config }
+
+func TestConfigure(t *testing.T) {
+	config := map[string]any{"testdata": true, "tags": []any{"json"}}
+
+	plain := &stringConfigGenerator{}
+	Configure(plain, config)
+	if expected := map[string]string{"testdata": "true", "tags": `["json"]`}; !reflect.DeepEqual(plain.config, expected) {
+		t.Errorf("expected string config %v, got %v", expected, plain.config)
+	}
+
+	typed := &typedConfigGenerator{}
+	Configure(typed, config)
+	if !reflect.DeepEqual(typed.typed, config) {
+		t.Errorf("expected typed config %v, got %v", config, typed.typed)
+	}
+	if typed.config != nil {
+		t.Errorf("expected SetConfig not to be called, got %v", typed.config)
+	}
+}
+
+// validatingGenerator checks its configuration itself
+type validatingGenerator struct {
+	describedGenerator
+}
+
+func (g *validatingGenerator) ValidateConfig(config map[string]string) error {
+	if config["style"] == "c" {
+		return nil
+	}
+	return errors.New("style must be c")
+}
+
+func TestValidateConfig(t *testing.T) {
+	options := []OptionSpec{
+		{Key: "package", Type: "string"},
+		{Key: "testdata", Type: "bool"},
+		{Key: "seed", Type: "int"},
+		{Key: "style", Type: "class|shape"},
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		err    string
+	}{
+		{"valid", map[string]string{"package": "models", "testdata": "true", "seed": "-3", "style": "shape"}, ""},
+		{"empty values", map[string]string{"testdata": "", "seed": "", "style": ""}, ""},
+		{"unknown key", map[string]string{"packge": "models"}, `unknown option "packge"; options are package, seed, style, testdata`},
+		{"malformed bool", map[string]string{"testdata": "yes"}, `invalid testdata "yes" (expected true or false)`},
+		{"malformed int", map[string]string{"seed": "1.5"}, `invalid seed "1.5" (expected an integer)`},
+		{"value not listed", map[string]string{"style": "struct"}, `invalid style "struct" (expected class or shape)`},
+		{"several errors", map[string]string{"seed": "x", "extra": "1"}, "unknown option \"extra\"; options are package, seed, style, testdata\ninvalid seed \"x\" (expected an integer)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOptions(options, tt.config)
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	if err := ValidateOptions(nil, map[string]string{"style": "a"}); err == nil || err.Error() != `unknown option "style"; the generator takes no options` {
+		t.Errorf("expected options to be rejected, got %v", err)
+	}
+
+	// Generators without metadata accept anything, described generators are
+	// checked against their options, and validators check themselves
+	if err := ValidateConfig(&plainGenerator{}, map[string]string{"anything": "x"}); err != nil {
+		t.Errorf("unexpected error for a generator without metadata: %v", err)
+	}
+	if err := ValidateConfig(&describedGenerator{}, map[string]string{"style": "c"}); err == nil {
+		t.Error("expected an error for a value the described generator doesn't list")
+	}
+	if err := ValidateConfig(&validatingGenerator{}, map[string]string{"style": "c"}); err != nil {
+		t.Errorf("unexpected error from the generator's own validation: %v", err)
+	}
+}
@@ -0,0 +1,1058 @@
+package cpp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/graph"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// Generator generates C++17 code with nlohmann/json serialization from TypeGen AST
+type Generator struct {
+	config map[string]string                    // Configuration options
+	root   *ast.Module                          // Root module, used to resolve qualified types
+	cycles map[ast.Declaration]*graph.Component // Types that reference themselves through a cycle
+
+	// Per-file state, reset for every generated program
+	module     *ast.Module       // Module containing the current file
+	dir        string            // Slash-separated directory of the current file, relative to the root
+	imports    map[string]string // Import alias -> TypeGen import path
+	stdInclude map[string]bool   // Standard headers needed by the current file
+	localDeps  map[string]bool   // Generated headers needed by the current file
+	header     string            // Slash-separated path of the current file's header
+	current    ast.Declaration   // Declaration being generated
+}
+
+// function is a generated free function, emitted inline in the header or split into a source file
+type function struct {
+	signature string
+	body      []string
+}
+
+// NewGenerator creates a new C++ code generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config: make(map[string]string),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "cpp"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "C++17 headers serialized through nlohmann/json"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "namespace", Type: "string", Description: "Root namespace for generated code, e.g. acme::api"},
+		{Key: "source", Type: "bool", Default: "false", Description: "Write a .cpp per .tg file holding the conversion functions, leaving only declarations in the header"},
+	}
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	switch g.config["source"] {
+	case "", "true", "false":
+	default:
+		return fmt.Errorf("invalid source option %q (expected true or false)", g.config["source"])
+	}
+
+	g.root = module
+	g.cycles = make(map[ast.Declaration]*graph.Component)
+	for _, component := range graph.Build(module).TopologicalOrder() {
+		if component.Cyclic {
+			for _, node := range component.Nodes {
+				g.cycles[node.Decl] = component
+			}
+		}
+	}
+	return g.generateModuleRecursive(ctx, module, dest, "", "")
+}
+
+// generateModuleRecursive recursively generates C++ code for a module and its submodules
+func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath, dir string) error {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		stem := strings.TrimSuffix(filename, ".tg")
+
+		header, source, err := g.generateProgram(module, dir, stem, module.Files[filename])
+		if err != nil {
+			return fmt.Errorf("failed to generate code for %s: %w", filename, err)
+		}
+
+		headerPath := dest.Join(basePath, stem+".h")
+		if err := dest.WriteFile(headerPath, []byte(header), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", headerPath, err)
+		}
+
+		if source != "" {
+			sourcePath := dest.Join(basePath, stem+".cpp")
+			if err := dest.WriteFile(sourcePath, []byte(source), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", sourcePath, err)
+			}
+		}
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+
+	for _, subModuleName := range subModuleNames {
+		subModulePath := dest.Join(basePath, subModuleName)
+		if err := g.generateModuleRecursive(ctx, module.SubModules[subModuleName], dest, subModulePath, path.Join(dir, subModuleName)); err != nil {
+			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
+		}
+	}
+
+	return nil
+}
+
+// generateProgram converts a TypeGen program to a C++ header, plus a source file when
+// the source option is enabled
+func (g *Generator) generateProgram(module *ast.Module, dir, stem string, program *ast.ProgramNode) (string, string, error) {
+	g.module = module
+	g.dir = dir
+	g.imports = make(map[string]string)
+	g.stdInclude = make(map[string]bool)
+	g.localDeps = make(map[string]bool)
+	g.header = path.Join(dir, stem+".h")
+
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		g.imports[parts[len(parts)-1]] = imp.Path
+	}
+
+	splitSource := g.config["source"] == "true"
+
+	var body []string
+	if forward := g.forwardDeclarations(program.Declarations, splitSource); forward != "" {
+		body = append(body, forward)
+	}
+
+	var functions []function
+	var deferred []string
+	for _, decl := range g.orderDeclarations(program.Declarations) {
+		g.current = decl
+		code, fns, err := g.generateDeclaration(decl)
+		if err != nil {
+			return "", "", err
+		}
+
+		for _, fn := range fns {
+			switch {
+			case splitSource:
+				code += "\n\n" + fn.signature + ";"
+			case g.forwardDeclared(decl):
+				// The functions of a cycle use all of its types, so they wait until those are complete
+				deferred = append(deferred, formatFunction("inline "+fn.signature, fn.body))
+			default:
+				code += "\n\n" + formatFunction("inline "+fn.signature, fn.body)
+			}
+		}
+		body = append(body, code)
+		functions = append(functions, fns...)
+	}
+	body = append(body, deferred...)
+
+	namespace := g.namespaceFor(dir)
+	header := g.buildHeader(namespace, body)
+
+	if !splitSource {
+		return header, "", nil
+	}
+
+	var definitions []string
+	for _, fn := range functions {
+		definitions = append(definitions, formatFunction(fn.signature, fn.body))
+	}
+
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("#include \"%s\"", g.header))
+	parts = append(parts, "")
+	source := strings.Join(parts, "\n") + "\n" + wrapNamespace(namespace, strings.Join(definitions, "\n\n")) + "\n"
+
+	return header, source, nil
+}
+
+// buildHeader assembles the header: generated notice, #pragma once, includes and namespaced body
+func (g *Generator) buildHeader(namespace string, body []string) string {
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+	parts = append(parts, "#pragma once")
+	parts = append(parts, "")
+
+	// Standard headers first, then nlohmann/json, then generated headers
+	var std []string
+	for include := range g.stdInclude {
+		std = append(std, include)
+	}
+	sort.Strings(std)
+	for _, include := range std {
+		parts = append(parts, fmt.Sprintf("#include <%s>", include))
+	}
+	if len(std) > 0 {
+		parts = append(parts, "")
+	}
+	parts = append(parts, "#include <nlohmann/json.hpp>")
+
+	if len(g.localDeps) > 0 {
+		var local []string
+		for include := range g.localDeps {
+			local = append(local, include)
+		}
+		sort.Strings(local)
+		parts = append(parts, "")
+		for _, include := range local {
+			parts = append(parts, fmt.Sprintf("#include \"%s\"", include))
+		}
+	}
+	parts = append(parts, "")
+
+	return strings.Join(parts, "\n") + "\n" + wrapNamespace(namespace, strings.Join(body, "\n\n")) + "\n"
+}
+
+// generateDeclaration generates the C++ type for a declaration and its JSON conversion functions
+func (g *Generator) generateDeclaration(decl ast.Declaration) (string, []function, error) {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return g.generateStruct(d)
+	case *ast.EnumNode:
+		if hasPayloads(d) {
+			return g.generateVariant(d)
+		}
+		return g.generateEnumClass(d)
+	case *ast.TypeAliasNode:
+		code, err := g.generateUsing(d)
+		return code, nil, err
+	case *ast.ConstantNode:
+		code, err := g.generateConstant(d)
+		return code, nil, err
+	default:
+		return "", nil, fmt.Errorf("unknown declaration type: %T", decl)
+	}
+}
+
+// generateStruct generates a plain struct with value-initialized members
+func (g *Generator) generateStruct(s *ast.StructNode) (string, []function, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("struct %s {", s.Name))
+	for _, field := range s.Fields {
+		cppType, err := g.generateType(field.Type, field.Optional)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, fmt.Sprintf("  %s %s{};", cppType, fieldName(field.Name)))
+	}
+	parts = append(parts, "};")
+
+	var toJSON, fromJSON []string
+	toJSON = append(toJSON, "j = nlohmann::json::object();")
+	for _, field := range s.Fields {
+		member := "value." + fieldName(field.Name)
+		if field.Optional {
+			toJSON = append(toJSON, fmt.Sprintf("if (%s) {", member))
+			toJSON = append(toJSON, fmt.Sprintf("  j[\"%s\"] = %s;", field.JSONName(), g.encodeExpr(field.Type, g.deref(field.Type, member), 0)))
+			toJSON = append(toJSON, "}")
+
+			raw := fmt.Sprintf("j.at(\"%s\")", field.JSONName())
+			decoded, err := g.decodeExpr(field.Type, raw, 0)
+			if err != nil {
+				return "", nil, err
+			}
+			fromJSON = append(fromJSON, fmt.Sprintf("if (j.contains(\"%s\") && !%s.is_null()) {", field.JSONName(), raw))
+			fromJSON = append(fromJSON, fmt.Sprintf("  %s = %s;", member, decoded))
+			fromJSON = append(fromJSON, "} else {")
+			if g.boxed(field.Type) {
+				fromJSON = append(fromJSON, fmt.Sprintf("  %s = nullptr;", member))
+			} else {
+				fromJSON = append(fromJSON, fmt.Sprintf("  %s = std::nullopt;", member))
+			}
+			fromJSON = append(fromJSON, "}")
+		} else {
+			toJSON = append(toJSON, fmt.Sprintf("j[\"%s\"] = %s;", field.JSONName(), g.encodeExpr(field.Type, member, 0)))
+
+			decoded, err := g.decodeExpr(field.Type, fmt.Sprintf("j.at(\"%s\")", field.JSONName()), 0)
+			if err != nil {
+				return "", nil, err
+			}
+			fromJSON = append(fromJSON, fmt.Sprintf("%s = %s;", member, decoded))
+		}
+	}
+
+	fns := []function{
+		{signature: fmt.Sprintf("void to_json(nlohmann::json& j, const %s& value)", s.Name), body: toJSON},
+		{signature: fmt.Sprintf("void from_json(const nlohmann::json& j, %s& value)", s.Name), body: fromJSON},
+	}
+	if len(s.Fields) == 0 {
+		fns[0].signature = fmt.Sprintf("void to_json(nlohmann::json& j, const %s& /*value*/)", s.Name)
+		fns[1].signature = fmt.Sprintf("void from_json(const nlohmann::json& /*j*/, %s& /*value*/)", s.Name)
+	}
+
+	return strings.Join(parts, "\n"), fns, nil
+}
+
+// generateEnumClass generates an enum class serialized in the {"type": "variant"} object format
+func (g *Generator) generateEnumClass(e *ast.EnumNode) (string, []function, error) {
+	g.stdInclude["stdexcept"] = true
+	g.stdInclude["string"] = true
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("enum class %s {", e.Name))
+	for _, variant := range e.Variants {
+		parts = append(parts, fmt.Sprintf("  %s,", toPascalCase(variant.Name)))
+	}
+	parts = append(parts, "};")
+
+	var toJSON []string
+	toJSON = append(toJSON, "switch (value) {")
+	for _, variant := range e.Variants {
+		toJSON = append(toJSON, fmt.Sprintf("  case %s::%s:", e.Name, toPascalCase(variant.Name)))
+		toJSON = append(toJSON, fmt.Sprintf("    j = nlohmann::json{{\"type\", \"%s\"}};", variant.JSONName()))
+		toJSON = append(toJSON, "    return;")
+	}
+	toJSON = append(toJSON, "}")
+	toJSON = append(toJSON, fmt.Sprintf("throw std::invalid_argument(\"invalid %s value\");", e.Name))
+
+	var fromJSON []string
+	fromJSON = append(fromJSON, "const auto type = j.at(\"type\").get<std::string>();")
+	for _, variant := range e.Variants {
+		fromJSON = append(fromJSON, fmt.Sprintf("if (type == \"%s\") {", variant.JSONName()))
+		fromJSON = append(fromJSON, fmt.Sprintf("  value = %s::%s;", e.Name, toPascalCase(variant.Name)))
+		fromJSON = append(fromJSON, "  return;")
+		fromJSON = append(fromJSON, "}")
+	}
+	fromJSON = append(fromJSON, fmt.Sprintf("throw std::invalid_argument(\"unknown %s type: \" + type);", e.Name))
+
+	fns := []function{
+		{signature: fmt.Sprintf("void to_json(nlohmann::json& j, const %s& value)", e.Name), body: toJSON},
+		{signature: fmt.Sprintf("void from_json(const nlohmann::json& j, %s& value)", e.Name), body: fromJSON},
+	}
+	return strings.Join(parts, "\n"), fns, nil
+}
+
+// generateVariant generates a std::variant over one struct per variant for enums with payloads
+func (g *Generator) generateVariant(e *ast.EnumNode) (string, []function, error) {
+	g.stdInclude["stdexcept"] = true
+	g.stdInclude["string"] = true
+	g.stdInclude["type_traits"] = true
+	g.stdInclude["variant"] = true
+
+	var blocks []string
+	var alternatives []string
+	for _, variant := range e.Variants {
+		structName := variantStructName(e, variant)
+		alternatives = append(alternatives, structName)
+
+		if variant.Payload == nil {
+			blocks = append(blocks, fmt.Sprintf("struct %s {};", structName))
+			continue
+		}
+
+		cppType, err := g.generateType(variant.Payload, false)
+		if err != nil {
+			return "", nil, err
+		}
+		blocks = append(blocks, fmt.Sprintf("struct %s {\n  %s payload{};\n};", structName, cppType))
+	}
+	if !g.forwardDeclared(e) {
+		blocks = append(blocks, fmt.Sprintf("using %s = std::variant<%s>;", e.Name, strings.Join(alternatives, ", ")))
+	}
+
+	// to_json visits the active alternative
+	var toJSON []string
+	toJSON = append(toJSON, "std::visit([&j](const auto& variant) {")
+	toJSON = append(toJSON, "  using T = std::decay_t<decltype(variant)>;")
+	for i, variant := range e.Variants {
+		keyword := "if constexpr"
+		if i > 0 {
+			keyword = "} else if constexpr"
+		}
+		toJSON = append(toJSON, fmt.Sprintf("  %s (std::is_same_v<T, %s>) {", keyword, variantStructName(e, variant)))
+		if variant.Payload != nil {
+			toJSON = append(toJSON, fmt.Sprintf("    j = nlohmann::json{{\"type\", \"%s\"}, {\"payload\", %s}};", variant.JSONName(), g.encodeExpr(variant.Payload, "variant.payload", 0)))
+		} else {
+			toJSON = append(toJSON, fmt.Sprintf("    j = nlohmann::json{{\"type\", \"%s\"}};", variant.JSONName()))
+		}
+	}
+	toJSON = append(toJSON, "  }")
+	toJSON = append(toJSON, "}, value);")
+
+	// from_json switches on the tag
+	var fromJSON []string
+	fromJSON = append(fromJSON, "const auto type = j.at(\"type\").get<std::string>();")
+	for _, variant := range e.Variants {
+		structName := variantStructName(e, variant)
+		fromJSON = append(fromJSON, fmt.Sprintf("if (type == \"%s\") {", variant.JSONName()))
+		if variant.Payload != nil {
+			decoded, err := g.decodeExpr(variant.Payload, "j.at(\"payload\")", 0)
+			if err != nil {
+				return "", nil, err
+			}
+			fromJSON = append(fromJSON, fmt.Sprintf("  value = %s{%s};", structName, decoded))
+		} else {
+			fromJSON = append(fromJSON, fmt.Sprintf("  value = %s{};", structName))
+		}
+		fromJSON = append(fromJSON, "  return;")
+		fromJSON = append(fromJSON, "}")
+	}
+	fromJSON = append(fromJSON, fmt.Sprintf("throw std::invalid_argument(\"unknown %s type: \" + type);", e.Name))
+
+	fns := []function{
+		{signature: fmt.Sprintf("void to_json(nlohmann::json& j, const %s& value)", e.Name), body: toJSON},
+		{signature: fmt.Sprintf("void from_json(const nlohmann::json& j, %s& value)", e.Name), body: fromJSON},
+	}
+	return strings.Join(blocks, "\n\n"), fns, nil
+}
+
+// generateUsing generates a using declaration for a type alias
+func (g *Generator) generateUsing(t *ast.TypeAliasNode) (string, error) {
+	cppType, err := g.generateType(t.Type, false)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("using %s = %s;", t.Name, cppType), nil
+}
+
+// generateConstant generates an inline constexpr constant
+func (g *Generator) generateConstant(c *ast.ConstantNode) (string, error) {
+	switch value := c.Value.(type) {
+	case *ast.IntConstant:
+		g.stdInclude["cstdint"] = true
+		return fmt.Sprintf("inline constexpr int64_t %s = %d;", c.Name, value.Value), nil
+	case *ast.StringConstant:
+		g.stdInclude["string_view"] = true
+		return fmt.Sprintf("inline constexpr std::string_view %s = %s;", c.Name, quote(value.Value)), nil
+	case *ast.FloatConstant:
+		return fmt.Sprintf("inline constexpr double %s = %s;", c.Name, value.String()), nil
+	case *ast.BoolConstant:
+		return fmt.Sprintf("inline constexpr bool %s = %t;", c.Name, value.Value), nil
+	default:
+		return "", fmt.Errorf("unsupported constant value type: %T", value)
+	}
+}
+
+// generateType converts a TypeGen type to a C++ type, recording the headers it needs
+func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
+	var baseType string
+
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		cppType, err := mapPrimitiveType(typ.Name)
+		if err != nil {
+			return "", err
+		}
+		baseType = cppType
+		switch {
+		case strings.HasSuffix(baseType, "_t"):
+			g.stdInclude["cstdint"] = true
+		case baseType == "std::string":
+			g.stdInclude["string"] = true
+		}
+	case *ast.NamedType:
+		name, err := g.namedType(typ.Name)
+		if err != nil {
+			return "", err
+		}
+		if g.boxed(typ) {
+			// A null pointer stands for an absent optional
+			g.stdInclude["memory"] = true
+			return fmt.Sprintf("std::unique_ptr<%s>", name), nil
+		}
+		baseType = name
+	case *ast.ArrayType:
+		var elementType string
+		var err error
+		if g.boxed(typ.ElementType) {
+			// std::vector holds incomplete types since C++17, so the element needs no pointer
+			elementType, err = g.namedType(typ.ElementType.(*ast.NamedType).Name)
+		} else {
+			elementType, err = g.generateType(typ.ElementType, false)
+		}
+		if err != nil {
+			return "", err
+		}
+		g.stdInclude["vector"] = true
+		baseType = fmt.Sprintf("std::vector<%s>", elementType)
+	case *ast.MapType:
+		keyType, err := g.generateType(typ.KeyType, false)
+		if err != nil {
+			return "", err
+		}
+		valueType, err := g.generateType(typ.ValueType, false)
+		if err != nil {
+			return "", err
+		}
+		g.stdInclude["map"] = true
+		baseType = fmt.Sprintf("std::map<%s, %s>", keyType, valueType)
+	case *ast.OptionalType:
+		return g.generateType(typ.ElementType, true)
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+
+	if optional {
+		g.stdInclude["optional"] = true
+		return fmt.Sprintf("std::optional<%s>", baseType), nil
+	}
+	return baseType, nil
+}
+
+// namedType returns the C++ spelling of a named type and records the header declaring it
+func (g *Generator) namedType(name string) (string, error) {
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		if _, filename, ok := findInFiles(g.module, name); ok {
+			g.addLocalDep(g.dir, filename)
+		}
+		return name, nil
+	}
+
+	alias, typeName := name[:idx], name[idx+1:]
+	importPath, ok := g.imports[alias]
+	if !ok {
+		return "", fmt.Errorf("unknown module %q in type %s", alias, name)
+	}
+
+	parts := strings.Split(importPath, ".")
+	if module := g.findModule(parts); module != nil {
+		if _, filename, ok := findInFiles(module, typeName); ok {
+			g.addLocalDep(strings.Join(parts, "/"), filename)
+		}
+	}
+
+	return "::" + strings.Join(append(nonEmpty(g.rootNamespace()), append(parts, typeName)...), "::"), nil
+}
+
+// addLocalDep records the generated header for a .tg file, unless it is the current file's own
+func (g *Generator) addLocalDep(dir, filename string) {
+	header := path.Join(dir, strings.TrimSuffix(filename, ".tg")+".h")
+	if header != g.header {
+		g.localDeps[header] = true
+	}
+}
+
+// decodeExpr returns a C++ expression converting the nlohmann::json expr into type t.
+// Types nlohmann/json maps onto the TypeGen wire format natively use get<T>(), while
+// maps with integer keys and optionals nested in collections are converted explicitly.
+func (g *Generator) decodeExpr(t ast.Type, expr string, depth int) (string, error) {
+	cppType, err := g.generateType(t, false)
+	if err != nil {
+		return "", err
+	}
+	if g.isPlain(t) {
+		return fmt.Sprintf("%s.get<%s>()", expr, cppType), nil
+	}
+
+	switch typ := t.(type) {
+	case *ast.NamedType:
+		if g.boxed(typ) {
+			name, err := g.namedType(typ.Name)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("std::make_unique<%s>(%s.get<%s>())", name, expr, name), nil
+		}
+		// Only aliases of non-plain types reach here
+		alias := g.resolveAlias(typ)
+		defer g.enter(alias)()
+		return g.decodeExpr(alias.Type, expr, depth)
+	case *ast.ArrayType:
+		e := fmt.Sprintf("e%d", depth)
+		element, err := g.decodeExpr(typ.ElementType, e, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[&] { %s out; for (const auto& %s : %s) { out.push_back(%s); } return out; }()", cppType, e, expr, element), nil
+	case *ast.MapType:
+		item := fmt.Sprintf("item%d", depth)
+		value, err := g.decodeExpr(typ.ValueType, item+".value()", depth+1)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[&] { %s out; for (const auto& %s : %s.items()) { out.emplace(%s, %s); } return out; }()",
+			cppType, item, expr, g.decodeKey(typ.KeyType, item+".key()"), value), nil
+	case *ast.OptionalType:
+		element, err := g.decodeExpr(typ.ElementType, expr, depth)
+		if err != nil {
+			return "", err
+		}
+		elementType, err := g.generateType(typ.ElementType, true)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s.is_null() ? %s{} : %s{%s}", expr, elementType, elementType, element), nil
+	default:
+		return fmt.Sprintf("%s.get<%s>()", expr, cppType), nil
+	}
+}
+
+// decodeKey converts a JSON object key into the C++ map key type
+func (g *Generator) decodeKey(t ast.Type, expr string) string {
+	primitive := keyPrimitive(t, g)
+	if !isStringKey(primitive) {
+		g.stdInclude["string"] = true // std::stoll and std::stoull
+	}
+	// Key types are checked by generateType before their keys are decoded
+	cppType, _ := mapPrimitiveType(primitive)
+	switch {
+	case strings.HasPrefix(primitive, "nat"):
+		return fmt.Sprintf("static_cast<%s>(std::stoull(%s))", cppType, expr)
+	case strings.HasPrefix(primitive, "int"):
+		return fmt.Sprintf("static_cast<%s>(std::stoll(%s))", cppType, expr)
+	default:
+		return expr
+	}
+}
+
+// encodeExpr returns a C++ expression converting expr of type t into a value assignable to nlohmann::json
+func (g *Generator) encodeExpr(t ast.Type, expr string, depth int) string {
+	if g.isPlain(t) {
+		return expr
+	}
+
+	switch typ := t.(type) {
+	case *ast.NamedType:
+		if g.boxed(typ) {
+			return "*" + expr
+		}
+		alias := g.resolveAlias(typ)
+		defer g.enter(alias)()
+		return g.encodeExpr(alias.Type, expr, depth)
+	case *ast.ArrayType:
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("[&] { auto out = nlohmann::json::array(); for (const auto& %s : %s) { out.push_back(%s); } return out; }()",
+			e, expr, g.encodeExpr(typ.ElementType, e, depth+1))
+	case *ast.MapType:
+		k := fmt.Sprintf("k%d", depth)
+		v := fmt.Sprintf("v%d", depth)
+		key := k
+		if !isStringKey(keyPrimitive(typ.KeyType, g)) {
+			g.stdInclude["string"] = true
+			key = fmt.Sprintf("std::to_string(%s)", k)
+		}
+		return fmt.Sprintf("[&] { auto out = nlohmann::json::object(); for (const auto& [%s, %s] : %s) { out[%s] = %s; } return out; }()",
+			k, v, expr, key, g.encodeExpr(typ.ValueType, v, depth+1))
+	case *ast.OptionalType:
+		return fmt.Sprintf("%s ? nlohmann::json(%s) : nlohmann::json(nullptr)", expr, g.encodeExpr(typ.ElementType, g.deref(typ.ElementType, expr), depth))
+	default:
+		return expr
+	}
+}
+
+// isPlain reports whether nlohmann/json's built-in conversions for type t already produce the wire format
+func (g *Generator) isPlain(t ast.Type) bool {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return true
+	case *ast.NamedType:
+		if g.boxed(typ) {
+			return false
+		}
+		if alias := g.resolveAlias(typ); alias != nil {
+			defer g.enter(alias)()
+			return g.isPlain(alias.Type)
+		}
+		return true // Structs and enums carry their own to_json/from_json
+	case *ast.ArrayType:
+		return g.boxed(typ.ElementType) || g.isPlain(typ.ElementType)
+	case *ast.MapType:
+		return isStringKey(keyPrimitive(typ.KeyType, g)) && g.isPlain(typ.ValueType)
+	case *ast.OptionalType:
+		return false
+	default:
+		return false
+	}
+}
+
+// resolveAlias returns the alias declaration if the named type is an alias, or nil otherwise
+func (g *Generator) resolveAlias(t *ast.NamedType) *ast.TypeAliasNode {
+	var module *ast.Module
+	name := t.Name
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		importPath, ok := g.imports[name[:idx]]
+		if !ok {
+			return nil
+		}
+		module = g.findModule(strings.Split(importPath, "."))
+		name = name[idx+1:]
+	} else {
+		module = g.module
+	}
+
+	if module == nil {
+		return nil
+	}
+	if decl, _, ok := findInFiles(module, name); ok {
+		if alias, ok := decl.(*ast.TypeAliasNode); ok {
+			return alias
+		}
+	}
+	return nil
+}
+
+// enter makes decl the current declaration while the type it spells is converted, since
+// whether references are boxed depends on it, and returns a function restoring the previous one
+func (g *Generator) enter(decl ast.Declaration) func() {
+	previous := g.current
+	g.current = decl
+	return func() { g.current = previous }
+}
+
+// findModule walks the root module's submodules along an import path
+func (g *Generator) findModule(parts []string) *ast.Module {
+	module := g.root
+	for _, part := range parts {
+		if module == nil {
+			return nil
+		}
+		module = module.SubModules[part]
+	}
+	return module
+}
+
+// rootNamespace returns the configured root namespace without leading or trailing separators
+func (g *Generator) rootNamespace() string {
+	return strings.Trim(g.config["namespace"], ":")
+}
+
+// namespaceFor returns the namespace for files in a directory relative to the root module
+func (g *Generator) namespaceFor(dir string) string {
+	parts := nonEmpty(g.rootNamespace())
+	if dir != "" {
+		parts = append(parts, strings.Split(dir, "/")...)
+	}
+	return strings.Join(parts, "::")
+}
+
+// wrapNamespace wraps code in a namespace block, if a namespace is set
+func wrapNamespace(namespace, code string) string {
+	if namespace == "" {
+		return code
+	}
+	return fmt.Sprintf("namespace %s {\n\n%s\n\n}  // namespace %s", namespace, code, namespace)
+}
+
+// formatFunction renders a function definition with a two-space indented body
+func formatFunction(signature string, body []string) string {
+	var lines []string
+	lines = append(lines, signature+" {")
+	for _, line := range body {
+		lines = append(lines, "  "+line)
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// orderDeclarations orders declarations so each type follows the same-file types it
+// depends on, keeping source order otherwise. Types of its own cycle that are forward
+// declared don't count, while depending on a type of another cycle means depending on
+// the whole cycle, since its types are complete only together. Constants go last.
+func (g *Generator) orderDeclarations(decls []ast.Declaration) []ast.Declaration {
+	byName := make(map[string]ast.Declaration)
+	for _, decl := range decls {
+		if name := typeDeclName(decl); name != "" {
+			byName[name] = decl
+		}
+	}
+
+	visited := make(map[ast.Declaration]bool)
+	var ordered []ast.Declaration
+
+	var visit func(decl ast.Declaration)
+	visit = func(decl ast.Declaration) {
+		if visited[decl] {
+			return
+		}
+		visited[decl] = true
+
+		names := make(map[string]bool)
+		ast.Inspect(decl, func(named *ast.NamedType) {
+			names[named.Name] = true
+		})
+		deps := make([]string, 0, len(names))
+		for name := range names {
+			deps = append(deps, name)
+		}
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			target, ok := byName[dep]
+			switch {
+			case !ok:
+			case g.cycles[target] == nil:
+				visit(target)
+			case g.cycles[target] == g.cycles[decl]:
+				if !g.forwardDeclared(target) {
+					visit(target)
+				}
+			default:
+				for _, member := range decls {
+					if g.cycles[member] == g.cycles[target] {
+						visit(member)
+					}
+				}
+			}
+		}
+		ordered = append(ordered, decl)
+	}
+
+	var constants []ast.Declaration
+	for _, decl := range decls {
+		if _, ok := decl.(*ast.ConstantNode); ok {
+			constants = append(constants, decl)
+			continue
+		}
+		visit(decl)
+	}
+
+	return append(ordered, constants...)
+}
+
+// forwardDeclarations declares the structs and tagged unions that are part of a cycle ahead
+// of all definitions, so that they can refer to each other. Inline conversion functions
+// are declared too, since each type's functions call the others'.
+func (g *Generator) forwardDeclarations(decls []ast.Declaration, splitSource bool) string {
+	var declarations, prototypes []string
+	for _, decl := range decls {
+		if !g.forwardDeclared(decl) {
+			continue
+		}
+
+		var name string
+		switch d := decl.(type) {
+		case *ast.StructNode:
+			name = d.Name
+			declarations = append(declarations, fmt.Sprintf("struct %s;", d.Name))
+		case *ast.EnumNode:
+			name = d.Name
+			g.stdInclude["variant"] = true
+			var alternatives []string
+			for _, variant := range d.Variants {
+				alternatives = append(alternatives, variantStructName(d, variant))
+				declarations = append(declarations, fmt.Sprintf("struct %s;", variantStructName(d, variant)))
+			}
+			declarations = append(declarations, fmt.Sprintf("using %s = std::variant<%s>;", d.Name, strings.Join(alternatives, ", ")))
+		}
+		prototypes = append(prototypes,
+			fmt.Sprintf("inline void to_json(nlohmann::json& j, const %s& value);", name),
+			fmt.Sprintf("inline void from_json(const nlohmann::json& j, %s& value);", name))
+	}
+
+	if len(declarations) == 0 {
+		return ""
+	}
+	if splitSource {
+		// The header declares every function ahead of the source file's definitions
+		return strings.Join(declarations, "\n")
+	}
+	return strings.Join(declarations, "\n") + "\n\n" + strings.Join(prototypes, "\n")
+}
+
+// forwardDeclared reports whether a declaration is a struct or tagged union in a cycle, which
+// forwardDeclarations declares ahead of the definitions. Simple enums and aliases can't be.
+func (g *Generator) forwardDeclared(decl ast.Declaration) bool {
+	if g.cycles[decl] == nil {
+		return false
+	}
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return true
+	case *ast.EnumNode:
+		return hasPayloads(d)
+	default:
+		return false
+	}
+}
+
+// boxed reports whether t refers to a type of the current declaration's own cycle, which
+// may still be incomplete where t is used. Such references are held by std::unique_ptr,
+// except for array elements.
+func (g *Generator) boxed(t ast.Type) bool {
+	named, ok := t.(*ast.NamedType)
+	if !ok || strings.Contains(named.Name, ".") || g.cycles[g.current] == nil {
+		return false
+	}
+	decl, _, ok := findInFiles(g.module, named.Name)
+	if !ok {
+		return false
+	}
+	if alias, ok := decl.(*ast.TypeAliasNode); ok {
+		return g.boxed(alias.Type)
+	}
+	return g.forwardDeclared(decl) && g.cycles[decl] == g.cycles[g.current]
+}
+
+// deref returns the expression for the value held by expr, an optional of type t. Boxed
+// types are already pointers, which null stands in for an absent value.
+func (g *Generator) deref(t ast.Type, expr string) string {
+	if g.boxed(t) {
+		return expr
+	}
+	return "*" + expr
+}
+
+// findInFiles finds a type declaration in a module's own files, without descending into submodules
+func findInFiles(module *ast.Module, name string) (ast.Declaration, string, bool) {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		for _, decl := range module.Files[filename].Declarations {
+			if typeDeclName(decl) == name {
+				return decl, filename, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// keyPrimitive returns the primitive name behind a map key type, following aliases
+func keyPrimitive(t ast.Type, g *Generator) string {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return typ.Name
+	case *ast.NamedType:
+		if alias := g.resolveAlias(typ); alias != nil {
+			return keyPrimitive(alias.Type, g)
+		}
+	}
+	return ""
+}
+
+// isStringKey reports whether map keys of a primitive type are std::string, like JSON object keys
+func isStringKey(primitive string) bool {
+	return primitive == "string" || primitive == "uuid"
+}
+
+// mapPrimitiveType maps TypeGen primitive types to C++ types
+func mapPrimitiveType(typeName string) (string, error) {
+	switch typeName {
+	case "bool":
+		return "bool", nil
+	case "string":
+		return "std::string", nil
+	case "int":
+		return "int64_t", nil // At least 64 bits
+	case "nat":
+		return "uint64_t", nil
+	case "int8", "int16", "int32", "int64":
+		return typeName + "_t", nil
+	case "nat8", "nat16", "nat32", "nat64":
+		return "uint" + strings.TrimPrefix(typeName, "nat") + "_t", nil // nat32 -> uint32_t
+	case "float32":
+		return "float", nil
+	case "float64":
+		return "double", nil
+	case "decimal", "bigint", "bignat":
+		return "std::string", nil // Exact decimal strings, as on the wire
+	case "uuid":
+		return "std::string", nil // Canonical hyphenated form, as on the wire
+	case "json":
+		return "nlohmann::json", nil
+	case "time", "date", "datetime", "timetz", "datetz", "datetimetz":
+		return "std::string", nil // ISO 8601 strings, as on the wire
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// typeDeclName returns the name of a type declaration, or "" for constants
+func typeDeclName(decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return d.Name
+	case *ast.EnumNode:
+		return d.Name
+	case *ast.TypeAliasNode:
+		return d.Name
+	default:
+		return ""
+	}
+}
+
+// variantStructName returns the struct name for a tagged union variant
+func variantStructName(e *ast.EnumNode, variant *ast.EnumVariantNode) string {
+	return e.Name + toPascalCase(variant.Name)
+}
+
+// hasPayloads reports whether any variant of the enum carries a payload
+func hasPayloads(e *ast.EnumNode) bool {
+	for _, variant := range e.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the C++ member name for a field, suffixing C++ keywords with an underscore
+func fieldName(name string) string {
+	return naming.CppKeywords.Escape(name)
+}
+
+// nonEmpty splits a namespace into its components, returning nil for an empty namespace
+func nonEmpty(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return strings.Split(namespace, "::")
+}
+
+// quote returns a double-quoted C++ string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "\"" + s + "\""
+}
+
+// toPascalCase converts snake_case to PascalCase for C++ type and enumerator names
+func toPascalCase(name string) string {
+	return (&naming.Caser{KeepCase: true}).PascalCase(name)
+}
+
+func init() {
+	// Register the C++ generator globally
+	generators.Register("cpp", func() generators.Generator {
+		return NewGenerator()
+	})
+	// Fields are escaped with a trailing underscore and variants are written
+	// in PascalCase, but type and namespace names as they are declared.
+	// Constants are in CONSTANT_CASE, which no keyword is.
+	validator.RegisterReservedWords("cpp", validator.ReservedWords{
+		Language: "C++",
+		Words:    naming.CppKeywords,
+		Names:    []string{validator.TypeNames, validator.ModuleNames},
+	})
+}
@@ -0,0 +1,435 @@
+package cpp
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// generateFile parses input as a single-file module and returns the generated header
+func generateFile(t *testing.T, input string, config map[string]string) string {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	result, exists := fs.GetFileString("test.h")
+	if !exists {
+		t.Fatal("test.h should have been generated")
+	}
+	return result
+}
+
+// assertGolden compares content against testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name, content string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if string(expected) != content {
+		t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", path, expected, content)
+	}
+}
+
+func TestGenerateStruct(t *testing.T) {
+	input := `struct User {
+		id: int64
+		age: nat8
+		name: string
+		email: ?string
+		tags: []string
+		scores: [string]float64
+		created_at: datetime
+		metadata: json
+	}`
+
+	result := generateFile(t, input, map[string]string{"namespace": "acme::api"})
+	assertGolden(t, "struct", result)
+}
+
+func TestGenerateEnumClass(t *testing.T) {
+	input := `enum Status {
+		active
+		pending_review
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "enum_class", result)
+}
+
+func TestGenerateVariant(t *testing.T) {
+	input := `struct Failure {
+		code: int32
+	}
+
+	enum Result {
+		success: string
+		error: Failure
+		pending
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "variant", result)
+}
+
+func TestGenerateAliasesAndConstants(t *testing.T) {
+	input := `type UserID = int64
+	type Tags = []string
+	const MAX_USERS = 100
+	const API_VERSION = "v1"`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "aliases_constants", result)
+}
+
+func TestGenerateFloatConstants(t *testing.T) {
+	input := `const RATIO = 0.75
+	const AVOGADRO = 6.022e23
+	const EPSILON = 1e-9
+	const OFFSET = -2.5
+	const WHOLE = 3.0`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"inline constexpr double RATIO = 0.75;",
+		"inline constexpr double AVOGADRO = 6.022e23;",
+		"inline constexpr double EPSILON = 1e-9;",
+		"inline constexpr double OFFSET = -2.5;",
+		"inline constexpr double WHOLE = 3.0;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBoolConstants(t *testing.T) {
+	input := `const ENABLED = true
+	const DEPRECATED = false`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"inline constexpr bool ENABLED = true;",
+		"inline constexpr bool DEPRECATED = false;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateSourceFile(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader(`struct User {
+		id: int64
+	}`), "user.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"user.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"source": "true", "namespace": "acme"})
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	header, exists := fs.GetFileString("user.h")
+	if !exists {
+		t.Fatal("user.h should have been generated")
+	}
+	source, exists := fs.GetFileString("user.cpp")
+	if !exists {
+		t.Fatal("user.cpp should have been generated")
+	}
+
+	assertGolden(t, "source_header", header)
+	assertGolden(t, "source_source", source)
+}
+
+func TestGenerateExplicitConversions(t *testing.T) {
+	input := `struct Item {
+		id: int64
+	}
+
+	type ItemsByID = [int64]Item
+
+	struct Inventory {
+		by_id: ItemsByID
+		counts: [nat32]int32
+		items: []Item
+	}`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		// Integer keys are stringified, as nlohmann/json would otherwise emit an array of pairs
+		`out[std::to_string(k0)] = v0;`,
+		`out.emplace(static_cast<int64_t>(std::stoll(item0.key())), item0.value().get<Item>());`,
+		`out.emplace(static_cast<uint32_t>(std::stoull(item0.key())), item0.value().get<int32_t>());`,
+		// Structs and vectors of structs go through the generated to_json/from_json
+		`value.items = j.at("items").get<std::vector<Item>>();`,
+		`j["items"] = value.items;`,
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateDependencyOrder(t *testing.T) {
+	input := `struct Order {
+		item: Item
+	}
+
+	struct Item {
+		id: int64
+	}`
+
+	result := generateFile(t, input, nil)
+
+	if strings.Index(result, "struct Item {") > strings.Index(result, "struct Order {") {
+		t.Errorf("Expected Item to be declared before Order, got:\n%s", result)
+	}
+}
+
+func TestGenerateRecursiveTypes(t *testing.T) {
+	input := `struct Directory {
+		root: Node
+		owner: User
+	}
+
+	struct Node {
+		name: string
+		parent: ?Node
+		children: []Node
+		links: [string]Node
+	}
+
+	struct User {
+		id: int64
+		status: Status
+	}
+
+	enum Status {
+		active
+		banned: User
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "recursive", result)
+}
+
+func TestGenerateKeywordFields(t *testing.T) {
+	input := `struct Shape {
+		class: string
+	}`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"std::string class_{};",
+		`j["class"] = value.class_;`,
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateModuleIncludesAndNamespaces(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`import auth
+
+	struct Session {
+		token: auth.Token
+		user: User
+	}`), "session.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	user, err := parser.Parse(strings.NewReader(`struct User { id: int64 }`), "user.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	sub, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"session.tg": root, "user.tg": user})
+	module.SubModules["auth"] = ast.NewModule("api/auth", map[string]*ast.ProgramNode{"token.tg": sub})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"namespace": "acme"})
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	session, exists := fs.GetFileString("session.h")
+	if !exists {
+		t.Fatal("session.h should have been generated")
+	}
+
+	expected := []string{
+		"#include \"auth/token.h\"\n#include \"user.h\"",
+		"::acme::auth::Token token{};",
+		"User user{};",
+		"namespace acme {",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(session, exp) {
+			t.Errorf("Expected session.h to contain %q, got:\n%s", exp, session)
+		}
+	}
+
+	token, exists := fs.GetFileString("auth/token.h")
+	if !exists || !strings.Contains(token, "namespace acme::auth {") {
+		t.Errorf("Expected auth/token.h in namespace acme::auth, got:\n%s", token)
+	}
+}
+
+func TestGenerateInvalidSourceOption(t *testing.T) {
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{})
+
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"source": "yes"})
+
+	err := generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid source option") {
+		t.Errorf("Expected invalid source option error, got: %v", err)
+	}
+}
+
+func TestGeneratePrimitives(t *testing.T) {
+	input := `struct Primitives {
+		flag: bool
+		name: string
+		count: int
+		total: nat
+		labels: [int]string
+		flags: [nat]bool
+		tiny: int8
+		small: int16
+		medium: int32
+		large: int64
+		tiny_nat: nat8
+		small_nat: nat16
+		medium_nat: nat32
+		large_nat: nat64
+		ratio: float32
+		score: float64
+		price: decimal
+		balance: bigint
+		population: bignat
+		id: uuid
+		owners: [uuid]string
+		payload: json
+		day: date
+		clock: time
+		moment: datetime
+		day_tz: datetz
+		clock_tz: timetz
+		moment_tz: datetimetz
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "primitives", result)
+}
+
+func TestGenerateUnsupportedPrimitive(t *testing.T) {
+	program := &ast.ProgramNode{Declarations: []ast.Declaration{
+		&ast.StructNode{Name: "Wide", Fields: []*ast.FieldNode{
+			{Name: "value", Type: &ast.PrimitiveType{Name: "int128"}},
+		}},
+	}}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	err := NewGenerator().Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "unsupported primitive type int128") {
+		t.Errorf("Expected unsupported primitive type error, got: %v", err)
+	}
+}
+
+func TestReservedWords(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader(`struct Request {
+		class: string
+		default: ?string
+	}
+
+	type union = [string]string`), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+	module.SubModules = map[string]*ast.Module{"namespace": ast.NewModule("namespace", nil)}
+
+	v := validator.NewValidator()
+	v.SetGenerators([]string{"cpp"})
+	var messages []string
+	for _, err := range v.Validate(module).Errors {
+		if err.Type == validator.ReservedWordError {
+			messages = append(messages, fmt.Sprintf("%s:%d:%d %s", err.File, err.Line, err.Column, err.Message))
+		}
+	}
+	sort.Strings(messages)
+
+	// Fields are escaped with a trailing underscore, so only the names of types
+	// and namespaces are reserved.
+	expected := []string{
+		"namespace:0:0 module 'namespace' is a reserved word in C++, the target language of generator 'cpp'",
+		"test.tg:6:2 type alias 'union' is a reserved word in C++, the target language of generator 'cpp'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected reserved words:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
@@ -0,0 +1,663 @@
+package dart
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// barrelFilename is the per-directory file re-exporting every generated library
+const barrelFilename = "index.dart"
+
+// caser writes class names in PascalCase and members in camelCase, keeping
+// the case of acronyms after the first letter of a word
+var caser = &naming.Caser{KeepCase: true}
+
+// Generator generates Dart code from TypeGen AST
+type Generator struct {
+	config map[string]string // Configuration options
+	module *ast.Module       // Root module, used to resolve named types
+}
+
+// NewGenerator creates a new Dart code generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config: make(map[string]string),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "dart"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Null-safe Dart classes with fromJson/toJson"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "package", Type: "string", Description: "Dart package name; when set, cross-module imports use package: URIs"},
+	}
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	g.module = module
+	return g.generateModuleRecursive(ctx, module, dest, "", 0)
+}
+
+// generateModuleRecursive recursively generates Dart code for a module and its submodules
+func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath string, depth int) error {
+	var exports []string
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		dartFilename := strings.TrimSuffix(filename, ".tg") + ".dart"
+		dartPath := dest.Join(basePath, dartFilename)
+
+		code, err := g.generateProgram(module.Files[filename], depth)
+		if err != nil {
+			return fmt.Errorf("failed to generate code for %s: %w", filename, err)
+		}
+
+		if err := dest.WriteFile(dartPath, []byte(code), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", dartPath, err)
+		}
+
+		exports = append(exports, dartFilename)
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+
+	for _, subModuleName := range subModuleNames {
+		subModulePath := dest.Join(basePath, subModuleName)
+		if err := g.generateModuleRecursive(ctx, module.SubModules[subModuleName], dest, subModulePath, depth+1); err != nil {
+			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
+		}
+		exports = append(exports, subModuleName+"/"+barrelFilename)
+	}
+
+	// Write the barrel file re-exporting this directory's libraries
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+	for _, export := range exports {
+		parts = append(parts, fmt.Sprintf("export '%s';", export))
+	}
+
+	barrelPath := dest.Join(basePath, barrelFilename)
+	if err := dest.WriteFile(barrelPath, []byte(strings.Join(parts, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", barrelPath, err)
+	}
+
+	return nil
+}
+
+// generateProgram converts a TypeGen program to a Dart library
+func (g *Generator) generateProgram(program *ast.ProgramNode, depth int) (string, error) {
+	var body []string
+	for _, decl := range program.Declarations {
+		code, err := g.generateDeclaration(decl)
+		if err != nil {
+			return "", err
+		}
+		body = append(body, code)
+	}
+
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+
+	imports := g.buildImports(program, depth)
+	if len(imports) > 0 {
+		parts = append(parts, imports...)
+		parts = append(parts, "")
+	}
+
+	return strings.Join(parts, "\n") + "\n" + strings.Join(body, "\n\n") + "\n", nil
+}
+
+// buildImports returns the import directives a program needs: the barrel of its own
+// directory for types declared in sibling files, and a prefixed barrel per TypeGen import
+func (g *Generator) buildImports(program *ast.ProgramNode, depth int) []string {
+	declared := make(map[string]bool)
+	for _, decl := range program.Declarations {
+		declared[declName(decl)] = true
+	}
+
+	referenced := make(map[string]bool)
+	ast.Inspect(program, func(named *ast.NamedType) {
+		referenced[named.Name] = true
+	})
+
+	needsSiblings := false
+	for name := range referenced {
+		if !strings.Contains(name, ".") && !declared[name] {
+			needsSiblings = true
+			break
+		}
+	}
+
+	var imports []string
+	if needsSiblings {
+		imports = append(imports, fmt.Sprintf("import '%s';", barrelFilename))
+	}
+
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		alias := parts[len(parts)-1]
+		target := strings.Join(parts, "/") + "/" + barrelFilename
+
+		if pkg := g.config["package"]; pkg != "" {
+			target = fmt.Sprintf("package:%s/%s", pkg, target)
+		} else {
+			target = strings.Repeat("../", depth) + target
+		}
+		imports = append(imports, fmt.Sprintf("import '%s' as %s;", target, alias))
+	}
+
+	sort.Strings(imports)
+	return imports
+}
+
+// generateDeclaration generates Dart code for a declaration
+func (g *Generator) generateDeclaration(decl ast.Declaration) (string, error) {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return g.generateClass(d)
+	case *ast.EnumNode:
+		if hasPayloads(d) {
+			return g.generateSealedClass(d)
+		}
+		return g.generateEnum(d)
+	case *ast.TypeAliasNode:
+		return g.generateTypedef(d)
+	case *ast.ConstantNode:
+		return g.generateConstant(d)
+	default:
+		return "", fmt.Errorf("unknown declaration type: %T", decl)
+	}
+}
+
+// generateClass generates an immutable class with a const constructor and fromJson/toJson
+func (g *Generator) generateClass(s *ast.StructNode) (string, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("class %s {", s.Name))
+
+	for _, field := range s.Fields {
+		dartType, err := g.generateType(field.Type, field.Optional)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("  final %s %s;", dartType, caser.CamelCase(field.Name)))
+	}
+	if len(s.Fields) > 0 {
+		parts = append(parts, "")
+	}
+
+	// Constructor
+	if len(s.Fields) == 0 {
+		parts = append(parts, fmt.Sprintf("  const %s();", s.Name))
+	} else {
+		parts = append(parts, fmt.Sprintf("  const %s({", s.Name))
+		for _, field := range s.Fields {
+			if field.Optional {
+				parts = append(parts, fmt.Sprintf("    this.%s,", caser.CamelCase(field.Name)))
+			} else {
+				parts = append(parts, fmt.Sprintf("    required this.%s,", caser.CamelCase(field.Name)))
+			}
+		}
+		parts = append(parts, "  });")
+	}
+
+	// fromJson
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  factory %s.fromJson(Map<String, dynamic> json) {", s.Name))
+	if len(s.Fields) == 0 {
+		parts = append(parts, fmt.Sprintf("    return const %s();", s.Name))
+	} else {
+		parts = append(parts, fmt.Sprintf("    return %s(", s.Name))
+		for _, field := range s.Fields {
+			raw := fmt.Sprintf("json['%s']", field.JSONName())
+			value := g.decodeExpr(field.Type, raw, 0)
+			if field.Optional {
+				value = fmt.Sprintf("%s == null ? null : %s", raw, value)
+			}
+			parts = append(parts, fmt.Sprintf("      %s: %s,", caser.CamelCase(field.Name), value))
+		}
+		parts = append(parts, "    );")
+	}
+	parts = append(parts, "  }")
+
+	// toJson
+	parts = append(parts, "")
+	parts = append(parts, "  Map<String, dynamic> toJson() {")
+	parts = append(parts, "    return {")
+	for _, field := range s.Fields {
+		name := caser.CamelCase(field.Name)
+		if field.Optional {
+			// Public fields don't promote, so conversions need an explicit non-null assertion
+			value := name
+			if !g.isPlain(field.Type) {
+				value = g.encodeExpr(field.Type, name+"!", 0)
+			}
+			parts = append(parts, fmt.Sprintf("      if (%s != null) '%s': %s,", name, field.JSONName(), value))
+		} else {
+			parts = append(parts, fmt.Sprintf("      '%s': %s,", field.JSONName(), g.encodeExpr(field.Type, name, 0)))
+		}
+	}
+	parts = append(parts, "    };")
+	parts = append(parts, "  }")
+
+	parts = append(parts, "}")
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateEnum generates an enhanced enum serialized in the {"type": "variant"} object format
+func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("enum %s {", e.Name))
+
+	for i, variant := range e.Variants {
+		terminator := ","
+		if i == len(e.Variants)-1 {
+			terminator = ";"
+		}
+		parts = append(parts, fmt.Sprintf("  %s('%s')%s", caser.CamelCase(variant.Name), variant.JSONName(), terminator))
+	}
+
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  const %s(this.value);", e.Name))
+	parts = append(parts, "")
+	parts = append(parts, "  final String value;")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  static %s fromJson(Map<String, dynamic> json) {", e.Name))
+	parts = append(parts, "    final type = json['type'];")
+	parts = append(parts, "    if (type == null) {")
+	parts = append(parts, "      throw FormatException(\"missing 'type' field\");")
+	parts = append(parts, "    }")
+	parts = append(parts, fmt.Sprintf("    for (final variant in %s.values) {", e.Name))
+	parts = append(parts, "      if (variant.value == type) {")
+	parts = append(parts, "        return variant;")
+	parts = append(parts, "      }")
+	parts = append(parts, "    }")
+	parts = append(parts, "    throw FormatException('unknown enum value: $type');")
+	parts = append(parts, "  }")
+	parts = append(parts, "")
+	parts = append(parts, "  Map<String, dynamic> toJson() => {'type': value};")
+	parts = append(parts, "}")
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateSealedClass generates a sealed class hierarchy for enums with payloads
+func (g *Generator) generateSealedClass(e *ast.EnumNode) (string, error) {
+	var parts []string
+
+	parts = append(parts, fmt.Sprintf("sealed class %s {", e.Name))
+	parts = append(parts, fmt.Sprintf("  const %s();", e.Name))
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  factory %s.fromJson(Map<String, dynamic> json) {", e.Name))
+	parts = append(parts, "    switch (json['type']) {")
+	for _, variant := range e.Variants {
+		className := variantClassName(e, variant)
+		parts = append(parts, fmt.Sprintf("      case '%s':", variant.JSONName()))
+		if variant.Payload != nil {
+			parts = append(parts, "        if (!json.containsKey('payload')) {")
+			parts = append(parts, fmt.Sprintf("          throw FormatException(\"missing 'payload' field for type '%s'\");", variant.JSONName()))
+			parts = append(parts, "        }")
+			parts = append(parts, fmt.Sprintf("        return %s(%s);", className, g.decodeExpr(variant.Payload, "json['payload']", 0)))
+		} else {
+			parts = append(parts, fmt.Sprintf("        return const %s();", className))
+		}
+	}
+	parts = append(parts, "      case null:")
+	parts = append(parts, "        throw FormatException(\"missing 'type' field\");")
+	parts = append(parts, "      default:")
+	parts = append(parts, "        throw FormatException('unknown type: ${json['type']}');")
+	parts = append(parts, "    }")
+	parts = append(parts, "  }")
+	parts = append(parts, "")
+	parts = append(parts, "  Map<String, dynamic> toJson();")
+	parts = append(parts, "}")
+
+	for _, variant := range e.Variants {
+		className := variantClassName(e, variant)
+		parts = append(parts, "")
+		parts = append(parts, fmt.Sprintf("final class %s extends %s {", className, e.Name))
+
+		if variant.Payload != nil {
+			dartType, err := g.generateType(variant.Payload, false)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, fmt.Sprintf("  final %s payload;", dartType))
+			parts = append(parts, "")
+			parts = append(parts, fmt.Sprintf("  const %s(this.payload);", className))
+			parts = append(parts, "")
+			parts = append(parts, "  @override")
+			parts = append(parts, fmt.Sprintf("  Map<String, dynamic> toJson() => {'type': '%s', 'payload': %s};", variant.JSONName(), g.encodeExpr(variant.Payload, "payload", 0)))
+		} else {
+			parts = append(parts, fmt.Sprintf("  const %s();", className))
+			parts = append(parts, "")
+			parts = append(parts, "  @override")
+			parts = append(parts, fmt.Sprintf("  Map<String, dynamic> toJson() => {'type': '%s'};", variant.JSONName()))
+		}
+
+		parts = append(parts, "}")
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateTypedef generates a Dart typedef for a type alias
+func (g *Generator) generateTypedef(t *ast.TypeAliasNode) (string, error) {
+	dartType, err := g.generateType(t.Type, false)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("typedef %s = %s;", t.Name, dartType), nil
+}
+
+// generateConstant generates a top-level Dart constant
+func (g *Generator) generateConstant(c *ast.ConstantNode) (string, error) {
+	switch value := c.Value.(type) {
+	case *ast.IntConstant:
+		return fmt.Sprintf("const int %s = %d;", c.Name, value.Value), nil
+	case *ast.StringConstant:
+		return fmt.Sprintf("const String %s = %s;", c.Name, quote(value.Value)), nil
+	case *ast.FloatConstant:
+		return fmt.Sprintf("const double %s = %s;", c.Name, value.Decimal()), nil
+	case *ast.BoolConstant:
+		return fmt.Sprintf("const bool %s = %t;", c.Name, value.Value), nil
+	default:
+		return "", fmt.Errorf("unsupported constant value type: %T", value)
+	}
+}
+
+// generateType converts a TypeGen type to a Dart type
+func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
+	var baseType string
+
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		dartType, err := mapPrimitiveType(typ.Name)
+		if err != nil {
+			return "", err
+		}
+		baseType = dartType
+	case *ast.NamedType:
+		baseType = typ.Name // Qualified names map directly onto prefixed imports
+	case *ast.ArrayType:
+		elementType, err := g.generateType(typ.ElementType, false)
+		if err != nil {
+			return "", err
+		}
+		baseType = fmt.Sprintf("List<%s>", elementType)
+	case *ast.MapType:
+		keyType, err := g.generateType(typ.KeyType, false)
+		if err != nil {
+			return "", err
+		}
+		valueType, err := g.generateType(typ.ValueType, false)
+		if err != nil {
+			return "", err
+		}
+		baseType = fmt.Sprintf("Map<%s, %s>", keyType, valueType)
+	case *ast.OptionalType:
+		return g.generateType(typ.ElementType, true)
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+
+	if optional && baseType != "dynamic" {
+		return baseType + "?", nil
+	}
+	return baseType, nil
+}
+
+// decodeExpr returns a Dart expression converting the JSON-decoded value expr into type t
+func (g *Generator) decodeExpr(t ast.Type, expr string, depth int) string {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		switch dartType := dartPrimitive(typ.Name); dartType {
+		case "dynamic":
+			return expr
+		case "double":
+			return fmt.Sprintf("(%s as num).toDouble()", expr)
+		case "DateTime":
+			return fmt.Sprintf("DateTime.parse(%s as String)", expr)
+		case "BigInt":
+			// Written as strings, but numbers are accepted too
+			return fmt.Sprintf("BigInt.parse(%s.toString())", expr)
+		default:
+			return fmt.Sprintf("%s as %s", expr, dartType)
+		}
+	case *ast.NamedType:
+		if alias, ok := g.resolve(typ.Name).(*ast.TypeAliasNode); ok {
+			return g.decodeExpr(alias.Type, expr, depth)
+		}
+		return fmt.Sprintf("%s.fromJson(%s as Map<String, dynamic>)", typ.Name, expr)
+	case *ast.ArrayType:
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("(%s as List<dynamic>).map((%s) => %s).toList()", expr, e, g.decodeExpr(typ.ElementType, e, depth+1))
+	case *ast.MapType:
+		k := fmt.Sprintf("k%d", depth)
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("(%s as Map<String, dynamic>).map((%s, %s) => MapEntry(%s, %s))",
+			expr, k, v, g.decodeKey(typ.KeyType, k), g.decodeExpr(typ.ValueType, v, depth+1))
+	case *ast.OptionalType:
+		return fmt.Sprintf("%s == null ? null : %s", expr, g.decodeExpr(typ.ElementType, expr, depth))
+	default:
+		return expr
+	}
+}
+
+// decodeKey converts a JSON object key into the Dart map key type
+func (g *Generator) decodeKey(t ast.Type, expr string) string {
+	if dartPrimitive(keyPrimitive(t, g)) == "int" {
+		return fmt.Sprintf("int.parse(%s)", expr)
+	}
+	return expr
+}
+
+// encodeExpr returns a Dart expression converting expr of type t into a JSON-encodable value
+func (g *Generator) encodeExpr(t ast.Type, expr string, depth int) string {
+	if g.isPlain(t) {
+		return expr
+	}
+
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		// Only DateTime and BigInt reach here, as every other primitive is plain
+		if dartPrimitive(typ.Name) == "BigInt" {
+			return fmt.Sprintf("%s.toString()", expr)
+		}
+		return fmt.Sprintf("%s.toIso8601String()", expr)
+	case *ast.NamedType:
+		if alias, ok := g.resolve(typ.Name).(*ast.TypeAliasNode); ok {
+			return g.encodeExpr(alias.Type, expr, depth)
+		}
+		return fmt.Sprintf("%s.toJson()", expr)
+	case *ast.ArrayType:
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%s.map((%s) => %s).toList()", expr, e, g.encodeExpr(typ.ElementType, e, depth+1))
+	case *ast.MapType:
+		k := fmt.Sprintf("k%d", depth)
+		v := fmt.Sprintf("v%d", depth)
+		key := k
+		if dartPrimitive(keyPrimitive(typ.KeyType, g)) == "int" {
+			key = k + ".toString()"
+		}
+		return fmt.Sprintf("%s.map((%s, %s) => MapEntry(%s, %s))", expr, k, v, key, g.encodeExpr(typ.ValueType, v, depth+1))
+	case *ast.OptionalType:
+		return fmt.Sprintf("%s == null ? null : %s", expr, g.encodeExpr(typ.ElementType, expr, depth))
+	default:
+		return expr
+	}
+}
+
+// isPlain reports whether values of type t are already JSON-encodable without conversion
+func (g *Generator) isPlain(t ast.Type) bool {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		dartType := dartPrimitive(typ.Name)
+		return dartType != "DateTime" && dartType != "BigInt"
+	case *ast.NamedType:
+		if alias, ok := g.resolve(typ.Name).(*ast.TypeAliasNode); ok {
+			return g.isPlain(alias.Type)
+		}
+		return false
+	case *ast.ArrayType:
+		return g.isPlain(typ.ElementType)
+	case *ast.MapType:
+		return dartPrimitive(keyPrimitive(typ.KeyType, g)) == "String" && g.isPlain(typ.ValueType)
+	case *ast.OptionalType:
+		return g.isPlain(typ.ElementType)
+	default:
+		return false
+	}
+}
+
+// keyPrimitive returns the primitive name behind a map key type, following aliases
+func keyPrimitive(t ast.Type, g *Generator) string {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return typ.Name
+	case *ast.NamedType:
+		if alias, ok := g.resolve(typ.Name).(*ast.TypeAliasNode); ok {
+			return keyPrimitive(alias.Type, g)
+		}
+	}
+	return ""
+}
+
+// resolve finds the declaration a named type refers to, or nil if it isn't in the module
+func (g *Generator) resolve(name string) ast.Declaration {
+	if g.module == nil {
+		return nil
+	}
+
+	// Qualified names resolve by their bare type name
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	decl, _, found := g.module.FindDeclaration(name)
+	if !found {
+		return nil
+	}
+	return decl
+}
+
+// mapPrimitiveType maps TypeGen primitive types to Dart types
+func mapPrimitiveType(typeName string) (string, error) {
+	switch typeName {
+	case "bool":
+		return "bool", nil
+	case "string":
+		return "String", nil
+	case "int", "int8", "int16", "int32", "int64", "nat", "nat8", "nat16", "nat32", "nat64":
+		return "int", nil
+	case "float32", "float64":
+		return "double", nil
+	case "decimal":
+		return "String", nil // Exact decimal strings, as on the wire
+	case "bigint", "bignat":
+		return "BigInt", nil
+	case "uuid":
+		return "String", nil // Canonical hyphenated form, as on the wire
+	case "json":
+		return "dynamic", nil
+	case "datetime", "datetimetz":
+		return "DateTime", nil
+	case "time", "date", "timetz", "datetz":
+		return "String", nil // No date-only or time-only type in dart:core
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// dartPrimitive returns the Dart type of a primitive, or "" if it is
+// unsupported; generateType reports those
+func dartPrimitive(typeName string) string {
+	dartType, _ := mapPrimitiveType(typeName)
+	return dartType
+}
+
+// declName returns the name of a declaration
+func declName(decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return d.Name
+	case *ast.EnumNode:
+		return d.Name
+	case *ast.TypeAliasNode:
+		return d.Name
+	case *ast.ConstantNode:
+		return d.Name
+	default:
+		return ""
+	}
+}
+
+// variantClassName returns the subclass name for a tagged union variant
+func variantClassName(e *ast.EnumNode, variant *ast.EnumVariantNode) string {
+	return e.Name + caser.PascalCase(variant.Name)
+}
+
+// hasPayloads reports whether any variant of the enum carries a payload
+func hasPayloads(e *ast.EnumNode) bool {
+	for _, variant := range e.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// quote returns a single-quoted Dart string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "'", "\\'")
+	s = strings.ReplaceAll(s, "$", "\\$")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "'" + s + "'"
+}
+
+func init() {
+	// Register the Dart generator globally
+	generators.Register("dart", func() generators.Generator {
+		return NewGenerator()
+	})
+	// Fields and simple enum values are written in camelCase, which leaves a
+	// single lowercase word as it is, and import prefixes are module names
+	validator.RegisterReservedWords("dart", validator.ReservedWords{
+		Language: "Dart",
+		Words:    naming.DartKeywords,
+		Names:    []string{validator.FieldNames, validator.VariantNames, validator.TypeNames, validator.ModuleNames},
+	})
+}
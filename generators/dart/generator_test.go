@@ -0,0 +1,359 @@
+package dart
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// generateFile parses input as a single-file module and returns the generated .dart content
+func generateFile(t *testing.T, input string, config map[string]string) string {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	result, exists := fs.GetFileString("test.dart")
+	if !exists {
+		t.Fatal("test.dart should have been generated")
+	}
+	return result
+}
+
+// assertGolden compares content against testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name, content string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if string(expected) != content {
+		t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", path, expected, content)
+	}
+}
+
+func TestGenerateClass(t *testing.T) {
+	input := `struct User {
+		id: int64
+		display_name: string
+		email: ?string
+		tags: []string
+		scores: [string]float64
+		created_at: datetime
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "class", result)
+}
+
+func TestGenerateSimpleEnum(t *testing.T) {
+	input := `enum Status {
+		active
+		pending_review
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "simple_enum", result)
+}
+
+func TestGenerateSealedClass(t *testing.T) {
+	input := `struct Failure {
+		code: int32
+	}
+
+	enum Result {
+		success: string
+		error: Failure
+		items: []Failure
+		pending
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "sealed_class", result)
+}
+
+func TestGenerateTypedefsAndConstants(t *testing.T) {
+	input := `type UserID = int64
+	type Tags = []string
+	const MAX_USERS = 100
+	const API_VERSION = "v1"`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "typedefs_constants", result)
+}
+
+func TestGenerateFloatConstants(t *testing.T) {
+	input := `const RATIO = 0.75
+	const AVOGADRO = 6.022e23
+	const EPSILON = 1e-9
+	const OFFSET = -2.5
+	const WHOLE = 3.0
+	const HALF = .5
+	const ONE = 1.`
+
+	result := generateFile(t, input, nil)
+
+	// Dart rejects "1." and ".5", so those are written out in full
+	expected := []string{
+		"const double RATIO = 0.75;",
+		"const double AVOGADRO = 6.022e23;",
+		"const double EPSILON = 1e-9;",
+		"const double OFFSET = -2.5;",
+		"const double WHOLE = 3.0;",
+		"const double HALF = 0.5;",
+		"const double ONE = 1.0;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBoolConstants(t *testing.T) {
+	input := `const ENABLED = true
+	const DEPRECATED = false`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"const bool ENABLED = true;",
+		"const bool DEPRECATED = false;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateNestedConversions(t *testing.T) {
+	input := `struct Item {
+		id: int64
+	}
+
+	type ItemList = []Item
+
+	struct Inventory {
+		items: ItemList
+		by_id: [int64]Item
+		maybe: ?[]Item
+	}`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"items: (json['items'] as List<dynamic>).map((e0) => Item.fromJson(e0 as Map<String, dynamic>)).toList(),",
+		"byId: (json['by_id'] as Map<String, dynamic>).map((k0, v0) => MapEntry(int.parse(k0), Item.fromJson(v0 as Map<String, dynamic>))),",
+		"'items': items.map((e0) => e0.toJson()).toList(),",
+		"'by_id': byId.map((k0, v0) => MapEntry(k0.toString(), v0.toJson())),",
+		"if (maybe != null) 'maybe': maybe!.map((e0) => e0.toJson()).toList(),",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateQualifiedType(t *testing.T) {
+	input := `import api.auth
+
+	struct Session {
+		token: auth.Token
+	}`
+
+	result := generateFile(t, input, map[string]string{"package": "acme_api"})
+
+	expected := []string{
+		"import 'package:acme_api/api/auth/index.dart' as auth;",
+		"final auth.Token token;",
+		"token: auth.Token.fromJson(json['token'] as Map<String, dynamic>),",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBarrelFiles(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`struct Root { id: int64 }`), "root.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	user, err := parser.Parse(strings.NewReader(`struct User { root: Root }`), "user.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	sub, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"root.tg": root, "user.tg": user})
+	module.SubModules["auth"] = ast.NewModule("api/auth", map[string]*ast.ProgramNode{"token.tg": sub})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	barrel, exists := fs.GetFileString("index.dart")
+	if !exists {
+		t.Fatal("index.dart should have been generated")
+	}
+	for _, exp := range []string{"export 'root.dart';", "export 'user.dart';", "export 'auth/index.dart';"} {
+		if !strings.Contains(barrel, exp) {
+			t.Errorf("Expected barrel to contain %q, got:\n%s", exp, barrel)
+		}
+	}
+
+	if _, exists := fs.GetFileString("auth/index.dart"); !exists {
+		t.Error("auth/index.dart should have been generated")
+	}
+
+	// Types from sibling files are reached through the directory barrel
+	userCode, _ := fs.GetFileString("user.dart")
+	if !strings.Contains(userCode, "import 'index.dart';") {
+		t.Errorf("Expected user.dart to import the barrel, got:\n%s", userCode)
+	}
+	rootCode, _ := fs.GetFileString("root.dart")
+	if strings.Contains(rootCode, "import ") {
+		t.Errorf("Expected root.dart to have no imports, got:\n%s", rootCode)
+	}
+}
+
+func TestGeneratePrimitives(t *testing.T) {
+	input := `struct Primitives {
+		flag: bool
+		name: string
+		count: int
+		total: nat
+		labels: [int]string
+		flags: [nat]bool
+		tiny: int8
+		small: int16
+		medium: int32
+		large: int64
+		tiny_nat: nat8
+		small_nat: nat16
+		medium_nat: nat32
+		large_nat: nat64
+		ratio: float32
+		score: float64
+		price: decimal
+		balance: bigint
+		population: bignat
+		history: []bigint
+		refund: ?bigint
+		id: uuid
+		owners: [uuid]string
+		payload: json
+		day: date
+		clock: time
+		moment: datetime
+		day_tz: datetz
+		clock_tz: timetz
+		moment_tz: datetimetz
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "primitives", result)
+}
+
+func TestGenerateUnsupportedPrimitive(t *testing.T) {
+	program := &ast.ProgramNode{Declarations: []ast.Declaration{
+		&ast.StructNode{Name: "Wide", Fields: []*ast.FieldNode{
+			{Name: "value", Type: &ast.PrimitiveType{Name: "int128"}},
+		}},
+	}}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	err := NewGenerator().Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "unsupported primitive type int128") {
+		t.Errorf("Expected unsupported primitive type error, got: %v", err)
+	}
+}
+
+func TestReservedWords(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader(`struct Request {
+		class: string
+		default: ?string
+		new: bool
+		get: string
+		is_new: bool
+	}
+
+	enum Mode {
+		default
+		custom
+	}`), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+	module.SubModules = map[string]*ast.Module{"switch": ast.NewModule("switch", nil)}
+
+	v := validator.NewValidator()
+	v.SetGenerators([]string{"dart"})
+	var messages []string
+	for _, err := range v.Validate(module).Errors {
+		if err.Type == validator.ReservedWordError {
+			messages = append(messages, fmt.Sprintf("%s:%d:%d %s", err.File, err.Line, err.Column, err.Message))
+		}
+	}
+	sort.Strings(messages)
+
+	// get is a built-in identifier, which Dart allows as a name, and is_new is
+	// written as isNew.
+	expected := []string{
+		"switch:0:0 module 'switch' is a reserved word in Dart, the target language of generator 'dart'",
+		"test.tg:10:3 variant 'default' of enum 'Mode' is a reserved word in Dart, the target language of generator 'dart'",
+		"test.tg:2:3 field 'class' of struct 'Request' is a reserved word in Dart, the target language of generator 'dart'",
+		"test.tg:3:3 field 'default' of struct 'Request' is a reserved word in Dart, the target language of generator 'dart'",
+		"test.tg:4:3 field 'new' of struct 'Request' is a reserved word in Dart, the target language of generator 'dart'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected reserved words:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
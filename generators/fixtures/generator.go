@@ -0,0 +1,472 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+const (
+	defaultSeed     = 1
+	defaultMaxDepth = 2
+)
+
+// baseDate anchors generated dates and times, so fixtures don't depend on the current time
+var baseDate = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator generates example JSON payloads from TypeGen AST
+type Generator struct {
+	config map[string]string // Configuration options
+	root   *ast.Module       // Root module, used to resolve qualified types
+
+	seed         int64
+	maxDepth     int
+	omitOptional bool
+
+	// Per-fixture state
+	rand      *rand.Rand
+	expanding map[ast.Declaration]int // Declarations currently being expanded, with nesting count
+}
+
+// scope is the lexical context a type expression is resolved in
+type scope struct {
+	module  *ast.Module       // Module declaring the type expression
+	imports map[string]string // Import alias -> TypeGen import path of the declaring file
+}
+
+// object is a JSON object that keeps its keys in insertion order
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]any)}
+}
+
+func (o *object) set(key string, value any) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON encodes the object with keys in insertion order
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyData)
+		buf.WriteByte(':')
+		buf.Write(valueData)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// NewGenerator creates a new fixture generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config: make(map[string]string),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "fixtures"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Example JSON payloads for every struct and enum"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "seed", Type: "int", Default: "1", Description: "Seed for placeholder values; output is stable for a given seed"},
+		{Key: "optionals", Type: "include|omit", Default: "include", Description: "Whether optional fields are filled or left out"},
+		{Key: "max-depth", Type: "int", Default: "2", Description: "How many times a type may nest inside itself before recursion is cut"},
+	}
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	if err := g.parseConfig(); err != nil {
+		return err
+	}
+
+	g.root = module
+	return g.generateModuleRecursive(ctx, module, dest, "", "")
+}
+
+// parseConfig reads and validates the generator options
+func (g *Generator) parseConfig() error {
+	g.seed = defaultSeed
+	if value, ok := g.config["seed"]; ok {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed %q: %w", value, err)
+		}
+		g.seed = seed
+	}
+
+	g.maxDepth = defaultMaxDepth
+	if value, ok := g.config["max-depth"]; ok {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 1 {
+			return fmt.Errorf("invalid max-depth %q (expected a positive integer)", value)
+		}
+		g.maxDepth = depth
+	}
+
+	switch g.config["optionals"] {
+	case "", "include":
+		g.omitOptional = false
+	case "omit":
+		g.omitOptional = true
+	default:
+		return fmt.Errorf("invalid optionals option %q (expected include or omit)", g.config["optionals"])
+	}
+
+	return nil
+}
+
+// generateModuleRecursive recursively generates fixtures for a module and its submodules
+func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath, dir string) error {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if err := g.generateProgram(module, module.Files[filename], dest, basePath, dir); err != nil {
+			return fmt.Errorf("failed to generate fixtures for %s: %w", filename, err)
+		}
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+
+	for _, subModuleName := range subModuleNames {
+		subModulePath := dest.Join(basePath, subModuleName)
+		if err := g.generateModuleRecursive(ctx, module.SubModules[subModuleName], dest, subModulePath, dir+"/"+subModuleName); err != nil {
+			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
+		}
+	}
+
+	return nil
+}
+
+// generateProgram writes one fixture per struct and enum declared in the program
+func (g *Generator) generateProgram(module *ast.Module, program *ast.ProgramNode, dest generators.FS, basePath, dir string) error {
+	sc := scope{module: module, imports: importMap(program)}
+
+	for _, decl := range program.Declarations {
+		var name string
+		switch d := decl.(type) {
+		case *ast.StructNode:
+			name = d.Name
+		case *ast.EnumNode:
+			name = d.Name
+		default:
+			continue // Aliases and constants have no payload of their own
+		}
+
+		// Seed per declaration, so editing one type doesn't change the fixtures of others
+		hash := fnv.New64a()
+		hash.Write([]byte(dir + "/" + name))
+		g.rand = rand.New(rand.NewSource(g.seed ^ int64(hash.Sum64())))
+		g.expanding = make(map[ast.Declaration]int)
+
+		value, err := g.declValue(decl, sc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode fixture for %s: %w", name, err)
+		}
+
+		fixturePath := dest.Join(basePath, naming.SnakeCase(name)+".json")
+		if err := dest.WriteFile(fixturePath, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", fixturePath, err)
+		}
+	}
+
+	return nil
+}
+
+// declValue generates an example value for a struct or enum declaration
+func (g *Generator) declValue(decl ast.Declaration, sc scope) (any, error) {
+	if g.expanding[decl] >= g.maxDepth {
+		return nil, nil // Only reached by required cycles, which have no finite instance
+	}
+	g.expanding[decl]++
+	defer func() { g.expanding[decl]-- }()
+
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		obj := newObject()
+		for _, field := range d.Fields {
+			if field.Optional {
+				if g.omitOptional {
+					continue
+				}
+				if g.cutoff(field.Type, sc) {
+					obj.set(field.JSONName(), nil)
+					continue
+				}
+			}
+
+			value, err := g.value(field.Type, field.Name, sc)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			obj.set(field.JSONName(), value)
+		}
+		return obj, nil
+
+	case *ast.EnumNode:
+		if len(d.Variants) == 0 {
+			return nil, fmt.Errorf("enum %s has no variants", d.Name)
+		}
+
+		// Prefer the first variant whose payload can still be expanded
+		variant := d.Variants[0]
+		for _, candidate := range d.Variants {
+			if candidate.Payload == nil || !g.cutoff(candidate.Payload, sc) {
+				variant = candidate
+				break
+			}
+		}
+
+		obj := newObject()
+		obj.set("type", variant.JSONName())
+		if variant.Payload != nil {
+			payload, err := g.value(variant.Payload, variant.Name, sc)
+			if err != nil {
+				return nil, fmt.Errorf("variant %s: %w", variant.Name, err)
+			}
+			obj.set("payload", payload)
+		}
+		return obj, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported declaration type: %T", decl)
+	}
+}
+
+// value generates an example value for type t. hint names the field or variant holding it.
+func (g *Generator) value(t ast.Type, hint string, sc scope) (any, error) {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return g.primitive(typ.Name, hint)
+
+	case *ast.NamedType:
+		decl, declScope, ok := g.resolve(typ.Name, sc)
+		if !ok {
+			return nil, fmt.Errorf("unknown type %s", typ.Name)
+		}
+		if alias, ok := decl.(*ast.TypeAliasNode); ok {
+			return g.value(alias.Type, hint, declScope)
+		}
+		return g.declValue(decl, declScope)
+
+	case *ast.ArrayType:
+		if g.cutoff(typ.ElementType, sc) {
+			return []any{}, nil
+		}
+		element, err := g.value(typ.ElementType, hint, sc)
+		if err != nil {
+			return nil, err
+		}
+		return []any{element}, nil
+
+	case *ast.MapType:
+		obj := newObject()
+		if g.cutoff(typ.ValueType, sc) {
+			return obj, nil
+		}
+		key, err := g.value(typ.KeyType, "key", sc)
+		if err != nil {
+			return nil, err
+		}
+		value, err := g.value(typ.ValueType, hint, sc)
+		if err != nil {
+			return nil, err
+		}
+		obj.set(fmt.Sprint(key), value) // JSON object keys are strings, including integer keys
+		return obj, nil
+
+	case *ast.OptionalType:
+		if g.omitOptional || g.cutoff(typ.ElementType, sc) {
+			return nil, nil
+		}
+		return g.value(typ.ElementType, hint, sc)
+
+	default:
+		return nil, fmt.Errorf("unknown type: %T", t)
+	}
+}
+
+// cutoff reports whether expanding t would nest a declaration deeper than max-depth
+func (g *Generator) cutoff(t ast.Type, sc scope) bool {
+	switch typ := t.(type) {
+	case *ast.NamedType:
+		decl, declScope, ok := g.resolve(typ.Name, sc)
+		if !ok {
+			return false
+		}
+		if alias, ok := decl.(*ast.TypeAliasNode); ok {
+			return g.cutoff(alias.Type, declScope)
+		}
+		return g.expanding[decl] >= g.maxDepth
+	case *ast.OptionalType:
+		return g.cutoff(typ.ElementType, sc)
+	default:
+		return false
+	}
+}
+
+// primitive generates a placeholder for a primitive type that fits the type's range and format
+func (g *Generator) primitive(typeName, hint string) (any, error) {
+	switch typeName {
+	case "bool":
+		return g.rand.Intn(2) == 1, nil
+	case "string":
+		return fmt.Sprintf("%s-%d", hint, g.rand.Intn(1000)), nil
+	case "int8", "nat8":
+		return g.rand.Intn(100), nil
+	case "int", "int16", "int32", "int64", "nat", "nat16", "nat32", "nat64":
+		return g.rand.Intn(10000), nil
+	case "float32", "float64":
+		return float64(g.rand.Intn(100000)) / 100, nil
+	case "decimal":
+		// Decimals are strings on the wire, so no precision is lost
+		return fmt.Sprintf("%d.%02d", g.rand.Intn(1000), g.rand.Intn(100)), nil
+	case "bigint", "bignat":
+		// Beyond 64 bits, so readers that truncate to a machine integer fail
+		return fmt.Sprintf("%d%018d", 100+g.rand.Intn(900), g.rand.Int63n(1e18)), nil
+	case "uuid":
+		// Version 4 layout, drawn from the seeded source so output stays stable
+		return fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x", g.rand.Uint32(), g.rand.Intn(1<<16), g.rand.Intn(1<<12),
+			0x8000|g.rand.Intn(1<<14), g.rand.Int63n(1<<48)), nil
+	case "json":
+		obj := newObject()
+		obj.set(hint, fmt.Sprintf("value-%d", g.rand.Intn(1000)))
+		return obj, nil
+	case "date", "datetz":
+		// Dates are midnight UTC timestamps: the Go generator decodes every time type as RFC 3339
+		return baseDate.AddDate(0, 0, g.rand.Intn(365)).Format(time.RFC3339), nil
+	case "time", "timetz", "datetime", "datetimetz":
+		offset := time.Duration(g.rand.Intn(365*24*60*60)) * time.Second
+		return baseDate.Add(offset).Format(time.RFC3339), nil
+	default:
+		return nil, fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// resolve finds the declaration a named type refers to from the given scope, returning the
+// scope of the file that declares it
+func (g *Generator) resolve(name string, sc scope) (ast.Declaration, scope, bool) {
+	module := sc.module
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		importPath, ok := sc.imports[name[:idx]]
+		if !ok {
+			return nil, scope{}, false
+		}
+		module = g.root
+		for _, part := range strings.Split(importPath, ".") {
+			if module == nil {
+				return nil, scope{}, false
+			}
+			module = module.SubModules[part]
+		}
+		name = name[idx+1:]
+	}
+
+	if module == nil {
+		return nil, scope{}, false
+	}
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		program := module.Files[filename]
+		for _, decl := range program.Declarations {
+			if declName(decl) == name {
+				return decl, scope{module: module, imports: importMap(program)}, true
+			}
+		}
+	}
+	return nil, scope{}, false
+}
+
+// importMap maps each import's alias to its full TypeGen import path
+func importMap(program *ast.ProgramNode) map[string]string {
+	imports := make(map[string]string)
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		imports[parts[len(parts)-1]] = imp.Path
+	}
+	return imports
+}
+
+// declName returns the name of a type declaration, or "" for constants
+func declName(decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return d.Name
+	case *ast.EnumNode:
+		return d.Name
+	case *ast.TypeAliasNode:
+		return d.Name
+	default:
+		return ""
+	}
+}
+
+func init() {
+	// Register the fixtures generator globally
+	generators.Register("fixtures", func() generators.Generator {
+		return NewGenerator()
+	})
+}
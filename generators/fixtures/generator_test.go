@@ -0,0 +1,256 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+const testSchema = `enum Status {
+	active
+	suspended
+}
+
+struct User {
+	id: int64
+	age: nat8
+	name: string
+	verified: bool
+	balance: float64
+	nickname: ?string
+	status: Status
+	tags: []string
+	scores: [int32]float32
+	metadata: json
+	birthday: date
+	created_at: datetime
+}
+
+struct TreeNode {
+	label: string
+	parent: ?TreeNode
+	children: []TreeNode
+}
+
+enum Result {
+	success: User
+	failure: string
+	pending
+}`
+
+// generateFixtures parses input as a single-file module and returns the generated in-memory files
+func generateFixtures(t *testing.T, input string, config map[string]string) *generators.InMemoryFS {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	return fs
+}
+
+// assertGolden compares the fixture at path against testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, fs *generators.InMemoryFS, path, name string) {
+	t.Helper()
+
+	content, exists := fs.GetFileString(path)
+	if !exists {
+		t.Fatalf("%s should have been generated", path)
+	}
+
+	goldenPath := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(goldenPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if string(expected) != content {
+		t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", goldenPath, expected, content)
+	}
+}
+
+func TestGenerateFixtures(t *testing.T) {
+	fs := generateFixtures(t, testSchema, nil)
+
+	assertGolden(t, fs, "user.json", "user")
+	assertGolden(t, fs, "status.json", "status")
+	assertGolden(t, fs, "tree_node.json", "tree_node")
+	assertGolden(t, fs, "result.json", "result")
+}
+
+func TestGenerateOmitOptionals(t *testing.T) {
+	fs := generateFixtures(t, testSchema, map[string]string{"optionals": "omit"})
+
+	assertGolden(t, fs, "user.json", "user_omit")
+	assertGolden(t, fs, "tree_node.json", "tree_node_omit")
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	first := generateFixtures(t, testSchema, nil)
+	second := generateFixtures(t, testSchema, nil)
+	reseeded := generateFixtures(t, testSchema, map[string]string{"seed": "42"})
+
+	a, _ := first.GetFileString("user.json")
+	b, _ := second.GetFileString("user.json")
+	c, _ := reseeded.GetFileString("user.json")
+
+	if a != b {
+		t.Errorf("Expected identical fixtures across runs, got:\n%s\nand:\n%s", a, b)
+	}
+	if a == c {
+		t.Error("Expected a different seed to produce different fixtures")
+	}
+}
+
+func TestGeneratePrimitives(t *testing.T) {
+	input := `struct Primitives {
+		flag: bool
+		name: string
+		count: int
+		total: nat
+		tiny: int8
+		small: int16
+		medium: int32
+		large: int64
+		tiny_nat: nat8
+		small_nat: nat16
+		medium_nat: nat32
+		large_nat: nat64
+		ratio: float32
+		score: float64
+		price: decimal
+		balance: bigint
+		population: bignat
+		id: uuid
+		owners: [uuid]string
+		payload: json
+		day: date
+		clock: time
+		moment: datetime
+		day_tz: datetz
+		clock_tz: timetz
+		moment_tz: datetimetz
+	}`
+
+	fs := generateFixtures(t, input, nil)
+	assertGolden(t, fs, "primitives.json", "primitives")
+}
+
+func TestGenerateRespectsTypes(t *testing.T) {
+	input := `struct Limits {
+		tiny: int8
+		small: nat8
+		day: date
+		moment: datetimetz
+		clock: timetz
+		price: decimal
+		population: bignat
+		count: int
+		tallies: [nat]int
+		id: uuid
+	}`
+
+	for seed := 0; seed < 50; seed++ {
+		fs := generateFixtures(t, input, map[string]string{"seed": fmt.Sprint(seed)})
+		content, _ := fs.GetFileString("limits.json")
+
+		var limits struct {
+			Tiny       int8             `json:"tiny"`
+			Small      uint8            `json:"small"`
+			Day        time.Time        `json:"day"`
+			Moment     time.Time        `json:"moment"`
+			Clock      time.Time        `json:"clock"`
+			Price      string           `json:"price"`
+			Population string           `json:"population"`
+			Count      int64            `json:"count"`
+			Tallies    map[uint64]int64 `json:"tallies"`
+			ID         string           `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(content), &limits); err != nil {
+			t.Fatalf("Fixture does not decode into sized Go types (seed %d): %v\n%s", seed, err, content)
+		}
+		if h, m, s := limits.Day.Clock(); h != 0 || m != 0 || s != 0 {
+			t.Errorf("Expected date fixture at midnight, got %s", limits.Day)
+		}
+		if _, err := strconv.ParseFloat(limits.Price, 64); err != nil {
+			t.Errorf("Expected decimal fixture to be a numeric string, got %q", limits.Price)
+		}
+		if !uuidPattern.MatchString(limits.ID) {
+			t.Errorf("Expected uuid fixture in canonical form, got %q", limits.ID)
+		}
+		if _, ok := new(big.Int).SetString(limits.Population, 10); !ok {
+			t.Errorf("Expected bignat fixture to be an integer string, got %q", limits.Population)
+		}
+	}
+}
+
+func TestGenerateRequiredCycle(t *testing.T) {
+	input := `struct Loop {
+		next: Loop
+	}`
+
+	fs := generateFixtures(t, input, map[string]string{"max-depth": "1"})
+	content, _ := fs.GetFileString("loop.json")
+
+	if !strings.Contains(content, `"next": null`) {
+		t.Errorf("Expected the cycle to be cut with null, got:\n%s", content)
+	}
+}
+
+func TestGenerateInvalidConfig(t *testing.T) {
+	tests := []struct {
+		config        map[string]string
+		errorContains string
+	}{
+		{map[string]string{"optionals": "sometimes"}, "invalid optionals option"},
+		{map[string]string{"seed": "abc"}, "invalid seed"},
+		{map[string]string{"max-depth": "0"}, "invalid max-depth"},
+	}
+
+	for _, tt := range tests {
+		module := ast.NewModule("test", map[string]*ast.ProgramNode{})
+
+		generator := NewGenerator()
+		generator.SetConfig(tt.config)
+
+		err := generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+		if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+			t.Errorf("Expected error containing %q for %v, got: %v", tt.errorContains, tt.config, err)
+		}
+	}
+}
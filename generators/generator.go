@@ -1,9 +1,15 @@
 package generators
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
 )
@@ -17,6 +23,46 @@ type Generator interface {
 	Generate(ctx context.Context, module *ast.Module, dest FS) error
 }
 
+// TypedConfigurable is implemented by generators that take typed
+// configuration values: the bools, numbers, lists and maps of a version 2
+// typegen.yaml. Configure calls SetConfigTyped instead of SetConfig for
+// them; values set on the command line are still strings.
+type TypedConfigurable interface {
+	SetConfigTyped(config map[string]any)
+}
+
+// OptionSpec documents a configuration option accepted by a generator
+type OptionSpec struct {
+	// Key is the option name, as passed with -c key=value or in a task's config
+	Key string `json:"key"`
+	// Type describes the accepted values, e.g. "string", "bool" or "class|shape"
+	Type string `json:"type"`
+	// Default is the value used when the option is not set; empty means none
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+}
+
+// Describer is implemented by generators that document themselves, for help
+// output and tooling
+type Describer interface {
+	// Name returns the name the generator is registered under
+	Name() string
+	
+	// Description returns a one-line summary of the generated code
+	Description() string
+	
+	// Options returns the configuration options the generator accepts
+	Options() []OptionSpec
+}
+
+// ConfigValidator is implemented by generators that check their
+// configuration themselves, instead of against the options they document;
+// see ValidateConfig
+type ConfigValidator interface {
+	// ValidateConfig returns an error for unknown keys and malformed values
+	ValidateConfig(config map[string]string) error
+}
+
 // FS provides a filesystem abstraction that supports writing
 // Compatible with fs.FS but adds write operations
 type FS interface {
@@ -30,6 +76,37 @@ type FS interface {
 	Join(elem ...string) string
 }
 
+// ReadFS is implemented by filesystems that can also read files back, e.g.
+// to compare generated code with the files already on disk
+type ReadFS interface {
+	FS
+	
+	// ReadFile reads a file, returning an error satisfying errors.Is(err, fs.ErrNotExist) if it doesn't exist
+	ReadFile(name string) ([]byte, error)
+	
+	// Files returns the slash-separated paths of all files, in sorted order
+	Files() ([]string, error)
+}
+
+// ChangeFS is implemented by filesystems that leave files whose content is
+// unchanged as they are, and report it
+type ChangeFS interface {
+	FS
+	
+	// WriteFileChanged writes data to a file like WriteFile, and reports
+	// whether the file's content changed
+	WriteFileChanged(name string, data []byte, perm os.FileMode) (bool, error)
+}
+
+// writeFileChanged writes a file to fs, reporting whether its content
+// changed: always, unless fs implements ChangeFS
+func writeFileChanged(fs FS, name string, data []byte, perm os.FileMode) (bool, error) {
+	if changeFS, ok := fs.(ChangeFS); ok {
+		return changeFS.WriteFileChanged(name, data, perm)
+	}
+	return true, fs.WriteFile(name, data, perm)
+}
+
 // osFS implements FS using the os package for real filesystem operations
 type osFS struct {
 	root string
@@ -40,17 +117,57 @@ func NewOSFS(root string) FS {
 	return &osFS{root: root}
 }
 
-// WriteFile implements FS.WriteFile
+// WriteFile implements FS.WriteFile; see WriteFileChanged
 func (fs *osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	_, err := fs.WriteFileChanged(name, data, perm)
+	return err
+}
+
+// WriteFileChanged implements ChangeFS.WriteFileChanged. A file whose
+// content is already data is not written, so it keeps its modification
+// time, and only gets perm if it has other permissions. Otherwise data is
+// written to a temporary file in the same directory, which is renamed over
+// the file, so an interrupted write never leaves a half-written file.
+func (fs *osFS) WriteFileChanged(name string, data []byte, perm os.FileMode) (bool, error) {
 	fullPath := filepath.Join(fs.root, name)
+	if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() && info.Size() == int64(len(data)) {
+		if existing, err := os.ReadFile(fullPath); err == nil && bytes.Equal(existing, data) {
+			if info.Mode().Perm() != perm.Perm() {
+				if err := os.Chmod(fullPath, perm); err != nil {
+					return false, err
+				}
+			}
+			return false, nil
+		}
+	}
 	
 	// Create directory if needed
 	dir := filepath.Dir(fullPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+		return false, err
+	}
+	
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(fullPath)+".tmp*")
+	if err != nil {
+		return false, err
 	}
+	// Only left to remove when something failed before the rename
+	defer os.Remove(temp.Name())
 	
-	return os.WriteFile(fullPath, data, perm)
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), fullPath)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", fullPath, err)
+	}
+	return true, nil
 }
 
 // MkdirAll implements FS.MkdirAll
@@ -62,4 +179,63 @@ func (fs *osFS) MkdirAll(path string, perm os.FileMode) error {
 // Join implements FS.Join
 func (fs *osFS) Join(elem ...string) string {
 	return filepath.Join(elem...)
-}
\ No newline at end of file
+}
+
+// ReadFile implements ReadFS.ReadFile
+func (fs *osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(fs.root, name))
+}
+
+// Files implements ReadFS.Files. A missing root has no files.
+func (fs *osFS) Files() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(fs.root, func(path string, entry iofs.DirEntry, err error) error {
+		if err != nil {
+			if path == fs.root && errors.Is(err, iofs.ErrNotExist) {
+				return iofs.SkipAll
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			rel, err := filepath.Rel(fs.root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// loggingFS logs every file written through it
+type loggingFS struct {
+	FS
+	logger *slog.Logger
+}
+
+// NewLoggingFS wraps fs so that every file written is logged at debug level
+func NewLoggingFS(fs FS, logger *slog.Logger) FS {
+	return &loggingFS{FS: fs, logger: logger}
+}
+
+// WriteFile implements FS.WriteFile
+func (fs *loggingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	_, err := fs.WriteFileChanged(name, data, perm)
+	return err
+}
+
+// WriteFileChanged implements ChangeFS.WriteFileChanged
+func (fs *loggingFS) WriteFileChanged(name string, data []byte, perm os.FileMode) (bool, error) {
+	changed, err := writeFileChanged(fs.FS, name, data, perm)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		fs.logger.Debug(fmt.Sprintf("wrote %s (%d bytes)", name, len(data)))
+	} else {
+		fs.logger.Debug(fmt.Sprintf("unchanged %s (%d bytes)", name, len(data)))
+	}
+	return changed, nil
+}
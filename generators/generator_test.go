@@ -1,7 +1,16 @@
 package generators
 
 import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestInMemoryFS_WriteFile(t *testing.T) {
@@ -146,4 +155,223 @@ func TestInMemoryFS_NonExistentFile(t *testing.T) {
 	if fs.Exists("nonexistent.txt") {
 		t.Error("Exists should return false for non-existent file")
 	}
-}
\ No newline at end of file
+}
+func TestReadFS(t *testing.T) {
+	root := t.TempDir()
+	filesystems := map[string]ReadFS{
+		"memory": NewInMemoryFS(),
+		"os":     NewOSFS(root).(ReadFS),
+	}
+
+	for name, fs := range filesystems {
+		t.Run(name, func(t *testing.T) {
+			if err := fs.WriteFile("b.txt", []byte("b"), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			if err := fs.WriteFile(fs.Join("sub", "a.txt"), []byte("a"), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+
+			content, err := fs.ReadFile(fs.Join("sub", "a.txt"))
+			if err != nil || string(content) != "a" {
+				t.Errorf("ReadFile returned %q, %v", content, err)
+			}
+			if _, err := fs.ReadFile("missing.txt"); !errors.Is(err, iofs.ErrNotExist) {
+				t.Errorf("expected ErrNotExist for a missing file, got %v", err)
+			}
+
+			files, err := fs.Files()
+			if err != nil {
+				t.Fatalf("Files failed: %v", err)
+			}
+			if expected := []string{"b.txt", "sub/a.txt"}; !reflect.DeepEqual(files, expected) {
+				t.Errorf("expected %v, got %v", expected, files)
+			}
+		})
+	}
+}
+
+func TestOSFS_FilesMissingRoot(t *testing.T) {
+	fs := NewOSFS(filepath.Join(t.TempDir(), "missing")).(ReadFS)
+
+	files, err := fs.Files()
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected no files and no error, got %v, %v", files, err)
+	}
+	if _, err := os.Stat(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Files should not create the root")
+	}
+}
+
+func TestOSFS_WriteFileChanged(t *testing.T) {
+	root := t.TempDir()
+	fs := NewOSFS(root).(ChangeFS)
+	path := filepath.Join(root, "sub", "a.txt")
+
+	changed, err := fs.WriteFileChanged(fs.Join("sub", "a.txt"), []byte("a"), 0600)
+	if err != nil || !changed {
+		t.Fatalf("expected a new file to be changed, got %v, %v", changed, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %v", info.Mode().Perm())
+	}
+
+	// The same content is not written again, so the file keeps its
+	// modification time, but gets the new permissions
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = fs.WriteFileChanged(fs.Join("sub", "a.txt"), []byte("a"), 0644)
+	if err != nil || changed {
+		t.Fatalf("expected the same content to be unchanged, got %v, %v", changed, err)
+	}
+	if info, err = os.Stat(path); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("expected the modification time to be kept, got %v (%v)", info.ModTime(), err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("expected permissions 0644 on an unchanged file, got %v", info.Mode().Perm())
+	}
+
+	// Other content of the same size replaces the file
+	if changed, err = fs.WriteFileChanged(fs.Join("sub", "a.txt"), []byte("b"), 0644); err != nil || !changed {
+		t.Fatalf("expected new content to be changed, got %v, %v", changed, err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "b" {
+		t.Errorf("expected the new content, got %q", content)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the written file, got %v", entries)
+	}
+}
+
+func TestOSFS_WriteFileFailureRemovesTemporaryFile(t *testing.T) {
+	root := t.TempDir()
+	fs := NewOSFS(root)
+
+	// A directory in the way makes the rename into place fail once the
+	// content is written, like a write interrupted before it completes
+	if err := os.MkdirAll(filepath.Join(root, "a.txt", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteFile("a.txt", []byte("a"), 0644); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a.txt" || !entries[0].IsDir() {
+		t.Errorf("expected the temporary file to be removed, got %v", entries)
+	}
+}
+
+func TestInMemoryFS_ConcurrentWrites(t *testing.T) {
+	fs := NewInMemoryFS()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := fs.Join("types", fmt.Sprintf("group%d", i%5))
+			if err := fs.MkdirAll(dir, 0755); err != nil {
+				t.Error(err)
+			}
+			if err := fs.WriteFile(fs.Join(dir, fmt.Sprintf("file%d.txt", i)), []byte("content"), 0644); err != nil {
+				t.Error(err)
+			}
+			fs.ListFiles()
+			fs.FileExists(fs.Join(dir, "file0.txt"))
+		}(i)
+	}
+	wg.Wait()
+
+	if files := fs.ListFiles(); len(files) != 50 {
+		t.Errorf("expected 50 files, got %d", len(files))
+	}
+	if dirs := fs.ListDirs(); len(dirs) != 6 {
+		t.Errorf("expected 6 directories, got %v", dirs)
+	}
+	if ops := fs.Ops(); len(ops) != 100 {
+		t.Errorf("expected 100 operations, got %d", len(ops))
+	}
+}
+
+func TestInMemoryFS_FailWritesMatching(t *testing.T) {
+	fs := NewInMemoryFS()
+	fs.FailWritesMatching("models/*.py")
+
+	if err := fs.WriteFile("models/user.py", []byte("x"), 0644); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected injected failure, got %v", err)
+	}
+	if err := fs.WriteFile("./models/../models/order.py", []byte("x"), 0644); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected injected failure of an unclean path, got %v", err)
+	}
+	if err := fs.WriteFile("models/sub/user.py", []byte("x"), 0644); err != nil {
+		t.Errorf("unexpected failure of a file in a subdirectory: %v", err)
+	}
+	if err := fs.WriteFile("user.py", []byte("x"), 0644); err != nil {
+		t.Errorf("unexpected failure of a file at the root: %v", err)
+	}
+
+	expected := []string{"models/sub/user.py", "user.py"}
+	if files := fs.ListFiles(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+}
+
+func TestInMemoryFS_FailAfterN(t *testing.T) {
+	fs := NewInMemoryFS()
+	fs.FailAfterN(2)
+
+	for i, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		err := fs.WriteFile(name, []byte(name), 0644)
+		if i < 2 && err != nil {
+			t.Errorf("write %d: unexpected error: %v", i+1, err)
+		}
+		if i >= 2 {
+			var pathErr *iofs.PathError
+			if !errors.As(err, &pathErr) || pathErr.Path != name || !errors.Is(err, ErrInjected) {
+				t.Errorf("write %d: expected injected failure of %s, got %v", i+1, name, err)
+			}
+		}
+	}
+
+	expected := []Op{
+		{Kind: "write", Path: "a.txt", Bytes: 5},
+		{Kind: "write", Path: "b.txt", Bytes: 5},
+		{Kind: "write", Path: "c.txt", Bytes: 5, Err: &iofs.PathError{Op: "write", Path: "c.txt", Err: ErrInjected}},
+		{Kind: "write", Path: "d.txt", Bytes: 5, Err: &iofs.PathError{Op: "write", Path: "d.txt", Err: ErrInjected}},
+	}
+	if ops := fs.Ops(); !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected operations %+v, got %+v", expected, ops)
+	}
+}
+
+func TestInMemoryFS_NormalizedPaths(t *testing.T) {
+	fs := NewInMemoryFS()
+
+	name := fs.Join("models", filepath.Join("sub", "user.py"))
+	if name != "models/sub/user.py" {
+		t.Errorf("expected a slash-separated path, got %q", name)
+	}
+	if err := fs.WriteFile(filepath.Join("models", "sub", "user.py"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, lookup := range []string{name, "./models/sub/user.py", filepath.Join("models", "sub", "user.py")} {
+		if !fs.FileExists(lookup) {
+			t.Errorf("expected %q to exist", lookup)
+		}
+	}
+	if !fs.DirExists(filepath.Join("models", "sub")) {
+		t.Error("expected the parent directory to exist")
+	}
+}
@@ -0,0 +1,397 @@
+package golang
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/generators/importmgr"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// testdataFilename is the per-package file holding fake data functions
+const testdataFilename = "testdata.go"
+
+// testdataEnabled reports whether the testdata option asks for fake data functions
+func (g *Generator) testdataEnabled() (bool, error) {
+	switch g.config["testdata"] {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid testdata option %q (expected true or false)", g.config["testdata"])
+	}
+}
+
+// generateTestdata writes testdata.go for a module, with a Fake<Type> function per declared type
+func (g *Generator) generateTestdata(module *ast.Module, dest generators.FS, basePath, packageName string) error {
+	if _, exists := module.Files[strings.TrimSuffix(testdataFilename, ".go")+".tg"]; exists {
+		return fmt.Errorf("%s would overwrite the code generated for testdata.tg", testdataFilename)
+	}
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	imports := importmgr.New()
+	imports.AddStdlib("math/rand")
+	imports.AddStdlib("time")
+	var funcs []string
+	for _, filename := range filenames {
+		program := module.Files[filename]
+
+		for _, imp := range g.usedImports(program) {
+			moduleName := g.config["module-name"]
+			if moduleName == "" {
+				return fmt.Errorf("module-name configuration is required when using imports (import: %s)", imp.Path)
+			}
+			imports.Add(fmt.Sprintf("%s/%s", moduleName, strings.ReplaceAll(imp.Path, ".", "/")))
+		}
+
+		for _, decl := range program.Declarations {
+			code, err := g.generateFakeFunc(decl, imports)
+			if err != nil {
+				return fmt.Errorf("failed to generate fake data for %s: %w", filename, err)
+			}
+			if code != "" {
+				funcs = append(funcs, code)
+			}
+		}
+	}
+
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+	parts = append(parts, "// Fake data functions for tests. Every function is deterministic for a given *rand.Rand,")
+	parts = append(parts, "// so seeding it with a fixed value reproduces failures.")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("package %s", packageName))
+	parts = append(parts, "")
+	parts = append(parts, imports.Render(importmgr.GoFlat))
+	parts = append(parts, "")
+	parts = append(parts, fakeHelpers)
+	for _, fn := range funcs {
+		parts = append(parts, "")
+		parts = append(parts, fn)
+	}
+
+	testdataPath := dest.Join(basePath, testdataFilename)
+	if err := dest.WriteFile(testdataPath, []byte(strings.Join(parts, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", testdataPath, err)
+	}
+	return nil
+}
+
+// generateFakeFunc generates the Fake<Type> and Fake<Type>WithDepth functions for a declaration
+func (g *Generator) generateFakeFunc(decl ast.Declaration, imports *importmgr.Manager) (string, error) {
+	var name string
+	var body []string
+
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		name = d.Name
+		body = append(body, fmt.Sprintf("\treturn %s{", d.Name))
+		for _, field := range d.Fields {
+			var value string
+			var err error
+			if field.Optional {
+				value, err = g.fakeOptional(field.Type, imports)
+			} else {
+				value, err = g.fakeExpr(field.Type, imports)
+			}
+			if err != nil {
+				return "", err
+			}
+			body = append(body, fmt.Sprintf("\t\t%s: %s,", g.toGoFieldName(field.Name), value))
+		}
+		body = append(body, "\t}")
+
+	case *ast.EnumNode:
+		name = d.Name
+		if len(d.Variants) == 0 {
+			body = append(body, fmt.Sprintf("\treturn %s(0)", d.Name))
+			break
+		}
+
+		hasPayloads := false
+		for _, variant := range d.Variants {
+			if variant.Payload != nil {
+				hasPayloads = true
+				break
+			}
+		}
+		if !hasPayloads {
+			body = append(body, fmt.Sprintf("\treturn %s(rng.Intn(%d))", d.Name, len(d.Variants)))
+			break
+		}
+
+		body = append(body, fmt.Sprintf("\tswitch rng.Intn(%d) {", len(d.Variants)))
+		for i, variant := range d.Variants {
+			variantTypeName := fmt.Sprintf("%s_%s", d.Name, g.toPascalCase(variant.Name))
+			if i < len(d.Variants)-1 {
+				body = append(body, fmt.Sprintf("\tcase %d:", i))
+			} else {
+				body = append(body, "\tdefault:")
+			}
+			if variant.Payload == nil {
+				body = append(body, fmt.Sprintf("\t\treturn %s{Payload: %s{}}", d.Name, variantTypeName))
+				continue
+			}
+			value, err := g.fakeExpr(variant.Payload, imports)
+			if err != nil {
+				return "", err
+			}
+			body = append(body, fmt.Sprintf("\t\treturn %s{Payload: %s(%s)}", d.Name, variantTypeName, value))
+		}
+		body = append(body, "\t}")
+
+	case *ast.TypeAliasNode:
+		name = d.Name
+		value, err := g.fakeExpr(d.Type, imports)
+		if err != nil {
+			return "", err
+		}
+		body = append(body, fmt.Sprintf("\treturn %s", value))
+
+	default:
+		return "", nil // Constants have no fake values
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("// Fake%s returns a random %s, nesting at most FakeMaxDepth levels", name, name))
+	parts = append(parts, fmt.Sprintf("func Fake%s(rng *rand.Rand) %s {", name, name))
+	parts = append(parts, fmt.Sprintf("\treturn Fake%sWithDepth(rng, FakeMaxDepth)", name))
+	parts = append(parts, "}")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("// Fake%sWithDepth returns a random %s. Optionals, arrays and maps are left empty once depth runs out.", name, name))
+	parts = append(parts, fmt.Sprintf("func Fake%sWithDepth(rng *rand.Rand, depth int) %s {", name, name))
+	parts = append(parts, body...)
+	parts = append(parts, "}")
+	return strings.Join(parts, "\n"), nil
+}
+
+// fakeExpr returns a Go expression producing a random value of type t
+func (g *Generator) fakeExpr(t ast.Type, imports *importmgr.Manager) (string, error) {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return g.fakePrimitive(typ.Name, imports)
+
+	case *ast.NamedType:
+		if idx := strings.LastIndex(typ.Name, "."); idx >= 0 {
+			return fmt.Sprintf("%s.Fake%sWithDepth(rng, depth-1)", typ.Name[:idx], typ.Name[idx+1:]), nil
+		}
+		return fmt.Sprintf("Fake%sWithDepth(rng, depth-1)", typ.Name), nil
+
+	case *ast.ArrayType:
+		elementType, err := g.fakeType(typ.ElementType, imports)
+		if err != nil {
+			return "", err
+		}
+		element, err := g.fakeExpr(typ.ElementType, imports)
+		if err != nil {
+			return "", err
+		}
+		imports.Add(g.typegenImport())
+		return fmt.Sprintf("typegen.Array[%s](fakeSlice(rng, depth, func() %s { return %s }))", elementType, elementType, element), nil
+
+	case *ast.MapType:
+		keyType, err := g.fakeType(typ.KeyType, imports)
+		if err != nil {
+			return "", err
+		}
+		valueType, err := g.fakeType(typ.ValueType, imports)
+		if err != nil {
+			return "", err
+		}
+		key, err := g.fakeExpr(typ.KeyType, imports)
+		if err != nil {
+			return "", err
+		}
+		value, err := g.fakeExpr(typ.ValueType, imports)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("fakeMap(rng, depth, func() %s { return %s }, func() %s { return %s })", keyType, key, valueType, value), nil
+
+	case *ast.OptionalType:
+		return g.fakeOptional(typ.ElementType, imports)
+
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+}
+
+// fakeOptional returns a Go expression producing a random pointer to a value of type t, or nil
+func (g *Generator) fakeOptional(t ast.Type, imports *importmgr.Manager) (string, error) {
+	elementType, err := g.fakeType(t, imports)
+	if err != nil {
+		return "", err
+	}
+	element, err := g.fakeExpr(t, imports)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fakeOptional(rng, depth, func() %s { return %s })", elementType, element), nil
+}
+
+// fakeType returns the Go type of t as spelled in testdata.go
+func (g *Generator) fakeType(t ast.Type, imports *importmgr.Manager) (string, error) {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		if importPath := g.primitiveImport(typ.Name); importPath != "" {
+			imports.Add(importPath)
+		}
+		return g.mapPrimitiveType(typ.Name), nil
+	case *ast.NamedType:
+		return g.handleQualifiedType(typ.Name), nil
+	case *ast.ArrayType:
+		elementType, err := g.fakeType(typ.ElementType, imports)
+		if err != nil {
+			return "", err
+		}
+		imports.Add(g.typegenImport())
+		return fmt.Sprintf("typegen.Array[%s]", elementType), nil
+	case *ast.MapType:
+		keyType, err := g.fakeType(typ.KeyType, imports)
+		if err != nil {
+			return "", err
+		}
+		valueType, err := g.fakeType(typ.ValueType, imports)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("map[%s]%s", keyType, valueType), nil
+	case *ast.OptionalType:
+		elementType, err := g.fakeType(typ.ElementType, imports)
+		if err != nil {
+			return "", err
+		}
+		return "*" + elementType, nil
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+}
+
+// fakePrimitive returns a Go expression producing a random in-range value of a primitive type
+func (g *Generator) fakePrimitive(typeName string, imports *importmgr.Manager) (string, error) {
+	switch typeName {
+	case "bool":
+		return "rng.Intn(2) == 1", nil
+	case "string":
+		return "fakeString(rng)", nil
+	case "int8":
+		return "int8(rng.Intn(1<<8) - 1<<7)", nil
+	case "int16":
+		return "int16(rng.Intn(1<<16) - 1<<15)", nil
+	case "int32":
+		return "int32(rng.Uint32())", nil
+	case "int64", "int":
+		return "int64(rng.Uint64())", nil
+	case "nat8":
+		return "uint8(rng.Intn(1 << 8))", nil
+	case "nat16":
+		return "uint16(rng.Intn(1 << 16))", nil
+	case "nat32":
+		return "rng.Uint32()", nil
+	case "nat64", "nat":
+		return "rng.Uint64()", nil
+	case "float32":
+		return "rng.Float32() * 1000", nil
+	case "float64":
+		return "rng.Float64() * 1000", nil
+	case "decimal":
+		imports.Add(g.primitiveImport(typeName))
+		if g.config["decimal"] == "shopspring" {
+			return "decimal.New(rng.Int63n(1000000), -2)", nil
+		}
+		return "typegen.NewDecimal(rng.Int63n(1000000), 2)", nil
+	case "bigint":
+		imports.Add(g.primitiveImport(typeName))
+		return "typegen.NewBigInt(rng.Int63() - rng.Int63())", nil
+	case "bignat":
+		imports.Add(g.primitiveImport(typeName))
+		return "typegen.NewBigNat(rng.Uint64())", nil
+	case "uuid":
+		imports.Add(g.primitiveImport(typeName))
+		goType, _ := g.uuidType()
+		return goType + "(fakeUUID(rng))", nil
+	case "json":
+		return "map[string]interface{}{fakeString(rng): fakeString(rng)}", nil
+	case "date", "datetz":
+		return "fakeTime(rng).Truncate(24 * time.Hour)", nil
+	case "time", "timetz", "datetime", "datetimetz":
+		return "fakeTime(rng)", nil
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// typegenImport returns the import path of the generated typegen package
+func (g *Generator) typegenImport() string {
+	return g.config["module-name"] + "/typegen"
+}
+
+// fakeHelpers are the package-level settings and helpers shared by the generated Fake functions
+const fakeHelpers = `// FakeMaxDepth is the nesting depth Fake functions start with
+const FakeMaxDepth = 3
+
+// FakeOptionalProbability is the chance that a Fake function fills an optional value
+var FakeOptionalProbability = 0.5
+
+const fakeAlphabet = "abcdefghijklmnopqrstuvwxyz"
+
+func fakeString(rng *rand.Rand) string {
+	b := make([]byte, 1+rng.Intn(12))
+	for i := range b {
+		b[i] = fakeAlphabet[rng.Intn(len(fakeAlphabet))]
+	}
+	return string(b)
+}
+
+func fakeTime(rng *rand.Rand) time.Time {
+	// Whole seconds between 2000-01-01 and 2030-01-01, in UTC so values survive a JSON round trip
+	return time.Unix(946684800+rng.Int63n(946684800), 0).UTC()
+}
+
+func fakeUUID(rng *rand.Rand) [16]byte {
+	var b [16]byte
+	rng.Read(b[:])
+	// A version 4, variant 1 UUID
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return b
+}
+
+func fakeSlice[T any](rng *rand.Rand, depth int, fake func() T) []T {
+	if depth <= 0 {
+		return []T{}
+	}
+	values := make([]T, 1+rng.Intn(3))
+	for i := range values {
+		values[i] = fake()
+	}
+	return values
+}
+
+func fakeMap[K comparable, V any](rng *rand.Rand, depth int, key func() K, value func() V) map[K]V {
+	values := make(map[K]V)
+	if depth <= 0 {
+		return values
+	}
+	for n := 1 + rng.Intn(3); n > 0; n-- {
+		values[key()] = value()
+	}
+	return values
+}
+
+func fakeOptional[T any](rng *rand.Rand, depth int, fake func() T) *T {
+	if depth <= 0 || rng.Float64() >= FakeOptionalProbability {
+		return nil
+	}
+	value := fake()
+	return &value
+}`
@@ -0,0 +1,221 @@
+package golang
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	tgparser "github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+const fakeTestSchema = `import auth
+
+enum Status {
+	active
+	suspended
+}
+
+type Tags = []string
+
+struct User {
+	id: int64
+	age: nat8
+	name: string
+	email: ?string
+	tags: Tags
+	scores: [int32]float64
+	status: Status
+	token: auth.Token
+	created_at: datetime
+	birthday: date
+	balance: decimal
+	reserve: bignat
+	visits: nat
+	session: uuid
+	extra: json
+}
+
+struct TreeNode {
+	label: string
+	parent: ?TreeNode
+	children: []TreeNode
+}
+
+enum Result {
+	success: User
+	failure: string
+	pending
+}`
+
+// generateFakeModule generates a module with a root package and an auth subpackage
+func generateFakeModule(t *testing.T, config map[string]string) *generators.InMemoryFS {
+	t.Helper()
+
+	root, err := tgparser.Parse(strings.NewReader(fakeTestSchema), "models.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	token, err := tgparser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("models", map[string]*ast.ProgramNode{"models.tg": root})
+	module.SubModules["auth"] = ast.NewModule("models/auth", map[string]*ast.ProgramNode{"token.tg": token})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	return fs
+}
+
+func TestGenerateTestdata(t *testing.T) {
+	fs := generateFakeModule(t, map[string]string{"module-name": "example.com/fake", "testdata": "true"})
+
+	result, exists := fs.GetFileString("testdata.go")
+	if !exists {
+		t.Fatal("testdata.go should have been generated")
+	}
+
+	expected := []string{
+		"// Code generated by TypeGen. DO NOT EDIT.",
+		"package models",
+		"import (\n\t\"example.com/fake/auth\"\n\t\"example.com/fake/typegen\"\n\t\"math/rand\"\n\t\"time\"\n)\n",
+		"func FakeUser(rng *rand.Rand) User {",
+		"func FakeUserWithDepth(rng *rand.Rand, depth int) User {",
+		"func FakeStatus(rng *rand.Rand) Status {",
+		"func FakeTags(rng *rand.Rand) Tags {",
+		"func FakeTreeNode(rng *rand.Rand) TreeNode {",
+		"func FakeResult(rng *rand.Rand) Result {",
+		"Age: uint8(rng.Intn(1 << 8)),",
+		"Balance: typegen.NewDecimal(rng.Int63n(1000000), 2),",
+		"Reserve: typegen.NewBigNat(rng.Uint64()),",
+		"Visits: rng.Uint64(),",
+		"Session: typegen.UUID(fakeUUID(rng)),",
+		"Token: auth.FakeTokenWithDepth(rng, depth-1),",
+		"Parent: fakeOptional(rng, depth, func() TreeNode { return FakeTreeNodeWithDepth(rng, depth-1) }),",
+		"return Result{Payload: Result_Success(FakeUserWithDepth(rng, depth-1))}",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "testdata.go", result, 0); err != nil {
+		t.Errorf("Generated testdata.go does not parse: %v\n%s", err, result)
+	}
+
+	if sub, exists := fs.GetFileString("auth/testdata.go"); !exists || !strings.Contains(sub, "func FakeToken(rng *rand.Rand) Token {") {
+		t.Errorf("Expected auth/testdata.go with FakeToken, got:\n%s", sub)
+	}
+}
+
+func TestGenerateTestdataDisabled(t *testing.T) {
+	fs := generateFakeModule(t, map[string]string{"module-name": "example.com/fake"})
+
+	if fs.FileExists("testdata.go") || fs.FileExists("auth/testdata.go") {
+		t.Error("testdata.go should not be generated without the testdata option")
+	}
+}
+
+func TestGenerateTestdataInvalidOption(t *testing.T) {
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{})
+
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"testdata": "yes"})
+
+	err := generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid testdata option") {
+		t.Errorf("Expected invalid testdata option error, got: %v", err)
+	}
+}
+
+// TestGenerateTestdataCompiles builds the generated package and checks that equal seeds give equal values
+func TestGenerateTestdataCompiles(t *testing.T) {
+	fs := generateFakeModule(t, map[string]string{"module-name": "example.com/fake", "testdata": "true"})
+
+	output := runGenerated(t, fs, "example.com/fake", `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"example.com/fake"
+)
+
+func main() {
+	for i := 0; i < 2; i++ {
+		rng := rand.New(rand.NewSource(42))
+		var values []any
+		for j := 0; j < 20; j++ {
+			values = append(values, models.FakeUser(rng), models.FakeTreeNode(rng), models.FakeResult(rng))
+		}
+		data, err := json.Marshal(values)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(data))
+	}
+}
+`)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || lines[0] != lines[1] {
+		t.Errorf("Expected two identical runs for the same seed, got:\n%s", output)
+	}
+}
+
+// runGenerated writes the generated files into a Go module named moduleName,
+// runs main as its check/main.go and returns the output. It skips the test
+// in short mode or without a go toolchain.
+func runGenerated(t *testing.T, fs *generators.InMemoryFS, moduleName, main string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping compilation of generated code in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":        "module " + moduleName + "\n\ngo 1.21\n",
+		"check/main.go": main,
+	}
+	for _, path := range fs.ListFiles() {
+		content, _ := fs.GetFileString(path)
+		files[path] = content
+	}
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goBin, "run", "./check")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Generated code failed to build or run: %v\n%s", err, output)
+	}
+	return string(output)
+}
@@ -3,26 +3,31 @@ package golang
 import (
 	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/generators/importmgr"
+	"github.com/WhatsApp-Platform/typegen/naming"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+	"github.com/WhatsApp-Platform/typegen/validator"
 )
 
 // Generator generates Go code from TypeGen AST
 type Generator struct {
 	packageName        string
-	importMap          map[string]bool   // Track required imports
-	config             map[string]string // Configuration options
-	generatedArrayType bool              // Track if custom array type has been generated
+	imports            *importmgr.Manager // Imports of the file being generated
+	config             map[string]string  // Configuration options
+	generatedArrayType bool               // Track if custom array type has been generated
+	generatedSupport   map[string]bool    // Files of the typegen package generated so far, besides array.go
+	model              *semantic.Model    // Resolved type references of the module being generated
 }
 
 // NewGenerator creates a new Go code generator
 func NewGenerator() *Generator {
 	return &Generator{
 		packageName: "main", // Default package name
-		importMap:   make(map[string]bool),
+		imports:     importmgr.New(),
 		config:      make(map[string]string),
 	}
 }
@@ -32,16 +37,52 @@ func (g *Generator) SetConfig(config map[string]string) {
 	g.config = config
 }
 
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "go"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Idiomatic Go types with JSON marshaling"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "module-name", Type: "string", Description: "Go module path of the output directory, required when schemas use imports"},
+		{Key: "testdata", Type: "bool", Default: "false", Description: "Also write a testdata.go with a Fake<Type> function per type"},
+		{Key: "initialisms", Type: "bool", Default: "false", Description: "Write initialisms like ID and URL in uppercase in field and variant names, e.g. UserID for user_id"},
+		{Key: "decimal", Type: "typegen|shopspring", Default: "typegen", Description: "Go type of decimal: the generated typegen.Decimal, or decimal.Decimal from github.com/shopspring/decimal"},
+		{Key: "go-uuid-package", Type: "typegen|google", Default: "typegen", Description: "Go type of uuid: the generated typegen.UUID, or uuid.UUID from github.com/google/uuid"},
+	}
+}
+
 // Generate implements generators.Generator interface for module generation
 func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
 	g.generatedArrayType = false // Reset for each generation
+	g.generatedSupport = make(map[string]bool)
+	if _, err := g.testdataEnabled(); err != nil {
+		return err
+	}
+	if _, err := g.initialismsEnabled(); err != nil {
+		return err
+	}
+	if err := g.checkDecimalOption(); err != nil {
+		return err
+	}
+	if err := g.checkUUIDOption(); err != nil {
+		return err
+	}
+	g.model = semantic.Build(module)
 	return g.generateModuleRecursive(ctx, module, dest, "", module.Name)
 }
 
 // generateModuleRecursive recursively generates Go code for a module and its submodules
 func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath, packageName string) error {
 	// Generate Go file for each .tg file in this module
-	for filename, program := range module.Files {
+	for _, filename := range module.FileNames() {
+		program := module.Files[filename]
 		// Convert filename from .tg to .go
 		goFilename := strings.TrimSuffix(filename, ".tg") + ".go"
 		goPath := dest.Join(basePath, goFilename)
@@ -58,8 +99,16 @@ func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Mod
 		}
 	}
 
+	// Generate fake data functions alongside the models when requested
+	if enabled, _ := g.testdataEnabled(); enabled {
+		if err := g.generateTestdata(module, dest, basePath, packageName); err != nil {
+			return err
+		}
+	}
+
 	// Recursively process submodules
-	for subModuleName, subModule := range module.SubModules {
+	for _, subModuleName := range module.SubModuleNames() {
+		subModule := module.SubModules[subModuleName]
 		subModulePath := dest.Join(basePath, subModuleName)
 		subPackageName := subModuleName // Use submodule name as package name
 		if err := g.generateModuleRecursive(ctx, subModule, dest, subModulePath, subPackageName); err != nil {
@@ -72,7 +121,7 @@ func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Mod
 
 // generateProgram converts a TypeGen program to Go code
 func (g *Generator) generateProgram(program *ast.ProgramNode, packageName string, dest generators.FS) (string, error) {
-	g.importMap = make(map[string]bool) // Reset imports for each generation
+	g.imports = importmgr.New() // Reset imports for each generation
 	g.packageName = packageName
 
 	var parts []string
@@ -86,10 +135,8 @@ func (g *Generator) generateProgram(program *ast.ProgramNode, packageName string
 	parts = append(parts, "")
 
 
-	// Process imports first to determine dependencies
-	for _, imp := range program.Imports {
-		// For Go, we'll need to handle qualified names in type generation
-		// Store import information for later use
+	// Import the packages the program's types refer to; Go rejects unused imports
+	for _, imp := range g.usedImports(program) {
 		if err := g.generateImport(imp.Path); err != nil {
 			return "", err
 		}
@@ -109,7 +156,7 @@ func (g *Generator) generateProgram(program *ast.ProgramNode, packageName string
 	result := parts[0] + "\n"        // generated header
 	result += "\n" + parts[2] + "\n" // package declaration (skip empty line at parts[1])
 
-	imports := g.buildImports()
+	imports := g.imports.Render(importmgr.GoFlat)
 	if imports != "" {
 		result += "\n" + imports + "\n"
 	}
@@ -136,12 +183,31 @@ func (g *Generator) generateImport(importPath string) error {
 	goImportPath := strings.ReplaceAll(importPath, ".", "/")
 	fullImportPath := fmt.Sprintf("%s/%s", moduleName, goImportPath)
 
-	// Add to import map for later generation
-	g.importMap[fmt.Sprintf("\"%s\"", fullImportPath)] = true
+	// Add to the imports for later generation
+	g.imports.Add(fullImportPath)
 
 	return nil
 }
 
+// usedImports returns the imports of a program that its qualified type
+// references go through, in import order
+func (g *Generator) usedImports(program *ast.ProgramNode) []*ast.ImportNode {
+	used := make(map[*ast.ImportNode]bool)
+	ast.Inspect(program, func(named *ast.NamedType) {
+		if binding, ok := g.model.Binding(named); ok && binding.Import != nil {
+			used[binding.Import] = true
+		}
+	})
+
+	var imports []*ast.ImportNode
+	for _, imp := range program.Imports {
+		if used[imp] {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
 // handleQualifiedType converts TypeGen qualified types to Go qualified types
 // e.g., "auth.UserAuthentication" -> "auth.UserAuthentication"
 // Also ensures the import is added for qualified types
@@ -166,45 +232,60 @@ func (g *Generator) handleQualifiedType(typeName string) string {
 	return fmt.Sprintf("%s.%s", packageAlias, typeName)
 }
 
-// buildImports generates the import statements
-func (g *Generator) buildImports() string {
-	if len(g.importMap) == 0 {
-		return ""
-	}
-
-	var imports []string
-	for imp := range g.importMap {
-		imports = append(imports, imp)
-	}
-	sort.Strings(imports)
-
-	if len(imports) == 1 {
-		return fmt.Sprintf("import %s", imports[0])
-	}
-
-	result := "import (\n"
-	for _, imp := range imports {
-		result += fmt.Sprintf("\t%s\n", imp)
-	}
-	result += ")"
-
-	return result
-}
-
 // generateDeclaration generates Go code for a declaration
 func (g *Generator) generateDeclaration(decl ast.Declaration, dest generators.FS) (string, error) {
+	var code string
+	var doc []string
+	var err error
 	switch d := decl.(type) {
 	case *ast.StructNode:
-		return g.generateStruct(d, dest)
+		code, err = g.generateStruct(d, dest)
+		doc = d.Doc
 	case *ast.EnumNode:
-		return g.generateEnum(d, dest)
+		code, err = g.generateEnum(d, dest)
+		doc = d.Doc
 	case *ast.TypeAliasNode:
-		return g.generateTypeAlias(d, dest)
+		code, err = g.generateTypeAlias(d, dest)
+		doc = d.Doc
 	case *ast.ConstantNode:
-		return g.generateConstant(d)
+		code, err = g.generateConstant(d)
+		doc = d.Doc
 	default:
 		return "", fmt.Errorf("unknown declaration type: %T", decl)
 	}
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append(docComment(doc, ""), code), "\n"), nil
+}
+
+// withDeprecation returns doc followed by a Deprecated paragraph when a
+// field or variant has a @deprecated attribute, for tools that flag its use
+func withDeprecation(doc []string, deprecated *ast.AttributeNode) []string {
+	if deprecated == nil {
+		return doc
+	}
+	message := "do not use."
+	if value, ok := deprecated.Value.(*ast.StringConstant); ok && value.Value != "" {
+		message = value.Value
+	}
+	if len(doc) > 0 {
+		doc = append(doc[:len(doc):len(doc)], "")
+	}
+	return append(doc, "Deprecated: "+message)
+}
+
+// docComment returns the lines of a doc comment as Go comment lines
+func docComment(doc []string, indent string) []string {
+	lines := make([]string, 0, len(doc))
+	for _, line := range doc {
+		if line == "" {
+			lines = append(lines, indent+"//")
+		} else {
+			lines = append(lines, indent+"// "+line)
+		}
+	}
+	return lines
 }
 
 // generateStruct generates a Go struct
@@ -222,6 +303,7 @@ func (g *Generator) generateStruct(s *ast.StructNode, dest generators.FS) (strin
 		if err != nil {
 			return "", err
 		}
+		parts = append(parts, docComment(withDeprecation(field.Doc, field.Attribute("deprecated")), "\t")...)
 		parts = append(parts, "\t"+fieldCode)
 	}
 
@@ -240,9 +322,9 @@ func (g *Generator) generateField(field *ast.FieldNode, dest generators.FS) (str
 	// Add JSON tag for field mapping
 	var jsonTag string
 	if !field.Optional {
-		jsonTag = fmt.Sprintf("`json:\"%s\"`", field.Name)
+		jsonTag = fmt.Sprintf("`json:\"%s\"`", field.JSONName())
 	} else {
-		jsonTag = fmt.Sprintf("`json:\"%s,omitempty\"`", field.Name)
+		jsonTag = fmt.Sprintf("`json:\"%s,omitempty\"`", field.JSONName())
 	}
 	return fmt.Sprintf("%s %s %s", goName, goType, jsonTag), nil
 }
@@ -271,6 +353,7 @@ func (g *Generator) generateEnum(e *ast.EnumNode, dest generators.FS) (string, e
 
 	for i, variant := range e.Variants {
 		constName := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
+		parts = append(parts, docComment(withDeprecation(variant.Doc, variant.Attribute("deprecated")), "\t")...)
 		if i == 0 {
 			parts = append(parts, fmt.Sprintf("\t%s %s = iota", constName, e.Name))
 		} else {
@@ -287,7 +370,7 @@ func (g *Generator) generateEnum(e *ast.EnumNode, dest generators.FS) (string, e
 	for _, variant := range e.Variants {
 		constName := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
 		parts = append(parts, fmt.Sprintf("\tcase %s:", constName))
-		parts = append(parts, fmt.Sprintf("\t\treturn \"%s\"", variant.Name))
+		parts = append(parts, fmt.Sprintf("\t\treturn \"%s\"", variant.JSONName()))
 	}
 	parts = append(parts, "\tdefault:")
 	parts = append(parts, "\t\treturn \"unknown\"")
@@ -295,8 +378,8 @@ func (g *Generator) generateEnum(e *ast.EnumNode, dest generators.FS) (string, e
 	parts = append(parts, "}")
 
 	// Add custom JSON marshaling for simple enums to support {"type": "variant"} format
-	g.importMap["\"encoding/json\""] = true
-	g.importMap["\"fmt\""] = true
+	g.imports.AddStdlib("encoding/json")
+	g.imports.AddStdlib("fmt")
 
 	// Add MarshalJSON method
 	parts = append(parts, "")
@@ -320,7 +403,7 @@ func (g *Generator) generateEnum(e *ast.EnumNode, dest generators.FS) (string, e
 	parts = append(parts, "\tswitch typeStr {")
 	for _, variant := range e.Variants {
 		constName := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
-		parts = append(parts, fmt.Sprintf("\tcase \"%s\":", variant.Name))
+		parts = append(parts, fmt.Sprintf("\tcase \"%s\":", variant.JSONName()))
 		parts = append(parts, fmt.Sprintf("\t\t*e = %s", constName))
 	}
 	parts = append(parts, "\tdefault:")
@@ -335,8 +418,8 @@ func (g *Generator) generateEnum(e *ast.EnumNode, dest generators.FS) (string, e
 
 // generateTaggedUnion generates a tagged union for enums with payloads
 func (g *Generator) generateTaggedUnion(e *ast.EnumNode, dest generators.FS) (string, error) {
-	g.importMap["\"encoding/json\""] = true
-	g.importMap["\"fmt\""] = true
+	g.imports.AddStdlib("encoding/json")
+	g.imports.AddStdlib("fmt")
 
 	var parts []string
 
@@ -356,6 +439,7 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode, dest generators.FS) (st
 	// Generate variant types
 	for _, variant := range e.Variants {
 		variantTypeName := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
+		parts = append(parts, docComment(withDeprecation(variant.Doc, variant.Attribute("deprecated")), "")...)
 
 		if variant.Payload != nil {
 			// Variant with payload - create a type alias
@@ -372,7 +456,7 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode, dest generators.FS) (st
 		// Add interface method
 		methodName := fmt.Sprintf("%sType", strings.ToLower(e.Name))
 		parts = append(parts, fmt.Sprintf("func (%s) %s() string {", variantTypeName, methodName))
-		parts = append(parts, fmt.Sprintf("\treturn \"%s\"", variant.Name))
+		parts = append(parts, fmt.Sprintf("\treturn \"%s\"", variant.JSONName()))
 		parts = append(parts, "}")
 		parts = append(parts, "")
 	}
@@ -387,12 +471,12 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode, dest generators.FS) (st
 
 		if variant.Payload != nil {
 			parts = append(parts, "\t\treturn json.Marshal(map[string]interface{}{")
-			parts = append(parts, fmt.Sprintf("\t\t\t\"type\": \"%s\",", variant.Name))
+			parts = append(parts, fmt.Sprintf("\t\t\t\"type\": \"%s\",", variant.JSONName()))
 			parts = append(parts, "\t\t\t\"payload\": payload,")
 			parts = append(parts, "\t\t})")
 		} else {
 			parts = append(parts, "\t\treturn json.Marshal(map[string]interface{}{")
-			parts = append(parts, fmt.Sprintf("\t\t\t\"type\": \"%s\",", variant.Name))
+			parts = append(parts, fmt.Sprintf("\t\t\t\"type\": \"%s\",", variant.JSONName()))
 			parts = append(parts, "\t\t})")
 		}
 	}
@@ -424,12 +508,12 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode, dest generators.FS) (st
 
 	for _, variant := range e.Variants {
 		variantTypeName := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
-		parts = append(parts, fmt.Sprintf("\tcase \"%s\":", variant.Name))
+		parts = append(parts, fmt.Sprintf("\tcase \"%s\":", variant.JSONName()))
 
 		if variant.Payload != nil {
 			parts = append(parts, "\t\tpayloadBytes, exists := raw[\"payload\"]")
 			parts = append(parts, "\t\tif !exists {")
-			parts = append(parts, fmt.Sprintf("\t\t\treturn fmt.Errorf(\"missing 'payload' field for type '%s'\")", variant.Name))
+			parts = append(parts, fmt.Sprintf("\t\t\treturn fmt.Errorf(\"missing 'payload' field for type '%s'\")", variant.JSONName()))
 			parts = append(parts, "\t\t}")
 			parts = append(parts, fmt.Sprintf("\t\tvar payload %s", variantTypeName))
 			parts = append(parts, "\t\tif err := json.Unmarshal(payloadBytes, &payload); err != nil {")
@@ -465,7 +549,11 @@ func (g *Generator) generateTypeAlias(t *ast.TypeAliasNode, dest generators.FS)
 func (g *Generator) generateConstant(c *ast.ConstantNode) (string, error) {
 	switch value := c.Value.(type) {
 	case *ast.IntConstant:
-		return fmt.Sprintf("const %s = %d", c.Name, value.Value), nil
+		return fmt.Sprintf("const %s = %s", c.Name, value.String()), nil
+	case *ast.FloatConstant:
+		return fmt.Sprintf("const %s = %s", c.Name, value.String()), nil
+	case *ast.BoolConstant:
+		return fmt.Sprintf("const %s = %t", c.Name, value.Value), nil
 	case *ast.StringConstant:
 		return fmt.Sprintf("const %s = %q", c.Name, value.Value), nil
 	default:
@@ -479,6 +567,14 @@ func (g *Generator) generateType(t ast.Type, optional bool, dest generators.FS)
 
 	switch typ := t.(type) {
 	case *ast.PrimitiveType:
+		if filename, code, ok := g.supportFile(typ.Name); ok {
+			if moduleName := g.config["module-name"]; moduleName == "" {
+				return "", fmt.Errorf("module-name configuration is required when using %s", typ.Name)
+			}
+			if err := g.generateSupportModule(dest, filename, code); err != nil {
+				return "", err
+			}
+		}
 		baseType = g.mapPrimitiveType(typ.Name)
 	case *ast.NamedType:
 		baseType = g.handleQualifiedType(typ.Name)
@@ -498,8 +594,7 @@ func (g *Generator) generateType(t ast.Type, optional bool, dest generators.FS)
 		if !ok || moduleName == "" {
 			return "", fmt.Errorf("module-name configuration is required when using arrays")
 		}
-		typegenImportPath := fmt.Sprintf("%s/typegen", moduleName)
-		g.importMap[fmt.Sprintf("\"%s\"", typegenImportPath)] = true
+		g.imports.Add(fmt.Sprintf("%s/typegen", moduleName))
 
 		baseType = fmt.Sprintf("typegen.Array[%s]", elementType)
 	case *ast.MapType:
@@ -539,7 +634,7 @@ func (g *Generator) mapPrimitiveType(typeName string) string {
 		return "int16"
 	case "int32":
 		return "int32"
-	case "int64":
+	case "int64", "int":
 		return "int64"
 	case "nat8":
 		return "uint8"
@@ -547,31 +642,45 @@ func (g *Generator) mapPrimitiveType(typeName string) string {
 		return "uint16"
 	case "nat32":
 		return "uint32"
-	case "nat64":
+	case "nat64", "nat":
 		return "uint64"
 	case "float32":
 		return "float32"
 	case "float64":
 		return "float64"
+	case "decimal":
+		goType, _ := g.decimalType()
+		g.imports.Add(g.primitiveImport(typeName))
+		return goType
+	case "bigint":
+		g.imports.Add(g.primitiveImport(typeName))
+		return "typegen.BigInt"
+	case "bignat":
+		g.imports.Add(g.primitiveImport(typeName))
+		return "typegen.BigNat"
+	case "uuid":
+		goType, _ := g.uuidType()
+		g.imports.Add(g.primitiveImport(typeName))
+		return goType
 	case "json":
 		return "interface{}"
 	case "time":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	case "date":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	case "datetime":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	case "timetz":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	case "datetz":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	case "datetimetz":
-		g.importMap["\"time\""] = true
+		g.imports.AddStdlib("time")
 		return "time.Time"
 	default:
 		return typeName // Fallback to original name
@@ -583,19 +692,106 @@ func (g *Generator) toGoFieldName(name string) string {
 	return g.toPascalCase(name)
 }
 
-// toPascalCase converts snake_case to PascalCase for Go identifiers
+// toPascalCase converts snake_case to PascalCase for Go identifiers, with
+// the Go initialisms in uppercase when the initialisms option is set
 func (g *Generator) toPascalCase(name string) string {
-	parts := strings.Split(name, "_")
-	var result strings.Builder
-	for _, part := range parts {
-		if len(part) > 0 {
-			result.WriteString(strings.ToUpper(part[:1]))
-			if len(part) > 1 {
-				result.WriteString(part[1:])
-			}
-		}
+	caser := naming.Caser{KeepCase: true}
+	if g.config["initialisms"] == "true" {
+		caser.Initialisms = goInitialisms
+	}
+	return caser.PascalCase(name)
+}
+
+// goInitialisms are the initialisms written in uppercase with the
+// initialisms option
+var goInitialisms = naming.NewCaser(naming.DefaultInitialisms...).Initialisms
+
+// initialismsEnabled reports whether the initialisms option is set
+func (g *Generator) initialismsEnabled() (bool, error) {
+	switch g.config["initialisms"] {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid initialisms option %q (expected true or false)", g.config["initialisms"])
+	}
+}
+
+// shopspringDecimal is the import path of the decimal package used with the
+// decimal option set to shopspring
+const shopspringDecimal = "github.com/shopspring/decimal"
+
+// checkDecimalOption returns an error if the decimal option is invalid
+func (g *Generator) checkDecimalOption() error {
+	switch g.config["decimal"] {
+	case "", "typegen", "shopspring":
+		return nil
+	default:
+		return fmt.Errorf("invalid decimal option %q (expected typegen or shopspring)", g.config["decimal"])
+	}
+}
+
+// decimalType returns the Go type of decimal and the path of its package
+func (g *Generator) decimalType() (string, string) {
+	if g.config["decimal"] == "shopspring" {
+		return "decimal.Decimal", shopspringDecimal
+	}
+	return "typegen.Decimal", g.typegenImport()
+}
+
+// googleUUID is the import path of the uuid package used with the
+// go-uuid-package option set to google
+const googleUUID = "github.com/google/uuid"
+
+// checkUUIDOption returns an error if the go-uuid-package option is invalid
+func (g *Generator) checkUUIDOption() error {
+	switch g.config["go-uuid-package"] {
+	case "", "typegen", "google":
+		return nil
+	default:
+		return fmt.Errorf("invalid go-uuid-package option %q (expected typegen or google)", g.config["go-uuid-package"])
+	}
+}
+
+// uuidType returns the Go type of uuid and the path of its package
+func (g *Generator) uuidType() (string, string) {
+	if g.config["go-uuid-package"] == "google" {
+		return "uuid.UUID", googleUUID
+	}
+	return "typegen.UUID", g.typegenImport()
+}
+
+// primitiveImport returns the path of the package the Go type of a primitive
+// type comes from, or "" for builtin types and time.Time
+func (g *Generator) primitiveImport(typeName string) string {
+	switch typeName {
+	case "decimal":
+		_, importPath := g.decimalType()
+		return importPath
+	case "uuid":
+		_, importPath := g.uuidType()
+		return importPath
+	case "bigint", "bignat":
+		return g.typegenImport()
+	default:
+		return ""
+	}
+}
+
+// supportFile returns the name and code of the file of the typegen package
+// that defines the Go type of a primitive type, if it has one
+func (g *Generator) supportFile(typeName string) (string, string, bool) {
+	switch {
+	case typeName == "decimal" && g.config["decimal"] != "shopspring":
+		return "decimal.go", decimalTypeFile, true
+	case typeName == "bigint" || typeName == "bignat":
+		return "bigint.go", bigIntTypeFile, true
+	case typeName == "uuid" && g.config["go-uuid-package"] != "google":
+		return "uuid.go", uuidTypeFile, true
+	default:
+		return "", "", false
 	}
-	return result.String()
 }
 
 // generateArrayModule generates the typegen/array.go file if it hasn't been generated yet
@@ -636,10 +832,287 @@ func (a Array[T]) MarshalJSON() ([]byte, error) {
 `
 }
 
+// generateSupportModule generates a file of the typegen package if it hasn't been generated yet
+func (g *Generator) generateSupportModule(dest generators.FS, filename, code string) error {
+	if g.generatedSupport[filename] {
+		return nil
+	}
+
+	path := dest.Join("typegen", filename)
+	if err := dest.WriteFile(path, []byte(code), 0644); err != nil {
+		return fmt.Errorf("failed to write typegen/%s: %w", filename, err)
+	}
+
+	g.generatedSupport[filename] = true
+	return nil
+}
+
+// decimalTypeFile is the typegen/decimal.go file with the Decimal type
+const decimalTypeFile = `// Code generated by TypeGen. DO NOT EDIT.
+
+package typegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// decimalPattern matches a decimal number written as in JSON
+var decimalPattern = regexp.MustCompile(` + "`^-?(0|[1-9][0-9]*)(\\.[0-9]+)?([eE][+-]?[0-9]+)?$`" + `)
+
+// Decimal is an exact decimal number. It is written in JSON as a string, such
+// as "12.50", so no precision is lost, and read from a string or a number.
+// The zero value is 0.
+type Decimal struct {
+	text string
+}
+
+// ParseDecimal returns the decimal written in s, such as "-12.50" or "1e-3"
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{text: s}, nil
+}
+
+// NewDecimal returns unscaled * 10^-scale, e.g. NewDecimal(1250, 2) is 12.50
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	digits := strconv.FormatInt(unscaled, 10)
+	sign := ""
+	if unscaled < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if scale <= 0 {
+		if unscaled == 0 {
+			return Decimal{text: "0"}
+		}
+		return Decimal{text: sign + digits + strings.Repeat("0", int(-scale))}
+	}
+	if len(digits) <= int(scale) {
+		digits = strings.Repeat("0", int(scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(scale)
+	return Decimal{text: sign + digits[:point] + "." + digits[point:]}
+}
+
+// String returns the decimal as written, e.g. "12.50"
+func (d Decimal) String() string {
+	if d.text == "" {
+		return "0"
+	}
+	return d.text
+}
+
+// Rat returns the exact value of the decimal
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// Cmp compares the values of d and e, returning -1, 0 or +1. Unlike ==, it
+// finds 1.5 and 1.50 equal.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.Rat().Cmp(e.Rat())
+}
+
+// MarshalJSON writes the decimal as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads the decimal from a JSON string or number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, "\"") {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+`
+
+// bigIntTypeFile is the typegen/bigint.go file with the BigInt and BigNat types
+const bigIntTypeFile = `// Code generated by TypeGen. DO NOT EDIT.
+
+package typegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BigInt is an arbitrary-precision integer. It is written in JSON as a
+// string, such as "123456789012345678901234567890", since many JSON readers
+// lose precision beyond 2^53, and read from a string or a number. The zero
+// value, with a nil Int, is 0.
+type BigInt struct {
+	*big.Int
+}
+
+// NewBigInt returns x as a BigInt
+func NewBigInt(x int64) BigInt {
+	return BigInt{big.NewInt(x)}
+}
+
+// String returns the integer in base 10
+func (i BigInt) String() string {
+	if i.Int == nil {
+		return "0"
+	}
+	return i.Int.String()
+}
+
+// MarshalJSON writes the integer as a JSON string
+func (i BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON reads the integer from a JSON string or number
+func (i *BigInt) UnmarshalJSON(data []byte) error {
+	value, err := parseBigInt(data)
+	if err != nil || value == nil {
+		return err
+	}
+	i.Int = value
+	return nil
+}
+
+// BigNat is an arbitrary-precision natural number, a BigInt that is never
+// negative
+type BigNat struct {
+	*big.Int
+}
+
+// NewBigNat returns x as a BigNat
+func NewBigNat(x uint64) BigNat {
+	return BigNat{new(big.Int).SetUint64(x)}
+}
+
+// String returns the number in base 10
+func (n BigNat) String() string {
+	if n.Int == nil {
+		return "0"
+	}
+	return n.Int.String()
+}
+
+// MarshalJSON writes the number as a JSON string
+func (n BigNat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
+// UnmarshalJSON reads the number from a JSON string or number, rejecting
+// negative values
+func (n *BigNat) UnmarshalJSON(data []byte) error {
+	value, err := parseBigInt(data)
+	if err != nil || value == nil {
+		return err
+	}
+	if value.Sign() < 0 {
+		return fmt.Errorf("invalid natural number %s", value)
+	}
+	n.Int = value
+	return nil
+}
+
+// parseBigInt reads an integer from a JSON string or number, returning nil
+// for null
+func parseBigInt(data []byte) (*big.Int, error) {
+	text := string(data)
+	if text == "null" {
+		return nil, nil
+	}
+	if strings.HasPrefix(text, "\"") {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+	}
+	value, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", text)
+	}
+	return value, nil
+}
+`
+
+// uuidTypeFile is the typegen/uuid.go file with the UUID type
+const uuidTypeFile = `// Code generated by TypeGen. DO NOT EDIT.
+
+package typegen
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// UUID is a universally unique identifier. It is written in JSON as a string
+// in the canonical form, such as "123e4567-e89b-12d3-a456-426614174000". The
+// zero value is the nil UUID. UUIDs compare with == and can be map keys.
+type UUID [16]byte
+
+// ParseUUID returns the UUID written in s in the canonical form, in lower or
+// upper case
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	digits := s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return UUID{}, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// String returns the UUID in the canonical form, in lower case
+func (u UUID) String() string {
+	text := hex.EncodeToString(u[:])
+	return text[:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:]
+}
+
+// MarshalText writes the UUID in the canonical form. encoding/json uses it
+// for values and map keys alike.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText reads the UUID from the canonical form
+func (u *UUID) UnmarshalText(data []byte) error {
+	parsed, err := ParseUUID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+`
+
 
 func init() {
 	// Register the Go generator globally
 	generators.Register("go", func() generators.Generator {
 		return NewGenerator()
 	})
+	// Fields are written in PascalCase, but type and package names as they
+	// are declared
+	validator.RegisterReservedWords("go", validator.ReservedWords{
+		Language: "Go",
+		Words:    naming.GoKeywords,
+		Names:    []string{validator.TypeNames, validator.ModuleNames},
+	})
 }
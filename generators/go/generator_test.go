@@ -224,6 +224,281 @@ func TestGenerateTimeTypes(t *testing.T) {
 	}
 }
 
+func TestGenerateTimezoneTypes(t *testing.T) {
+	input := `struct Schedule {
+		opens_at: timetz
+		holiday: datetz
+		starts_at: datetimetz
+		closes_at: ?timetz
+	}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("schedule", map[string]*ast.ProgramNode{"schedule.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("schedule.go")
+
+	expected := []string{
+		"import \"time\"",
+		"OpensAt time.Time `json:\"opens_at\"`",
+		"Holiday time.Time `json:\"holiday\"`",
+		"StartsAt time.Time `json:\"starts_at\"`",
+		"ClosesAt *time.Time `json:\"closes_at,omitempty\"`",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+
+	// The offset of a value must survive a round trip
+	output := runGenerated(t, fs, "example.com/schedule", `package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"example.com/schedule"
+)
+
+func main() {
+	var s schedule.Schedule
+	err := json.Unmarshal([]byte(`+"`"+`{"opens_at": "0000-01-01T09:30:00+05:30", "holiday": "2024-12-25T00:00:00-08:00", "starts_at": "2024-06-01T12:00:00Z"}`+"`"+`), &s)
+	data, _ := json.Marshal(s)
+	fmt.Println(string(data), err)
+}
+`)
+	expectedOutput := `{"opens_at":"0000-01-01T09:30:00+05:30","holiday":"2024-12-25T00:00:00-08:00","starts_at":"2024-06-01T12:00:00Z"} <nil>
+`
+	if output != expectedOutput {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expectedOutput, output)
+	}
+}
+
+func TestGenerateDecimal(t *testing.T) {
+	input := `struct Invoice {
+		total: decimal
+		discount: ?decimal
+		lines: [string]decimal
+	}
+	type Price = decimal`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"module-name": "example.com/app"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"import \"example.com/app/typegen\"",
+		"Total typegen.Decimal `json:\"total\"`",
+		"Discount *typegen.Decimal `json:\"discount,omitempty\"`",
+		"Lines map[string]typegen.Decimal `json:\"lines\"`",
+		"type Price = typegen.Decimal",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	decimal, exists := fs.GetFileString("typegen/decimal.go")
+	if !exists || !strings.Contains(decimal, "type Decimal struct") {
+		t.Errorf("Expected typegen/decimal.go with the Decimal type, got:\n%s", decimal)
+	}
+
+	// With shopspring, decimals use its package and no Decimal type is written
+	fs = generators.NewInMemoryFS()
+	generator.SetConfig(map[string]string{"decimal": "shopspring"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ = fs.GetFileString("test.go")
+	for _, exp := range []string{"import \"github.com/shopspring/decimal\"", "Total decimal.Decimal `json:\"total\"`"} {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	if _, exists := fs.GetFileString("typegen/decimal.go"); exists {
+		t.Error("typegen/decimal.go should not be generated with shopspring")
+	}
+
+	generator.SetConfig(nil)
+	err = generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "module-name configuration is required when using decimal") {
+		t.Errorf("Expected module-name error, got: %v", err)
+	}
+
+	generator.SetConfig(map[string]string{"decimal": "float"})
+	err = generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid decimal option") {
+		t.Errorf("Expected invalid decimal option error, got: %v", err)
+	}
+}
+
+func TestGenerateBigIntegers(t *testing.T) {
+	input := `struct Ledger {
+		balance: bigint
+		supply: ?bignat
+		history: []bigint
+	}
+	type Supply = bignat`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("ledger", map[string]*ast.ProgramNode{"ledger.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"module-name": "example.com/ledger"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("ledger.go")
+
+	expected := []string{
+		"import \"example.com/ledger/typegen\"",
+		"Balance typegen.BigInt `json:\"balance\"`",
+		"Supply *typegen.BigNat `json:\"supply,omitempty\"`",
+		"History typegen.Array[typegen.BigInt] `json:\"history\"`",
+		"type Supply = typegen.BigNat",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	support, _ := fs.GetFileString("typegen/bigint.go")
+	if count := strings.Count(support, "\"math/big\""); count != 1 {
+		t.Errorf("Expected typegen/bigint.go to import math/big once, got %d:\n%s", count, support)
+	}
+
+	output := runGenerated(t, fs, "example.com/ledger", `package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"example.com/ledger"
+)
+
+func main() {
+	var l ledger.Ledger
+	err := json.Unmarshal([]byte(`+"`"+`{"balance": "-123456789012345678901234567890", "supply": 42, "history": ["1"]}`+"`"+`), &l)
+	data, _ := json.Marshal(l)
+	fmt.Println(string(data), err)
+
+	var s ledger.Supply
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`"-1"`+"`"+`), &s))
+}
+`)
+	expectedOutput := `{"balance":"-123456789012345678901234567890","supply":"42","history":["1"]} <nil>
+invalid natural number -1
+`
+	if output != expectedOutput {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", expectedOutput, output)
+	}
+}
+
+func TestGenerateUUID(t *testing.T) {
+	input := `struct Session {
+		id: uuid
+		parent: ?uuid
+		roles: [uuid]string
+	}
+	type SessionID = uuid`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("session", map[string]*ast.ProgramNode{"session.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"module-name": "example.com/session"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("session.go")
+
+	expected := []string{
+		"import \"example.com/session/typegen\"",
+		"Id typegen.UUID `json:\"id\"`",
+		"Parent *typegen.UUID `json:\"parent,omitempty\"`",
+		"Roles map[typegen.UUID]string `json:\"roles\"`",
+		"type SessionID = typegen.UUID",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+
+	output := runGenerated(t, fs, "example.com/session", `package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"example.com/session"
+)
+
+func main() {
+	var s session.Session
+	err := json.Unmarshal([]byte(`+"`"+`{"id": "123E4567-E89B-12D3-A456-426614174000", "roles": {"00000000-0000-0000-0000-000000000001": "admin"}}`+"`"+`), &s)
+	data, _ := json.Marshal(s)
+	fmt.Println(string(data), err)
+
+	var id session.SessionID
+	fmt.Println(json.Unmarshal([]byte(`+"`"+`"123e4567e89b12d3a456426614174000"`+"`"+`), &id))
+}
+`)
+	expectedOutput := `{"id":"123e4567-e89b-12d3-a456-426614174000","roles":{"00000000-0000-0000-0000-000000000001":"admin"}} <nil>
+invalid UUID "123e4567e89b12d3a456426614174000"
+`
+	if output != expectedOutput {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expectedOutput, output)
+	}
+
+	// With google, uuids use its package and no UUID type is written
+	fs = generators.NewInMemoryFS()
+	generator.SetConfig(map[string]string{"go-uuid-package": "google"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ = fs.GetFileString("session.go")
+	for _, exp := range []string{"import \"github.com/google/uuid\"", "Id uuid.UUID `json:\"id\"`", "Roles map[uuid.UUID]string `json:\"roles\"`"} {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	if _, exists := fs.GetFileString("typegen/uuid.go"); exists {
+		t.Error("typegen/uuid.go should not be generated with google")
+	}
+
+	generator.SetConfig(map[string]string{"go-uuid-package": "gofrs"})
+	err = generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid go-uuid-package option") {
+		t.Errorf("Expected invalid go-uuid-package option error, got: %v", err)
+	}
+}
+
 func TestGenerateSimpleEnum(t *testing.T) {
 	input := `enum Status {
 		active
@@ -469,10 +744,12 @@ func TestGeneratePrimitiveTypes(t *testing.T) {
 		int16_field: int16
 		int32_field: int32
 		int64_field: int64
+		int_field: int
 		nat8_field: nat8
 		nat16_field: nat16
 		nat32_field: nat32
 		nat64_field: nat64
+		nat_field: nat
 		float32_field: float32
 		float64_field: float64
 		bool_field: bool
@@ -516,6 +793,8 @@ func TestGeneratePrimitiveTypes(t *testing.T) {
 		"Nat16Field uint16 `json:\"nat16_field\"`",
 		"Nat32Field uint32 `json:\"nat32_field\"`",
 		"Nat64Field uint64 `json:\"nat64_field\"`",
+		"IntField int64 `json:\"int_field\"`",
+		"NatField uint64 `json:\"nat_field\"`",
 		"Float32Field float32 `json:\"float32_field\"`",
 		"Float64Field float64 `json:\"float64_field\"`",
 		"BoolField bool `json:\"bool_field\"`",
@@ -732,8 +1011,102 @@ func TestToPascalCase(t *testing.T) {
 	}
 }
 
+// TestGenerateImportBlock checks the exact import declaration, so changes to
+// import handling can't change the generated code
+func TestGenerateImportBlock(t *testing.T) {
+	token, err := parser.Parse(strings.NewReader("struct Token {\n\tvalue: string\n}"), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	header := "// Code generated by TypeGen. DO NOT EDIT.\n\npackage test\n\n"
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"none", "struct A {\n\tid: int64\n}", "type A struct"},
+		{"single", "struct A {\n\tat: time\n\tday: date\n}", "import \"time\"\n\ntype A struct"},
+		{
+			"several",
+			"import auth\nstruct A {\n\ttoken: auth.Token\n\tat: time\n\ttags: []string\n}\nenum E {\n\ta\n\tb: string\n}",
+			"import (\n\t\"encoding/json\"\n\t\"example.com/app/auth\"\n\t\"example.com/app/typegen\"\n\t\"fmt\"\n\t\"time\"\n)\n\ntype A struct",
+		},
+	}
+	for _, tt := range tests {
+		program, err := parser.Parse(strings.NewReader(tt.input), "test.tg")
+		if err != nil {
+			t.Fatalf("%s: parse error: %v", tt.name, err)
+		}
+		module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+		module.SubModules["auth"] = ast.NewModule("auth", map[string]*ast.ProgramNode{"token.tg": token})
+
+		fs := generators.NewInMemoryFS()
+		generator := NewGenerator()
+		generator.SetConfig(map[string]string{"module-name": "example.com/app"})
+		if err := generator.Generate(context.Background(), module, fs); err != nil {
+			t.Fatalf("%s: generation error: %v", tt.name, err)
+		}
+		result, _ := fs.GetFileString("test.go")
+		if !strings.HasPrefix(result, header+tt.expected) {
+			t.Errorf("%s: expected output to start with %q, got:\n%s", tt.name, header+tt.expected, result)
+		}
+	}
+}
+
+func TestGenerateInitialisms(t *testing.T) {
+	input := `struct User {
+		user_id: int64
+		avatar_url: string
+		http_status: int32
+		identity: string
+	}
+	enum Source {
+		api
+		json_feed: string
+	}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"initialisms": "true"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"UserID int64 `json:\"user_id\"`",
+		"AvatarURL string `json:\"avatar_url\"`",
+		"HTTPStatus int32 `json:\"http_status\"`",
+		"Identity string `json:\"identity\"`",
+		"type Source_API struct{}",
+		"type Source_JSONFeed string",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+
+	generator.SetConfig(map[string]string{"initialisms": "yes"})
+	err = generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid initialisms option") {
+		t.Errorf("Expected invalid initialisms option error, got: %v", err)
+	}
+}
+
 func TestGenerateIntConstant(t *testing.T) {
-	input := `const MAX_RETRIES = 5`
+	input := `const MAX_RETRIES = 5
+	const MIN_TEMP = -40
+	const MIN_INT = -9223372036854775808
+	const FLAG_ADMIN = 0x08
+	const MASK = -0b1010`
 
 	program, err := parser.Parse(strings.NewReader(input), "test.tg")
 	if err != nil {
@@ -764,6 +1137,10 @@ func TestGenerateIntConstant(t *testing.T) {
 	expected := []string{
 		"package test",
 		"const MAX_RETRIES = 5",
+		"const MIN_TEMP = -40",
+		"const MIN_INT = -9223372036854775808",
+		"const FLAG_ADMIN = 0x08",
+		"const MASK = -0b1010",
 	}
 
 	for _, exp := range expected {
@@ -773,6 +1150,65 @@ func TestGenerateIntConstant(t *testing.T) {
 	}
 }
 
+func TestGenerateFloatConstant(t *testing.T) {
+	input := `const PI = 3.14
+	const EPSILON = 1e-9
+	const OFFSET = -0.5
+	const RATIO = 2.0`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	// Whole numbers keep a decimal point so the constant stays a float
+	expected := []string{
+		"const PI = 3.14",
+		"const EPSILON = 1e-9",
+		"const OFFSET = -0.5",
+		"const RATIO = 2.0",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBoolConstant(t *testing.T) {
+	input := `const FEATURE_ENABLED = true
+	const LEGACY_MODE = false`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"const FEATURE_ENABLED = true",
+		"const LEGACY_MODE = false",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
 func TestGenerateStringConstant(t *testing.T) {
 	input := `const API_URL = "https://api.example.com"`
 
@@ -865,3 +1301,192 @@ struct User {
 		}
 	}
 }
+
+func TestGenerateOnlyUsedImports(t *testing.T) {
+	input := `import auth
+import billing
+
+struct User {
+	token: auth.Token
+}`
+
+	program, err := parser.Parse(strings.NewReader(input), "user.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	token, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"user.tg": program})
+	module.SubModules["auth"] = ast.NewModule("test/auth", map[string]*ast.ProgramNode{"token.tg": token})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"module-name": "example.com/test"})
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	result, _ := fs.GetFileString("user.go")
+	if !strings.Contains(result, `import "example.com/test/auth"`) {
+		t.Errorf("Expected the auth package to be imported, got:\n%s", result)
+	}
+	if strings.Contains(result, "billing") {
+		t.Errorf("Expected the unused billing import to be left out, got:\n%s", result)
+	}
+}
+
+func TestGenerateDocComments(t *testing.T) {
+	input := `// User is a customer.
+//
+// Users sign in by email.
+struct User {
+  // ID is the user's id
+  id: int64
+  name: string
+}
+
+// Status of an order
+enum Status {
+  // Not paid yet
+  pending
+  paid
+}
+
+// Event is a shipment event
+enum Event {
+  // Left the warehouse
+  shipped: User
+}
+
+// UserID identifies a user
+type UserID = int64
+
+// MAX_ITEMS is the cart size
+const MAX_ITEMS = 10`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"// User is a customer.\n//\n// Users sign in by email.\ntype User struct {",
+		"\t// ID is the user's id\n\tId int64",
+		"\tName string",
+		"// Status of an order\ntype Status int",
+		"\t// Not paid yet\n\tStatus_Pending Status = iota",
+		"// Event is a shipment event\ntype Event struct {",
+		"// Left the warehouse\ntype Event_Shipped User",
+		"// UserID identifies a user\ntype UserID = int64",
+		"// MAX_ITEMS is the cart size\nconst MAX_ITEMS = 10",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateDeprecated(t *testing.T) {
+	input := `struct User {
+  // The user's login
+  login: string @deprecated("use email")
+  nickname: ?string @deprecated
+  email: string @sensitive
+}
+
+enum Status {
+  active
+  legacy @deprecated
+}
+
+enum Event {
+  shipped: User @deprecated("use sent")
+}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"\t// The user's login\n\t//\n\t// Deprecated: use email\n\tLogin string",
+		"\t// Deprecated: do not use.\n\tNickname *string",
+		"\t// Deprecated: do not use.\n\tStatus_Legacy\n",
+		"// Deprecated: use sent\ntype Event_Shipped User",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	if strings.Contains(result, "// Deprecated: do not use.\n\tEmail") {
+		t.Errorf("Expected only @deprecated fields to be deprecated, got:\n%s", result)
+	}
+}
+
+func TestGenerateWireNames(t *testing.T) {
+	input := `struct User {
+  user_id: int64 = "userId"
+  nickname: ?string = "nickName"
+  status: Status
+}
+
+enum Status {
+  active = "ACTIVE"
+  banned
+}
+
+enum Event {
+  created: User = "CREATED"
+  deleted
+}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.go")
+
+	expected := []string{
+		"UserId int64 `json:\"userId\"`",
+		"Nickname *string `json:\"nickName,omitempty\"`",
+		"Status Status `json:\"status\"`",
+		"case Status_Active:\n\t\treturn \"ACTIVE\"",
+		"case \"ACTIVE\":\n\t\t*e = Status_Active",
+		"case \"banned\":\n\t\t*e = Status_Banned",
+		"func (Event_Created) eventType() string {\n\treturn \"CREATED\"",
+		"\"type\": \"CREATED\",",
+		"case \"CREATED\":",
+		"missing 'payload' field for type 'CREATED'",
+		"case \"deleted\":",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
@@ -0,0 +1,701 @@
+package hack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+// Generator generates Hack code from TypeGen AST
+type Generator struct {
+	config    map[string]string // Configuration options
+	module    *ast.Module       // Root module, used to resolve qualified named types
+	current   *ast.Module       // Module of the file being generated
+	namespace string            // Namespace of the file being generated
+	imports   map[string]string // Import alias -> import path for the current file
+	libs      map[string]bool   // HH\Lib namespaces used by the current file
+}
+
+// NewGenerator creates a new Hack code generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config:  make(map[string]string),
+		imports: make(map[string]string),
+		libs:    make(map[string]bool),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "hack"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Hack classes or shapes with fromDict/toDict helpers"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "namespace", Type: "string", Description: "Root namespace for generated code, e.g. Acme\\Api"},
+		{Key: "style", Type: "class|shape", Default: "class", Description: "Struct representation"},
+	}
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	style := g.style()
+	if style != "class" && style != "shape" {
+		return fmt.Errorf("unsupported style %q (supported: class, shape)", style)
+	}
+
+	g.module = module
+	return g.generateModuleRecursive(ctx, module, dest, "", g.config["namespace"])
+}
+
+// style returns the configured struct style, defaulting to classes
+func (g *Generator) style() string {
+	if style := g.config["style"]; style != "" {
+		return style
+	}
+	return "class"
+}
+
+// generateModuleRecursive recursively generates Hack code for a module and its submodules
+func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Module, dest generators.FS, basePath, namespace string) error {
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		hackFilename := strings.TrimSuffix(filename, ".tg") + ".hack"
+		hackPath := dest.Join(basePath, hackFilename)
+
+		code, err := g.generateProgram(module, module.Files[filename], filename, namespace)
+		if err != nil {
+			return fmt.Errorf("failed to generate code for %s: %w", filename, err)
+		}
+
+		if err := dest.WriteFile(hackPath, []byte(code), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", hackPath, err)
+		}
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+
+	for _, subModuleName := range subModuleNames {
+		subModulePath := dest.Join(basePath, subModuleName)
+		if err := g.generateModuleRecursive(ctx, module.SubModules[subModuleName], dest, subModulePath, joinNamespace(namespace, subModuleName)); err != nil {
+			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
+		}
+	}
+
+	return nil
+}
+
+// generateProgram converts a TypeGen program to Hack code
+func (g *Generator) generateProgram(module *ast.Module, program *ast.ProgramNode, filename, namespace string) (string, error) {
+	g.current = module
+	g.namespace = namespace
+	g.imports = make(map[string]string)
+	g.libs = make(map[string]bool)
+
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		g.imports[parts[len(parts)-1]] = imp.Path
+	}
+
+	var body []string
+	var constants []*ast.ConstantNode
+
+	for _, decl := range program.Declarations {
+		if c, ok := decl.(*ast.ConstantNode); ok {
+			constants = append(constants, c)
+			continue
+		}
+
+		code, err := g.generateDeclaration(decl)
+		if err != nil {
+			return "", err
+		}
+		body = append(body, code)
+	}
+
+	if len(constants) > 0 {
+		code, err := g.generateConstants(constants, filename)
+		if err != nil {
+			return "", err
+		}
+		body = append(body, code)
+	}
+
+	var parts []string
+	parts = append(parts, "// Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+
+	if namespace != "" {
+		parts = append(parts, fmt.Sprintf("namespace %s;", namespace))
+		parts = append(parts, "")
+	}
+
+	if len(g.libs) > 0 {
+		var libs []string
+		for lib := range g.libs {
+			libs = append(libs, lib)
+		}
+		sort.Strings(libs)
+		parts = append(parts, fmt.Sprintf("use namespace HH\\Lib\\{%s};", strings.Join(libs, ", ")))
+		parts = append(parts, "")
+	}
+
+	return strings.Join(parts, "\n") + "\n" + strings.Join(body, "\n\n") + "\n", nil
+}
+
+// generateDeclaration generates Hack code for a declaration
+func (g *Generator) generateDeclaration(decl ast.Declaration) (string, error) {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		if g.style() == "shape" {
+			return g.generateShape(d)
+		}
+		return g.generateClass(d)
+	case *ast.EnumNode:
+		if hasPayloads(d) {
+			return g.generateTaggedUnion(d)
+		}
+		return g.generateEnum(d)
+	case *ast.TypeAliasNode:
+		return g.generateTypeAlias(d)
+	default:
+		return "", fmt.Errorf("unknown declaration type: %T", decl)
+	}
+}
+
+// generateClass generates a final class with promoted constructor properties and fromDict/toDict
+func (g *Generator) generateClass(s *ast.StructNode) (string, error) {
+	// Hack requires defaulted parameters to come last, so optional fields follow required ones
+	var params []*ast.FieldNode
+	for _, field := range s.Fields {
+		if !field.Optional {
+			params = append(params, field)
+		}
+	}
+	for _, field := range s.Fields {
+		if field.Optional {
+			params = append(params, field)
+		}
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("final class %s {", s.Name))
+
+	if len(params) == 0 {
+		parts = append(parts, "  public function __construct()[] {}")
+	} else {
+		parts = append(parts, "  public function __construct(")
+		for _, field := range params {
+			hackType, err := g.generateType(field.Type, field.Optional)
+			if err != nil {
+				return "", err
+			}
+			param := fmt.Sprintf("    public %s $%s", hackType, field.Name)
+			if field.Optional {
+				param += " = null"
+			}
+			parts = append(parts, param+",")
+		}
+		parts = append(parts, "  )[] {}")
+	}
+
+	// fromDict
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  public static function fromDict(KeyedContainer<arraykey, mixed> $data): %s {", s.Name))
+	if len(params) == 0 {
+		parts = append(parts, fmt.Sprintf("    return new %s();", s.Name))
+	} else {
+		parts = append(parts, fmt.Sprintf("    return new %s(", s.Name))
+		for _, field := range params {
+			var value string
+			if field.Optional {
+				raw := fmt.Sprintf("idx($data, '%s')", field.JSONName())
+				value = fmt.Sprintf("%s is null ? null : %s", raw, g.decodeExpr(field.Type, raw, 0))
+			} else {
+				value = g.decodeExpr(field.Type, fmt.Sprintf("$data['%s']", field.JSONName()), 0)
+			}
+			parts = append(parts, fmt.Sprintf("      %s,", value))
+		}
+		parts = append(parts, "    );")
+	}
+	parts = append(parts, "  }")
+
+	// toDict
+	parts = append(parts, "")
+	parts = append(parts, "  public function toDict(): dict<string, mixed> {")
+	parts = append(parts, "    $result = dict[];")
+	for _, field := range s.Fields {
+		prop := fmt.Sprintf("$this->%s", field.Name)
+		if field.Optional {
+			parts = append(parts, fmt.Sprintf("    if (%s is nonnull) {", prop))
+			parts = append(parts, fmt.Sprintf("      $result['%s'] = %s;", field.JSONName(), g.encodeExpr(field.Type, prop, 0)))
+			parts = append(parts, "    }")
+		} else {
+			parts = append(parts, fmt.Sprintf("    $result['%s'] = %s;", field.JSONName(), g.encodeExpr(field.Type, prop, 0)))
+		}
+	}
+	parts = append(parts, "    return $result;")
+	parts = append(parts, "  }")
+
+	parts = append(parts, "}")
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateShape generates a shape type alias for a struct
+func (g *Generator) generateShape(s *ast.StructNode) (string, error) {
+	if len(s.Fields) == 0 {
+		return fmt.Sprintf("type %s = shape();", s.Name), nil
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("type %s = shape(", s.Name))
+	for _, field := range s.Fields {
+		hackType, err := g.generateType(field.Type, field.Optional)
+		if err != nil {
+			return "", err
+		}
+		key := fmt.Sprintf("'%s'", field.JSONName())
+		if field.Optional {
+			key = "?" + key
+		}
+		parts = append(parts, fmt.Sprintf("  %s => %s,", key, hackType))
+	}
+	parts = append(parts, ");")
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateEnum generates a string-backed enum plus a helper class for the object JSON format
+func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("enum %s: string as string {", e.Name))
+	for _, variant := range e.Variants {
+		parts = append(parts, fmt.Sprintf("  %s = '%s';", strings.ToUpper(variant.Name), variant.JSONName()))
+	}
+	parts = append(parts, "}")
+
+	// JSON helpers for the {"type": "variant"} format
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("abstract final class %sJson {", e.Name))
+	parts = append(parts, fmt.Sprintf("  public static function fromDict(KeyedContainer<arraykey, mixed> $data): %s {", e.Name))
+	parts = append(parts, "    $type = idx($data, 'type');")
+	parts = append(parts, "    if ($type is null) {")
+	parts = append(parts, "      throw new \\InvalidArgumentException(\"missing 'type' field\");")
+	parts = append(parts, "    }")
+	parts = append(parts, fmt.Sprintf("    $value = %s::coerce($type);", e.Name))
+	parts = append(parts, "    if ($value is null) {")
+	parts = append(parts, "      throw new \\InvalidArgumentException('unknown enum value: '.($type as string));")
+	parts = append(parts, "    }")
+	parts = append(parts, "    return $value;")
+	parts = append(parts, "  }")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  public static function toDict(%s $value): dict<string, mixed> {", e.Name))
+	parts = append(parts, "    return dict['type' => (string)$value];")
+	parts = append(parts, "  }")
+	parts = append(parts, "}")
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateTaggedUnion generates an abstract base class with one final subclass per variant
+func (g *Generator) generateTaggedUnion(e *ast.EnumNode) (string, error) {
+	var parts []string
+
+	// Base class with factory
+	parts = append(parts, fmt.Sprintf("abstract class %s {", e.Name))
+	parts = append(parts, "  abstract public function toDict(): dict<string, mixed>;")
+	parts = append(parts, "")
+	parts = append(parts, fmt.Sprintf("  public static function fromDict(KeyedContainer<arraykey, mixed> $data): %s {", e.Name))
+	parts = append(parts, "    $type = idx($data, 'type');")
+	parts = append(parts, "    switch ($type) {")
+	for _, variant := range e.Variants {
+		className := variantClassName(e, variant)
+		parts = append(parts, fmt.Sprintf("      case '%s':", variant.JSONName()))
+		if variant.Payload != nil {
+			parts = append(parts, "        if (!C\\contains_key($data, 'payload')) {")
+			parts = append(parts, fmt.Sprintf("          throw new \\InvalidArgumentException(\"missing 'payload' field for type '%s'\");", variant.JSONName()))
+			parts = append(parts, "        }")
+			g.libs["C"] = true
+			parts = append(parts, fmt.Sprintf("        return new %s(%s);", className, g.decodeExpr(variant.Payload, "$data['payload']", 0)))
+		} else {
+			parts = append(parts, fmt.Sprintf("        return new %s();", className))
+		}
+	}
+	parts = append(parts, "      case null:")
+	parts = append(parts, "        throw new \\InvalidArgumentException(\"missing 'type' field\");")
+	parts = append(parts, "      default:")
+	parts = append(parts, "        throw new \\InvalidArgumentException('unknown type: '.($type as string));")
+	parts = append(parts, "    }")
+	parts = append(parts, "  }")
+	parts = append(parts, "}")
+
+	// Variant subclasses
+	for _, variant := range e.Variants {
+		className := variantClassName(e, variant)
+		parts = append(parts, "")
+		parts = append(parts, fmt.Sprintf("final class %s extends %s {", className, e.Name))
+
+		if variant.Payload != nil {
+			hackType, err := g.generateType(variant.Payload, false)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, fmt.Sprintf("  public function __construct(public %s $payload)[] {}", hackType))
+			parts = append(parts, "")
+			parts = append(parts, "  <<__Override>>")
+			parts = append(parts, "  public function toDict(): dict<string, mixed> {")
+			parts = append(parts, fmt.Sprintf("    return dict['type' => '%s', 'payload' => %s];", variant.JSONName(), g.encodeExpr(variant.Payload, "$this->payload", 0)))
+			parts = append(parts, "  }")
+		} else {
+			parts = append(parts, "  <<__Override>>")
+			parts = append(parts, "  public function toDict(): dict<string, mixed> {")
+			parts = append(parts, fmt.Sprintf("    return dict['type' => '%s'];", variant.JSONName()))
+			parts = append(parts, "  }")
+		}
+
+		parts = append(parts, "}")
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateTypeAlias generates a Hack type alias
+func (g *Generator) generateTypeAlias(t *ast.TypeAliasNode) (string, error) {
+	hackType, err := g.generateType(t.Type, false)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("type %s = %s;", t.Name, hackType), nil
+}
+
+// generateConstants collects a file's constants into one abstract final class.
+// The class is named after the file so that several files sharing a namespace don't collide.
+func (g *Generator) generateConstants(constants []*ast.ConstantNode, filename string) (string, error) {
+	var parts []string
+	parts = append(parts, fmt.Sprintf("abstract final class %sConstants {", toPascalCase(strings.TrimSuffix(filename, ".tg"))))
+
+	for _, c := range constants {
+		switch value := c.Value.(type) {
+		case *ast.IntConstant:
+			parts = append(parts, fmt.Sprintf("  const int %s = %d;", c.Name, value.Value))
+		case *ast.StringConstant:
+			parts = append(parts, fmt.Sprintf("  const string %s = %s;", c.Name, quote(value.Value)))
+		case *ast.FloatConstant:
+			parts = append(parts, fmt.Sprintf("  const float %s = %s;", c.Name, value.Decimal()))
+		case *ast.BoolConstant:
+			parts = append(parts, fmt.Sprintf("  const bool %s = %t;", c.Name, value.Value))
+		default:
+			return "", fmt.Errorf("unsupported constant value type: %T", value)
+		}
+	}
+
+	parts = append(parts, "}")
+	return strings.Join(parts, "\n"), nil
+}
+
+// generateType converts a TypeGen type to a Hack type
+func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
+	var baseType string
+
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		hackType, err := mapPrimitiveType(typ.Name)
+		if err != nil {
+			return "", err
+		}
+		baseType = hackType
+	case *ast.NamedType:
+		baseType = g.qualifyName(typ.Name)
+	case *ast.ArrayType:
+		elementType, err := g.generateType(typ.ElementType, false)
+		if err != nil {
+			return "", err
+		}
+		baseType = fmt.Sprintf("vec<%s>", elementType)
+	case *ast.MapType:
+		keyType, err := g.generateType(typ.KeyType, false)
+		if err != nil {
+			return "", err
+		}
+		valueType, err := g.generateType(typ.ValueType, false)
+		if err != nil {
+			return "", err
+		}
+		baseType = fmt.Sprintf("dict<%s, %s>", keyType, valueType)
+	case *ast.OptionalType:
+		return g.generateType(typ.ElementType, true)
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+
+	if optional && baseType != "mixed" {
+		return "?" + baseType, nil
+	}
+	return baseType, nil
+}
+
+// decodeExpr returns a Hack expression converting the JSON-decoded value expr into type t
+func (g *Generator) decodeExpr(t ast.Type, expr string, depth int) string {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		// Unsupported primitives are reported by generateType
+		hackType, _ := mapPrimitiveType(typ.Name)
+		switch hackType {
+		case "mixed":
+			return expr
+		case "float":
+			return fmt.Sprintf("(float)(%s as num)", expr)
+		default:
+			return fmt.Sprintf("%s as %s", expr, hackType)
+		}
+	case *ast.NamedType:
+		decl := g.resolve(typ.Name)
+		name := g.qualifyName(typ.Name)
+		switch d := decl.(type) {
+		case *ast.TypeAliasNode:
+			return g.decodeExpr(d.Type, expr, depth)
+		case *ast.EnumNode:
+			if !hasPayloads(d) {
+				return fmt.Sprintf("%sJson::fromDict(%s as KeyedContainer<_, _>)", name, expr)
+			}
+		case *ast.StructNode:
+			if g.style() == "shape" {
+				return fmt.Sprintf("%s as %s", expr, name)
+			}
+		}
+		return fmt.Sprintf("%s::fromDict(%s as KeyedContainer<_, _>)", name, expr)
+	case *ast.ArrayType:
+		g.libs["Vec"] = true
+		v := fmt.Sprintf("$v%d", depth)
+		return fmt.Sprintf("Vec\\map(%s as Traversable<_>, %s ==> %s)", expr, v, g.decodeExpr(typ.ElementType, v, depth+1))
+	case *ast.MapType:
+		g.libs["Dict"] = true
+		k := fmt.Sprintf("$k%d", depth)
+		v := fmt.Sprintf("$v%d", depth)
+		return fmt.Sprintf("Dict\\pull_with_key(%s as KeyedContainer<_, _>, (%s, %s) ==> %s, (%s, %s) ==> %s)",
+			expr, k, v, g.decodeExpr(typ.ValueType, v, depth+1), k, v, g.decodeExpr(typ.KeyType, k, depth+1))
+	case *ast.OptionalType:
+		return fmt.Sprintf("%s is null ? null : %s", expr, g.decodeExpr(typ.ElementType, expr, depth))
+	default:
+		return expr
+	}
+}
+
+// encodeExpr returns a Hack expression converting expr of type t into a JSON-encodable value
+func (g *Generator) encodeExpr(t ast.Type, expr string, depth int) string {
+	switch typ := t.(type) {
+	case *ast.NamedType:
+		decl := g.resolve(typ.Name)
+		switch d := decl.(type) {
+		case *ast.TypeAliasNode:
+			return g.encodeExpr(d.Type, expr, depth)
+		case *ast.EnumNode:
+			if !hasPayloads(d) {
+				return fmt.Sprintf("%sJson::toDict(%s)", g.qualifyName(typ.Name), expr)
+			}
+		case *ast.StructNode:
+			if g.style() == "shape" {
+				return expr
+			}
+		}
+		return fmt.Sprintf("%s->toDict()", expr)
+	case *ast.ArrayType:
+		if isPlain(typ.ElementType, g) {
+			return expr
+		}
+		g.libs["Vec"] = true
+		v := fmt.Sprintf("$v%d", depth)
+		return fmt.Sprintf("Vec\\map(%s, %s ==> %s)", expr, v, g.encodeExpr(typ.ElementType, v, depth+1))
+	case *ast.MapType:
+		if isPlain(typ.ValueType, g) {
+			return expr
+		}
+		g.libs["Dict"] = true
+		v := fmt.Sprintf("$v%d", depth)
+		return fmt.Sprintf("Dict\\map(%s, %s ==> %s)", expr, v, g.encodeExpr(typ.ValueType, v, depth+1))
+	case *ast.OptionalType:
+		if isPlain(typ.ElementType, g) {
+			return expr
+		}
+		return fmt.Sprintf("%s is null ? null : %s", expr, g.encodeExpr(typ.ElementType, expr, depth))
+	default:
+		return expr
+	}
+}
+
+// isPlain reports whether values of type t are already JSON-encodable without conversion
+func isPlain(t ast.Type, g *Generator) bool {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return true
+	case *ast.NamedType:
+		if alias, ok := g.resolve(typ.Name).(*ast.TypeAliasNode); ok {
+			return isPlain(alias.Type, g)
+		}
+		if _, ok := g.resolve(typ.Name).(*ast.StructNode); ok {
+			return g.style() == "shape"
+		}
+		return false
+	case *ast.ArrayType:
+		return isPlain(typ.ElementType, g)
+	case *ast.MapType:
+		return isPlain(typ.ValueType, g)
+	case *ast.OptionalType:
+		return isPlain(typ.ElementType, g)
+	default:
+		return false
+	}
+}
+
+// resolve finds the declaration a named type refers to, or nil if it isn't declared.
+// Bare names resolve in the module of the current file, qualified names in the
+// submodule their import alias points to.
+func (g *Generator) resolve(name string) ast.Declaration {
+	module := g.current
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		importPath, ok := g.imports[name[:idx]]
+		if !ok {
+			return nil
+		}
+		module = g.module
+		for _, part := range strings.Split(importPath, ".") {
+			if module == nil {
+				return nil
+			}
+			module = module.SubModules[part]
+		}
+		name = name[idx+1:]
+	}
+
+	if module == nil {
+		return nil
+	}
+	for _, filename := range module.FileNames() {
+		for _, decl := range module.Files[filename].Declarations {
+			if decl.DeclName() == name {
+				return decl
+			}
+		}
+	}
+	return nil
+}
+
+// qualifyName converts a TypeGen type reference into a Hack class/type name.
+// Qualified references (auth.Token) become fully qualified namespace paths.
+func (g *Generator) qualifyName(name string) string {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return name
+	}
+
+	importPath, ok := g.imports[parts[0]]
+	if !ok {
+		importPath = parts[0]
+	}
+
+	ns := joinNamespace(g.config["namespace"], strings.ReplaceAll(importPath, ".", "\\"))
+	return fmt.Sprintf("\\%s\\%s", ns, parts[1])
+}
+
+// mapPrimitiveType maps TypeGen primitive types to Hack types
+func mapPrimitiveType(typeName string) (string, error) {
+	switch typeName {
+	case "bool":
+		return "bool", nil
+	case "string":
+		return "string", nil
+	case "int", "int8", "int16", "int32", "int64", "nat", "nat8", "nat16", "nat32", "nat64":
+		return "int", nil
+	case "float32", "float64":
+		return "float", nil
+	case "decimal", "bigint", "bignat":
+		return "string", nil // Exact decimal strings, as on the wire
+	case "uuid":
+		return "string", nil // Canonical hyphenated form, as on the wire
+	case "json":
+		return "mixed", nil
+	case "time", "date", "datetime", "timetz", "datetz", "datetimetz":
+		return "string", nil // ISO 8601 strings, matching the JSON wire format
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// variantClassName returns the subclass name for a tagged union variant
+func variantClassName(e *ast.EnumNode, variant *ast.EnumVariantNode) string {
+	return fmt.Sprintf("%s_%s", e.Name, toPascalCase(variant.Name))
+}
+
+// hasPayloads reports whether any variant of the enum carries a payload
+func hasPayloads(e *ast.EnumNode) bool {
+	for _, variant := range e.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// joinNamespace joins namespace segments with backslashes, skipping empty segments
+func joinNamespace(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, strings.Trim(part, "\\"))
+		}
+	}
+	return strings.Join(nonEmpty, "\\")
+}
+
+// quote returns a single-quoted Hack string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "'", "\\'")
+	return "'" + s + "'"
+}
+
+// toPascalCase converts snake_case to PascalCase for Hack class names
+func toPascalCase(name string) string {
+	return (&naming.Caser{KeepCase: true}).PascalCase(name)
+}
+
+func init() {
+	// Register the Hack generator globally
+	generators.Register("hack", func() generators.Generator {
+		return NewGenerator()
+	})
+	// Fields are $-prefixed properties or shape keys, so only class and type
+	// names can clash with a keyword
+	validator.RegisterReservedWords("hack", validator.ReservedWords{
+		Language:   "Hack",
+		Words:      naming.HackKeywords,
+		IgnoreCase: true,
+		Names:      []string{validator.TypeNames},
+	})
+}
@@ -0,0 +1,391 @@
+package hack
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// generateFile parses input as a single-file module and returns the generated .hack content
+func generateFile(t *testing.T, input string, config map[string]string) string {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	result, exists := fs.GetFileString("test.hack")
+	if !exists {
+		t.Fatal("test.hack should have been generated")
+	}
+	return result
+}
+
+// assertGolden compares content against testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name, content string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if string(expected) != content {
+		t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", path, expected, content)
+	}
+}
+
+func TestGenerateStructClass(t *testing.T) {
+	input := `struct User {
+		id: int64
+		name: string
+		email: ?string
+		tags: []string
+		scores: [string]float64
+	}`
+
+	result := generateFile(t, input, map[string]string{"namespace": "Acme\\Api"})
+	assertGolden(t, "struct_class", result)
+}
+
+func TestGenerateStructShape(t *testing.T) {
+	input := `struct User {
+		id: int64
+		email: ?string
+	}`
+
+	result := generateFile(t, input, map[string]string{"style": "shape"})
+	assertGolden(t, "struct_shape", result)
+}
+
+func TestGenerateSimpleEnum(t *testing.T) {
+	input := `enum Status {
+		active
+		inactive
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "simple_enum", result)
+}
+
+func TestGenerateTaggedUnion(t *testing.T) {
+	input := `struct Failure {
+		code: int32
+	}
+
+	enum Result {
+		success: string
+		error: Failure
+		items: []Failure
+		pending
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "tagged_union", result)
+}
+
+func TestGenerateAliasesAndConstants(t *testing.T) {
+	input := `type UserID = int64
+	type Tags = []string
+	const MAX_USERS = 100
+	const API_VERSION = "v1"`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "aliases_constants", result)
+}
+
+func TestGenerateFloatConstants(t *testing.T) {
+	input := `const RATIO = 0.75
+	const AVOGADRO = 6.022e23
+	const EPSILON = 1e-9
+	const OFFSET = -2.5
+	const WHOLE = 3.0`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"  const float RATIO = 0.75;",
+		"  const float AVOGADRO = 6.022e23;",
+		"  const float EPSILON = 1e-9;",
+		"  const float OFFSET = -2.5;",
+		"  const float WHOLE = 3.0;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBoolConstants(t *testing.T) {
+	input := `const ENABLED = true
+	const DEPRECATED = false`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"  const bool ENABLED = true;",
+		"  const bool DEPRECATED = false;",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateEnumFieldsUseJSONHelpers(t *testing.T) {
+	input := `enum Status {
+		active
+	}
+
+	type StatusList = []Status
+
+	struct Account {
+		status: Status
+		history: StatusList
+		previous: ?Status
+	}`
+
+	result := generateFile(t, input, nil)
+
+	expected := []string{
+		"StatusJson::fromDict($data['status'] as KeyedContainer<_, _>)",
+		"Vec\\map($data['history'] as Traversable<_>, $v0 ==> StatusJson::fromDict($v0 as KeyedContainer<_, _>))",
+		"$result['status'] = StatusJson::toDict($this->status);",
+		"$result['history'] = Vec\\map($this->history, $v0 ==> StatusJson::toDict($v0));",
+		"use namespace HH\\Lib\\{Vec};",
+	}
+
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateQualifiedType(t *testing.T) {
+	input := `import api.auth
+
+	struct Session {
+		token: auth.Token
+	}`
+
+	result := generateFile(t, input, map[string]string{"namespace": "Acme"})
+
+	if !strings.Contains(result, "public \\Acme\\api\\auth\\Token $token,") {
+		t.Errorf("Expected fully qualified type for auth.Token, got:\n%s", result)
+	}
+}
+
+func TestGenerateSubmoduleNamespaces(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`struct Root { id: int64 }`), "root.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	sub, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"root.tg": root})
+	module.SubModules["auth"] = ast.NewModule("api/auth", map[string]*ast.ProgramNode{"token.tg": sub})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"namespace": "Acme"})
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	rootCode, exists := fs.GetFileString("root.hack")
+	if !exists || !strings.Contains(rootCode, "namespace Acme;") {
+		t.Errorf("Expected root.hack in namespace Acme, got:\n%s", rootCode)
+	}
+
+	subCode, exists := fs.GetFileString("auth/token.hack")
+	if !exists || !strings.Contains(subCode, "namespace Acme\\auth;") {
+		t.Errorf("Expected auth/token.hack in namespace Acme\\auth, got:\n%s", subCode)
+	}
+}
+
+func TestGenerateSameNameInTwoModules(t *testing.T) {
+	root, err := parser.Parse(strings.NewReader(`import auth
+
+	enum Status {
+		active
+		banned
+	}
+
+	struct Session {
+		status: Status
+		token: auth.Status
+	}`), "session.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	sub, err := parser.Parse(strings.NewReader(`struct Status { value: string }`), "status.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"session.tg": root})
+	module.SubModules["auth"] = ast.NewModule("api/auth", map[string]*ast.ProgramNode{"status.tg": sub})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"namespace": "Acme"})
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("session.hack")
+
+	// auth.Status is the struct in the auth submodule, not the root enum
+	expected := []string{
+		"StatusJson::fromDict($data['status'] as KeyedContainer<_, _>)",
+		"\\Acme\\auth\\Status::fromDict($data['token'] as KeyedContainer<_, _>)",
+		"$result['token'] = $this->token->toDict();",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateInvalidStyle(t *testing.T) {
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{})
+
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"style": "record"})
+
+	err := generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "unsupported style") {
+		t.Errorf("Expected unsupported style error, got: %v", err)
+	}
+}
+
+func TestGeneratePrimitives(t *testing.T) {
+	input := `struct Primitives {
+		flag: bool
+		name: string
+		count: int
+		total: nat
+		labels: [int]string
+		flags: [nat]bool
+		tiny: int8
+		small: int16
+		medium: int32
+		large: int64
+		tiny_nat: nat8
+		small_nat: nat16
+		medium_nat: nat32
+		large_nat: nat64
+		ratio: float32
+		score: float64
+		price: decimal
+		balance: bigint
+		population: bignat
+		id: uuid
+		owners: [uuid]string
+		payload: json
+		day: date
+		clock: time
+		moment: datetime
+		day_tz: datetz
+		clock_tz: timetz
+		moment_tz: datetimetz
+	}`
+
+	result := generateFile(t, input, nil)
+	assertGolden(t, "primitives", result)
+}
+
+func TestGenerateUnsupportedPrimitive(t *testing.T) {
+	program := &ast.ProgramNode{Declarations: []ast.Declaration{
+		&ast.StructNode{Name: "Wide", Fields: []*ast.FieldNode{
+			{Name: "value", Type: &ast.PrimitiveType{Name: "int128"}},
+		}},
+	}}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	err := NewGenerator().Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "unsupported primitive type int128") {
+		t.Errorf("Expected unsupported primitive type error, got: %v", err)
+	}
+}
+
+func TestReservedWords(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader(`struct Function {
+		class: string
+		list: []string
+	}
+
+	struct Vec {
+		id: int64
+	}
+
+	enum Shape {
+		circle
+	}`), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	v := validator.NewValidator()
+	v.SetGenerators([]string{"hack"})
+	var messages []string
+	for _, err := range v.Validate(module).Errors {
+		if err.Type == validator.ReservedWordError {
+			messages = append(messages, fmt.Sprintf("%s:%d:%d %s", err.File, err.Line, err.Column, err.Message))
+		}
+	}
+	sort.Strings(messages)
+
+	// Fields are properties or shape keys, which may be keywords, but class names
+	// clash in any case.
+	expected := []string{
+		"test.tg:1:1 struct 'Function' is a reserved word in Hack, the target language of generator 'hack'",
+		"test.tg:6:2 struct 'Vec' is a reserved word in Hack, the target language of generator 'hack'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected reserved words:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
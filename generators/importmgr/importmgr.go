@@ -0,0 +1,180 @@
+// Package importmgr collects the imports of a generated file and renders
+// them in the conventions of the target language. Generators add an import
+// wherever they emit code that needs it; the manager removes duplicates and
+// renders the imports in a deterministic order, whatever order they were
+// added in.
+package importmgr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Style is how Render writes the imports
+type Style int
+
+const (
+	// Go writes a Go import declaration: a single import on one line,
+	// several in a parenthesized block with the standard library packages
+	// first, separated from the others by a blank line
+	Go Style = iota
+	// GoFlat is Go with all packages in a single sorted group
+	GoFlat
+	// Python writes an "import module" statement per module imported as a
+	// whole, then a "from module import a, b" statement per module with its
+	// symbols merged and sorted
+	Python
+	// PythonSeparate is Python with a from-import statement per Add call,
+	// so "from typing import List" and "from typing import Dict" stay apart
+	PythonSeparate
+)
+
+// Manager collects the imports of a generated file. The zero value is not
+// usable; create managers with New.
+type Manager struct {
+	modules map[string]*module
+}
+
+// module is the imports from a module
+type module struct {
+	path   string
+	stdlib bool
+	// whole is set when the module itself is imported
+	whole bool
+	// symbols are the symbols imported from the module
+	symbols map[string]bool
+	// groups are the symbols of each Add call, joined by ", "
+	groups map[string]bool
+}
+
+// New returns an empty Manager
+func New() *Manager {
+	return &Manager{modules: make(map[string]*module)}
+}
+
+// Add records an import. With no symbols the module itself is imported:
+// a Go package path, or a Python module for "import module". With symbols,
+// Python imports them from the module, and Go writes each symbol as the
+// name the package is imported under. A symbol may carry a Python alias,
+// e.g. "factories as auth_factories".
+func (m *Manager) Add(path string, symbols ...string) {
+	m.add(path, false, symbols)
+}
+
+// AddStdlib records an import of a standard library package or module. The
+// Go style renders the standard library in its own group.
+func (m *Manager) AddStdlib(path string, symbols ...string) {
+	m.add(path, true, symbols)
+}
+
+func (m *Manager) add(path string, stdlib bool, symbols []string) {
+	mod, ok := m.modules[path]
+	if !ok {
+		mod = &module{path: path, symbols: make(map[string]bool), groups: make(map[string]bool)}
+		m.modules[path] = mod
+	}
+	mod.stdlib = mod.stdlib || stdlib
+	if len(symbols) == 0 {
+		mod.whole = true
+		return
+	}
+	for _, symbol := range symbols {
+		mod.symbols[symbol] = true
+	}
+	mod.groups[strings.Join(symbols, ", ")] = true
+}
+
+// Len returns the number of modules imported
+func (m *Manager) Len() int {
+	return len(m.modules)
+}
+
+// Render returns the imports written in a style, without a trailing
+// newline, or "" if there are none
+func (m *Manager) Render(style Style) string {
+	switch style {
+	case GoFlat:
+		return m.renderGo(false)
+	case Python:
+		return m.renderPython(true)
+	case PythonSeparate:
+		return m.renderPython(false)
+	default:
+		return m.renderGo(true)
+	}
+}
+
+// sorted returns the modules ordered by path
+func (m *Manager) sorted() []*module {
+	modules := make([]*module, 0, len(m.modules))
+	for _, mod := range m.modules {
+		modules = append(modules, mod)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].path < modules[j].path })
+	return modules
+}
+
+func (m *Manager) renderGo(grouped bool) string {
+	var stdlib, other []string
+	for _, mod := range m.sorted() {
+		var specs []string
+		if mod.whole {
+			specs = append(specs, fmt.Sprintf("%q", mod.path))
+		}
+		for _, name := range sortedKeys(mod.symbols) {
+			specs = append(specs, fmt.Sprintf("%s %q", name, mod.path))
+		}
+		if grouped && mod.stdlib {
+			stdlib = append(stdlib, specs...)
+		} else {
+			other = append(other, specs...)
+		}
+	}
+
+	switch specs := len(stdlib) + len(other); {
+	case specs == 0:
+		return ""
+	case specs == 1:
+		return "import " + strings.Join(append(stdlib, other...), "")
+	}
+	var groups []string
+	for _, group := range [][]string{stdlib, other} {
+		if len(group) > 0 {
+			groups = append(groups, "\t"+strings.Join(group, "\n\t"))
+		}
+	}
+	return "import (\n" + strings.Join(groups, "\n\n") + "\n)"
+}
+
+func (m *Manager) renderPython(merged bool) string {
+	var imports, fromImports []string
+	for _, mod := range m.sorted() {
+		if mod.whole {
+			imports = append(imports, "import "+mod.path)
+		}
+		if len(mod.symbols) == 0 {
+			continue
+		}
+		if merged {
+			fromImports = append(fromImports, fmt.Sprintf("from %s import %s", mod.path, strings.Join(sortedKeys(mod.symbols), ", ")))
+			continue
+		}
+		for _, group := range sortedKeys(mod.groups) {
+			fromImports = append(fromImports, fmt.Sprintf("from %s import %s", mod.path, group))
+		}
+	}
+	if !merged {
+		sort.Strings(fromImports)
+	}
+	return strings.Join(append(imports, fromImports...), "\n")
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
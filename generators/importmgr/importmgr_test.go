@@ -0,0 +1,93 @@
+package importmgr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// addition is a call to Add or AddStdlib
+type addition struct {
+	stdlib  bool
+	path    string
+	symbols []string
+}
+
+func std(path string, symbols ...string) addition {
+	return addition{stdlib: true, path: path, symbols: symbols}
+}
+
+func ext(path string, symbols ...string) addition {
+	return addition{path: path, symbols: symbols}
+}
+
+func TestRender(t *testing.T) {
+	goImports := []addition{
+		std("time"), ext("example.com/app/auth"), std("encoding/json"), std("time"),
+		ext("example.com/app/typegen"), std("fmt"), ext("example.com/app/auth"),
+	}
+	pythonImports := []addition{
+		std("typing", "List"), ext("pydantic", "BaseModel"), std("typing", "Optional"),
+		std("typing", "List"), ext("pydantic_core", "CoreSchema", "core_schema"),
+		std("random"), ext("pydantic", "Field"), std("datetime", "datetime"),
+		ext("app.auth", "factories as auth_factories"), std("string"),
+	}
+
+	tests := []struct {
+		name      string
+		additions []addition
+		style     Style
+		expected  string
+	}{
+		{"go empty", nil, Go, ""},
+		{"go single", []addition{std("time"), std("time")}, Go, `import "time"`},
+		{"go", goImports, Go, "import (\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"time\"\n\n\t\"example.com/app/auth\"\n\t\"example.com/app/typegen\"\n)"},
+		{"go flat", goImports, GoFlat, "import (\n\t\"encoding/json\"\n\t\"example.com/app/auth\"\n\t\"example.com/app/typegen\"\n\t\"fmt\"\n\t\"time\"\n)"},
+		{"go stdlib only", []addition{std("fmt"), std("strings")}, Go, "import (\n\t\"fmt\"\n\t\"strings\"\n)"},
+		{"go named", []addition{ext("example.com/app/auth/v2", "auth"), ext("example.com/app/auth/v2")}, Go, "import (\n\t\"example.com/app/auth/v2\"\n\tauth \"example.com/app/auth/v2\"\n)"},
+		{"python empty", nil, Python, ""},
+		{
+			"python", pythonImports, Python,
+			"import random\nimport string\n" +
+				"from app.auth import factories as auth_factories\nfrom datetime import datetime\nfrom pydantic import BaseModel, Field\n" +
+				"from pydantic_core import CoreSchema, core_schema\nfrom typing import List, Optional",
+		},
+		{
+			"python separate", pythonImports, PythonSeparate,
+			"import random\nimport string\n" +
+				"from app.auth import factories as auth_factories\nfrom datetime import datetime\nfrom pydantic import BaseModel\nfrom pydantic import Field\n" +
+				"from pydantic_core import CoreSchema, core_schema\nfrom typing import List\nfrom typing import Optional",
+		},
+		{"python module and symbols", []addition{std("datetime", "date"), std("datetime")}, Python, "import datetime\nfrom datetime import date"},
+	}
+	for _, tt := range tests {
+		// The order of the additions must not matter
+		rng := rand.New(rand.NewSource(1))
+		for attempt := 0; attempt < 5; attempt++ {
+			m := New()
+			for _, i := range rng.Perm(len(tt.additions)) {
+				if a := tt.additions[i]; a.stdlib {
+					m.AddStdlib(a.path, a.symbols...)
+				} else {
+					m.Add(a.path, a.symbols...)
+				}
+			}
+			if got := m.Render(tt.style); got != tt.expected {
+				t.Errorf("%s: expected:\n%s\ngot:\n%s", tt.name, tt.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	m := New()
+	if m.Len() != 0 {
+		t.Errorf("expected no imports, got %d", m.Len())
+	}
+	m.Add("typing", "List")
+	m.AddStdlib("typing", "Dict")
+	m.Add("pydantic")
+	if m.Len() != 2 {
+		t.Errorf("expected 2 imported modules, got %d", m.Len())
+	}
+}
@@ -0,0 +1,270 @@
+package generators
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/parser/printer"
+)
+
+// FileProcessor post-processes generated files between generation and the
+// write, such as a formatter; see NewProcessingFS
+type FileProcessor interface {
+	// Name identifies the processor in errors
+	Name() string
+
+	// Match reports whether the processor applies to a file, given its
+	// slash-separated path relative to the output directory
+	Match(path string) bool
+
+	// Process returns the processed content of a file
+	Process(data []byte) ([]byte, error)
+}
+
+// MatchPattern reports whether a slash-separated path matches a glob
+// pattern with the syntax of path.Match. A pattern without a slash is
+// matched against the file name, so "*.go" matches Go files in any
+// directory; other patterns are matched against the whole path. The empty
+// pattern matches every file.
+func MatchPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// CheckPattern returns an error if pattern is not a valid MatchPattern pattern
+func CheckPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// processingFS applies processors to the files written through it
+type processingFS struct {
+	FS
+	processors []FileProcessor
+}
+
+// NewProcessingFS wraps fs so that the content of every file written is
+// passed through the processors that match it, in order. A failure names
+// the file and the processor, and the file isn't written.
+func NewProcessingFS(fs FS, processors ...FileProcessor) FS {
+	if len(processors) == 0 {
+		return fs
+	}
+	return &processingFS{FS: fs, processors: processors}
+}
+
+// WriteFile implements FS.WriteFile
+func (fs *processingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	_, err := fs.WriteFileChanged(name, data, perm)
+	return err
+}
+
+// WriteFileChanged implements ChangeFS.WriteFileChanged
+func (fs *processingFS) WriteFileChanged(name string, data []byte, perm os.FileMode) (bool, error) {
+	slashName := filepath.ToSlash(name)
+	for _, processor := range fs.processors {
+		if !processor.Match(slashName) {
+			continue
+		}
+		processed, err := processor.Process(data)
+		if err != nil {
+			return false, fmt.Errorf("post-processing %s with %s: %w", slashName, processor.Name(), err)
+		}
+		data = processed
+	}
+	return writeFileChanged(fs.FS, name, data, perm)
+}
+
+// gofmtProcessor formats Go source in-process
+type gofmtProcessor struct{}
+
+// GofmtProcessor returns the processor formatting .go files like gofmt
+func GofmtProcessor() FileProcessor {
+	return gofmtProcessor{}
+}
+
+// Name implements FileProcessor.Name
+func (gofmtProcessor) Name() string { return "gofmt" }
+
+// Match implements FileProcessor.Match
+func (gofmtProcessor) Match(name string) bool { return MatchPattern("*.go", name) }
+
+// Process implements FileProcessor.Process
+func (gofmtProcessor) Process(data []byte) ([]byte, error) {
+	return format.Source(data)
+}
+
+// newlinesProcessor normalizes line endings
+type newlinesProcessor struct{}
+
+// NewlinesProcessor returns the processor normalizing line endings of every
+// file: CRLF and CR become LF, and non-empty files end with exactly one
+// newline
+func NewlinesProcessor() FileProcessor {
+	return newlinesProcessor{}
+}
+
+// Name implements FileProcessor.Name
+func (newlinesProcessor) Name() string { return "newlines" }
+
+// Match implements FileProcessor.Match
+func (newlinesProcessor) Match(string) bool { return true }
+
+// Process implements FileProcessor.Process
+func (newlinesProcessor) Process(data []byte) ([]byte, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return data, nil
+	}
+	return append(data, '\n'), nil
+}
+
+// fingerprintProcessor adds the fingerprint of the module to the generated
+// header of files
+type fingerprintProcessor struct {
+	module      *ast.Module
+	once        sync.Once
+	fingerprint string
+	err         error
+}
+
+// FingerprintProcessor returns the processor adding a "Schema fingerprint:"
+// line, holding printer.Fingerprint of the module, right after the
+// GeneratedHeader comment within the first lines of every file. Files
+// without the header are left unchanged. The fingerprint is computed once, for the first file.
+func FingerprintProcessor(module *ast.Module) FileProcessor {
+	return &fingerprintProcessor{module: module}
+}
+
+// Name implements FileProcessor.Name
+func (*fingerprintProcessor) Name() string { return "fingerprint" }
+
+// Match implements FileProcessor.Match
+func (*fingerprintProcessor) Match(string) bool { return true }
+
+// Process implements FileProcessor.Process
+func (p *fingerprintProcessor) Process(data []byte) ([]byte, error) {
+	p.once.Do(func() {
+		p.fingerprint, p.err = printer.Fingerprint(p.module)
+	})
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	// The line repeats the header's comment markers, e.g. "// " or "<!-- " and " -->"
+	lines := strings.SplitAfter(string(data), "\n")
+	for i := 0; i < len(lines) && i < 5; i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		index := strings.Index(line, GeneratedHeader)
+		if index < 0 {
+			continue
+		}
+		newline := lines[i][len(line):]
+		if newline == "" {
+			newline = "\n"
+			lines[i] += newline
+		}
+		stamp := line[:index] + "Schema fingerprint: " + p.fingerprint + line[index+len(GeneratedHeader):] + newline
+		lines = append(lines[:i+1], append([]string{stamp}, lines[i+1:]...)...)
+		return []byte(strings.Join(lines, "")), nil
+	}
+	return data, nil
+}
+
+// DefaultProcessTimeout is how long an ExecProcessor's command may run for
+// each file when it doesn't set a timeout
+const DefaultProcessTimeout = time.Minute
+
+// ExecProcessor pipes the content of files through an external command,
+// whose standard output replaces it, such as goimports or "black -q -"
+type ExecProcessor struct {
+	// Args are the command and its arguments
+	Args []string
+	// Pattern selects the files processed, see MatchPattern
+	Pattern string
+	// Dir is the working directory of the command; empty means the
+	// current directory
+	Dir string
+	// Env is the environment of the command, empty for the current one
+	Env []string
+	// Timeout is how long the command may run for each file, by default
+	// DefaultProcessTimeout
+	Timeout time.Duration
+	// Label names the processor in errors; empty means the command line
+	Label string
+}
+
+// Name implements FileProcessor.Name
+func (p *ExecProcessor) Name() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return strings.Join(p.Args, " ")
+}
+
+// Match implements FileProcessor.Match
+func (p *ExecProcessor) Match(name string) bool {
+	return MatchPattern(p.Pattern, name)
+}
+
+// Process implements FileProcessor.Process. The command fails when it exits
+// with an error or writes nothing for a non-empty file, and its standard
+// error is part of the error, indented under it.
+func (p *ExecProcessor) Process(data []byte) ([]byte, error) {
+	if len(p.Args) == 0 {
+		return nil, errors.New("no command")
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultProcessTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Args[0], p.Args[1:]...)
+	cmd.Dir = p.Dir
+	if len(p.Env) > 0 {
+		cmd.Env = p.Env
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	detail := ""
+	if text := strings.TrimSpace(stderr.String()); text != "" {
+		detail = "\n    " + strings.ReplaceAll(text, "\n", "\n    ")
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("timed out after %s%s", timeout, detail)
+	case err != nil:
+		return nil, fmt.Errorf("%w%s", err, detail)
+	case stdout.Len() == 0 && len(data) > 0:
+		return nil, fmt.Errorf("command wrote nothing%s", detail)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,210 @@
+package generators
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/parser/printer"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"", "api/user.go", true},
+		{"*.go", "user.go", true},
+		{"*.go", "api/user.go", true},
+		{"*.go", "api/user.py", false},
+		{"api/*.go", "api/user.go", true},
+		{"api/*.go", "user.go", false},
+		{"api/*.go", "api/v1/user.go", false},
+	}
+	for _, test := range tests {
+		if got := MatchPattern(test.pattern, test.name); got != test.expected {
+			t.Errorf("MatchPattern(%q, %q) = %v, expected %v", test.pattern, test.name, got, test.expected)
+		}
+	}
+	if err := CheckPattern("[a-"); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestGofmtProcessor(t *testing.T) {
+	p := GofmtProcessor()
+	if !p.Match("api/user.go") || p.Match("api/user.py") {
+		t.Error("expected gofmt to match .go files only")
+	}
+
+	out, err := p.Process([]byte("package api\ntype User struct{\nID int64\nName string}\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "package api\n\ntype User struct {\n\tID   int64\n\tName string\n}\n"
+	if string(out) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	if _, err := p.Process([]byte("package api\nfunc {")); err == nil {
+		t.Error("expected an error for invalid Go")
+	}
+}
+
+func TestNewlinesProcessor(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"a\rb", "a\nb\n"},
+		{"a\n\n\n", "a\n"},
+		{"a  \n", "a  \n"},
+		{"\n\n", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		out, err := NewlinesProcessor().Process([]byte(test.input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(out) != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.input, test.expected, out)
+		}
+	}
+}
+
+func TestExecProcessor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec processor tests use a shell")
+	}
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr not found")
+	}
+
+	tests := []struct {
+		name     string
+		p        *ExecProcessor
+		input    string
+		expected string
+		err      string
+	}{
+		{"filter", &ExecProcessor{Args: []string{"tr", "a-z", "A-Z"}}, "user\n", "USER\n", ""},
+		{"environment", &ExecProcessor{Args: []string{"sh", "-c", `echo "$LANG_NAME"`}, Env: []string{"LANG_NAME=go"}}, "x", "go\n", ""},
+		{"failure", &ExecProcessor{Args: []string{"sh", "-c", "echo bad input >&2; exit 2"}}, "x", "", "exit status 2\n    bad input"},
+		{"no output", &ExecProcessor{Args: []string{"sh", "-c", "cat >/dev/null"}}, "x", "", "command wrote nothing"},
+		{"empty file", &ExecProcessor{Args: []string{"sh", "-c", "cat >/dev/null"}}, "", "", ""},
+		{"timeout", &ExecProcessor{Args: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond}, "x", "", "timed out after 50ms"},
+		{"no command", &ExecProcessor{}, "x", "", "no command"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := test.p.Process([]byte(test.input))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, out)
+			}
+		})
+	}
+
+	p := &ExecProcessor{Args: []string{"tr", "a-z", "A-Z"}, Pattern: "*.txt"}
+	if p.Name() != "tr a-z A-Z" || !p.Match("docs/a.txt") || p.Match("a.go") {
+		t.Errorf("unexpected name %q or pattern", p.Name())
+	}
+}
+
+// failingProcessor fails on every file
+type failingProcessor struct{}
+
+func (failingProcessor) Name() string                   { return "failing" }
+func (failingProcessor) Match(string) bool              { return true }
+func (failingProcessor) Process([]byte) ([]byte, error) { return nil, errors.New("broken") }
+
+func TestProcessingFS(t *testing.T) {
+	mem := NewInMemoryFS()
+	fs := NewProcessingFS(mem, NewlinesProcessor(), GofmtProcessor())
+
+	if err := fs.WriteFile(fs.Join("api", "user.go"), []byte("package api\r\nvar X=1\r\n\r\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fs.WriteFile("notes.txt", []byte("a\r\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := mem.GetFileString("api/user.go"); content != "package api\n\nvar X = 1\n" {
+		t.Errorf("unexpected api/user.go: %q", content)
+	}
+	if content, _ := mem.GetFileString("notes.txt"); content != "a\n" {
+		t.Errorf("unexpected notes.txt: %q", content)
+	}
+
+	// Content is compared with the file after processing
+	osFS := NewProcessingFS(NewOSFS(t.TempDir()), NewlinesProcessor()).(ChangeFS)
+	if _, err := osFS.WriteFileChanged("notes.txt", []byte("a\r\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed, err := osFS.WriteFileChanged("notes.txt", []byte("a"), 0644)
+	if err != nil || changed {
+		t.Errorf("expected notes.txt to be unchanged, got %v, %v", changed, err)
+	}
+
+	// A failure names the file and the processor, and nothing is written
+	err = NewProcessingFS(mem, failingProcessor{}).WriteFile(mem.Join("api", "order.go"), []byte("x"), 0644)
+	if err == nil || err.Error() != "post-processing api/order.go with failing: broken" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if mem.FileExists("api/order.go") {
+		t.Error("expected api/order.go not to be written")
+	}
+
+	if NewProcessingFS(mem) != FS(mem) {
+		t.Error("expected no processors to return the FS itself")
+	}
+}
+
+func TestFingerprintProcessor(t *testing.T) {
+	program, err := parser.Parse(strings.NewReader("struct User {\n  id: int64\n}\n"), "user.tg")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{"user.tg": program})
+	fingerprint, err := printer.Fingerprint(module)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+
+	tests := []struct {
+		input, expected string
+	}{
+		{"// Code generated by TypeGen. DO NOT EDIT.\n\npackage api\n", "// Code generated by TypeGen. DO NOT EDIT.\n// Schema fingerprint: " + fingerprint + "\n\npackage api\n"},
+		{"from x import y\n# Code generated by TypeGen. DO NOT EDIT.", "from x import y\n# Code generated by TypeGen. DO NOT EDIT.\n# Schema fingerprint: " + fingerprint + "\n"},
+		{"<!-- Code generated by TypeGen. DO NOT EDIT. -->\r\n", "<!-- Code generated by TypeGen. DO NOT EDIT. -->\r\n<!-- Schema fingerprint: " + fingerprint + " -->\r\n"},
+		{"package api\n", "package api\n"},
+	}
+	p := FingerprintProcessor(module)
+	for _, test := range tests {
+		out, err := p.Process([]byte(test.input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(out) != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.input, test.expected, out)
+		}
+	}
+
+	broken := ast.NewModule("api", map[string]*ast.ProgramNode{"user.tg": {Declarations: []ast.Declaration{&ast.ConstantNode{Name: "BROKEN"}}}})
+	if _, err := FingerprintProcessor(broken).Process([]byte("package api\n")); err == nil {
+		t.Error("expected an error for a module that can't be printed")
+	}
+}
@@ -0,0 +1,352 @@
+package pydantic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/generators/importmgr"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// factoriesFilename is the per-package module holding fake data factories
+const factoriesFilename = "factories.py"
+
+// testdataEnabled reports whether the testdata option asks for fake data factories
+func (g *Generator) testdataEnabled() (bool, error) {
+	switch g.config["testdata"] {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid testdata option %q (expected true or false)", g.config["testdata"])
+	}
+}
+
+// generateFactories writes factories.py for a module, with a fake_<type> function per declared type
+func (g *Generator) generateFactories(module *ast.Module, dest generators.FS, basePath string) error {
+	if _, exists := module.Files[strings.TrimSuffix(factoriesFilename, ".py")+".tg"]; exists {
+		return fmt.Errorf("%s would overwrite the code generated for factories.tg", factoriesFilename)
+	}
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	stdlib := importmgr.New()
+	stdlib.AddStdlib("random")
+	stdlib.AddStdlib("string")
+	stdlib.AddStdlib("datetime", "datetime", "timedelta", "timezone")
+	stdlib.AddStdlib("typing", "Callable", "Dict", "List", "Optional", "TypeVar")
+	modelImports := importmgr.New()
+	factoryImports := importmgr.New()
+	var funcs []string
+
+	for _, filename := range filenames {
+		program := module.Files[filename]
+
+		var types []string
+		for _, name := range g.getTypesFromProgram(program) {
+			if !isConstantName(program, name) {
+				types = append(types, name)
+			}
+		}
+		if len(types) > 0 {
+			sort.Strings(types)
+			modelImports.Add("."+strings.TrimSuffix(filename, ".tg"), types...)
+		}
+
+		for _, imp := range program.Imports {
+			g.addFactoryImport(factoryImports, imp.Path)
+		}
+
+		for _, decl := range program.Declarations {
+			code, err := g.generateFactory(decl, stdlib)
+			if err != nil {
+				return fmt.Errorf("failed to generate factories for %s: %w", filename, err)
+			}
+			if code != "" {
+				funcs = append(funcs, code)
+			}
+		}
+	}
+
+	var parts []string
+	parts = append(parts, "# Code generated by TypeGen. DO NOT EDIT.")
+	parts = append(parts, "")
+	parts = append(parts, "# Fake data factories for tests. Every factory is deterministic for a given random.Random,")
+	parts = append(parts, "# so seeding it with a fixed value reproduces failures.")
+	parts = append(parts, "")
+	parts = append(parts, stdlib.Render(importmgr.Python))
+
+	for _, imports := range []*importmgr.Manager{factoryImports, modelImports} {
+		if imports.Len() > 0 {
+			parts = append(parts, "")
+			parts = append(parts, imports.Render(importmgr.Python))
+		}
+	}
+
+	parts = append(parts, "")
+	parts = append(parts, factoryHelpers)
+	for _, fn := range funcs {
+		parts = append(parts, "")
+		parts = append(parts, "")
+		parts = append(parts, fn)
+	}
+
+	factoriesPath := dest.Join(basePath, factoriesFilename)
+	if err := dest.WriteFile(factoriesPath, []byte(strings.Join(parts, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", factoriesPath, err)
+	}
+	return nil
+}
+
+// addFactoryImport imports the factories module of another TypeGen module, resolved the same
+// way generateImport resolves the models
+func (g *Generator) addFactoryImport(imports *importmgr.Manager, importPath string) {
+	parts := strings.Split(importPath, ".")
+	alias := parts[len(parts)-1]
+
+	packagePath := importPath
+	if moduleName := g.config["module-name"]; moduleName != "" {
+		packagePath = moduleName + "." + importPath
+	}
+	imports.Add(packagePath, "factories as "+alias+"_factories")
+}
+
+// generateFactory generates the fake_<type> function for a declaration
+func (g *Generator) generateFactory(decl ast.Declaration, stdlib *importmgr.Manager) (string, error) {
+	var name string
+	var body []string
+
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		name = d.Name
+		if len(d.Fields) == 0 {
+			body = append(body, fmt.Sprintf("    return %s()", d.Name))
+			break
+		}
+		body = append(body, fmt.Sprintf("    return %s(", d.Name))
+		for _, field := range d.Fields {
+			fieldType := field.Type
+			if field.Optional {
+				fieldType = &ast.OptionalType{ElementType: field.Type}
+			}
+			value, err := g.factoryExpr(fieldType, stdlib)
+			if err != nil {
+				return "", err
+			}
+			body = append(body, fmt.Sprintf("        %s=%s,", g.toPythonFieldName(field.Name), value))
+		}
+		body = append(body, "    )")
+
+	case *ast.EnumNode:
+		name = d.Name
+		hasPayloads := false
+		for _, variant := range d.Variants {
+			if variant.Payload != nil {
+				hasPayloads = true
+				break
+			}
+		}
+		if !hasPayloads {
+			body = append(body, fmt.Sprintf("    return rng.choice(list(%s))", d.Name))
+			break
+		}
+
+		body = append(body, fmt.Sprintf("    choice = rng.randrange(%d)", len(d.Variants)))
+		for i, variant := range d.Variants {
+			className := fmt.Sprintf("%s_%s", d.Name, g.toPascalCase(variant.Name))
+			if i < len(d.Variants)-1 {
+				body = append(body, fmt.Sprintf("    if choice == %d:", i))
+			}
+			indent := "        "
+			if i == len(d.Variants)-1 {
+				indent = "    "
+			}
+			if variant.Payload == nil {
+				body = append(body, fmt.Sprintf("%sreturn %s()", indent, className))
+				continue
+			}
+			value, err := g.factoryExpr(variant.Payload, stdlib)
+			if err != nil {
+				return "", err
+			}
+			body = append(body, fmt.Sprintf("%sreturn %s(payload=%s)", indent, className, value))
+		}
+
+	case *ast.TypeAliasNode:
+		name = d.Name
+		value, err := g.factoryExpr(d.Type, stdlib)
+		if err != nil {
+			return "", err
+		}
+		body = append(body, fmt.Sprintf("    return %s", value))
+
+	default:
+		return "", nil // Constants have no fake values
+	}
+
+	var parts []string
+	parts = append(parts, fmt.Sprintf("def fake_%s(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> %s:", naming.SnakeCase(name), name))
+	parts = append(parts, fmt.Sprintf("    \"\"\"Return a random %s. Optionals, lists and dicts are left empty once depth runs out.\"\"\"", name))
+	parts = append(parts, body...)
+	return strings.Join(parts, "\n"), nil
+}
+
+// factoryExpr returns a Python expression producing a random value of type t
+func (g *Generator) factoryExpr(t ast.Type, stdlib *importmgr.Manager) (string, error) {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		return factoryPrimitive(typ.Name, stdlib)
+
+	case *ast.NamedType:
+		if idx := strings.LastIndex(typ.Name, "."); idx >= 0 {
+			return fmt.Sprintf("%s_factories.fake_%s(rng, depth - 1)", typ.Name[:idx], naming.SnakeCase(typ.Name[idx+1:])), nil
+		}
+		return fmt.Sprintf("fake_%s(rng, depth - 1)", naming.SnakeCase(typ.Name)), nil
+
+	case *ast.ArrayType:
+		element, err := g.factoryExpr(typ.ElementType, stdlib)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("_fake_list(rng, depth, lambda: %s)", element), nil
+
+	case *ast.MapType:
+		key, err := g.factoryExpr(typ.KeyType, stdlib)
+		if err != nil {
+			return "", err
+		}
+		value, err := g.factoryExpr(typ.ValueType, stdlib)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("_fake_dict(rng, depth, lambda: %s, lambda: %s)", key, value), nil
+
+	case *ast.OptionalType:
+		element, err := g.factoryExpr(typ.ElementType, stdlib)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("_fake_optional(rng, depth, lambda: %s)", element), nil
+
+	default:
+		return "", fmt.Errorf("unknown type: %T", t)
+	}
+}
+
+// factoryPrimitive returns a Python expression producing a random in-range value of a primitive
+// type, adding the standard library imports it needs to stdlib
+func factoryPrimitive(typeName string, stdlib *importmgr.Manager) (string, error) {
+	switch typeName {
+	case "bool":
+		return "rng.random() < 0.5", nil
+	case "string":
+		return "_fake_string(rng)", nil
+	case "int":
+		return "rng.randint(-(2**63), 2**63 - 1)", nil
+	case "nat":
+		return "rng.randint(0, 2**64 - 1)", nil
+	case "int8", "int16", "int32", "int64":
+		bits := strings.TrimPrefix(typeName, "int")
+		return fmt.Sprintf("rng.randint(-(2**%s), 2**%s - 1)", sizeMinusOne(bits), sizeMinusOne(bits)), nil
+	case "nat8", "nat16", "nat32", "nat64":
+		return fmt.Sprintf("rng.randint(0, 2**%s - 1)", strings.TrimPrefix(typeName, "nat")), nil
+	case "bigint":
+		return "rng.randint(-(2**100), 2**100)", nil
+	case "bignat":
+		return "rng.randint(0, 2**100)", nil
+	case "float32", "float64":
+		return "rng.uniform(0, 1000)", nil
+	case "decimal":
+		stdlib.AddStdlib("decimal", "Decimal")
+		return "Decimal(rng.randint(0, 999999)).scaleb(-2)", nil
+	case "uuid":
+		stdlib.AddStdlib("uuid", "UUID")
+		return "UUID(int=rng.getrandbits(128), version=4)", nil
+	case "json":
+		return "{_fake_string(rng): _fake_string(rng)}", nil
+	case "date", "datetz":
+		return "_fake_datetime(rng).date()", nil
+	case "timetz":
+		return "_fake_datetime(rng).timetz()", nil
+	case "time", "datetime", "datetimetz":
+		return "_fake_datetime(rng)", nil
+	default:
+		return "", fmt.Errorf("unsupported primitive type %s", typeName)
+	}
+}
+
+// sizeMinusOne returns the exponent of a signed integer's range for a bit size, e.g. "8" -> "7"
+func sizeMinusOne(bits string) string {
+	switch bits {
+	case "8":
+		return "7"
+	case "16":
+		return "15"
+	case "32":
+		return "31"
+	default:
+		return "63"
+	}
+}
+
+// isConstantName reports whether name is a constant declared in the program
+func isConstantName(program *ast.ProgramNode, name string) bool {
+	for _, decl := range program.Declarations {
+		if c, ok := decl.(*ast.ConstantNode); ok && c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// factoryHelpers are the module-level settings and helpers shared by the generated factories
+const factoryHelpers = `# Nesting depth factories start with
+FAKE_MAX_DEPTH = 3
+
+# Chance that a factory fills an optional value
+OPTIONAL_PROBABILITY = 0.5
+
+T = TypeVar("T")
+K = TypeVar("K")
+V = TypeVar("V")
+
+_EPOCH = datetime(2000, 1, 1, tzinfo=timezone.utc)
+
+
+def _fake_string(rng: random.Random) -> str:
+    return "".join(rng.choice(string.ascii_lowercase) for _ in range(rng.randint(1, 12)))
+
+
+def _fake_datetime(rng: random.Random) -> datetime:
+    # Whole seconds between 2000-01-01 and 2030-01-01, in UTC
+    return _EPOCH + timedelta(seconds=rng.randrange(946684800))
+
+
+def _fake_list(rng: random.Random, depth: int, fake: Callable[[], T]) -> List[T]:
+    if depth <= 0:
+        return []
+    return [fake() for _ in range(rng.randint(1, 3))]
+
+
+def _fake_dict(rng: random.Random, depth: int, key: Callable[[], K], value: Callable[[], V]) -> Dict[K, V]:
+    if depth <= 0:
+        return {}
+    result: Dict[K, V] = {}
+    for _ in range(rng.randint(1, 3)):
+        k = key()
+        result[k] = value()
+    return result
+
+
+def _fake_optional(rng: random.Random, depth: int, fake: Callable[[], T]) -> Optional[T]:
+    if depth <= 0 or rng.random() >= OPTIONAL_PROBABILITY:
+        return None
+    return fake()`
@@ -3,25 +3,34 @@ package pydantic
 import (
 	"context"
 	"fmt"
+	"path"
 	"sort"
 	"strings"
 
 	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/generators/importmgr"
+	"github.com/WhatsApp-Platform/typegen/graph"
+	"github.com/WhatsApp-Platform/typegen/naming"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+	"github.com/WhatsApp-Platform/typegen/validator"
 )
 
 // Generator generates Python code with Pydantic models from TypeGen AST
 type Generator struct {
-	importMap    map[string]bool   // Track required imports
-	config       map[string]string // Configuration options
-	cyclicTypes  map[string]bool   // Track types that are part of cycles
-	definedTypes map[string]bool   // Track which types have been defined already
+	imports      *importmgr.Manager // Imports of the file being generated
+	config       map[string]string  // Configuration options
+	cyclicTypes  map[string]bool    // Track types that are part of cycles
+	definedTypes map[string]bool    // Track which types have been defined already
+	requireTZ    bool               // Whether the file being generated needs the _require_tzinfo validator
+	model        *semantic.Model    // Resolved type references of the module being generated
+	graph        *graph.Graph       // Dependency graph of the module being generated
 }
 
 // NewGenerator creates a new Python code generator
 func NewGenerator() *Generator {
 	return &Generator{
-		importMap:    make(map[string]bool),
+		imports:      importmgr.New(),
 		config:       make(map[string]string),
 		cyclicTypes:  make(map[string]bool),
 		definedTypes: make(map[string]bool),
@@ -33,8 +42,31 @@ func (g *Generator) SetConfig(config map[string]string) {
 	g.config = config
 }
 
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "python+pydantic"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Python classes built on Pydantic models"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "module-name", Type: "string", Description: "Python package prefix for the imports between generated modules"},
+		{Key: "testdata", Type: "bool", Default: "false", Description: "Also write a factories.py with a fake_<type> function per type"},
+	}
+}
+
 // Generate implements generators.Generator interface for module generation
 func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	if _, err := g.testdataEnabled(); err != nil {
+		return err
+	}
+	g.model = semantic.Build(module)
+	g.graph = graph.FromModel(g.model)
 	return g.generateModuleRecursive(ctx, module, dest, "")
 }
 
@@ -45,13 +77,14 @@ func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Mod
 	var moduleImports []string
 
 	// Generate Python file for each .tg file in this module
-	for filename, program := range module.Files {
+	for _, filename := range module.FileNames() {
+		program := module.Files[filename]
 		// Convert filename from .tg to .py
 		pythonFilename := strings.TrimSuffix(filename, ".tg") + ".py"
 		pythonPath := dest.Join(basePath, pythonFilename)
 
-		// Generate code for this file with module context for cross-file imports
-		code, err := g.generateProgramWithModule(program, module, filename)
+		// Generate code for this file
+		code, err := g.generateProgram(program)
 		if err != nil {
 			return fmt.Errorf("failed to generate code for %s: %w", filename, err)
 		}
@@ -71,8 +104,16 @@ func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Mod
 		}
 	}
 
+	// Generate fake data factories alongside the models when requested
+	if enabled, _ := g.testdataEnabled(); enabled {
+		if err := g.generateFactories(module, dest, basePath); err != nil {
+			return err
+		}
+	}
+
 	// Recursively process submodules
-	for subModuleName, subModule := range module.SubModules {
+	for _, subModuleName := range module.SubModuleNames() {
+		subModule := module.SubModules[subModuleName]
 		subModulePath := dest.Join(basePath, subModuleName)
 		if err := g.generateModuleRecursive(ctx, subModule, dest, subModulePath); err != nil {
 			return fmt.Errorf("failed to generate submodule %s: %w", subModuleName, err)
@@ -90,16 +131,12 @@ func (g *Generator) generateModuleRecursive(ctx context.Context, module *ast.Mod
 	return nil
 }
 
-// generateProgramWithModule converts a TypeGen program to Python code with module context for cross-file imports
-func (g *Generator) generateProgramWithModule(program *ast.ProgramNode, module *ast.Module, currentFilename string) (string, error) {
-	return g.generateProgramInternal(program, module, currentFilename)
-}
-
-// generateProgramInternal is the internal implementation that handles both cases
-func (g *Generator) generateProgramInternal(program *ast.ProgramNode, module *ast.Module, currentFilename string) (string, error) {
-	g.importMap = make(map[string]bool)    // Reset imports for each generation
+// generateProgram converts a TypeGen program of the module being generated to Python code
+func (g *Generator) generateProgram(program *ast.ProgramNode) (string, error) {
+	g.imports = importmgr.New()            // Reset imports for each generation
 	g.cyclicTypes = make(map[string]bool)  // Reset cyclic types tracking
 	g.definedTypes = make(map[string]bool) // Reset defined types tracking
+	g.requireTZ = false
 
 	var parts []string
 
@@ -116,22 +153,15 @@ func (g *Generator) generateProgramInternal(program *ast.ProgramNode, module *as
 		parts = append(parts, "")
 	}
 
-	// Generate cross-file imports if module context is available
-	if module != nil {
-		crossFileImports := g.generateCrossFileImports(program, module, currentFilename)
-		if len(crossFileImports) > 0 {
-			for _, crossImport := range crossFileImports {
-				parts = append(parts, crossImport)
-			}
-			parts = append(parts, "")
-		}
+	// Import types defined in other files of the module
+	crossFileImports := g.generateCrossFileImports(program)
+	if len(crossFileImports) > 0 {
+		parts = append(parts, crossFileImports...)
+		parts = append(parts, "")
 	}
 
 	// Sort declarations topologically, handling circular references
-	sortedDeclarations, cyclicTypes, err := g.topologicalSortWithCycles(program.Declarations)
-	if err != nil {
-		return "", fmt.Errorf("failed to sort declarations: %w", err)
-	}
+	sortedDeclarations, cyclicTypes := g.sortDeclarations(program)
 
 	// Store cyclic types for forward reference generation
 	for _, typeName := range cyclicTypes {
@@ -139,6 +169,7 @@ func (g *Generator) generateProgramInternal(program *ast.ProgramNode, module *as
 	}
 
 	// Generate declarations in sorted order
+	declarationsStart := len(parts)
 	for _, decl := range sortedDeclarations {
 		code, err := g.generateDeclaration(decl)
 		if err != nil {
@@ -154,6 +185,11 @@ func (g *Generator) generateProgramInternal(program *ast.ProgramNode, module *as
 		}
 	}
 
+	// Define the validator of the timetz fields before the declarations using it
+	if g.requireTZ {
+		parts = append(parts[:declarationsStart], append([]string{requireTZInfo, ""}, parts[declarationsStart:]...)...)
+	}
+
 	// Add model_rebuild() calls for cyclic types and variant classes that use forward references
 	allTypesNeedingRebuild := g.collectTypesNeedingRebuild(cyclicTypes, sortedDeclarations)
 	if len(allTypesNeedingRebuild) > 0 {
@@ -165,7 +201,7 @@ func (g *Generator) generateProgramInternal(program *ast.ProgramNode, module *as
 	}
 
 	// Build final code with imports at top
-	result := g.buildImports()
+	result := g.imports.Render(importmgr.PythonSeparate)
 	if result != "" {
 		result += "\n\n"
 	}
@@ -206,21 +242,6 @@ func (g *Generator) generateImport(importPath string) string {
 	}
 }
 
-// buildImports generates the import statements
-func (g *Generator) buildImports() string {
-	if len(g.importMap) == 0 {
-		return ""
-	}
-
-	var imports []string
-	for imp := range g.importMap {
-		imports = append(imports, imp)
-	}
-	sort.Strings(imports)
-
-	return strings.Join(imports, "\n")
-}
-
 // generateDeclaration generates Python code for a declaration
 func (g *Generator) generateDeclaration(decl ast.Declaration) (string, error) {
 	switch d := decl.(type) {
@@ -239,16 +260,29 @@ func (g *Generator) generateDeclaration(decl ast.Declaration) (string, error) {
 
 // generateStruct generates a Pydantic BaseModel for a struct
 func (g *Generator) generateStruct(s *ast.StructNode) (string, error) {
-	g.importMap["from pydantic import BaseModel"] = true
+	g.imports.Add("pydantic", "BaseModel")
 
 	var parts []string
 	parts = append(parts, fmt.Sprintf("class %s(BaseModel):", s.Name))
+	parts = append(parts, docstring(s.Doc, "    ")...)
 
 	if len(s.Fields) == 0 {
-		parts = append(parts, "    pass")
+		if len(s.Doc) == 0 {
+			parts = append(parts, "    pass")
+		}
 		return strings.Join(parts, "\n"), nil
 	}
 
+	// Fields with a wire name are read by alias; populate_by_name keeps
+	// building models by field name working
+	for _, field := range s.Fields {
+		if field.WireName != "" {
+			g.imports.Add("pydantic", "ConfigDict")
+			parts = append(parts, "    model_config = ConfigDict(populate_by_name=True)", "")
+			break
+		}
+	}
+
 	for _, field := range s.Fields {
 		fieldCode, err := g.generateField(field)
 		if err != nil {
@@ -268,17 +302,30 @@ func (g *Generator) generateField(field *ast.FieldNode) (string, error) {
 		return "", err
 	}
 
-	if !field.Optional {
+	var args []string
+	if field.Optional {
+		args = append(args, "default=None")
+	}
+	if field.WireName != "" {
+		args = append(args, fmt.Sprintf("alias=%q", field.WireName))
+	}
+	if len(field.Doc) > 0 {
+		args = append(args, fmt.Sprintf("description=%q", strings.Join(field.Doc, "\n")))
+	}
+	// Keep sensitive values out of logs and tracebacks
+	if field.Attribute("sensitive") != nil {
+		args = append(args, "repr=False")
+	}
+	if len(args) == 0 {
 		return fmt.Sprintf("%s: %s", pythonName, pythonType), nil
-	} else {
-		g.importMap["from pydantic import Field"] = true
-		return fmt.Sprintf("%s: %s = Field(default=None)", pythonName, pythonType), nil
 	}
+	g.imports.Add("pydantic", "Field")
+	return fmt.Sprintf("%s: %s = Field(%s)", pythonName, pythonType, strings.Join(args, ", ")), nil
 }
 
 // generateEnum generates a Python Enum
 func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
-	g.importMap["from enum import Enum"] = true
+	g.imports.AddStdlib("enum", "Enum")
 
 	// Check if any variants have payloads - if so, use a different approach
 	hasPayloads := false
@@ -294,22 +341,26 @@ func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
 	}
 
 	// Simple enum without payloads - use custom class with JSON serialization
-	g.importMap["from typing import Any"] = true
-	g.importMap["from pydantic_core import CoreSchema, core_schema"] = true
-	g.importMap["from pydantic import GetCoreSchemaHandler, GetJsonSchemaHandler"] = true
-	g.importMap["from pydantic.json_schema import JsonSchemaValue"] = true
+	g.imports.AddStdlib("typing", "Any")
+	g.imports.Add("pydantic_core", "CoreSchema", "core_schema")
+	g.imports.Add("pydantic", "GetCoreSchemaHandler", "GetJsonSchemaHandler")
+	g.imports.Add("pydantic.json_schema", "JsonSchemaValue")
 
 	var parts []string
 	parts = append(parts, fmt.Sprintf("class %s(Enum):", e.Name))
+	parts = append(parts, docstring(e.Doc, "    ")...)
 
 	if len(e.Variants) == 0 {
-		parts = append(parts, "    pass")
+		if len(e.Doc) == 0 {
+			parts = append(parts, "    pass")
+		}
 		return strings.Join(parts, "\n"), nil
 	}
 
 	// Generate enum values as strings
 	for _, variant := range e.Variants {
-		parts = append(parts, fmt.Sprintf("    %s = \"%s\"", strings.ToUpper(variant.Name), variant.Name))
+		parts = append(parts, comment(variant.Doc, "    ")...)
+		parts = append(parts, fmt.Sprintf("    %s = \"%s\"", strings.ToUpper(variant.Name), variant.JSONName()))
 	}
 
 	// Add custom Pydantic schema for JSON serialization
@@ -340,7 +391,7 @@ func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
 
 	// Add validation cases for each variant
 	for _, variant := range e.Variants {
-		parts = append(parts, fmt.Sprintf("            if type_str == \"%s\":", variant.Name))
+		parts = append(parts, fmt.Sprintf("            if type_str == \"%s\":", variant.JSONName()))
 		parts = append(parts, fmt.Sprintf("                return cls.%s", strings.ToUpper(variant.Name)))
 	}
 
@@ -357,9 +408,9 @@ func (g *Generator) generateEnum(e *ast.EnumNode) (string, error) {
 
 // generateTaggedUnion generates a tagged union for enums with payloads
 func (g *Generator) generateTaggedUnion(e *ast.EnumNode) (string, error) {
-	g.importMap["from typing import Union"] = true
-	g.importMap["from typing import Literal"] = true
-	g.importMap["from pydantic import BaseModel"] = true
+	g.imports.AddStdlib("typing", "Union")
+	g.imports.AddStdlib("typing", "Literal")
+	g.imports.Add("pydantic", "BaseModel")
 
 	var parts []string
 	var variantTypes []string
@@ -368,7 +419,8 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode) (string, error) {
 	for _, variant := range e.Variants {
 		className := fmt.Sprintf("%s_%s", e.Name, g.toPascalCase(variant.Name))
 		parts = append(parts, fmt.Sprintf("class %s(BaseModel):", className))
-		parts = append(parts, fmt.Sprintf("    type: Literal['%s'] = '%s'", variant.Name, variant.Name))
+		parts = append(parts, docstring(variant.Doc, "    ")...)
+		parts = append(parts, fmt.Sprintf("    type: Literal['%s'] = '%s'", variant.JSONName(), variant.JSONName()))
 
 		if variant.Payload != nil {
 			pythonType, err := g.generateType(variant.Payload, false)
@@ -383,6 +435,7 @@ func (g *Generator) generateTaggedUnion(e *ast.EnumNode) (string, error) {
 	}
 
 	// Generate the union type
+	parts = append(parts, comment(e.Doc, "")...)
 	parts = append(parts, fmt.Sprintf("%s = Union[%s]", e.Name, strings.Join(variantTypes, ", ")))
 
 	return strings.Join(parts, "\n"), nil
@@ -395,16 +448,33 @@ func (g *Generator) generateTypeAlias(t *ast.TypeAliasNode) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprintf("%s = %s", t.Name, pythonType), nil
+	return strings.Join(append(comment(t.Doc, ""), fmt.Sprintf("%s = %s", t.Name, pythonType)), "\n"), nil
 }
 
-// generateConstant generates a Python constant declaration with Final type hint
+// generateConstant generates a Python constant declaration with Final type
+// hint, below its doc comment
 func (g *Generator) generateConstant(c *ast.ConstantNode) (string, error) {
-	g.importMap["from typing import Final"] = true
+	code, err := g.generateConstantValue(c)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append(comment(c.Doc, ""), code), "\n"), nil
+}
+
+// generateConstantValue generates the assignment of a constant
+func (g *Generator) generateConstantValue(c *ast.ConstantNode) (string, error) {
+	g.imports.AddStdlib("typing", "Final")
 
 	switch value := c.Value.(type) {
 	case *ast.IntConstant:
-		return fmt.Sprintf("%s: Final[int] = %d", c.Name, value.Value), nil
+		return fmt.Sprintf("%s: Final[int] = %s", c.Name, value.String()), nil
+	case *ast.FloatConstant:
+		return fmt.Sprintf("%s: Final[float] = %s", c.Name, value.Decimal()), nil
+	case *ast.BoolConstant:
+		if value.Value {
+			return fmt.Sprintf("%s: Final[bool] = True", c.Name), nil
+		}
+		return fmt.Sprintf("%s: Final[bool] = False", c.Name), nil
 	case *ast.StringConstant:
 		return fmt.Sprintf("%s: Final[str] = %q", c.Name, value.Value), nil
 	default:
@@ -412,6 +482,49 @@ func (g *Generator) generateConstant(c *ast.ConstantNode) (string, error) {
 	}
 }
 
+// docstring returns the lines of a doc comment as a docstring, each
+// starting with indent
+func docstring(doc []string, indent string) []string {
+	if len(doc) == 0 {
+		return nil
+	}
+	text := strings.NewReplacer(`\`, `\\`, `"""`, `\"\"\"`).Replace(strings.Join(doc, "\n"))
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		// An unescaped quote before the closing quotes would end the
+		// docstring early; it's escaped when an odd number of backslashes
+		// precede it
+		if body := strings.TrimSuffix(text, `"`); body != text {
+			if (len(body)-len(strings.TrimRight(body, `\`)))%2 == 0 {
+				text = body + `\"`
+			}
+		}
+		return []string{indent + `"""` + text + `"""`}
+	}
+	result := []string{indent + `"""` + lines[0]}
+	for _, line := range lines[1:] {
+		if line == "" {
+			result = append(result, "")
+		} else {
+			result = append(result, indent+line)
+		}
+	}
+	return append(result, indent+`"""`)
+}
+
+// comment returns the lines of a doc comment as Python comment lines
+func comment(doc []string, indent string) []string {
+	lines := make([]string, 0, len(doc))
+	for _, line := range doc {
+		if line == "" {
+			lines = append(lines, indent+"#")
+		} else {
+			lines = append(lines, indent+"# "+line)
+		}
+	}
+	return lines
+}
+
 // generateType converts a TypeGen type to Python type annotation
 func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
 	var baseType string
@@ -427,14 +540,14 @@ func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
 			baseType = typ.Name
 		}
 	case *ast.ArrayType:
-		g.importMap["from typing import List"] = true
+		g.imports.AddStdlib("typing", "List")
 		elementType, err := g.generateType(typ.ElementType, false)
 		if err != nil {
 			return "", err
 		}
 		baseType = fmt.Sprintf("List[%s]", elementType)
 	case *ast.MapType:
-		g.importMap["from typing import Dict"] = true
+		g.imports.AddStdlib("typing", "Dict")
 		keyType, err := g.generateType(typ.KeyType, false)
 		if err != nil {
 			return "", err
@@ -451,7 +564,7 @@ func (g *Generator) generateType(t ast.Type, optional bool) (string, error) {
 	}
 
 	if optional {
-		g.importMap["from typing import Optional"] = true
+		g.imports.AddStdlib("typing", "Optional")
 		return fmt.Sprintf("Optional[%s]", baseType), nil
 	}
 
@@ -465,29 +578,66 @@ func (g *Generator) mapPrimitiveType(typeName string) string {
 		return "bool"
 	case "string":
 		return "str"
-	case "int8", "int16", "int32", "int64":
+	case "int8", "int16", "int32", "int64", "int":
 		return "int"
-	case "nat8", "nat16", "nat32", "nat64":
+	case "nat8", "nat16", "nat32", "nat64", "nat":
 		return "int" // Python doesn't distinguish signed/unsigned
+	case "bigint", "bignat":
+		// Written as a JSON string like other generators do, since many JSON
+		// readers lose precision beyond 2^53
+		g.imports.AddStdlib("typing", "Annotated")
+		g.imports.Add("pydantic", "PlainSerializer")
+		return `Annotated[int, PlainSerializer(str, return_type=str, when_used="json")]`
 	case "float32", "float64":
 		return "float"
+	case "decimal":
+		g.imports.AddStdlib("decimal", "Decimal")
+		return "Decimal"
+	case "uuid":
+		g.imports.AddStdlib("uuid", "UUID")
+		return "UUID"
 	case "json":
-		g.importMap["from typing import Any"] = true
+		g.imports.AddStdlib("typing", "Any")
 		return "Any"
 	case "time":
-		g.importMap["from datetime import datetime"] = true
+		g.imports.AddStdlib("datetime", "datetime")
 		return "datetime"
 	case "date":
-		g.importMap["from datetime import date"] = true
+		g.imports.AddStdlib("datetime", "date")
+		return "date"
+	case "datetime":
+		g.imports.AddStdlib("datetime", "datetime")
+		return "datetime"
+	case "timetz":
+		g.imports.AddStdlib("datetime", "time")
+		g.imports.AddStdlib("typing", "Annotated")
+		g.imports.Add("pydantic", "AfterValidator")
+		g.requireTZ = true
+		return "Annotated[time, AfterValidator(_require_tzinfo)]"
+	case "datetz":
+		// Python dates have no offset, so the offset of a datetz is dropped
+		g.imports.AddStdlib("datetime", "date")
 		return "date"
+	case "datetimetz":
+		g.imports.Add("pydantic", "AwareDatetime")
+		return "AwareDatetime"
 	case "duration":
-		g.importMap["from datetime import timedelta"] = true
+		g.imports.AddStdlib("datetime", "timedelta")
 		return "timedelta"
 	default:
 		return typeName // Fallback to original name
 	}
 }
 
+// requireTZInfo is the validator of timetz fields, which pydantic only
+// enforces for datetimes with AwareDatetime
+const requireTZInfo = `def _require_tzinfo(value: time) -> time:
+    """Reject a timetz without a UTC offset"""
+    if value.tzinfo is None:
+        raise ValueError("expected a time with a UTC offset")
+    return value
+`
+
 // toPythonFieldName converts TypeGen field names (snake_case) to Python (already snake_case)
 func (g *Generator) toPythonFieldName(name string) string {
 	return name // TypeGen already uses snake_case for fields
@@ -567,37 +717,31 @@ func (g *Generator) typeUsesForwardReference(t ast.Type) bool {
 
 // toPascalCase converts snake_case to PascalCase for Python class names
 func (g *Generator) toPascalCase(name string) string {
-	parts := strings.Split(name, "_")
-	var result strings.Builder
-	for _, part := range parts {
-		if len(part) > 0 {
-			result.WriteString(strings.ToUpper(part[:1]))
-			if len(part) > 1 {
-				result.WriteString(part[1:])
-			}
-		}
-	}
-	return result.String()
+	return (&naming.Caser{KeepCase: true}).PascalCase(name)
 }
 
-// topologicalSortWithCycles sorts declarations and handles circular references
-func (g *Generator) topologicalSortWithCycles(declarations []ast.Declaration) ([]ast.Declaration, []string, error) {
-	// Create map from declaration name to declaration
-	declMap := make(map[string]ast.Declaration)
-	for _, decl := range declarations {
-		declMap[g.getDeclName(decl)] = decl
+// sortDeclarations orders the declarations of a program so that each comes
+// after the declarations of the same file it references, and returns the
+// names of those that are part of a cycle
+func (g *Generator) sortDeclarations(program *ast.ProgramNode) ([]ast.Declaration, []string) {
+	file, ok := g.model.FileOf(program)
+	if !ok {
+		return program.Declarations, nil
 	}
+	local := g.graph.Select(func(node *graph.Node) bool { return node.File == file.Path })
 
-	// Build dependency graph
-	dependencies := make(map[string][]string)
-	for _, decl := range declarations {
-		name := g.getDeclName(decl)
-		deps := g.analyzeDependencies(decl, declMap)
-		dependencies[name] = deps
+	var sorted []ast.Declaration
+	var cyclicTypes []string
+	for _, component := range local.TopologicalOrder() {
+		for _, node := range component.Nodes {
+			sorted = append(sorted, node.Decl)
+			if component.Cyclic {
+				cyclicTypes = append(cyclicTypes, node.Name)
+			}
+		}
 	}
-
-	// Perform topological sort using Kahn's algorithm with cycle handling
-	return g.kahnSortWithCycles(declarations, dependencies)
+	sort.Strings(cyclicTypes)
+	return sorted, cyclicTypes
 }
 
 // getDeclName returns the name of a declaration
@@ -616,120 +760,6 @@ func (g *Generator) getDeclName(decl ast.Declaration) string {
 	}
 }
 
-// analyzeDependencies finds all user-defined types that a declaration depends on
-func (g *Generator) analyzeDependencies(decl ast.Declaration, declMap map[string]ast.Declaration) []string {
-	var deps []string
-	seen := make(map[string]bool)
-
-	switch d := decl.(type) {
-	case *ast.StructNode:
-		for _, field := range d.Fields {
-			g.collectTypeDependencies(field.Type, declMap, seen, &deps)
-		}
-	case *ast.EnumNode:
-		for _, variant := range d.Variants {
-			if variant.Payload != nil {
-				g.collectTypeDependencies(variant.Payload, declMap, seen, &deps)
-			}
-		}
-	case *ast.TypeAliasNode:
-		g.collectTypeDependencies(d.Type, declMap, seen, &deps)
-	case *ast.ConstantNode:
-		// Constants have no type dependencies
-	}
-
-	return deps
-}
-
-// collectTypeDependencies recursively collects all type dependencies
-func (g *Generator) collectTypeDependencies(t ast.Type, declMap map[string]ast.Declaration, seen map[string]bool, deps *[]string) {
-	switch typ := t.(type) {
-	case *ast.NamedType:
-		// Only include dependencies on types defined in this file
-		if !strings.Contains(typ.Name, ".") { // Not a qualified name
-			if _, exists := declMap[typ.Name]; exists && !seen[typ.Name] {
-				seen[typ.Name] = true
-				*deps = append(*deps, typ.Name)
-			}
-		}
-	case *ast.ArrayType:
-		g.collectTypeDependencies(typ.ElementType, declMap, seen, deps)
-	case *ast.MapType:
-		g.collectTypeDependencies(typ.KeyType, declMap, seen, deps)
-		g.collectTypeDependencies(typ.ValueType, declMap, seen, deps)
-	case *ast.OptionalType:
-		g.collectTypeDependencies(typ.ElementType, declMap, seen, deps)
-	}
-}
-
-// kahnSortWithCycles performs topological sort with cycle handling
-func (g *Generator) kahnSortWithCycles(declarations []ast.Declaration, dependencies map[string][]string) ([]ast.Declaration, []string, error) {
-	// Calculate in-degrees and build reverse dependency graph
-	inDegree := make(map[string]int)
-	declMap := make(map[string]ast.Declaration)
-	dependents := make(map[string][]string) // reverse dependency map: dependency -> list of dependents
-
-	for _, decl := range declarations {
-		name := g.getDeclName(decl)
-		inDegree[name] = 0
-		declMap[name] = decl
-		dependents[name] = []string{}
-	}
-
-	// Build in-degrees and reverse dependencies
-	for declName, deps := range dependencies {
-		inDegree[declName] = len(deps)
-		for _, dep := range deps {
-			dependents[dep] = append(dependents[dep], declName)
-		}
-	}
-
-	// Initialize queue with nodes that have no incoming edges (no dependencies)
-	var queue []string
-	for name, degree := range inDegree {
-		if degree == 0 {
-			queue = append(queue, name)
-		}
-	}
-
-	var result []ast.Declaration
-	visited := 0
-
-	for len(queue) > 0 {
-		// Remove node from queue
-		current := queue[0]
-		queue = queue[1:]
-
-		result = append(result, declMap[current])
-		visited++
-
-		// For each node that depends on current
-		for _, dependent := range dependents[current] {
-			inDegree[dependent]--
-			if inDegree[dependent] == 0 {
-				queue = append(queue, dependent)
-			}
-		}
-	}
-
-	// Handle cycles: if there are remaining nodes, they are part of cycles
-	var cyclicTypes []string
-	if visited < len(declarations) {
-		// Find all nodes that are part of cycles (have non-zero in-degree)
-		for name, degree := range inDegree {
-			if degree > 0 {
-				cyclicTypes = append(cyclicTypes, name)
-				result = append(result, declMap[name])
-			}
-		}
-
-		// Sort cyclic types for consistent output
-		sort.Strings(cyclicTypes)
-	}
-
-	return result, cyclicTypes, nil
-}
-
 // getTypesFromProgram extracts all type names defined in a program
 func (g *Generator) getTypesFromProgram(program *ast.ProgramNode) []string {
 	var types []string
@@ -781,7 +811,7 @@ func (g *Generator) deduplicateTypes(types []string) []string {
 
 // generateInitPy creates the content for __init__.py with re-exports
 func (g *Generator) generateInitPy(moduleImports []string, allTypes []string) string {
-	var parts []string
+	parts := []string{"# Code generated by TypeGen. DO NOT EDIT.", ""}
 
 	// Add imports from modules
 	if len(moduleImports) > 0 {
@@ -812,40 +842,36 @@ func (g *Generator) generateInitPy(moduleImports []string, allTypes []string) st
 }
 
 // generateCrossFileImports generates import statements for types defined in other files in the same module
-func (g *Generator) generateCrossFileImports(program *ast.ProgramNode, module *ast.Module, currentFilename string) []string {
-	var imports []string
-	referencedTypes := make(map[string]bool)
-
-	// Collect all types referenced in this program
-	for _, decl := range program.Declarations {
-		g.collectReferencedTypes(decl, referencedTypes)
-	}
-
-	// Find which file defines each referenced type and generate imports
-	fileToTypes := make(map[string][]string)
-
-	for typeName := range referencedTypes {
-		// Skip qualified names (they already have module references)
-		if strings.Contains(typeName, ".") {
-			continue
-		}
-
-		// Find which file in the module defines this type
-		definingFile := g.findTypeDefiningFile(typeName, module, currentFilename)
-		if definingFile != "" {
-			fileToTypes[definingFile] = append(fileToTypes[definingFile], typeName)
-		}
-	}
+func (g *Generator) generateCrossFileImports(program *ast.ProgramNode) []string {
+	file, ok := g.model.FileOf(program)
+	if !ok {
+		return nil
+	}
+
+	// Group the referenced types by the file that defines them
+	fileToTypes := make(map[*semantic.File][]string)
+	seen := make(map[*semantic.Decl]bool)
+	ast.Inspect(program, func(named *ast.NamedType) {
+		// Qualified names already have module references
+		if strings.Contains(named.Name, ".") {
+			return
+		}
+		decl, found := g.model.Lookup(named)
+		if !found || decl.File == file || seen[decl] {
+			return
+		}
+		seen[decl] = true
+		fileToTypes[decl.File] = append(fileToTypes[decl.File], decl.Name)
+	})
 
 	// Generate import statements
-	for filename, types := range fileToTypes {
-		if len(types) > 0 {
-			// Convert filename from .tg to module name
-			moduleName := strings.TrimSuffix(filename, ".tg")
-			// Sort types for consistent output
-			sort.Strings(types)
-			imports = append(imports, fmt.Sprintf("from .%s import %s", moduleName, strings.Join(types, ", ")))
-		}
+	var imports []string
+	for definingFile, types := range fileToTypes {
+		// Convert filename from .tg to module name
+		moduleName := strings.TrimSuffix(path.Base(definingFile.Path), ".tg")
+		// Sort types for consistent output
+		sort.Strings(types)
+		imports = append(imports, fmt.Sprintf("from .%s import %s", moduleName, strings.Join(types, ", ")))
 	}
 
 	// Sort imports for consistent output
@@ -853,66 +879,16 @@ func (g *Generator) generateCrossFileImports(program *ast.ProgramNode, module *a
 	return imports
 }
 
-// collectReferencedTypes recursively collects all type names referenced in a declaration
-func (g *Generator) collectReferencedTypes(decl ast.Declaration, referencedTypes map[string]bool) {
-	switch d := decl.(type) {
-	case *ast.StructNode:
-		for _, field := range d.Fields {
-			g.collectTypesFromType(field.Type, referencedTypes)
-		}
-	case *ast.EnumNode:
-		for _, variant := range d.Variants {
-			if variant.Payload != nil {
-				g.collectTypesFromType(variant.Payload, referencedTypes)
-			}
-		}
-	case *ast.TypeAliasNode:
-		g.collectTypesFromType(d.Type, referencedTypes)
-	case *ast.ConstantNode:
-		// Constants don't reference types
-	}
-}
-
-// collectTypesFromType recursively extracts type names from a type expression
-func (g *Generator) collectTypesFromType(t ast.Type, referencedTypes map[string]bool) {
-	switch typ := t.(type) {
-	case *ast.PrimitiveType:
-		// Primitive types don't need imports
-	case *ast.NamedType:
-		referencedTypes[typ.Name] = true
-	case *ast.ArrayType:
-		g.collectTypesFromType(typ.ElementType, referencedTypes)
-	case *ast.MapType:
-		g.collectTypesFromType(typ.KeyType, referencedTypes)
-		g.collectTypesFromType(typ.ValueType, referencedTypes)
-	case *ast.OptionalType:
-		g.collectTypesFromType(typ.ElementType, referencedTypes)
-	}
-}
-
-// findTypeDefiningFile finds which file in the module defines the given type name
-func (g *Generator) findTypeDefiningFile(typeName string, module *ast.Module, currentFilename string) string {
-	// Check all files in the module except the current one
-	for filename, program := range module.Files {
-		if filename == currentFilename {
-			continue
-		}
-
-		// Check if this file defines the type
-		for _, decl := range program.Declarations {
-			declName := g.getDeclName(decl)
-			if declName == typeName {
-				return filename
-			}
-		}
-	}
-
-	return "" // Type not found in any file
-}
-
 func init() {
 	// Register the Python+Pydantic generator globally
 	generators.Register("python+pydantic", func() generators.Generator {
 		return NewGenerator()
 	})
+	generators.RegisterAlias("python", "python+pydantic")
+	// Field, class and package names are written as they are declared
+	validator.RegisterReservedWords("python+pydantic", validator.ReservedWords{
+		Language: "Python",
+		Words:    naming.PythonKeywords,
+		Names:    []string{validator.FieldNames, validator.TypeNames, validator.ModuleNames},
+	})
 }
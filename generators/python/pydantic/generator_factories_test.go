@@ -0,0 +1,214 @@
+package pydantic
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+const factoriesTestSchema = `import auth
+
+enum Status {
+	active
+	suspended
+}
+
+type Tags = []string
+
+struct User {
+	id: int64
+	age: nat8
+	name: string
+	email: ?string
+	tags: Tags
+	scores: [int32]float64
+	status: Status
+	token: auth.Token
+	birthday: date
+	opens_at: timetz
+	balance: decimal
+	reserve: bignat
+	visits: nat
+	session: uuid
+	extra: json
+}
+
+struct TreeNode {
+	label: string
+	parent: ?TreeNode
+	children: []TreeNode
+}
+
+enum Result {
+	success: User
+	failure: string
+	pending
+}
+
+const MAX_USERS = 100`
+
+// generateFactoriesModule generates a module with a root package and an auth subpackage
+func generateFactoriesModule(t *testing.T, config map[string]string) *generators.InMemoryFS {
+	t.Helper()
+
+	root, err := parser.Parse(strings.NewReader(factoriesTestSchema), "models.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse models.tg: %v", err)
+	}
+	token, err := parser.Parse(strings.NewReader(`struct Token { value: string }`), "token.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse token.tg: %v", err)
+	}
+
+	module := ast.NewModule("/test/module", map[string]*ast.ProgramNode{"models.tg": root})
+	module.SubModules["auth"] = ast.NewModule("/test/module/auth", map[string]*ast.ProgramNode{"token.tg": token})
+
+	fs := generators.NewInMemoryFS()
+	generator := NewGenerator()
+	generator.SetConfig(config)
+
+	if err := generator.Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	return fs
+}
+
+func TestGenerate_Factories(t *testing.T) {
+	fs := generateFactoriesModule(t, map[string]string{"module-name": "myapp", "testdata": "true"})
+
+	result, exists := fs.GetFileString("factories.py")
+	if !exists {
+		t.Fatal("factories.py should have been generated")
+	}
+
+	expected := []string{
+		"# Code generated by TypeGen. DO NOT EDIT.",
+		"\n\nimport random\nimport string\nfrom datetime import datetime, timedelta, timezone\nfrom decimal import Decimal\nfrom typing import Callable, Dict, List, Optional, TypeVar\nfrom uuid import UUID\n" +
+			"\nfrom myapp.auth import factories as auth_factories\n" +
+			"\nfrom .models import Result, Result_Failure, Result_Pending, Result_Success, Status, Tags, TreeNode, User\n\n",
+		"OPTIONAL_PROBABILITY = 0.5",
+		"def fake_user(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> User:",
+		"def fake_status(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> Status:",
+		"def fake_tags(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> Tags:",
+		"def fake_tree_node(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> TreeNode:",
+		"def fake_result(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> Result:",
+		"age=rng.randint(0, 2**8 - 1),",
+		"opens_at=_fake_datetime(rng).timetz(),",
+		"balance=Decimal(rng.randint(0, 999999)).scaleb(-2),",
+		"reserve=rng.randint(0, 2**100),",
+		"visits=rng.randint(0, 2**64 - 1),",
+		"session=UUID(int=rng.getrandbits(128), version=4),",
+		"id=rng.randint(-(2**63), 2**63 - 1),",
+		"token=auth_factories.fake_token(rng, depth - 1),",
+		"parent=_fake_optional(rng, depth, lambda: fake_tree_node(rng, depth - 1)),",
+		"return rng.choice(list(Status))",
+		"return Result_Success(payload=fake_user(rng, depth - 1))",
+		"return Result_Pending()",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected factories.py to contain %q, but got:\n%s", exp, result)
+		}
+	}
+
+	if strings.Contains(result, "MAX_USERS") {
+		t.Errorf("Constants should not get factories, got:\n%s", result)
+	}
+
+	if sub, exists := fs.GetFileString("auth/factories.py"); !exists || !strings.Contains(sub, "def fake_token(rng: random.Random, depth: int = FAKE_MAX_DEPTH) -> Token:") {
+		t.Errorf("Expected auth/factories.py with fake_token, got:\n%s", sub)
+	}
+}
+
+func TestGenerate_FactoriesDeterministic(t *testing.T) {
+	config := map[string]string{"module-name": "myapp", "testdata": "true"}
+	first, _ := generateFactoriesModule(t, config).GetFileString("factories.py")
+	second, _ := generateFactoriesModule(t, config).GetFileString("factories.py")
+
+	if first != second {
+		t.Errorf("Expected identical factories.py across runs, got:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestGenerate_FactoriesDisabled(t *testing.T) {
+	fs := generateFactoriesModule(t, map[string]string{"module-name": "myapp"})
+
+	if fs.FileExists("factories.py") || fs.FileExists("auth/factories.py") {
+		t.Error("factories.py should not be generated without the testdata option")
+	}
+}
+
+func TestGenerate_FactoriesInvalidOption(t *testing.T) {
+	module := ast.NewModule("/test/module", map[string]*ast.ProgramNode{})
+
+	generator := NewGenerator()
+	generator.SetConfig(map[string]string{"testdata": "yes"})
+
+	err := generator.Generate(context.Background(), module, generators.NewInMemoryFS())
+	if err == nil || !strings.Contains(err.Error(), "invalid testdata option") {
+		t.Errorf("Expected invalid testdata option error, got: %v", err)
+	}
+}
+
+// TestGenerate_FactoriesRun runs the factories with a stub pydantic and checks that equal seeds give equal values
+func TestGenerate_FactoriesRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping execution of generated code in short mode")
+	}
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+
+	fs := generateFactoriesModule(t, map[string]string{"module-name": "myapp", "testdata": "true"})
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"check.py": `import random
+
+from myapp import factories
+
+
+def run():
+    rng = random.Random(42)
+    return [repr(f(rng)) for _ in range(20) for f in (factories.fake_user, factories.fake_tree_node, factories.fake_result)]
+
+
+assert run() == run()
+print("ok")
+`,
+	}
+	for _, path := range fs.ListFiles() {
+		content, _ := fs.GetFileString(path)
+		files[filepath.Join("myapp", path)] = content
+	}
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(python, "check.py")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "No module named 'pydantic'") {
+			t.Skip("pydantic not installed")
+		}
+		t.Fatalf("Generated factories failed to run: %v\n%s", err, output)
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		t.Errorf("Expected identical values for the same seed, got:\n%s", output)
+	}
+}
@@ -283,4 +283,68 @@ func TestGenerate_EmptyModule(t *testing.T) {
 	if len(files) != 1 || files[0] != "__init__.py" {
 		t.Errorf("Expected only __init__.py, got: %v", files)
 	}
-}
\ No newline at end of file
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	parse := func(src, filename string) *ast.ProgramNode {
+		program, err := parser.Parse(strings.NewReader(src), filename)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", filename, err)
+		}
+		return program
+	}
+
+	// Enough files and submodules that map order would show
+	files := make(map[string]*ast.ProgramNode)
+	for _, name := range []string{"user", "order", "invoice", "product", "cart", "review", "shipment", "coupon"} {
+		filename := name + ".tg"
+		typeName := strings.ToUpper(name[:1]) + name[1:]
+		files[filename] = parse("struct "+typeName+" {\n\tid: int64\n}\n", filename)
+	}
+	module := ast.NewModule("shop", files)
+	for _, name := range []string{"auth", "billing", "catalog", "delivery"} {
+		sub := ast.NewModule(name, map[string]*ast.ProgramNode{
+			"a.tg": parse("struct First {\n\tid: int64\n}\n", "a.tg"),
+			"b.tg": parse("struct Second {\n\tfirst: First\n}\n", "b.tg"),
+			"c.tg": parse("enum Third {\n\tsecond: Second\n\tnone\n}\n", "c.tg"),
+		})
+		module.SubModules[name] = sub
+	}
+
+	generate := func() *generators.InMemoryFS {
+		fs := generators.NewInMemoryFS()
+		if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		return fs
+	}
+	writes := func(fs *generators.InMemoryFS) string {
+		var paths []string
+		for _, op := range fs.Ops() {
+			if op.Kind == "write" {
+				paths = append(paths, op.Path)
+			}
+		}
+		return strings.Join(paths, "\n")
+	}
+
+	first := generate()
+	if !first.FileExists("__init__.py") || !first.FileExists("auth/__init__.py") {
+		t.Fatalf("Expected __init__.py files, got: %v", first.ListFiles())
+	}
+	for run := 2; run <= 5; run++ {
+		fs := generate()
+		if got, expected := fs.ListFiles(), first.ListFiles(); strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Fatalf("Run %d: expected files %v, got %v", run, expected, got)
+		}
+		for _, name := range first.ListFiles() {
+			expected, _ := first.GetFileString(name)
+			if got, _ := fs.GetFileString(name); got != expected {
+				t.Errorf("Run %d: %s differs from the first run:\n%s\n---\n%s", run, name, expected, got)
+			}
+		}
+		if got, expected := writes(fs), writes(first); got != expected {
+			t.Errorf("Run %d: files written in a different order:\n%s\n---\n%s", run, expected, got)
+		}
+	}
+}
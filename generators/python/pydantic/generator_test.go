@@ -300,6 +300,206 @@ func TestGenerateTypeAlias(t *testing.T) {
 	}
 }
 
+func TestGenerateDecimal(t *testing.T) {
+	input := `struct Invoice {
+		total: decimal
+		discount: ?decimal
+		lines: []decimal
+	}
+	type Price = decimal`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"from decimal import Decimal",
+		"total: Decimal",
+		"discount: Optional[Decimal] = Field(default=None)",
+		"lines: List[Decimal]",
+		"Price = Decimal",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBigIntegers(t *testing.T) {
+	input := `struct Ledger {
+		balance: bigint
+		supply: ?bignat
+	}
+	type Supply = bignat`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	bigInt := `Annotated[int, PlainSerializer(str, return_type=str, when_used="json")]`
+	expected := []string{
+		"from pydantic import PlainSerializer",
+		"from typing import Annotated",
+		"balance: " + bigInt,
+		"supply: Optional[" + bigInt + "] = Field(default=None)",
+		"Supply = " + bigInt,
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGeneratePlatformIntegers(t *testing.T) {
+	input := `struct Counter {
+		total: int
+		by_index: [nat]int
+	}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	for _, exp := range []string{"total: int", "by_index: Dict[int, int]"} {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateUUID(t *testing.T) {
+	input := `struct Session {
+		id: uuid
+		parent: ?uuid
+		roles: [uuid]string
+	}
+	type SessionID = uuid`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"from uuid import UUID\n",
+		"id: UUID",
+		"parent: Optional[UUID] = Field(default=None)",
+		"roles: Dict[UUID, str]",
+		"SessionID = UUID",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	if strings.Contains(result, "alias=") {
+		t.Errorf("Expected no field aliases, but got:\n%s", result)
+	}
+}
+
+func TestGenerateTimezoneTypes(t *testing.T) {
+	input := `struct Schedule {
+		opens_at: timetz
+		holiday: datetz
+		starts_at: datetimetz
+		updated_at: datetime
+		closes_at: ?timetz
+	}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"from datetime import date\n",
+		"from datetime import datetime\n",
+		"from datetime import time\n",
+		"from pydantic import AfterValidator\n",
+		"from pydantic import AwareDatetime\n",
+		"from typing import Annotated\n",
+		"def _require_tzinfo(value: time) -> time:",
+		"opens_at: Annotated[time, AfterValidator(_require_tzinfo)]",
+		"holiday: date",
+		"starts_at: AwareDatetime",
+		"updated_at: datetime",
+		"closes_at: Optional[Annotated[time, AfterValidator(_require_tzinfo)]]",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+	if strings.Index(result, "def _require_tzinfo") > strings.Index(result, "class Schedule") {
+		t.Errorf("Expected _require_tzinfo to be defined before Schedule, but got:\n%s", result)
+	}
+}
+
+func TestGenerateRequireTZInfoOnlyWhenUsed(t *testing.T) {
+	input := `struct Event {
+		at: datetimetz
+		on: datetz
+	}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	for _, unexpected := range []string{"_require_tzinfo", "AfterValidator", "from datetime import time"} {
+		if strings.Contains(result, unexpected) {
+			t.Errorf("Expected result not to contain %q, but got:\n%s", unexpected, result)
+		}
+	}
+}
+
 func TestGenerateComplexExample(t *testing.T) {
 	input := `struct User {
 		id: int64
@@ -358,7 +558,11 @@ func TestGenerateComplexExample(t *testing.T) {
 }
 
 func TestGenerateIntConstant(t *testing.T) {
-	input := `const MAX_RETRIES = 5`
+	input := `const MAX_RETRIES = 5
+	const MIN_TEMP = -40
+	const MIN_INT = -9223372036854775808
+	const FLAG_ADMIN = 0x08
+	const MASK = -0b1010`
 
 	program, err := parser.Parse(strings.NewReader(input), "test.tg")
 	if err != nil {
@@ -389,6 +593,10 @@ func TestGenerateIntConstant(t *testing.T) {
 	expected := []string{
 		"from typing import Final",
 		"MAX_RETRIES: Final[int] = 5",
+		"MIN_TEMP: Final[int] = -40",
+		"MIN_INT: Final[int] = -9223372036854775808",
+		"FLAG_ADMIN: Final[int] = 0x08",
+		"MASK: Final[int] = -0b1010",
 	}
 
 	for _, exp := range expected {
@@ -408,6 +616,65 @@ func TestGenerateIntConstant(t *testing.T) {
 	}
 }
 
+func TestGenerateFloatConstant(t *testing.T) {
+	input := `const PI = 3.14
+	const EPSILON = 1e-9
+	const OFFSET = -0.5
+	const RATIO = 2.0`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	// Whole numbers keep a decimal point so the constant stays a float
+	expected := []string{
+		"PI: Final[float] = 3.14",
+		"EPSILON: Final[float] = 1e-9",
+		"OFFSET: Final[float] = -0.5",
+		"RATIO: Final[float] = 2.0",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateBoolConstant(t *testing.T) {
+	input := `const FEATURE_ENABLED = true
+	const LEGACY_MODE = false`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"FEATURE_ENABLED: Final[bool] = True",
+		"LEGACY_MODE: Final[bool] = False",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
 func TestGenerateStringConstant(t *testing.T) {
 	input := `const API_URL = "https://api.example.com"`
 
@@ -523,3 +790,204 @@ struct User {
 		}
 	}
 }
+
+// TestGenerateImportBlock checks the exact imports of a module, so changes to
+// import handling can't change the generated code
+func TestGenerateImportBlock(t *testing.T) {
+	fs := generateFactoriesModule(t, map[string]string{"module-name": "myapp"})
+
+	result, _ := fs.GetFileString("models.py")
+	expected := `from datetime import date
+from datetime import time
+from decimal import Decimal
+from enum import Enum
+from pydantic import AfterValidator
+from pydantic import BaseModel
+from pydantic import Field
+from pydantic import GetCoreSchemaHandler, GetJsonSchemaHandler
+from pydantic import PlainSerializer
+from pydantic.json_schema import JsonSchemaValue
+from pydantic_core import CoreSchema, core_schema
+from typing import Annotated
+from typing import Any
+from typing import Dict
+from typing import Final
+from typing import List
+from typing import Literal
+from typing import Optional
+from typing import Union
+from uuid import UUID
+
+# Code generated by TypeGen. DO NOT EDIT.
+
+from myapp import auth
+
+def _require_tzinfo(`
+	if !strings.HasPrefix(result, expected) {
+		t.Errorf("Expected models.py to start with:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func TestGenerateDocComments(t *testing.T) {
+	input := `// User is a customer.
+//
+// Users sign in by "email".
+struct User {
+  // The user's id
+  id: int64
+  // Optional nickname
+  nick: ?string
+  name: string
+}
+
+// Status of an order
+enum Status {
+  // Not paid yet
+  pending
+  paid
+}
+
+// A shipment event
+enum Event {
+  // Left the warehouse
+  shipped: User
+}
+
+// Identifies a "user"
+type UserID = int64
+
+// The cart size
+const MAX_ITEMS = 10`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"class User(BaseModel):\n    \"\"\"User is a customer.\n\n    Users sign in by \"email\".\n    \"\"\"\n",
+		"    id: int = Field(description=\"The user's id\")",
+		"    nick: Optional[str] = Field(default=None, description=\"Optional nickname\")",
+		"    name: str\n",
+		"class Status(Enum):\n    \"\"\"Status of an order\"\"\"\n    # Not paid yet\n    PENDING = \"pending\"",
+		"class Event_Shipped(BaseModel):\n    \"\"\"Left the warehouse\"\"\"\n",
+		"# A shipment event\nEvent = Union[Event_Shipped]",
+		"# Identifies a \"user\"\nUserID = int",
+		"# The cart size\nMAX_ITEMS: Final[int] = 10",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestDocstring(t *testing.T) {
+	tests := []struct {
+		doc      []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"One line"}, `    """One line"""`},
+		{[]string{`Ends with "quotes"`}, `    """Ends with "quotes\""""`},
+		{[]string{`Ends with a backslash \`}, `    """Ends with a backslash \\"""`},
+		{[]string{`Holds """ quotes`}, `    """Holds \"\"\" quotes"""`},
+		{[]string{"First", "", "Third"}, "    \"\"\"First\n\n    Third\n    \"\"\""},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(docstring(tt.doc, "    "), "\n"); got != tt.expected {
+			t.Errorf("docstring(%q): expected:\n%s\ngot:\n%s", tt.doc, tt.expected, got)
+		}
+	}
+}
+
+func TestGenerateSensitive(t *testing.T) {
+	input := `struct User {
+  // The user's password
+  password: string @sensitive
+  token: ?string @sensitive
+  name: string @deprecated
+}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"    password: str = Field(description=\"The user's password\", repr=False)",
+		"    token: Optional[str] = Field(default=None, repr=False)",
+		"    name: str\n",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
+
+func TestGenerateWireNames(t *testing.T) {
+	input := `struct User {
+  user_id: int64 = "userId"
+  nickname: ?string = "nickName"
+  status: Status
+}
+
+struct Plain {
+  name: string
+}
+
+enum Status {
+  active = "ACTIVE"
+  banned
+}
+
+enum Event {
+  created: User = "CREATED"
+  deleted
+}`
+
+	program, err := parser.Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"test.tg": program})
+
+	fs := generators.NewInMemoryFS()
+	if err := NewGenerator().Generate(context.Background(), module, fs); err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	result, _ := fs.GetFileString("test.py")
+
+	expected := []string{
+		"from pydantic import ConfigDict",
+		"class User(BaseModel):\n    model_config = ConfigDict(populate_by_name=True)\n\n    user_id: int = Field(alias=\"userId\")",
+		"    nickname: Optional[str] = Field(default=None, alias=\"nickName\")",
+		"    status: Status\n",
+		"class Plain(BaseModel):\n    name: str",
+		"    ACTIVE = \"ACTIVE\"",
+		"    BANNED = \"banned\"",
+		"if type_str == \"ACTIVE\":",
+		"type: Literal['CREATED'] = 'CREATED'",
+		"type: Literal['deleted'] = 'deleted'",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(result, exp) {
+			t.Errorf("Expected result to contain %q, but got:\n%s", exp, result)
+		}
+	}
+}
@@ -4,21 +4,36 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // Registry manages registered code generators
 type Registry struct {
 	mu         sync.RWMutex
 	generators map[string]func() Generator
+	// aliases maps alternative names to the name of their generator
+	aliases map[string]string
+	// deprecated maps deprecated names, of generators or aliases, to a note
+	// for their warning
+	deprecated map[string]string
+	// warned are the deprecated names already warned about
+	warned map[string]bool
 }
 
 // defaultRegistry is the global registry instance
-var defaultRegistry = NewRegistry()
+var defaultRegistry atomic.Pointer[Registry]
+
+func init() {
+	defaultRegistry.Store(NewRegistry())
+}
 
 // NewRegistry creates a new generator registry
 func NewRegistry() *Registry {
 	return &Registry{
 		generators: make(map[string]func() Generator),
+		aliases:    make(map[string]string),
+		deprecated: make(map[string]string),
+		warned:     make(map[string]bool),
 	}
 }
 
@@ -29,21 +44,100 @@ func (r *Registry) Register(name string, constructor func() Generator) {
 	r.generators[name] = constructor
 }
 
-// Get retrieves a generator by name
+// RegisterAlias registers alias as another name of the generator named
+// canonical, such as a shorthand. canonical needn't be registered yet, and
+// a generator registered under alias takes precedence over it.
+func (r *Registry) RegisterAlias(alias, canonical string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = canonical
+}
+
+// Deprecate marks a generator or alias name as deprecated: the first
+// DeprecationWarning for it warns, with note appended, such as when it will
+// be removed
+func (r *Registry) Deprecate(name, note string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deprecated[name] = note
+}
+
+// Unregister removes a generator or an alias, and its deprecation
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.generators, name)
+	delete(r.aliases, name)
+	delete(r.deprecated, name)
+	delete(r.warned, name)
+}
+
+// Resolve returns the name of the generator registered under name, which is
+// name itself unless it's an alias
+func (r *Registry) Resolve(name string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resolve(name)
+}
+
+func (r *Registry) resolve(name string) (string, error) {
+	if _, exists := r.generators[name]; exists {
+		return name, nil
+	}
+	if canonical, exists := r.aliases[name]; exists {
+		if _, exists := r.generators[canonical]; exists {
+			return canonical, nil
+		}
+		return "", fmt.Errorf("generator %q, an alias of %q, not found", name, canonical)
+	}
+	return "", fmt.Errorf("generator %q not found", name)
+}
+
+// Get retrieves a generator by name or alias
 func (r *Registry) Get(name string) (Generator, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	
-	constructor, exists := r.generators[name]
-	if !exists {
-		return nil, fmt.Errorf("generator %q not found", name)
+	canonical, err := r.resolve(name)
+	if err != nil {
+		return nil, err
 	}
 	
-	return constructor(), nil
+	return r.generators[canonical](), nil
+}
+
+// DeprecationWarning returns the warning for using a deprecated generator
+// name, suggesting the generator's name for an alias. It returns "" for
+// names that aren't deprecated, and for each name after its first warning,
+// so a build with many tasks using the name warns once.
+func (r *Registry) DeprecationWarning(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	note, deprecated := r.deprecated[name]
+	if !deprecated || r.warned[name] {
+		return ""
+	}
+	r.warned[name] = true
+
+	warning := fmt.Sprintf("generator name %q is deprecated", name)
+	if canonical, err := r.resolve(name); err == nil && canonical != name {
+		warning += fmt.Sprintf("; use %q instead", canonical)
+	}
+	if note != "" {
+		warning += ": " + note
+	}
+	return warning
 }
 
-// List returns all registered generator names
+// List returns all registered generator names, without aliases
 func (r *Registry) List() []string {
+	return r.Names(false)
+}
+
+// Names returns all registered generator names, sorted, and with
+// includeAliases their aliases too
+func (r *Registry) Names(includeAliases bool) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	
@@ -51,23 +145,157 @@ func (r *Registry) List() []string {
 	for name := range r.generators {
 		names = append(names, name)
 	}
+	if includeAliases {
+		for alias := range r.aliases {
+			if _, exists := r.generators[alias]; !exists {
+				names = append(names, alias)
+			}
+		}
+	}
 	sort.Strings(names)
 	return names
 }
 
+// aliasesOf returns the aliases of a generator, sorted
+func (r *Registry) aliasesOf(canonical string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var aliases []string
+	for alias, target := range r.aliases {
+		if _, exists := r.generators[alias]; target == canonical && !exists {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// isDeprecated reports whether a name is deprecated
+func (r *Registry) isDeprecated(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, deprecated := r.deprecated[name]
+	return deprecated
+}
+
 // Global functions that use the default registry
 
 // Register registers a generator globally
 func Register(name string, constructor func() Generator) {
-	defaultRegistry.Register(name, constructor)
+	defaultRegistry.Load().Register(name, constructor)
+}
+
+// RegisterAlias registers an alias of a generator globally
+func RegisterAlias(alias, canonical string) {
+	defaultRegistry.Load().RegisterAlias(alias, canonical)
+}
+
+// Deprecate marks a generator or alias name as deprecated globally
+func Deprecate(name, note string) {
+	defaultRegistry.Load().Deprecate(name, note)
+}
+
+// Unregister removes a generator or an alias from the global registry
+func Unregister(name string) {
+	defaultRegistry.Load().Unregister(name)
 }
 
 // Get retrieves a generator from the global registry
 func Get(name string) (Generator, error) {
-	return defaultRegistry.Get(name)
+	return defaultRegistry.Load().Get(name)
+}
+
+// Resolve returns the name of a generator of the global registry, resolving
+// aliases
+func Resolve(name string) (string, error) {
+	return defaultRegistry.Load().Resolve(name)
+}
+
+// DeprecationWarning returns the one-time warning for a deprecated generator
+// name of the global registry, or ""
+func DeprecationWarning(name string) string {
+	return defaultRegistry.Load().DeprecationWarning(name)
 }
 
 // List returns all globally registered generator names
 func List() []string {
-	return defaultRegistry.List()
-}
\ No newline at end of file
+	return defaultRegistry.Load().List()
+}
+
+// Names returns all globally registered generator names, and with
+// includeAliases their aliases
+func Names(includeAliases bool) []string {
+	return defaultRegistry.Load().Names(includeAliases)
+}
+
+// UseRegistry makes r the global registry until restore is called, so tests
+// can register generators without changing the registry of other tests:
+//
+//	defer generators.UseRegistry(generators.NewRegistry())()
+//
+// Tests using it must not run in parallel with tests using the global
+// registry.
+func UseRegistry(r *Registry) (restore func()) {
+	previous := defaultRegistry.Swap(r)
+	return func() { defaultRegistry.Store(previous) }
+}
+// Info describes a registered generator
+type Info struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Options     []OptionSpec `json:"options"`
+	// Aliases are the other names of the generator, and Deprecated the
+	// deprecated ones among its name and aliases
+	Aliases    []string `json:"aliases,omitempty"`
+	Deprecated []string `json:"deprecated,omitempty"`
+}
+
+// Info returns the metadata of a generator, by name or alias. Generators
+// that don't implement Describer have an empty description and no
+// documented options.
+func (r *Registry) Info(name string) (Info, error) {
+	canonical, err := r.Resolve(name)
+	if err != nil {
+		return Info{}, err
+	}
+	generator, err := r.Get(canonical)
+	if err != nil {
+		return Info{}, err
+	}
+	
+	info := Info{Name: canonical, Options: []OptionSpec{}, Aliases: r.aliasesOf(canonical)}
+	for _, name := range append([]string{canonical}, info.Aliases...) {
+		if r.isDeprecated(name) {
+			info.Deprecated = append(info.Deprecated, name)
+		}
+	}
+	if describer, ok := generator.(Describer); ok {
+		info.Description = describer.Description()
+		if options := describer.Options(); options != nil {
+			info.Options = options
+		}
+	}
+	return info, nil
+}
+
+// Infos returns the metadata of all registered generators, sorted by name
+func (r *Registry) Infos() []Info {
+	var infos []Info
+	for _, name := range r.List() {
+		if info, err := r.Info(name); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// GetInfo returns the metadata of a generator from the global registry
+func GetInfo(name string) (Info, error) {
+	return defaultRegistry.Load().Info(name)
+}
+
+// Infos returns the metadata of all globally registered generators
+func Infos() []Info {
+	return defaultRegistry.Load().Infos()
+}
@@ -0,0 +1,188 @@
+package generators
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// plainGenerator implements only the Generator interface
+type plainGenerator struct{}
+
+func (g *plainGenerator) SetConfig(config map[string]string) {}
+
+func (g *plainGenerator) Generate(ctx context.Context, module *ast.Module, dest FS) error {
+	return nil
+}
+
+// describedGenerator also documents itself
+type describedGenerator struct {
+	plainGenerator
+}
+
+func (g *describedGenerator) Name() string {
+	return "described"
+}
+
+func (g *describedGenerator) Description() string {
+	return "Described output"
+}
+
+func (g *describedGenerator) Options() []OptionSpec {
+	return []OptionSpec{{Key: "style", Type: "a|b", Default: "a", Description: "Output style"}}
+}
+
+func TestRegistry_Info(t *testing.T) {
+	r := NewRegistry()
+	r.Register("plain", func() Generator { return &plainGenerator{} })
+	r.Register("described", func() Generator { return &describedGenerator{} })
+
+	info, err := r.Info("described")
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Name != "described" || info.Description != "Described output" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if len(info.Options) != 1 || info.Options[0].Key != "style" || info.Options[0].Default != "a" {
+		t.Errorf("unexpected options: %+v", info.Options)
+	}
+
+	// Generators without metadata are still listed, with none
+	info, err = r.Info("plain")
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Description != "" || info.Options == nil || len(info.Options) != 0 {
+		t.Errorf("expected empty metadata, got %+v", info)
+	}
+
+	if _, err := r.Info("missing"); err == nil {
+		t.Error("expected an error for an unregistered generator")
+	}
+
+	infos := r.Infos()
+	if len(infos) != 2 || infos[0].Name != "described" || infos[1].Name != "plain" {
+		t.Errorf("expected infos sorted by name, got %+v", infos)
+	}
+}
+
+func TestRegistry_Aliases(t *testing.T) {
+	r := NewRegistry()
+	r.Register("python+pydantic", func() Generator { return &describedGenerator{} })
+	r.RegisterAlias("python", "python+pydantic")
+	r.RegisterAlias("py", "python+pydantic")
+	r.RegisterAlias("rust", "rust+serde")
+
+	if name, err := r.Resolve("python"); err != nil || name != "python+pydantic" {
+		t.Errorf("expected python to resolve to python+pydantic, got %q, %v", name, err)
+	}
+	if name, err := r.Resolve("python+pydantic"); err != nil || name != "python+pydantic" {
+		t.Errorf("expected python+pydantic to resolve to itself, got %q, %v", name, err)
+	}
+	if _, err := r.Get("py"); err != nil {
+		t.Errorf("expected Get to resolve aliases, got %v", err)
+	}
+	if _, err := r.Get("rust"); err == nil || err.Error() != `generator "rust", an alias of "rust+serde", not found` {
+		t.Errorf("expected an error for an alias of a missing generator, got %v", err)
+	}
+	if _, err := r.Get("java"); err == nil || err.Error() != `generator "java" not found` {
+		t.Errorf("expected an error for an unknown name, got %v", err)
+	}
+
+	if names := r.List(); len(names) != 1 || names[0] != "python+pydantic" {
+		t.Errorf("expected List without aliases, got %v", names)
+	}
+	expected := []string{"py", "python", "python+pydantic", "rust"}
+	if names := r.Names(true); strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected names %v, got %v", expected, names)
+	}
+
+	// A generator registered under an alias takes precedence
+	r.Register("py", func() Generator { return &plainGenerator{} })
+	if generator, _ := r.Get("py"); generator == nil {
+		t.Error("expected the py generator")
+	} else if _, ok := generator.(*plainGenerator); !ok {
+		t.Errorf("expected the generator registered as py, got %T", generator)
+	}
+
+	r.Deprecate("python+pydantic", "")
+	info, err := r.Info("python")
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Name != "python+pydantic" || strings.Join(info.Aliases, " ") != "python" || strings.Join(info.Deprecated, " ") != "python+pydantic" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+}
+
+func TestRegistry_DeprecationWarning(t *testing.T) {
+	r := NewRegistry()
+	r.Register("python", func() Generator { return &plainGenerator{} })
+	r.Register("legacy", func() Generator { return &plainGenerator{} })
+	r.RegisterAlias("python+pydantic", "python")
+	r.Deprecate("python+pydantic", "")
+	r.Deprecate("legacy", "it will be removed in v2")
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"python", ""},
+		{"python+pydantic", `generator name "python+pydantic" is deprecated; use "python" instead`},
+		{"python+pydantic", ""},
+		{"legacy", `generator name "legacy" is deprecated: it will be removed in v2`},
+		{"legacy", ""},
+		{"missing", ""},
+	}
+	for i, tt := range tests {
+		if warning := r.DeprecationWarning(tt.name); warning != tt.expected {
+			t.Errorf("%d: expected warning %q for %s, got %q", i, tt.expected, tt.name, warning)
+		}
+	}
+}
+
+func TestRegistry_Unregister(t *testing.T) {
+	r := NewRegistry()
+	r.Register("plain", func() Generator { return &plainGenerator{} })
+	r.RegisterAlias("p", "plain")
+
+	r.Unregister("p")
+	if _, err := r.Get("p"); err == nil {
+		t.Error("expected the alias to be removed")
+	}
+	r.Unregister("plain")
+	if _, err := r.Get("plain"); err == nil || len(r.List()) != 0 {
+		t.Error("expected the generator to be removed")
+	}
+	r.Unregister("missing")
+}
+
+// register registers a generator in the global registry until the end of
+// the test
+func register(t *testing.T, name string, constructor func() Generator) {
+	t.Helper()
+	Register(name, constructor)
+	t.Cleanup(func() { Unregister(name) })
+}
+
+func TestUseRegistry(t *testing.T) {
+	register(t, "global-test", func() Generator { return &plainGenerator{} })
+
+	scoped := NewRegistry()
+	restore := UseRegistry(scoped)
+	Register("scoped-test", func() Generator { return &plainGenerator{} })
+	if names := List(); len(names) != 1 || names[0] != "scoped-test" {
+		t.Errorf("expected only the scoped generator, got %v", names)
+	}
+	restore()
+
+	if _, err := Get("scoped-test"); err == nil {
+		t.Error("expected the scoped generator to be gone after restoring")
+	}
+	if _, err := Get("global-test"); err != nil {
+		t.Errorf("expected the global generator after restoring, got %v", err)
+	}
+}
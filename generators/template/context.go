@@ -0,0 +1,387 @@
+package template
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// The template context. Its fields and methods are documented in the README
+// and are a stable API: templates written against them keep working, so only
+// add to them.
+
+// Module is the context of module.tmpl, executed once per module
+type Module struct {
+	// Name is the name of the module's directory
+	Name string
+	// Path is the slash-separated path of the module from the root module,
+	// "" for the root
+	Path string
+	// Files are the module's .tg files, sorted by name
+	Files []*File
+	// SubModules are the module's submodules, sorted by name
+	SubModules []*Module
+	// Header is generators.GeneratedHeader, for templates to write in a
+	// comment at the top of generated files
+	Header string
+}
+
+// File is the context of file.tmpl, executed once per .tg file
+type File struct {
+	// Name is the file name without the .tg extension, e.g. "user"
+	Name string
+	// Path is the slash-separated path of the file from the root module,
+	// e.g. "auth/user.tg"
+	Path string
+	// Module is the module holding the file
+	Module *Module
+	// Imports are the file's import paths, as written, e.g. "auth"
+	Imports []string
+	// Declarations are the file's declarations, in source order
+	Declarations []*Declaration
+	// Header is generators.GeneratedHeader
+	Header string
+}
+
+// Structs returns the file's struct declarations
+func (f *File) Structs() []*Declaration { return f.declarations(KindStruct) }
+
+// Enums returns the file's enum declarations
+func (f *File) Enums() []*Declaration { return f.declarations(KindEnum) }
+
+// Aliases returns the file's type alias declarations
+func (f *File) Aliases() []*Declaration { return f.declarations(KindAlias) }
+
+// Constants returns the file's constant declarations
+func (f *File) Constants() []*Declaration { return f.declarations(KindConst) }
+
+func (f *File) declarations(kind string) []*Declaration {
+	var decls []*Declaration
+	for _, decl := range f.Declarations {
+		if decl.Kind == kind {
+			decls = append(decls, decl)
+		}
+	}
+	return decls
+}
+
+// Declaration kinds
+const (
+	KindStruct = "struct"
+	KindEnum   = "enum"
+	KindAlias  = "alias"
+	KindConst  = "const"
+)
+
+// Declaration is a struct, enum, type alias or constant
+type Declaration struct {
+	// Kind is "struct", "enum", "alias" or "const"
+	Kind string
+	// Name is the declared name
+	Name string
+	// Module is the path of the declaring module, as Module.Path
+	Module string
+	// File is the path of the declaring file, as File.Path
+	File string
+	// Fields are the fields of a struct
+	Fields []*Field
+	// Variants are the variants of an enum
+	Variants []*Variant
+	// Type is the aliased type of an alias
+	Type *Type
+	// Value is the value of a constant: an int64, a float64, a string or a bool
+	Value any
+	// Doc is the lines of the comment above the declaration, without "//"
+	Doc []string
+}
+
+// IsUnion reports whether the declaration is an enum with a payload on any
+// variant, a tagged union
+func (d *Declaration) IsUnion() bool {
+	for _, variant := range d.Variants {
+		if variant.Payload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Field is a field of a struct
+type Field struct {
+	// Name is the field name
+	Name string
+	// JSONName is the field's key in JSON: its wire name if it has one,
+	// otherwise its name
+	JSONName string
+	// Type is the field's type, without the ? of an optional field
+	Type *Type
+	// Optional is true for fields declared name: ?Type
+	Optional bool
+	// Doc is the lines of the comment above the field
+	Doc []string
+	// Attributes are the field's attributes, in source order
+	Attributes []*Attribute
+}
+
+// Attribute returns the field's attribute with a name, or nil
+func (f *Field) Attribute(name string) *Attribute {
+	return findAttribute(f.Attributes, name)
+}
+
+// Variant is a variant of an enum
+type Variant struct {
+	// Name is the variant name
+	Name string
+	// JSONName is the variant's type in JSON: its wire name if it has one,
+	// otherwise its name
+	JSONName string
+	// Payload is the variant's payload type, nil for a simple variant
+	Payload *Type
+	// Doc is the lines of the comment above the variant
+	Doc []string
+	// Attributes are the variant's attributes, in source order
+	Attributes []*Attribute
+}
+
+// Attribute returns the variant's attribute with a name, or nil
+func (v *Variant) Attribute(name string) *Attribute {
+	return findAttribute(v.Attributes, name)
+}
+
+// Attribute is an attribute of a field or variant, written @name or
+// @name(value)
+type Attribute struct {
+	// Name is the attribute's name, without the @
+	Name string
+	// Value is the attribute's value as Declaration.Value, nil without one
+	Value any
+}
+
+func findAttribute(attributes []*Attribute, name string) *Attribute {
+	for _, attribute := range attributes {
+		if attribute.Name == name {
+			return attribute
+		}
+	}
+	return nil
+}
+
+// Type kinds
+const (
+	KindPrimitive = "primitive"
+	KindNamed     = "named"
+	KindArray     = "array"
+	KindMap       = "map"
+	KindOptional  = "optional"
+)
+
+// Type is a type expression
+type Type struct {
+	// Kind is "primitive", "named", "array", "map" or "optional"
+	Kind string
+	// Name is the name of a primitive or named type, as written, e.g.
+	// "int64" or "auth.User"
+	Name string
+	// Elem is the element type of an array or optional
+	Elem *Type
+	// Key and Value are the types of a map
+	Key   *Type
+	Value *Type
+	// Decl is the declaration a named type refers to, across modules for
+	// imported types; nil if it can't be resolved
+	Decl *Declaration
+}
+
+// String returns the type in TypeGen syntax, e.g. "[]?string"
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindArray:
+		return "[]" + t.Elem.String()
+	case KindMap:
+		return "[" + t.Key.String() + "]" + t.Value.String()
+	case KindOptional:
+		return "?" + t.Elem.String()
+	}
+	return t.Name
+}
+
+// newContext builds the template context of a module and its submodules,
+// resolving named types across modules
+func newContext(root *ast.Module) *Module {
+	b := &contextBuilder{decls: make(map[string]*Declaration)}
+	module := b.module(root, "")
+	for _, file := range b.files {
+		b.resolveFile(file)
+	}
+	return module
+}
+
+// contextBuilder builds the context in two passes: declarations first, then
+// the types, which refer to declarations
+type contextBuilder struct {
+	// decls are the declarations by module path and name, see declKey
+	decls map[string]*Declaration
+	files []*fileSource
+}
+
+// fileSource is a file of the context with its AST, for resolving types
+type fileSource struct {
+	file    *File
+	program *ast.ProgramNode
+}
+
+// declKey is the key of a declaration in contextBuilder.decls
+func declKey(modulePath, name string) string {
+	return modulePath + "\x00" + name
+}
+
+func (b *contextBuilder) module(module *ast.Module, modulePath string) *Module {
+	m := &Module{Name: module.Name, Path: modulePath, Header: generators.GeneratedHeader}
+
+	filenames := make([]string, 0, len(module.Files))
+	for filename := range module.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		program := module.Files[filename]
+		file := &File{
+			Name:   strings.TrimSuffix(filename, ".tg"),
+			Path:   path.Join(modulePath, filename),
+			Module: m,
+			Header: generators.GeneratedHeader,
+		}
+		for _, imp := range program.Imports {
+			file.Imports = append(file.Imports, imp.Path)
+		}
+		for _, decl := range program.Declarations {
+			d := &Declaration{Module: modulePath, File: file.Path}
+			switch decl := decl.(type) {
+			case *ast.StructNode:
+				d.Kind, d.Name, d.Doc = KindStruct, decl.Name, decl.Doc
+			case *ast.EnumNode:
+				d.Kind, d.Name, d.Doc = KindEnum, decl.Name, decl.Doc
+			case *ast.TypeAliasNode:
+				d.Kind, d.Name, d.Doc = KindAlias, decl.Name, decl.Doc
+			case *ast.ConstantNode:
+				d.Kind, d.Name, d.Doc = KindConst, decl.Name, decl.Doc
+				d.Value = constantValue(decl.Value)
+			default:
+				continue
+			}
+			file.Declarations = append(file.Declarations, d)
+			if d.Kind != KindConst {
+				b.decls[declKey(modulePath, d.Name)] = d
+			}
+		}
+		m.Files = append(m.Files, file)
+		b.files = append(b.files, &fileSource{file: file, program: program})
+	}
+
+	subModuleNames := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		subModuleNames = append(subModuleNames, name)
+	}
+	sort.Strings(subModuleNames)
+	for _, name := range subModuleNames {
+		m.SubModules = append(m.SubModules, b.module(module.SubModules[name], path.Join(modulePath, name)))
+	}
+	return m
+}
+
+// resolveFile fills in the fields, variants and aliased types of a file's
+// declarations
+func (b *contextBuilder) resolveFile(source *fileSource) {
+	// Import alias -> module path, e.g. "auth" -> "auth" and "common.types" ->
+	// "common/types" under the alias "types"
+	imports := make(map[string]string)
+	for _, imp := range source.file.Imports {
+		parts := strings.Split(imp, ".")
+		imports[parts[len(parts)-1]] = strings.Join(parts, "/")
+	}
+	resolve := func(t ast.Type) *Type {
+		return b.typeOf(t, source.file.Module.Path, imports)
+	}
+
+	i := 0
+	for _, decl := range source.program.Declarations {
+		var d *Declaration
+		switch decl := decl.(type) {
+		case *ast.StructNode:
+			d = source.file.Declarations[i]
+			for _, field := range decl.Fields {
+				d.Fields = append(d.Fields, &Field{Name: field.Name, JSONName: field.JSONName(), Type: resolve(field.Type), Optional: field.Optional, Doc: field.Doc, Attributes: attributes(field.Attributes)})
+			}
+		case *ast.EnumNode:
+			d = source.file.Declarations[i]
+			for _, variant := range decl.Variants {
+				v := &Variant{Name: variant.Name, JSONName: variant.JSONName(), Doc: variant.Doc, Attributes: attributes(variant.Attributes)}
+				if variant.Payload != nil {
+					v.Payload = resolve(variant.Payload)
+				}
+				d.Variants = append(d.Variants, v)
+			}
+		case *ast.TypeAliasNode:
+			d = source.file.Declarations[i]
+			d.Type = resolve(decl.Type)
+		case *ast.ConstantNode:
+		default:
+			continue
+		}
+		i++
+	}
+}
+
+// typeOf converts a type expression of a file in modulePath
+func (b *contextBuilder) typeOf(t ast.Type, modulePath string, imports map[string]string) *Type {
+	switch t := t.(type) {
+	case *ast.PrimitiveType:
+		return &Type{Kind: KindPrimitive, Name: t.Name}
+	case *ast.NamedType:
+		named := &Type{Kind: KindNamed, Name: t.Name}
+		module, name := modulePath, t.Name
+		if idx := strings.LastIndex(t.Name, "."); idx >= 0 {
+			imported, ok := imports[t.Name[:idx]]
+			if !ok {
+				return named
+			}
+			module, name = imported, t.Name[idx+1:]
+		}
+		named.Decl = b.decls[declKey(module, name)]
+		return named
+	case *ast.ArrayType:
+		return &Type{Kind: KindArray, Elem: b.typeOf(t.ElementType, modulePath, imports)}
+	case *ast.MapType:
+		return &Type{Kind: KindMap, Key: b.typeOf(t.KeyType, modulePath, imports), Value: b.typeOf(t.ValueType, modulePath, imports)}
+	case *ast.OptionalType:
+		return &Type{Kind: KindOptional, Elem: b.typeOf(t.ElementType, modulePath, imports)}
+	}
+	return &Type{Kind: KindPrimitive, Name: t.String()}
+}
+
+// constantValue returns the Go value of a constant: an int64, a float64,
+// a string or a bool
+func constantValue(value ast.ConstantValue) any {
+	switch value := value.(type) {
+	case *ast.IntConstant:
+		return value.Value
+	case *ast.FloatConstant:
+		return value.Value
+	case *ast.BoolConstant:
+		return value.Value
+	case *ast.StringConstant:
+		return value.Value
+	}
+	return nil
+}
+
+func attributes(nodes []*ast.AttributeNode) []*Attribute {
+	var result []*Attribute
+	for _, node := range nodes {
+		result = append(result, &Attribute{Name: node.Name, Value: constantValue(node.Value)})
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package template
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/WhatsApp-Platform/typegen/naming"
+)
+
+// funcs are the helper functions available to templates, documented in the
+// README. Like the context, they are a stable API.
+var funcs = texttemplate.FuncMap{
+	"pascal":    naming.PascalCase,
+	"camel":     naming.CamelCase,
+	"snake":     naming.SnakeCase,
+	"screaming": naming.ScreamingSnakeCase,
+	"kebab":     naming.KebabCase,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"join":      join,
+	"quote":     strconv.Quote,
+	"indent":    indent,
+
+	"isOptional":  isOptional,
+	"isPrimitive": func(t *Type) bool { return t != nil && t.Kind == KindPrimitive },
+	"isNamed":     func(t *Type) bool { return t != nil && t.Kind == KindNamed },
+	"isArray":     func(t *Type) bool { return t != nil && t.Kind == KindArray },
+	"isMap":       func(t *Type) bool { return t != nil && t.Kind == KindMap },
+	"unwrap":      unwrap,
+}
+
+// join joins the elements of a list with sep. It takes the list last, so it
+// can be piped: {{ .Imports | join ", " }}
+func join(sep string, list any) (string, error) {
+	switch list := list.(type) {
+	case []string:
+		return strings.Join(list, sep), nil
+	case []*Declaration:
+		names := make([]string, len(list))
+		for i, decl := range list {
+			names[i] = decl.Name
+		}
+		return strings.Join(names, sep), nil
+	}
+	return "", fmt.Errorf("join: can't join %T", list)
+}
+
+// indent indents every non-empty line of s by n spaces
+func indent(n int, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = strings.Repeat(" ", n) + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isOptional reports whether a field is optional, or a type is ?T
+func isOptional(v any) (bool, error) {
+	switch v := v.(type) {
+	case *Field:
+		return v.Optional || v.Type.Kind == KindOptional, nil
+	case *Type:
+		return v != nil && v.Kind == KindOptional, nil
+	}
+	return false, fmt.Errorf("isOptional: expected a field or a type, got %T", v)
+}
+
+// unwrap returns the element type of ?T, and other types as they are
+func unwrap(t *Type) *Type {
+	for t != nil && t.Kind == KindOptional {
+		t = t.Elem
+	}
+	return t
+}
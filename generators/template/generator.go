@@ -0,0 +1,234 @@
+// Package template implements a generator that executes user-provided Go
+// text/templates, for simple targets such as documentation and config stubs
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Templates of the template directory
+const (
+	// fileTemplate is executed once per .tg file, with a *File
+	fileTemplate = "file.tmpl"
+	// moduleTemplate is executed once per module, with a *Module
+	moduleTemplate = "module.tmpl"
+)
+
+// Defaults of the naming templates
+const (
+	defaultFileName  = "{{ .Name }}.txt"
+	defaultIndexName = "index.txt"
+)
+
+// Generator renders the templates of a directory against the template
+// context of a module
+type Generator struct {
+	config map[string]string // Configuration options
+}
+
+// NewGenerator creates a new template generator
+func NewGenerator() *Generator {
+	return &Generator{
+		config: make(map[string]string),
+	}
+}
+
+// SetConfig implements generators.Generator interface
+func (g *Generator) SetConfig(config map[string]string) {
+	g.config = config
+}
+
+// Name implements generators.Describer interface
+func (g *Generator) Name() string {
+	return "template"
+}
+
+// Description implements generators.Describer interface
+func (g *Generator) Description() string {
+	return "Output of user-provided Go text/templates, per file and per module"
+}
+
+// Options implements generators.Describer interface
+func (g *Generator) Options() []generators.OptionSpec {
+	return []generators.OptionSpec{
+		{Key: "template-dir", Type: "string", Description: "Directory holding file.tmpl, executed per .tg file, module.tmpl, executed per module, and other *.tmpl files they share; required"},
+		{Key: "file-name", Type: "string", Default: defaultFileName, Description: "Template of the path file.tmpl writes to, relative to the module's directory; executed with the file"},
+		{Key: "index-name", Type: "string", Default: defaultIndexName, Description: "Template of the path module.tmpl writes to, relative to the module's directory; executed with the module"},
+	}
+}
+
+// Generate implements generators.Generator interface for module generation
+func (g *Generator) Generate(ctx context.Context, module *ast.Module, dest generators.FS) error {
+	dir := g.config["template-dir"]
+	if dir == "" {
+		return fmt.Errorf("template-dir is required: the directory of %s and %s", fileTemplate, moduleTemplate)
+	}
+	templates, err := loadTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	r := &renderer{
+		dir:     dir,
+		file:    templates.Lookup(fileTemplate),
+		module:  templates.Lookup(moduleTemplate),
+		dest:    dest,
+		written: make(map[string]string),
+	}
+	if r.file == nil && r.module == nil {
+		return fmt.Errorf("%s has neither %s nor %s", dir, fileTemplate, moduleTemplate)
+	}
+	if r.fileName, err = namingTemplate("file-name", g.config["file-name"], defaultFileName); err != nil {
+		return err
+	}
+	if r.indexName, err = namingTemplate("index-name", g.config["index-name"], defaultIndexName); err != nil {
+		return err
+	}
+
+	return r.renderModule(ctx, newContext(module))
+}
+
+// loadTemplates parses the *.tmpl files of dir into one set, so they can
+// use each other's definitions. Templates are named after their file.
+func loadTemplates(dir string) (*texttemplate.Template, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("template-dir: %w", err)
+		}
+		return nil, fmt.Errorf("no templates (*.tmpl) in %s", dir)
+	}
+	sort.Strings(paths)
+
+	templates := texttemplate.New("").Funcs(funcs).Option("missingkey=error")
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := templates.New(filepath.Base(p)).Parse(string(content)); err != nil {
+			return nil, templateError(dir, err)
+		}
+	}
+	return templates, nil
+}
+
+// namingTemplate parses the naming template of an option
+func namingTemplate(option, text, defaultText string) (*texttemplate.Template, error) {
+	if text == "" {
+		text = defaultText
+	}
+	t, err := texttemplate.New(option).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s option: %w", option, err)
+	}
+	return t, nil
+}
+
+// templateError rewrites the "template: file.tmpl:3: ..." errors of
+// text/template as "dir/file.tmpl:3: ...", pointing at the template file and
+// line
+func templateError(dir string, err error) error {
+	msg, ok := strings.CutPrefix(err.Error(), "template: ")
+	if !ok {
+		return err
+	}
+	return errors.New(filepath.Clean(dir) + string(filepath.Separator) + msg)
+}
+
+// renderer renders the templates of a Generate call
+type renderer struct {
+	dir       string
+	file      *texttemplate.Template // nil without file.tmpl
+	module    *texttemplate.Template // nil without module.tmpl
+	fileName  *texttemplate.Template
+	indexName *texttemplate.Template
+	dest      generators.FS
+
+	// written maps the paths written so far to what they were rendered for,
+	// to catch naming templates giving the same path twice
+	written map[string]string
+}
+
+// renderModule renders a module, its files and its submodules
+func (r *renderer) renderModule(ctx context.Context, module *Module) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if r.file != nil {
+		for _, file := range module.Files {
+			if err := r.render(r.file, r.fileName, file, module.Path, file.Path); err != nil {
+				return err
+			}
+		}
+	}
+	if r.module != nil {
+		what := "module " + module.Path
+		if module.Path == "" {
+			what = "the root module"
+		}
+		if err := r.render(r.module, r.indexName, module, module.Path, what); err != nil {
+			return err
+		}
+	}
+
+	for _, subModule := range module.SubModules {
+		if err := r.renderModule(ctx, subModule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render executes t with data, and writes the output to the path given by
+// the naming template, in the directory of the module at modulePath. what
+// names data in errors.
+func (r *renderer) render(t, naming *texttemplate.Template, data any, modulePath, what string) error {
+	var name bytes.Buffer
+	if err := naming.Execute(&name, data); err != nil {
+		return fmt.Errorf("%s option for %s: %w", naming.Name(), what, err)
+	}
+	fileName := filepath.ToSlash(strings.TrimSpace(name.String()))
+	relPath := path.Join(modulePath, fileName)
+	if fileName == "" || path.IsAbs(fileName) || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return fmt.Errorf("%s option gives %q for %s, which is not a file in the output directory", naming.Name(), name.String(), what)
+	}
+	if previous, exists := r.written[relPath]; exists {
+		return fmt.Errorf("%s option gives %s for both %s and %s", naming.Name(), relPath, previous, what)
+	}
+	r.written[relPath] = what
+
+	var content bytes.Buffer
+	if err := t.Execute(&content, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", what, templateError(r.dir, err))
+	}
+
+	outputPath := r.dest.Join(strings.Split(relPath, "/")...)
+	if err := r.dest.WriteFile(outputPath, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
+
+func init() {
+	// Register the template generator globally
+	generators.Register("template", func() generators.Generator {
+		return NewGenerator()
+	})
+}
@@ -0,0 +1,259 @@
+package template
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// generate renders templates against module and returns the generated in-memory files
+func generate(t *testing.T, module *ast.Module, config map[string]string) (*generators.InMemoryFS, error) {
+	t.Helper()
+
+	fs := generators.NewInMemoryFS()
+	g := NewGenerator()
+	g.SetConfig(config)
+	return fs, g.Generate(context.Background(), module, fs)
+}
+
+// parseModule parses a single-file module
+func parseModule(t *testing.T, input string) *ast.Module {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(input), "user.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	return ast.NewModule("test", map[string]*ast.ProgramNode{"user.tg": program})
+}
+
+// writeTemplates writes templates to a new directory and returns it
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGenerateMarkdownExample(t *testing.T) {
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "shop"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	fs, err := generate(t, module, map[string]string{
+		"template-dir": filepath.Join("examples", "markdown"),
+		"file-name":    "{{ .Name }}.md",
+		"index-name":   "README.md",
+	})
+	if err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+
+	expected := []string{"README.md", "auth/README.md", "auth/session.md", "auth/user.md", "order.md", "product.md"}
+	if files := fs.ListFiles(); strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected files %v, got %v", expected, files)
+	}
+
+	for _, name := range expected {
+		content, _ := fs.GetFileString(name)
+		goldenPath := filepath.Join("testdata", "markdown", filepath.FromSlash(name)+".golden")
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(goldenPath, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to update golden file: %v", err)
+			}
+		}
+
+		golden, err := os.ReadFile(goldenPath)
+		if err != nil {
+			t.Fatalf("Failed to read golden file: %v", err)
+		}
+		if string(golden) != content {
+			t.Errorf("Output does not match %s.\nExpected:\n%s\nGot:\n%s", goldenPath, golden, content)
+		}
+		if !strings.Contains(content, generators.GeneratedHeader) {
+			t.Errorf("%s has no generated header", name)
+		}
+	}
+}
+
+func TestTemplateContext(t *testing.T) {
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "shop"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	root := newContext(module)
+
+	if root.Path != "" || len(root.Files) != 2 || len(root.SubModules) != 1 || root.SubModules[0].Path != "auth" {
+		t.Fatalf("unexpected module: %+v", root)
+	}
+	order := root.Files[0]
+	if order.Path != "order.tg" || order.Name != "order" || order.Module != root {
+		t.Fatalf("unexpected file: %+v", order)
+	}
+	if len(order.Structs()) != 3 || len(order.Enums()) != 1 || len(order.Constants()) != 1 || len(order.Aliases()) != 0 {
+		t.Errorf("unexpected declarations of order.tg: %v", order.Declarations)
+	}
+
+	fields := order.Structs()[0].Fields
+	types := map[string]string{}
+	for _, field := range fields {
+		types[field.Name] = field.Type.String()
+	}
+	if types["buyer"] != "auth.User" || types["metadata"] != "[string]json" || types["coupon"] != "Coupon" {
+		t.Errorf("unexpected field types: %v", types)
+	}
+
+	// Named types resolve within the module, across files, and through imports
+	buyer := fields[1].Type.Decl
+	if buyer == nil || buyer.Kind != KindStruct || buyer.Module != "auth" || buyer.File != "auth/user.tg" {
+		t.Errorf("expected auth.User to resolve to the struct of auth/user.tg, got %+v", buyer)
+	}
+	if item := fields[2].Type.Elem.Decl; item == nil || item.File != "product.tg" {
+		t.Errorf("expected LineItem to resolve to product.tg, got %+v", item)
+	}
+	if !fields[3].Optional || fields[3].Type.Kind != KindNamed {
+		t.Errorf("expected coupon to be an optional field of a named type, got %+v", fields[3])
+	}
+
+	status := order.Enums()[0]
+	if !status.IsUnion() || status.Variants[0].Payload != nil || status.Variants[1].Payload.Decl.Name != "Shipment" {
+		t.Errorf("unexpected enum: %+v", status)
+	}
+	if constant := order.Constants()[0]; constant.Value != int64(100) {
+		t.Errorf("expected MAX_ITEMS to be 100, got %#v", constant.Value)
+	}
+	if alias := root.Files[1].Aliases()[1]; alias.Name != "Catalog" || alias.Type.Kind != KindArray || alias.Type.Elem.Decl.Name != "Product" {
+		t.Errorf("unexpected alias: %+v", alias)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	module := parseModule(t, "struct User {\n  id: int64\n}\n")
+
+	tests := []struct {
+		name      string
+		templates map[string]string
+		config    map[string]string
+		err       string
+	}{
+		{"missing template-dir", nil, map[string]string{}, "template-dir is required"},
+		{"no templates", map[string]string{"README": "x"}, nil, "no templates (*.tmpl) in"},
+		{"only shared templates", map[string]string{"types.tmpl": `{{ define "x" }}{{ end }}`}, nil, "has neither file.tmpl nor module.tmpl"},
+		{"parse error", map[string]string{"file.tmpl": "line\n{{ .Name "}, nil, "file.tmpl:2: unclosed action"},
+		{"unknown function", map[string]string{"file.tmpl": "{{ shout .Name }}"}, nil, `file.tmpl:1: function "shout" not defined`},
+		{"execution error", map[string]string{"file.tmpl": "line\n{{ range .Structs }}{{ .Nmae }}{{ end }}"}, nil, `rendering user.tg: DIR/file.tmpl:2:23: executing "file.tmpl" at <.Nmae>: can't evaluate field Nmae`},
+		{"error in a shared template", map[string]string{"file.tmpl": `{{ template "x" . }}`, "shared.tmpl": "{{ define \"x\" }}\n{{ .Missing }}{{ end }}"}, nil, "DIR/shared.tmpl:2:3: executing"},
+		{"invalid naming template", map[string]string{"file.tmpl": "x"}, map[string]string{"file-name": "{{ .Name "}, "invalid file-name option"},
+		{"path outside the output", map[string]string{"file.tmpl": "x"}, map[string]string{"file-name": "../{{ .Name }}.md"}, `file-name option gives "../user.md" for user.tg, which is not a file in the output directory`},
+		{"same path twice", map[string]string{"file.tmpl": "x", "module.tmpl": "y"}, map[string]string{"file-name": "index.txt"}, "index-name option gives index.txt for both user.tg and the root module"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			if config == nil {
+				config = map[string]string{}
+			}
+			dir := ""
+			if tt.templates != nil {
+				dir = writeTemplates(t, tt.templates)
+				config["template-dir"] = dir
+			}
+
+			_, err := generate(t, module, config)
+			expected := strings.ReplaceAll(tt.err, "DIR/", dir+string(filepath.Separator))
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected error containing %q, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestGenerateOutputPaths(t *testing.T) {
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "shop"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	dir := writeTemplates(t, map[string]string{
+		"file.tmpl":   "{{ range .Declarations }}{{ .Name }}\n{{ end }}",
+		"module.tmpl": "{{ len .Files }}",
+	})
+
+	// Without module.tmpl's naming option, the default is used; the file
+	// naming template may put files in subdirectories of the module's
+	fs, err := generate(t, module, map[string]string{"template-dir": dir, "file-name": "docs/{{ snake .Name }}.txt"})
+	if err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	expected := []string{"auth/docs/session.txt", "auth/docs/user.txt", "auth/index.txt", "docs/order.txt", "docs/product.txt", "index.txt"}
+	if files := fs.ListFiles(); strings.Join(files, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+	if content, _ := fs.GetFileString("auth/docs/user.txt"); content != "User\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestGenerateDocAndAttributes(t *testing.T) {
+	module := parseModule(t, `// User is a customer
+struct User {
+  // The login name
+  login: string @deprecated("use email") @max_length(64)
+  email: string
+}
+`)
+	dir := writeTemplates(t, map[string]string{
+		"file.tmpl": `{{ range .Structs }}{{ join " " .Doc }}
+{{ range .Fields }}{{ .Name }}: {{ join " " .Doc }}{{ range .Attributes }} @{{ .Name }}={{ .Value }}{{ end }}{{ with .Attribute "deprecated" }} (deprecated){{ end }}
+{{ end }}{{ end }}`,
+	})
+
+	fs, err := generate(t, module, map[string]string{"template-dir": dir})
+	if err != nil {
+		t.Fatalf("Generation error: %v", err)
+	}
+	expected := "User is a customer\nlogin: The login name @deprecated=use email @max_length=64 (deprecated)\nemail: \n"
+	if content, _ := fs.GetFileString("user.txt"); content != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func TestNamingFuncs(t *testing.T) {
+	tests := []struct {
+		input                                  string
+		pascal, camel, snake, screaming, kebab string
+	}{
+		{"user_id", "UserId", "userId", "user_id", "USER_ID", "user-id"},
+		{"UserProfile", "UserProfile", "userProfile", "user_profile", "USER_PROFILE", "user-profile"},
+		{"HTTPServer", "HttpServer", "httpServer", "http_server", "HTTP_SERVER", "http-server"},
+		{"MAX_ITEMS", "MaxItems", "maxItems", "max_items", "MAX_ITEMS", "max-items"},
+		{"v2_api", "V2Api", "v2Api", "v2_api", "V2_API", "v2-api"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, name := range []string{"pascal", "camel", "snake", "screaming", "kebab"} {
+			got = append(got, funcs[name].(func(string) string)(tt.input))
+		}
+		expected := []string{tt.pascal, tt.camel, tt.snake, tt.screaming, tt.kebab}
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Errorf("%s: expected %v, got %v", tt.input, expected, got)
+		}
+	}
+}
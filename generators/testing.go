@@ -1,76 +1,157 @@
 package generators
 
 import (
+	"errors"
+	iofs "io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
-	"strings"
+	"sync"
 )
 
-// InMemoryFS implements FS interface for testing purposes
+// ErrInjected is the error of the writes that an InMemoryFS is set to fail,
+// see FailWritesMatching and FailAfterN
+var ErrInjected = errors.New("injected write failure")
+
+// InMemoryFS implements FS interface for testing purposes. It is safe for
+// concurrent use. Paths are stored slash-separated and cleaned, so "a/b.go",
+// `a\b.go` on Windows and "./a/b.go" are the same file.
 type InMemoryFS struct {
+	mu    sync.Mutex
 	files map[string][]byte
 	dirs  map[string]bool
+	ops   []Op
+
+	// Failure injection, see FailWritesMatching and FailAfterN
+	failPatterns []string
+	failAfter    int // writes that succeed before all fail; -1 for no limit
+	writes       int // successful writes
+}
+
+// Op is an operation on an InMemoryFS, recorded for assertions; see Ops
+type Op struct {
+	// Kind is "write" or "mkdir"
+	Kind string
+	// Path is slash-separated and cleaned
+	Path string
+	// Bytes is the size of a write
+	Bytes int
+	// Err is the error returned, such as one wrapping ErrInjected
+	Err error
 }
 
 // NewInMemoryFS creates a new in-memory filesystem for testing
 func NewInMemoryFS() *InMemoryFS {
 	return &InMemoryFS{
-		files: make(map[string][]byte),
-		dirs:  make(map[string]bool),
+		files:     make(map[string][]byte),
+		dirs:      make(map[string]bool),
+		failAfter: -1,
+	}
+}
+
+// normalize returns the key of a path: slash-separated and cleaned
+func normalize(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// FailWritesMatching makes the writes to the files matching pattern fail
+// with an error wrapping ErrInjected. The pattern has the syntax of
+// path.Match and is matched against the slash-separated path, so "*.go"
+// only matches files at the root and "models/*.go" the files of models.
+func (fs *InMemoryFS) FailWritesMatching(pattern string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.failPatterns = append(fs.failPatterns, pattern)
+}
+
+// FailAfterN makes every write after the first n successful ones fail with
+// an error wrapping ErrInjected
+func (fs *InMemoryFS) FailAfterN(n int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.failAfter = n
+}
+
+// Ops returns the operations done so far, in order, failed ones included
+func (fs *InMemoryFS) Ops() []Op {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return append([]Op{}, fs.ops...)
+}
+
+// injectedFailure returns the error of a write to name that fails by
+// injection, or nil. fs.mu must be held.
+func (fs *InMemoryFS) injectedFailure(name string) error {
+	if fs.failAfter >= 0 && fs.writes >= fs.failAfter {
+		return &iofs.PathError{Op: "write", Path: name, Err: ErrInjected}
+	}
+	for _, pattern := range fs.failPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return &iofs.PathError{Op: "write", Path: name, Err: ErrInjected}
+		}
+	}
+	return nil
+}
+
+// addDirs records dir and its parents. fs.mu must be held.
+func (fs *InMemoryFS) addDirs(dir string) {
+	for ; dir != "." && dir != "/" && !fs.dirs[dir]; dir = path.Dir(dir) {
+		fs.dirs[dir] = true
 	}
 }
 
 // WriteFile implements FS.WriteFile
 func (fs *InMemoryFS) WriteFile(name string, data []byte, perm os.FileMode) error {
-	// Normalize path separators
-	name = filepath.ToSlash(name)
-	
-	// Track directory creation
-	dir := filepath.Dir(name)
-	if dir != "." {
-		fs.dirs[dir] = true
-		// Create all parent directories
-		parts := strings.Split(dir, "/")
-		for i := range parts {
-			parentDir := strings.Join(parts[:i+1], "/")
-			fs.dirs[parentDir] = true
-		}
+	name = normalize(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.injectedFailure(name); err != nil {
+		fs.ops = append(fs.ops, Op{Kind: "write", Path: name, Bytes: len(data), Err: err})
+		return err
 	}
-	
+
+	// Track directory creation
+	fs.addDirs(path.Dir(name))
+
 	// Store the file
 	fs.files[name] = make([]byte, len(data))
 	copy(fs.files[name], data)
-	
+	fs.writes++
+	fs.ops = append(fs.ops, Op{Kind: "write", Path: name, Bytes: len(data)})
+
 	return nil
 }
 
 // MkdirAll implements FS.MkdirAll
-func (fs *InMemoryFS) MkdirAll(path string, perm os.FileMode) error {
-	// Normalize path separators
-	path = filepath.ToSlash(path)
-	
-	fs.dirs[path] = true
-	
-	// Create all parent directories
-	parts := strings.Split(path, "/")
-	for i := range parts {
-		parentDir := strings.Join(parts[:i+1], "/")
-		fs.dirs[parentDir] = true
-	}
-	
+func (fs *InMemoryFS) MkdirAll(dir string, perm os.FileMode) error {
+	dir = normalize(dir)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.addDirs(dir)
+	fs.ops = append(fs.ops, Op{Kind: "mkdir", Path: dir})
+
 	return nil
 }
 
-// Join implements FS.Join
+// Join implements FS.Join. Paths are joined with slashes on every platform,
+// as they are stored.
 func (fs *InMemoryFS) Join(elem ...string) string {
-	return filepath.Join(elem...)
+	parts := make([]string, len(elem))
+	for i, e := range elem {
+		parts[i] = filepath.ToSlash(e)
+	}
+	return path.Join(parts...)
 }
 
 // GetFile returns the content of a file for testing assertions
-func (fs *InMemoryFS) GetFile(path string) ([]byte, bool) {
-	path = filepath.ToSlash(path)
-	content, exists := fs.files[path]
+func (fs *InMemoryFS) GetFile(name string) ([]byte, bool) {
+	name = normalize(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	content, exists := fs.files[name]
 	if !exists {
 		return nil, false
 	}
@@ -80,9 +161,23 @@ func (fs *InMemoryFS) GetFile(path string) ([]byte, bool) {
 	return result, true
 }
 
+// ReadFile implements ReadFS.ReadFile
+func (fs *InMemoryFS) ReadFile(name string) ([]byte, error) {
+	content, exists := fs.GetFile(name)
+	if !exists {
+		return nil, &iofs.PathError{Op: "open", Path: name, Err: iofs.ErrNotExist}
+	}
+	return content, nil
+}
+
+// Files implements ReadFS.Files
+func (fs *InMemoryFS) Files() ([]string, error) {
+	return fs.ListFiles(), nil
+}
+
 // GetFileString returns the content of a file as a string for testing assertions
-func (fs *InMemoryFS) GetFileString(path string) (string, bool) {
-	content, exists := fs.GetFile(path)
+func (fs *InMemoryFS) GetFileString(name string) (string, bool) {
+	content, exists := fs.GetFile(name)
 	if !exists {
 		return "", false
 	}
@@ -91,9 +186,11 @@ func (fs *InMemoryFS) GetFileString(path string) (string, bool) {
 
 // ListFiles returns all file paths that have been written
 func (fs *InMemoryFS) ListFiles() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	var files []string
-	for path := range fs.files {
-		files = append(files, path)
+	for name := range fs.files {
+		files = append(files, name)
 	}
 	sort.Strings(files)
 	return files
@@ -101,32 +198,40 @@ func (fs *InMemoryFS) ListFiles() []string {
 
 // ListDirs returns all directory paths that have been created
 func (fs *InMemoryFS) ListDirs() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	var dirs []string
-	for path := range fs.dirs {
-		dirs = append(dirs, path)
+	for name := range fs.dirs {
+		dirs = append(dirs, name)
 	}
 	sort.Strings(dirs)
 	return dirs
 }
 
 // Exists checks if a file or directory exists
-func (fs *InMemoryFS) Exists(path string) bool {
-	path = filepath.ToSlash(path)
-	_, fileExists := fs.files[path]
-	_, dirExists := fs.dirs[path]
+func (fs *InMemoryFS) Exists(name string) bool {
+	name = normalize(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, fileExists := fs.files[name]
+	_, dirExists := fs.dirs[name]
 	return fileExists || dirExists
 }
 
 // FileExists checks if a specific file exists
-func (fs *InMemoryFS) FileExists(path string) bool {
-	path = filepath.ToSlash(path)
-	_, exists := fs.files[path]
+func (fs *InMemoryFS) FileExists(name string) bool {
+	name = normalize(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, exists := fs.files[name]
 	return exists
 }
 
 // DirExists checks if a specific directory exists
-func (fs *InMemoryFS) DirExists(path string) bool {
-	path = filepath.ToSlash(path)
-	_, exists := fs.dirs[path]
+func (fs *InMemoryFS) DirExists(name string) bool {
+	name = normalize(name)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, exists := fs.dirs[name]
 	return exists
-}
\ No newline at end of file
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nodeShapes tells declaration kinds apart in DOT output
+var nodeShapes = map[string]string{
+	"struct":   "box",
+	"enum":     "ellipse",
+	"alias":    "parallelogram",
+	"constant": "plaintext",
+}
+
+// DOT renders the graph in Graphviz DOT. Submodules become nested clusters;
+// optional references are dashed.
+func (g *Graph) DOT() []byte {
+	var b strings.Builder
+	b.WriteString("digraph typegen {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	// Group nodes by submodule, then write the module tree depth first
+	byModule := make(map[string][]*Node)
+	modules := map[string]bool{"": true}
+	for _, node := range g.Nodes {
+		byModule[node.Module] = append(byModule[node.Module], node)
+		for module := node.Module; module != ""; module = moduleOf(module) {
+			modules[module] = true
+		}
+	}
+	children := make(map[string][]string)
+	for module := range modules {
+		if module != "" {
+			parent := moduleOf(module)
+			children[parent] = append(children[parent], module)
+		}
+	}
+	for _, list := range children {
+		sort.Strings(list)
+	}
+
+	var writeModule func(module, indent string)
+	writeModule = func(module, indent string) {
+		for _, node := range byModule[module] {
+			fmt.Fprintf(&b, "%s%s [label=%s, shape=%s, tooltip=%s];\n", indent,
+				strconv.Quote(node.ID),
+				strconv.Quote(node.Name+"\n"+node.Kind),
+				nodeShapes[node.Kind],
+				strconv.Quote(fmt.Sprintf("%s:%d", node.File, node.Line)))
+		}
+		for _, child := range children[module] {
+			fmt.Fprintf(&b, "%ssubgraph %s {\n", indent, strconv.Quote("cluster_"+child))
+			fmt.Fprintf(&b, "%s  label=%s;\n", indent, strconv.Quote(child))
+			writeModule(child, indent+"  ")
+			fmt.Fprintf(&b, "%s}\n", indent)
+		}
+	}
+	if len(g.Nodes) > 0 {
+		b.WriteString("\n")
+	}
+	writeModule("", "  ")
+
+	if len(g.Edges) > 0 {
+		b.WriteString("\n")
+	}
+	for _, edge := range g.Edges {
+		var attrs []string
+		attrs = append(attrs, "label="+strconv.Quote(edge.Label()))
+		if edge.Optional {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.Array || edge.Map {
+			attrs = append(attrs, "arrowhead=crow")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,505 @@
+// Package graph builds the type dependency graph of a TypeGen module: which
+// declarations reference which, through fields, enum payloads and aliases.
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+)
+
+// Node is a declaration of the module
+type Node struct {
+	// ID is the declaration name qualified by its submodule path, e.g. "auth.Token"
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Kind is "struct", "enum", "alias" or "constant"
+	Kind string `json:"kind"`
+	// Module is the slash-separated submodule path, "" for the root module
+	Module string `json:"module"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Decl is the declaration node
+	Decl ast.Declaration `json:"-"`
+}
+
+// Edge is a reference from one declaration to another
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Via is "field", "payload" or "alias"
+	Via string `json:"via"`
+	// Name is the field or variant holding the reference; empty for aliases
+	Name string `json:"name,omitempty"`
+	// Type is the referencing type as written, e.g. "[]?User"
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+	Array    bool   `json:"array,omitempty"`
+	Map      bool   `json:"map,omitempty"`
+}
+
+// Label describes the edge by the field or variant and its type
+func (e *Edge) Label() string {
+	if e.Name == "" {
+		return e.Type
+	}
+	return e.Name + ": " + e.Type
+}
+
+// Graph is a set of declarations and the references between them. Nodes are
+// ordered by ID, and edges by source node then declaration order.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// Build returns the dependency graph of a module. References that don't
+// resolve to a declaration are left out; the validator reports them.
+func Build(module *ast.Module) *Graph {
+	return FromModel(semantic.Build(module))
+}
+
+// FromModel returns the dependency graph of a resolved module
+func FromModel(model *semantic.Model) *Graph {
+	g := &Graph{}
+	nodes := make(map[*semantic.Decl]*Node)
+	for _, decl := range model.Decls() {
+		pos := decl.Pos()
+		node := &Node{
+			ID:     decl.QualifiedName(),
+			Name:   decl.Name,
+			Kind:   decl.Kind,
+			Module: decl.File.Dir,
+			File:   decl.File.Path,
+			Line:   pos.Line,
+			Column: pos.Column,
+			Decl:   decl.Node,
+		}
+		nodes[decl] = node
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+
+	for _, node := range g.Nodes {
+		edge := func(via, name string, optional bool, t ast.Type) {
+			for _, ref := range references(t, optional) {
+				target, found := model.Lookup(ref.named)
+				if !found {
+					continue
+				}
+				g.Edges = append(g.Edges, &Edge{
+					From:     node.ID,
+					To:       nodes[target].ID,
+					Via:      via,
+					Name:     name,
+					Type:     typeString(t, optional),
+					Optional: ref.optional,
+					Array:    ref.array,
+					Map:      ref.isMap,
+				})
+			}
+		}
+
+		switch d := node.Decl.(type) {
+		case *ast.StructNode:
+			for _, field := range d.Fields {
+				edge("field", field.Name, field.Optional, field.Type)
+			}
+		case *ast.EnumNode:
+			for _, variant := range d.Variants {
+				if variant.Payload != nil {
+					edge("payload", variant.Name, false, variant.Payload)
+				}
+			}
+		case *ast.TypeAliasNode:
+			edge("alias", "", false, d.Type)
+		}
+	}
+
+	return g
+}
+
+// reference is a named type found inside a type expression, with the
+// containers it was nested in
+type reference struct {
+	named    *ast.NamedType
+	optional bool
+	array    bool
+	isMap    bool
+}
+
+func references(t ast.Type, optional bool) []reference {
+	var refs []reference
+	var walk func(t ast.Type, ref reference)
+	walk = func(t ast.Type, ref reference) {
+		switch t := t.(type) {
+		case *ast.NamedType:
+			ref.named = t
+			refs = append(refs, ref)
+		case *ast.ArrayType:
+			ref.array = true
+			walk(t.ElementType, ref)
+		case *ast.MapType:
+			ref.isMap = true
+			walk(t.KeyType, ref)
+			walk(t.ValueType, ref)
+		case *ast.OptionalType:
+			ref.optional = true
+			walk(t.ElementType, ref)
+		}
+	}
+	walk(t, reference{optional: optional})
+	return refs
+}
+
+func typeString(t ast.Type, optional bool) string {
+	if optional {
+		return "?" + t.String()
+	}
+	return t.String()
+}
+
+// moduleOf returns the submodule directory of a module-relative file path
+func moduleOf(file string) string {
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		return file[:i]
+	}
+	return ""
+}
+
+// AmbiguousError is returned by Find for a bare name declared in several submodules
+type AmbiguousError struct {
+	Name string
+	// Candidates are the declarations with the name, ordered by ID
+	Candidates []*Node
+}
+
+func (e *AmbiguousError) Error() string {
+	var ids []string
+	for _, node := range e.Candidates {
+		ids = append(ids, node.ID)
+	}
+	return fmt.Sprintf("%q is ambiguous: use one of %s", e.Name, strings.Join(ids, ", "))
+}
+
+// Find returns the node for a declaration, given its ID or, when unambiguous,
+// its bare name. An ambiguous name returns an *AmbiguousError.
+func (g *Graph) Find(name string) (*Node, error) {
+	var matches []*Node
+	for _, node := range g.Nodes {
+		if node.ID == name {
+			return node, nil
+		}
+		if node.Name == name {
+			matches = append(matches, node)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no declaration named %q", name)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, &AmbiguousError{Name: name, Candidates: matches}
+}
+
+// Relations are the declarations a declaration references, its dependencies,
+// and the declarations that reference it, its dependents
+type Relations struct {
+	Node         *Node   `json:"declaration"`
+	Dependencies []*Node `json:"dependencies"`
+	Dependents   []*Node `json:"dependents"`
+}
+
+// Relations returns the direct dependencies and dependents of the node with
+// the given ID or, with transitive, every declaration reachable through them.
+// Both lists are ordered by ID; a declaration is in its own lists only when it
+// references itself, directly or through a cycle.
+func (g *Graph) Relations(id string, transitive bool) *Relations {
+	relations := &Relations{Dependencies: []*Node{}, Dependents: []*Node{}}
+	for _, node := range g.Nodes {
+		if node.ID == id {
+			relations.Node = node
+		}
+	}
+	relations.Dependencies = g.reachable(id, transitive)
+	relations.Dependents = g.Reverse().reachable(id, transitive)
+	return relations
+}
+
+// reachable returns the nodes reached by following edges from id: one edge,
+// or any number with transitive
+func (g *Graph) reachable(id string, transitive bool) []*Node {
+	outgoing := make(map[string][]string)
+	for _, edge := range g.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+
+	reached := make(map[string]bool)
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, to := range outgoing[current] {
+			if !reached[to] {
+				reached[to] = true
+				if transitive {
+					queue = append(queue, to)
+				}
+			}
+		}
+	}
+
+	nodes := []*Node{}
+	for _, node := range g.Nodes {
+		if reached[node.ID] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// DependenciesOf returns the nodes the node with the given ID references
+// directly, ordered by ID
+func (g *Graph) DependenciesOf(id string) []*Node {
+	return g.reachable(id, false)
+}
+
+// DependentsOf returns the nodes that reference the node with the given ID
+// directly, ordered by ID
+func (g *Graph) DependentsOf(id string) []*Node {
+	return g.Reverse().reachable(id, false)
+}
+
+// Component is a strongly connected component of the graph: a single
+// declaration, or declarations that all reference each other through a cycle
+type Component struct {
+	// Nodes are ordered by source position
+	Nodes []*Node `json:"nodes"`
+	// Cyclic is set when the declarations form a cycle, including a single
+	// declaration that references itself
+	Cyclic bool `json:"cyclic"`
+}
+
+// TopologicalOrder groups the nodes into strongly connected components and
+// orders them so that every component comes after the components it
+// references. Components that don't depend on each other keep the order of
+// their first declaration in the source, by file then position.
+func (g *Graph) TopologicalOrder() []*Component {
+	components := g.components()
+
+	componentOf := make(map[string]*Component)
+	for _, component := range components {
+		for _, node := range component.Nodes {
+			componentOf[node.ID] = component
+		}
+	}
+
+	// Count, for each component, the components it still waits for
+	pending := make(map[*Component]int)
+	dependents := make(map[*Component][]*Component)
+	seen := make(map[[2]*Component]bool)
+	for _, edge := range g.Edges {
+		from, to := componentOf[edge.From], componentOf[edge.To]
+		if from == nil || to == nil {
+			continue
+		}
+		if from == to {
+			from.Cyclic = true
+			continue
+		}
+		if key := [2]*Component{from, to}; !seen[key] {
+			seen[key] = true
+			pending[from]++
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return sourceBefore(components[i].Nodes[0], components[j].Nodes[0])
+	})
+	var ready, order []*Component
+	for _, component := range components {
+		if pending[component] == 0 {
+			ready = append(ready, component)
+		}
+	}
+	for len(ready) > 0 {
+		next := 0
+		for i, component := range ready {
+			if sourceBefore(component.Nodes[0], ready[next].Nodes[0]) {
+				next = i
+			}
+		}
+		component := ready[next]
+		ready = append(ready[:next], ready[next+1:]...)
+		order = append(order, component)
+
+		for _, dependent := range dependents[component] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return order
+}
+
+// components returns the strongly connected components of the graph, found
+// with Tarjan's algorithm
+func (g *Graph) components() []*Component {
+	outgoing := make(map[string][]string)
+	for _, edge := range g.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge.To)
+	}
+	nodes := make(map[string]*Node)
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	var components []*Component
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, to := range outgoing[id] {
+			if nodes[to] == nil {
+				continue
+			}
+			if _, visited := index[to]; !visited {
+				visit(to)
+				lowLink[id] = min(lowLink[id], lowLink[to])
+			} else if onStack[to] {
+				lowLink[id] = min(lowLink[id], index[to])
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+		component := &Component{}
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component.Nodes = append(component.Nodes, nodes[top])
+			if top == id {
+				break
+			}
+		}
+		sort.Slice(component.Nodes, func(i, j int) bool {
+			return sourceBefore(component.Nodes[i], component.Nodes[j])
+		})
+		component.Cyclic = len(component.Nodes) > 1
+		components = append(components, component)
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.ID]; !visited {
+			visit(node.ID)
+		}
+	}
+	return components
+}
+
+// sourceBefore reports whether a is declared before b, by file then position
+func sourceBefore(a, b *Node) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	if a.Column != b.Column {
+		return a.Column < b.Column
+	}
+	return a.ID < b.ID
+}
+
+// Select returns the nodes for which keep returns true and the edges between
+// them
+func (g *Graph) Select(keep func(node *Node) bool) *Graph {
+	selected := &Graph{}
+	kept := make(map[string]bool)
+	for _, node := range g.Nodes {
+		if keep(node) {
+			kept[node.ID] = true
+			selected.Nodes = append(selected.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		if kept[edge.From] && kept[edge.To] {
+			selected.Edges = append(selected.Edges, edge)
+		}
+	}
+	return selected
+}
+
+// Reverse returns the graph with every edge flipped, so edges point from a
+// declaration to the declarations that depend on it
+func (g *Graph) Reverse() *Graph {
+	reversed := &Graph{Nodes: g.Nodes}
+	for _, edge := range g.Edges {
+		flipped := *edge
+		flipped.From, flipped.To = edge.To, edge.From
+		reversed.Edges = append(reversed.Edges, &flipped)
+	}
+	sort.SliceStable(reversed.Edges, func(i, j int) bool {
+		return reversed.Edges[i].From < reversed.Edges[j].From
+	})
+	return reversed
+}
+
+// Focus returns the part of the graph reachable from the node with the given
+// ID by following at most depth edges; a depth of 0 or less means no limit
+func (g *Graph) Focus(id string, depth int) *Graph {
+	outgoing := make(map[string][]*Edge)
+	for _, edge := range g.Edges {
+		outgoing[edge.From] = append(outgoing[edge.From], edge)
+	}
+
+	distance := map[string]int{id: 0}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if depth > 0 && distance[current] >= depth {
+			continue
+		}
+		for _, edge := range outgoing[current] {
+			if _, seen := distance[edge.To]; !seen {
+				distance[edge.To] = distance[current] + 1
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	focused := &Graph{}
+	for _, node := range g.Nodes {
+		if _, reached := distance[node.ID]; reached {
+			focused.Nodes = append(focused.Nodes, node)
+		}
+	}
+	// Keep the edges that were followed, or could have been within the depth
+	for _, edge := range g.Edges {
+		from, fromReached := distance[edge.From]
+		_, toReached := distance[edge.To]
+		if fromReached && toReached && (depth <= 0 || from < depth) {
+			focused.Edges = append(focused.Edges, edge)
+		}
+	}
+	return focused
+}
@@ -0,0 +1,314 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func buildShop(t *testing.T) *Graph {
+	t.Helper()
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "shop"))
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+	return Build(module)
+}
+
+func checkGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != string(actual) {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, actual)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	g := buildShop(t)
+
+	tests := []struct {
+		golden string
+		graph  func() *Graph
+	}{
+		{"shop.dot", func() *Graph { return g }},
+		{"shop_focus.dot", func() *Graph { return g.Focus("Order", 1) }},
+		{"shop_reverse.dot", func() *Graph { return g.Reverse().Focus("auth.Session", 0) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			checkGolden(t, tt.golden, tt.graph().DOT())
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	g := buildShop(t)
+
+	user, err := g.Find("User")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if user.ID != "auth.User" || user.Kind != "struct" || user.Module != "auth" || user.File != "auth/user.tg" {
+		t.Errorf("unexpected node: %+v", user)
+	}
+
+	edges := make(map[string]*Edge)
+	for _, edge := range g.Edges {
+		edges[edge.From+"."+edge.Name+"->"+edge.To] = edge
+	}
+
+	expected := map[string]Edge{
+		"Order.buyer->auth.User":           {Via: "field", Type: "auth.User"},
+		"Order.items->LineItem":            {Via: "field", Type: "[]LineItem", Array: true},
+		"Order.coupon->Coupon":             {Via: "field", Type: "?Coupon", Optional: true},
+		"Order.status->Status":             {Via: "field", Type: "Status"},
+		"LineItem.product->Product":        {Via: "field", Type: "Product"},
+		"Product.price->Money":             {Via: "field", Type: "Money"},
+		"Status.shipped->Shipment":         {Via: "payload", Type: "Shipment"},
+		"Product.related->Product":         {Via: "field", Type: "[string]Product", Map: true},
+		"Catalog.->Product":                {Via: "alias", Type: "[]Product", Array: true},
+		"auth.Session.owner->auth.User":    {Via: "field", Type: "?User", Optional: true},
+		"auth.User.sessions->auth.Session": {Via: "field", Type: "[]Session", Array: true},
+	}
+	for key, want := range expected {
+		got, exists := edges[key]
+		if !exists {
+			t.Errorf("missing edge %s", key)
+			continue
+		}
+		if got.Via != want.Via || got.Type != want.Type || got.Optional != want.Optional || got.Array != want.Array || got.Map != want.Map {
+			t.Errorf("edge %s: expected %+v, got %+v", key, want, *got)
+		}
+	}
+	// Primitive types, like the ones aliased by Money, and constants have no edges
+	if len(g.Edges) != len(expected) {
+		t.Errorf("expected %d edges, got %d", len(expected), len(g.Edges))
+	}
+}
+
+func TestFind(t *testing.T) {
+	g := &Graph{Nodes: []*Node{
+		{ID: "auth.User", Name: "User"},
+		{ID: "billing.User", Name: "User"},
+		{ID: "Order", Name: "Order"},
+	}}
+
+	if node, err := g.Find("billing.User"); err != nil || node.ID != "billing.User" {
+		t.Errorf("Find by ID returned %v, %v", node, err)
+	}
+	if node, err := g.Find("Order"); err != nil || node.ID != "Order" {
+		t.Errorf("Find by name returned %v, %v", node, err)
+	}
+	_, err := g.Find("User")
+	if err == nil || !strings.Contains(err.Error(), "auth.User, billing.User") {
+		t.Errorf("expected an ambiguity error listing the candidates, got %v", err)
+	}
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Errorf("expected an *AmbiguousError with 2 candidates, got %#v", err)
+	}
+	if _, err := g.Find("Missing"); err == nil {
+		t.Error("expected an error for a missing declaration")
+	}
+}
+
+func TestFocusDepth(t *testing.T) {
+	g := buildShop(t)
+
+	ids := func(g *Graph) string {
+		var ids []string
+		for _, node := range g.Nodes {
+			ids = append(ids, node.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	tests := []struct {
+		depth    int
+		expected string
+	}{
+		{1, "Coupon LineItem Order Status auth.User"},
+		{2, "Coupon LineItem Order Product Shipment Status auth.Session auth.User"},
+		{0, "Coupon LineItem Money Order Product Shipment Status auth.Session auth.User"},
+	}
+	for _, tt := range tests {
+		if got := ids(g.Focus("Order", tt.depth)); got != tt.expected {
+			t.Errorf("depth %d: expected %q, got %q", tt.depth, tt.expected, got)
+		}
+	}
+
+	// Dependents of Product: LineItem, Catalog and Product itself, then Order
+	if got := ids(g.Reverse().Focus("Product", 1)); got != "Catalog LineItem Product" {
+		t.Errorf("unexpected dependents: %q", got)
+	}
+	if got := ids(g.Reverse().Focus("Product", 2)); got != "Catalog LineItem Order Product" {
+		t.Errorf("unexpected dependents: %q", got)
+	}
+}
+
+func TestRelations(t *testing.T) {
+	g := buildShop(t)
+
+	ids := func(nodes []*Node) string {
+		var ids []string
+		for _, node := range nodes {
+			ids = append(ids, node.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	tests := []struct {
+		id           string
+		transitive   bool
+		dependencies string
+		dependents   string
+	}{
+		{"LineItem", false, "Product", "Order"},
+		{"LineItem", true, "Money Product", "Order"},
+		// Product references itself
+		{"Product", false, "Money Product", "Catalog LineItem Product"},
+		{"Product", true, "Money Product", "Catalog LineItem Order Product"},
+		// User and Session reference each other
+		{"auth.Session", false, "auth.User", "auth.User"},
+		{"auth.Session", true, "auth.Session auth.User", "Order auth.Session auth.User"},
+		{"Coupon", true, "", "Order"},
+	}
+	for _, tt := range tests {
+		relations := g.Relations(tt.id, tt.transitive)
+		if relations.Node == nil || relations.Node.ID != tt.id {
+			t.Errorf("%s: expected the node, got %+v", tt.id, relations.Node)
+		}
+		if got := ids(relations.Dependencies); got != tt.dependencies {
+			t.Errorf("%s (transitive %v): expected dependencies %q, got %q", tt.id, tt.transitive, tt.dependencies, got)
+		}
+		if got := ids(relations.Dependents); got != tt.dependents {
+			t.Errorf("%s (transitive %v): expected dependents %q, got %q", tt.id, tt.transitive, tt.dependents, got)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	g := buildShop(t).Focus("Order", 1)
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("failed to marshal graph: %v", err)
+	}
+	for _, expected := range []string{
+		`{"id":"auth.User","name":"User","kind":"struct","module":"auth","file":"auth/user.tg","line":1,"column":1}`,
+		`{"from":"Order","to":"LineItem","via":"field","name":"items","type":"[]LineItem","array":true}`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected JSON to contain %s, got %s", expected, data)
+		}
+	}
+}
+
+// order describes components in topological order, joining cycle members
+// with "+" and marking cyclic components with "*"
+func order(components []*Component) string {
+	var parts []string
+	for _, component := range components {
+		var ids []string
+		for _, node := range component.Nodes {
+			ids = append(ids, node.ID)
+		}
+		part := strings.Join(ids, "+")
+		if component.Cyclic {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	// Product references itself, and auth.User and auth.Session each other
+	got := order(buildShop(t).TopologicalOrder())
+	expected := "auth.Session+auth.User* Coupon Shipment Status MAX_ITEMS Money Product* LineItem Order Catalog"
+	if got != expected {
+		t.Errorf("expected order %q, got %q", expected, got)
+	}
+
+	source := `struct Company {
+	name: string
+	departments: []Department
+	address: ?Address
+}
+
+struct Department {
+	employees: [string]Employee
+}
+
+enum Employee {
+	hired: Address
+	left
+}
+
+type Address = Street
+
+struct Street {
+	name: string
+}`
+	program, err := parser.Parse(strings.NewReader(source), "company.tg")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	g := Build(ast.NewModule("company", map[string]*ast.ProgramNode{"company.tg": program}))
+	if got, expected := order(g.TopologicalOrder()), "Street Address Employee Department Company"; got != expected {
+		t.Errorf("expected acyclic order %q, got %q", expected, got)
+	}
+}
+
+func TestDependenciesOf(t *testing.T) {
+	g := buildShop(t)
+
+	ids := func(nodes []*Node) string {
+		var ids []string
+		for _, node := range nodes {
+			ids = append(ids, node.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+	if got := ids(g.DependenciesOf("Order")); got != "Coupon LineItem Status auth.User" {
+		t.Errorf("unexpected dependencies: %q", got)
+	}
+	if got := ids(g.DependentsOf("Product")); got != "Catalog LineItem Product" {
+		t.Errorf("unexpected dependents: %q", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	g := buildShop(t).Select(func(node *Node) bool { return node.Module == "auth" })
+
+	if len(g.Nodes) != 2 || len(g.Edges) != 2 {
+		t.Fatalf("expected the 2 auth declarations and their 2 edges, got %d nodes and %d edges", len(g.Nodes), len(g.Edges))
+	}
+	for _, node := range g.Nodes {
+		if node.Decl == nil || node.Decl.DeclName() != node.Name {
+			t.Errorf("expected %s to keep its declaration, got %v", node.ID, node.Decl)
+		}
+	}
+	if got := order(g.TopologicalOrder()); got != "auth.Session+auth.User*" {
+		t.Errorf("expected a single cycle, got %q", got)
+	}
+}
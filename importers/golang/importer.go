@@ -0,0 +1,655 @@
+// Package golang converts the exported types of a Go package into TypeGen declarations.
+package golang
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Options control the conversion of a package
+type Options struct {
+	// Enums names types to convert to enums even when their constants
+	// don't follow the TypeName + Variant naming convention
+	Enums []string
+}
+
+// Result holds the converted files and everything that could not be converted faithfully
+type Result struct {
+	// Files maps .tg filenames, one per Go source file, to their declarations
+	Files map[string]*ast.ProgramNode
+	// Comments carries Go doc comments and notes on fields that were dropped or changed
+	Comments *importers.Comments
+	Issues   []importers.Issue
+}
+
+// validTypeName matches the PascalCase names the validator accepts
+var validTypeName = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+
+// converter carries the state of one Import call
+type converter struct {
+	fset     *token.FileSet
+	pkg      *types.Package
+	info     *types.Info
+	options  Options
+	names    map[*types.TypeName]string // TypeGen names of declared types
+	taken    map[string]bool
+	enums    map[*types.TypeName][]*types.Const
+	docs     map[token.Pos]string // doc comments of struct fields, by field position
+	files    map[string]*ast.ProgramNode
+	comments *importers.Comments
+	issues   []importers.Issue
+}
+
+// Import loads the Go package in dir and converts its exported types.
+// Structs become structs, named basic types with constants become enums,
+// and other named types become aliases, each in a .tg file named after the
+// Go file that declares it.
+func Import(dir string, options Options) (*Result, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load package %s: %w", dir, err)
+	}
+
+	im := &converter{
+		fset:     token.NewFileSet(),
+		options:  options,
+		names:    make(map[*types.TypeName]string),
+		taken:    make(map[string]bool),
+		enums:    make(map[*types.TypeName][]*types.Const),
+		docs:     make(map[token.Pos]string),
+		files:    make(map[string]*ast.ProgramNode),
+		comments: importers.NewComments(),
+	}
+
+	var files []*goast.File
+	for _, name := range buildPkg.GoFiles {
+		file, err := goparser.ParseFile(im.fset, filepath.Join(dir, name), nil, goparser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		files = append(files, file)
+	}
+
+	im.info = &types.Info{Defs: make(map[*goast.Ident]types.Object)}
+	config := types.Config{
+		Importer: importer.ForCompiler(im.fset, "source", nil),
+		// Keep going on type errors; fields whose types don't resolve are reported below
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				im.report(typeErr.Pos, typeErr.Msg)
+			}
+		},
+	}
+	im.pkg, _ = config.Check(buildPkg.ImportPath, im.fset, files, im.info)
+
+	im.collect(files)
+
+	for _, file := range files {
+		filename := naming.SnakeCase(strings.TrimSuffix(filepath.Base(im.fset.File(file.Pos()).Name()), ".go")) + ".tg"
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*goast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*goast.TypeSpec)
+				doc := typeSpec.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				im.declare(typeSpec, doc, filename)
+			}
+		}
+	}
+
+	return &Result{Files: im.files, Comments: im.comments, Issues: im.issues}, nil
+}
+
+// collect names the exported types, finds enum constants and indexes field doc comments
+func (im *converter) collect(files []*goast.File) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*goast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				switch s := spec.(type) {
+				case *goast.TypeSpec:
+					obj, ok := im.info.Defs[s.Name].(*types.TypeName)
+					if !ok || !obj.Exported() || s.TypeParams != nil {
+						continue
+					}
+					if reason := unsupported(obj.Type().Underlying()); reason != "" {
+						continue
+					}
+					im.names[obj] = im.typeName(obj.Name(), s.Pos())
+					im.collectFieldDocs(s.Type)
+
+				case *goast.ValueSpec:
+					if gen.Tok != token.CONST {
+						continue
+					}
+					for _, name := range s.Names {
+						if c, ok := im.info.Defs[name].(*types.Const); ok && c.Exported() {
+							if named, ok := c.Type().(*types.Named); ok && named.Obj().Pkg() == im.pkg {
+								im.enums[named.Obj()] = append(im.enums[named.Obj()], c)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// collectFieldDocs indexes the doc comments of the fields of struct types, including anonymous ones
+func (im *converter) collectFieldDocs(expr goast.Expr) {
+	goast.Inspect(expr, func(n goast.Node) bool {
+		if field, ok := n.(*goast.Field); ok && field.Doc != nil {
+			for _, name := range field.Names {
+				im.docs[name.Pos()] = field.Doc.Text()
+			}
+		}
+		return true
+	})
+}
+
+// declare converts one exported type declaration
+func (im *converter) declare(spec *goast.TypeSpec, doc *goast.CommentGroup, filename string) {
+	obj, ok := im.info.Defs[spec.Name].(*types.TypeName)
+	if !ok || !obj.Exported() {
+		return
+	}
+	if spec.TypeParams != nil {
+		im.report(spec.Pos(), fmt.Sprintf("generic type %s is not supported and was skipped", obj.Name()))
+		return
+	}
+	if reason := unsupported(obj.Type().Underlying()); reason != "" {
+		im.report(spec.Pos(), fmt.Sprintf("type %s is %s, which has no JSON encoding, and was skipped", obj.Name(), reason))
+		return
+	}
+	name := im.names[obj]
+
+	var decl ast.Declaration
+	switch underlying := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		if obj.IsAlias() {
+			decl = im.declareAlias(obj, name, filename)
+		} else {
+			decl = im.declareStruct(name, underlying, filename)
+		}
+	case *types.Basic:
+		if constants := im.enumConstants(obj); len(constants) > 0 && !obj.IsAlias() {
+			decl = im.declareEnum(name, underlying, constants, filename)
+		} else {
+			decl = im.declareAlias(obj, name, filename)
+		}
+	default:
+		decl = im.declareAlias(obj, name, filename)
+	}
+
+	if doc != nil {
+		im.comments.Leading[decl] = append(docLines(doc.Text()), im.comments.Leading[decl]...)
+	}
+	if hasCustomJSON(obj.Type()) {
+		im.report(spec.Pos(), fmt.Sprintf("%s has custom JSON marshaling; its encoding may differ from the TypeGen wire format", obj.Name()))
+		im.comments.Add(decl, "Go encodes this type with a custom MarshalJSON or MarshalText")
+	}
+}
+
+// declareStruct adds a struct for a Go struct type, flattening embedded structs like encoding/json
+func (im *converter) declareStruct(name string, st *types.Struct, filename string) *ast.StructNode {
+	decl := &ast.StructNode{Name: name}
+	im.add(filename, decl)
+
+	fieldNames := make(map[string]bool)
+	var pending []string
+
+	var addFields func(st *types.Struct, embeddedFrom string)
+	addFields = func(st *types.Struct, embeddedFrom string) {
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			jsonName, options := parseTag(reflect.StructTag(st.Tag(i)).Get("json"))
+			if jsonName == "-" && options == "" {
+				continue
+			}
+
+			// Untagged embedded structs contribute their fields, like in encoding/json
+			if field.Embedded() && jsonName == "" {
+				if embedded, ok := derefStruct(field.Type()); ok {
+					addFields(embedded, typeString(field.Type(), im.pkg))
+					continue
+				}
+			}
+			if !field.Exported() {
+				continue
+			}
+
+			if jsonName == "" {
+				jsonName = field.Name()
+			}
+
+			fieldName := naming.SnakeCase(jsonName)
+			if fieldName == "" || fieldName[0] < 'a' || fieldName[0] > 'z' {
+				fieldName = "f_" + fieldName
+			}
+			if importers.IsKeyword(fieldName) {
+				fieldName += "_"
+			}
+			for n := 2; fieldNames[fieldName]; n++ {
+				fieldName = fmt.Sprintf("%s_%d", naming.SnakeCase(jsonName), n)
+			}
+
+			fieldType, optional, reason := im.convert(field.Type(), name+field.Name(), filename, field.Pos())
+			if reason != "" {
+				pending = append(pending, fmt.Sprintf("%s %s: %s", field.Name(), typeString(field.Type(), im.pkg), reason))
+				im.report(field.Pos(), fmt.Sprintf("field %s.%s is %s and was dropped", name, field.Name(), reason))
+				continue
+			}
+			fieldNames[fieldName] = true
+
+			node := &ast.FieldNode{
+				Name:     fieldName,
+				Type:     fieldType,
+				Optional: optional || hasOption(options, "omitempty") || hasOption(options, "omitzero"),
+			}
+			// Go encodes untagged fields under their Go names
+			if fieldName != jsonName {
+				if importers.IsWireName(jsonName) {
+					node.WireName = jsonName
+				} else {
+					im.report(field.Pos(), fmt.Sprintf("JSON name %q of %s.%s can't be a wire name and becomes %q", jsonName, name, field.Name(), fieldName))
+				}
+			}
+			decl.Fields = append(decl.Fields, node)
+
+			for _, line := range pending {
+				im.comments.Add(node, line)
+			}
+			pending = nil
+			if doc, ok := im.docs[field.Pos()]; ok {
+				for _, line := range docLines(doc) {
+					im.comments.Add(node, line)
+				}
+			}
+			if embeddedFrom != "" {
+				im.comments.Add(node, "from embedded "+embeddedFrom)
+			}
+			if hasOption(options, "string") {
+				im.comments.Add(node, "Go encodes this field as a JSON string (,string tag option)")
+				im.report(field.Pos(), fmt.Sprintf("field %s.%s uses the ,string tag option, which TypeGen does not support", name, field.Name()))
+			}
+		}
+	}
+	addFields(st, "")
+
+	for _, line := range pending {
+		im.comments.AddTrailing(decl, line)
+	}
+	return decl
+}
+
+// declareEnum adds an enum for a named string or integer type with constants
+func (im *converter) declareEnum(name string, basic *types.Basic, constants []*types.Const, filename string) *ast.EnumNode {
+	decl := &ast.EnumNode{Name: name}
+	im.add(filename, decl)
+
+	isString := basic.Info()&types.IsString != 0
+	seen := make(map[string]bool)
+	for _, c := range constants {
+		var variantName string
+		if isString {
+			variantName = naming.SnakeCase(constant.StringVal(c.Val()))
+		} else {
+			variantName = naming.SnakeCase(strings.TrimPrefix(c.Name(), c.Type().(*types.Named).Obj().Name()))
+		}
+		if variantName == "" || variantName[0] < 'a' || variantName[0] > 'z' {
+			variantName = "v_" + variantName
+		}
+		if importers.IsKeyword(variantName) {
+			variantName += "_"
+		}
+		if seen[variantName] {
+			im.report(c.Pos(), fmt.Sprintf("constant %s duplicates enum variant %s and was skipped", c.Name(), variantName))
+			continue
+		}
+		seen[variantName] = true
+
+		variant := &ast.EnumVariantNode{Name: variantName}
+		decl.Variants = append(decl.Variants, variant)
+
+		if isString {
+			if value := constant.StringVal(c.Val()); value != variantName {
+				im.comments.Add(variant, fmt.Sprintf("%s = %q", c.Name(), value))
+			}
+		} else {
+			im.comments.Add(variant, fmt.Sprintf("%s = %s", c.Name(), c.Val().String()))
+		}
+	}
+
+	if !isString {
+		im.comments.Add(decl, "Go encodes this enum as an integer")
+		im.report(constants[0].Pos(), fmt.Sprintf("enum %s is integer-based in Go; TypeGen encodes variants by name", name))
+	}
+	return decl
+}
+
+// declareAlias adds a type alias for a named type that is neither a struct nor an enum
+func (im *converter) declareAlias(obj *types.TypeName, name, filename string) *ast.TypeAliasNode {
+	decl := &ast.TypeAliasNode{Name: name}
+	im.add(filename, decl)
+
+	target := obj.Type().Underlying()
+	if obj.IsAlias() {
+		target = types.Unalias(obj.Type())
+	}
+	t, optional, reason := im.convert(target, name, filename, obj.Pos())
+	if reason != "" {
+		t = primitive("json")
+		im.report(obj.Pos(), fmt.Sprintf("alias %s is %s; using json", name, reason))
+	}
+	if optional {
+		im.comments.Add(decl, "Go allows null here; TypeGen aliases cannot be optional")
+	}
+	decl.Type = t
+	return decl
+}
+
+// enumConstants returns the constants of a type when it should become an enum:
+// every constant follows the TypeName + Variant convention, or the type is in Options.Enums
+func (im *converter) enumConstants(obj *types.TypeName) []*types.Const {
+	constants := im.enums[obj]
+	if len(constants) == 0 {
+		return nil
+	}
+	for _, allowed := range im.options.Enums {
+		if allowed == obj.Name() {
+			return constants
+		}
+	}
+	for _, c := range constants {
+		if !strings.HasPrefix(c.Name(), obj.Name()) || c.Name() == obj.Name() {
+			return nil
+		}
+	}
+	return constants
+}
+
+// convert converts a Go type into a TypeGen type. The second result reports
+// whether the type is a pointer, which makes a field optional; a non-empty
+// reason means the type has no TypeGen equivalent.
+func (im *converter) convert(t types.Type, hint, filename string, pos token.Pos) (ast.Type, bool, string) {
+	if reason := unsupported(t); reason != "" {
+		return nil, false, reason
+	}
+	if alias, ok := t.(*types.Alias); ok {
+		if known, ok := wellKnown(alias.Obj()); ok {
+			return primitive(known), false, ""
+		}
+		if name, ok := im.names[alias.Obj()]; ok {
+			return &ast.NamedType{Name: name}, false, ""
+		}
+	}
+
+	switch typ := types.Unalias(t).(type) {
+	case *types.Pointer:
+		elem, _, reason := im.convert(typ.Elem(), hint, filename, pos)
+		return elem, true, reason
+
+	case *types.Named:
+		return im.convertNamed(typ, hint, filename, pos)
+
+	case *types.Basic:
+		return primitive(basicType(typ)), false, ""
+
+	case *types.Slice:
+		if isByte(typ.Elem()) {
+			return primitive("string"), false, "" // encoding/json writes []byte as base64
+		}
+		elem, _, reason := im.convert(typ.Elem(), hint+"Item", filename, pos)
+		if reason != "" {
+			return nil, false, "a slice of " + reason
+		}
+		return &ast.ArrayType{ElementType: elem}, false, ""
+
+	case *types.Array:
+		elem, _, reason := im.convert(typ.Elem(), hint+"Item", filename, pos)
+		if reason != "" {
+			return nil, false, "an array of " + reason
+		}
+		return &ast.ArrayType{ElementType: elem}, false, ""
+
+	case *types.Map:
+		key, reason := mapKeyType(typ.Key())
+		if reason != "" {
+			return nil, false, reason
+		}
+		value, _, reason := im.convert(typ.Elem(), hint+"Value", filename, pos)
+		if reason != "" {
+			return nil, false, "a map of " + reason
+		}
+		return &ast.MapType{KeyType: primitive(key), ValueType: value}, false, ""
+
+	case *types.Interface:
+		return primitive("json"), false, ""
+
+	case *types.Struct:
+		name := im.uniqueName(hint, pos)
+		im.declareStruct(name, typ, filename)
+		return &ast.NamedType{Name: name}, false, ""
+
+	default:
+		return nil, false, fmt.Sprintf("an unsupported type %s", t)
+	}
+}
+
+// convertNamed converts a reference to a named type
+func (im *converter) convertNamed(named *types.Named, hint, filename string, pos token.Pos) (ast.Type, bool, string) {
+	obj := named.Obj()
+	if named.TypeArgs().Len() > 0 {
+		return nil, false, "an instance of a generic type"
+	}
+
+	if known, ok := wellKnown(obj); ok {
+		return primitive(known), false, ""
+	}
+
+	if name, ok := im.names[obj]; ok {
+		return &ast.NamedType{Name: name}, false, ""
+	}
+
+	// Unexported and foreign named types are inlined through their underlying type
+	if obj.Pkg() != im.pkg {
+		im.report(pos, fmt.Sprintf("%s is from another package; converted from its underlying type", typeString(named, im.pkg)))
+	}
+	return im.convert(named.Underlying(), hint, filename, pos)
+}
+
+// typeName reserves the TypeGen name of a Go type, converting it to PascalCase when needed
+func (im *converter) typeName(goName string, pos token.Pos) string {
+	name := goName
+	if !validTypeName.MatchString(name) {
+		name = naming.PascalCase(goName)
+		im.report(pos, fmt.Sprintf("type %s renamed to %s", goName, name))
+	}
+	return im.uniqueName(name, pos)
+}
+
+// uniqueName reserves a declaration name, adding a numeric suffix on collisions
+func (im *converter) uniqueName(name string, pos token.Pos) string {
+	unique := name
+	for i := 2; im.taken[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	if unique != name {
+		im.report(pos, fmt.Sprintf("name %s is already taken; using %s", name, unique))
+	}
+	im.taken[unique] = true
+	return unique
+}
+
+// add appends a declaration to a .tg file
+func (im *converter) add(filename string, decl ast.Declaration) {
+	program, ok := im.files[filename]
+	if !ok {
+		program = &ast.ProgramNode{}
+		im.files[filename] = program
+	}
+	program.Declarations = append(program.Declarations, decl)
+}
+
+func (im *converter) report(pos token.Pos, message string) {
+	position := im.fset.Position(pos)
+	path := fmt.Sprintf("%s:%d:%d", filepath.Base(position.Filename), position.Line, position.Column)
+	im.issues = append(im.issues, importers.Issue{Path: path, Message: message})
+}
+
+// wellKnown maps standard library types with a fixed JSON encoding to TypeGen primitives
+func wellKnown(obj *types.TypeName) (string, bool) {
+	if obj.Pkg() == nil {
+		return "", false
+	}
+	switch obj.Pkg().Path() + "." + obj.Name() {
+	case "time.Time":
+		return "datetime", true
+	case "time.Duration":
+		return "int64", true // nanoseconds, as encoding/json writes it
+	case "encoding/json.Number":
+		return "float64", true
+	case "encoding/json.RawMessage", "encoding/json/jsontext.Value":
+		return "json", true
+	}
+	return "", false
+}
+
+// unsupported returns why a type has no JSON encoding, or "" if it has one
+func unsupported(t types.Type) string {
+	switch typ := types.Unalias(t).(type) {
+	case *types.Signature:
+		return "a function"
+	case *types.Chan:
+		return "a channel"
+	case *types.Basic:
+		switch {
+		case typ.Info()&types.IsComplex != 0:
+			return "a complex number"
+		case typ.Kind() == types.UnsafePointer:
+			return "an unsafe pointer"
+		case typ.Kind() == types.Uintptr:
+			return "a uintptr"
+		case typ.Kind() == types.Invalid:
+			return "an unresolved type"
+		}
+	}
+	return ""
+}
+
+// basicType maps a Go basic type to a TypeGen primitive
+func basicType(basic *types.Basic) string {
+	switch basic.Kind() {
+	case types.Bool:
+		return "bool"
+	case types.String:
+		return "string"
+	case types.Int8:
+		return "int8"
+	case types.Int16:
+		return "int16"
+	case types.Int32:
+		return "int32"
+	case types.Int, types.Int64:
+		return "int64"
+	case types.Uint8:
+		return "nat8"
+	case types.Uint16:
+		return "nat16"
+	case types.Uint32:
+		return "nat32"
+	case types.Uint, types.Uint64:
+		return "nat64"
+	case types.Float32:
+		return "float32"
+	case types.Float64:
+		return "float64"
+	default:
+		return "json"
+	}
+}
+
+// mapKeyType maps a Go map key to a TypeGen map key primitive
+func mapKeyType(t types.Type) (string, string) {
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok || basic.Info()&(types.IsString|types.IsInteger) == 0 || unsupported(basic) != "" {
+		return "", fmt.Sprintf("a map keyed by %s", t)
+	}
+	return basicType(basic), ""
+}
+
+// hasCustomJSON reports whether a type controls its own JSON encoding
+func hasCustomJSON(t types.Type) bool {
+	methods := types.NewMethodSet(types.NewPointer(t))
+	for _, name := range []string{"MarshalJSON", "MarshalText"} {
+		if methods.Lookup(nil, name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// derefStruct returns the struct behind a possibly pointer-typed embedded field
+func derefStruct(t types.Type) (*types.Struct, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	return st, ok
+}
+
+func isByte(t types.Type) bool {
+	basic, ok := types.Unalias(t).(*types.Basic)
+	return ok && basic.Kind() == types.Uint8
+}
+
+// parseTag splits a json struct tag into its name and options
+func parseTag(tag string) (string, string) {
+	name, options, _ := strings.Cut(tag, ",")
+	return name, options
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// typeString renders a Go type relative to the imported package
+func typeString(t types.Type, pkg *types.Package) string {
+	return types.TypeString(t, types.RelativeTo(pkg))
+}
+
+// docLines splits a doc comment into lines without the trailing newline
+func docLines(text string) []string {
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+func primitive(name string) ast.Type {
+	return &ast.PrimitiveType{Name: name}
+}
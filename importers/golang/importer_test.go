@@ -0,0 +1,142 @@
+package golang
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var shopOptions = Options{Enums: []string{"Currency"}}
+
+// render concatenates the imported files and issues in a stable form
+func render(result *Result) string {
+	filenames := make([]string, 0, len(result.Files))
+	for filename := range result.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var b strings.Builder
+	for _, filename := range filenames {
+		b.WriteString("-- " + filename + " --\n")
+		b.WriteString(importers.Format(result.Files[filename], result.Comments))
+	}
+	b.WriteString("-- issues --\n")
+	for _, issue := range result.Issues {
+		b.WriteString(issue.String() + "\n")
+	}
+	return b.String()
+}
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, actual)
+	}
+}
+
+func TestImportGolden(t *testing.T) {
+	result, err := Import(filepath.Join("testdata", "shop"), shopOptions)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	assertGolden(t, "shop", render(result))
+}
+
+func TestImportOutputValidates(t *testing.T) {
+	result, err := Import(filepath.Join("testdata", "shop"), shopOptions)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	files := make(map[string]*ast.ProgramNode)
+	for filename, program := range result.Files {
+		source := importers.Format(program, result.Comments)
+		parsed, err := parser.Parse(strings.NewReader(source), filename)
+		if err != nil {
+			t.Fatalf("imported %s does not parse: %v\n%s", filename, err, source)
+		}
+		files[filename] = parsed
+	}
+
+	module := ast.NewModule("shop", files)
+	if validation := validator.NewValidator().Validate(module); validation.HasErrors() {
+		t.Fatalf("imported module does not validate:\n%s", validation.String())
+	}
+}
+
+func TestImportOneFilePerSourceFile(t *testing.T) {
+	result, err := Import(filepath.Join("testdata", "shop"), shopOptions)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	for _, filename := range []string{"user.tg", "order.tg", "json_types.tg"} {
+		if _, ok := result.Files[filename]; !ok {
+			t.Errorf("expected %s in %v", filename, result.Files)
+		}
+	}
+	// Anonymous structs are declared next to their parent
+	if result.Files["user.tg"].Declarations[0].(*ast.StructNode).Name != "Base" {
+		t.Errorf("expected Base first in user.tg")
+	}
+}
+
+func TestImportEnumAllowlist(t *testing.T) {
+	result, err := Import(filepath.Join("testdata", "shop"), Options{})
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	source := importers.Format(result.Files["order.tg"], nil)
+	if !strings.Contains(source, "type Currency = string") {
+		t.Errorf("expected Currency to stay an alias without the allowlist:\n%s", source)
+	}
+	if !strings.Contains(source, "enum Priority {") {
+		t.Errorf("expected Priority to be detected by naming convention:\n%s", source)
+	}
+}
+
+func TestImportMissingPackage(t *testing.T) {
+	if _, err := Import(filepath.Join("testdata", "missing"), Options{}); err == nil {
+		t.Error("expected an error for a missing package")
+	}
+}
+
+func TestImportDeterministic(t *testing.T) {
+	first, err := Import(filepath.Join("testdata", "shop"), shopOptions)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := Import(filepath.Join("testdata", "shop"), shopOptions)
+		if err != nil {
+			t.Fatalf("Import error: %v", err)
+		}
+		if render(again) != render(first) {
+			t.Fatal("import output is not deterministic")
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package shop
+
+import "strings"
+
+// Code has a custom encoding
+type Code string
+
+func (c Code) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(c))), nil
+}
+
+type Labels []string
+
+type HTTP_Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Code  Code   `json:"code"`
+}
@@ -0,0 +1,52 @@
+package shop
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Priority is an iota-based enum
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// Currency doesn't follow the naming convention and is listed in the allowlist
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+)
+
+// Order is a purchase by a user
+type Order struct {
+	ID       int64           `json:"id"`
+	Buyer    *User           `json:"buyer"`
+	Items    []LineItem      `json:"items"`
+	Priority Priority        `json:"priority"`
+	Currency Currency        `json:"currency"`
+	Total    float64         `json:"total,string"`
+	Timeout  time.Duration   `json:"timeout"`
+	Extra    json.RawMessage `json:"extra"`
+	Callback func()          `json:"-"`
+	OnChange func(Order)
+	Updates  chan string
+}
+
+type LineItem struct {
+	SKU      string `json:"sku"`
+	Quantity uint32 `json:"quantity"`
+	Note     string `json:"note,omitzero"`
+}
+
+// Page is generic and is skipped
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+// Handler has no JSON encoding
+type Handler func(Order) error
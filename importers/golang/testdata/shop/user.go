@@ -0,0 +1,44 @@
+// Package shop is a fixture for the Go importer tests.
+package shop
+
+import "time"
+
+// Base holds the fields shared by stored records
+type Base struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User is a registered customer
+type User struct {
+	Base
+	// Email is the login address
+	Email    string            `json:"email"`
+	Name     *string           `json:"name"`
+	Nickname string            `json:"nickname,omitempty"`
+	UserID   string            `json:"userId"`
+	Age      uint8             `json:"age"`
+	Tags     []string          `json:"tags"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+	Avatar   []byte            `json:"avatar"`
+	Role     Role              `json:"role"`
+	Scores   map[int32]float64 `json:"scores"`
+	Settings struct {
+		Theme    string `json:"theme"`
+		Language string
+	} `json:"settings"`
+	Password string `json:"-"`
+	internal string
+}
+
+// UserID identifies a user
+type UserID = string
+
+// Role is stored as a string
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleGuest  Role = "read-only"
+)
@@ -0,0 +1,169 @@
+// Package importers holds the pieces shared by the converters that turn
+// other schema languages into TypeGen source.
+package importers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/generators"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/parser/grammar"
+)
+
+// Issue reports a construct that could not be converted faithfully.
+// Importers keep going after an issue, falling back to the closest TypeGen type.
+type Issue struct {
+	// Path locates the construct in the source, e.g. "user.json#/$defs/User/properties/tags"
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// Comments attaches // comment lines to the nodes of imported programs, for
+// notes that have no place in the TypeGen syntax itself
+type Comments struct {
+	// Leading lines go above a declaration, struct field or enum variant
+	Leading map[ast.Node][]string
+	// Trailing lines go at the end of a struct or enum body, e.g. for dropped fields
+	Trailing map[ast.Node][]string
+}
+
+// NewComments creates an empty set of comments
+func NewComments() *Comments {
+	return &Comments{
+		Leading:  make(map[ast.Node][]string),
+		Trailing: make(map[ast.Node][]string),
+	}
+}
+
+// Add appends a comment line above a node
+func (c *Comments) Add(node ast.Node, line string) {
+	c.Leading[node] = append(c.Leading[node], line)
+}
+
+// AddTrailing appends a comment line at the end of a struct or enum body
+func (c *Comments) AddTrailing(node ast.Node, line string) {
+	c.Trailing[node] = append(c.Trailing[node], line)
+}
+
+func (c *Comments) leading(node ast.Node, indent string) []string {
+	if c == nil {
+		return nil
+	}
+	return commentLines(c.Leading[node], indent)
+}
+
+func (c *Comments) trailing(node ast.Node, indent string) []string {
+	if c == nil {
+		return nil
+	}
+	return commentLines(c.Trailing[node], indent)
+}
+
+func commentLines(lines []string, indent string) []string {
+	var result []string
+	for _, line := range lines {
+		if line == "" {
+			result = append(result, indent+"//")
+		} else {
+			result = append(result, indent+"// "+line)
+		}
+	}
+	return result
+}
+
+// Format renders a program as TypeGen source: imports first, then the
+// declarations in order, separated by blank lines. comments may be nil.
+func Format(program *ast.ProgramNode, comments *Comments) string {
+	var parts []string
+
+	if len(program.Imports) > 0 {
+		for _, imp := range program.Imports {
+			parts = append(parts, imp.String())
+		}
+		parts = append(parts, "")
+	}
+
+	for i, decl := range program.Declarations {
+		if i > 0 {
+			parts = append(parts, "")
+		}
+		parts = append(parts, comments.leading(decl, "")...)
+		parts = append(parts, formatDeclaration(decl, comments)...)
+	}
+
+	return strings.Join(parts, "\n") + "\n"
+}
+
+// formatDeclaration renders one declaration with the comments of its members,
+// quoting string constants so they parse back
+func formatDeclaration(decl ast.Declaration, comments *Comments) []string {
+	var lines []string
+
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		lines = append(lines, fmt.Sprintf("struct %s {", d.Name))
+		for _, field := range d.Fields {
+			lines = append(lines, comments.leading(field, "  ")...)
+			lines = append(lines, "  "+field.String())
+		}
+		lines = append(lines, comments.trailing(d, "  ")...)
+		lines = append(lines, "}")
+
+	case *ast.EnumNode:
+		lines = append(lines, fmt.Sprintf("enum %s {", d.Name))
+		for _, variant := range d.Variants {
+			lines = append(lines, comments.leading(variant, "  ")...)
+			lines = append(lines, "  "+variant.String())
+		}
+		lines = append(lines, comments.trailing(d, "  ")...)
+		lines = append(lines, "}")
+
+	case *ast.ConstantNode:
+		if s, ok := d.Value.(*ast.StringConstant); ok {
+			lines = append(lines, fmt.Sprintf("const %s = %s", d.Name, strconv.Quote(s.Value)))
+		} else {
+			lines = append(lines, d.String())
+		}
+
+	default:
+		lines = append(lines, decl.String())
+	}
+
+	return lines
+}
+
+// WriteFiles writes each program as a .tg file, in filename order. comments may be nil.
+func WriteFiles(files map[string]*ast.ProgramNode, comments *Comments, dest generators.FS) error {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if err := dest.WriteFile(filename, []byte(Format(files[filename], comments)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+// IsWireName reports whether a JSON name can be kept as the wire name of a
+// renamed field or variant, which can't contain quotes, backslashes or commas
+func IsWireName(name string) bool {
+	return !strings.ContainsAny(name, "\"\\`,")
+}
+
+// IsKeyword reports whether a name is reserved by the TypeGen grammar and
+// therefore cannot be used as a field or variant name
+func IsKeyword(name string) bool {
+	_, reserved := grammar.Keywords[name]
+	return reserved
+}
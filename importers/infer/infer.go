@@ -0,0 +1,363 @@
+// Package infer derives a starting TypeGen schema from sample JSON payloads.
+package infer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Options control the inference
+type Options struct {
+	// Root names the declaration of the top-level value, e.g. "Response"
+	Root string
+	// Datetimes turns strings that parse as RFC 3339 timestamps in every
+	// sample into datetime fields
+	Datetimes bool
+}
+
+// Result holds the inferred declarations and everything that was guessed
+type Result struct {
+	Program *ast.ProgramNode
+	// Comments carries notes on conflicting shapes
+	Comments *importers.Comments
+	Issues   []importers.Issue
+}
+
+// kind is the JSON type of an observed value
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindString
+	kindDatetime
+	kindObject
+	kindArray
+)
+
+var kindNames = map[kind]string{
+	kindNull:     "null",
+	kindBool:     "boolean",
+	kindInt:      "integer",
+	kindFloat:    "number",
+	kindString:   "string",
+	kindDatetime: "datetime",
+	kindObject:   "object",
+	kindArray:    "array",
+}
+
+// shape accumulates every value observed at one position of the samples
+type shape struct {
+	kinds map[kind]bool
+	// objects counts the objects merged here, to tell which keys are missing in some
+	objects int
+	keys    []string // object keys in first-seen order
+	fields  map[string]*shape
+	present map[string]int // objects that had each key
+	items   *shape         // merged elements of every array seen here
+}
+
+func newShape() *shape {
+	return &shape{
+		kinds:   make(map[kind]bool),
+		fields:  make(map[string]*shape),
+		present: make(map[string]int),
+	}
+}
+
+// Infer merges the samples, in order, into one schema. Each sample must be a
+// single JSON value; samples are keyed by name only for error messages.
+func Infer(names []string, samples [][]byte, options Options) (*Result, error) {
+	root := naming.PascalCase(options.Root)
+	if root == "" {
+		return nil, fmt.Errorf("root name %q has no letters or digits", options.Root)
+	}
+	if root[0] < 'A' || root[0] > 'Z' {
+		root = "T" + root
+	}
+
+	merged := newShape()
+	for i, sample := range samples {
+		dec := json.NewDecoder(bytes.NewReader(sample))
+		dec.UseNumber()
+		if err := merged.add(dec, options); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", names[i], err)
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("failed to read %s: unexpected data after the top-level value", names[i])
+		}
+	}
+
+	in := &inferrer{
+		program:  &ast.ProgramNode{},
+		comments: importers.NewComments(),
+		taken:    make(map[string]bool),
+	}
+	in.taken[root] = true
+
+	if merged.resolved() == kindObject && len(merged.keys) > 0 {
+		in.declareStruct(root, merged, "$")
+	} else {
+		alias := &ast.TypeAliasNode{Name: root}
+		in.program.Declarations = append(in.program.Declarations, alias)
+		var note string
+		alias.Type, _, note = in.typeOf(merged, root, "$")
+		if note != "" {
+			in.comments.Add(alias, note)
+		}
+	}
+
+	return &Result{Program: in.program, Comments: in.comments, Issues: in.issues}, nil
+}
+
+// add reads one JSON value from dec and merges it into the shape
+func (s *shape) add(dec *json.Decoder, options Options) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case nil:
+		s.kinds[kindNull] = true
+	case bool:
+		s.kinds[kindBool] = true
+	case json.Number:
+		if _, err := strconv.ParseInt(t.String(), 10, 64); err == nil {
+			s.kinds[kindInt] = true
+		} else {
+			s.kinds[kindFloat] = true
+		}
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, t); options.Datetimes && err == nil {
+			s.kinds[kindDatetime] = true
+		} else {
+			s.kinds[kindString] = true
+		}
+	case json.Delim:
+		if t == '{' {
+			s.kinds[kindObject] = true
+			s.objects++
+			seen := make(map[string]bool)
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := keyTok.(string)
+				field, ok := s.fields[key]
+				if !ok {
+					field = newShape()
+					s.fields[key] = field
+					s.keys = append(s.keys, key)
+				}
+				if !seen[key] {
+					seen[key] = true
+					s.present[key]++
+				}
+				if err := field.add(dec, options); err != nil {
+					return err
+				}
+			}
+		} else {
+			s.kinds[kindArray] = true
+			if s.items == nil {
+				s.items = newShape()
+			}
+			for dec.More() {
+				if err := s.items.add(dec, options); err != nil {
+					return err
+				}
+			}
+		}
+		// Consume the closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolved returns the single non-null kind of a shape after widening
+// integers to numbers and timestamps to strings, or kindNull when the values
+// conflict or were all null
+func (s *shape) resolved() kind {
+	var kinds []kind
+	for k := range s.kinds {
+		if k != kindNull {
+			kinds = append(kinds, k)
+		}
+	}
+	switch {
+	case len(kinds) == 1:
+		return kinds[0]
+	case len(kinds) == 2 && s.kinds[kindInt] && s.kinds[kindFloat]:
+		return kindFloat
+	case len(kinds) == 2 && s.kinds[kindString] && s.kinds[kindDatetime]:
+		return kindString
+	}
+	return kindNull
+}
+
+// conflict describes the kinds of a shape whose values disagree
+func (s *shape) conflict() string {
+	var names []string
+	for k := range s.kinds {
+		if k != kindNull {
+			names = append(names, kindNames[k])
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// inferrer builds the declarations of one Infer call
+type inferrer struct {
+	program  *ast.ProgramNode
+	comments *importers.Comments
+	taken    map[string]bool
+	issues   []importers.Issue
+}
+
+// declareStruct declares a struct for an object shape; nested objects are
+// declared after it, named after their key path
+func (in *inferrer) declareStruct(name string, s *shape, path string) *ast.StructNode {
+	decl := &ast.StructNode{Name: name}
+	in.program.Declarations = append(in.program.Declarations, decl)
+
+	fieldNames := make(map[string]bool)
+	for _, key := range s.keys {
+		field := s.fields[key]
+		fieldPath := path + "." + key
+
+		fieldName := naming.SnakeCase(key)
+		if fieldName == "" || fieldName[0] < 'a' || fieldName[0] > 'z' {
+			fieldName = "f_" + fieldName
+		}
+		if importers.IsKeyword(fieldName) {
+			fieldName += "_"
+		}
+		base := fieldName
+		for n := 2; fieldNames[fieldName]; n++ {
+			fieldName = fmt.Sprintf("%s_%d", base, n)
+		}
+		fieldNames[fieldName] = true
+
+		fieldType, nullable, note := in.typeOf(field, name+naming.PascalCase(key), fieldPath)
+		node := &ast.FieldNode{
+			Name:     fieldName,
+			Type:     fieldType,
+			Optional: nullable || s.present[key] < s.objects,
+		}
+		decl.Fields = append(decl.Fields, node)
+
+		if fieldName != key {
+			if importers.IsWireName(key) {
+				node.WireName = key
+			} else {
+				in.report(fieldPath, fmt.Sprintf("key %q can't be a wire name and is renamed to %q", key, fieldName))
+			}
+		}
+		if note != "" {
+			in.comments.Add(node, note)
+		}
+	}
+	return decl
+}
+
+// typeOf converts a shape into a TypeGen type. It also reports whether null
+// was seen, and returns a comment for types that had to fall back to json.
+func (in *inferrer) typeOf(s *shape, name, path string) (ast.Type, bool, string) {
+	nullable := s.kinds[kindNull]
+
+	switch s.resolved() {
+	case kindBool:
+		return primitive("bool"), nullable, ""
+	case kindInt:
+		return primitive("int64"), nullable, ""
+	case kindFloat:
+		return primitive("float64"), nullable, ""
+	case kindString:
+		return primitive("string"), nullable, ""
+	case kindDatetime:
+		return primitive("datetime"), nullable, ""
+
+	case kindObject:
+		if len(s.keys) == 0 {
+			in.report(path, "only empty objects seen; using json")
+			return primitive("json"), nullable, "only empty objects seen"
+		}
+		structName := in.uniqueName(name)
+		in.declareStruct(structName, s, path)
+		return &ast.NamedType{Name: structName}, nullable, ""
+
+	case kindArray:
+		if s.items == nil || len(s.items.kinds) == 0 {
+			in.report(path, "only empty arrays seen; using []json")
+			return &ast.ArrayType{ElementType: primitive("json")}, nullable, "only empty arrays seen"
+		}
+		elem, elemNullable, note := in.typeOf(s.items, singular(name), path+"[]")
+		if elemNullable {
+			in.report(path+"[]", "null elements are not supported by TypeGen arrays and were ignored")
+			if note == "" {
+				note = "some elements are null"
+			}
+		}
+		return &ast.ArrayType{ElementType: elem}, nullable, note
+	}
+
+	if len(s.kinds) == 1 && nullable {
+		in.report(path, "only null seen; using json")
+		return primitive("json"), true, "only null seen"
+	}
+	conflict := s.conflict()
+	in.report(path, fmt.Sprintf("conflicting types %s; using json", conflict))
+	return primitive("json"), nullable, "conflicting types: " + conflict
+}
+
+// uniqueName reserves a declaration name, adding a numeric suffix on collisions
+func (in *inferrer) uniqueName(name string) string {
+	unique := name
+	for i := 2; in.taken[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	in.taken[unique] = true
+	return unique
+}
+
+func (in *inferrer) report(path, message string) {
+	in.issues = append(in.issues, importers.Issue{Path: path, Message: message})
+}
+
+// singular names the elements of an array after the array: "OrderItems" -> "OrderItem".
+// Names it cannot singularize get an "Item" suffix.
+func singular(name string) string {
+	for _, ending := range []string{"ss", "us", "is"} {
+		if strings.HasSuffix(name, ending) {
+			return name + "Item"
+		}
+	}
+	if strings.HasSuffix(name, "ies") && len(name) > 3 {
+		return strings.TrimSuffix(name, "ies") + "y"
+	}
+	if strings.HasSuffix(name, "s") && len(name) > 1 {
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+func primitive(name string) ast.Type {
+	return &ast.PrimitiveType{Name: name}
+}
@@ -0,0 +1,192 @@
+package infer
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var cases = []struct {
+	name    string
+	options Options
+}{
+	{"users", Options{Root: "User", Datetimes: true}},
+	{"conflicts", Options{Root: "Response"}},
+	{"list", Options{Root: "list_response"}},
+}
+
+// readSamples loads every sample of a testdata case, in file name order
+func readSamples(t *testing.T, name string) ([]string, [][]byte) {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", name, "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no samples for case %s: %v", name, err)
+	}
+	var samples [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, data)
+	}
+	return paths, samples
+}
+
+// render formats the inferred program followed by its issues
+func render(result *Result) string {
+	var b strings.Builder
+	b.WriteString(importers.Format(result.Program, result.Comments))
+	b.WriteString("-- issues --\n")
+	for _, issue := range result.Issues {
+		b.WriteString(issue.String() + "\n")
+	}
+	return b.String()
+}
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, actual)
+	}
+}
+
+func TestInferGolden(t *testing.T) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, samples := readSamples(t, tc.name)
+			result, err := Infer(names, samples, tc.options)
+			if err != nil {
+				t.Fatalf("Infer error: %v", err)
+			}
+			assertGolden(t, tc.name, render(result))
+		})
+	}
+}
+
+func TestInferOutputValidates(t *testing.T) {
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, samples := readSamples(t, tc.name)
+			result, err := Infer(names, samples, tc.options)
+			if err != nil {
+				t.Fatalf("Infer error: %v", err)
+			}
+
+			source := importers.Format(result.Program, result.Comments)
+			program, err := parser.Parse(strings.NewReader(source), "inferred.tg")
+			if err != nil {
+				t.Fatalf("inferred schema does not parse: %v\n%s", err, source)
+			}
+			module := ast.NewModule("inferred", map[string]*ast.ProgramNode{"inferred.tg": program})
+			if validation := validator.NewValidator().Validate(module); validation.HasErrors() {
+				t.Fatalf("inferred schema does not validate:\n%s\n%s", validation.String(), source)
+			}
+		})
+	}
+}
+
+func TestInferOptionalFields(t *testing.T) {
+	samples := [][]byte{
+		[]byte(`{"a": 1, "b": "x", "c": true}`),
+		[]byte(`{"a": 2, "c": null}`),
+	}
+	result, err := Infer([]string{"1.json", "2.json"}, samples, Options{Root: "Sample"})
+	if err != nil {
+		t.Fatalf("Infer error: %v", err)
+	}
+
+	source := importers.Format(result.Program, nil)
+	for _, expected := range []string{"a: int64", "b: ?string", "c: ?bool"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestInferNumbers(t *testing.T) {
+	samples := [][]byte{
+		[]byte(`{"count": 1, "ratio": 1, "big": 1e3}`),
+		[]byte(`{"count": 2, "ratio": 0.5, "big": 2}`),
+	}
+	result, err := Infer([]string{"1.json", "2.json"}, samples, Options{Root: "Sample"})
+	if err != nil {
+		t.Fatalf("Infer error: %v", err)
+	}
+
+	source := importers.Format(result.Program, nil)
+	for _, expected := range []string{"count: int64", "ratio: float64", "big: float64"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestInferWireNames(t *testing.T) {
+	samples := [][]byte{[]byte(`{"userId": 1, "a,b": 2}`)}
+	result, err := Infer([]string{"1.json"}, samples, Options{Root: "Sample"})
+	if err != nil {
+		t.Fatalf("Infer error: %v", err)
+	}
+
+	source := importers.Format(result.Program, nil)
+	for _, expected := range []string{`user_id: int64 = "userId"`, "a_b: int64\n"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+
+	// A comma can't be in a wire name, so that key changes on the wire
+	if len(result.Issues) != 1 || result.Issues[0].Message != `key "a,b" can't be a wire name and is renamed to "a_b"` {
+		t.Errorf("expected the key with a comma to be reported, got %v", result.Issues)
+	}
+}
+
+func TestInferDatetimesFlag(t *testing.T) {
+	samples := [][]byte{[]byte(`{"at": "2024-01-02T03:04:05Z"}`)}
+
+	for _, tc := range []struct {
+		datetimes bool
+		expected  string
+	}{
+		{false, "at: string"},
+		{true, "at: datetime"},
+	} {
+		result, err := Infer([]string{"1.json"}, samples, Options{Root: "Sample", Datetimes: tc.datetimes})
+		if err != nil {
+			t.Fatalf("Infer error: %v", err)
+		}
+		if source := importers.Format(result.Program, nil); !strings.Contains(source, tc.expected) {
+			t.Errorf("Datetimes=%v: expected %q in:\n%s", tc.datetimes, tc.expected, source)
+		}
+	}
+}
+
+func TestInferInvalidSample(t *testing.T) {
+	_, err := Infer([]string{"bad.json"}, [][]byte{[]byte(`{"a": }`)}, Options{Root: "Sample"})
+	if err == nil || !strings.Contains(err.Error(), "bad.json") {
+		t.Errorf("expected an error naming the sample, got %v", err)
+	}
+}
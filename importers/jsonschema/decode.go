@@ -0,0 +1,167 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// node is a decoded JSON value that keeps the key order of objects,
+// so declarations and fields come out in document order
+type node struct {
+	keys   []string
+	fields map[string]*node
+	items  []*node
+	value  any // string, json.Number, bool or nil for scalars
+	object bool
+	array  bool
+}
+
+// decode parses a JSON document into a node tree
+func decode(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	n, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the top-level value")
+	}
+	return n, nil
+}
+
+func decodeValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &node{value: tok}, nil
+	}
+
+	switch delim {
+	case '{':
+		n := &node{object: true, fields: make(map[string]*node)}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, duplicate := n.fields[key]; !duplicate {
+				n.keys = append(n.keys, key)
+			}
+			n.fields[key] = value
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+
+	default: // '['
+		n := &node{array: true}
+		for dec.More() {
+			item, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+}
+
+// get returns the value of an object key, or nil
+func (n *node) get(key string) *node {
+	if n == nil || !n.object {
+		return nil
+	}
+	return n.fields[key]
+}
+
+// has reports whether an object has a key
+func (n *node) has(key string) bool {
+	return n.get(key) != nil
+}
+
+// objectKeys returns an object's keys in document order
+func (n *node) objectKeys() []string {
+	if n == nil || !n.object {
+		return nil
+	}
+	return n.keys
+}
+
+// str returns the value of a string scalar
+func (n *node) str() (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	s, ok := n.value.(string)
+	return s, ok
+}
+
+// number returns the value of a number scalar
+func (n *node) number() (float64, bool) {
+	if n == nil {
+		return 0, false
+	}
+	num, ok := n.value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := num.Float64()
+	return f, err == nil
+}
+
+// integer returns the value of a number scalar without a fractional part
+func (n *node) integer() (int64, bool) {
+	if n == nil {
+		return 0, false
+	}
+	num, ok := n.value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(num.String(), 10, 64)
+	return i, err == nil
+}
+
+// boolean returns the value of a boolean scalar
+func (n *node) boolean() (bool, bool) {
+	if n == nil {
+		return false, false
+	}
+	b, ok := n.value.(bool)
+	return b, ok
+}
+
+// strings returns the string items of an array, or the single string of a scalar
+func (n *node) strings() []string {
+	if s, ok := n.str(); ok {
+		return []string{s}
+	}
+	if n == nil || !n.array {
+		return nil
+	}
+	var result []string
+	for _, item := range n.items {
+		if s, ok := item.str(); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,818 @@
+// Package jsonschema converts JSON Schema documents into TypeGen declarations.
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Result holds the converted files and everything that could not be converted faithfully
+type Result struct {
+	// Files maps .tg filenames to their declarations
+	Files  map[string]*ast.ProgramNode
+	Issues []importers.Issue
+}
+
+// definition is a named schema that becomes a top-level declaration
+type definition struct {
+	ref      string // canonical reference, e.g. "user.json#/$defs/User"
+	name     string // TypeGen type or constant name
+	file     string // .tg file the declaration goes into
+	schema   *node
+	constant bool
+}
+
+// scope locates the schema being converted
+type scope struct {
+	doc  string // document filename, for resolving local $refs
+	path string // JSON pointer into the document, for issues
+	file string // .tg file that receives inline declarations
+	hint string // name for an inline declaration, derived from the enclosing names
+}
+
+func (s scope) child(segment, hint string) scope {
+	s.path += "/" + escapePointer(segment)
+	s.hint = hint
+	return s
+}
+
+func (s scope) location() string {
+	return s.doc + s.path
+}
+
+// importer carries the state of one Import call
+type importer struct {
+	docs   map[string]*node
+	defs   map[string]*definition
+	order  []*definition
+	names  map[string]bool
+	files  map[string]*ast.ProgramNode
+	issues []importers.Issue
+}
+
+// schemaKeywords mark an object as a schema rather than a plain container of definitions
+var schemaKeywords = []string{"type", "properties", "items", "additionalProperties", "enum", "const", "oneOf", "anyOf", "allOf", "$ref"}
+
+// Import converts JSON Schema documents, keyed by filename, into TypeGen files.
+// Each document's root schema and definitions go into a .tg file named after
+// the document; a definition that only groups further definitions gets a file
+// of its own. $refs may point into any document of the same import.
+func Import(documents map[string][]byte) (*Result, error) {
+	im := &importer{
+		docs:  make(map[string]*node),
+		defs:  make(map[string]*definition),
+		names: make(map[string]bool),
+		files: make(map[string]*ast.ProgramNode),
+	}
+
+	filenames := make([]string, 0, len(documents))
+	for filename := range documents {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		doc, err := decode(documents[filename])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+		if !doc.object {
+			return nil, fmt.Errorf("failed to parse %s: schema must be a JSON object", filename)
+		}
+		im.docs[filename] = doc
+	}
+
+	// Name every definition first so $refs resolve regardless of order
+	for _, filename := range filenames {
+		doc := im.docs[filename]
+		base := documentBase(filename)
+		file := naming.SnakeCase(base) + ".tg"
+
+		if isSchema(doc) {
+			rootName := base
+			if title, ok := doc.get("title").str(); ok && title != "" {
+				rootName = title
+			}
+			im.register(filename, "#", rootName, file, doc)
+		}
+		im.registerDefinitions(filename, "#", doc, file)
+	}
+
+	for _, def := range im.order {
+		im.declare(def)
+	}
+
+	return &Result{Files: im.files, Issues: im.issues}, nil
+}
+
+// registerDefinitions names the entries of $defs and definitions, descending into groups
+func (im *importer) registerDefinitions(doc, pointer string, n *node, file string) {
+	for _, key := range []string{"$defs", "definitions"} {
+		container := n.get(key)
+		if container == nil || !container.object {
+			continue
+		}
+		for _, name := range container.keys {
+			schema := container.fields[name]
+			defPointer := pointer + "/" + key + "/" + escapePointer(name)
+
+			if isGroup(schema) {
+				im.registerDefinitions(doc, defPointer, schema, naming.SnakeCase(name)+".tg")
+				continue
+			}
+			im.register(doc, defPointer, name, file, schema)
+		}
+	}
+}
+
+// register reserves a TypeGen name for a definition
+func (im *importer) register(doc, pointer, rawName, file string, schema *node) {
+	def := &definition{
+		ref:    doc + pointer,
+		file:   file,
+		schema: schema,
+	}
+
+	location := doc + pointer
+	if isConstant(schema) {
+		def.constant = true
+		def.name = im.uniqueName(constantName(rawName), location)
+	} else {
+		def.name = im.uniqueName(typeName(rawName), location)
+	}
+
+	im.defs[def.ref] = def
+	im.order = append(im.order, def)
+}
+
+// declare converts a definition into its top-level declaration
+func (im *importer) declare(def *definition) {
+	doc, pointer, _ := strings.Cut(def.ref, "#")
+	s := scope{doc: doc, path: "#" + pointer, file: def.file, hint: def.name}
+
+	if def.constant {
+		im.declareConstant(def, s)
+		return
+	}
+
+	t, nullable := im.convertType(def.schema, s, def.name)
+	if named, ok := t.(*ast.NamedType); ok && named.Name == def.name {
+		return // The schema became a struct or enum of its own
+	}
+	if nullable {
+		im.report(s, "null is only representable on fields; the alias drops it")
+	}
+	im.add(def.file, &ast.TypeAliasNode{Name: def.name, Type: t})
+}
+
+// declareConstant converts a const definition into a constant
+func (im *importer) declareConstant(def *definition, s scope) {
+	value := def.schema.get("const")
+
+	if str, ok := value.str(); ok {
+		im.add(def.file, &ast.ConstantNode{Name: def.name, Value: &ast.StringConstant{Value: str}})
+		return
+	}
+	if i, ok := value.integer(); ok && i >= 0 {
+		im.add(def.file, &ast.ConstantNode{Name: def.name, Value: &ast.IntConstant{Value: i}})
+		return
+	}
+	im.report(s, "only string and non-negative integer constants are supported; the constant is skipped")
+}
+
+// convertType converts a schema into a TypeGen type. Schemas that need a
+// declaration of their own (objects with properties, string enums, tagged
+// unions) are declared under name, or under a fresh name derived from the
+// scope hint when name is empty. The second result reports whether null is
+// allowed, which TypeGen can only express on fields.
+func (im *importer) convertType(n *node, s scope, name string) (ast.Type, bool) {
+	if n == nil {
+		return primitive("json"), false
+	}
+	if b, ok := n.boolean(); ok {
+		if !b {
+			im.report(s, "the false schema has no TypeGen equivalent; using json")
+		}
+		return primitive("json"), false
+	}
+	if !n.object {
+		im.report(s, "schema must be an object or a boolean; using json")
+		return primitive("json"), false
+	}
+
+	if ref, ok := n.get("$ref").str(); ok {
+		return im.convertRef(ref, s), false
+	}
+
+	if n.has("patternProperties") {
+		im.report(s.child("patternProperties", s.hint), "patternProperties is not supported and was dropped")
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if branches := n.get(key); branches != nil {
+			return im.convertOneOf(n, branches.items, s.child(key, s.hint), name)
+		}
+	}
+
+	if all := n.get("allOf"); all != nil {
+		if len(all.items) == 1 {
+			return im.convertType(all.items[0], s.child("allOf", s.hint).child("0", s.hint), name)
+		}
+		im.report(s.child("allOf", s.hint), "allOf merging is not supported; using json")
+		return primitive("json"), false
+	}
+
+	if value := n.get("const"); value != nil {
+		return constantType(value), false
+	}
+
+	types := n.get("type").strings()
+	nullable := false
+	var nonNull []string
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+		} else {
+			nonNull = append(nonNull, t)
+		}
+	}
+
+	if values := n.get("enum"); values != nil {
+		return im.convertEnum(values, s, name), nullable || hasNull(values)
+	}
+
+	var schemaType string
+	switch {
+	case len(nonNull) > 1:
+		im.report(s.child("type", s.hint), fmt.Sprintf("multiple types %s are not supported; using json", strings.Join(nonNull, ", ")))
+		return primitive("json"), nullable
+	case len(nonNull) == 1:
+		schemaType = nonNull[0]
+	case n.has("properties") || n.has("additionalProperties"):
+		schemaType = "object"
+	case n.has("items"):
+		schemaType = "array"
+	case nullable:
+		im.report(s, "a schema that only allows null has no TypeGen equivalent; using json")
+		return primitive("json"), true
+	default:
+		return primitive("json"), false
+	}
+
+	switch schemaType {
+	case "string":
+		switch format, _ := n.get("format").str(); format {
+		case "date-time":
+			return primitive("datetime"), nullable
+		case "date":
+			return primitive("date"), nullable
+		case "time":
+			return primitive("time"), nullable
+		default:
+			return primitive("string"), nullable
+		}
+
+	case "integer":
+		return primitive(integerType(n)), nullable
+
+	case "number":
+		if format, _ := n.get("format").str(); format == "float" {
+			return primitive("float32"), nullable
+		}
+		return primitive("float64"), nullable
+
+	case "boolean":
+		return primitive("bool"), nullable
+
+	case "array":
+		return im.convertArray(n, s), nullable
+
+	case "object":
+		return im.convertObject(n, s, name), nullable
+
+	default:
+		im.report(s.child("type", s.hint), fmt.Sprintf("unknown type %q; using json", schemaType))
+		return primitive("json"), nullable
+	}
+}
+
+// convertRef resolves a $ref to the name of its definition
+func (im *importer) convertRef(ref string, s scope) ast.Type {
+	def, ok := im.resolve(ref, s.doc)
+	if !ok {
+		im.report(s.child("$ref", s.hint), fmt.Sprintf("unresolved $ref %q; using json", ref))
+		return primitive("json")
+	}
+	if def.constant {
+		return constantType(def.schema.get("const"))
+	}
+	return &ast.NamedType{Name: def.name}
+}
+
+// resolve finds the definition a $ref points to
+func (im *importer) resolve(ref, doc string) (*definition, bool) {
+	target, fragment, _ := strings.Cut(ref, "#")
+	if target != "" {
+		doc = path.Base(target)
+	}
+	if fragment == "/" {
+		fragment = ""
+	}
+	def, ok := im.defs[doc+"#"+fragment]
+	return def, ok
+}
+
+// convertArray converts an array schema; tuples are not representable
+func (im *importer) convertArray(n *node, s scope) ast.Type {
+	items := n.get("items")
+	if n.has("prefixItems") || (items != nil && items.array) {
+		im.report(s, "tuple validation is not supported; using []json")
+		return &ast.ArrayType{ElementType: primitive("json")}
+	}
+
+	element, nullable := im.convertType(items, s.child("items", s.hint+"Item"), "")
+	if nullable {
+		im.report(s.child("items", s.hint), "null elements are not supported; the element type drops null")
+	}
+	return &ast.ArrayType{ElementType: element}
+}
+
+// convertObject declares a struct for objects with properties and maps
+// objects with an additionalProperties schema to [string]V
+func (im *importer) convertObject(n *node, s scope, name string) ast.Type {
+	if props := n.get("properties"); props != nil && len(props.keys) > 0 {
+		if name == "" {
+			name = im.uniqueName(typeName(s.hint), s.location())
+		}
+		im.declareStruct(name, n, s, "")
+		return &ast.NamedType{Name: name}
+	}
+
+	if additional := n.get("additionalProperties"); additional != nil && additional.object {
+		value, nullable := im.convertType(additional, s.child("additionalProperties", s.hint+"Value"), "")
+		if nullable {
+			im.report(s.child("additionalProperties", s.hint), "null values are not supported; the value type drops null")
+		}
+		return &ast.MapType{KeyType: primitive("string"), ValueType: value}
+	}
+
+	return primitive("json")
+}
+
+// declareStruct adds a struct for an object schema, leaving out the skip property
+func (im *importer) declareStruct(name string, n *node, s scope, skip string) {
+	decl := &ast.StructNode{Name: name}
+	im.add(s.file, decl)
+
+	if additional := n.get("additionalProperties"); additional != nil && additional.object && n.has("properties") {
+		im.report(s.child("additionalProperties", s.hint), "additionalProperties alongside properties is not supported and was dropped")
+	}
+
+	required := make(map[string]bool)
+	for _, key := range n.get("required").strings() {
+		required[key] = true
+	}
+
+	props := n.get("properties")
+	propsScope := s.child("properties", name)
+	fieldNames := make(map[string]bool)
+
+	for _, key := range props.objectKeys() {
+		if key == skip {
+			continue
+		}
+		fieldScope := propsScope.child(key, name+typeName(key))
+
+		fieldName := identifier(key)
+		for i := 2; fieldNames[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s_%d", naming.SnakeCase(key), i)
+		}
+		fieldNames[fieldName] = true
+
+		fieldType, nullable := im.convertType(props.fields[key], fieldScope, "")
+		decl.Fields = append(decl.Fields, &ast.FieldNode{
+			Name:     fieldName,
+			Type:     fieldType,
+			Optional: !required[key] || nullable,
+			WireName: im.wireName(fieldName, key, fieldScope, "property"),
+		})
+	}
+}
+
+// convertEnum declares a simple enum for a string enum
+func (im *importer) convertEnum(values *node, s scope, name string) ast.Type {
+	var variants []string
+	for _, value := range values.items {
+		if value.value == nil && !value.object && !value.array {
+			continue // null is handled as nullability
+		}
+		str, ok := value.str()
+		if !ok {
+			im.report(s.child("enum", s.hint), "only string enums are supported; using json")
+			return primitive("json")
+		}
+		variants = append(variants, str)
+	}
+	if len(variants) == 0 {
+		im.report(s.child("enum", s.hint), "enum has no values; using json")
+		return primitive("json")
+	}
+
+	if name == "" {
+		name = im.uniqueName(typeName(s.hint), s.location())
+	}
+	decl := &ast.EnumNode{Name: name}
+	im.add(s.file, decl)
+
+	seen := make(map[string]bool)
+	for i, value := range variants {
+		variant := identifier(value)
+		if seen[variant] {
+			im.report(s.child("enum", s.hint), fmt.Sprintf("enum value %q collides with another value after renaming and was dropped", value))
+			continue
+		}
+		seen[variant] = true
+		decl.Variants = append(decl.Variants, &ast.EnumVariantNode{Name: variant, WireName: im.wireName(variant, value, s.child("enum", s.hint).child(fmt.Sprint(i), s.hint), "enum value")})
+	}
+	return &ast.NamedType{Name: name}
+}
+
+// convertOneOf handles nullable types written as a oneOf with null, and
+// discriminated unions, which become tagged enums
+func (im *importer) convertOneOf(parent *node, branches []*node, s scope, name string) (ast.Type, bool) {
+	nullable := false
+	var nonNull []*node
+	var nonNullIndex []int
+	for i, branch := range branches {
+		if types := branch.get("type").strings(); len(types) == 1 && types[0] == "null" {
+			nullable = true
+			continue
+		}
+		nonNull = append(nonNull, branch)
+		nonNullIndex = append(nonNullIndex, i)
+	}
+
+	if len(nonNull) == 1 {
+		t, branchNullable := im.convertType(nonNull[0], s.child(fmt.Sprint(nonNullIndex[0]), s.hint), name)
+		return t, nullable || branchNullable
+	}
+
+	discriminator := im.discriminator(parent, nonNull, s.doc)
+	if discriminator == "" {
+		im.report(s, "oneOf without a discriminator property is not supported; using json")
+		return primitive("json"), nullable
+	}
+
+	if name == "" {
+		name = im.uniqueName(typeName(s.hint), s.location())
+	}
+	decl := &ast.EnumNode{Name: name}
+	im.add(s.file, decl)
+
+	if discriminator != "type" {
+		im.report(s, fmt.Sprintf("discriminator %q becomes the TypeGen \"type\" tag", discriminator))
+	}
+
+	native := true
+	seen := make(map[string]bool)
+	for i, branch := range nonNull {
+		branchScope := s.child(fmt.Sprint(nonNullIndex[i]), s.hint)
+		schema := branch
+		var refName string
+		if ref, ok := branch.get("$ref").str(); ok {
+			if def, ok := im.resolve(ref, s.doc); ok {
+				schema = def.schema
+				refName = def.name
+			}
+		}
+
+		tag := discriminatorValue(schema, discriminator)
+		if tag == "" {
+			tag = mappedValue(parent, branch.get("$ref"))
+		}
+		if tag == "" {
+			im.report(branchScope, "branch has no discriminator value and was dropped")
+			continue
+		}
+
+		variantName := identifier(tag)
+		if seen[variantName] {
+			im.report(branchScope, fmt.Sprintf("discriminator value %q is used by another branch; the branch was dropped", tag))
+			continue
+		}
+		seen[variantName] = true
+		variant := &ast.EnumVariantNode{Name: variantName, WireName: im.wireName(variantName, tag, branchScope, "discriminator value")}
+		decl.Variants = append(decl.Variants, variant)
+
+		if refName != "" {
+			variant.Payload = &ast.NamedType{Name: refName}
+			native = false
+			continue
+		}
+
+		var rest []string
+		for _, key := range schema.get("properties").objectKeys() {
+			if key != discriminator {
+				rest = append(rest, key)
+			}
+		}
+		switch {
+		case len(rest) == 0:
+			// A variant without payload
+		case len(rest) == 1 && rest[0] == "payload":
+			payload, _ := im.convertType(schema.get("properties").get("payload"), branchScope.child("properties", name).child("payload", name+typeName(tag)), "")
+			variant.Payload = payload
+		default:
+			payloadName := im.uniqueName(name+typeName(tag), branchScope.location())
+			im.declareStruct(payloadName, schema, branchScope, discriminator)
+			variant.Payload = &ast.NamedType{Name: payloadName}
+			native = false
+		}
+	}
+
+	if !native {
+		im.report(s, "branches are not in TypeGen's {\"type\", \"payload\"} shape; variants carry the branch object as their payload, which changes the wire format")
+	}
+	return &ast.NamedType{Name: name}, nullable
+}
+
+// discriminator finds the property that tags the branches of a oneOf: the
+// OpenAPI discriminator if present, otherwise a property every branch fixes
+// with a const, preferring "type"
+func (im *importer) discriminator(parent *node, branches []*node, doc string) string {
+	if property, ok := parent.get("discriminator").get("propertyName").str(); ok {
+		return property
+	}
+
+	var candidates []string
+	for i, branch := range branches {
+		schema := branch
+		if ref, ok := branch.get("$ref").str(); ok {
+			def, found := im.resolve(ref, doc)
+			if !found {
+				return ""
+			}
+			schema = def.schema
+		}
+
+		var tagged []string
+		for _, key := range schema.get("properties").objectKeys() {
+			if discriminatorValue(schema, key) != "" {
+				tagged = append(tagged, key)
+			}
+		}
+		if i == 0 {
+			candidates = tagged
+			continue
+		}
+		candidates = intersect(candidates, tagged)
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "type" {
+			return candidate
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// discriminatorValue returns the string a branch fixes its discriminator property to
+func discriminatorValue(schema *node, property string) string {
+	prop := schema.get("properties").get(property)
+	if value, ok := prop.get("const").str(); ok {
+		return value
+	}
+	if values := prop.get("enum"); values != nil && len(values.items) == 1 {
+		value, _ := values.items[0].str()
+		return value
+	}
+	return ""
+}
+
+// mappedValue looks a $ref branch up in an OpenAPI discriminator mapping
+func mappedValue(parent, ref *node) string {
+	target, ok := ref.str()
+	if !ok {
+		return ""
+	}
+	mapping := parent.get("discriminator").get("mapping")
+	if mapping == nil {
+		return ""
+	}
+	for _, key := range mapping.keys {
+		if value, _ := mapping.fields[key].str(); value == target {
+			return key
+		}
+	}
+	return ""
+}
+
+// identifier converts a JSON name into a TypeGen snake_case identifier
+func identifier(raw string) string {
+	name := naming.SnakeCase(raw)
+	if name == "" || !isLetter(name[0]) {
+		name = "n_" + name
+	}
+	if importers.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
+// wireName returns the wire name that keeps raw as the JSON name of a field
+// or variant named name, or "" when they are the same. Names that can't be
+// wire names are reported, since the JSON name changes on the wire.
+func (im *importer) wireName(name, raw string, s scope, what string) string {
+	if name == raw {
+		return ""
+	}
+	if !importers.IsWireName(raw) {
+		im.report(s, fmt.Sprintf("%s %q can't be a wire name and is renamed to %q; the JSON name changes on the wire", what, raw, name))
+		return ""
+	}
+	return raw
+}
+
+// uniqueName reserves a declaration name, adding a numeric suffix on collisions
+func (im *importer) uniqueName(name, location string) string {
+	unique := name
+	for i := 2; im.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	if unique != name {
+		im.issues = append(im.issues, importers.Issue{Path: location, Message: fmt.Sprintf("name %s is already taken; using %s", name, unique)})
+	}
+	im.names[unique] = true
+	return unique
+}
+
+// add appends a declaration to a .tg file
+func (im *importer) add(file string, decl ast.Declaration) {
+	program, ok := im.files[file]
+	if !ok {
+		program = &ast.ProgramNode{}
+		im.files[file] = program
+	}
+	program.Declarations = append(program.Declarations, decl)
+}
+
+func (im *importer) report(s scope, message string) {
+	im.issues = append(im.issues, importers.Issue{Path: s.location(), Message: message})
+}
+
+// integerType picks the narrowest sized integer type that fits the schema's bounds
+func integerType(n *node) string {
+	var lo, hi *float64
+	if v, ok := n.get("minimum").number(); ok {
+		v = math.Ceil(v)
+		lo = &v
+	}
+	if v, ok := n.get("exclusiveMinimum").number(); ok {
+		v = math.Floor(v) + 1
+		lo = &v
+	}
+	if v, ok := n.get("maximum").number(); ok {
+		v = math.Floor(v)
+		hi = &v
+	}
+	if v, ok := n.get("exclusiveMaximum").number(); ok {
+		v = math.Ceil(v) - 1
+		hi = &v
+	}
+
+	sizes := []int{8, 16, 32, 64}
+	if lo != nil && *lo >= 0 {
+		for _, bits := range sizes {
+			if hi != nil && *hi <= math.Exp2(float64(bits))-1 {
+				return fmt.Sprintf("nat%d", bits)
+			}
+		}
+		return "nat64"
+	}
+	if lo != nil && hi != nil {
+		for _, bits := range sizes {
+			limit := math.Exp2(float64(bits - 1))
+			if *lo >= -limit && *hi <= limit-1 {
+				return fmt.Sprintf("int%d", bits)
+			}
+		}
+	}
+	return "int64"
+}
+
+// constantType returns the type of a const value
+func constantType(value *node) ast.Type {
+	if _, ok := value.str(); ok {
+		return primitive("string")
+	}
+	if _, ok := value.integer(); ok {
+		return primitive("int64")
+	}
+	if _, ok := value.number(); ok {
+		return primitive("float64")
+	}
+	if _, ok := value.boolean(); ok {
+		return primitive("bool")
+	}
+	return primitive("json")
+}
+
+func primitive(name string) ast.Type {
+	return &ast.PrimitiveType{Name: name}
+}
+
+// isSchema reports whether an object describes a value rather than only holding definitions
+func isSchema(n *node) bool {
+	for _, key := range schemaKeywords {
+		if n.has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGroup reports whether a definition only groups further definitions
+func isGroup(n *node) bool {
+	return (n.has("$defs") || n.has("definitions")) && !isSchema(n)
+}
+
+// isConstant reports whether a definition is a single string or integer value
+func isConstant(n *node) bool {
+	value := n.get("const")
+	if value == nil {
+		return false
+	}
+	if _, ok := value.str(); ok {
+		return true
+	}
+	_, ok := value.integer()
+	return ok
+}
+
+func hasNull(values *node) bool {
+	for _, value := range values.items {
+		if value.value == nil && !value.object && !value.array {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName converts a JSON name into a TypeGen PascalCase type name
+func typeName(raw string) string {
+	name := naming.PascalCase(raw)
+	if name == "" || !isLetter(name[0]) {
+		name = "T" + name
+	}
+	return name
+}
+
+// constantName converts a JSON name into a TypeGen CONSTANT_CASE name
+func constantName(raw string) string {
+	name := naming.ScreamingSnakeCase(raw)
+	if name == "" || !isLetter(name[0]) {
+		name = "C_" + name
+	}
+	return name
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// documentBase strips directories and JSON Schema extensions from a filename
+func documentBase(filename string) string {
+	base := path.Base(filename)
+	base = strings.TrimSuffix(base, ".json")
+	base = strings.TrimSuffix(base, ".schema")
+	return base
+}
+
+// escapePointer escapes a JSON pointer segment
+func escapePointer(segment string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(segment)
+}
+
+func intersect(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+	var result []string
+	for _, s := range a {
+		if inB[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
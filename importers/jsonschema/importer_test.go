@@ -0,0 +1,446 @@
+package jsonschema
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// readDocuments loads every JSON document of a testdata case
+func readDocuments(t *testing.T, name string) map[string][]byte {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", name, "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no documents for case %s: %v", name, err)
+	}
+	documents := make(map[string][]byte)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		documents[filepath.Base(path)] = data
+	}
+	return documents
+}
+
+// render concatenates the imported files and issues in a stable form
+func render(result *Result) string {
+	filenames := make([]string, 0, len(result.Files))
+	for filename := range result.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var b strings.Builder
+	for _, filename := range filenames {
+		b.WriteString("-- " + filename + " --\n")
+		b.WriteString(importers.Format(result.Files[filename], nil))
+	}
+	b.WriteString("-- issues --\n")
+	for _, issue := range result.Issues {
+		b.WriteString(issue.String() + "\n")
+	}
+	return b.String()
+}
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, actual)
+	}
+}
+
+func TestImportGolden(t *testing.T) {
+	for _, name := range []string{"orders", "unsupported"} {
+		t.Run(name, func(t *testing.T) {
+			result, err := Import(readDocuments(t, name))
+			if err != nil {
+				t.Fatalf("Import error: %v", err)
+			}
+			assertGolden(t, name, render(result))
+		})
+	}
+}
+
+// parseImported formats the imported files, parses them back and validates the module
+func parseImported(t *testing.T, result *Result) *ast.Module {
+	t.Helper()
+
+	files := make(map[string]*ast.ProgramNode)
+	for filename, program := range result.Files {
+		source := importers.Format(program, nil)
+		parsed, err := parser.Parse(strings.NewReader(source), filename)
+		if err != nil {
+			t.Fatalf("imported %s does not parse: %v\n%s", filename, err, source)
+		}
+		files[filename] = parsed
+	}
+
+	module := ast.NewModule("imported", files)
+	if validation := validator.NewValidator().Validate(module); validation.HasErrors() {
+		t.Fatalf("imported module does not validate:\n%s", validation.String())
+	}
+	return module
+}
+
+func TestImportOutputValidates(t *testing.T) {
+	for _, name := range []string{"orders", "unsupported"} {
+		t.Run(name, func(t *testing.T) {
+			result, err := Import(readDocuments(t, name))
+			if err != nil {
+				t.Fatalf("Import error: %v", err)
+			}
+			parseImported(t, result)
+		})
+	}
+}
+
+// TestImportRoundTrip checks that every object, enum and union of the source
+// schema survives the import with the same fields, requiredness, value kinds
+// and variants, by comparing a shape computed from the JSON Schema with one
+// computed from the re-parsed TypeGen module
+func TestImportRoundTrip(t *testing.T) {
+	documents := readDocuments(t, "orders")
+	result, err := Import(documents)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	module := parseImported(t, result)
+
+	source := newSchemaShapes(t, documents)
+	source.collect("api.json", "Order", source.docs["api.json"])
+	for filename, doc := range source.docs {
+		source.collectDefinitions(filename, doc)
+	}
+
+	imported := make(map[string]string)
+	for _, program := range module.Files {
+		for _, decl := range program.Declarations {
+			if shape := typegenShape(module, decl); shape != "" {
+				imported[declName(decl)] = shape
+			}
+		}
+	}
+
+	for name, expected := range source.shapes {
+		if actual, ok := imported[name]; !ok {
+			t.Errorf("%s is missing from the import", name)
+		} else if actual != expected {
+			t.Errorf("%s changed shape:\n  schema:  %s\n  typegen: %s", name, expected, actual)
+		}
+	}
+	if len(imported) != len(source.shapes) {
+		t.Errorf("expected %d declarations with a shape, got %d: %v", len(source.shapes), len(imported), imported)
+	}
+}
+
+// schemaShapes computes shapes of named schemas straight from JSON Schema documents
+type schemaShapes struct {
+	docs   map[string]*node
+	shapes map[string]string
+}
+
+func newSchemaShapes(t *testing.T, documents map[string][]byte) *schemaShapes {
+	s := &schemaShapes{docs: make(map[string]*node), shapes: make(map[string]string)}
+	for filename, data := range documents {
+		doc, err := decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.docs[filename] = doc
+	}
+	return s
+}
+
+func (s *schemaShapes) collectDefinitions(doc string, n *node) {
+	defs := n.get("$defs")
+	for _, name := range defs.objectKeys() {
+		def := defs.get(name)
+		if isGroup(def) {
+			s.collectDefinitions(doc, def)
+			continue
+		}
+		s.collect(doc, naming.PascalCase(name), def)
+	}
+}
+
+// collect records the shape of a named schema and of the objects nested in its properties
+func (s *schemaShapes) collect(doc, name string, n *node) {
+	switch {
+	case n.has("properties"):
+		var fields []string
+		props := n.get("properties")
+		required := make(map[string]bool)
+		for _, key := range n.get("required").strings() {
+			required[key] = true
+		}
+		for _, key := range props.objectKeys() {
+			prop := props.get(key)
+			kind, nullable := s.kind(doc, prop)
+			marker := "!"
+			if !required[key] || nullable {
+				marker = "?"
+			}
+			fields = append(fields, naming.SnakeCase(key)+":"+kind+marker)
+			if prop.has("properties") || prop.has("enum") {
+				s.collect(doc, name+naming.PascalCase(key), prop)
+			}
+		}
+		s.shapes[name] = "struct{" + strings.Join(fields, ",") + "}"
+	case n.has("enum"):
+		var values []string
+		for _, value := range n.get("enum").strings() {
+			values = append(values, naming.SnakeCase(value))
+		}
+		s.shapes[name] = "enum{" + strings.Join(values, ",") + "}"
+	case n.has("oneOf"):
+		var variants []string
+		for _, branch := range n.get("oneOf").items {
+			variants = append(variants, discriminatorValue(branch, "type"))
+		}
+		s.shapes[name] = "union{" + strings.Join(variants, ",") + "}"
+	}
+}
+
+// kind describes the values a schema accepts, following $refs
+func (s *schemaShapes) kind(doc string, n *node) (string, bool) {
+	if ref, ok := n.get("$ref").str(); ok {
+		target, fragment, _ := strings.Cut(ref, "#")
+		if target != "" {
+			doc = target
+		}
+		resolved := s.docs[doc]
+		for _, segment := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+			resolved = resolved.get(segment)
+		}
+		return s.kind(doc, resolved)
+	}
+
+	types := n.get("type").strings()
+	nullable := len(types) == 2 && types[1] == "null"
+	typ := ""
+	if len(types) > 0 {
+		typ = types[0]
+	}
+
+	switch {
+	case n.has("enum"):
+		return "enum", nullable
+	case n.has("oneOf"):
+		return "union", nullable
+	case n.has("properties"):
+		return "object", nullable
+	case typ == "object" && n.has("additionalProperties"):
+		value, _ := s.kind(doc, n.get("additionalProperties"))
+		return "map(" + value + ")", nullable
+	case typ == "array":
+		element, _ := s.kind(doc, n.get("items"))
+		return "array(" + element + ")", nullable
+	case typ == "string":
+		if format, ok := n.get("format").str(); ok {
+			return strings.ReplaceAll(format, "-", ""), nullable
+		}
+		return "string", nullable
+	case typ == "integer", typ == "number", typ == "boolean":
+		return typ, nullable
+	default:
+		return "json", nullable
+	}
+}
+
+// typegenShape computes the shape of a declaration in the same vocabulary as schemaShapes
+func typegenShape(module *ast.Module, decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		var fields []string
+		for _, field := range d.Fields {
+			marker := "!"
+			if field.Optional {
+				marker = "?"
+			}
+			fields = append(fields, field.Name+":"+typegenKind(module, field.Type)+marker)
+		}
+		return "struct{" + strings.Join(fields, ",") + "}"
+	case *ast.EnumNode:
+		var variants []string
+		union := false
+		for _, variant := range d.Variants {
+			variants = append(variants, variant.Name)
+			union = union || variant.Payload != nil
+		}
+		if union {
+			return "union{" + strings.Join(variants, ",") + "}"
+		}
+		return "enum{" + strings.Join(variants, ",") + "}"
+	default:
+		return ""
+	}
+}
+
+func typegenKind(module *ast.Module, t ast.Type) string {
+	switch typ := t.(type) {
+	case *ast.PrimitiveType:
+		switch {
+		case strings.HasPrefix(typ.Name, "int"), strings.HasPrefix(typ.Name, "nat"):
+			return "integer"
+		case strings.HasPrefix(typ.Name, "float"):
+			return "number"
+		case typ.Name == "bool":
+			return "boolean"
+		default:
+			return typ.Name
+		}
+	case *ast.ArrayType:
+		return "array(" + typegenKind(module, typ.ElementType) + ")"
+	case *ast.MapType:
+		return "map(" + typegenKind(module, typ.ValueType) + ")"
+	case *ast.NamedType:
+		decl, _, _ := module.FindDeclaration(typ.Name)
+		switch d := decl.(type) {
+		case *ast.StructNode:
+			return "object"
+		case *ast.EnumNode:
+			if strings.HasPrefix(typegenShape(module, d), "union") {
+				return "union"
+			}
+			return "enum"
+		case *ast.TypeAliasNode:
+			return typegenKind(module, d.Type)
+		}
+	}
+	return "unknown"
+}
+
+func declName(decl ast.Declaration) string {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		return d.Name
+	case *ast.EnumNode:
+		return d.Name
+	case *ast.TypeAliasNode:
+		return d.Name
+	case *ast.ConstantNode:
+		return d.Name
+	}
+	return ""
+}
+
+func TestImportIntegerBounds(t *testing.T) {
+	tests := []struct {
+		schema   string
+		expected string
+	}{
+		{`{"type": "integer"}`, "int64"},
+		{`{"type": "integer", "minimum": 0}`, "nat64"},
+		{`{"type": "integer", "minimum": 0, "maximum": 255}`, "nat8"},
+		{`{"type": "integer", "minimum": 0, "maximum": 256}`, "nat16"},
+		{`{"type": "integer", "minimum": 0, "exclusiveMaximum": 65536}`, "nat16"},
+		{`{"type": "integer", "minimum": 0, "maximum": 4294967295}`, "nat32"},
+		{`{"type": "integer", "minimum": -128, "maximum": 127}`, "int8"},
+		{`{"type": "integer", "exclusiveMinimum": -129, "maximum": 127}`, "int8"},
+		{`{"type": "integer", "minimum": -129, "maximum": 127}`, "int16"},
+		{`{"type": "integer", "minimum": -1, "maximum": 3000000000}`, "int64"},
+		{`{"type": "integer", "maximum": 10}`, "int64"},
+	}
+
+	for _, tt := range tests {
+		result, err := Import(map[string][]byte{"value.json": []byte(tt.schema)})
+		if err != nil {
+			t.Fatalf("Import error for %s: %v", tt.schema, err)
+		}
+		alias := result.Files["value.tg"].Declarations[0].(*ast.TypeAliasNode)
+		if alias.Type.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.schema, tt.expected, alias.Type.String())
+		}
+	}
+}
+
+func TestImportKeywordAndCollisionNames(t *testing.T) {
+	schema := `{
+		"title": "item",
+		"type": "object",
+		"properties": {
+			"type": {"type": "string"},
+			"userId": {"type": "string"},
+			"user_id": {"type": "string"},
+			"say \"hi\"": {"type": "string"}
+		},
+		"$defs": {"Item": {"type": "string"}}
+	}`
+
+	result, err := Import(map[string][]byte{"item.json": []byte(schema)})
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	output := importers.Format(result.Files["item.tg"], nil)
+	for _, expected := range []string{"struct Item {", `type_: ?string = "type"`, `user_id: ?string = "userId"`, `user_id_2: ?string = "user_id"`, "type Item2 = string"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	// Renamed properties keep their JSON names, unless it has quotes
+	issues := render(result)
+	for _, expected := range []string{
+		`item.json#/properties/say "hi": property "say \"hi\"" can't be a wire name and is renamed to "say_hi"; the JSON name changes on the wire`,
+		`item.json#/$defs/Item: name Item is already taken; using Item2`,
+	} {
+		if !strings.Contains(issues, expected) {
+			t.Errorf("Expected issue %q, got:\n%s", expected, issues)
+		}
+	}
+	if strings.Count(issues, "renamed") != 1 {
+		t.Errorf("Expected one renamed property, got:\n%s", issues)
+	}
+}
+
+func TestImportInvalidDocument(t *testing.T) {
+	if _, err := Import(map[string][]byte{"broken.json": []byte(`{"type": `)}); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("Expected a parse error naming the document, got: %v", err)
+	}
+	if _, err := Import(map[string][]byte{"list.json": []byte(`[]`)}); err == nil || !strings.Contains(err.Error(), "must be a JSON object") {
+		t.Errorf("Expected an error for a non-object document, got: %v", err)
+	}
+}
+
+func TestImportDeterministic(t *testing.T) {
+	documents := readDocuments(t, "orders")
+	first, err := Import(documents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Import(documents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(render(first), render(second)) {
+		t.Error("Expected identical output across imports")
+	}
+}
@@ -0,0 +1,446 @@
+// Package proto converts Protocol Buffers schemas (.proto files) into TypeGen declarations.
+package proto
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Result holds the converted files and everything that could not be converted faithfully
+type Result struct {
+	// Files maps .tg filenames, one per .proto file, to their declarations
+	Files map[string]*ast.ProgramNode
+	// Comments carries proto comments, field numbers and reserved ranges
+	Comments *importers.Comments
+	Issues   []importers.Issue
+}
+
+// validTypeName matches the PascalCase names the validator accepts
+var validTypeName = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+
+// scalarTypes maps proto scalar types to TypeGen primitives
+var scalarTypes = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint32":   "nat32",
+	"fixed32":  "nat32",
+	"uint64":   "nat64",
+	"fixed64":  "nat64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "string",
+}
+
+// symbol is a message or enum declared in one of the imported files
+type symbol struct {
+	name      string // TypeGen name
+	isMessage bool
+}
+
+// importer carries the state of one Import call
+type importer struct {
+	symbols  map[string]*symbol // by fully qualified proto name, without the leading dot
+	taken    map[string]bool
+	files    map[string]*ast.ProgramNode
+	comments *importers.Comments
+	issues   []importers.Issue
+
+	// State of the file being converted
+	filename string
+	syntax   string
+	program  *ast.ProgramNode
+}
+
+// Import converts .proto files, keyed by file name. Types resolve across all
+// files by their package-qualified names, so the files' own imports don't
+// need to be provided unless their types are referenced.
+func Import(files map[string][]byte) (*Result, error) {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	parsed := make(map[string]*protoFile)
+	for _, filename := range filenames {
+		file, err := parseFile(string(files[filename]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+		parsed[filename] = file
+	}
+
+	im := &importer{
+		symbols:  make(map[string]*symbol),
+		taken:    make(map[string]bool),
+		files:    make(map[string]*ast.ProgramNode),
+		comments: importers.NewComments(),
+	}
+
+	// Name every message and enum first, so references resolve in any order
+	for _, filename := range filenames {
+		im.filename = filename
+		file := parsed[filename]
+		for _, msg := range file.messages {
+			im.collectMessage(msg, file.pkg, "")
+		}
+		for _, e := range file.enums {
+			im.collectEnum(e, file.pkg, "")
+		}
+	}
+
+	for _, filename := range filenames {
+		file := parsed[filename]
+		im.filename = filename
+		im.syntax = file.syntax
+		im.program = &ast.ProgramNode{}
+
+		for _, msg := range file.messages {
+			im.declareMessage(msg, file.pkg)
+		}
+		for _, e := range file.enums {
+			im.declareEnum(e, qualify(file.pkg, e.name))
+		}
+		for _, skip := range file.skipped {
+			im.report(skip.pos, fmt.Sprintf("%s has no TypeGen equivalent and was skipped", skip.what))
+		}
+
+		if len(im.program.Declarations) > 0 {
+			base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+			im.files[naming.SnakeCase(base)+".tg"] = im.program
+		}
+	}
+
+	return &Result{Files: im.files, Comments: im.comments, Issues: im.issues}, nil
+}
+
+// collectMessage names a message and everything nested in it. Nested types
+// are flattened into their parent's name: Order.Item becomes OrderItem.
+func (im *importer) collectMessage(msg *message, scope, parentName string) {
+	full := qualify(scope, msg.name)
+	im.symbols[full] = &symbol{name: im.typeName(parentName, msg.name, msg.pos), isMessage: true}
+
+	for _, nested := range msg.messages {
+		im.collectMessage(nested, full, im.symbols[full].name)
+	}
+	for _, e := range msg.enums {
+		im.collectEnum(e, full, im.symbols[full].name)
+	}
+}
+
+func (im *importer) collectEnum(e *enum, scope, parentName string) {
+	im.symbols[qualify(scope, e.name)] = &symbol{name: im.typeName(parentName, e.name, e.pos)}
+}
+
+// typeName reserves the TypeGen name of a proto type
+func (im *importer) typeName(parentName, protoName string, pos position) string {
+	name := protoName
+	if !validTypeName.MatchString(name) {
+		name = naming.PascalCase(protoName)
+		im.report(pos, fmt.Sprintf("type %s renamed to %s", protoName, name))
+	}
+	name = parentName + name
+
+	unique := name
+	for i := 2; im.taken[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	if unique != name {
+		im.report(pos, fmt.Sprintf("name %s is already taken; using %s", name, unique))
+	}
+	im.taken[unique] = true
+	return unique
+}
+
+// declareMessage adds a struct for a message, followed by its oneofs and nested types
+func (im *importer) declareMessage(msg *message, scope string) {
+	full := qualify(scope, msg.name)
+	name := im.symbols[full].name
+
+	decl := &ast.StructNode{Name: name}
+	im.program.Declarations = append(im.program.Declarations, decl)
+	im.addDoc(decl, msg.doc)
+
+	fieldNames := make(map[string]bool)
+	fieldName := func(protoName string, pos position) string {
+		n := naming.SnakeCase(protoName)
+		if n == "" || n[0] < 'a' || n[0] > 'z' {
+			n = "f_" + n
+		}
+		if importers.IsKeyword(n) {
+			n += "_"
+		}
+		base := n
+		for i := 2; fieldNames[n]; i++ {
+			n = fmt.Sprintf("%s_%d", base, i)
+		}
+		fieldNames[n] = true
+		if n != protoName {
+			im.report(pos, fmt.Sprintf("field %s.%s renamed to %s", msg.name, protoName, n))
+		}
+		return n
+	}
+
+	var oneofs []*oneof
+	oneofNames := make(map[*oneof]string)
+	for _, f := range msg.fields {
+		// A oneof becomes one optional field of a tagged enum, where its first member was
+		if f.oneof != nil {
+			if _, declared := oneofNames[f.oneof]; declared {
+				continue
+			}
+			oneofs = append(oneofs, f.oneof)
+			oneofNames[f.oneof] = im.typeName(name, naming.PascalCase(f.oneof.name), f.oneof.pos)
+			node := &ast.FieldNode{Optional: true}
+			node.Name = fieldName(f.oneof.name, f.oneof.pos)
+			node.Type = &ast.NamedType{Name: oneofNames[f.oneof]}
+			decl.Fields = append(decl.Fields, node)
+			im.addDoc(node, f.oneof.doc)
+			im.comments.Add(node, "oneof "+f.oneof.name)
+			continue
+		}
+
+		node := &ast.FieldNode{}
+		node.Name = fieldName(f.name, f.pos)
+		var optional bool
+		node.Type, optional = im.fieldType(f, full)
+		node.Optional = optional || im.hasPresence(f, full)
+		decl.Fields = append(decl.Fields, node)
+
+		im.addDoc(node, f.doc)
+		im.comments.Add(node, fmt.Sprintf("field %d", f.number))
+	}
+
+	for _, reserved := range msg.reserved {
+		im.comments.AddTrailing(decl, fmt.Sprintf("reserved %s;", reserved))
+	}
+	for _, skip := range msg.skipped {
+		im.comments.AddTrailing(decl, skip.what+" was skipped")
+		im.report(skip.pos, fmt.Sprintf("%s has no TypeGen equivalent and was skipped", skip.what))
+	}
+
+	for _, group := range oneofs {
+		im.declareOneof(msg, group, oneofNames[group], full)
+	}
+	for _, e := range msg.enums {
+		im.declareEnum(e, qualify(full, e.name))
+	}
+	for _, nested := range msg.messages {
+		im.declareMessage(nested, full)
+	}
+}
+
+// declareOneof adds a tagged enum with a variant per member of a oneof
+func (im *importer) declareOneof(msg *message, group *oneof, name, scope string) {
+	decl := &ast.EnumNode{Name: name}
+	im.program.Declarations = append(im.program.Declarations, decl)
+
+	for _, f := range msg.fields {
+		if f.oneof != group {
+			continue
+		}
+		variantName := naming.SnakeCase(f.name)
+		if importers.IsKeyword(variantName) {
+			variantName += "_"
+		}
+		payload, _ := im.fieldType(f, scope)
+		variant := &ast.EnumVariantNode{Name: variantName, Payload: payload}
+		decl.Variants = append(decl.Variants, variant)
+
+		im.addDoc(variant, f.doc)
+		im.comments.Add(variant, fmt.Sprintf("field %d", f.number))
+	}
+}
+
+// declareEnum adds a simple enum, keeping the proto numbers in comments
+func (im *importer) declareEnum(e *enum, full string) {
+	decl := &ast.EnumNode{Name: im.symbols[full].name}
+	im.program.Declarations = append(im.program.Declarations, decl)
+	im.addDoc(decl, e.doc)
+
+	// Values are conventionally prefixed with the enum name: Status.STATUS_ACTIVE -> active
+	prefix := naming.ScreamingSnakeCase(e.name) + "_"
+	for _, value := range e.values {
+		if !strings.HasPrefix(value.name, prefix) {
+			prefix = ""
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, value := range e.values {
+		variantName := naming.SnakeCase(strings.TrimPrefix(value.name, prefix))
+		if variantName == "" || variantName[0] < 'a' || variantName[0] > 'z' {
+			variantName = "v_" + variantName
+		}
+		if importers.IsKeyword(variantName) {
+			variantName += "_"
+		}
+		if seen[variantName] {
+			im.report(value.pos, fmt.Sprintf("enum value %s duplicates variant %s and was skipped", value.name, variantName))
+			continue
+		}
+		seen[variantName] = true
+
+		variant := &ast.EnumVariantNode{Name: variantName}
+		decl.Variants = append(decl.Variants, variant)
+		im.addDoc(variant, value.doc)
+		im.comments.Add(variant, fmt.Sprintf("%s = %d", value.name, value.number))
+	}
+
+	for _, reserved := range e.reserved {
+		im.comments.AddTrailing(decl, fmt.Sprintf("reserved %s;", reserved))
+	}
+}
+
+// fieldType converts the type of a field, including its repeated or map
+// wrapping. The second result reports whether the type itself is nullable.
+func (im *importer) fieldType(f *field, scope string) (ast.Type, bool) {
+	elem, optional := im.convertType(f.typ, scope, f.pos)
+
+	if f.keyType != "" {
+		key, ok := scalarTypes[f.keyType]
+		if !ok || f.keyType == "bytes" || f.keyType == "double" || f.keyType == "float" {
+			im.report(f.pos, fmt.Sprintf("map key type %s is not supported; using string", f.keyType))
+			key = "string"
+		} else if f.keyType == "bool" {
+			im.report(f.pos, "bool map keys are not supported by TypeGen; using string")
+			key = "string"
+		}
+		return &ast.MapType{KeyType: primitive(key), ValueType: elem}, false
+	}
+	if f.label == "repeated" {
+		return &ast.ArrayType{ElementType: elem}, false
+	}
+	return elem, optional
+}
+
+// convertType converts a scalar, well-known or declared type by name
+func (im *importer) convertType(name, scope string, pos position) (ast.Type, bool) {
+	if scalar, ok := scalarTypes[name]; ok {
+		return primitive(scalar), false
+	}
+
+	if known, optional, ok := wellKnown(name); ok {
+		switch strings.TrimPrefix(name, ".") {
+		case "google.protobuf.Duration":
+			im.report(pos, "google.protobuf.Duration is encoded as a string like \"1.5s\" in protobuf JSON; using int64")
+		case "google.protobuf.Any":
+			im.report(pos, "google.protobuf.Any has no TypeGen equivalent; using json")
+		}
+		return known, optional
+	}
+
+	if sym := im.resolve(name, scope); sym != nil {
+		return &ast.NamedType{Name: sym.name}, false
+	}
+
+	im.report(pos, fmt.Sprintf("unresolved type %s; using json", name))
+	return primitive("json"), false
+}
+
+// resolve finds a referenced type like protoc does, searching from the
+// innermost scope outwards. A leading dot makes the name fully qualified.
+func (im *importer) resolve(name, scope string) *symbol {
+	if strings.HasPrefix(name, ".") {
+		return im.symbols[name[1:]]
+	}
+	parts := strings.Split(scope, ".")
+	for i := len(parts); i >= 0; i-- {
+		if sym, ok := im.symbols[qualify(strings.Join(parts[:i], "."), name)]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// hasPresence reports whether a singular field may be absent: proto3 fields
+// only with the optional label or a message type, editions fields by default
+func (im *importer) hasPresence(f *field, scope string) bool {
+	switch {
+	case f.label == "optional":
+		return true
+	case f.label != "" || f.keyType != "":
+		return false
+	case im.syntax == "editions":
+		return true
+	}
+	if _, scalar := scalarTypes[f.typ]; scalar {
+		return false
+	}
+	if _, _, ok := wellKnown(f.typ); ok {
+		return true
+	}
+	sym := im.resolve(f.typ, scope)
+	return sym == nil || sym.isMessage
+}
+
+// wellKnown maps google.protobuf types to TypeGen types. Wrappers exist to
+// make scalars nullable, so the second result reports them as optional.
+func wellKnown(name string) (ast.Type, bool, bool) {
+	switch strings.TrimPrefix(name, ".") {
+	case "google.protobuf.Timestamp":
+		return primitive("datetime"), false, true
+	case "google.protobuf.Duration":
+		return primitive("int64"), false, true
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.Any", "google.protobuf.Empty":
+		return primitive("json"), false, true
+	case "google.protobuf.ListValue":
+		return &ast.ArrayType{ElementType: primitive("json")}, false, true
+	case "google.protobuf.FieldMask":
+		return primitive("string"), false, true
+	case "google.protobuf.DoubleValue":
+		return primitive("float64"), true, true
+	case "google.protobuf.FloatValue":
+		return primitive("float32"), true, true
+	case "google.protobuf.Int64Value":
+		return primitive("int64"), true, true
+	case "google.protobuf.UInt64Value":
+		return primitive("nat64"), true, true
+	case "google.protobuf.Int32Value":
+		return primitive("int32"), true, true
+	case "google.protobuf.UInt32Value":
+		return primitive("nat32"), true, true
+	case "google.protobuf.BoolValue":
+		return primitive("bool"), true, true
+	case "google.protobuf.StringValue", "google.protobuf.BytesValue":
+		return primitive("string"), true, true
+	}
+	return nil, false, false
+}
+
+func (im *importer) addDoc(node ast.Node, doc []string) {
+	for _, line := range doc {
+		im.comments.Add(node, line)
+	}
+}
+
+func (im *importer) report(pos position, message string) {
+	path := fmt.Sprintf("%s:%d:%d", im.filename, pos.line, pos.column)
+	im.issues = append(im.issues, importers.Issue{Path: path, Message: message})
+}
+
+// qualify joins a scope and a name into a fully qualified proto name
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func primitive(name string) ast.Type {
+	return &ast.PrimitiveType{Name: name}
+}
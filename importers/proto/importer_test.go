@@ -0,0 +1,170 @@
+package proto
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/importers"
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/validator"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// readProtos loads every .proto file of a testdata case
+func readProtos(t *testing.T, name string) map[string][]byte {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", name, "*.proto"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no .proto files for case %s: %v", name, err)
+	}
+	files := make(map[string][]byte)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[filepath.Base(path)] = data
+	}
+	return files
+}
+
+// render concatenates the imported files and issues in a stable form
+func render(result *Result) string {
+	filenames := make([]string, 0, len(result.Files))
+	for filename := range result.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var b strings.Builder
+	for _, filename := range filenames {
+		b.WriteString("-- " + filename + " --\n")
+		b.WriteString(importers.Format(result.Files[filename], result.Comments))
+	}
+	b.WriteString("-- issues --\n")
+	for _, issue := range result.Issues {
+		b.WriteString(issue.String() + "\n")
+	}
+	return b.String()
+}
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != actual {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, actual)
+	}
+}
+
+func TestImportGolden(t *testing.T) {
+	result, err := Import(readProtos(t, "shop"))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	assertGolden(t, "shop", render(result))
+}
+
+func TestImportOutputValidates(t *testing.T) {
+	result, err := Import(readProtos(t, "shop"))
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	files := make(map[string]*ast.ProgramNode)
+	for filename, program := range result.Files {
+		source := importers.Format(program, result.Comments)
+		parsed, err := parser.Parse(strings.NewReader(source), filename)
+		if err != nil {
+			t.Fatalf("imported %s does not parse: %v\n%s", filename, err, source)
+		}
+		files[filename] = parsed
+	}
+
+	module := ast.NewModule("shop", files)
+	if validation := validator.NewValidator().Validate(module); validation.HasErrors() {
+		t.Fatalf("imported module does not validate:\n%s", validation.String())
+	}
+}
+
+func TestImportPresence(t *testing.T) {
+	files := map[string][]byte{
+		"presence.proto": []byte(`
+syntax = "proto3";
+message Inner {}
+enum Kind { KIND_A = 0; }
+message Outer {
+  string name = 1;
+  optional string nickname = 2;
+  Inner inner = 3;
+  Kind kind = 4;
+  repeated Inner inners = 5;
+}
+`),
+	}
+	result, err := Import(files)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	source := importers.Format(result.Files["presence.tg"], nil)
+	for _, expected := range []string{"name: string", "nickname: ?string", "inner: ?Inner", "kind: Kind", "inners: []Inner"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestImportResolvesNestedScopes(t *testing.T) {
+	files := map[string][]byte{
+		"scopes.proto": []byte(`
+syntax = "proto3";
+package a.b;
+message Item {}
+message Outer {
+  message Item {}
+  Item nested = 1;
+  .a.b.Item top = 2;
+  b.Item relative = 3;
+}
+`),
+	}
+	result, err := Import(files)
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+
+	source := importers.Format(result.Files["scopes.tg"], nil)
+	for _, expected := range []string{"nested: ?OuterItem", "top: ?Item", "relative: ?Item"} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+	if len(result.Issues) != 0 {
+		t.Errorf("expected no issues, got %v", result.Issues)
+	}
+}
+
+func TestImportSyntaxError(t *testing.T) {
+	_, err := Import(map[string][]byte{"bad.proto": []byte("message Broken {\n  string = 1;\n}\n")})
+	if err == nil || !strings.Contains(err.Error(), "bad.proto") || !strings.Contains(err.Error(), "2:") {
+		t.Errorf("expected a positioned error naming the file, got %v", err)
+	}
+}
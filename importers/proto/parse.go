@@ -0,0 +1,650 @@
+package proto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The parser covers the parts of the proto2, proto3 and editions grammars
+// that describe data: messages, enums, fields, oneofs and maps. Options are
+// skipped, and services and extensions are only recorded so they can be reported.
+
+// position locates a token in a .proto file
+type position struct {
+	line, column int
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string // identifier, number or symbol text; the unquoted value of strings
+	pos  position
+	// doc holds the comment lines directly above the token, without the comment markers
+	doc []string
+}
+
+// protoFile is a parsed .proto file
+type protoFile struct {
+	syntax   string // "proto2", "proto3" or "editions"
+	pkg      string
+	imports  []string
+	messages []*message
+	enums    []*enum
+	// skipped lists services and extensions, which have no TypeGen equivalent
+	skipped []skipped
+}
+
+type skipped struct {
+	what string // e.g. "service Orders"
+	pos  position
+}
+
+type message struct {
+	name     string
+	pos      position
+	doc      []string
+	fields   []*field
+	messages []*message
+	enums    []*enum
+	reserved []string
+	skipped  []skipped
+}
+
+type field struct {
+	name    string
+	label   string // "optional", "required", "repeated" or ""
+	typ     string // scalar or message/enum type name, as written
+	keyType string // map key type, for map fields
+	number  int64
+	pos     position
+	doc     []string
+	oneof   *oneof
+}
+
+type oneof struct {
+	name string
+	pos  position
+	doc  []string
+}
+
+type enum struct {
+	name     string
+	pos      position
+	doc      []string
+	values   []*enumValue
+	reserved []string
+}
+
+type enumValue struct {
+	name   string
+	number int64
+	pos    position
+	doc    []string
+}
+
+// protoParser is a recursive descent parser over the tokens of one file
+type protoParser struct {
+	tokens []token
+	next   int
+}
+
+// parseFile parses the source of a .proto file
+func parseFile(source string) (*protoFile, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &protoParser{tokens: tokens}
+	return p.file()
+}
+
+func (p *protoParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *protoParser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+func (p *protoParser) errorf(tok token, format string, args ...any) error {
+	return fmt.Errorf("%d:%d: %s", tok.pos.line, tok.pos.column, fmt.Sprintf(format, args...))
+}
+
+// accept consumes the next token if it is the given symbol or keyword
+func (p *protoParser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokenSymbol || tok.kind == tokenIdent) && tok.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *protoParser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *protoParser) ident() (token, error) {
+	tok := p.advance()
+	if tok.kind != tokenIdent {
+		return tok, p.errorf(tok, "expected an identifier, found %q", tok.text)
+	}
+	return tok, nil
+}
+
+func (p *protoParser) integer() (int64, error) {
+	tok := p.advance()
+	negative := false
+	if tok.kind == tokenSymbol && tok.text == "-" {
+		negative = true
+		tok = p.advance()
+	}
+	if tok.kind != tokenNumber {
+		return 0, p.errorf(tok, "expected a number, found %q", tok.text)
+	}
+	n, err := strconv.ParseInt(tok.text, 0, 64)
+	if err != nil {
+		return 0, p.errorf(tok, "invalid number %q", tok.text)
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
+
+func (p *protoParser) file() (*protoFile, error) {
+	file := &protoFile{syntax: "proto2"}
+
+	for p.peek().kind != tokenEOF {
+		tok := p.peek()
+		switch {
+		case p.accept(";"):
+		case p.accept("syntax"), p.accept("edition"):
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value := p.advance()
+			if value.kind != tokenString {
+				return nil, p.errorf(value, "expected a string, found %q", value.text)
+			}
+			file.syntax = value.text
+			if tok.text == "edition" {
+				file.syntax = "editions"
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("package"):
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			file.pkg = name.text
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("import"):
+			if !p.accept("public") {
+				p.accept("weak")
+			}
+			path := p.advance()
+			if path.kind != tokenString {
+				return nil, p.errorf(path, "expected an import path, found %q", path.text)
+			}
+			file.imports = append(file.imports, path.text)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("option"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.accept("message"):
+			msg, err := p.message(tok)
+			if err != nil {
+				return nil, err
+			}
+			file.messages = append(file.messages, msg)
+		case p.accept("enum"):
+			e, err := p.enum(tok)
+			if err != nil {
+				return nil, err
+			}
+			file.enums = append(file.enums, e)
+		case p.accept("service"), p.accept("extend"):
+			skip, err := p.skipBlock(tok)
+			if err != nil {
+				return nil, err
+			}
+			file.skipped = append(file.skipped, skip)
+		default:
+			return nil, p.errorf(tok, "unexpected %q", tok.text)
+		}
+	}
+	return file, nil
+}
+
+func (p *protoParser) message(start token) (*message, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	msg := &message{name: name.text, pos: start.pos, doc: start.doc}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.accept("}") {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, p.errorf(tok, "unterminated message %s", msg.name)
+		case p.accept(";"):
+		case p.accept("message"):
+			nested, err := p.message(tok)
+			if err != nil {
+				return nil, err
+			}
+			msg.messages = append(msg.messages, nested)
+		case p.accept("enum"):
+			e, err := p.enum(tok)
+			if err != nil {
+				return nil, err
+			}
+			msg.enums = append(msg.enums, e)
+		case p.accept("oneof"):
+			fields, err := p.oneof(tok)
+			if err != nil {
+				return nil, err
+			}
+			msg.fields = append(msg.fields, fields...)
+		case p.accept("reserved"):
+			reserved, err := p.reserved()
+			if err != nil {
+				return nil, err
+			}
+			msg.reserved = append(msg.reserved, reserved)
+		case p.accept("option"), p.accept("extensions"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.accept("extend"):
+			skip, err := p.skipBlock(tok)
+			if err != nil {
+				return nil, err
+			}
+			msg.skipped = append(msg.skipped, skip)
+		default:
+			f, group, err := p.field(tok)
+			if err != nil {
+				return nil, err
+			}
+			if group != nil {
+				msg.skipped = append(msg.skipped, *group)
+			} else {
+				msg.fields = append(msg.fields, f)
+			}
+		}
+	}
+	return msg, nil
+}
+
+// field parses a normal or map field. Proto2 groups are skipped and returned
+// as the second result instead.
+func (p *protoParser) field(start token) (*field, *skipped, error) {
+	f := &field{pos: start.pos, doc: start.doc}
+
+	if p.accept("optional") || p.accept("required") || p.accept("repeated") {
+		f.label = start.text
+	}
+
+	if p.accept("map") {
+		if err := p.expect("<"); err != nil {
+			return nil, nil, err
+		}
+		key, err := p.ident()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, nil, err
+		}
+		value, err := p.ident()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, nil, err
+		}
+		f.keyType, f.typ = key.text, value.text
+	} else {
+		typ, err := p.ident()
+		if err != nil {
+			return nil, nil, err
+		}
+		f.typ = typ.text
+		if typ.text == "group" {
+			group, err := p.skipBlock(typ)
+			return nil, &group, err
+		}
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, nil, err
+	}
+	f.name = name.text
+	if err := p.expect("="); err != nil {
+		return nil, nil, err
+	}
+	if f.number, err = p.integer(); err != nil {
+		return nil, nil, err
+	}
+	if err := p.skipOptions(); err != nil {
+		return nil, nil, err
+	}
+	return f, nil, p.expect(";")
+}
+
+// oneof parses a oneof block into fields that point back at it
+func (p *protoParser) oneof(start token) ([]*field, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	group := &oneof{name: name.text, pos: start.pos, doc: start.doc}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var fields []*field
+	for !p.accept("}") {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, p.errorf(tok, "unterminated oneof %s", group.name)
+		case p.accept(";"):
+		case p.accept("option"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			f, skip, err := p.field(tok)
+			if err != nil {
+				return nil, err
+			}
+			if skip == nil {
+				f.oneof = group
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields, nil
+}
+
+func (p *protoParser) enum(start token) (*enum, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	e := &enum{name: name.text, pos: start.pos, doc: start.doc}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.accept("}") {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokenEOF:
+			return nil, p.errorf(tok, "unterminated enum %s", e.name)
+		case p.accept(";"):
+		case p.accept("option"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.accept("reserved"):
+			reserved, err := p.reserved()
+			if err != nil {
+				return nil, err
+			}
+			e.reserved = append(e.reserved, reserved)
+		default:
+			valueName, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			number, err := p.integer()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.skipOptions(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			e.values = append(e.values, &enumValue{name: valueName.text, number: number, pos: tok.pos, doc: tok.doc})
+		}
+	}
+	return e, nil
+}
+
+// reserved renders a reserved statement as written, e.g. `2, 9 to 11, "foo"`
+func (p *protoParser) reserved() (string, error) {
+	var parts []string
+	for !p.accept(";") {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return "", p.errorf(tok, "unterminated reserved statement")
+		case tok.kind == tokenString:
+			parts = append(parts, strconv.Quote(tok.text))
+		case tok.text == ",":
+			parts[len(parts)-1] += ","
+		default:
+			parts = append(parts, tok.text)
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// skipOptions skips a bracketed list of field options
+func (p *protoParser) skipOptions() error {
+	if !p.accept("[") {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return p.errorf(tok, "unterminated field options")
+		case tok.kind == tokenSymbol && tok.text == "[":
+			depth++
+		case tok.kind == tokenSymbol && tok.text == "]":
+			depth--
+		}
+	}
+	return nil
+}
+
+// skipStatement skips to the end of a statement, including aggregate option values
+func (p *protoParser) skipStatement() error {
+	depth := 0
+	for {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return p.errorf(tok, "unterminated statement")
+		case tok.kind != tokenSymbol:
+		case tok.text == "{":
+			depth++
+		case tok.text == "}":
+			depth--
+		case tok.text == ";" && depth == 0:
+			return nil
+		}
+	}
+}
+
+// skipBlock skips a named block such as a service, recording what was skipped
+func (p *protoParser) skipBlock(start token) (skipped, error) {
+	var words []string
+	for !p.accept("{") {
+		tok := p.advance()
+		if tok.kind == tokenEOF {
+			return skipped{}, p.errorf(tok, "unterminated %s", start.text)
+		}
+		words = append(words, tok.text)
+	}
+	for depth := 1; depth > 0; {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return skipped{}, p.errorf(tok, "unterminated %s", start.text)
+		case tok.kind == tokenSymbol && tok.text == "{":
+			depth++
+		case tok.kind == tokenSymbol && tok.text == "}":
+			depth--
+		}
+	}
+	what := start.text
+	if len(words) > 0 {
+		what += " " + words[0]
+	}
+	return skipped{what: what, pos: start.pos}, nil
+}
+
+// tokenize splits a .proto source into tokens, attaching the comment lines
+// directly above each token as its doc
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	var doc []string
+	runes := []rune(source)
+	line, column := 1, 1
+	lastLine := 0 // line of the previous token, to drop trailing comments
+
+	advance := func(n int) {
+		for i := 0; i < n; i++ {
+			if runes[0] == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+			runes = runes[1:]
+		}
+	}
+
+	for len(runes) > 0 {
+		r := runes[0]
+		pos := position{line, column}
+
+		switch {
+		case unicode.IsSpace(r):
+			if r == '\n' && len(doc) > 0 && strings.HasPrefix(strings.TrimLeft(string(runes[1:]), " \t\r"), "\n") {
+				doc = nil // a blank line detaches a comment from what follows
+			}
+			advance(1)
+
+		case r == '/' && len(runes) > 1 && runes[1] == '/':
+			end := 0
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(string(runes[2:end]), "/"))
+			if line != lastLine {
+				doc = append(doc, text)
+			}
+			advance(end)
+
+		case r == '/' && len(runes) > 1 && runes[1] == '*':
+			rest := string(runes)
+			end := strings.Index(rest, "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%d:%d: unterminated comment", line, column)
+			}
+			if line != lastLine {
+				for _, l := range strings.Split(rest[2:end], "\n") {
+					if l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*")); l != "" {
+						doc = append(doc, l)
+					}
+				}
+			}
+			advance(len([]rune(rest[:end+2])))
+
+		case r == '"' || r == '\'':
+			end := 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				if end < len(runes) && runes[end] == '\n' {
+					break
+				}
+				end++
+			}
+			if end >= len(runes) || runes[end] != r {
+				return nil, fmt.Errorf("%d:%d: unterminated string", line, column)
+			}
+			raw := string(runes[1:end])
+			if r == '\'' {
+				raw = strings.ReplaceAll(raw, `"`, `\"`)
+			}
+			value, err := strconv.Unquote(`"` + raw + `"`)
+			if err != nil {
+				return nil, fmt.Errorf("%d:%d: invalid string: %v", line, column, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: pos, doc: doc})
+			doc, lastLine = nil, line
+			advance(end + 1)
+
+		case unicode.IsLetter(r) || r == '_' || r == '.':
+			end := 0
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[:end]), pos: pos, doc: doc})
+			doc, lastLine = nil, line
+			advance(end)
+
+		case unicode.IsDigit(r):
+			end := 0
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '.' ||
+				((runes[end] == '-' || runes[end] == '+') && (runes[end-1] == 'e' || runes[end-1] == 'E'))) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[:end]), pos: pos, doc: doc})
+			doc, lastLine = nil, line
+			advance(end)
+
+		default:
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: pos, doc: doc})
+			doc, lastLine = nil, line
+			advance(1)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of file", pos: position{line, column}}), nil
+}
@@ -0,0 +1,52 @@
+package diff
+
+import "testing"
+
+func TestUnifiedEqual(t *testing.T) {
+	if d := Unified("a", []byte("x\n"), "b", []byte("x\n")); d != nil {
+		t.Errorf("expected no diff, got:\n%s", d)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := "struct User {\n    id: int64\n  name:string\n}\n"
+	new := "struct User {\n  id: int64\n  name: string\n}\n"
+
+	expected := `--- user.tg.orig
++++ user.tg
+@@ -1,4 +1,4 @@
+ struct User {
+-    id: int64
+-  name:string
++  id: int64
++  name: string
+ }
+`
+	if d := string(Unified("user.tg.orig", []byte(old), "user.tg", []byte(new))); d != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", d, expected)
+	}
+}
+
+func TestUnifiedSeparateHunks(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	new := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nK\n"
+
+	expected := `--- old
++++ new
+@@ -1,4 +1,4 @@
+-a
++A
+ b
+ c
+ d
+@@ -8,4 +8,4 @@
+ h
+ i
+ j
+-k
++K
+`
+	if d := string(Unified("old", []byte(old), "new", []byte(new))); d != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", d, expected)
+	}
+}
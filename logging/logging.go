@@ -0,0 +1,144 @@
+// Package logging provides the leveled, human-readable logger shared by the
+// CLI, the Builder and generators. It is a thin layer over log/slog: records
+// are printed one per line as the message followed by any attributes as
+// key=value pairs, with no timestamp or level prefix, so the default output
+// reads like the plain progress messages it replaces.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LevelTrace is below slog.LevelDebug, for the most detailed output: config
+// dumps and cache hits and misses
+const LevelTrace = slog.LevelDebug - 4
+
+// Level maps the CLI verbosity flags to a level: quiet keeps errors only,
+// verbosity 0 keeps the usual summaries, 1 (-v) adds per-file lines and
+// timing and 2 (-vv) adds config dumps and cache information
+func Level(quiet bool, verbosity int) slog.Level {
+	switch {
+	case quiet:
+		return slog.LevelError
+	case verbosity >= 2:
+		return LevelTrace
+	case verbosity == 1:
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// New returns a logger printing records at or above level to w
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&handler{out: &output{w: w}, level: level})
+}
+
+// Default returns a logger printing the usual summaries to stderr
+func Default() *slog.Logger {
+	return New(os.Stderr, slog.LevelInfo)
+}
+
+// Discard returns a logger that prints nothing
+func Discard() *slog.Logger {
+	return New(io.Discard, slog.LevelError+1)
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, for generators and other
+// code that receives a context rather than a logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or Default if there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return Default()
+}
+
+// output serializes writes from a handler and the handlers derived from it
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// handler is a slog.Handler printing "message key=value ..." lines
+type handler struct {
+	out   *output
+	level slog.Level
+	attrs string // preformatted attributes from WithAttrs
+	group string // prefix from WithGroup, ending in "."
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	var b strings.Builder
+	b.WriteString(record.Message)
+	b.WriteString(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&b, h.group, attr)
+		return true
+	})
+	b.WriteString("\n")
+
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+	_, err := io.WriteString(h.out.w, b.String())
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, attr := range attrs {
+		appendAttr(&b, h.group, attr)
+	}
+	derived := *h
+	derived.attrs = b.String()
+	return &derived
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	derived := *h
+	derived.group = h.group + name + "."
+	return &derived
+}
+
+// appendAttr writes " key=value", flattening groups into dotted keys
+func appendAttr(b *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			appendAttr(b, prefix, member)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, attr.Key, value)
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		quiet     bool
+		verbosity int
+		expected  slog.Level
+	}{
+		{false, 0, slog.LevelInfo},
+		{false, 1, slog.LevelDebug},
+		{false, 2, LevelTrace},
+		{false, 3, LevelTrace},
+		{true, 2, slog.LevelError},
+	}
+	for _, tt := range tests {
+		if got := Level(tt.quiet, tt.verbosity); got != tt.expected {
+			t.Errorf("Level(%v, %d): expected %v, got %v", tt.quiet, tt.verbosity, tt.expected, got)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelDebug)
+
+	logger.Info("Build completed")
+	logger.Debug("parsed", "path", "schemas/user.tg", "declarations", 3)
+	logger.With("task", 1).WithGroup("config").Debug("task config", "module-name", "example.com/api", "note", "two words")
+	logger.Log(context.Background(), LevelTrace, "cache hit")
+
+	expected := "Build completed\n" +
+		"parsed path=schemas/user.tg declarations=3\n" +
+		"task config task=1 config.module-name=example.com/api config.note=\"two words\"\n"
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, slog.LevelInfo)
+
+	FromContext(WithLogger(context.Background(), logger)).Info("from context")
+	if out.String() != "from context\n" {
+		t.Errorf("expected the logger from the context, got %q", out.String())
+	}
+	if FromContext(context.Background()) == nil {
+		t.Error("expected a default logger")
+	}
+}
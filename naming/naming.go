@@ -0,0 +1,138 @@
+// Package naming converts names between case conventions: the snake_case,
+// PascalCase and CONSTANT_CASE of TypeGen and the conventions of the target
+// languages. Generators, importers and the validator share it, so a name is
+// split into words, and acronyms are handled, the same way everywhere.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words splits a name into words at underscores, dashes, spaces and other
+// characters that aren't letters or digits, and at case changes. An
+// acronym is one word, ending before the capital that starts the next word:
+// "user_id", "userID" and "UserId" are all "user" and "id" with any case,
+// and "HTTPServer" is "HTTP" and "Server".
+func Words(s string) []string {
+	var words []string
+	var current []rune
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := current[len(current)-1]
+			// Split at "aB", and before the last capital of an acronym in "HTTPServer"
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	return words
+}
+
+// DefaultInitialisms are the acronyms Go writes in uppercase, as listed by
+// the Go style guides
+var DefaultInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "URI",
+	"URL", "UTF8", "UUID", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// Caser converts names to PascalCase and camelCase. The zero value
+// capitalizes each word and lowercases the rest of it.
+type Caser struct {
+	// Initialisms are the words written in uppercase, matched in any case,
+	// so "user_id" becomes "UserID" when "ID" is one
+	Initialisms map[string]bool
+	// KeepCase keeps the case of the letters after the first of each word,
+	// so "HTTPServer" stays as it is instead of becoming "HttpServer"
+	KeepCase bool
+}
+
+// NewCaser returns a Caser writing the given initialisms in uppercase
+func NewCaser(initialisms ...string) *Caser {
+	c := &Caser{Initialisms: make(map[string]bool, len(initialisms))}
+	for _, initialism := range initialisms {
+		c.Initialisms[strings.ToUpper(initialism)] = true
+	}
+	return c
+}
+
+// PascalCase converts a name to PascalCase: "user_id" -> "UserId"
+func (c *Caser) PascalCase(s string) string {
+	var result strings.Builder
+	for _, word := range Words(s) {
+		result.WriteString(c.word(word))
+	}
+	return result.String()
+}
+
+// CamelCase converts a name to camelCase: "user_id" -> "userId". The first
+// word is all lowercase, initialism or not: "ID_token" -> "idToken".
+func (c *Caser) CamelCase(s string) string {
+	var result strings.Builder
+	for i, word := range Words(s) {
+		if i == 0 {
+			result.WriteString(strings.ToLower(word))
+		} else {
+			result.WriteString(c.word(word))
+		}
+	}
+	return result.String()
+}
+
+// word returns a word in uppercase if it's an initialism, or else capitalized
+func (c *Caser) word(word string) string {
+	if upper := strings.ToUpper(word); c.Initialisms[upper] {
+		return upper
+	}
+	if !c.KeepCase {
+		word = strings.ToLower(word)
+	}
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// PascalCase converts a name to PascalCase without initialisms:
+// "user_id" -> "UserId", "HTTPServer" -> "HttpServer"
+func PascalCase(s string) string {
+	return (&Caser{}).PascalCase(s)
+}
+
+// CamelCase converts a name to camelCase without initialisms:
+// "user_id" -> "userId"
+func CamelCase(s string) string {
+	return (&Caser{}).CamelCase(s)
+}
+
+// SnakeCase converts a name to snake_case: "UserID" -> "user_id"
+func SnakeCase(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "_"))
+}
+
+// ScreamingSnakeCase converts a name to SCREAMING_SNAKE_CASE, the
+// CONSTANT_CASE of TypeGen constants: "maxRetries" -> "MAX_RETRIES"
+func ScreamingSnakeCase(s string) string {
+	return strings.ToUpper(strings.Join(Words(s), "_"))
+}
+
+// KebabCase converts a name to kebab-case: "UserID" -> "user-id"
+func KebabCase(s string) string {
+	return strings.ToLower(strings.Join(Words(s), "-"))
+}
@@ -0,0 +1,102 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // words joined by spaces
+	}{
+		{"", ""},
+		{"user", "user"},
+		{"user_id", "user id"},
+		{"userID", "user ID"},
+		{"UserId", "User Id"},
+		{"HTTPServer", "HTTP Server"},
+		{"parseHTTPRequest", "parse HTTP Request"},
+		{"MAX_ITEMS", "MAX ITEMS"},
+		{"kebab-case name", "kebab case name"},
+		{"__leading__trailing__", "leading trailing"},
+		{"v2_api", "v2 api"},
+		{"v2Api", "v2 Api"},
+		{"Vector3D", "Vector3 D"},
+		{"utf8", "utf8"},
+		{"ID", "ID"},
+		{"a_b_c", "a b c"},
+		{"ÉtéCafé", "Été Café"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(Words(tt.input), " "); got != tt.expected {
+			t.Errorf("Words(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		input                                  string
+		pascal, camel, snake, screaming, kebab string
+	}{
+		{"", "", "", "", "", ""},
+		{"user", "User", "user", "user", "USER", "user"},
+		{"user_id", "UserId", "userId", "user_id", "USER_ID", "user-id"},
+		{"userID", "UserId", "userId", "user_id", "USER_ID", "user-id"},
+		{"UserProfile", "UserProfile", "userProfile", "user_profile", "USER_PROFILE", "user-profile"},
+		{"HTTPServer", "HttpServer", "httpServer", "http_server", "HTTP_SERVER", "http-server"},
+		{"MAX_ITEMS", "MaxItems", "maxItems", "max_items", "MAX_ITEMS", "max-items"},
+		{"maxRetries", "MaxRetries", "maxRetries", "max_retries", "MAX_RETRIES", "max-retries"},
+		{"v2_api", "V2Api", "v2Api", "v2_api", "V2_API", "v2-api"},
+		{"a_b_c_d", "ABCD", "aBCD", "a_b_c_d", "A_B_C_D", "a-b-c-d"},
+		{"order-line item", "OrderLineItem", "orderLineItem", "order_line_item", "ORDER_LINE_ITEM", "order-line-item"},
+	}
+	for _, tt := range tests {
+		got := []string{PascalCase(tt.input), CamelCase(tt.input), SnakeCase(tt.input), ScreamingSnakeCase(tt.input), KebabCase(tt.input)}
+		expected := []string{tt.pascal, tt.camel, tt.snake, tt.screaming, tt.kebab}
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Errorf("%q: expected %q, got %q", tt.input, expected, got)
+		}
+	}
+}
+
+func TestCaser(t *testing.T) {
+	initialisms := NewCaser(DefaultInitialisms...)
+	keepCase := &Caser{KeepCase: true}
+	both := NewCaser(DefaultInitialisms...)
+	both.KeepCase = true
+
+	tests := []struct {
+		caser         *Caser
+		input         string
+		pascal, camel string
+	}{
+		{initialisms, "user_id", "UserID", "userID"},
+		{initialisms, "avatar_url", "AvatarURL", "avatarURL"},
+		{initialisms, "HttpServer", "HTTPServer", "httpServer"},
+		{initialisms, "id", "ID", "id"},
+		{initialisms, "id_token", "IDToken", "idToken"},
+		{initialisms, "identity", "Identity", "identity"},
+		{initialisms, "utf8_text", "UTF8Text", "utf8Text"},
+		{initialisms, "json_api_url", "JSONAPIURL", "jsonAPIURL"},
+		{initialisms, "MAX_ITEMS", "MaxItems", "maxItems"},
+		{keepCase, "user_id", "UserId", "userId"},
+		{keepCase, "HTTPServer", "HTTPServer", "httpServer"},
+		{keepCase, "userName", "UserName", "userName"},
+		{keepCase, "a_b_c_d", "ABCD", "aBCD"},
+		{keepCase, "item_2", "Item2", "item2"},
+		{both, "user_Id", "UserID", "userID"},
+		{both, "fooBAR_baz", "FooBARBaz", "fooBARBaz"},
+		{NewCaser("k8s"), "k8s_cluster", "K8SCluster", "k8sCluster"},
+		{&Caser{}, "HTTPServer", "HttpServer", "httpServer"},
+	}
+	for _, tt := range tests {
+		if got := tt.caser.PascalCase(tt.input); got != tt.pascal {
+			t.Errorf("%+v PascalCase(%q) = %q, want %q", *tt.caser, tt.input, got, tt.pascal)
+		}
+		if got := tt.caser.CamelCase(tt.input); got != tt.camel {
+			t.Errorf("%+v CamelCase(%q) = %q, want %q", *tt.caser, tt.input, got, tt.camel)
+		}
+	}
+}
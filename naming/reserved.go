@@ -0,0 +1,87 @@
+package naming
+
+// Reserved is a set of words a target language reserves, which can't be
+// used as identifiers there
+type Reserved map[string]bool
+
+// NewReserved returns the set of the given words
+func NewReserved(words ...string) Reserved {
+	r := make(Reserved, len(words))
+	for _, word := range words {
+		r[word] = true
+	}
+	return r
+}
+
+// Escape returns the name suffixed with an underscore if it's reserved:
+// "class" -> "class_"
+func (r Reserved) Escape(name string) string {
+	if r[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// GoKeywords are the keywords of Go
+var GoKeywords = NewReserved(
+	"break", "case", "chan", "const", "continue", "default", "defer", "else",
+	"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+	"map", "package", "range", "return", "select", "struct", "switch", "type",
+	"var",
+)
+
+// PythonKeywords are the keywords of Python 3. Soft keywords like match
+// remain valid identifiers.
+var PythonKeywords = NewReserved(
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break",
+	"class", "continue", "def", "del", "elif", "else", "except", "finally",
+	"for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+	"not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+)
+
+// CppKeywords are the keywords of C++20, including the alternative
+// spellings of operators like and
+var CppKeywords = NewReserved(
+	"alignas", "alignof", "and", "and_eq", "asm", "auto", "bitand", "bitor",
+	"bool", "break", "case", "catch", "char", "char8_t", "char16_t",
+	"char32_t", "class", "co_await", "co_return", "co_yield", "compl",
+	"concept", "const", "const_cast", "consteval", "constexpr", "constinit",
+	"continue", "decltype", "default", "delete", "do", "double",
+	"dynamic_cast", "else", "enum", "explicit", "export", "extern", "false",
+	"float", "for", "friend", "goto", "if", "inline", "int", "long",
+	"mutable", "namespace", "new", "noexcept", "not", "not_eq", "nullptr",
+	"operator", "or", "or_eq", "private", "protected", "public", "register",
+	"reinterpret_cast", "requires", "return", "short", "signed", "sizeof",
+	"static", "static_assert", "static_cast", "struct", "switch", "template",
+	"this", "thread_local", "throw", "true", "try", "typedef", "typeid",
+	"typename", "union", "unsigned", "using", "virtual", "void", "volatile",
+	"wchar_t", "while", "xor", "xor_eq",
+)
+
+// DartKeywords are the reserved words of Dart, which can't be identifiers
+// anywhere. Built-in identifiers like get and required remain valid names
+// for fields.
+var DartKeywords = NewReserved(
+	"assert", "break", "case", "catch", "class", "const", "continue",
+	"default", "do", "else", "enum", "extends", "false", "final", "finally",
+	"for", "if", "in", "is", "new", "null", "rethrow", "return", "super",
+	"switch", "this", "throw", "true", "try", "var", "void", "while", "with",
+)
+
+// HackKeywords are the words Hack reserves as class names: the keywords it
+// shares with PHP and the names of its built-in types. Class names are
+// case-insensitive, so these are reserved in any case.
+var HackKeywords = NewReserved(
+	"abstract", "and", "array", "arraykey", "as", "bool", "break",
+	"callable", "case", "catch", "class", "clone", "const", "continue",
+	"declare", "default", "dict", "do", "dynamic", "echo", "else", "elseif",
+	"empty", "enddeclare", "endfor", "endforeach", "endif", "endswitch",
+	"endwhile", "eval", "exit", "extends", "false", "final", "finally",
+	"float", "for", "foreach", "function", "global", "goto", "if",
+	"implements", "include", "include_once", "instanceof", "insteadof", "int",
+	"interface", "isset", "keyset", "list", "mixed", "namespace", "new",
+	"nonnull", "noreturn", "nothing", "null", "num", "or", "parent", "print",
+	"private", "protected", "public", "require", "require_once", "return",
+	"self", "static", "string", "switch", "throw", "trait", "true", "try",
+	"unset", "use", "var", "vec", "void", "while", "xor", "yield",
+)
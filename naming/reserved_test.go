@@ -0,0 +1,34 @@
+package naming
+
+import "testing"
+
+func TestReserved(t *testing.T) {
+	tests := []struct {
+		reserved Reserved
+		name     string
+		expected string
+	}{
+		{GoKeywords, "type", "type_"},
+		{GoKeywords, "range", "range_"},
+		{GoKeywords, "string", "string"}, // predeclared identifiers aren't keywords
+		{PythonKeywords, "class", "class_"},
+		{PythonKeywords, "None", "None_"},
+		{PythonKeywords, "match", "match"}, // soft keyword
+		{PythonKeywords, "Class", "Class"},
+		{CppKeywords, "delete", "delete_"},
+		{CppKeywords, "name", "name"},
+		{CppKeywords, "if", "if_"},
+		{CppKeywords, "nullptr", "nullptr_"},
+		{DartKeywords, "default", "default_"},
+		{DartKeywords, "required", "required"}, // built-in identifier
+		{HackKeywords, "function", "function_"},
+		{NewReserved("self"), "self", "self_"},
+		{NewReserved(), "type", "type"},
+		{nil, "type", "type"},
+	}
+	for _, tt := range tests {
+		if got := tt.reserved.Escape(tt.name); got != tt.expected {
+			t.Errorf("Escape(%q) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -10,35 +11,142 @@ type StructNode struct {
 	BaseNode
 	Name   string
 	Fields []*FieldNode
+	// Embeds are the structs whose fields are embedded with ...Name, in
+	// source order
+	Embeds []*EmbedNode
+	// Doc is the lines of the comment directly above the declaration,
+	// without their leading "//"
+	Doc []string
 }
 
 func (n *StructNode) DeclNode() {}
 
+func (n *StructNode) DeclName() string { return n.Name }
+
+// Members returns the struct's fields and embeddings in source order; each
+// is a *FieldNode or an *EmbedNode
+func (n *StructNode) Members() []Node {
+	members := make([]Node, 0, len(n.Fields)+len(n.Embeds))
+	embeds := n.Embeds
+	for i, field := range n.Fields {
+		for len(embeds) > 0 && embeds[0].Index <= i {
+			members = append(members, embeds[0])
+			embeds = embeds[1:]
+		}
+		members = append(members, field)
+	}
+	for _, embed := range embeds {
+		members = append(members, embed)
+	}
+	return members
+}
+
 func (n *StructNode) String() string {
 	var parts []string
 	parts = append(parts, fmt.Sprintf("struct %s {", n.Name))
 	
-	for _, field := range n.Fields {
-		parts = append(parts, fmt.Sprintf("  %s", field.String()))
+	for _, member := range n.Members() {
+		parts = append(parts, fmt.Sprintf("  %s", member.String()))
 	}
 	
 	parts = append(parts, "}")
 	return strings.Join(parts, "\n")
 }
 
+// EmbedNode represents the embedding of a struct in another, written
+// ...Name: the embedded struct's fields become fields of the struct
+type EmbedNode struct {
+	BaseNode
+	// Type is the embedded struct
+	Type *NamedType
+	// Index is the number of fields of the struct declared before the
+	// embedding, where the embedded fields go
+	Index int
+}
+
+func (n *EmbedNode) String() string {
+	return "..." + n.Type.String()
+}
+
 // FieldNode represents a field in a struct
 type FieldNode struct {
 	BaseNode
 	Name     string
 	Type     Type
 	Optional bool
+	// Doc is the lines of the comment directly above the field
+	Doc []string
+	// Attributes are the attributes after the field's type, in source order
+	Attributes []*AttributeNode
+	// WireName is the field's name in JSON, written = "name" after its
+	// type; empty when the field goes by its own name
+	WireName string
+}
+
+// JSONName returns the name of the field in JSON: its wire name, or else
+// its name
+func (n *FieldNode) JSONName() string {
+	if n.WireName != "" {
+		return n.WireName
+	}
+	return n.Name
 }
 
 func (n *FieldNode) String() string {
+	text := fmt.Sprintf("%s: %s", n.Name, n.Type.String())
 	if n.Optional {
-		return fmt.Sprintf("%s: ?%s", n.Name, n.Type.String())
+		text = fmt.Sprintf("%s: ?%s", n.Name, n.Type.String())
+	}
+	return text + wireNameString(n.WireName) + attributesString(n.Attributes)
+}
+
+// wireNameString returns a wire name as written after a field's type or a
+// variant, or "" for none
+func wireNameString(wireName string) string {
+	if wireName == "" {
+		return ""
+	}
+	return " = " + strconv.Quote(wireName)
+}
+
+// Attribute returns the field's attribute with a name, or nil
+func (n *FieldNode) Attribute(name string) *AttributeNode {
+	return findAttribute(n.Attributes, name)
+}
+
+// AttributeNode represents an attribute of a field or enum variant, written
+// @name or @name(value)
+type AttributeNode struct {
+	BaseNode
+	Name string
+	// Value is the attribute's value, nil for an attribute written @name
+	Value ConstantValue
+}
+
+func (n *AttributeNode) String() string {
+	if n.Value != nil {
+		return fmt.Sprintf("@%s(%s)", n.Name, n.Value.String())
+	}
+	return "@" + n.Name
+}
+
+// attributesString returns attributes as written after a type, each
+// preceded by a space
+func attributesString(attributes []*AttributeNode) string {
+	var b strings.Builder
+	for _, attribute := range attributes {
+		b.WriteString(" " + attribute.String())
+	}
+	return b.String()
+}
+
+func findAttribute(attributes []*AttributeNode, name string) *AttributeNode {
+	for _, attribute := range attributes {
+		if attribute.Name == name {
+			return attribute
+		}
 	}
-	return fmt.Sprintf("%s: %s", n.Name, n.Type.String())
+	return nil
 }
 
 // EnumNode represents an enum declaration
@@ -46,10 +154,14 @@ type EnumNode struct {
 	BaseNode
 	Name     string
 	Variants []*EnumVariantNode
+	// Doc is the lines of the comment directly above the declaration
+	Doc []string
 }
 
 func (n *EnumNode) DeclNode() {}
 
+func (n *EnumNode) DeclName() string { return n.Name }
+
 func (n *EnumNode) String() string {
 	var parts []string
 	parts = append(parts, fmt.Sprintf("enum %s {", n.Name))
@@ -67,13 +179,34 @@ type EnumVariantNode struct {
 	BaseNode
 	Name    string
 	Payload Type
+	// Doc is the lines of the comment directly above the variant
+	Doc []string
+	// Attributes are the attributes after the variant, in source order
+	Attributes []*AttributeNode
+	// WireName is the variant's "type" in JSON, written = "name" after the
+	// variant; empty when the variant goes by its own name
+	WireName string
+}
+
+// JSONName returns the "type" of the variant in JSON: its wire name, or
+// else its name
+func (n *EnumVariantNode) JSONName() string {
+	if n.WireName != "" {
+		return n.WireName
+	}
+	return n.Name
+}
+
+// Attribute returns the variant's attribute with a name, or nil
+func (n *EnumVariantNode) Attribute(name string) *AttributeNode {
+	return findAttribute(n.Attributes, name)
 }
 
 func (n *EnumVariantNode) String() string {
 	if n.Payload != nil {
-		return fmt.Sprintf("%s: %s", n.Name, n.Payload.String())
+		return fmt.Sprintf("%s: %s", n.Name, n.Payload.String()) + wireNameString(n.WireName) + attributesString(n.Attributes)
 	}
-	return n.Name
+	return n.Name + wireNameString(n.WireName) + attributesString(n.Attributes)
 }
 
 // TypeAliasNode represents a type alias declaration
@@ -81,15 +214,19 @@ type TypeAliasNode struct {
 	BaseNode
 	Name string
 	Type Type
+	// Doc is the lines of the comment directly above the declaration
+	Doc []string
 }
 
 func (n *TypeAliasNode) DeclNode() {}
 
+func (n *TypeAliasNode) DeclName() string { return n.Name }
+
 func (n *TypeAliasNode) String() string {
 	return fmt.Sprintf("type %s = %s", n.Name, n.Type.String())
 }
 
-// ConstantValue represents a constant value (integer or string)
+// ConstantValue represents a constant value (integer, float, string or boolean)
 type ConstantValue interface {
 	Node
 	ConstantValueNode()
@@ -99,14 +236,70 @@ type ConstantValue interface {
 type IntConstant struct {
 	BaseNode
 	Value int64
+	// Literal is the source text of a hexadecimal, octal or binary value,
+	// such as "0x08" or "-0b1010", and empty for a decimal one
+	Literal string
 }
 
 func (n *IntConstant) ConstantValueNode() {}
 
+// String returns the value as written: its literal, or the value in decimal
 func (n *IntConstant) String() string {
+	if n.Literal != "" {
+		return n.Literal
+	}
 	return fmt.Sprintf("%d", n.Value)
 }
 
+// FloatConstant represents a floating point constant value
+type FloatConstant struct {
+	BaseNode
+	Value float64
+	// Literal is the source text of the value, such as "1.5e3" or "-1e-9",
+	// and empty for a constant that was not parsed
+	Literal string
+}
+
+func (n *FloatConstant) ConstantValueNode() {}
+
+// String returns the value as written: its literal, or the shortest literal
+// of the value that is still a float literal, e.g. "2.0" rather than "2"
+func (n *FloatConstant) String() string {
+	if n.Literal != "" {
+		return n.Literal
+	}
+	return n.shortest()
+}
+
+// Decimal returns the value as written when its literal is a decimal with
+// digits on both sides of any point, such as "1.5e3", which most languages
+// accept as written; forms like "1.", ".5" or "0x1p-2" fall back to the
+// shortest literal of the value
+func (n *FloatConstant) Decimal() string {
+	text := strings.TrimPrefix(n.Literal, "-")
+	if text == "" || strings.ContainsAny(text, "xX_") {
+		return n.shortest()
+	}
+	if dot := strings.IndexByte(text, '.'); dot >= 0 {
+		if dot == 0 || dot == len(text)-1 || !isDigit(text[dot-1]) || !isDigit(text[dot+1]) {
+			return n.shortest()
+		}
+	}
+	return n.Literal
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (n *FloatConstant) shortest() string {
+	text := strconv.FormatFloat(n.Value, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eIN") {
+		text += ".0"
+	}
+	return text
+}
+
 // StringConstant represents a string constant value
 type StringConstant struct {
 	BaseNode
@@ -119,15 +312,31 @@ func (n *StringConstant) String() string {
 	return fmt.Sprintf("\"%s\"", n.Value)
 }
 
+// BoolConstant represents a boolean constant value
+type BoolConstant struct {
+	BaseNode
+	Value bool
+}
+
+func (n *BoolConstant) ConstantValueNode() {}
+
+func (n *BoolConstant) String() string {
+	return strconv.FormatBool(n.Value)
+}
+
 // ConstantNode represents a constant declaration
 type ConstantNode struct {
 	BaseNode
 	Name  string
 	Value ConstantValue
+	// Doc is the lines of the comment directly above the declaration
+	Doc []string
 }
 
 func (n *ConstantNode) DeclNode() {}
 
+func (n *ConstantNode) DeclName() string { return n.Name }
+
 func (n *ConstantNode) String() string {
 	return fmt.Sprintf("const %s = %s", n.Name, n.Value.String())
 }
\ No newline at end of file
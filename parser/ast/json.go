@@ -0,0 +1,832 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSON encoding of the AST, for editor plugins and external generators.
+// Every node is an object with a "kind" discriminator and its span under
+// "pos" and "end", so Declaration, Type and ConstantValue values can be told
+// apart without knowing the Go types. Programs and modules, the roots of an
+// encoding, also hold the format's version under "version", and programs
+// list the comments of their file under "comments". Decoding is lossless: a decoded tree prints and encodes as the original.
+
+// JSONVersion is the version of the JSON encoding of the AST. It changes
+// when a change of the AST changes the encoding in a way older decoders
+// can't read, and decoding rejects other versions.
+const JSONVersion = 1
+
+func (n *ProgramNode) MarshalJSON() ([]byte, error) {
+	imports := n.Imports
+	if imports == nil {
+		imports = []*ImportNode{}
+	}
+	declarations := n.Declarations
+	if declarations == nil {
+		declarations = []Declaration{}
+	}
+	return json.Marshal(struct {
+		Kind         string         `json:"kind"`
+		Version      int            `json:"version"`
+		Pos          Position       `json:"pos"`
+		End          Position       `json:"end"`
+		Imports      []*ImportNode  `json:"imports"`
+		Declarations []Declaration  `json:"declarations"`
+		Comments     []*CommentNode `json:"comments,omitempty"`
+	}{"program", JSONVersion, n.Position, n.End, imports, declarations, n.Comments})
+}
+
+func (n *ImportNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Path string   `json:"path"`
+	}{"import", n.Position, n.End, n.Path})
+}
+
+func (n *CommentNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Text string   `json:"text"`
+	}{"comment", n.Position, n.End, n.Text})
+}
+
+func (n *StructNode) MarshalJSON() ([]byte, error) {
+	fields := n.Fields
+	if fields == nil {
+		fields = []*FieldNode{}
+	}
+	return json.Marshal(struct {
+		Kind   string       `json:"kind"`
+		Pos    Position     `json:"pos"`
+		End    Position     `json:"end"`
+		Name   string       `json:"name"`
+		Doc    []string     `json:"doc,omitempty"`
+		Fields []*FieldNode `json:"fields"`
+		Embeds []*EmbedNode `json:"embeds,omitempty"`
+	}{"struct", n.Position, n.End, n.Name, n.Doc, fields, n.Embeds})
+}
+
+func (n *EmbedNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string     `json:"kind"`
+		Pos   Position   `json:"pos"`
+		End   Position   `json:"end"`
+		Type  *NamedType `json:"type"`
+		Index int        `json:"index"`
+	}{"embed", n.Position, n.End, n.Type, n.Index})
+}
+
+func (n *FieldNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string           `json:"kind"`
+		Pos        Position         `json:"pos"`
+		End        Position         `json:"end"`
+		Name       string           `json:"name"`
+		Doc        []string         `json:"doc,omitempty"`
+		Type       Type             `json:"type"`
+		Optional   bool             `json:"optional"`
+		WireName   string           `json:"wire_name,omitempty"`
+		Attributes []*AttributeNode `json:"attributes,omitempty"`
+	}{"field", n.Position, n.End, n.Name, n.Doc, n.Type, n.Optional, n.WireName, n.Attributes})
+}
+
+func (n *EnumNode) MarshalJSON() ([]byte, error) {
+	variants := n.Variants
+	if variants == nil {
+		variants = []*EnumVariantNode{}
+	}
+	return json.Marshal(struct {
+		Kind     string             `json:"kind"`
+		Pos      Position           `json:"pos"`
+		End      Position           `json:"end"`
+		Name     string             `json:"name"`
+		Doc      []string           `json:"doc,omitempty"`
+		Variants []*EnumVariantNode `json:"variants"`
+	}{"enum", n.Position, n.End, n.Name, n.Doc, variants})
+}
+
+func (n *EnumVariantNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string           `json:"kind"`
+		Pos        Position         `json:"pos"`
+		End        Position         `json:"end"`
+		Name       string           `json:"name"`
+		Doc        []string         `json:"doc,omitempty"`
+		Payload    Type             `json:"payload,omitempty"`
+		WireName   string           `json:"wire_name,omitempty"`
+		Attributes []*AttributeNode `json:"attributes,omitempty"`
+	}{"variant", n.Position, n.End, n.Name, n.Doc, n.Payload, n.WireName, n.Attributes})
+}
+
+func (n *AttributeNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string        `json:"kind"`
+		Pos   Position      `json:"pos"`
+		End   Position      `json:"end"`
+		Name  string        `json:"name"`
+		Value ConstantValue `json:"value,omitempty"`
+	}{"attribute", n.Position, n.End, n.Name, n.Value})
+}
+
+func (n *TypeAliasNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Name string   `json:"name"`
+		Doc  []string `json:"doc,omitempty"`
+		Type Type     `json:"type"`
+	}{"alias", n.Position, n.End, n.Name, n.Doc, n.Type})
+}
+
+func (n *ConstantNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string        `json:"kind"`
+		Pos   Position      `json:"pos"`
+		End   Position      `json:"end"`
+		Name  string        `json:"name"`
+		Doc   []string      `json:"doc,omitempty"`
+		Value ConstantValue `json:"value"`
+	}{"constant", n.Position, n.End, n.Name, n.Doc, n.Value})
+}
+
+func (n *IntConstant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Value   int64    `json:"value"`
+		Literal string   `json:"literal,omitempty"`
+	}{"int", n.Position, n.End, n.Value, n.Literal})
+}
+
+func (n *FloatConstant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Value   float64  `json:"value"`
+		Literal string   `json:"literal,omitempty"`
+	}{"float", n.Position, n.End, n.Value, n.Literal})
+}
+
+func (n *StringConstant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string   `json:"kind"`
+		Pos   Position `json:"pos"`
+		End   Position `json:"end"`
+		Value string   `json:"value"`
+	}{"string", n.Position, n.End, n.Value})
+}
+
+func (n *BoolConstant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string   `json:"kind"`
+		Pos   Position `json:"pos"`
+		End   Position `json:"end"`
+		Value bool     `json:"value"`
+	}{"bool", n.Position, n.End, n.Value})
+}
+
+func (n *PrimitiveType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Name string   `json:"name"`
+	}{"primitive", n.Position, n.End, n.Name})
+}
+
+func (n *NamedType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Name string   `json:"name"`
+	}{"named", n.Position, n.End, n.Name})
+}
+
+func (n *ArrayType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Element Type     `json:"element"`
+	}{"array", n.Position, n.End, n.ElementType})
+}
+
+func (n *MapType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string   `json:"kind"`
+		Pos   Position `json:"pos"`
+		End   Position `json:"end"`
+		Key   Type     `json:"key"`
+		Value Type     `json:"value"`
+	}{"map", n.Position, n.End, n.KeyType, n.ValueType})
+}
+
+func (n *OptionalType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Element Type     `json:"element"`
+	}{"optional", n.Position, n.End, n.ElementType})
+}
+
+// MarshalJSON encodes the module with its files and submodules keyed by name
+func (m *Module) MarshalJSON() ([]byte, error) {
+	files := m.Files
+	if files == nil {
+		files = map[string]*ProgramNode{}
+	}
+	subModules := m.SubModules
+	if subModules == nil {
+		subModules = map[string]*Module{}
+	}
+	return json.Marshal(struct {
+		Kind       string                  `json:"kind"`
+		Version    int                     `json:"version"`
+		Path       string                  `json:"path"`
+		Name       string                  `json:"name"`
+		Files      map[string]*ProgramNode `json:"files"`
+		SubModules map[string]*Module      `json:"submodules"`
+	}{"module", JSONVersion, m.Path, m.Name, files, subModules})
+}
+
+// UnmarshalJSON decodes a module encoded by MarshalJSON
+func (m *Module) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind       string                  `json:"kind"`
+		Version    int                     `json:"version"`
+		Path       string                  `json:"path"`
+		Name       string                  `json:"name"`
+		Files      map[string]*ProgramNode `json:"files"`
+		SubModules map[string]*Module      `json:"submodules"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "module"); err != nil {
+		return err
+	}
+	if err := checkVersion(v.Version); err != nil {
+		return err
+	}
+	if v.Files == nil {
+		v.Files = map[string]*ProgramNode{}
+	}
+	if v.SubModules == nil {
+		v.SubModules = map[string]*Module{}
+	}
+	*m = Module{Path: v.Path, Name: v.Name, Files: v.Files, SubModules: v.SubModules}
+	return nil
+}
+
+func (n *ProgramNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind         string            `json:"kind"`
+		Version      int               `json:"version"`
+		Pos          Position          `json:"pos"`
+		End          Position          `json:"end"`
+		Imports      []*ImportNode     `json:"imports"`
+		Declarations []json.RawMessage `json:"declarations"`
+		Comments     []*CommentNode    `json:"comments"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "program"); err != nil {
+		return err
+	}
+	if err := checkVersion(v.Version); err != nil {
+		return err
+	}
+	*n = ProgramNode{BaseNode: BaseNode{v.Pos, v.End}}
+	if len(v.Imports) > 0 {
+		n.Imports = v.Imports
+	}
+	if len(v.Comments) > 0 {
+		n.Comments = v.Comments
+	}
+	for i, raw := range v.Declarations {
+		decl, err := decodeDeclaration(raw)
+		if err != nil {
+			return fmt.Errorf("declaration %d: %w", i+1, err)
+		}
+		n.Declarations = append(n.Declarations, decl)
+	}
+	return nil
+}
+
+func (n *ImportNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Path string   `json:"path"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "import"); err != nil {
+		return err
+	}
+	*n = ImportNode{BaseNode{v.Pos, v.End}, v.Path}
+	return nil
+}
+
+func (n *CommentNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Text string   `json:"text"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "comment"); err != nil {
+		return err
+	}
+	*n = CommentNode{BaseNode{v.Pos, v.End}, v.Text}
+	return nil
+}
+
+func (n *StructNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind   string       `json:"kind"`
+		Pos    Position     `json:"pos"`
+		End    Position     `json:"end"`
+		Name   string       `json:"name"`
+		Doc    []string     `json:"doc"`
+		Fields []*FieldNode `json:"fields"`
+		Embeds []*EmbedNode `json:"embeds"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "struct"); err != nil {
+		return err
+	}
+	*n = StructNode{BaseNode: BaseNode{v.Pos, v.End}, Name: v.Name, Doc: v.Doc}
+	if len(v.Fields) > 0 {
+		n.Fields = v.Fields
+	}
+	if len(v.Embeds) > 0 {
+		n.Embeds = v.Embeds
+	}
+	return nil
+}
+
+func (n *EmbedNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string     `json:"kind"`
+		Pos   Position   `json:"pos"`
+		End   Position   `json:"end"`
+		Type  *NamedType `json:"type"`
+		Index int        `json:"index"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "embed"); err != nil {
+		return err
+	}
+	if v.Type == nil {
+		return fmt.Errorf("embed: missing type")
+	}
+	*n = EmbedNode{BaseNode{v.Pos, v.End}, v.Type, v.Index}
+	return nil
+}
+
+func (n *FieldNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind       string           `json:"kind"`
+		Pos        Position         `json:"pos"`
+		End        Position         `json:"end"`
+		Name       string           `json:"name"`
+		Doc        []string         `json:"doc"`
+		Type       json.RawMessage  `json:"type"`
+		Optional   bool             `json:"optional"`
+		WireName   string           `json:"wire_name"`
+		Attributes []*AttributeNode `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "field"); err != nil {
+		return err
+	}
+	t, err := decodeType(v.Type)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", v.Name, err)
+	}
+	*n = FieldNode{BaseNode{v.Pos, v.End}, v.Name, t, v.Optional, v.Doc, nil, v.WireName}
+	if len(v.Attributes) > 0 {
+		n.Attributes = v.Attributes
+	}
+	return nil
+}
+
+func (n *EnumNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind     string             `json:"kind"`
+		Pos      Position           `json:"pos"`
+		End      Position           `json:"end"`
+		Name     string             `json:"name"`
+		Doc      []string           `json:"doc"`
+		Variants []*EnumVariantNode `json:"variants"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "enum"); err != nil {
+		return err
+	}
+	*n = EnumNode{BaseNode: BaseNode{v.Pos, v.End}, Name: v.Name, Doc: v.Doc}
+	if len(v.Variants) > 0 {
+		n.Variants = v.Variants
+	}
+	return nil
+}
+
+func (n *EnumVariantNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind       string           `json:"kind"`
+		Pos        Position         `json:"pos"`
+		End        Position         `json:"end"`
+		Name       string           `json:"name"`
+		Doc        []string         `json:"doc"`
+		Payload    json.RawMessage  `json:"payload"`
+		WireName   string           `json:"wire_name"`
+		Attributes []*AttributeNode `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "variant"); err != nil {
+		return err
+	}
+	*n = EnumVariantNode{BaseNode: BaseNode{v.Pos, v.End}, Name: v.Name, Doc: v.Doc, WireName: v.WireName}
+	if len(v.Attributes) > 0 {
+		n.Attributes = v.Attributes
+	}
+	if len(v.Payload) > 0 && string(v.Payload) != "null" {
+		payload, err := decodeType(v.Payload)
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", v.Name, err)
+		}
+		n.Payload = payload
+	}
+	return nil
+}
+
+func (n *AttributeNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string          `json:"kind"`
+		Pos   Position        `json:"pos"`
+		End   Position        `json:"end"`
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "attribute"); err != nil {
+		return err
+	}
+	*n = AttributeNode{BaseNode: BaseNode{v.Pos, v.End}, Name: v.Name}
+	if len(v.Value) > 0 && string(v.Value) != "null" {
+		value, err := decodeConstantValue(v.Value)
+		if err != nil {
+			return fmt.Errorf("attribute %s: %w", v.Name, err)
+		}
+		n.Value = value
+	}
+	return nil
+}
+
+func (n *TypeAliasNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind string          `json:"kind"`
+		Pos  Position        `json:"pos"`
+		End  Position        `json:"end"`
+		Name string          `json:"name"`
+		Doc  []string        `json:"doc"`
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "alias"); err != nil {
+		return err
+	}
+	t, err := decodeType(v.Type)
+	if err != nil {
+		return fmt.Errorf("alias %s: %w", v.Name, err)
+	}
+	*n = TypeAliasNode{BaseNode{v.Pos, v.End}, v.Name, t, v.Doc}
+	return nil
+}
+
+func (n *ConstantNode) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string          `json:"kind"`
+		Pos   Position        `json:"pos"`
+		End   Position        `json:"end"`
+		Name  string          `json:"name"`
+		Doc   []string        `json:"doc"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "constant"); err != nil {
+		return err
+	}
+	value, err := decodeConstantValue(v.Value)
+	if err != nil {
+		return fmt.Errorf("constant %s: %w", v.Name, err)
+	}
+	*n = ConstantNode{BaseNode{v.Pos, v.End}, v.Name, value, v.Doc}
+	return nil
+}
+
+func (n *IntConstant) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Value   int64    `json:"value"`
+		Literal string   `json:"literal"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "int"); err != nil {
+		return err
+	}
+	*n = IntConstant{BaseNode{v.Pos, v.End}, v.Value, v.Literal}
+	return nil
+}
+
+func (n *FloatConstant) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind    string   `json:"kind"`
+		Pos     Position `json:"pos"`
+		End     Position `json:"end"`
+		Value   float64  `json:"value"`
+		Literal string   `json:"literal"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "float"); err != nil {
+		return err
+	}
+	*n = FloatConstant{BaseNode{v.Pos, v.End}, v.Value, v.Literal}
+	return nil
+}
+
+func (n *StringConstant) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string   `json:"kind"`
+		Pos   Position `json:"pos"`
+		End   Position `json:"end"`
+		Value string   `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "string"); err != nil {
+		return err
+	}
+	*n = StringConstant{BaseNode{v.Pos, v.End}, v.Value}
+	return nil
+}
+
+func (n *BoolConstant) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string   `json:"kind"`
+		Pos   Position `json:"pos"`
+		End   Position `json:"end"`
+		Value bool     `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "bool"); err != nil {
+		return err
+	}
+	*n = BoolConstant{BaseNode{v.Pos, v.End}, v.Value}
+	return nil
+}
+
+func (n *PrimitiveType) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Name string   `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "primitive"); err != nil {
+		return err
+	}
+	*n = PrimitiveType{BaseNode{v.Pos, v.End}, v.Name}
+	return nil
+}
+
+func (n *NamedType) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind string   `json:"kind"`
+		Pos  Position `json:"pos"`
+		End  Position `json:"end"`
+		Name string   `json:"name"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "named"); err != nil {
+		return err
+	}
+	*n = NamedType{BaseNode{v.Pos, v.End}, v.Name}
+	return nil
+}
+
+func (n *ArrayType) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind    string          `json:"kind"`
+		Pos     Position        `json:"pos"`
+		End     Position        `json:"end"`
+		Element json.RawMessage `json:"element"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "array"); err != nil {
+		return err
+	}
+	element, err := decodeType(v.Element)
+	if err != nil {
+		return fmt.Errorf("array element: %w", err)
+	}
+	*n = ArrayType{BaseNode{v.Pos, v.End}, element}
+	return nil
+}
+
+func (n *MapType) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind  string          `json:"kind"`
+		Pos   Position        `json:"pos"`
+		End   Position        `json:"end"`
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "map"); err != nil {
+		return err
+	}
+	key, err := decodeType(v.Key)
+	if err != nil {
+		return fmt.Errorf("map key: %w", err)
+	}
+	value, err := decodeType(v.Value)
+	if err != nil {
+		return fmt.Errorf("map value: %w", err)
+	}
+	*n = MapType{BaseNode{v.Pos, v.End}, key, value}
+	return nil
+}
+
+func (n *OptionalType) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Kind    string          `json:"kind"`
+		Pos     Position        `json:"pos"`
+		End     Position        `json:"end"`
+		Element json.RawMessage `json:"element"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkKind(v.Kind, "optional"); err != nil {
+		return err
+	}
+	element, err := decodeType(v.Element)
+	if err != nil {
+		return fmt.Errorf("optional element: %w", err)
+	}
+	*n = OptionalType{BaseNode{v.Pos, v.End}, element}
+	return nil
+}
+
+// decodeDeclaration decodes a Declaration by its kind
+func decodeDeclaration(data json.RawMessage) (Declaration, error) {
+	kind, err := kindOf(data)
+	if err != nil {
+		return nil, err
+	}
+	var decl Declaration
+	switch kind {
+	case "struct":
+		decl = &StructNode{}
+	case "enum":
+		decl = &EnumNode{}
+	case "alias":
+		decl = &TypeAliasNode{}
+	case "constant":
+		decl = &ConstantNode{}
+	default:
+		return nil, fmt.Errorf("unknown declaration kind %q", kind)
+	}
+	return decl, json.Unmarshal(data, decl)
+}
+
+// decodeType decodes a Type by its kind
+func decodeType(data json.RawMessage) (Type, error) {
+	kind, err := kindOf(data)
+	if err != nil {
+		return nil, err
+	}
+	var t Type
+	switch kind {
+	case "primitive":
+		t = &PrimitiveType{}
+	case "named":
+		t = &NamedType{}
+	case "array":
+		t = &ArrayType{}
+	case "map":
+		t = &MapType{}
+	case "optional":
+		t = &OptionalType{}
+	default:
+		return nil, fmt.Errorf("unknown type kind %q", kind)
+	}
+	return t, json.Unmarshal(data, t)
+}
+
+// decodeConstantValue decodes a ConstantValue by its kind
+func decodeConstantValue(data json.RawMessage) (ConstantValue, error) {
+	kind, err := kindOf(data)
+	if err != nil {
+		return nil, err
+	}
+	var value ConstantValue
+	switch kind {
+	case "int":
+		value = &IntConstant{}
+	case "float":
+		value = &FloatConstant{}
+	case "string":
+		value = &StringConstant{}
+	case "bool":
+		value = &BoolConstant{}
+	default:
+		return nil, fmt.Errorf("unknown constant value kind %q", kind)
+	}
+	return value, json.Unmarshal(data, value)
+}
+
+// kindOf returns the "kind" of an encoded node
+func kindOf(data json.RawMessage) (string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", fmt.Errorf("missing node")
+	}
+	var v struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	if v.Kind == "" {
+		return "", fmt.Errorf("node has no kind")
+	}
+	return v.Kind, nil
+}
+
+// checkKind returns an error if a node decoded as kind expected is another
+func checkKind(kind, expected string) error {
+	if kind != expected {
+		return fmt.Errorf("expected a %s node, got kind %q", expected, kind)
+	}
+	return nil
+}
+
+// checkVersion returns an error for encodings of another JSONVersion
+func checkVersion(version int) error {
+	if version != JSONVersion {
+		return fmt.Errorf("unsupported AST JSON version %d (expected %d)", version, JSONVersion)
+	}
+	return nil
+}
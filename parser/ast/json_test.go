@@ -0,0 +1,104 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+func TestModuleJSONRoundTrip(t *testing.T) {
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "everything"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	data, err := json.Marshal(module)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded ast.Module
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(module, &decoded) {
+		t.Errorf("decoded module differs from the parsed one:\n%s", decoded.String())
+	}
+	for name, file := range module.AllFiles() {
+		if got := decoded.AllFiles()[name]; got == nil || got.String() != file.String() {
+			t.Errorf("%s prints differently after decoding:\n%v", name, got)
+		}
+	}
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("re-encoding differs:\n%s\n%s", data, again)
+	}
+}
+
+func TestProgramJSONRoundTrip(t *testing.T) {
+	program, err := parser.ParseFile(filepath.Join("testdata", "everything", "order.tg"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"kind":"program","version":1,`) {
+		t.Errorf("expected a versioned program, got %.40s", data)
+	}
+	var decoded ast.ProgramNode
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(program, &decoded) {
+		t.Errorf("decoded program differs from the parsed one:\n%s", decoded.String())
+	}
+
+	// Positions are kept, file names included
+	original := program.Declarations[2].(*ast.StructNode).Fields[1]
+	field := decoded.Declarations[2].(*ast.StructNode).Fields[1]
+	if pos := field.Type.Pos(); pos != original.Type.Pos() || !strings.HasSuffix(pos.Filename, "order.tg") {
+		t.Errorf("expected the position of auth.User to be %s, got %s", original.Type.Pos(), pos)
+	}
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"future version", `{"kind":"program","version":2,"imports":[],"declarations":[]}`, "unsupported AST JSON version 2 (expected 1)"},
+		{"missing version", `{"kind":"program","imports":[],"declarations":[]}`, "unsupported AST JSON version 0"},
+		{"wrong kind", `{"kind":"module","version":1}`, `expected a program node, got kind "module"`},
+		{"unknown declaration", `{"kind":"program","version":1,"declarations":[{"kind":"union","name":"U"}]}`, `declaration 1: unknown declaration kind "union"`},
+		{"unknown type", `{"kind":"program","version":1,"declarations":[{"kind":"alias","name":"A","type":{"kind":"set"}}]}`, `alias A: unknown type kind "set"`},
+		{"missing type", `{"kind":"program","version":1,"declarations":[{"kind":"struct","name":"S","fields":[{"kind":"field","name":"id"}]}]}`, "field id: missing node"},
+		{"nested type", `{"kind":"program","version":1,"declarations":[{"kind":"alias","name":"A","type":{"kind":"array","element":{"name":"x"}}}]}`, "alias A: array element: node has no kind"},
+		{"unknown constant", `{"kind":"program","version":1,"declarations":[{"kind":"constant","name":"C","value":{"kind":"list","value":[1]}}]}`, `constant C: unknown constant value kind "list"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var program ast.ProgramNode
+			err := json.Unmarshal([]byte(tt.input), &program)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	var module ast.Module
+	if err := json.Unmarshal([]byte(`{"kind":"module","version":3}`), &module); err == nil || !strings.Contains(err.Error(), "version 3") {
+		t.Errorf("expected a version error for a module, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package ast
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -46,27 +47,29 @@ func (m *Module) FileNames() []string {
 	for name := range m.Files {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
 
-// AllDeclarations returns all declarations from all files in the module and submodules
+// AllDeclarations returns all declarations from all files in the module and submodules,
+// ordered by file name, then submodule name
 func (m *Module) AllDeclarations() []Declaration {
 	var decls []Declaration
 	
 	// Add declarations from files in this module
-	for _, program := range m.Files {
-		decls = append(decls, program.Declarations...)
+	for _, filename := range m.FileNames() {
+		decls = append(decls, m.Files[filename].Declarations...)
 	}
 	
 	// Add declarations from submodules recursively
-	for _, subModule := range m.SubModules {
-		decls = append(decls, subModule.AllDeclarations()...)
+	for _, subModuleName := range m.SubModuleNames() {
+		decls = append(decls, m.SubModules[subModuleName].AllDeclarations()...)
 	}
 	
 	return decls
 }
 
-// AllImports returns all unique import paths from all files in the module and submodules
+// AllImports returns a sorted list of all unique import paths from all files in the module and submodules
 func (m *Module) AllImports() []string {
 	importSet := make(map[string]bool)
 	
@@ -88,34 +91,25 @@ func (m *Module) AllImports() []string {
 	for imp := range importSet {
 		imports = append(imports, imp)
 	}
+	sort.Strings(imports)
 	return imports
 }
 
-// FindDeclaration finds a declaration by name across all files in the module and submodules
+// FindDeclaration finds a declaration by name across all files in the module and submodules.
+// Files are searched by name, then submodules, so the first match is the same on every call.
 func (m *Module) FindDeclaration(name string) (Declaration, string, bool) {
 	// Search in files of this module
-	for filename, program := range m.Files {
-		for _, decl := range program.Declarations {
-			switch d := decl.(type) {
-			case *StructNode:
-				if d.Name == name {
-					return d, filename, true
-				}
-			case *EnumNode:
-				if d.Name == name {
-					return d, filename, true
-				}
-			case *TypeAliasNode:
-				if d.Name == name {
-					return d, filename, true
-				}
+	for _, filename := range m.FileNames() {
+		for _, decl := range m.Files[filename].Declarations {
+			if decl.DeclName() == name {
+				return decl, filename, true
 			}
 		}
 	}
 	
 	// Search in submodules recursively
-	for subModuleName, subModule := range m.SubModules {
-		if decl, filename, found := subModule.FindDeclaration(name); found {
+	for _, subModuleName := range m.SubModuleNames() {
+		if decl, filename, found := m.SubModules[subModuleName].FindDeclaration(name); found {
 			// Return path relative to the submodule
 			return decl, filepath.Join(subModuleName, filename), true
 		}
@@ -130,16 +124,16 @@ func (m *Module) String() string {
 	parts = append(parts, fmt.Sprintf("Module: %s (%s)", m.Name, m.Path))
 	parts = append(parts, "")
 	
-	for filename, program := range m.Files {
+	for _, filename := range m.FileNames() {
 		parts = append(parts, fmt.Sprintf("=== %s ===", filename))
-		parts = append(parts, program.String())
+		parts = append(parts, m.Files[filename].String())
 		parts = append(parts, "")
 	}
 	
 	// Add submodule information
-	for subModuleName, subModule := range m.SubModules {
+	for _, subModuleName := range m.SubModuleNames() {
 		parts = append(parts, fmt.Sprintf("=== SubModule: %s ===", subModuleName))
-		parts = append(parts, subModule.String())
+		parts = append(parts, m.SubModules[subModuleName].String())
 		parts = append(parts, "")
 	}
 	
@@ -179,5 +173,6 @@ func (m *Module) SubModuleNames() []string {
 	for name := range m.SubModules {
 		names = append(names, name)
 	}
+	sort.Strings(names)
 	return names
 }
\ No newline at end of file
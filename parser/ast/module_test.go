@@ -0,0 +1,110 @@
+package ast_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// parseProgram parses source as the file filename
+func parseProgram(t *testing.T, filename, source string) *ast.ProgramNode {
+	t.Helper()
+	program, err := parser.Parse(strings.NewReader(source), filename)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", filename, err)
+	}
+	return program
+}
+
+func TestFindDeclaration(t *testing.T) {
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{
+		"limits.tg": parseProgram(t, "limits.tg", "const MAX_USERS = 100\n\nstruct User {\n  id: int64\n}"),
+	})
+	auth := ast.NewModule("api/auth", map[string]*ast.ProgramNode{})
+	auth.SubModules["tokens"] = ast.NewModule("api/auth/tokens", map[string]*ast.ProgramNode{
+		"token.tg": parseProgram(t, "token.tg", "const TOKEN_PREFIX = \"tg_\"\n\ntype TokenID = string"),
+	})
+	module.SubModules["auth"] = auth
+
+	tests := []struct {
+		name string
+		kind string
+		file string
+	}{
+		{"MAX_USERS", "*ast.ConstantNode", "limits.tg"},
+		{"User", "*ast.StructNode", "limits.tg"},
+		{"TOKEN_PREFIX", "*ast.ConstantNode", filepath.Join("auth", "tokens", "token.tg")},
+		{"TokenID", "*ast.TypeAliasNode", filepath.Join("auth", "tokens", "token.tg")},
+	}
+	for _, tt := range tests {
+		decl, file, found := module.FindDeclaration(tt.name)
+		if !found {
+			t.Errorf("FindDeclaration(%q): not found", tt.name)
+			continue
+		}
+		if kind := fmt.Sprintf("%T", decl); kind != tt.kind || file != tt.file || decl.DeclName() != tt.name {
+			t.Errorf("FindDeclaration(%q): expected %s in %s, got %s %q in %s", tt.name, tt.kind, tt.file, kind, decl.DeclName(), file)
+		}
+	}
+
+	if _, _, found := module.FindDeclaration("MISSING"); found {
+		t.Error("FindDeclaration(\"MISSING\"): expected not found")
+	}
+}
+
+// shuffledModule builds a module with files and submodules inserted in a
+// different order on every call
+func shuffledModule(t *testing.T, seed int) *ast.Module {
+	t.Helper()
+	files := []string{"zeta.tg", "alpha.tg", "mid.tg", "beta.tg"}
+	subModules := []string{"users", "billing", "auth"}
+	rotate := func(names []string) []string {
+		n := seed % len(names)
+		return append(append([]string{}, names[n:]...), names[:n]...)
+	}
+
+	module := ast.NewModule("api", map[string]*ast.ProgramNode{})
+	for _, filename := range rotate(files) {
+		name := strings.ToUpper(strings.TrimSuffix(filename, ".tg"))
+		module.Files[filename] = parseProgram(t, filename, fmt.Sprintf("import shared.%s\n\nconst %s = 1", strings.ToLower(name), name))
+	}
+	for _, subModuleName := range rotate(subModules) {
+		name := strings.ToUpper(subModuleName)
+		module.SubModules[subModuleName] = ast.NewModule("api/"+subModuleName, map[string]*ast.ProgramNode{
+			"types.tg": parseProgram(t, "types.tg", fmt.Sprintf("import shared.common\n\nconst %s = 1", name)),
+		})
+	}
+	return module
+}
+
+func TestModuleOrdering(t *testing.T) {
+	for seed := 0; seed < 20; seed++ {
+		module := shuffledModule(t, seed)
+
+		if got := strings.Join(module.FileNames(), ","); got != "alpha.tg,beta.tg,mid.tg,zeta.tg" {
+			t.Fatalf("FileNames: unexpected order %s", got)
+		}
+		if got := strings.Join(module.SubModuleNames(), ","); got != "auth,billing,users" {
+			t.Fatalf("SubModuleNames: unexpected order %s", got)
+		}
+		if got := strings.Join(module.AllImports(), ","); got != "shared.alpha,shared.beta,shared.common,shared.mid,shared.zeta" {
+			t.Fatalf("AllImports: unexpected order %s", got)
+		}
+
+		var names []string
+		for _, decl := range module.AllDeclarations() {
+			names = append(names, decl.DeclName())
+		}
+		if got := strings.Join(names, ","); got != "ALPHA,BETA,MID,ZETA,AUTH,BILLING,USERS" {
+			t.Fatalf("AllDeclarations: unexpected order %s", got)
+		}
+
+		if module.String() != shuffledModule(t, seed+1).String() {
+			t.Fatal("String: expected the same output regardless of insertion order")
+		}
+	}
+}
@@ -4,9 +4,9 @@ import "fmt"
 
 // Position represents a position in the source code
 type Position struct {
-	Filename string
-	Line     int
-	Column   int
+	Filename string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
 }
 
 func (p Position) String() string {
@@ -16,9 +16,26 @@ func (p Position) String() string {
 	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
+// Span is the extent of a node in the source, from its first character to
+// just after its last one
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) String() string {
+	if s.Start.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d-%d:%d", s.Start.Filename, s.Start.Line, s.Start.Column, s.End.Line, s.End.Column)
+	}
+	return fmt.Sprintf("%d:%d-%d:%d", s.Start.Line, s.Start.Column, s.End.Line, s.End.Column)
+}
+
 // Node is the base interface for all AST nodes
 type Node interface {
 	Pos() Position
+	// Span returns the extent of the node; both ends are zero for nodes that
+	// weren't parsed
+	Span() Span
 	String() string
 }
 
@@ -26,6 +43,8 @@ type Node interface {
 type Declaration interface {
 	Node
 	DeclNode()
+	// DeclName returns the declared name
+	DeclName() string
 }
 
 // Type represents any type expression
@@ -36,9 +55,16 @@ type Type interface {
 
 // BaseNode provides common functionality for AST nodes
 type BaseNode struct {
+	// Position is where the node starts
 	Position Position
+	// End is the position just after the node's last character
+	End Position
 }
 
 func (n *BaseNode) Pos() Position {
 	return n.Position
+}
+
+func (n *BaseNode) Span() Span {
+	return Span{Start: n.Position, End: n.End}
 }
\ No newline at end of file
@@ -10,6 +10,10 @@ type ProgramNode struct {
 	BaseNode
 	Imports      []*ImportNode
 	Declarations []Declaration
+	// Comments are the // and /* */ comments of the file, in source order.
+	// They are not children of the declarations they are next to, so Walk
+	// doesn't visit them; the printer puts them back by position.
+	Comments []*CommentNode
 }
 
 func (n *ProgramNode) String() string {
@@ -37,4 +41,15 @@ type ImportNode struct {
 
 func (n *ImportNode) String() string {
 	return fmt.Sprintf("import %s", n.Path)
-}
\ No newline at end of file
+}
+
+// CommentNode represents a // or /* */ comment
+type CommentNode struct {
+	BaseNode
+	// Text is the comment as written, with its // or /* */ markers
+	Text string
+}
+
+func (n *CommentNode) String() string {
+	return n.Text
+}
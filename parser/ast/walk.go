@@ -0,0 +1,93 @@
+package ast
+
+// Walk traverses the tree rooted at node depth-first, in source order,
+// calling visit for each node. When visit returns false, the node's
+// children are skipped.
+//
+// The children of a node are:
+//   - ProgramNode: its imports, then its declarations
+//   - StructNode: its fields and embeddings, in source order; EnumNode: its
+//     variants
+//   - EmbedNode: its embedded type
+//   - FieldNode: its type, then its attributes; TypeAliasNode: its type
+//   - EnumVariantNode: its payload type, if any, then its attributes
+//   - ConstantNode and AttributeNode: their value, if any
+//   - ArrayType and OptionalType: their element type; MapType: its key and
+//     value types
+//
+// Other nodes are leaves. Nil nodes are skipped.
+func Walk(node Node, visit func(Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *ProgramNode:
+		for _, imp := range n.Imports {
+			Walk(imp, visit)
+		}
+		for _, decl := range n.Declarations {
+			Walk(decl, visit)
+		}
+	case *StructNode:
+		for _, member := range n.Members() {
+			Walk(member, visit)
+		}
+	case *EmbedNode:
+		Walk(n.Type, visit)
+	case *FieldNode:
+		Walk(n.Type, visit)
+		for _, attribute := range n.Attributes {
+			Walk(attribute, visit)
+		}
+	case *EnumNode:
+		for _, variant := range n.Variants {
+			Walk(variant, visit)
+		}
+	case *EnumVariantNode:
+		Walk(n.Payload, visit)
+		for _, attribute := range n.Attributes {
+			Walk(attribute, visit)
+		}
+	case *AttributeNode:
+		Walk(n.Value, visit)
+	case *TypeAliasNode:
+		Walk(n.Type, visit)
+	case *ConstantNode:
+		Walk(n.Value, visit)
+	case *ArrayType:
+		Walk(n.ElementType, visit)
+	case *MapType:
+		Walk(n.KeyType, visit)
+		Walk(n.ValueType, visit)
+	case *OptionalType:
+		Walk(n.ElementType, visit)
+	}
+}
+
+// WalkTypes calls visit for each type expression under node, such as a
+// field or a declaration, in the order of Walk. When visit returns false,
+// the type's element, key and value types are skipped.
+func WalkTypes(node Node, visit func(Type) bool) {
+	Walk(node, func(n Node) bool {
+		if t, ok := n.(Type); ok {
+			return visit(t)
+		}
+		return true
+	})
+}
+
+// Inspect calls visit for each node of type T in the tree rooted at node,
+// in the order of Walk, such as every *NamedType a declaration refers to:
+//
+//	ast.Inspect(decl, func(named *ast.NamedType) {
+//		referenced[named.Name] = true
+//	})
+func Inspect[T Node](node Node, visit func(T)) {
+	Walk(node, func(n Node) bool {
+		if t, ok := n.(T); ok {
+			visit(t)
+		}
+		return true
+	})
+}
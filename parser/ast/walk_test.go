@@ -0,0 +1,169 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// everyKind has a node of every kind but OptionalType, which the parser
+// doesn't produce; parseEveryKind adds one
+const everyKind = `import auth
+
+const LIMIT = 10
+const NAME = "shop"
+
+struct Order {
+  buyer: auth.User
+  ...auth.Audit
+  tags: ?[]string
+}
+
+enum Status {
+  pending
+  shipped: [string]int32
+}
+
+type Ids = []int64
+`
+
+func parseEveryKind(t *testing.T) *ast.ProgramNode {
+	t.Helper()
+
+	program, err := parser.Parse(strings.NewReader(everyKind), "order.tg")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	program.Declarations = append(program.Declarations, &ast.TypeAliasNode{
+		Name: "Maybe",
+		Type: &ast.OptionalType{ElementType: &ast.PrimitiveType{Name: "string"}},
+	})
+	return program
+}
+
+// describe names a node by its Go type and its source
+func describe(node ast.Node) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	if _, ok := node.(*ast.ProgramNode); ok {
+		return name
+	}
+	return name + " " + strings.SplitN(node.String(), "\n", 2)[0]
+}
+
+func TestWalk(t *testing.T) {
+	program := parseEveryKind(t)
+
+	var visited []string
+	ast.Walk(program, func(node ast.Node) bool {
+		visited = append(visited, describe(node))
+		return true
+	})
+
+	expected := []string{
+		"ProgramNode",
+		"ImportNode import auth",
+		"ConstantNode const LIMIT = 10",
+		"IntConstant 10",
+		`ConstantNode const NAME = "shop"`,
+		`StringConstant "shop"`,
+		"StructNode struct Order {",
+		"FieldNode buyer: auth.User",
+		"NamedType auth.User",
+		"EmbedNode ...auth.Audit",
+		"NamedType auth.Audit",
+		"FieldNode tags: ?[]string",
+		"ArrayType []string",
+		"PrimitiveType string",
+		"EnumNode enum Status {",
+		"EnumVariantNode pending",
+		"EnumVariantNode shipped: [string]int32",
+		"MapType [string]int32",
+		"PrimitiveType string",
+		"PrimitiveType int32",
+		"TypeAliasNode type Ids = []int64",
+		"ArrayType []int64",
+		"PrimitiveType int64",
+		"TypeAliasNode type Maybe = ?string",
+		"OptionalType ?string",
+		"PrimitiveType string",
+	}
+	if strings.Join(visited, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected nodes:\n%s\n\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(visited, "\n"))
+	}
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	program := parseEveryKind(t)
+
+	// Not descending into declarations visits the program's direct children
+	var visited []string
+	ast.Walk(program, func(node ast.Node) bool {
+		visited = append(visited, describe(node))
+		_, isDecl := node.(ast.Declaration)
+		return !isDecl
+	})
+	expected := "ProgramNode, ImportNode import auth, ConstantNode const LIMIT = 10, ConstantNode const NAME = \"shop\", StructNode struct Order {, EnumNode enum Status {, TypeAliasNode type Ids = []int64, TypeAliasNode type Maybe = ?string"
+	if got := strings.Join(visited, ", "); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+
+	ast.Walk(nil, func(ast.Node) bool {
+		t.Error("expected no node for nil")
+		return true
+	})
+}
+
+func TestWalkTypes(t *testing.T) {
+	program := parseEveryKind(t)
+	order := program.Declarations[2].(*ast.StructNode)
+
+	var types []string
+	ast.WalkTypes(order, func(typ ast.Type) bool {
+		types = append(types, typ.String())
+		return true
+	})
+	if got := strings.Join(types, " "); got != "auth.User auth.Audit []string string" {
+		t.Errorf("unexpected types of Order: %s", got)
+	}
+
+	// Returning false stops at the outermost type
+	types = nil
+	ast.WalkTypes(order.Fields[1], func(typ ast.Type) bool {
+		types = append(types, typ.String())
+		return false
+	})
+	if got := strings.Join(types, " "); got != "[]string" {
+		t.Errorf("expected only the field's type, got %s", got)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	program := parseEveryKind(t)
+
+	var named []string
+	ast.Inspect(program, func(n *ast.NamedType) {
+		named = append(named, n.Name)
+	})
+	if got := strings.Join(named, " "); got != "auth.User auth.Audit" {
+		t.Errorf("unexpected named types: %s", got)
+	}
+
+	var primitives []string
+	ast.Inspect(program.Declarations[3], func(p *ast.PrimitiveType) {
+		primitives = append(primitives, p.Name)
+	})
+	if got := strings.Join(primitives, " "); got != "string int32" {
+		t.Errorf("unexpected primitives of Status: %s", got)
+	}
+
+	var declarations []string
+	ast.Inspect(program, func(decl ast.Declaration) {
+		declarations = append(declarations, describe(decl))
+	})
+	if len(declarations) != 6 {
+		t.Errorf("expected 6 declarations, got %v", declarations)
+	}
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ParseOptions control which files and directories of a module are parsed
+type ParseOptions struct {
+	// ExcludedDirs are directories skipped with everything under them, such
+	// as generated code inside the module
+	ExcludedDirs []string
+	// Exclude are gitignore-style patterns of files and directories to skip,
+	// relative to the module directory; see Exclusions
+	Exclude []string
+	// Partial returns the module of the files that parsed along with the
+	// error when others fail, for tools that can work on part of a module
+	Partial bool
+}
+
+// Exclusions decides which files and directories under a module directory
+// are skipped. Directories are skipped when ShouldSkipDirectory says so, then
+// the exclude patterns apply in order, the last matching one deciding, as in
+// a .gitignore file:
+//
+//   - experimental/ skips every directory named experimental, and *_draft.tg
+//     every file or directory matching it, at any depth
+//   - a pattern with a slash other than at its end, such as /legacy or
+//     billing/*.tg, is relative to the module directory
+//   - * and ? match within a name, and ** matches any number of directories,
+//     as in **/internal/*.tg
+//   - a pattern ending in a slash only matches directories
+//   - a pattern starting with ! includes what it matches again, such as
+//     !vendor/ for the vendor directories that are skipped by default
+//
+// Nothing under a skipped directory is parsed, so a file can't be included
+// again when its directory is skipped.
+type Exclusions struct {
+	root     string
+	dirs     map[string]bool
+	patterns []excludePattern
+	matched  []bool
+}
+
+// excludePattern is a compiled exclude pattern
+type excludePattern struct {
+	text     string
+	negated  bool
+	dirOnly  bool
+	segments []string
+}
+
+// Exclusions compiles the options for the module directory modulePath
+func (o ParseOptions) Exclusions(modulePath string) (*Exclusions, error) {
+	e := &Exclusions{root: modulePath, dirs: make(map[string]bool)}
+	for _, dir := range o.ExcludedDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve excluded directory %s: %w", dir, err)
+		}
+		e.dirs[abs] = true
+	}
+	for _, text := range o.Exclude {
+		pattern, err := compileExcludePattern(text)
+		if err != nil {
+			return nil, err
+		}
+		e.patterns = append(e.patterns, pattern)
+	}
+	e.matched = make([]bool, len(e.patterns))
+	return e, nil
+}
+
+// compileExcludePattern compiles a gitignore-style pattern
+func compileExcludePattern(text string) (excludePattern, error) {
+	pattern := excludePattern{text: text}
+	rest := text
+	if strings.HasPrefix(rest, "!") {
+		pattern.negated = true
+		rest = rest[1:]
+	}
+	if strings.HasSuffix(rest, "/") {
+		pattern.dirOnly = true
+		rest = strings.TrimSuffix(rest, "/")
+	}
+	anchored := strings.Contains(rest, "/")
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		return pattern, fmt.Errorf("invalid exclude pattern %q: it matches no name", text)
+	}
+
+	pattern.segments = strings.Split(rest, "/")
+	for _, segment := range pattern.segments {
+		if segment == "" {
+			return pattern, fmt.Errorf("invalid exclude pattern %q: empty path element", text)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return pattern, fmt.Errorf("invalid exclude pattern %q: %w", text, err)
+		}
+	}
+	// A pattern without a slash matches a name at any depth
+	if !anchored {
+		pattern.segments = append([]string{"**"}, pattern.segments...)
+	}
+	return pattern, nil
+}
+
+// Skip reports whether the file or directory at path, which is under the
+// module directory, is skipped. A nil Exclusions only skips the directories
+// that ShouldSkipDirectory names.
+func (e *Exclusions) Skip(filePath string, isDir bool) bool {
+	if e == nil {
+		return isDir && ShouldSkipDirectory(filepath.Base(filePath))
+	}
+	if isDir && len(e.dirs) > 0 {
+		if abs, err := filepath.Abs(filePath); err == nil && e.dirs[abs] {
+			return true
+		}
+	}
+
+	rel, err := filepath.Rel(e.root, filePath)
+	if err != nil || rel == "." {
+		return false
+	}
+	names := strings.Split(filepath.ToSlash(rel), "/")
+
+	skip := isDir && ShouldSkipDirectory(names[len(names)-1])
+	for i, pattern := range e.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(pattern.segments, names) {
+			e.matched[i] = true
+			skip = !pattern.negated
+		}
+	}
+	return skip
+}
+
+// Unmatched returns the exclude patterns that have matched no file or
+// directory passed to Skip
+func (e *Exclusions) Unmatched() []string {
+	var unmatched []string
+	for i, pattern := range e.patterns {
+		if !e.matched[i] {
+			unmatched = append(unmatched, pattern.text)
+		}
+	}
+	return unmatched
+}
+
+// matchSegments matches the names of a path with the segments of a pattern,
+// where ** matches any number of names
+func matchSegments(segments, names []string) bool {
+	if len(segments) == 0 {
+		return len(names) == 0
+	}
+	if segments[0] == "**" {
+		for i := 0; i <= len(names); i++ {
+			if matchSegments(segments[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	matched, _ := path.Match(segments[0], names[0])
+	return matched && matchSegments(segments[1:], names[1:])
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExclusionsSkip(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		isDir    bool
+		skip     bool
+	}{
+		{nil, "user.tg", false, false},
+		{nil, "vendor", true, true},
+		{nil, ".cache", true, true},
+		{[]string{"experimental/"}, "experimental", true, true},
+		{[]string{"experimental/"}, "billing/experimental", true, true},
+		{[]string{"experimental/"}, "experimental", false, false},
+		{[]string{"*_draft.tg"}, "user_draft.tg", false, true},
+		{[]string{"*_draft.tg"}, "billing/invoice_draft.tg", false, true},
+		{[]string{"*_draft.tg"}, "user.tg", false, false},
+		{[]string{"/legacy"}, "legacy", true, true},
+		{[]string{"/legacy"}, "billing/legacy", true, false},
+		{[]string{"billing/*.tg"}, "billing/invoice.tg", false, true},
+		{[]string{"billing/*.tg"}, "shop/billing/invoice.tg", false, false},
+		{[]string{"**/internal/*.tg"}, "internal/secret.tg", false, true},
+		{[]string{"**/internal/*.tg"}, "a/b/internal/secret.tg", false, true},
+		{[]string{"a/**/z.tg"}, "a/z.tg", false, true},
+		{[]string{"a/**/z.tg"}, "a/b/c/z.tg", false, true},
+		{[]string{"*.tg", "!user.tg"}, "user.tg", false, false},
+		{[]string{"*.tg", "!user.tg"}, "order.tg", false, true},
+		{[]string{"!user.tg", "*.tg"}, "user.tg", false, true},
+		{[]string{"!vendor/"}, "vendor", true, false},
+		{[]string{"!.schemas/"}, ".schemas", true, false},
+	}
+
+	root := t.TempDir()
+	for _, tt := range tests {
+		exclusions, err := ParseOptions{Exclude: tt.patterns}.Exclusions(root)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.patterns, err)
+		}
+		if skip := exclusions.Skip(filepath.Join(root, tt.path), tt.isDir); skip != tt.skip {
+			t.Errorf("%v: Skip(%s, dir %v) = %v, expected %v", tt.patterns, tt.path, tt.isDir, skip, tt.skip)
+		}
+	}
+}
+
+func TestExclusionsInvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"", "!", "/", "a//b", "[a-"} {
+		if _, err := (ParseOptions{Exclude: []string{pattern}}).Exclusions("."); err == nil || !strings.Contains(err.Error(), "invalid exclude pattern") {
+			t.Errorf("%q: expected an invalid pattern error, got %v", pattern, err)
+		}
+	}
+}
+
+func TestParseModuleWithOptions(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{
+		"user.tg":                        "struct User {\n  id: int64\n}\n",
+		"user_draft.tg":                  "struct UserDraft {\n  id: int64\n}\n",
+		"billing/invoice.tg":             "struct Invoice {\n  id: int64\n}\n",
+		"billing/refund_draft.tg":        "struct Refund {\n  id: int64\n}\n",
+		"billing/experimental/credit.tg": "struct Credit {\n  id: int64\n}\n",
+		"experimental/beta/feature.tg":   "struct Feature {\n  id: int64\n}\n",
+		"vendor/shared/money.tg":         "struct Money {\n  amount: int64\n}\n",
+		"gen/generated.tg":               "struct Generated {\n  id: int64\n}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := ParseOptions{
+		ExcludedDirs: []string{filepath.Join(dir, "gen")},
+		Exclude:      []string{"experimental/", "*_draft.tg", "!vendor/", "*.proto"},
+	}
+	module, err := ParseModuleWithOptions(dir, options)
+	if err != nil {
+		t.Fatalf("ParseModuleWithOptions failed: %v", err)
+	}
+
+	if files := module.FileNames(); !reflect.DeepEqual(files, []string{"user.tg"}) {
+		t.Errorf("expected only user.tg at the root, got %v", files)
+	}
+	if names := module.SubModuleNames(); !reflect.DeepEqual(names, []string{"billing", "vendor"}) {
+		t.Errorf("expected the billing and vendor submodules, got %v", names)
+	}
+	billing := module.SubModules["billing"]
+	if files := billing.FileNames(); !reflect.DeepEqual(files, []string{"invoice.tg"}) {
+		t.Errorf("expected only invoice.tg in billing, got %v", files)
+	}
+	if len(billing.SubModules) != 0 {
+		t.Errorf("expected the nested experimental directory to be skipped, got %v", billing.SubModuleNames())
+	}
+
+	// Skip records the patterns that matched, to report typos
+	exclusions, err := options.Exclusions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exclusions.Skip(filepath.Join(dir, "experimental"), true)
+	exclusions.Skip(filepath.Join(dir, "user_draft.tg"), false)
+	if unmatched := exclusions.Unmatched(); !reflect.DeepEqual(unmatched, []string{"!vendor/", "*.proto"}) {
+		t.Errorf("expected unmatched patterns, got %v", unmatched)
+	}
+}
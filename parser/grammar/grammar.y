@@ -3,8 +3,46 @@ package grammar
 
 import (
 	"fmt"
+	"math"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
 )
+
+// span returns the base of a node from the start of its first token to the
+// end of its last
+func span(start, end ast.Position) ast.BaseNode {
+	return ast.BaseNode{Position: start, End: end}
+}
+
+// endOf returns the end of a field or variant: that of its last attribute,
+// else of its wire name, else of its head
+func endOf(head, wireName ast.Position, attributes []*ast.AttributeNode) ast.Position {
+	if len(attributes) > 0 {
+		return attributes[len(attributes)-1].End
+	}
+	if wireName.Line > 0 {
+		return wireName
+	}
+	return head
+}
+
+// skipToDeclaration discards tokens from the lookahead char up to the next
+// declaration keyword or the end of the file, and returns that token as the
+// new lookahead
+func skipToDeclaration(lex yyLexer, lval *yySymType, char, token int) (int, int) {
+	for {
+		switch char {
+		case 0, STRUCT, ENUM, TYPE, CONST:
+			return char, token
+		}
+		char, token = yylex1(lex, lval)
+	}
+}
+
+// Syntax errors list the tokens the parser expected, which Lexer.Error
+// turns into readable messages
+func init() {
+	yyErrorVerbose = true
+}
 %}
 
 %union {
@@ -16,47 +54,57 @@ import (
 	imports  []*ast.ImportNode
 	struct_  *ast.StructNode
 	field    *ast.FieldNode
-	fields   []*ast.FieldNode
+	embed    *ast.EmbedNode
 	enum_    *ast.EnumNode
 	variant  *ast.EnumVariantNode
 	variants []*ast.EnumVariantNode
+	doc      []string
+	pos      ast.Position
+	end      ast.Position
+	attr     *ast.AttributeNode
+	attrs    []*ast.AttributeNode
 	typedef  *ast.TypeAliasNode
 	const_   *ast.ConstantNode
 	constval ast.ConstantValue
 	type_    ast.Type
 	ident    string
 	str      string
-	num      int64
+	num      uint64
+	float    float64
 }
 
 %token <ident> IDENTIFIER
 %token <str>   STRING_LITERAL
 %token <num>   NUMBER_LITERAL
+%token <float> FLOAT_LITERAL
 
 %token IMPORT STRUCT ENUM TYPE CONST
+%token TRUE FALSE
 %token LBRACE RBRACE LPAREN RPAREN LBRACKET RBRACKET
-%token COLON SEMICOLON COMMA EQUALS QUESTION DOT
+%token COLON SEMICOLON COMMA EQUALS QUESTION DOT ELLIPSIS MINUS AT
 %token COMMENT
 
 // Primitive types
 %token INT8 INT16 INT32 INT64 INT BIGINT
 %token NAT8 NAT16 NAT32 NAT64 NAT BIGNAT
 %token FLOAT32 FLOAT64 DECIMAL
-%token STRING BOOL JSON
+%token STRING BOOL JSON UUID
 %token TIME DATE DATETIME TIMETZ DATETZ DATETIMETZ
 
 %type <program>  program
 %type <imports>  import_list
 %type <import_>  import_stmt
-%type <str>      module_path qualified_name
+%type <str>      module_path qualified_name wire_name
 %type <decls>    declaration_list
 %type <decl>     declaration
-%type <struct_>  struct_decl
-%type <fields>   field_list non_empty_field_list
-%type <field>    field
+%type <struct_>  struct_decl struct_body
+%type <field>    field field_head
+%type <embed>    embed
 %type <enum_>    enum_decl
 %type <variants> variant_list
-%type <variant>  variant
+%type <variant>  variant variant_head
+%type <attrs>    attribute_list
+%type <attr>     attribute
 %type <typedef>  type_alias
 %type <const_>   const_decl
 %type <constval> constant_value
@@ -93,8 +141,8 @@ import_list:
 import_stmt:
     IMPORT module_path {
         $$ = &ast.ImportNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
-            Path: $2,
+            BaseNode: span($<pos>1, $<end>2),
+            Path:     $2,
         }
     }
 
@@ -104,15 +152,30 @@ module_path:
     }
 |   module_path DOT IDENTIFIER {
         $$ = $1 + "." + $3
+        $<end>$ = $<end>3
     }
 
+// A declaration with a syntax error is dropped: the parser skips to the
+// next declaration keyword and carries on, so a file reports all its syntax
+// errors at once. Resetting Errflag after the skip reports an error in the
+// very next declaration too, where yacc would stay silent for three tokens
 declaration_list:
     declaration {
         $$ = []ast.Declaration{$1}
     }
+|   error {
+        $$ = nil
+        yyrcvr.char, yytoken = skipToDeclaration(yylex, &yyrcvr.lval, yyrcvr.char, yytoken)
+        Errflag = 0
+    }
 |   declaration_list declaration {
         $$ = append($1, $2)
     }
+|   declaration_list error {
+        $$ = $1
+        yyrcvr.char, yytoken = skipToDeclaration(yylex, &yyrcvr.lval, yyrcvr.char, yytoken)
+        Errflag = 0
+    }
 
 declaration:
     struct_decl  { $$ = $1 }
@@ -121,54 +184,112 @@ declaration:
 |   const_decl   { $$ = $1 }
 
 struct_decl:
-    STRUCT IDENTIFIER LBRACE field_list RBRACE {
-        $$ = &ast.StructNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
-            Name:   $2,
-            Fields: $4,
-        }
+    STRUCT IDENTIFIER LBRACE struct_body RBRACE {
+        $4.BaseNode = span($<pos>1, $<end>5)
+        $4.Name = $2
+        $4.Doc = $<doc>1
+        $$ = $4
     }
 
-field_list:
+// The body collects the fields and embeddings; an embedding records how
+// many fields come before it
+struct_body:
     /* empty */ {
-        $$ = nil
+        $$ = &ast.StructNode{}
     }
-|   non_empty_field_list {
+|   struct_body field {
+        $1.Fields = append($1.Fields, $2)
         $$ = $1
     }
-
-non_empty_field_list:
-    field {
-        $$ = []*ast.FieldNode{$1}
+|   struct_body embed {
+        $2.Index = len($1.Fields)
+        $1.Embeds = append($1.Embeds, $2)
+        $$ = $1
     }
-|   non_empty_field_list field {
-        $$ = append($1, $2)
+
+embed:
+    ELLIPSIS qualified_name {
+        $$ = &ast.EmbedNode{
+            BaseNode: span($<pos>1, $<end>2),
+            Type:     &ast.NamedType{BaseNode: span($<pos>2, $<end>2), Name: $2},
+        }
     }
 
 field:
+    field_head wire_name attribute_list {
+        $1.WireName = $2
+        $1.Attributes = $3
+        $1.End = endOf($1.End, $<end>2, $3)
+        $$ = $1
+    }
+
+// The field is built from its name and type; field adds its wire name and
+// attributes and extends its span over them
+field_head:
     IDENTIFIER COLON type_expr {
         $$ = &ast.FieldNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $3.Span().End),
             Name:     $1,
             Type:     $3,
             Optional: false,
+            Doc:      $<doc>1,
         }
     }
 |   IDENTIFIER COLON QUESTION type_expr {
         $$ = &ast.FieldNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $4.Span().End),
             Name:     $1,
             Type:     $4,
             Optional: true,
+            Doc:      $<doc>1,
+        }
+    }
+
+// A wire name, written = "name", replaces the name of a field or variant in
+// JSON
+wire_name:
+    /* empty */ {
+        $$ = ""
+        $<end>$ = ast.Position{}
+    }
+|   EQUALS STRING_LITERAL {
+        if $2 == "" {
+            yylex.(*Lexer).Error("wire name must not be empty")
+        }
+        $$ = $2
+        $<end>$ = $<end>2
+    }
+
+attribute_list:
+    /* empty */ {
+        $$ = nil
+    }
+|   attribute_list attribute {
+        $$ = append($1, $2)
+    }
+
+attribute:
+    AT IDENTIFIER {
+        $$ = &ast.AttributeNode{
+            BaseNode: span($<pos>1, $<end>2),
+            Name:     $2,
+        }
+    }
+|   AT IDENTIFIER LPAREN constant_value RPAREN {
+        $$ = &ast.AttributeNode{
+            BaseNode: span($<pos>1, $<end>5),
+            Name:  $2,
+            Value: $4,
         }
     }
 
 enum_decl:
     ENUM IDENTIFIER LBRACE variant_list RBRACE {
         $$ = &ast.EnumNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $<end>5),
             Name:     $2,
             Variants: $4,
+            Doc:      $<doc>1,
         }
     }
 
@@ -181,27 +302,38 @@ variant_list:
     }
 
 variant:
+    variant_head wire_name attribute_list {
+        $1.WireName = $2
+        $1.Attributes = $3
+        $1.End = endOf($1.End, $<end>2, $3)
+        $$ = $1
+    }
+
+variant_head:
     IDENTIFIER {
         $$ = &ast.EnumVariantNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $<end>1),
             Name:    $1,
             Payload: nil,
+            Doc:     $<doc>1,
         }
     }
 |   IDENTIFIER COLON type_expr {
         $$ = &ast.EnumVariantNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $3.Span().End),
             Name:    $1,
             Payload: $3,
+            Doc:     $<doc>1,
         }
     }
 
 type_alias:
     TYPE IDENTIFIER EQUALS type_expr {
         $$ = &ast.TypeAliasNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $4.Span().End),
             Name: $2,
             Type: $4,
+            Doc:  $<doc>1,
         }
     }
 
@@ -212,43 +344,89 @@ const_decl:
             return 1
         }
         $$ = &ast.ConstantNode{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $4.Span().End),
             Name:  $2,
             Value: $4,
+            Doc:   $<doc>1,
         }
     }
 
 constant_value:
     NUMBER_LITERAL {
+        if $1 > math.MaxInt64 {
+            yylex.(*Lexer).Error(fmt.Sprintf("integer constant %d overflows int64", $1))
+            return 1
+        }
+        $$ = &ast.IntConstant{
+            BaseNode: span($<pos>1, $<end>1),
+            Value: int64($1),
+            Literal: $<str>1,
+        }
+    }
+|   MINUS NUMBER_LITERAL {
+        if $2 > math.MaxInt64+1 {
+            yylex.(*Lexer).Error(fmt.Sprintf("integer constant -%d overflows int64", $2))
+            return 1
+        }
+        // Negating in uint64 keeps math.MinInt64, whose magnitude has no int64
         $$ = &ast.IntConstant{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $<end>2),
+            Value: int64(-$2),
+        }
+        if $<str>2 != "" {
+            $$.(*ast.IntConstant).Literal = "-" + $<str>2
+        }
+    }
+|   FLOAT_LITERAL {
+        $$ = &ast.FloatConstant{
+            BaseNode: span($<pos>1, $<end>1),
             Value: $1,
+            Literal: $<str>1,
+        }
+    }
+|   MINUS FLOAT_LITERAL {
+        $$ = &ast.FloatConstant{
+            BaseNode: span($<pos>1, $<end>2),
+            Value: -$2,
+            Literal: "-" + $<str>2,
         }
     }
 |   STRING_LITERAL {
         $$ = &ast.StringConstant{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $<end>1),
             Value: $1,
         }
     }
+|   TRUE {
+        $$ = &ast.BoolConstant{
+            BaseNode: span($<pos>1, $<end>1),
+            Value: true,
+        }
+    }
+|   FALSE {
+        $$ = &ast.BoolConstant{
+            BaseNode: span($<pos>1, $<end>1),
+            Value: false,
+        }
+    }
 
 type_expr:
     primitive_type { $$ = $1 }
 |   qualified_name {
         $$ = &ast.NamedType{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $<end>1),
             Name: $1,
         }
     }
 |   LBRACKET RBRACKET type_expr {
         $$ = &ast.ArrayType{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode:    span($<pos>1, $3.Span().End),
             ElementType: $3,
         }
     }
 |   LBRACKET type_expr RBRACKET type_expr {
         $$ = &ast.MapType{
-            BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+            BaseNode: span($<pos>1, $4.Span().End),
             KeyType: $2, ValueType: $4,
         }
     }
@@ -259,32 +437,34 @@ qualified_name:
     }
 |   qualified_name DOT IDENTIFIER {
         $$ = $1 + "." + $3
+        $<end>$ = $<end>3
     }
 
 primitive_type:
-    INT8       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int8"} }
-|   INT16      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int16"} }
-|   INT32      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int32"} }
-|   INT64      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int64"} }
-|   INT        { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int"} }
-|   BIGINT     { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bigint"} }
-|   NAT8       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat8"} }
-|   NAT16      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat16"} }
-|   NAT32      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat32"} }
-|   NAT64      { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat64"} }
-|   NAT        { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat"} }
-|   BIGNAT     { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bignat"} }
-|   FLOAT32    { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "float32"} }
-|   FLOAT64    { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "float64"} }
-|   DECIMAL    { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "decimal"} }
-|   STRING     { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "string"} }
-|   BOOL       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bool"} }
-|   JSON       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "json"} }
-|   TIME       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "time"} }
-|   DATE       { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "date"} }
-|   DATETIME   { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetime"} }
-|   TIMETZ     { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "timetz"} }
-|   DATETZ     { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetz"} }
-|   DATETIMETZ { $$ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetimetz"} }
+    INT8       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "int8"} }
+|   INT16      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "int16"} }
+|   INT32      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "int32"} }
+|   INT64      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "int64"} }
+|   INT        { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "int"} }
+|   BIGINT     { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "bigint"} }
+|   NAT8       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "nat8"} }
+|   NAT16      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "nat16"} }
+|   NAT32      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "nat32"} }
+|   NAT64      { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "nat64"} }
+|   NAT        { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "nat"} }
+|   BIGNAT     { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "bignat"} }
+|   FLOAT32    { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "float32"} }
+|   FLOAT64    { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "float64"} }
+|   DECIMAL    { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "decimal"} }
+|   STRING     { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "string"} }
+|   BOOL       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "bool"} }
+|   JSON       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "json"} }
+|   UUID       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "uuid"} }
+|   TIME       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "time"} }
+|   DATE       { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "date"} }
+|   DATETIME   { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "datetime"} }
+|   TIMETZ     { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "timetz"} }
+|   DATETZ     { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "datetz"} }
+|   DATETIMETZ { $$ = &ast.PrimitiveType{BaseNode: span($<pos>1, $<end>1), Name: "datetimetz"} }
 
 %%
\ No newline at end of file
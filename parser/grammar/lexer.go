@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"text/scanner"
 	"unicode"
 	
@@ -25,6 +27,22 @@ func (p Position) String() string {
 	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
+// SyntaxError is a lexical or grammar error at a position in the source
+type SyntaxError struct {
+	Pos     Position
+	Message string
+	// Got is the text of the token the parser didn't expect, empty at the
+	// end of the file; only grammar errors set it
+	Got string
+	// Expected describes the tokens the parser would have accepted instead,
+	// e.g. "name" or "'}'", when there are few enough to list
+	Expected []string
+}
+
+func (e SyntaxError) String() string {
+	return fmt.Sprintf("%s: %s", e.Pos.String(), e.Message)
+}
+
 // Keywords maps keyword strings to their token types
 var Keywords = map[string]int{
 	"import":     IMPORT,
@@ -32,6 +50,8 @@ var Keywords = map[string]int{
 	"enum":       ENUM,
 	"type":       TYPE,
 	"const":      CONST,
+	"true":       TRUE,
+	"false":      FALSE,
 	
 	// Primitive types
 	"int8":       INT8,
@@ -52,6 +72,7 @@ var Keywords = map[string]int{
 	"string":     STRING,
 	"bool":       BOOL,
 	"json":       JSON,
+	"uuid":       UUID,
 	"time":       TIME,
 	"date":       DATE,
 	"datetime":   DATETIME,
@@ -73,24 +94,39 @@ type Lexer struct {
 	scanner  scanner.Scanner
 	filename string
 	result   ast.Node
-	errors   []string
+	errors   []SyntaxError
+	// scanError is the error the scanner reported for the last token, if any
+	scanError string
+	// lastLine is the line of the last token
+	lastLine int
+	// token is the text of the last token, which a grammar error is about
+	token string
+	// doc holds the consecutive line comments read since the last token,
+	// which document the next token if it starts on the line below docEnd
+	doc    []string
+	docEnd int
+	// comments are all the comments read, for printers to put back
+	comments []*ast.CommentNode
 }
 
 // NewLexer creates a new lexer for goyacc
 func NewLexer(input io.Reader, filename string) *Lexer {
 	lex := &Lexer{
 		filename: filename,
-		errors:   make([]string, 0),
+		errors:   make([]SyntaxError, 0),
 	}
 	
 	lex.scanner.Init(input)
 	lex.scanner.Filename = filename
-	lex.scanner.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanStrings | scanner.ScanComments
+	lex.scanner.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings | scanner.ScanComments
 	
 	// Configure scanner for TypeGen syntax
 	lex.scanner.IsIdentRune = func(ch rune, i int) bool {
 		return unicode.IsLetter(ch) || (i > 0 && (unicode.IsDigit(ch) || ch == '_'))
 	}
+	lex.scanner.Error = func(s *scanner.Scanner, msg string) {
+		lex.scanError = msg
+	}
 	
 	return lex
 }
@@ -98,6 +134,7 @@ func NewLexer(input io.Reader, filename string) *Lexer {
 // Lex implements the goyacc lexer interface
 func (l *Lexer) Lex(lval *yySymType) int {
 	for {
+		l.scanError = ""
 		ch := l.scanner.Scan()
 		pos := Position{
 			Filename: l.filename,
@@ -105,11 +142,26 @@ func (l *Lexer) Lex(lval *yySymType) int {
 			Column:   l.scanner.Column,
 		}
 		
+		if ch != scanner.Comment {
+			lval.doc = l.takeDoc(pos.Line)
+			l.lastLine = pos.Line
+			l.token = l.scanner.TokenText()
+			lval.pos = ast.Position{Filename: pos.Filename, Line: pos.Line, Column: pos.Column}
+			lval.end = l.end()
+		}
+
 		switch ch {
 		case scanner.EOF:
 			return 0
 		case scanner.Comment:
-			// Skip comments - continue to next token
+			l.comments = append(l.comments, &ast.CommentNode{
+				BaseNode: ast.BaseNode{
+					Position: ast.Position{Filename: pos.Filename, Line: pos.Line, Column: pos.Column},
+					End:      l.end(),
+				},
+				Text: l.scanner.TokenText(),
+			})
+			l.addComment(l.scanner.TokenText(), pos.Line)
 			continue
 		case scanner.Ident:
 			text := l.scanner.TokenText()
@@ -120,10 +172,31 @@ func (l *Lexer) Lex(lval *yySymType) int {
 			return IDENTIFIER
 		case scanner.Int:
 			text := l.scanner.TokenText()
-			if val, err := strconv.ParseInt(text, 10, 64); err == nil {
+			// Hexadecimal, octal and binary literals keep their text, so
+			// generators can write them as they were written
+			base, literal := 10, ""
+			if len(text) > 2 && text[0] == '0' && strings.ContainsRune("xXoObB", rune(text[1])) {
+				base, literal = 0, text
+			}
+			if val, err := strconv.ParseUint(text, base, 64); err == nil {
 				lval.num = val
+				lval.str = literal
 				return NUMBER_LITERAL
 			}
+			if l.scanError != "" {
+				l.addError(pos, fmt.Sprintf("invalid number: %s: %s", text, l.scanError))
+			} else {
+				l.addError(pos, fmt.Sprintf("invalid number: %s", text))
+			}
+			continue
+		case scanner.Float:
+			text := l.scanner.TokenText()
+			// Floats keep their text, so "1e-9" is not reformatted as "1e-09"
+			if val, err := strconv.ParseFloat(text, 64); err == nil {
+				lval.float = val
+				lval.str = text
+				return FLOAT_LITERAL
+			}
 			l.addError(pos, fmt.Sprintf("invalid number: %s", text))
 			continue
 		case scanner.String:
@@ -161,7 +234,23 @@ func (l *Lexer) Lex(lval *yySymType) int {
 		case '?':
 			return QUESTION
 		case '.':
-			return DOT
+			// The scanner returns the dots of "..." one at a time
+			if l.scanner.Peek() != '.' {
+				return DOT
+			}
+			l.scanner.Next()
+			if l.scanner.Peek() != '.' {
+				l.addError(pos, "unexpected character: ..")
+				continue
+			}
+			l.scanner.Next()
+			l.token = "..."
+			lval.end = l.end()
+			return ELLIPSIS
+		case '-':
+			return MINUS
+		case '@':
+			return AT
 		default:
 			text := l.scanner.TokenText()
 			l.addError(pos, fmt.Sprintf("unexpected character: %s", text))
@@ -170,6 +259,39 @@ func (l *Lexer) Lex(lval *yySymType) int {
 	}
 }
 
+// end returns the position just after the last token
+func (l *Lexer) end() ast.Position {
+	pos := l.scanner.Pos()
+	return ast.Position{Filename: l.filename, Line: pos.Line, Column: pos.Column}
+}
+
+// addComment records a comment read at a line. Only line comments on lines
+// of their own are documentation: a comment after a token, or a block
+// comment, ends the comments read so far.
+func (l *Lexer) addComment(text string, line int) {
+	if line == l.lastLine || !strings.HasPrefix(text, "//") {
+		l.doc = nil
+		return
+	}
+	if len(l.doc) > 0 && line != l.docEnd+1 {
+		l.doc = nil
+	}
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "//"), " ")
+	l.doc = append(l.doc, strings.TrimRight(text, " \t\r"))
+	l.docEnd = line
+}
+
+// takeDoc returns the comments directly above a token starting at a line,
+// if any, and forgets the comments read so far
+func (l *Lexer) takeDoc(line int) []string {
+	doc := l.doc
+	l.doc = nil
+	if len(doc) == 0 || l.docEnd != line-1 {
+		return nil
+	}
+	return doc
+}
+
 // Error implements the goyacc error interface
 func (l *Lexer) Error(s string) {
 	pos := Position{
@@ -177,7 +299,77 @@ func (l *Lexer) Error(s string) {
 		Line:     l.scanner.Line,
 		Column:   l.scanner.Column,
 	}
-	l.errors = append(l.errors, pos.String() + ": " + s)
+	if !strings.HasPrefix(s, "syntax error") {
+		l.errors = append(l.errors, SyntaxError{Pos: pos, Message: s})
+		return
+	}
+
+	// goyacc writes "syntax error: unexpected TOKEN, expecting A or B", with
+	// the expected tokens only when there are at most four
+	err := SyntaxError{Pos: pos, Got: l.token}
+	if _, expecting, found := strings.Cut(s, ", expecting "); found {
+		for _, name := range strings.Split(expecting, " or ") {
+			description := describeToken(name)
+			if !slices.Contains(err.Expected, description) {
+				err.Expected = append(err.Expected, description)
+			}
+		}
+	}
+
+	got := "end of file"
+	if l.token != "" {
+		got = "'" + l.token + "'"
+	}
+	err.Message = "unexpected " + got
+	if len(err.Expected) > 0 {
+		err.Message += ", expected " + joinOr(err.Expected)
+	}
+	l.errors = append(l.errors, err)
+}
+
+// tokenDescriptions describes the tokens that aren't keywords in messages
+var tokenDescriptions = map[string]string{
+	"$end":           "end of file",
+	"IDENTIFIER":     "name",
+	"STRING_LITERAL": "string",
+	"NUMBER_LITERAL": "number",
+	"FLOAT_LITERAL":  "number",
+	"LBRACE":         "'{'",
+	"RBRACE":         "'}'",
+	"LPAREN":         "'('",
+	"RPAREN":         "')'",
+	"LBRACKET":       "'['",
+	"RBRACKET":       "']'",
+	"COLON":          "':'",
+	"SEMICOLON":      "';'",
+	"COMMA":          "','",
+	"EQUALS":         "'='",
+	"QUESTION":       "'?'",
+	"DOT":            "'.'",
+	"ELLIPSIS":       "'...'",
+	"MINUS":          "'-'",
+	"AT":             "'@'",
+}
+
+// describeToken returns how messages name a token of the grammar
+func describeToken(name string) string {
+	if description, ok := tokenDescriptions[name]; ok {
+		return description
+	}
+	for keyword, token := range Keywords {
+		if yyTokname(token) == name {
+			return "'" + keyword + "'"
+		}
+	}
+	return name
+}
+
+// joinOr joins descriptions as "a, b or c"
+func joinOr(descriptions []string) string {
+	if len(descriptions) == 1 {
+		return descriptions[0]
+	}
+	return strings.Join(descriptions[:len(descriptions)-1], ", ") + " or " + descriptions[len(descriptions)-1]
 }
 
 // Result returns the parsed AST
@@ -185,14 +377,20 @@ func (l *Lexer) Result() ast.Node {
 	return l.result
 }
 
-// Errors returns any parse errors
-func (l *Lexer) Errors() []string {
+// Errors returns the lexical and grammar errors with their positions, in
+// the order they were found
+func (l *Lexer) Errors() []SyntaxError {
 	return l.errors
 }
 
+// Comments returns the comments read, in source order
+func (l *Lexer) Comments() []*ast.CommentNode {
+	return l.comments
+}
+
 // addError adds a lexical error
 func (l *Lexer) addError(pos Position, message string) {
-	l.errors = append(l.errors, fmt.Sprintf("%s: %s", pos.String(), message))
+	l.errors = append(l.errors, SyntaxError{Pos: pos, Message: message})
 }
 
 // Parse parses the input using goyacc
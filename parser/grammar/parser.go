@@ -10,9 +10,47 @@ import __yyfmt__ "fmt"
 import (
 	"fmt"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"math"
 )
 
-//line grammar.y:10
+// span returns the base of a node from the start of its first token to the
+// end of its last
+func span(start, end ast.Position) ast.BaseNode {
+	return ast.BaseNode{Position: start, End: end}
+}
+
+// endOf returns the end of a field or variant: that of its last attribute,
+// else of its wire name, else of its head
+func endOf(head, wireName ast.Position, attributes []*ast.AttributeNode) ast.Position {
+	if len(attributes) > 0 {
+		return attributes[len(attributes)-1].End
+	}
+	if wireName.Line > 0 {
+		return wireName
+	}
+	return head
+}
+
+// skipToDeclaration discards tokens from the lookahead char up to the next
+// declaration keyword or the end of the file, and returns that token as the
+// new lookahead
+func skipToDeclaration(lex yyLexer, lval *yySymType, char, token int) (int, int) {
+	for {
+		switch char {
+		case 0, STRUCT, ENUM, TYPE, CONST:
+			return char, token
+		}
+		char, token = yylex1(lex, lval)
+	}
+}
+
+// Syntax errors list the tokens the parser expected, which Lexer.Error
+// turns into readable messages
+func init() {
+	yyErrorVerbose = true
+}
+
+//line grammar.y:48
 type yySymType struct {
 	yys      int
 	node     ast.Node
@@ -23,64 +61,77 @@ type yySymType struct {
 	imports  []*ast.ImportNode
 	struct_  *ast.StructNode
 	field    *ast.FieldNode
-	fields   []*ast.FieldNode
+	embed    *ast.EmbedNode
 	enum_    *ast.EnumNode
 	variant  *ast.EnumVariantNode
 	variants []*ast.EnumVariantNode
+	doc      []string
+	pos      ast.Position
+	end      ast.Position
+	attr     *ast.AttributeNode
+	attrs    []*ast.AttributeNode
 	typedef  *ast.TypeAliasNode
 	const_   *ast.ConstantNode
 	constval ast.ConstantValue
 	type_    ast.Type
 	ident    string
 	str      string
-	num      int64
+	num      uint64
+	float    float64
 }
 
 const IDENTIFIER = 57346
 const STRING_LITERAL = 57347
 const NUMBER_LITERAL = 57348
-const IMPORT = 57349
-const STRUCT = 57350
-const ENUM = 57351
-const TYPE = 57352
-const CONST = 57353
-const LBRACE = 57354
-const RBRACE = 57355
-const LPAREN = 57356
-const RPAREN = 57357
-const LBRACKET = 57358
-const RBRACKET = 57359
-const COLON = 57360
-const SEMICOLON = 57361
-const COMMA = 57362
-const EQUALS = 57363
-const QUESTION = 57364
-const DOT = 57365
-const COMMENT = 57366
-const INT8 = 57367
-const INT16 = 57368
-const INT32 = 57369
-const INT64 = 57370
-const INT = 57371
-const BIGINT = 57372
-const NAT8 = 57373
-const NAT16 = 57374
-const NAT32 = 57375
-const NAT64 = 57376
-const NAT = 57377
-const BIGNAT = 57378
-const FLOAT32 = 57379
-const FLOAT64 = 57380
-const DECIMAL = 57381
-const STRING = 57382
-const BOOL = 57383
-const JSON = 57384
-const TIME = 57385
-const DATE = 57386
-const DATETIME = 57387
-const TIMETZ = 57388
-const DATETZ = 57389
-const DATETIMETZ = 57390
+const FLOAT_LITERAL = 57349
+const IMPORT = 57350
+const STRUCT = 57351
+const ENUM = 57352
+const TYPE = 57353
+const CONST = 57354
+const TRUE = 57355
+const FALSE = 57356
+const LBRACE = 57357
+const RBRACE = 57358
+const LPAREN = 57359
+const RPAREN = 57360
+const LBRACKET = 57361
+const RBRACKET = 57362
+const COLON = 57363
+const SEMICOLON = 57364
+const COMMA = 57365
+const EQUALS = 57366
+const QUESTION = 57367
+const DOT = 57368
+const ELLIPSIS = 57369
+const MINUS = 57370
+const AT = 57371
+const COMMENT = 57372
+const INT8 = 57373
+const INT16 = 57374
+const INT32 = 57375
+const INT64 = 57376
+const INT = 57377
+const BIGINT = 57378
+const NAT8 = 57379
+const NAT16 = 57380
+const NAT32 = 57381
+const NAT64 = 57382
+const NAT = 57383
+const BIGNAT = 57384
+const FLOAT32 = 57385
+const FLOAT64 = 57386
+const DECIMAL = 57387
+const STRING = 57388
+const BOOL = 57389
+const JSON = 57390
+const UUID = 57391
+const TIME = 57392
+const DATE = 57393
+const DATETIME = 57394
+const TIMETZ = 57395
+const DATETZ = 57396
+const DATETIMETZ = 57397
 
 var yyToknames = [...]string{
 	"$end",
@@ -89,11 +140,14 @@ var yyToknames = [...]string{
 	"IDENTIFIER",
 	"STRING_LITERAL",
 	"NUMBER_LITERAL",
+	"FLOAT_LITERAL",
 	"IMPORT",
 	"STRUCT",
 	"ENUM",
 	"TYPE",
 	"CONST",
+	"TRUE",
+	"FALSE",
 	"LBRACE",
 	"RBRACE",
 	"LPAREN",
@@ -106,6 +160,9 @@ var yyToknames = [...]string{
 	"EQUALS",
 	"QUESTION",
 	"DOT",
+	"ELLIPSIS",
+	"MINUS",
+	"AT",
 	"COMMENT",
 	"INT8",
 	"INT16",
@@ -125,6 +182,7 @@ var yyToknames = [...]string{
 	"STRING",
 	"BOOL",
 	"JSON",
+	"UUID",
 	"TIME",
 	"DATE",
 	"DATETIME",
@@ -139,95 +197,115 @@ const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line grammar.y:290
+//line grammar.y:470
 
 //line yacctab:1
 var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
+	-1, 3,
+	1, 2,
+	-2, 0,
+	-1, 16,
+	1, 1,
+	-2, 0,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 156
+const yyLast = 186
 
 var yyAct = [...]int8{
-	37, 35, 32, 75, 24, 28, 27, 74, 71, 83,
-	5, 36, 69, 26, 17, 6, 11, 12, 13, 14,
-	72, 65, 11, 12, 13, 14, 17, 25, 68, 67,
-	81, 33, 36, 40, 70, 29, 73, 23, 22, 79,
-	21, 77, 41, 42, 43, 44, 45, 46, 47, 48,
-	49, 50, 51, 52, 53, 54, 55, 56, 57, 58,
-	59, 60, 61, 62, 63, 64, 65, 20, 19, 38,
-	3, 4, 78, 15, 16, 80, 66, 82, 40, 76,
-	84, 10, 9, 34, 85, 8, 31, 41, 42, 43,
-	44, 45, 46, 47, 48, 49, 50, 51, 52, 53,
-	54, 55, 56, 57, 58, 59, 60, 61, 62, 63,
-	64, 65, 30, 7, 39, 18, 2, 1, 0, 0,
-	0, 0, 0, 40, 0, 0, 0, 0, 0, 0,
-	0, 0, 41, 42, 43, 44, 45, 46, 47, 48,
-	49, 50, 51, 52, 53, 54, 55, 56, 57, 58,
-	59, 60, 61, 62, 63, 64,
+	67, 37, 93, 82, 39, 34, 71, 68, 70, 103,
+	79, 85, 26, 83, 72, 73, 66, 30, 29, 92,
+	84, 107, 74, 98, 5, 109, 36, 106, 18, 69,
+	28, 40, 27, 78, 88, 89, 94, 101, 80, 81,
+	96, 18, 87, 41, 42, 43, 44, 45, 46, 47,
+	48, 49, 50, 51, 52, 53, 54, 55, 56, 57,
+	58, 59, 60, 61, 62, 63, 64, 65, 6, 66,
+	38, 36, 31, 25, 7, 12, 13, 14, 15, 24,
+	23, 90, 66, 91, 22, 21, 95, 3, 97, 4,
+	16, 11, 17, 99, 100, 10, 102, 40, 86, 35,
+	104, 33, 9, 105, 76, 77, 75, 32, 108, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 19, 8, 20, 2, 1,
+	0, 0, 12, 13, 14, 15, 0, 0, 0, 40,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 41, 42, 43, 44, 45, 46, 47, 48, 49,
+	50, 51, 52, 53, 54, 55, 56, 57, 58, 59,
+	60, 61, 62, 63, 64, 65,
 }
 
 var yyPact = [...]int16{
-	8, -1000, 8, 14, -1000, -1000, 64, -1000, -1000, -1000,
-	-1000, 63, 36, 34, 33, 14, -1000, -1000, -19, -1000,
-	15, 1, -15, -16, 31, 27, 28, 107, 23, -1000,
-	-1, 27, -1000, -10, 7, -1000, -11, -1000, -1000, -20,
-	62, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	66, -1000, 66, 133, -1000, -1000, -1000, 81, -1000, -1000,
+	-1000, -1000, 80, 76, 75, 69, 133, -1000, -1000, -1000,
+	-14, -1000, 17, 15, -6, -7, 68, -1000, 67, 130,
+	1, -1000, 6, 22, -1000, -11, -1, -1000, -1000, -15,
+	78, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, 17, -1000, -1000, 107, 26, 107, -8, -1000, 107,
-	-1000, -1000, -1000, 107, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 28,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -11, 65, -2,
+	-1000, -1000, -1000, 31, 130, 36, 130, 3, -1000, -1000,
+	-1000, -15, 12, -20, -1000, -1000, -1000, -1000, 130, -20,
+	-1000, 130, -1000, 23, -1000, -1000, 4, 1, 7, -1000,
 }
 
-var yyPgo = [...]int8{
-	0, 117, 116, 71, 115, 114, 70, 10, 113, 112,
-	86, 2, 85, 83, 1, 82, 81, 76, 0, 69,
+var yyPgo = [...]uint8{
+	0, 139, 138, 89, 137, 4, 3, 87, 24, 136,
+	107, 106, 105, 104, 102, 101, 5, 99, 2, 96,
+	95, 91, 0, 1, 70,
 }
 
 var yyR1 = [...]int8{
-	0, 1, 1, 2, 2, 3, 4, 4, 6, 6,
-	7, 7, 7, 7, 8, 9, 9, 10, 10, 11,
-	11, 12, 13, 13, 14, 14, 15, 16, 17, 17,
-	18, 18, 18, 18, 5, 5, 19, 19, 19, 19,
-	19, 19, 19, 19, 19, 19, 19, 19, 19, 19,
-	19, 19, 19, 19, 19, 19, 19, 19, 19, 19,
+	0, 1, 1, 2, 2, 3, 4, 4, 7, 7,
+	7, 7, 8, 8, 8, 8, 9, 10, 10, 10,
+	13, 11, 12, 12, 6, 6, 18, 18, 19, 19,
+	14, 15, 15, 16, 17, 17, 20, 21, 22, 22,
+	22, 22, 22, 22, 22, 23, 23, 23, 23, 5,
+	5, 24, 24, 24, 24, 24, 24, 24, 24, 24,
+	24, 24, 24, 24, 24, 24, 24, 24, 24, 24,
+	24, 24, 24, 24, 24, 24,
 }
 
 var yyR2 = [...]int8{
-	0, 2, 1, 1, 2, 2, 1, 3, 1, 2,
-	1, 1, 1, 1, 5, 0, 1, 1, 2, 3,
-	4, 5, 1, 2, 1, 3, 4, 4, 1, 1,
-	1, 1, 3, 4, 1, 3, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	0, 2, 1, 1, 2, 2, 1, 3, 1, 1,
+	2, 2, 1, 1, 1, 1, 5, 0, 2, 2,
+	2, 3, 3, 4, 0, 2, 0, 2, 2, 5,
+	5, 1, 2, 3, 1, 3, 4, 4, 1, 2,
+	1, 2, 1, 1, 1, 1, 1, 3, 4, 1,
+	3, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1,
 }
 
 var yyChk = [...]int16{
-	-1000, -1, -2, -6, -3, -7, 7, -8, -12, -15,
-	-16, 8, 9, 10, 11, -6, -3, -7, -4, 4,
-	4, 4, 4, 4, 23, 12, 12, 21, 21, 4,
-	-9, -10, -11, 4, -13, -14, 4, -18, -19, -5,
-	16, 25, 26, 27, 28, 29, 30, 31, 32, 33,
-	34, 35, 36, 37, 38, 39, 40, 41, 42, 43,
-	44, 45, 46, 47, 48, 4, -17, 6, 5, 13,
-	-11, 18, 13, -14, 18, 23, 17, -18, -18, 22,
-	-18, 4, -18, 17, -18, -18,
+	-1000, -1, -2, -7, -3, -8, 2, 8, -9, -14,
+	-20, -21, 9, 10, 11, 12, -7, -3, -8, 2,
+	-4, 4, 4, 4, 4, 4, 26, 15, 15, 24,
+	24, 4, -10, -15, -16, -17, 4, -23, -24, -5,
+	19, 31, 32, 33, 34, 35, 36, 37, 38, 39,
+	40, 41, 42, 43, 44, 45, 46, 47, 48, 49,
+	50, 51, 52, 53, 54, 55, 4, -22, 6, 28,
+	7, 5, 13, 14, 16, -11, -13, -12, 27, 4,
+	16, -16, -6, 24, 21, 26, 20, -23, 6, 7,
+	-6, -5, 21, -18, 5, -23, 4, -23, 20, -18,
+	-23, 25, -19, 29, -23, -23, 4, 17, -22, 18,
 }
 
 var yyDef = [...]int8{
-	0, -2, 0, 2, 3, 8, 0, 10, 11, 12,
-	13, 0, 0, 0, 0, 1, 4, 9, 5, 6,
-	0, 0, 0, 0, 0, 15, 0, 0, 0, 7,
-	0, 16, 17, 0, 0, 22, 24, 26, 30, 31,
-	0, 36, 37, 38, 39, 40, 41, 42, 43, 44,
-	45, 46, 47, 48, 49, 50, 51, 52, 53, 54,
-	55, 56, 57, 58, 59, 34, 27, 28, 29, 14,
-	18, 0, 21, 23, 0, 0, 0, 0, 19, 0,
-	25, 35, 32, 0, 20, 33,
+	0, -2, 0, -2, 3, 8, 9, 0, 12, 13,
+	14, 15, 0, 0, 0, 0, -2, 4, 10, 11,
+	5, 6, 0, 0, 0, 0, 0, 17, 0, 0,
+	0, 7, 0, 0, 31, 24, 34, 36, 45, 46,
+	0, 51, 52, 53, 54, 55, 56, 57, 58, 59,
+	60, 61, 62, 63, 64, 65, 66, 67, 68, 69,
+	70, 71, 72, 73, 74, 75, 49, 37, 38, 0,
+	40, 42, 43, 44, 16, 18, 19, 24, 0, 0,
+	30, 32, 26, 0, 0, 0, 0, 0, 39, 41,
+	26, 20, 0, 33, 25, 35, 50, 47, 0, 21,
+	22, 0, 27, 0, 48, 23, 28, 0, 0, 29,
 }
 
 var yyTok1 = [...]int8{
@@ -239,7 +317,8 @@ var yyTok2 = [...]int8{
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
-	42, 43, 44, 45, 46, 47, 48,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55,
 }
 
 var yyTok3 = [...]int8{
@@ -585,7 +664,7 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:70
+//line grammar.y:118
 		{
 			yyVAL.program = &ast.ProgramNode{
 				Imports:      yyDollar[1].imports,
@@ -595,7 +674,7 @@ yydefault:
 		}
 	case 2:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:77
+//line grammar.y:125
 		{
 			yyVAL.program = &ast.ProgramNode{
 				Imports:      nil,
@@ -605,401 +684,563 @@ yydefault:
 		}
 	case 3:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:86
+//line grammar.y:134
 		{
 			yyVAL.imports = []*ast.ImportNode{yyDollar[1].import_}
 		}
 	case 4:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:89
+//line grammar.y:137
 		{
 			yyVAL.imports = append(yyDollar[1].imports, yyDollar[2].import_)
 		}
 	case 5:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:94
+//line grammar.y:142
 		{
 			yyVAL.import_ = &ast.ImportNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[2].end),
 				Path:     yyDollar[2].str,
 			}
 		}
 	case 6:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:102
+//line grammar.y:150
 		{
 			yyVAL.str = yyDollar[1].ident
 		}
 	case 7:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line grammar.y:105
+//line grammar.y:153
 		{
 			yyVAL.str = yyDollar[1].str + "." + yyDollar[3].ident
+			yyVAL.end = yyDollar[3].end
 		}
 	case 8:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:110
+//line grammar.y:163
 		{
 			yyVAL.decls = []ast.Declaration{yyDollar[1].decl}
 		}
 	case 9:
-		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:113
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:166
 		{
-			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decl)
+			yyVAL.decls = nil
+			yyrcvr.char, yytoken = skipToDeclaration(yylex, &yyrcvr.lval, yyrcvr.char, yytoken)
+			Errflag = 0
 		}
 	case 10:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:118
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:171
 		{
-			yyVAL.decl = yyDollar[1].struct_
+			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decl)
 		}
 	case 11:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:119
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:174
 		{
-			yyVAL.decl = yyDollar[1].enum_
+			yyVAL.decls = yyDollar[1].decls
+			yyrcvr.char, yytoken = skipToDeclaration(yylex, &yyrcvr.lval, yyrcvr.char, yytoken)
+			Errflag = 0
 		}
 	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:120
+//line grammar.y:181
 		{
-			yyVAL.decl = yyDollar[1].typedef
+			yyVAL.decl = yyDollar[1].struct_
 		}
 	case 13:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:121
+//line grammar.y:182
 		{
-			yyVAL.decl = yyDollar[1].const_
+			yyVAL.decl = yyDollar[1].enum_
 		}
 	case 14:
-		yyDollar = yyS[yypt-5 : yypt+1]
-//line grammar.y:124
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:183
 		{
-			yyVAL.struct_ = &ast.StructNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
-				Name:     yyDollar[2].ident,
-				Fields:   yyDollar[4].fields,
-			}
+			yyVAL.decl = yyDollar[1].typedef
 		}
 	case 15:
-		yyDollar = yyS[yypt-0 : yypt+1]
-//line grammar.y:133
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:184
 		{
-			yyVAL.fields = nil
+			yyVAL.decl = yyDollar[1].const_
 		}
 	case 16:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:136
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line grammar.y:187
 		{
-			yyVAL.fields = yyDollar[1].fields
+			yyDollar[4].struct_.BaseNode = span(yyDollar[1].pos, yyDollar[5].end)
+			yyDollar[4].struct_.Name = yyDollar[2].ident
+			yyDollar[4].struct_.Doc = yyDollar[1].doc
+			yyVAL.struct_ = yyDollar[4].struct_
 		}
 	case 17:
-		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:141
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line grammar.y:197
 		{
-			yyVAL.fields = []*ast.FieldNode{yyDollar[1].field}
+			yyVAL.struct_ = &ast.StructNode{}
 		}
 	case 18:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:144
+//line grammar.y:200
 		{
-			yyVAL.fields = append(yyDollar[1].fields, yyDollar[2].field)
+			yyDollar[1].struct_.Fields = append(yyDollar[1].struct_.Fields, yyDollar[2].field)
+			yyVAL.struct_ = yyDollar[1].struct_
 		}
 	case 19:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:204
+		{
+			yyDollar[2].embed.Index = len(yyDollar[1].struct_.Fields)
+			yyDollar[1].struct_.Embeds = append(yyDollar[1].struct_.Embeds, yyDollar[2].embed)
+			yyVAL.struct_ = yyDollar[1].struct_
+		}
+	case 20:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:211
+		{
+			yyVAL.embed = &ast.EmbedNode{
+				BaseNode: span(yyDollar[1].pos, yyDollar[2].end),
+				Type:     &ast.NamedType{BaseNode: span(yyDollar[2].pos, yyDollar[2].end), Name: yyDollar[2].str},
+			}
+		}
+	case 21:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line grammar.y:149
+//line grammar.y:219
+		{
+			yyDollar[1].field.WireName = yyDollar[2].str
+			yyDollar[1].field.Attributes = yyDollar[3].attrs
+			yyDollar[1].field.End = endOf(yyDollar[1].field.End, yyDollar[2].end, yyDollar[3].attrs)
+			yyVAL.field = yyDollar[1].field
+		}
+	case 22:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line grammar.y:229
 		{
 			yyVAL.field = &ast.FieldNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[3].type_.Span().End),
 				Name:     yyDollar[1].ident,
 				Type:     yyDollar[3].type_,
 				Optional: false,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 20:
+	case 23:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line grammar.y:157
+//line grammar.y:238
 		{
 			yyVAL.field = &ast.FieldNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[4].type_.Span().End),
 				Name:     yyDollar[1].ident,
 				Type:     yyDollar[4].type_,
 				Optional: true,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 21:
+	case 24:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line grammar.y:251
+		{
+			yyVAL.str = ""
+			yyVAL.end = ast.Position{}
+		}
+	case 25:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:255
+		{
+			if yyDollar[2].str == "" {
+				yylex.(*Lexer).Error("wire name must not be empty")
+			}
+			yyVAL.str = yyDollar[2].str
+			yyVAL.end = yyDollar[2].end
+		}
+	case 26:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line grammar.y:264
+		{
+			yyVAL.attrs = nil
+		}
+	case 27:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:267
+		{
+			yyVAL.attrs = append(yyDollar[1].attrs, yyDollar[2].attr)
+		}
+	case 28:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:272
+		{
+			yyVAL.attr = &ast.AttributeNode{
+				BaseNode: span(yyDollar[1].pos, yyDollar[2].end),
+				Name:     yyDollar[2].ident,
+			}
+		}
+	case 29:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line grammar.y:167
+//line grammar.y:278
+		{
+			yyVAL.attr = &ast.AttributeNode{
+				BaseNode: span(yyDollar[1].pos, yyDollar[5].end),
+				Name:     yyDollar[2].ident,
+				Value:    yyDollar[4].constval,
+			}
+		}
+	case 30:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line grammar.y:287
 		{
 			yyVAL.enum_ = &ast.EnumNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[5].end),
 				Name:     yyDollar[2].ident,
 				Variants: yyDollar[4].variants,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 22:
+	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:176
+//line grammar.y:297
 		{
 			yyVAL.variants = []*ast.EnumVariantNode{yyDollar[1].variant}
 		}
-	case 23:
+	case 32:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line grammar.y:179
+//line grammar.y:300
 		{
 			yyVAL.variants = append(yyDollar[1].variants, yyDollar[2].variant)
 		}
-	case 24:
+	case 33:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line grammar.y:305
+		{
+			yyDollar[1].variant.WireName = yyDollar[2].str
+			yyDollar[1].variant.Attributes = yyDollar[3].attrs
+			yyDollar[1].variant.End = endOf(yyDollar[1].variant.End, yyDollar[2].end, yyDollar[3].attrs)
+			yyVAL.variant = yyDollar[1].variant
+		}
+	case 34:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:184
+//line grammar.y:313
 		{
 			yyVAL.variant = &ast.EnumVariantNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
 				Name:     yyDollar[1].ident,
 				Payload:  nil,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 25:
+	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line grammar.y:191
+//line grammar.y:321
 		{
 			yyVAL.variant = &ast.EnumVariantNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[3].type_.Span().End),
 				Name:     yyDollar[1].ident,
 				Payload:  yyDollar[3].type_,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 26:
+	case 36:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line grammar.y:200
+//line grammar.y:331
 		{
 			yyVAL.typedef = &ast.TypeAliasNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[4].type_.Span().End),
 				Name:     yyDollar[2].ident,
 				Type:     yyDollar[4].type_,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 27:
+	case 37:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line grammar.y:209
+//line grammar.y:341
 		{
 			if !IsConstantCase(yyDollar[2].ident) {
 				yylex.(*Lexer).Error(fmt.Sprintf("constant name '%s' must be in CONSTANT_CASE format", yyDollar[2].ident))
 				return 1
 			}
 			yyVAL.const_ = &ast.ConstantNode{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[4].constval.Span().End),
 				Name:     yyDollar[2].ident,
 				Value:    yyDollar[4].constval,
+				Doc:      yyDollar[1].doc,
 			}
 		}
-	case 28:
+	case 38:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:222
+//line grammar.y:355
 		{
+			if yyDollar[1].num > math.MaxInt64 {
+				yylex.(*Lexer).Error(fmt.Sprintf("integer constant %d overflows int64", yyDollar[1].num))
+				return 1
+			}
 			yyVAL.constval = &ast.IntConstant{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
-				Value:    yyDollar[1].num,
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
+				Value:    int64(yyDollar[1].num),
+				Literal:  yyDollar[1].str,
 			}
 		}
-	case 29:
+	case 39:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:366
+		{
+			if yyDollar[2].num > math.MaxInt64+1 {
+				yylex.(*Lexer).Error(fmt.Sprintf("integer constant -%d overflows int64", yyDollar[2].num))
+				return 1
+			}
+			// Negating in uint64 keeps math.MinInt64, whose magnitude has no int64
+			yyVAL.constval = &ast.IntConstant{
+				BaseNode: span(yyDollar[1].pos, yyDollar[2].end),
+				Value:    int64(-yyDollar[2].num),
+			}
+			if yyDollar[2].str != "" {
+				yyVAL.constval.(*ast.IntConstant).Literal = "-" + yyDollar[2].str
+			}
+		}
+	case 40:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:380
+		{
+			yyVAL.constval = &ast.FloatConstant{
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
+				Value:    yyDollar[1].float,
+				Literal:  yyDollar[1].str,
+			}
+		}
+	case 41:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line grammar.y:387
+		{
+			yyVAL.constval = &ast.FloatConstant{
+				BaseNode: span(yyDollar[1].pos, yyDollar[2].end),
+				Value:    -yyDollar[2].float,
+				Literal:  "-" + yyDollar[2].str,
+			}
+		}
+	case 42:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:228
+//line grammar.y:394
 		{
 			yyVAL.constval = &ast.StringConstant{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
 				Value:    yyDollar[1].str,
 			}
 		}
-	case 30:
+	case 43:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:400
+		{
+			yyVAL.constval = &ast.BoolConstant{
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
+				Value:    true,
+			}
+		}
+	case 44:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:406
+		{
+			yyVAL.constval = &ast.BoolConstant{
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
+				Value:    false,
+			}
+		}
+	case 45:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:236
+//line grammar.y:414
 		{
 			yyVAL.type_ = yyDollar[1].type_
 		}
-	case 31:
+	case 46:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:237
+//line grammar.y:415
 		{
 			yyVAL.type_ = &ast.NamedType{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[1].end),
 				Name:     yyDollar[1].str,
 			}
 		}
-	case 32:
+	case 47:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line grammar.y:243
+//line grammar.y:421
 		{
 			yyVAL.type_ = &ast.ArrayType{
-				BaseNode:    ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode:    span(yyDollar[1].pos, yyDollar[3].type_.Span().End),
 				ElementType: yyDollar[3].type_,
 			}
 		}
-	case 33:
+	case 48:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line grammar.y:249
+//line grammar.y:427
 		{
 			yyVAL.type_ = &ast.MapType{
-				BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}},
+				BaseNode: span(yyDollar[1].pos, yyDollar[4].type_.Span().End),
 				KeyType:  yyDollar[2].type_, ValueType: yyDollar[4].type_,
 			}
 		}
-	case 34:
+	case 49:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:257
+//line grammar.y:435
 		{
 			yyVAL.str = yyDollar[1].ident
 		}
-	case 35:
+	case 50:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line grammar.y:260
+//line grammar.y:438
 		{
 			yyVAL.str = yyDollar[1].str + "." + yyDollar[3].ident
+			yyVAL.end = yyDollar[3].end
 		}
-	case 36:
+	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:265
+//line grammar.y:444
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int8"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "int8"}
 		}
-	case 37:
+	case 52:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:266
+//line grammar.y:445
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int16"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "int16"}
 		}
-	case 38:
+	case 53:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:267
+//line grammar.y:446
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int32"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "int32"}
 		}
-	case 39:
+	case 54:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:268
+//line grammar.y:447
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int64"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "int64"}
 		}
-	case 40:
+	case 55:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:269
+//line grammar.y:448
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "int"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "int"}
 		}
-	case 41:
+	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:270
+//line grammar.y:449
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bigint"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "bigint"}
 		}
-	case 42:
+	case 57:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:271
+//line grammar.y:450
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat8"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "nat8"}
 		}
-	case 43:
+	case 58:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:272
+//line grammar.y:451
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat16"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "nat16"}
 		}
-	case 44:
+	case 59:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:273
+//line grammar.y:452
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat32"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "nat32"}
 		}
-	case 45:
+	case 60:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:274
+//line grammar.y:453
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat64"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "nat64"}
 		}
-	case 46:
+	case 61:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:275
+//line grammar.y:454
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "nat"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "nat"}
 		}
-	case 47:
+	case 62:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:276
+//line grammar.y:455
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bignat"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "bignat"}
 		}
-	case 48:
+	case 63:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:277
+//line grammar.y:456
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "float32"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "float32"}
 		}
-	case 49:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:278
+//line grammar.y:457
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "float64"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "float64"}
 		}
-	case 50:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:279
+//line grammar.y:458
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "decimal"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "decimal"}
 		}
-	case 51:
+	case 66:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:280
+//line grammar.y:459
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "string"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "string"}
 		}
-	case 52:
+	case 67:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:281
+//line grammar.y:460
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "bool"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "bool"}
 		}
-	case 53:
+	case 68:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:282
+//line grammar.y:461
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "json"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "json"}
 		}
-	case 54:
+	case 69:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:283
+//line grammar.y:462
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "time"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "uuid"}
 		}
-	case 55:
+	case 70:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:284
+//line grammar.y:463
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "date"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "time"}
 		}
-	case 56:
+	case 71:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:285
+//line grammar.y:464
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetime"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "date"}
 		}
-	case 57:
+	case 72:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:286
+//line grammar.y:465
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "timetz"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "datetime"}
 		}
-	case 58:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:287
+//line grammar.y:466
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetz"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "timetz"}
 		}
-	case 59:
+	case 74:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line grammar.y:467
+		{
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "datetz"}
+		}
+	case 75:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line grammar.y:288
+//line grammar.y:468
 		{
-			yyVAL.type_ = &ast.PrimitiveType{BaseNode: ast.BaseNode{Position: ast.Position{Filename: yylex.(*Lexer).filename, Line: yylex.(*Lexer).scanner.Line, Column: yylex.(*Lexer).scanner.Column}}, Name: "datetimetz"}
+			yyVAL.type_ = &ast.PrimitiveType{BaseNode: span(yyDollar[1].pos, yyDollar[1].end), Name: "datetimetz"}
 		}
 	}
 	goto yystack /* stack new state and value */
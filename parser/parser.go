@@ -1,11 +1,14 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
 	"github.com/WhatsApp-Platform/typegen/parser/grammar"
@@ -14,14 +17,106 @@ import (
 // ParseError represents a parsing error
 type ParseError struct {
 	Message string
-	Errors  []string
+	// Diagnostics are the syntax errors with their positions, in the order
+	// they were found
+	Diagnostics []ParseDiagnostic
+}
+
+// ParseDiagnostic is a single parse error at a position in the source
+type ParseDiagnostic struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	// Got is the text of the unexpected token of a syntax error, empty at
+	// the end of the file
+	Got string `json:"got,omitempty"`
+	// Expected describes the tokens the parser would have accepted instead,
+	// e.g. "name" or "'}'"; it is empty when there are too many to list
+	Expected []string `json:"expected,omitempty"`
+}
+
+// Position returns the position of the error
+func (d ParseDiagnostic) Position() ast.Position {
+	return ast.Position{Filename: d.File, Line: d.Line, Column: d.Column}
+}
+
+// String formats the error as "file:line:column: message"
+func (d ParseDiagnostic) String() string {
+	return d.Position().String() + ": " + d.Message
 }
 
 func (e *ParseError) Error() string {
-	if len(e.Errors) == 0 {
+	if len(e.Diagnostics) == 0 {
 		return e.Message
 	}
-	return fmt.Sprintf("%s:\n%s", e.Message, strings.Join(e.Errors, "\n"))
+	lines := make([]string, len(e.Diagnostics))
+	for i, diagnostic := range e.Diagnostics {
+		lines[i] = diagnostic.String()
+	}
+	return fmt.Sprintf("%s:\n%s", e.Message, strings.Join(lines, "\n"))
+}
+
+// FileError is the error of a file of a module that failed to parse
+type FileError struct {
+	Path string
+	// Err is a *ParseError, or the error reading the file
+	Err error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ModuleParseError is returned when files of a module fail to parse. It
+// holds the error of every file that failed, submodules included, in
+// directory order.
+type ModuleParseError struct {
+	Files []*FileError
+}
+
+// Error lists the errors grouped by file, separated by blank lines
+func (e *ModuleParseError) Error() string {
+	if len(e.Files) == 1 {
+		return e.Files[0].Error()
+	}
+	parts := make([]string, len(e.Files))
+	for i, file := range e.Files {
+		parts[i] = file.Error()
+	}
+	return fmt.Sprintf("%d files failed to parse:\n\n%s", len(e.Files), strings.Join(parts, "\n\n"))
+}
+
+// Unwrap returns the file errors, so errors.As finds the *ParseError of the
+// first file
+func (e *ModuleParseError) Unwrap() []error {
+	errs := make([]error, len(e.Files))
+	for i, file := range e.Files {
+		errs[i] = file
+	}
+	return errs
+}
+
+// Diagnostics returns the positioned errors of a parse error: those of a
+// *ParseError, or of every file of a *ModuleParseError, in order
+func Diagnostics(err error) []ParseDiagnostic {
+	var moduleErr *ModuleParseError
+	if errors.As(err, &moduleErr) {
+		var diagnostics []ParseDiagnostic
+		for _, file := range moduleErr.Files {
+			diagnostics = append(diagnostics, Diagnostics(file.Err)...)
+		}
+		return diagnostics
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Diagnostics
+	}
+	return nil
 }
 
 // ParseFile parses a TypeGen file and returns the AST
@@ -41,9 +136,20 @@ func Parse(input io.Reader, filename string) (*ast.ProgramNode, error) {
 	
 	// Check for errors
 	if errors := lexer.Errors(); len(errors) > 0 {
+		diagnostics := make([]ParseDiagnostic, len(errors))
+		for i, err := range errors {
+			diagnostics[i] = ParseDiagnostic{
+				File:     err.Pos.Filename,
+				Line:     err.Pos.Line,
+				Column:   err.Pos.Column,
+				Message:  err.Message,
+				Got:      err.Got,
+				Expected: err.Expected,
+			}
+		}
 		return nil, &ParseError{
-			Message: "parse errors occurred",
-			Errors:  errors,
+			Message:     "parse errors occurred",
+			Diagnostics: diagnostics,
 		}
 	}
 	
@@ -67,47 +173,85 @@ func Parse(input io.Reader, filename string) (*ast.ProgramNode, error) {
 			Message: "invalid AST root node",
 		}
 	}
+	program.Comments = lexer.Comments()
 	
 	return program, nil
 }
 
-// ParseModule parses all .tg files in a directory (non-recursive, for backwards compatibility)
+// ParseModule parses all .tg files in a directory (non-recursive, for
+// backwards compatibility). When files fail to parse, it returns a
+// *ModuleParseError with the errors of all of them.
 func ParseModule(modulePath string) (map[string]*ast.ProgramNode, error) {
 	entries, err := os.ReadDir(modulePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read module directory %s: %w", modulePath, err)
 	}
 	
-	results := make(map[string]*ast.ProgramNode)
-	
+	var paths []string
 	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tg") {
+			paths = append(paths, filepath.Join(modulePath, entry.Name()))
 		}
-		
-		if !strings.HasSuffix(entry.Name(), ".tg") {
+	}
+	parsed := parseFiles(paths, runtime.GOMAXPROCS(0))
+	
+	results := make(map[string]*ast.ProgramNode)
+	moduleErr := &ModuleParseError{}
+	for _, filePath := range paths {
+		if err := parsed[filePath].err; err != nil {
+			moduleErr.Files = append(moduleErr.Files, &FileError{Path: filePath, Err: err})
 			continue
 		}
-		
-		filePath := filepath.Join(modulePath, entry.Name())
-		program, err := ParseFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
-		}
-		
-		results[entry.Name()] = program
+		results[filepath.Base(filePath)] = parsed[filePath].program
+	}
+	if len(moduleErr.Files) > 0 {
+		return nil, moduleErr
 	}
 	
 	return results, nil
 }
 
-// ParseModuleToAST parses all .tg files in a directory recursively and returns an ast.Module
+// ParseModuleToAST parses all .tg files in a directory recursively and returns
+// an ast.Module. When files fail to parse, it returns a *ModuleParseError
+// with the errors of all of them.
 func ParseModuleToAST(modulePath string) (*ast.Module, error) {
-	return parseModuleRecursive(modulePath)
+	return parseModuleRecursive(modulePath, nil, false)
+}
+
+// ParseModuleToASTExcluding parses a module like ParseModuleToAST, skipping
+// the given directories, such as generated code inside the module
+func ParseModuleToASTExcluding(modulePath string, excluded []string) (*ast.Module, error) {
+	return ParseModuleWithOptions(modulePath, ParseOptions{ExcludedDirs: excluded})
+}
+
+// ParseModuleWithOptions parses a module like ParseModuleToAST, skipping the
+// files and directories that the options exclude. With options.Partial, the
+// module of the files that parsed is returned along with a
+// *ModuleParseError.
+func ParseModuleWithOptions(modulePath string, options ParseOptions) (*ast.Module, error) {
+	exclusions, err := options.Exclusions(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseModuleRecursive(modulePath, exclusions, options.Partial)
 }
 
-// shouldSkipDirectory returns true if the directory should be skipped during parsing
-func shouldSkipDirectory(name string) bool {
+// ParseFileToModule parses a single .tg file as a module of its own, named
+// after the file without its extension
+func ParseFileToModule(filename string) (*ast.Module, error) {
+	program, err := ParseFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	
+	modulePath := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return ast.NewModule(modulePath, map[string]*ast.ProgramNode{
+		filepath.Base(filename): program,
+	}), nil
+}
+
+// ShouldSkipDirectory returns true if the directory should be skipped during parsing
+func ShouldSkipDirectory(name string) bool {
 	skipDirs := []string{
 		".git", ".svn", ".hg",           // Version control
 		"node_modules", "vendor",        // Dependencies
@@ -126,49 +270,153 @@ func shouldSkipDirectory(name string) bool {
 	return strings.HasPrefix(name, ".")
 }
 
-// parseModuleRecursive recursively parses a module directory
-func parseModuleRecursive(modulePath string) (*ast.Module, error) {
+// parseModuleRecursive recursively parses a module directory, skipping what
+// exclusions skips. Directories are listed first, then every .tg file is
+// parsed concurrently, and the module is assembled in directory order, so
+// the module and the errors returned don't depend on scheduling. The errors
+// of all files are returned together; with partial, so is the module of the
+// files that parsed.
+func parseModuleRecursive(modulePath string, exclusions *Exclusions, partial bool) (*ast.Module, error) {
+	return parseModuleConcurrently(modulePath, exclusions, partial, runtime.GOMAXPROCS(0))
+}
+
+// parseModuleConcurrently parses a module like parseModuleRecursive with up
+// to workers goroutines parsing files
+func parseModuleConcurrently(modulePath string, exclusions *Exclusions, partial bool, workers int) (*ast.Module, error) {
+	p := &moduleParser{exclusions: exclusions, dirs: make(map[string]moduleDir)}
+	p.list(modulePath)
+	p.parsed = parseFiles(p.paths, workers)
+	module, err := p.module(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.errs.Files) > 0 {
+		if partial {
+			return module, &p.errs
+		}
+		return nil, &p.errs
+	}
+	return module, nil
+}
+
+// moduleParser parses the files of a module directory tree
+type moduleParser struct {
+	exclusions *Exclusions
+	// dirs are the listed directories by path
+	dirs map[string]moduleDir
+	// paths are the .tg files to parse, in directory order
+	paths []string
+	// parsed holds the result of parsing each of paths, by path
+	parsed map[string]parsedFile
+	// errs collects the files that failed to parse, in directory order
+	errs ModuleParseError
+}
+
+// moduleDir is the listing of a module directory
+type moduleDir struct {
+	entries []os.DirEntry
+	err     error
+}
+
+// parsedFile is the result of parsing a file
+type parsedFile struct {
+	program *ast.ProgramNode
+	err     error
+}
+
+// list reads a directory and the subdirectories that aren't skipped,
+// recording the .tg files to parse
+func (p *moduleParser) list(modulePath string) {
 	entries, err := os.ReadDir(modulePath)
+	p.dirs[modulePath] = moduleDir{entries: entries, err: err}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read module directory %s: %w", modulePath, err)
+		return
 	}
-	
+	for _, entry := range entries {
+		entryPath := filepath.Join(modulePath, entry.Name())
+		if entry.IsDir() {
+			if !p.exclusions.Skip(entryPath, true) {
+				p.list(entryPath)
+			}
+		} else if strings.HasSuffix(entry.Name(), ".tg") && !p.exclusions.Skip(entryPath, false) {
+			p.paths = append(p.paths, entryPath)
+		}
+	}
+}
+
+// module assembles the module of a listed directory from the files that
+// parsed, collecting the others in errs. Only a directory that can't be read
+// fails it.
+func (p *moduleParser) module(modulePath string) (*ast.Module, error) {
+	dir := p.dirs[modulePath]
+	if dir.err != nil {
+		return nil, fmt.Errorf("failed to read module directory %s: %w", modulePath, dir.err)
+	}
+
 	files := make(map[string]*ast.ProgramNode)
 	subModules := make(map[string]*ast.Module)
-	
-	for _, entry := range entries {
+
+	for _, entry := range dir.entries {
+		entryPath := filepath.Join(modulePath, entry.Name())
 		if entry.IsDir() {
-			// Skip certain directories
-			if shouldSkipDirectory(entry.Name()) {
+			if _, listed := p.dirs[entryPath]; !listed {
 				continue
 			}
-			
+
 			// Parse subdirectory as submodule
-			subModulePath := filepath.Join(modulePath, entry.Name())
-			subModule, err := parseModuleRecursive(subModulePath)
+			subModule, err := p.module(entryPath)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse submodule %s: %w", subModulePath, err)
+				return nil, fmt.Errorf("failed to parse submodule %s: %w", entryPath, err)
 			}
-			
+
 			// Only include submodules that have content
 			if len(subModule.Files) > 0 || len(subModule.SubModules) > 0 {
 				subModules[entry.Name()] = subModule
 			}
-		} else if strings.HasSuffix(entry.Name(), ".tg") {
-			// Parse .tg file
-			filePath := filepath.Join(modulePath, entry.Name())
-			program, err := ParseFile(filePath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+		} else if parsed, listed := p.parsed[entryPath]; listed {
+			if parsed.err != nil {
+				p.errs.Files = append(p.errs.Files, &FileError{Path: entryPath, Err: parsed.err})
+				continue
 			}
-			
-			files[entry.Name()] = program
+			files[entry.Name()] = parsed.program
 		}
 	}
-	
+
 	// Create the module
 	module := ast.NewModule(modulePath, files)
 	module.SubModules = subModules
-	
+
 	return module, nil
-}
\ No newline at end of file
+}
+
+// parseFiles parses files with up to workers goroutines. The generated
+// parser keeps its state in a parser value per call, so files parse
+// independently.
+func parseFiles(paths []string, workers int) map[string]parsedFile {
+	results := make([]parsedFile, len(paths))
+	workers = max(1, min(workers, len(paths)))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				program, err := ParseFile(paths[i])
+				results[i] = parsedFile{program: program, err: err}
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	parsed := make(map[string]parsedFile, len(paths))
+	for i, path := range paths {
+		parsed[path] = results[i]
+	}
+	return parsed
+}
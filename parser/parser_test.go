@@ -1,6 +1,14 @@
 package parser
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	
@@ -130,6 +138,25 @@ func TestParseTypeAlias(t *testing.T) {
 	}
 }
 
+func TestParsePrimitiveTypes(t *testing.T) {
+	for _, name := range []string{"int", "nat", "float64", "decimal", "bigint", "bignat", "timetz", "datetz", "datetimetz", "uuid"} {
+		input := fmt.Sprintf("struct Value {\n  value: %s\n}\ntype Alias = %s\n", name, name)
+		program, err := Parse(strings.NewReader(input), "test.tg")
+		if err != nil {
+			t.Fatalf("%s: parse failed: %v", name, err)
+		}
+
+		field := program.Declarations[0].(*ast.StructNode).Fields[0]
+		alias := program.Declarations[1].(*ast.TypeAliasNode)
+		for _, typ := range []ast.Type{field.Type, alias.Type} {
+			primitive, ok := typ.(*ast.PrimitiveType)
+			if !ok || primitive.Name != name {
+				t.Errorf("Expected primitive type %s, got %#v", name, typ)
+			}
+		}
+	}
+}
+
 func TestParseWithImports(t *testing.T) {
 	input := `
 import auth.types
@@ -288,6 +315,298 @@ type UserID = int64  // Alias comment
 	if typeAlias.Name != "UserID" {
 		t.Errorf("Expected type alias name 'UserID', got '%s'", typeAlias.Name)
 	}
+
+	// Every comment is kept, in source order, for the printer
+	if len(program.Comments) != 11 {
+		t.Fatalf("Expected 11 comments, got %d", len(program.Comments))
+	}
+	first, last := program.Comments[0], program.Comments[10]
+	if first.Text != "// This is a file-level comment" || first.Span().String() != "test_with_comments.tg:2:1-2:32" {
+		t.Errorf("Unexpected first comment %q at %s", first.Text, first.Span())
+	}
+	if last.Text != "// Alias comment" || last.Pos().Line != 20 {
+		t.Errorf("Unexpected last comment %q at %s", last.Text, last.Pos())
+	}
+}
+
+func TestParseDocComments(t *testing.T) {
+	input := `// File comment, detached by the blank line
+
+// User is a customer.
+//
+//   Indented line  
+struct User {
+  // The user's id
+  id: int64 // Not a doc comment
+  name: string
+  /* Block comments are not doc comments */
+  email: ?string
+
+  // Detached from the field
+  
+  phone: string
+}
+
+// Status of an order
+enum Status {
+  // Not paid yet
+  pending
+  // Paid in full
+  paid: int64
+}
+
+// UserID identifies a user
+type UserID = int64
+// Comment between declarations
+// MAX_ITEMS is the cart size
+const MAX_ITEMS = 10
+`
+	program, err := Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user := program.Declarations[0].(*ast.StructNode)
+	status := program.Declarations[1].(*ast.EnumNode)
+	tests := []struct {
+		name     string
+		doc      []string
+		expected string
+	}{
+		{"User", user.Doc, "User is a customer.||  Indented line"},
+		{"User.id", user.Fields[0].Doc, "The user's id"},
+		{"User.name", user.Fields[1].Doc, ""},
+		{"User.email", user.Fields[2].Doc, ""},
+		{"User.phone", user.Fields[3].Doc, ""},
+		{"Status", status.Doc, "Status of an order"},
+		{"Status.pending", status.Variants[0].Doc, "Not paid yet"},
+		{"Status.paid", status.Variants[1].Doc, "Paid in full"},
+		{"UserID", program.Declarations[2].(*ast.TypeAliasNode).Doc, "UserID identifies a user"},
+		{"MAX_ITEMS", program.Declarations[3].(*ast.ConstantNode).Doc, "Comment between declarations|MAX_ITEMS is the cart size"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.doc, "|"); got != tt.expected {
+			t.Errorf("%s: expected doc %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	input := `struct User {
+  email: string @sensitive @deprecated("use contact")
+  name: ?string @max_length(64)
+  age: int32
+}
+
+enum Status {
+  active @default
+  legacy: string @deprecated
+}
+`
+	program, err := Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user := program.Declarations[0].(*ast.StructNode)
+	status := program.Declarations[1].(*ast.EnumNode)
+	tests := []struct {
+		name       string
+		attributes []*ast.AttributeNode
+		expected   string
+	}{
+		{"User.email", user.Fields[0].Attributes, ` @sensitive @deprecated("use contact")`},
+		{"User.name", user.Fields[1].Attributes, " @max_length(64)"},
+		{"User.age", user.Fields[2].Attributes, ""},
+		{"Status.active", status.Variants[0].Attributes, " @default"},
+		{"Status.legacy", status.Variants[1].Attributes, " @deprecated"},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, attribute := range tt.attributes {
+			got += " " + attribute.String()
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected attributes %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+	if user.Fields[0].Attribute("sensitive") == nil || user.Fields[2].Attribute("sensitive") != nil {
+		t.Errorf("Attribute found the wrong attributes")
+	}
+	if !user.Fields[1].Optional {
+		t.Errorf("Expected name to stay optional")
+	}
+
+	// Attributes name an identifier and take a constant value
+	for _, src := range []string{
+		"struct A {\n  b: string @\n}",
+		"struct A {\n  b: string @c(d)\n}",
+		"struct A {\n  b: string @c()\n}",
+		"struct A {\n  @c b: string\n}",
+	} {
+		if _, err := Parse(strings.NewReader(src), "test.tg"); err == nil {
+			t.Errorf("Expected a syntax error for %q", src)
+		}
+	}
+}
+
+func TestParseEmbeds(t *testing.T) {
+	input := `struct Order {
+  ...AuditFields
+  id: int64
+  total: decimal
+  ...auth.Owner
+}
+`
+	program, err := Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	order := program.Declarations[0].(*ast.StructNode)
+	if len(order.Fields) != 2 || len(order.Embeds) != 2 {
+		t.Fatalf("Expected 2 fields and 2 embeds, got %d and %d", len(order.Fields), len(order.Embeds))
+	}
+	if order.Embeds[0].Type.Name != "AuditFields" || order.Embeds[0].Index != 0 {
+		t.Errorf("Expected ...AuditFields before the fields, got %s at %d", order.Embeds[0], order.Embeds[0].Index)
+	}
+	if order.Embeds[1].Type.Name != "auth.Owner" || order.Embeds[1].Index != 2 {
+		t.Errorf("Expected ...auth.Owner after the fields, got %s at %d", order.Embeds[1], order.Embeds[1].Index)
+	}
+	expected := "struct Order {\n  ...AuditFields\n  id: int64\n  total: decimal\n  ...auth.Owner\n}"
+	if got := order.String(); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	for _, src := range []string{
+		"struct A {\n  ..B\n}",
+		"struct A {\n  ...\n}",
+		"struct A {\n  ...[]B\n}",
+		"struct A {\n  b: ...B\n}",
+	} {
+		if _, err := Parse(strings.NewReader(src), "test.tg"); err == nil {
+			t.Errorf("Expected a syntax error for %q", src)
+		}
+	}
+}
+
+func TestParseSpans(t *testing.T) {
+	input := `import auth.session
+
+// A user
+struct User {
+  id: int64
+  tags: []string = "t" @deprecated
+  m: [string]auth.Token
+  ...auth.Base
+}
+
+enum Role {
+  admin
+  guest: User = "GUEST"
+}
+type Users = []User
+const LIMIT = -5
+`
+	program, err := Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user := program.Declarations[0].(*ast.StructNode)
+	role := program.Declarations[1].(*ast.EnumNode)
+	users := program.Declarations[2].(*ast.TypeAliasNode)
+	limit := program.Declarations[3].(*ast.ConstantNode)
+	tags := user.Fields[1]
+	m := user.Fields[2].Type.(*ast.MapType)
+
+	tests := []struct {
+		name string
+		node ast.Node
+		want string
+	}{
+		{"import", program.Imports[0], "test.tg:1:1-1:20"},
+		{"struct", user, "test.tg:4:1-9:2"},
+		{"field", user.Fields[0], "test.tg:5:3-5:12"},
+		{"field type", user.Fields[0].Type, "test.tg:5:7-5:12"},
+		{"field with wire name and attribute", tags, "test.tg:6:3-6:35"},
+		{"array type", tags.Type, "test.tg:6:9-6:17"},
+		{"attribute", tags.Attributes[0], "test.tg:6:24-6:35"},
+		{"map type", m, "test.tg:7:6-7:24"},
+		{"map key", m.KeyType, "test.tg:7:7-7:13"},
+		{"map value", m.ValueType, "test.tg:7:14-7:24"},
+		{"embed", user.Embeds[0], "test.tg:8:3-8:15"},
+		{"enum", role, "test.tg:11:1-14:2"},
+		{"variant", role.Variants[0], "test.tg:12:3-12:8"},
+		{"variant with payload and wire name", role.Variants[1], "test.tg:13:3-13:24"},
+		{"type alias", users, "test.tg:15:1-15:20"},
+		{"constant", limit, "test.tg:16:1-16:17"},
+		{"constant value", limit.Value, "test.tg:16:15-16:17"},
+	}
+	for _, tt := range tests {
+		if got := tt.node.Span().String(); got != tt.want {
+			t.Errorf("%s: expected span %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestParseWireNames(t *testing.T) {
+	input := `struct User {
+  user_id: int64 = "userId"
+  name: ?string = "Name" @deprecated
+  age: int32
+}
+
+enum Status {
+  active = "ACTIVE"
+  suspended: string = "SUSPENDED" @deprecated
+  deleted
+}
+`
+	program, err := Parse(strings.NewReader(input), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	user := program.Declarations[0].(*ast.StructNode)
+	status := program.Declarations[1].(*ast.EnumNode)
+	tests := []struct {
+		name     string
+		wireName string
+		jsonName string
+		expected string
+	}{
+		{"User.user_id", user.Fields[0].WireName, user.Fields[0].JSONName(), "userId"},
+		{"User.name", user.Fields[1].WireName, user.Fields[1].JSONName(), "Name"},
+		{"User.age", user.Fields[2].WireName, user.Fields[2].JSONName(), ""},
+		{"Status.active", status.Variants[0].WireName, status.Variants[0].JSONName(), "ACTIVE"},
+		{"Status.suspended", status.Variants[1].WireName, status.Variants[1].JSONName(), "SUSPENDED"},
+		{"Status.deleted", status.Variants[2].WireName, status.Variants[2].JSONName(), ""},
+	}
+	for _, tt := range tests {
+		if tt.wireName != tt.expected {
+			t.Errorf("%s: expected wire name %q, got %q", tt.name, tt.expected, tt.wireName)
+		}
+		if tt.expected != "" && tt.jsonName != tt.expected {
+			t.Errorf("%s: expected JSON name %q, got %q", tt.name, tt.expected, tt.jsonName)
+		}
+	}
+	if user.Fields[2].JSONName() != "age" || status.Variants[2].JSONName() != "deleted" {
+		t.Errorf("expected names without wire names to be their JSON names")
+	}
+	if got := user.Fields[1].String(); got != `name: ?string = "Name" @deprecated` {
+		t.Errorf("unexpected field %s", got)
+	}
+	if user.Fields[1].Attribute("deprecated") == nil || status.Variants[1].Attribute("deprecated") == nil {
+		t.Errorf("expected the attributes after the wire names")
+	}
+
+	// Wire names are non-empty strings written before the attributes
+	for _, src := range []string{
+		"struct A {\n  b: string = \"\"\n}",
+		"struct A {\n  b: string = c\n}",
+		"struct A {\n  b: string @c = \"d\"\n}",
+		"enum A {\n  b = 1\n}",
+	} {
+		if _, err := Parse(strings.NewReader(src), "test.tg"); err == nil {
+			t.Errorf("Expected a syntax error for %q", src)
+		}
+	}
 }
 
 func TestParseIntConstant(t *testing.T) {
@@ -352,6 +671,145 @@ func TestParseStringConstant(t *testing.T) {
 	}
 }
 
+func TestParseNegativeIntConstant(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"const MIN_TEMP = -40", -40},
+		{"const ZERO = -0", 0},
+		{"const MIN_INT = -9223372036854775808", math.MinInt64},
+		{"const MAX_INT = 9223372036854775807", math.MaxInt64},
+		// Like Go, a minus may be separated from its number
+		{"const SPACED = - 5", -5},
+		{"const SPLIT = -\n5", -5},
+	}
+	for _, tt := range tests {
+		program, err := Parse(strings.NewReader(tt.input), "test.tg")
+		if err != nil {
+			t.Fatalf("%q: Parse failed: %v", tt.input, err)
+		}
+		constDecl := program.Declarations[0].(*ast.ConstantNode)
+		intConst, ok := constDecl.Value.(*ast.IntConstant)
+		if !ok {
+			t.Fatalf("%q: Expected IntConstant, got %T", tt.input, constDecl.Value)
+		}
+		if intConst.Value != tt.expected {
+			t.Errorf("%q: Expected constant value %d, got %d", tt.input, tt.expected, intConst.Value)
+		}
+	}
+
+	invalid := map[string]string{
+		"const TOO_LOW = -9223372036854775809": "integer constant -9223372036854775809 overflows int64",
+		"const TOO_HIGH = 9223372036854775808": "integer constant 9223372036854775808 overflows int64",
+		"const TWICE = --5":                    "unexpected '-', expected number",
+		"const NAME = -\"x\"":                  "unexpected '\"x\"', expected number",
+	}
+	for input, expected := range invalid {
+		if _, err := Parse(strings.NewReader(input), "test.tg"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: Expected error containing %q, got: %v", input, expected, err)
+		}
+	}
+}
+
+func TestParsePrefixedIntConstant(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		literal  string
+	}{
+		{"const FLAG_ADMIN = 0x08", 8, "0x08"},
+		{"const MASK = 0b1010", 10, "0b1010"},
+		{"const MODE = 0o755", 0o755, "0o755"},
+		{"const UPPER = 0XFF", 255, "0XFF"},
+		{"const LOWEST = -0x8000000000000000", math.MinInt64, "-0x8000000000000000"},
+		{"const DECIMAL = 42", 42, ""},
+	}
+	for _, tt := range tests {
+		program, err := Parse(strings.NewReader(tt.input), "test.tg")
+		if err != nil {
+			t.Fatalf("%q: Parse failed: %v", tt.input, err)
+		}
+		intConst := program.Declarations[0].(*ast.ConstantNode).Value.(*ast.IntConstant)
+		if intConst.Value != tt.expected || intConst.Literal != tt.literal {
+			t.Errorf("%q: Expected %d written %q, got %d written %q", tt.input, tt.expected, tt.literal, intConst.Value, intConst.Literal)
+		}
+	}
+
+	// Digits invalid for the base are reported where the number starts
+	invalid := map[string]string{
+		"const MASK = 0b102":               "test.tg:1:14: invalid number: 0b102: invalid digit '2' in binary literal",
+		"const MODE = 0o758":               "test.tg:1:14: invalid number: 0o758: invalid digit '8' in octal literal",
+		"const FLAG = 0x":                  "test.tg:1:14: invalid number: 0x: hexadecimal literal has no digits",
+		"const HUGE = 0x1ffffffffffffffff": "test.tg:1:14: invalid number: 0x1ffffffffffffffff",
+	}
+	for input, expected := range invalid {
+		if _, err := Parse(strings.NewReader(input), "test.tg"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: Expected error containing %q, got: %v", input, expected, err)
+		}
+	}
+}
+
+func TestParseFloatConstant(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		text     string
+		decimal  string
+	}{
+		{"const PI = 3.14", 3.14, "3.14", "3.14"},
+		{"const EPSILON = 1e-9", 1e-9, "1e-9", "1e-9"},
+		{"const RATE = 2.5E3", 2500, "2.5E3", "2.5E3"},
+		{"const OFFSET = -0.5", -0.5, "-0.5", "-0.5"},
+		{"const TINY = -1e-9", -1e-9, "-1e-9", "-1e-9"},
+		{"const HALF = .5", 0.5, ".5", "0.5"},
+		{"const ONE = 1.", 1, "1.", "1.0"},
+		{"const QUARTER = 0x1p-2", 0.25, "0x1p-2", "0.25"},
+	}
+	for _, tt := range tests {
+		program, err := Parse(strings.NewReader(tt.input), "test.tg")
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", tt.input, err)
+		}
+		constDecl := program.Declarations[0].(*ast.ConstantNode)
+		floatConst, ok := constDecl.Value.(*ast.FloatConstant)
+		if !ok {
+			t.Fatalf("%s: Expected FloatConstant, got %T", tt.input, constDecl.Value)
+		}
+		if floatConst.Value != tt.expected {
+			t.Errorf("%s: Expected constant value %g, got %g", tt.input, tt.expected, floatConst.Value)
+		}
+		if floatConst.String() != tt.text {
+			t.Errorf("%s: Expected String() %q, got %q", tt.input, tt.text, floatConst.String())
+		}
+		if floatConst.Decimal() != tt.decimal {
+			t.Errorf("%s: Expected Decimal() %q, got %q", tt.input, tt.decimal, floatConst.Decimal())
+		}
+	}
+
+	// Out of range floats are lexer errors
+	if _, err := Parse(strings.NewReader("const HUGE = 1e400"), "test.tg"); err == nil || !strings.Contains(err.Error(), "invalid number: 1e400") {
+		t.Errorf("Expected invalid number error, got: %v", err)
+	}
+}
+
+func TestParseBoolConstant(t *testing.T) {
+	program, err := Parse(strings.NewReader("const FEATURE_ENABLED = true\nconst LEGACY_MODE = false"), "test.tg")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for i, expected := range []bool{true, false} {
+		constDecl := program.Declarations[i].(*ast.ConstantNode)
+		boolConst, ok := constDecl.Value.(*ast.BoolConstant)
+		if !ok {
+			t.Fatalf("Expected BoolConstant, got %T", constDecl.Value)
+		}
+		if boolConst.Value != expected {
+			t.Errorf("Expected %s to be %t, got %t", constDecl.Name, expected, boolConst.Value)
+		}
+	}
+}
+
 func TestParseMultipleConstants(t *testing.T) {
 	input := `
 const MAX_CONNECTIONS = 100
@@ -484,4 +942,294 @@ func TestConstantNameValidation(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestParseFileToModule(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "user.tg")
+	if err := os.WriteFile(filename, []byte("struct User {\n  id: int64\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	module, err := ParseFileToModule(filename)
+	if err != nil {
+		t.Fatalf("ParseFileToModule failed: %v", err)
+	}
+	if module.Name != "user" {
+		t.Errorf("expected module name 'user', got %q", module.Name)
+	}
+	if _, exists := module.GetFile("user.tg"); !exists || len(module.Files) != 1 {
+		t.Errorf("expected the module to contain only user.tg, got %v", module.FileNames())
+	}
+	if len(module.SubModules) != 0 {
+		t.Errorf("expected no submodules, got %d", len(module.SubModules))
+	}
+}
+
+func TestParseModuleToASTExcluding(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{
+		"user.tg":          "struct User {\n  id: int64\n}\n",
+		"auth/token.tg":    "struct Token {\n  value: string\n}\n",
+		"gen/generated.tg": "struct Generated {\n  id: int64\n}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	module, err := ParseModuleToASTExcluding(dir, []string{filepath.Join(dir, "gen")})
+	if err != nil {
+		t.Fatalf("ParseModuleToASTExcluding failed: %v", err)
+	}
+	if _, exists := module.SubModules["gen"]; exists {
+		t.Error("expected the excluded directory to be skipped")
+	}
+	if _, exists := module.SubModules["auth"]; !exists {
+		t.Error("expected the other submodules to be parsed")
+	}
+	
+	module, err = ParseModuleToAST(dir)
+	if err != nil {
+		t.Fatalf("ParseModuleToAST failed: %v", err)
+	}
+	if _, exists := module.SubModules["gen"]; !exists {
+		t.Error("expected ParseModuleToAST to parse every submodule")
+	}
+}
+
+// writeTree writes a synthetic module of nested submodules, each with files
+// of a few declarations referencing each other, and returns its directory
+func writeTree(tb testing.TB, depth, width, filesPerDir int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	var write func(dir string, level int)
+	write = func(dir string, level int) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for i := 0; i < filesPerDir; i++ {
+			source := fmt.Sprintf(`const LIMIT_%[1]d = %[1]d
+
+struct Item%[1]d {
+  id: int64
+  tags: []string
+  next: ?Item%[1]d
+}
+
+enum Status%[1]d {
+  active
+  moved: Item%[1]d
+}
+
+type Items%[1]d = [string]Item%[1]d
+`, i)
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.tg", i)), []byte(source), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		if level < depth {
+			for i := 0; i < width; i++ {
+				write(filepath.Join(dir, fmt.Sprintf("sub%d", i)), level+1)
+			}
+		}
+	}
+	write(root, 1)
+	return root
+}
+
+func TestParseModuleConcurrently(t *testing.T) {
+	dir := writeTree(t, 3, 3, 5)
+
+	encode := func(module *ast.Module) string {
+		data, err := json.Marshal(module)
+		if err != nil {
+			t.Fatalf("failed to encode module: %v", err)
+		}
+		return string(data)
+	}
+	serial, err := parseModuleConcurrently(dir, nil, false, 1)
+	if err != nil {
+		t.Fatalf("serial parse failed: %v", err)
+	}
+	expected := encode(serial)
+	if len(serial.AllFiles()) != 5*(1+3+9) {
+		t.Fatalf("expected 65 files, got %d", len(serial.AllFiles()))
+	}
+	for _, workers := range []int{2, 8, 64} {
+		parallel, err := parseModuleConcurrently(dir, nil, false, workers)
+		if err != nil {
+			t.Fatalf("parse with %d workers failed: %v", workers, err)
+		}
+		if encode(parallel) != expected {
+			t.Errorf("%d workers: expected the same module as the serial parse", workers)
+		}
+	}
+
+	// The errors are in directory order, whichever file fails first
+	for _, name := range []string{"sub2/sub0/file4.tg", "sub1/file3.tg", "sub1/sub2/file0.tg"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("struct {"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, serialErr := parseModuleConcurrently(dir, nil, false, 1)
+	if serialErr == nil {
+		t.Fatal("expected the serial parse to fail")
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := parseModuleConcurrently(dir, nil, false, 8); err == nil || err.Error() != serialErr.Error() {
+			t.Fatalf("expected the serial error %q, got %v", serialErr, err)
+		}
+	}
+}
+
+func TestParseModuleErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{
+		"user.tg":         "struct User {\n  id: int64\n}\n",
+		"broken.tg":       "struct Broken {\n  id int64\n}\n",
+		"auth/token.tg":   "struct Token {\n  value: string\n}\n",
+		"auth/session.tg": "enum Session {\n  active\n  expired:\n}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	broken := []string{filepath.Join(dir, "auth", "session.tg"), filepath.Join(dir, "broken.tg")}
+
+	module, err := ParseModuleToAST(dir)
+	if module != nil {
+		t.Error("expected no module by default")
+	}
+	var moduleErr *ModuleParseError
+	if !errors.As(err, &moduleErr) {
+		t.Fatalf("expected a *ModuleParseError, got %v", err)
+	}
+	if len(moduleErr.Files) != 2 || moduleErr.Files[0].Path != broken[0] || moduleErr.Files[1].Path != broken[1] {
+		t.Fatalf("expected errors for %v, got %+v", broken, moduleErr.Files)
+	}
+	for _, path := range broken {
+		if !strings.Contains(err.Error(), "failed to parse "+path+": parse errors occurred:\n"+path+":") {
+			t.Errorf("expected the error to list %s with its positions, got:\n%v", path, err)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "2 files failed to parse:\n\n") {
+		t.Errorf("expected a summary line, got:\n%v", err)
+	}
+
+	// errors.As finds the first file's error, and Diagnostics every file's
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr != moduleErr.Files[0].Err {
+		t.Errorf("expected errors.As to find the first file's *ParseError, got %v", parseErr)
+	}
+	diagnostics := Diagnostics(err)
+	if len(diagnostics) != 2 || diagnostics[0].File != broken[0] || diagnostics[1].File != broken[1] {
+		t.Errorf("expected a diagnostic per broken file, got %+v", diagnostics)
+	}
+
+	module, err = ParseModuleWithOptions(dir, ParseOptions{Partial: true})
+	if !errors.As(err, &moduleErr) || len(moduleErr.Files) != 2 {
+		t.Fatalf("expected both errors with Partial, got %v", err)
+	}
+	if module == nil || module.FileNames()[0] != "user.tg" || len(module.Files) != 1 || module.SubModules["auth"].FileNames()[0] != "token.tg" {
+		t.Fatalf("expected the files that parsed, got %v", module)
+	}
+
+	if _, err := ParseModule(dir); !errors.As(err, &moduleErr) || len(moduleErr.Files) != 1 || moduleErr.Files[0].Path != broken[1] {
+		t.Errorf("expected ParseModule to report broken.tg, got %v", err)
+	}
+}
+
+func BenchmarkParseModuleToAST(b *testing.B) {
+	dir := writeTree(b, 4, 4, 10)
+	for name, workers := range map[string]int{"serial": 1, "parallel": runtime.GOMAXPROCS(0)} {
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := parseModuleConcurrently(dir, nil, false, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRecoversFromSyntaxErrors(t *testing.T) {
+	_, err := ParseFile(filepath.Join("testdata", "broken.tg"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+
+	file := filepath.Join("testdata", "broken.tg")
+	expected := []ParseDiagnostic{
+		{File: file, Line: 7, Column: 8, Message: "unexpected 'string', expected ':'", Got: "string", Expected: []string{"':'"}},
+		{File: file, Line: 13, Column: 1, Message: "unexpected '}'", Got: "}"},
+		{File: file, Line: 21, Column: 1, Message: "unexpected 'const'", Got: "const"},
+	}
+	if !reflect.DeepEqual(parseErr.Diagnostics, expected) {
+		t.Errorf("expected diagnostics %v, got %v", expected, parseErr.Diagnostics)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []ParseDiagnostic
+	}{
+		{"missing colon", "struct User {\n  id int64\n}\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 2, Column: 6, Message: "unexpected 'int64', expected ':'", Got: "int64", Expected: []string{"':'"}},
+		}},
+		{"unexpected character", "struct User {\n  id: int64\n  $\n}\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 3, Column: 3, Message: "unexpected character: $"},
+		}},
+		{"invalid number", "const MAX = 99999999999999999999\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 1, Column: 13, Message: "invalid number: 99999999999999999999"},
+			{File: "bad.tg", Line: 2, Column: 1, Message: "unexpected end of file"},
+		}},
+		{"unterminated struct", "struct User {\n  id: int64\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 3, Column: 1, Message: "unexpected end of file, expected name, '}' or '...'", Expected: []string{"name", "'}'", "'...'"}},
+		}},
+		{"errors in several declarations", "struct User {\n  id int64\n}\n\nstruct Order {\n  id: int64\n}\n\nenum Status {\n  active,\n}\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 2, Column: 6, Message: "unexpected 'int64', expected ':'", Got: "int64", Expected: []string{"':'"}},
+			{File: "bad.tg", Line: 10, Column: 9, Message: "unexpected ',', expected name or '}'", Got: ",", Expected: []string{"name", "'}'"}},
+		}},
+		{"adjacent broken declarations", "struct A { id int64 }\nstruct { x: int64 }\ntype = int64\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 1, Column: 15, Message: "unexpected 'int64', expected ':'", Got: "int64", Expected: []string{"':'"}},
+			{File: "bad.tg", Line: 2, Column: 8, Message: "unexpected '{', expected name", Got: "{", Expected: []string{"name"}},
+			{File: "bad.tg", Line: 3, Column: 6, Message: "unexpected '=', expected name", Got: "=", Expected: []string{"name"}},
+		}},
+		{"broken constant after a broken struct", "struct A { id int64 }\nconst = 5\n", []ParseDiagnostic{
+			{File: "bad.tg", Line: 1, Column: 15, Message: "unexpected 'int64', expected ':'", Got: "int64", Expected: []string{"':'"}},
+			{File: "bad.tg", Line: 2, Column: 7, Message: "unexpected '=', expected name", Got: "=", Expected: []string{"name"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input), "bad.tg")
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a *ParseError, got %v", err)
+			}
+			if !reflect.DeepEqual(parseErr.Diagnostics, tt.expected) {
+				t.Errorf("expected diagnostics %v, got %v", tt.expected, parseErr.Diagnostics)
+			}
+
+			// The text lists the same errors, one per line
+			lines := []string{"parse errors occurred:"}
+			for _, diagnostic := range tt.expected {
+				lines = append(lines, fmt.Sprintf("bad.tg:%d:%d: %s", diagnostic.Line, diagnostic.Column, diagnostic.Message))
+			}
+			if err.Error() != strings.Join(lines, "\n") {
+				t.Errorf("unexpected error text:\n%s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package printer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// fingerprintVersion is written first in every fingerprinted input, so a
+// change to the canonical form can invalidate existing fingerprints
+const fingerprintVersion = 1
+
+// Fingerprint returns a hash of a module as printed in canonical style: its
+// files in path order, then its submodules in name order, each file with
+// its sorted imports and its declarations in source order. Whitespace and
+// comments don't change it; any change to a declaration, field, type,
+// constant or import does, as does moving or renaming a file. The name of
+// the root module is left out.
+func Fingerprint(module *ast.Module) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "typegen fingerprint %d\n", fingerprintVersion)
+	if err := fingerprintModule(h, module, ""); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DeclFingerprint returns a hash of a single declaration as printed in
+// canonical style, for caches finer-grained than a module. References to
+// other types are hashed by name only.
+func DeclFingerprint(decl ast.Declaration) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "typegen fingerprint %d\n", fingerprintVersion)
+	if err := Fprint(h, &ast.ProgramNode{Declarations: []ast.Declaration{decl}}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprintModule writes the canonical form of a module and its
+// submodules, dir being the module's slash-separated path
+func fingerprintModule(w io.Writer, module *ast.Module, dir string) error {
+	for _, name := range module.FileNames() {
+		fmt.Fprintf(w, "file %q\n", dir+name)
+		file := *module.Files[name]
+		file.Comments = nil
+		if err := Fprint(w, &file); err != nil {
+			return fmt.Errorf("%s%s: %w", dir, name, err)
+		}
+	}
+	for _, name := range module.SubModuleNames() {
+		fmt.Fprintf(w, "module %q\n", dir+name)
+		if err := fingerprintModule(w, module.SubModules[name], dir+name+"/"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+const schema = `import auth
+import billing
+
+// The maximum number of items
+const MAX_ITEMS = 100
+
+struct Order {
+  id: int64
+  buyer: auth.User
+  items: []string
+  note: ?string
+}
+
+enum Status {
+  pending
+  shipped: string
+}
+`
+
+// fingerprintOf returns the fingerprint of a module with a main.tg file and
+// an auth submodule
+func fingerprintOf(t *testing.T, src string) string {
+	t.Helper()
+	module := ast.NewModule("shop", map[string]*ast.ProgramNode{"main.tg": parse(t, src, "main.tg")})
+	module.SubModules["auth"] = ast.NewModule("auth", map[string]*ast.ProgramNode{
+		"user.tg": parse(t, "struct User {\n  id: int64\n}\n", "user.tg"),
+	})
+	fingerprint, err := Fingerprint(module)
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	return fingerprint
+}
+
+func TestFingerprint(t *testing.T) {
+	expected := fingerprintOf(t, schema)
+	if len(expected) != 64 {
+		t.Errorf("expected a hex SHA-256, got %q", expected)
+	}
+
+	same := map[string]string{
+		"whitespace": strings.NewReplacer("  ", "\t", ": ", ":", "\n\n", "\n\n\n").Replace(schema),
+		"comments":   strings.Replace(schema, "// The maximum number of items", "// At most\n// this many items", 1) + "// trailing\n",
+		"imports":    strings.Replace(schema, "import auth\nimport billing", "import billing\nimport auth", 1),
+	}
+	for name, src := range same {
+		if got := fingerprintOf(t, src); got != expected {
+			t.Errorf("%s: expected the same fingerprint", name)
+		}
+	}
+
+	changed := map[string]string{
+		"field added":        strings.Replace(schema, "  note: ?string\n", "  note: ?string\n  total: int64\n", 1),
+		"field renamed":      strings.Replace(schema, "note:", "notes:", 1),
+		"type changed":       strings.Replace(schema, "items: []string", "items: []int32", 1),
+		"made optional":      strings.Replace(schema, "id: int64", "id: ?int64", 1),
+		"constant changed":   strings.Replace(schema, "= 100", "= 101", 1),
+		"variant removed":    strings.Replace(schema, "  pending\n", "", 1),
+		"import removed":     strings.Replace(schema, "import billing\n", "", 1),
+		"declarations moved": strings.Replace(schema, "const MAX_ITEMS = 100\n", "", 1) + "const MAX_ITEMS = 100\n",
+	}
+	for name, src := range changed {
+		if got := fingerprintOf(t, src); got == expected {
+			t.Errorf("%s: expected the fingerprint to change", name)
+		}
+	}
+
+	renamed := ast.NewModule("other", map[string]*ast.ProgramNode{"main.tg": parse(t, schema, "main.tg")})
+	moved := ast.NewModule("shop", map[string]*ast.ProgramNode{"order.tg": parse(t, schema, "order.tg")})
+	a, _ := Fingerprint(renamed)
+	b, _ := Fingerprint(ast.NewModule("shop", map[string]*ast.ProgramNode{"main.tg": parse(t, schema, "main.tg")}))
+	c, _ := Fingerprint(moved)
+	if a != b {
+		t.Error("expected the root module name not to change the fingerprint")
+	}
+	if b == c {
+		t.Error("expected renaming a file to change the fingerprint")
+	}
+
+	broken := ast.NewModule("shop", map[string]*ast.ProgramNode{"main.tg": {Declarations: []ast.Declaration{&ast.ConstantNode{Name: "BROKEN"}}}})
+	if _, err := Fingerprint(broken); err == nil || !strings.Contains(err.Error(), "main.tg") {
+		t.Errorf("expected an error naming the file, got %v", err)
+	}
+}
+
+func TestDeclFingerprint(t *testing.T) {
+	fingerprints := func(src string) map[string]string {
+		result := make(map[string]string)
+		for _, decl := range parse(t, src, "main.tg").Declarations {
+			fingerprint, err := DeclFingerprint(decl)
+			if err != nil {
+				t.Fatalf("DeclFingerprint failed: %v", err)
+			}
+			result[decl.DeclName()] = fingerprint
+		}
+		return result
+	}
+
+	before := fingerprints(schema)
+	after := fingerprints(strings.Replace(schema, "shipped: string", "shipped: int64", 1) + "\n// unrelated\n")
+	for _, name := range []string{"MAX_ITEMS", "Order"} {
+		if before[name] != after[name] {
+			t.Errorf("%s: expected an unchanged declaration to keep its fingerprint", name)
+		}
+	}
+	if before["Status"] == after["Status"] {
+		t.Error("Status: expected the changed declaration to change fingerprint")
+	}
+	if before["Order"] == before["Status"] {
+		t.Error("expected declarations to have different fingerprints")
+	}
+}
@@ -0,0 +1,361 @@
+// Package printer turns TypeGen ASTs back into .tg source in canonical
+// style. It is the authoritative round trip of the parser: printing a parsed
+// program and parsing the output gives an equal program, up to the order of
+// imports and positions. Comments are put back next to the nodes they were
+// written next to. The String methods of the AST nodes remain debug output.
+package printer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// DefaultIndent is the number of spaces that indent fields and variants
+const DefaultIndent = 2
+
+// Config controls the canonical style
+type Config struct {
+	// Indent is the number of spaces before fields and variants; 0 means DefaultIndent
+	Indent int
+}
+
+// Fprint prints a program in canonical style with the default configuration
+func Fprint(w io.Writer, program *ast.ProgramNode) error {
+	return (&Config{}).Fprint(w, program)
+}
+
+// Fprint prints a program in canonical style: sorted imports first, then
+// the declarations in their original order, separated by blank lines.
+// Fields and variants are indented on their own lines, and every line ends
+// with a newline.
+//
+// The comments of the program are printed on lines of their own above the
+// node that follows them, or at the end of the line of the node they follow
+// on the same line. Comments directly above an import move with it when
+// imports are sorted; those separated from the first import by a blank line
+// stay at the top of the file. One blank line is kept where the source has
+// blank lines between a comment and what surrounds it.
+func (c *Config) Fprint(w io.Writer, program *ast.ProgramNode) error {
+	indent := DefaultIndent
+	if c.Indent > 0 {
+		indent = c.Indent
+	}
+	p := &printer{w: bufio.NewWriter(w), indent: strings.Repeat(" ", indent), comments: program.Comments}
+
+	if len(program.Imports) > 0 {
+		// Attach the comments to the imports in source order before sorting
+		imports := make([]attachedImport, len(program.Imports))
+		for i, imp := range program.Imports {
+			imports[i].node = imp
+			imports[i].leading = p.leading(imp.Span())
+			if i == 0 {
+				header := detached(imports[i].leading, imp.Pos().Line)
+				p.lines(header, "", 0, imp.Pos().Line)
+				imports[i].leading = imports[i].leading[len(header):]
+			}
+			next := eof
+			if i+1 < len(program.Imports) {
+				next = program.Imports[i+1].Pos()
+			} else if len(program.Declarations) > 0 {
+				next = program.Declarations[0].Pos()
+			}
+			imports[i].trailing = p.trailing(imp.Span().End.Line, next)
+		}
+		sort.SliceStable(imports, func(i, j int) bool { return imports[i].node.Path < imports[j].node.Path })
+		for _, imp := range imports {
+			p.lines(imp.leading, "", 0, imp.node.Pos().Line)
+			p.printf("import %s%s\n", imp.node.Path, inline(imp.trailing))
+		}
+		if len(program.Declarations) > 0 {
+			p.printf("\n")
+		}
+	}
+
+	for i, decl := range program.Declarations {
+		if i > 0 {
+			p.printf("\n")
+		}
+		next := eof
+		if i+1 < len(program.Declarations) {
+			next = program.Declarations[i+1].Pos()
+		}
+		if err := p.declaration(decl, next); err != nil {
+			return err
+		}
+	}
+
+	// The comments after the last node end the file
+	if len(p.comments) > 0 {
+		last := 0
+		for _, imp := range program.Imports {
+			last = max(last, imp.Span().End.Line)
+		}
+		if n := len(program.Declarations); n > 0 {
+			last = program.Declarations[n-1].Span().End.Line
+		}
+		p.lines(p.comments, "", last, 0)
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// printer writes source, keeping the first write error
+type printer struct {
+	w      *bufio.Writer
+	indent string
+	// comments are the comments not printed yet, in source order
+	comments []*ast.CommentNode
+	err      error
+}
+
+// attachedImport is an import with the comments printed with it
+type attachedImport struct {
+	node              *ast.ImportNode
+	leading, trailing []*ast.CommentNode
+}
+
+// eof is a position after any node, for the last node of a list
+var eof = ast.Position{Line: math.MaxInt}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// declaration prints a declaration with its comments, next being the
+// position of the following declaration
+func (p *printer) declaration(decl ast.Declaration, next ast.Position) error {
+	switch d := decl.(type) {
+	case *ast.StructNode:
+		members := d.Members()
+		p.open("struct "+d.Name, d.Span(), members)
+		for i, member := range members {
+			p.lines(p.leading(member.Span()), p.indent, prevEnd(members, i), member.Pos().Line)
+			text := ""
+			if field, ok := member.(*ast.FieldNode); ok {
+				typ := Type(field.Type)
+				if field.Optional {
+					typ = "?" + typ
+				}
+				attributes, err := Attributes(field.Attributes)
+				if err != nil {
+					return fmt.Errorf("field %s.%s: %w", d.Name, field.Name, err)
+				}
+				text = fmt.Sprintf("%s: %s%s%s", field.Name, typ, wireName(field.WireName), attributes)
+			} else {
+				text = "..." + member.(*ast.EmbedNode).Type.Name
+			}
+			p.printf("%s%s%s\n", p.indent, text, inline(p.trailing(member.Span().End.Line, nextStart(members, i, d.Span().End))))
+		}
+		p.close(d.Span(), prevEnd(members, len(members)), next)
+
+	case *ast.EnumNode:
+		members := make([]ast.Node, len(d.Variants))
+		for i, variant := range d.Variants {
+			members[i] = variant
+		}
+		p.open("enum "+d.Name, d.Span(), members)
+		for i, variant := range d.Variants {
+			p.lines(p.leading(variant.Span()), p.indent, prevEnd(members, i), variant.Pos().Line)
+			attributes, err := Attributes(variant.Attributes)
+			if err != nil {
+				return fmt.Errorf("variant %s.%s: %w", d.Name, variant.Name, err)
+			}
+			text := variant.Name
+			if variant.Payload != nil {
+				text += ": " + Type(variant.Payload)
+			}
+			text += wireName(variant.WireName) + attributes
+			p.printf("%s%s%s\n", p.indent, text, inline(p.trailing(variant.Span().End.Line, nextStart(members, i, d.Span().End))))
+		}
+		p.close(d.Span(), prevEnd(members, len(members)), next)
+
+	case *ast.TypeAliasNode:
+		p.lines(p.leading(d.Span()), "", 0, d.Pos().Line)
+		p.printf("type %s = %s%s\n", d.Name, Type(d.Type), inline(p.trailing(d.Span().End.Line, next)))
+
+	case *ast.ConstantNode:
+		value, err := Constant(d.Value)
+		if err != nil {
+			return fmt.Errorf("constant %s: %w", d.Name, err)
+		}
+		p.lines(p.leading(d.Span()), "", 0, d.Pos().Line)
+		p.printf("const %s = %s%s\n", d.Name, value, inline(p.trailing(d.Span().End.Line, next)))
+
+	default:
+		return fmt.Errorf("cannot print declaration of type %T", decl)
+	}
+	return nil
+}
+
+// open prints the first line of a struct or enum, with the comments above
+// it and those after its brace on the same line
+func (p *printer) open(head string, span ast.Span, members []ast.Node) {
+	p.lines(p.take(func(c *ast.CommentNode) bool { return before(c.Pos(), span.Start) }), "", 0, span.Start.Line)
+	p.printf("%s {%s\n", head, inline(p.trailing(span.Start.Line, nextStart(members, -1, span.End))))
+}
+
+// close prints the comments left before the closing brace of a struct or
+// enum, then the brace with the comments after it on the same line; prev
+// is the last line of the last member
+func (p *printer) close(span ast.Span, prev int, next ast.Position) {
+	p.lines(p.take(func(c *ast.CommentNode) bool { return before(c.Pos(), span.End) }), p.indent, prev, 0)
+	p.printf("}%s\n", inline(p.trailing(span.End.Line, next)))
+}
+
+// prevEnd returns the last line of the member before the i-th, or 0 for
+// the first member, which no blank line may precede
+func prevEnd(members []ast.Node, i int) int {
+	if i == 0 {
+		return 0
+	}
+	return members[i-1].Span().End.Line
+}
+
+// nextStart returns the start of the member after the i-th, or end after
+// the last member
+func nextStart(members []ast.Node, i int, end ast.Position) ast.Position {
+	if i+1 < len(members) {
+		return members[i+1].Pos()
+	}
+	return end
+}
+
+// take removes and returns the first comments not printed yet that match,
+// stopping at the first that doesn't
+func (p *printer) take(match func(*ast.CommentNode) bool) []*ast.CommentNode {
+	n := 0
+	for n < len(p.comments) && match(p.comments[n]) {
+		n++
+	}
+	taken := p.comments[:n]
+	p.comments = p.comments[n:]
+	return taken
+}
+
+// leading takes the comments to print above a node printed on one line:
+// those before it, and those inside it on lines before its last
+func (p *printer) leading(span ast.Span) []*ast.CommentNode {
+	return p.take(func(c *ast.CommentNode) bool {
+		return before(c.Pos(), span.Start) || (c.Pos().Line < span.End.Line && before(c.Pos(), span.End))
+	})
+}
+
+// trailing takes the comments to print at the end of a line: those up to
+// the line, before the next node
+func (p *printer) trailing(line int, next ast.Position) []*ast.CommentNode {
+	return p.take(func(c *ast.CommentNode) bool {
+		return c.Pos().Line <= line && before(c.Pos(), next)
+	})
+}
+
+// lines prints comments on lines of their own, keeping one blank line where
+// the source has blank lines between them, or around them: after prev, the
+// last line printed, and before next, the line of what follows. Either is 0
+// when no blank line may be printed there.
+func (p *printer) lines(comments []*ast.CommentNode, indent string, prev, next int) {
+	for i, comment := range comments {
+		if i == 0 && prev > 0 && comment.Pos().Line > prev+1 {
+			p.printf("\n")
+		}
+		if i > 0 && comment.Pos().Line > comments[i-1].Span().End.Line+1 {
+			p.printf("\n")
+		}
+		p.printf("%s%s\n", indent, comment.Text)
+	}
+	if n := len(comments); n > 0 && next > comments[n-1].Span().End.Line+1 {
+		p.printf("\n")
+	}
+}
+
+// detached returns the comments that a blank line separates from the line
+// after them: all but those directly above it
+func detached(comments []*ast.CommentNode, line int) []*ast.CommentNode {
+	n := len(comments)
+	for n > 0 && comments[n-1].Span().End.Line+1 >= line {
+		line = comments[n-1].Pos().Line
+		n--
+	}
+	return comments[:n]
+}
+
+// inline returns comments to print at the end of a line, each preceded by a
+// space
+func inline(comments []*ast.CommentNode) string {
+	var b strings.Builder
+	for _, comment := range comments {
+		b.WriteString(" " + comment.Text)
+	}
+	return b.String()
+}
+
+// before reports whether a position comes before another
+func before(a, b ast.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
+
+// Type returns the source of a type expression, e.g. "[string]?User"
+func Type(t ast.Type) string {
+	switch t := t.(type) {
+	case *ast.PrimitiveType:
+		return t.Name
+	case *ast.NamedType:
+		return t.Name
+	case *ast.ArrayType:
+		return "[]" + Type(t.ElementType)
+	case *ast.MapType:
+		return "[" + Type(t.KeyType) + "]" + Type(t.ValueType)
+	case *ast.OptionalType:
+		return "?" + Type(t.ElementType)
+	}
+	return t.String()
+}
+
+// Attributes returns the source of the attributes of a field or variant,
+// each preceded by a space, e.g. ` @deprecated @max_length(64)`
+func Attributes(attributes []*ast.AttributeNode) (string, error) {
+	var b strings.Builder
+	for _, attribute := range attributes {
+		b.WriteString(" @" + attribute.Name)
+		if attribute.Value == nil {
+			continue
+		}
+		value, err := Constant(attribute.Value)
+		if err != nil {
+			return "", fmt.Errorf("attribute %s: %w", attribute.Name, err)
+		}
+		b.WriteString("(" + value + ")")
+	}
+	return b.String(), nil
+}
+
+// wireName returns the source of the wire name of a field or variant,
+// preceded by a space, or "" for none
+func wireName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " = " + strconv.Quote(name)
+}
+
+// Constant returns the source of a constant value, quoting strings
+func Constant(value ast.ConstantValue) (string, error) {
+	switch v := value.(type) {
+	case *ast.IntConstant, *ast.FloatConstant, *ast.BoolConstant:
+		return v.String(), nil
+	case *ast.StringConstant:
+		return strconv.Quote(v.Value), nil
+	}
+	return "", fmt.Errorf("cannot print constant value of type %T", value)
+}
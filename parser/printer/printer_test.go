@@ -0,0 +1,220 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+const messy = `import   zeta
+import alpha.beta
+const MAX_RETRIES=5
+const MIN_TEMP = - 40
+const FLAGS = 0x0F
+const   GREETING =   "hello \"world\"\n"
+const RATE=-2.50e1
+const EPSILON  =1e-9
+const  STRICT=true
+struct User{
+      id:int64
+  name :   ?string   @deprecated( "use full_name" )
+	tags:[]string@sensitive   @max_items(10)
+  scores: [string]float64="Scores"
+  nested: [][int32]User
+     ...  auth.Timestamps
+}
+struct Empty {}
+enum Event { created: auth.User   deleted ="DELETED"  @deprecated
+}
+type   UserList=[]User
+`
+
+const canonical = `import alpha.beta
+import zeta
+
+const MAX_RETRIES = 5
+
+const MIN_TEMP = -40
+
+const FLAGS = 0x0F
+
+const GREETING = "hello \"world\"\n"
+
+const RATE = -2.50e1
+
+const EPSILON = 1e-9
+
+const STRICT = true
+
+struct User {
+  id: int64
+  name: ?string @deprecated("use full_name")
+  tags: []string @sensitive @max_items(10)
+  scores: [string]float64 = "Scores"
+  nested: [][int32]User
+  ...auth.Timestamps
+}
+
+struct Empty {
+}
+
+enum Event {
+  created: auth.User
+  deleted = "DELETED" @deprecated
+}
+
+type UserList = []User
+`
+
+func parse(t *testing.T, src, filename string) *ast.ProgramNode {
+	t.Helper()
+	program, err := parser.Parse(strings.NewReader(src), filename)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v\n%s", filename, err, src)
+	}
+	return program
+}
+
+func printProgram(t *testing.T, program *ast.ProgramNode, config *Config) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := config.Fprint(&b, program); err != nil {
+		t.Fatalf("Fprint error: %v", err)
+	}
+	return b.String()
+}
+
+func TestFprint(t *testing.T) {
+	if got := printProgram(t, parse(t, messy, "messy.tg"), &Config{}); got != canonical {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", got, canonical)
+	}
+
+	var b bytes.Buffer
+	if err := Fprint(&b, parse(t, canonical, "canonical.tg")); err != nil || b.String() != canonical {
+		t.Errorf("expected canonical source to print unchanged, got %v:\n%s", err, b.String())
+	}
+}
+
+func TestFprintIndent(t *testing.T) {
+	got := printProgram(t, parse(t, "enum Status { active\n pending: string }", "status.tg"), &Config{Indent: 4})
+	if expected := "enum Status {\n    active\n    pending: string\n}\n"; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestFprintEmpty(t *testing.T) {
+	if got := printProgram(t, &ast.ProgramNode{}, &Config{}); got != "" {
+		t.Errorf("expected no output for an empty program, got %q", got)
+	}
+	imports := &ast.ProgramNode{Imports: []*ast.ImportNode{{Path: "b"}, {Path: "a"}}}
+	if got := printProgram(t, imports, &Config{}); got != "import a\nimport b\n" {
+		t.Errorf("expected only the sorted imports, got %q", got)
+	}
+}
+
+func TestFprintErrors(t *testing.T) {
+	program := &ast.ProgramNode{Declarations: []ast.Declaration{
+		&ast.ConstantNode{Name: "BROKEN"},
+	}}
+	if err := Fprint(&bytes.Buffer{}, program); err == nil || !strings.Contains(err.Error(), "BROKEN") {
+		t.Errorf("expected an error naming the constant, got %v", err)
+	}
+
+	failing := errors.New("disk full")
+	if err := Fprint(failingWriter{failing}, parse(t, canonical, "canonical.tg")); !errors.Is(err, failing) {
+		t.Errorf("expected the write error, got %v", err)
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+// TestRoundTrip checks that printing every .tg fixture of the repository and
+// parsing the output gives an equal program, and that printing is stable
+func TestRoundTrip(t *testing.T) {
+	sources := map[string]string{"messy.tg": messy}
+	root := filepath.Join("..", "..")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != root {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".tg" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sources[path] = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to collect fixtures: %v", err)
+	}
+	if len(sources) < 10 {
+		t.Fatalf("expected the repository's fixtures, found %d files", len(sources))
+	}
+
+	for path, src := range sources {
+		t.Run(path, func(t *testing.T) {
+			program, err := parser.Parse(strings.NewReader(src), path)
+			if err != nil {
+				t.Skipf("fixture doesn't parse: %v", err)
+			}
+			printed := printProgram(t, program, &Config{})
+			reparsed := parse(t, printed, path)
+
+			sort.Slice(program.Imports, func(i, j int) bool { return program.Imports[i].Path < program.Imports[j].Path })
+			if expected, got := withoutPositions(t, program), withoutPositions(t, reparsed); expected != got {
+				t.Errorf("printed program parses differently:\n%s\n--- expected ---\n%s\n--- got ---\n%s", printed, expected, got)
+			}
+			if again := printProgram(t, reparsed, &Config{}); again != printed {
+				t.Errorf("printing is not stable:\n--- once ---\n%s\n--- twice ---\n%s", printed, again)
+			}
+		})
+	}
+}
+
+// withoutPositions returns the JSON encoding of a program with every
+// position removed
+func withoutPositions(t *testing.T, program *ast.ProgramNode) string {
+	t.Helper()
+	data, err := json.Marshal(program)
+	if err != nil {
+		t.Fatalf("failed to encode program: %v", err)
+	}
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatalf("failed to decode program: %v", err)
+	}
+	var strip func(v any)
+	strip = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			delete(v, "pos")
+			delete(v, "end")
+			for _, child := range v {
+				strip(child)
+			}
+		case []any:
+			for _, child := range v {
+				strip(child)
+			}
+		}
+	}
+	strip(tree)
+	data, _ = json.MarshalIndent(tree, "", "  ")
+	return string(data)
+}
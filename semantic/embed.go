@@ -0,0 +1,167 @@
+package semantic
+
+import (
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Field is a field of a struct once its embeddings are expanded
+type Field struct {
+	Node *ast.FieldNode
+	// Decl is the struct that declares the field
+	Decl *Decl
+	// Embed is the embedding of the struct the field comes through, nil for
+	// the struct's own fields
+	Embed *ast.EmbedNode
+}
+
+// Embedded returns the struct an embedding refers to. Embeddings that don't
+// resolve, or that refer to an enum, alias or constant, return false.
+func (m *Model) Embedded(embed *ast.EmbedNode) (*Decl, bool) {
+	decl, ok := m.Lookup(embed.Type)
+	if !ok || decl.Kind != "struct" {
+		return nil, false
+	}
+	return decl, true
+}
+
+// Fields returns the fields of a struct with its embeddings expanded, in
+// source order: the fields of an embedded struct, expanded in turn, take
+// the place of the embedding. Embeddings that aren't of a struct, and
+// embeddings of a struct that is already being expanded, are skipped; the
+// validator reports them.
+func (m *Model) Fields(decl *Decl) []*Field {
+	return m.fields(decl, nil, map[*Decl]bool{})
+}
+
+func (m *Model) fields(decl *Decl, through *ast.EmbedNode, expanding map[*Decl]bool) []*Field {
+	node, ok := decl.Node.(*ast.StructNode)
+	if !ok {
+		return nil
+	}
+	expanding[decl] = true
+	defer delete(expanding, decl)
+
+	var fields []*Field
+	for _, member := range node.Members() {
+		switch member := member.(type) {
+		case *ast.FieldNode:
+			fields = append(fields, &Field{Node: member, Decl: decl, Embed: through})
+		case *ast.EmbedNode:
+			embedded, ok := m.Embedded(member)
+			if !ok || expanding[embedded] {
+				continue
+			}
+			embed := through
+			if embed == nil {
+				embed = member
+			}
+			fields = append(fields, m.fields(embedded, embed, expanding)...)
+		}
+	}
+	return fields
+}
+
+// Flatten returns a copy of a module in which every embedding is replaced by
+// the fields of the embedded struct, so generators find all the fields of a
+// struct in its Fields. Embedded fields whose types are declared out of
+// sight of the embedding file refer to them by qualified name, and the
+// imports they need are added to the file. Declarations without embeddings
+// are shared with the module, which isn't modified.
+func Flatten(module *ast.Module) *ast.Module {
+	if module == nil {
+		return nil
+	}
+	return Build(module).flatten(module, "")
+}
+
+func (m *Model) flatten(module *ast.Module, dir string) *ast.Module {
+	flat := &ast.Module{
+		Path:       module.Path,
+		Name:       module.Name,
+		Files:      make(map[string]*ast.ProgramNode, len(module.Files)),
+		SubModules: make(map[string]*ast.Module, len(module.SubModules)),
+	}
+	for filename, program := range module.Files {
+		flat.Files[filename] = m.flattenProgram(m.byPath[joinPath(dir, filename)], program)
+	}
+	for name, subModule := range module.SubModules {
+		flat.SubModules[name] = m.flatten(subModule, joinPath(dir, name))
+	}
+	return flat
+}
+
+func (m *Model) flattenProgram(file *File, program *ast.ProgramNode) *ast.ProgramNode {
+	flat := &ast.ProgramNode{
+		BaseNode:     program.BaseNode,
+		Imports:      append([]*ast.ImportNode(nil), program.Imports...),
+		Declarations: make([]ast.Declaration, len(program.Declarations)),
+	}
+	for i, decl := range program.Declarations {
+		node, ok := decl.(*ast.StructNode)
+		if !ok || len(node.Embeds) == 0 {
+			flat.Declarations[i] = decl
+			continue
+		}
+		expanded := &ast.StructNode{BaseNode: node.BaseNode, Name: node.Name, Doc: node.Doc}
+		for _, field := range m.Fields(m.byNode[node]) {
+			if field.Embed == nil {
+				expanded.Fields = append(expanded.Fields, field.Node)
+				continue
+			}
+			copied := *field.Node
+			copied.Type = m.requalify(field.Node.Type, file, flat)
+			expanded.Fields = append(expanded.Fields, &copied)
+		}
+		flat.Declarations[i] = expanded
+	}
+	return flat
+}
+
+// requalify returns a copy of a type of an embedded field that refers to
+// the same declarations when written in file, adding the imports this
+// needs to program
+func (m *Model) requalify(t ast.Type, file *File, program *ast.ProgramNode) ast.Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		named := *t
+		if decl, ok := m.Lookup(t); ok {
+			named.Name = m.nameIn(decl, file, program)
+		}
+		return &named
+	case *ast.ArrayType:
+		return &ast.ArrayType{BaseNode: t.BaseNode, ElementType: m.requalify(t.ElementType, file, program)}
+	case *ast.MapType:
+		return &ast.MapType{BaseNode: t.BaseNode, KeyType: m.requalify(t.KeyType, file, program), ValueType: m.requalify(t.ValueType, file, program)}
+	case *ast.OptionalType:
+		return &ast.OptionalType{BaseNode: t.BaseNode, ElementType: m.requalify(t.ElementType, file, program)}
+	}
+	return t
+}
+
+// nameIn returns the name a declaration is referred to by in file, adding
+// an import of its module to program when the file doesn't import it yet
+func (m *Model) nameIn(decl *Decl, file *File, program *ast.ProgramNode) string {
+	if local, ok := m.Resolve(decl.Name, file.Path); ok && local == decl {
+		return decl.Name
+	}
+	for _, imp := range program.Imports {
+		if imported, ok := m.ResolveImport(imp.Path, decl.Name); ok && imported == decl {
+			return qualifier(imp.Path) + "." + decl.Name
+		}
+	}
+
+	path := strings.ReplaceAll(decl.File.Dir, "/", ".")
+	if path == "" {
+		path = decl.File.ModulePath()
+	}
+	program.Imports = append(program.Imports, &ast.ImportNode{Path: path})
+	return qualifier(path) + "." + decl.Name
+}
+
+// qualifier returns the module name that types imported with a path are
+// qualified with, the path's last component
+func qualifier(importPath string) string {
+	return importPath[strings.LastIndex(importPath, ".")+1:]
+}
@@ -0,0 +1,126 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+var embedSources = map[string]string{
+	"main.tg": `import audit
+struct Order {
+	id: int64
+	...audit.Stamped
+	total: decimal
+}
+struct Loop {
+	...Loop
+	id: int64
+}
+struct Bad {
+	...Status
+	...Missing
+}
+enum Status {
+	active
+}`,
+	"audit/stamp.tg": `struct Stamped {
+	...Times
+	by: ?Actor
+}
+struct Times {
+	created: datetime
+}
+struct Actor {
+	name: string
+}`,
+	"shop/cart.tg": `import main
+struct Cart {
+	...main.Order
+}`,
+}
+
+// fieldsString describes fields as "name: type", with the struct embedded
+// fields come from
+func fieldsString(fields []*Field) string {
+	var parts []string
+	for _, field := range fields {
+		part := field.Node.String()
+		if field.Embed != nil {
+			part += " (" + field.Decl.QualifiedName() + " via " + field.Embed.Type.Name + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func TestFields(t *testing.T) {
+	m := Build(buildModule(t, embedSources))
+
+	tests := []struct {
+		decl     string
+		expected string
+	}{
+		{"Order", "id: int64, created: datetime (audit.Times via audit.Stamped), by: ?Actor (audit.Stamped via audit.Stamped), total: decimal"},
+		{"Loop", "id: int64"},
+		{"Bad", ""},
+		{"audit.Stamped", "created: datetime (audit.Times via Times), by: ?Actor"},
+		{"Status", ""},
+	}
+	for _, tt := range tests {
+		if got := fieldsString(m.Fields(findDecl(t, m, tt.decl))); got != tt.expected {
+			t.Errorf("Fields(%s):\nexpected %s\ngot      %s", tt.decl, tt.expected, got)
+		}
+	}
+
+	bad := findDecl(t, m, "Bad").Node.(*ast.StructNode)
+	for _, embed := range bad.Embeds {
+		if decl, ok := m.Embedded(embed); ok {
+			t.Errorf("expected ...%s not to embed a struct, got %s", embed.Type.Name, decl.QualifiedName())
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	module := buildModule(t, embedSources)
+	flat := Flatten(module)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"main.tg", "import audit\n\nstruct Order {\n  id: int64\n  created: datetime\n  by: ?audit.Actor\n  total: decimal\n}"},
+		{"audit/stamp.tg", "struct Stamped {\n  created: datetime\n  by: ?Actor\n}"},
+		{"shop/cart.tg", "import main\nimport audit\n\nstruct Cart {\n  id: int64\n  created: datetime\n  by: ?audit.Actor\n  total: decimal\n}"},
+	}
+	for _, tt := range tests {
+		program := flat.AllFiles()[tt.path]
+		if got := program.String(); !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("%s:\nexpected %s\ngot      %s", tt.path, tt.expected, got)
+		}
+	}
+
+	// The requalified names resolve to the same declarations
+	m := Build(flat)
+	cart := findDecl(t, m, "shop.Cart").Node.(*ast.StructNode)
+	actor, ok := m.Lookup(cart.Fields[2].Type.(*ast.NamedType))
+	if !ok || actor.QualifiedName() != "audit.Actor" {
+		t.Errorf("expected the embedded by field to refer to audit.Actor, got %v", actor)
+	}
+
+	// The module itself is unchanged, and declarations without embeddings are shared
+	main := module.Files["main.tg"]
+	if order := main.Declarations[0].(*ast.StructNode); len(order.Fields) != 2 || len(order.Embeds) != 1 {
+		t.Errorf("expected Order to keep its embedding, got %s", order)
+	}
+	if len(main.Imports) != 1 || len(module.SubModules["shop"].Files["cart.tg"].Imports) != 1 {
+		t.Errorf("expected no imports to be added to the module")
+	}
+	if flat.Files["main.tg"].Declarations[3] != main.Declarations[3] {
+		t.Errorf("expected Status to be shared")
+	}
+	if Flatten(nil) != nil {
+		t.Errorf("expected no module for nil")
+	}
+}
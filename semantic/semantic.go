@@ -0,0 +1,478 @@
+// Package semantic resolves the type references of a TypeGen module: which
+// declaration, in which file, each named type refers to. Generators and the
+// validator query the resolved model instead of looking names up themselves.
+package semantic
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// File is a .tg file of the module
+type File struct {
+	// Path is the slash-separated path relative to the root module, e.g. "auth/user.tg"
+	Path string
+	// Dir is the slash-separated submodule directory, "" for the root module
+	Dir     string
+	Program *ast.ProgramNode
+	// Imports maps the module name types are qualified with, the last
+	// component of the import path, to the import
+	Imports map[string]*ast.ImportNode
+	// Decls are the file's declarations in source order
+	Decls []*Decl
+}
+
+// ModulePath returns the file's path in dot notation without the extension,
+// e.g. "auth.user"
+func (f *File) ModulePath() string {
+	return strings.ReplaceAll(strings.TrimSuffix(f.Path, ".tg"), "/", ".")
+}
+
+// Decl is a declaration of the module
+type Decl struct {
+	Name string
+	// Kind is "struct", "enum", "alias" or "constant"
+	Kind string
+	File *File
+	Node ast.Declaration
+}
+
+// QualifiedName returns the name qualified by the submodule path in dot
+// notation, e.g. "auth.Token"
+func (d *Decl) QualifiedName() string {
+	if d.File.Dir == "" {
+		return d.Name
+	}
+	return strings.ReplaceAll(d.File.Dir, "/", ".") + "." + d.Name
+}
+
+// Pos returns the position of the declaration
+func (d *Decl) Pos() ast.Position {
+	return d.Node.Pos()
+}
+
+// Binding is the resolution of a named type reference
+type Binding struct {
+	Ref  *ast.NamedType
+	File *File
+	// Decl is the declaration the reference resolves to, nil if it doesn't
+	// resolve
+	Decl *Decl
+	// Import is the import a qualified reference resolves through
+	Import *ast.ImportNode
+	// Candidates lists every declaration an ambiguous reference could refer
+	// to; Decl is the first of them
+	Candidates []*Decl
+}
+
+// Resolved reports whether the reference resolves to a declaration
+func (b *Binding) Resolved() bool {
+	return b.Decl != nil
+}
+
+// Ambiguous reports whether the reference matches several declarations
+func (b *Binding) Ambiguous() bool {
+	return len(b.Candidates) > 1
+}
+
+// Model is the resolved form of a module
+type Model struct {
+	Module *ast.Module
+
+	files     []*File
+	byPath    map[string]*File
+	byProgram map[*ast.ProgramNode]*File
+	decls     []*Decl
+	byNode    map[ast.Declaration]*Decl
+	byName    map[string][]*Decl
+	bindings  []*Binding
+	byRef     map[*ast.NamedType]*Binding
+	deps      map[*Decl][]*Decl
+	direct    map[*Decl][]*Decl
+	cyclic    map[*Decl]bool
+}
+
+// Build resolves every type reference of a module and its submodules.
+// References that don't resolve are recorded with a nil Decl; the validator
+// reports them.
+func Build(module *ast.Module) *Model {
+	m := &Model{
+		Module:    module,
+		byPath:    make(map[string]*File),
+		byProgram: make(map[*ast.ProgramNode]*File),
+		byNode:    make(map[ast.Declaration]*Decl),
+		byName:    make(map[string][]*Decl),
+		byRef:     make(map[*ast.NamedType]*Binding),
+		deps:      make(map[*Decl][]*Decl),
+		direct:    make(map[*Decl][]*Decl),
+	}
+	if module != nil {
+		m.addModule(module, "")
+	}
+	sort.Slice(m.files, func(i, j int) bool { return m.files[i].Path < m.files[j].Path })
+	for _, file := range m.files {
+		m.decls = append(m.decls, file.Decls...)
+		for _, decl := range file.Decls {
+			m.byName[decl.Name] = append(m.byName[decl.Name], decl)
+		}
+	}
+
+	for _, file := range m.files {
+		ast.Inspect(file.Program, func(named *ast.NamedType) {
+			binding := m.bind(named.Name, file)
+			binding.Ref = named
+			m.bindings = append(m.bindings, binding)
+			m.byRef[named] = binding
+		})
+	}
+
+	for _, decl := range m.decls {
+		seen := make(map[*Decl]bool)
+		ast.Inspect(decl.Node, func(named *ast.NamedType) {
+			if target := m.byRef[named].Decl; target != nil && !seen[target] {
+				seen[target] = true
+				m.deps[decl] = append(m.deps[decl], target)
+			}
+		})
+		seen = make(map[*Decl]bool)
+		for _, t := range directTypes(decl.Node) {
+			if target, ok := m.Direct(t); ok && !seen[target] {
+				seen[target] = true
+				m.direct[decl] = append(m.direct[decl], target)
+			}
+		}
+	}
+	m.cyclic = m.findCycles()
+
+	return m
+}
+
+// addModule records the files and declarations of a module and its
+// submodules, dir being the module's slash-separated path
+func (m *Model) addModule(module *ast.Module, dir string) {
+	for filename, program := range module.Files {
+		file := &File{
+			Path:    joinPath(dir, filename),
+			Dir:     dir,
+			Program: program,
+			Imports: make(map[string]*ast.ImportNode),
+		}
+		for _, imp := range program.Imports {
+			parts := strings.Split(imp.Path, ".")
+			file.Imports[parts[len(parts)-1]] = imp
+		}
+		for _, node := range program.Declarations {
+			decl := &Decl{Name: node.DeclName(), Kind: declKind(node), File: file, Node: node}
+			if decl.Kind == "" {
+				continue
+			}
+			file.Decls = append(file.Decls, decl)
+			m.byNode[node] = decl
+		}
+		m.files = append(m.files, file)
+		m.byPath[file.Path] = file
+		m.byProgram[program] = file
+	}
+
+	for name, subModule := range module.SubModules {
+		m.addModule(subModule, joinPath(dir, name))
+	}
+}
+
+// bind resolves a type name as written in a file. Unqualified names resolve
+// within the file, then within its module (directory); qualified names like
+// "auth.Token" resolve through the file's imports.
+func (m *Model) bind(name string, file *File) *Binding {
+	binding := &Binding{File: file}
+
+	if !strings.Contains(name, ".") {
+		for _, decl := range file.Decls {
+			if decl.Name == name {
+				binding.Decl = decl
+				return binding
+			}
+		}
+		for _, decl := range m.byName[name] {
+			if decl.File.Dir == file.Dir && decl.File != file {
+				binding.Candidates = append(binding.Candidates, decl)
+			}
+		}
+	} else {
+		parts := strings.SplitN(name, ".", 2)
+		imp, imported := file.Imports[parts[0]]
+		if !imported {
+			return binding
+		}
+		binding.Import = imp
+		binding.Candidates = m.imported(imp.Path, parts[1])
+	}
+
+	if len(binding.Candidates) > 0 {
+		binding.Decl = binding.Candidates[0]
+	}
+	if len(binding.Candidates) == 1 {
+		binding.Candidates = nil
+	}
+	return binding
+}
+
+// imported returns the declarations named name that an import path can
+// refer to. The import names either a submodule directory or a file of the
+// module, possibly prefixed with the module's own name; when nothing matches
+// the full path, declarations whose directory or file ends with the
+// imported module name are returned.
+func (m *Model) imported(importPath, name string) []*Decl {
+	parts := strings.Split(importPath, ".")
+	moduleName := parts[len(parts)-1]
+
+	var matches, fallback []*Decl
+	for _, decl := range m.byName[name] {
+		dirPath := strings.ReplaceAll(decl.File.Dir, "/", ".")
+		filePath := decl.File.ModulePath()
+		if pathMatches(importPath, dirPath) || pathMatches(importPath, filePath) {
+			matches = append(matches, decl)
+		} else if pathMatches(dirPath, moduleName) || pathMatches(filePath, moduleName) {
+			fallback = append(fallback, decl)
+		}
+	}
+	if len(matches) > 0 {
+		return matches
+	}
+	return fallback
+}
+
+// pathMatches reports whether the dot-separated path is suffix or ends with it
+func pathMatches(path, suffix string) bool {
+	return suffix != "" && (path == suffix || strings.HasSuffix(path, "."+suffix))
+}
+
+// Files returns the module's files ordered by path
+func (m *Model) Files() []*File {
+	return m.files
+}
+
+// File returns the file at a slash-separated path relative to the root module
+func (m *Model) File(path string) (*File, bool) {
+	file, ok := m.byPath[path]
+	return file, ok
+}
+
+// FileOf returns the file a program was parsed from
+func (m *Model) FileOf(program *ast.ProgramNode) (*File, bool) {
+	file, ok := m.byProgram[program]
+	return file, ok
+}
+
+// Decls returns the module's declarations ordered by file, then in source
+// order
+func (m *Model) Decls() []*Decl {
+	return m.decls
+}
+
+// DeclOf returns the resolved form of a declaration node
+func (m *Model) DeclOf(node ast.Declaration) (*Decl, bool) {
+	decl, ok := m.byNode[node]
+	return decl, ok
+}
+
+// Bindings returns the binding of every named type of the module, ordered by
+// file, then in the order of ast.Walk
+func (m *Model) Bindings() []*Binding {
+	return m.bindings
+}
+
+// Binding returns the binding of a named type of the module
+func (m *Model) Binding(ref *ast.NamedType) (*Binding, bool) {
+	binding, ok := m.byRef[ref]
+	return binding, ok
+}
+
+// Lookup returns the declaration a named type of the module refers to
+func (m *Model) Lookup(ref *ast.NamedType) (*Decl, bool) {
+	if binding, ok := m.byRef[ref]; ok && binding.Decl != nil {
+		return binding.Decl, true
+	}
+	return nil, false
+}
+
+// Resolve returns the declaration a type name refers to when written in the
+// file at path, whether or not the file contains such a reference
+func (m *Model) Resolve(name, path string) (*Decl, bool) {
+	file, ok := m.byPath[path]
+	if !ok {
+		return nil, false
+	}
+	binding := m.bind(name, file)
+	return binding.Decl, binding.Decl != nil
+}
+
+// ResolveImport returns the declaration named name that an import path
+// refers to
+func (m *Model) ResolveImport(importPath, name string) (*Decl, bool) {
+	if decls := m.imported(importPath, name); len(decls) > 0 {
+		return decls[0], true
+	}
+	return nil, false
+}
+
+// Underlying follows the aliases a type refers to and returns the first type
+// that isn't a reference to an alias. With "type Manager = User", the
+// underlying type of Manager is User. References that don't resolve and
+// alias cycles stop the chain at the last reference reached.
+func (m *Model) Underlying(t ast.Type) ast.Type {
+	seen := make(map[*Decl]bool)
+	for {
+		named, ok := t.(*ast.NamedType)
+		if !ok {
+			return t
+		}
+		decl, ok := m.Lookup(named)
+		if !ok || seen[decl] {
+			return t
+		}
+		alias, ok := decl.Node.(*ast.TypeAliasNode)
+		if !ok {
+			return t
+		}
+		seen[decl] = true
+		t = alias.Type
+	}
+}
+
+// DependenciesOf returns the declarations a declaration refers to through
+// its fields, payloads or aliased type, each once, in the order they are
+// first referenced
+func (m *Model) DependenciesOf(decl *Decl) []*Decl {
+	return m.deps[decl]
+}
+
+// DirectDependenciesOf returns the dependencies of a declaration that its
+// fields, embeddings, payloads or aliased type refer to without indirection,
+// that is not through ?, [] or a map, each once. A value of a struct or
+// alias contains a value of each of them; a value of an enum contains one
+// of them at most.
+func (m *Model) DirectDependenciesOf(decl *Decl) []*Decl {
+	return m.direct[decl]
+}
+
+// Direct returns the declaration a type refers to without indirection: the
+// declaration of a named type, but not of ?T, []T or a map
+func (m *Model) Direct(t ast.Type) (*Decl, bool) {
+	named, ok := t.(*ast.NamedType)
+	if !ok {
+		return nil, false
+	}
+	return m.Lookup(named)
+}
+
+// directTypes returns the types of the required fields, embeddings and
+// payloads of a declaration, or its aliased type
+func directTypes(node ast.Declaration) []ast.Type {
+	var types []ast.Type
+	switch node := node.(type) {
+	case *ast.StructNode:
+		for _, field := range node.Fields {
+			if !field.Optional {
+				types = append(types, field.Type)
+			}
+		}
+		for _, embed := range node.Embeds {
+			types = append(types, embed.Type)
+		}
+	case *ast.EnumNode:
+		for _, variant := range node.Variants {
+			if variant.Payload != nil {
+				types = append(types, variant.Payload)
+			}
+		}
+	case *ast.TypeAliasNode:
+		types = append(types, node.Type)
+	}
+	return types
+}
+
+// IsCyclic reports whether a declaration depends on itself, directly or
+// through other declarations
+func (m *Model) IsCyclic(decl *Decl) bool {
+	return m.cyclic[decl]
+}
+
+// findCycles returns the declarations that are part of a dependency cycle,
+// found as the strongly connected components of the dependency graph
+func (m *Model) findCycles() map[*Decl]bool {
+	cyclic := make(map[*Decl]bool)
+	index := make(map[*Decl]int)
+	lowLink := make(map[*Decl]int)
+	onStack := make(map[*Decl]bool)
+	var stack []*Decl
+
+	var visit func(decl *Decl)
+	visit = func(decl *Decl) {
+		index[decl] = len(index)
+		lowLink[decl] = index[decl]
+		stack = append(stack, decl)
+		onStack[decl] = true
+
+		for _, dep := range m.deps[decl] {
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				lowLink[decl] = min(lowLink[decl], lowLink[dep])
+			} else if onStack[dep] {
+				lowLink[decl] = min(lowLink[decl], index[dep])
+			}
+		}
+
+		if lowLink[decl] != index[decl] {
+			return
+		}
+		var component []*Decl
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == decl {
+				break
+			}
+		}
+		selfReference := false
+		for _, dep := range m.deps[decl] {
+			selfReference = selfReference || dep == decl
+		}
+		if len(component) > 1 || selfReference {
+			for _, member := range component {
+				cyclic[member] = true
+			}
+		}
+	}
+
+	for _, decl := range m.decls {
+		if _, visited := index[decl]; !visited {
+			visit(decl)
+		}
+	}
+	return cyclic
+}
+
+func declKind(node ast.Declaration) string {
+	switch node.(type) {
+	case *ast.StructNode:
+		return "struct"
+	case *ast.EnumNode:
+		return "enum"
+	case *ast.TypeAliasNode:
+		return "alias"
+	case *ast.ConstantNode:
+		return "constant"
+	}
+	return ""
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,382 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// buildModule parses sources keyed by slash-separated path into a module
+// with a submodule per directory
+func buildModule(t *testing.T, sources map[string]string) *ast.Module {
+	t.Helper()
+	root := ast.NewModule("root", map[string]*ast.ProgramNode{})
+	for path, source := range sources {
+		program, err := parser.Parse(strings.NewReader(source), path)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", path, err)
+		}
+		module := root
+		parts := strings.Split(path, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			sub, ok := module.SubModules[dir]
+			if !ok {
+				sub = ast.NewModule(dir, map[string]*ast.ProgramNode{})
+				module.SubModules[dir] = sub
+			}
+			module = sub
+		}
+		module.Files[parts[len(parts)-1]] = program
+	}
+	return root
+}
+
+// findDecl returns the declaration of the model with a qualified name
+func findDecl(t *testing.T, m *Model, qualifiedName string) *Decl {
+	t.Helper()
+	for _, decl := range m.Decls() {
+		if decl.QualifiedName() == qualifiedName {
+			return decl
+		}
+	}
+	t.Fatalf("declaration %s not found", qualifiedName)
+	return nil
+}
+
+// names returns the qualified names of declarations
+func names(decls []*Decl) string {
+	var parts []string
+	for _, decl := range decls {
+		parts = append(parts, decl.QualifiedName())
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestResolve(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"main.tg": `import auth
+import root.billing.invoice
+struct Session {
+	user: auth.User
+	role: Role
+	invoice: invoice.Invoice
+}`,
+		"roles.tg": `enum Role {
+	admin
+}`,
+		"auth/user.tg": `struct User {
+	id: int64
+	token: Token
+}`,
+		"auth/token.tg": `struct Token {
+	value: string
+}`,
+		"billing/invoice.tg": `struct Invoice {
+	id: int64
+}`,
+		"billing/other.tg": `struct Invoice {
+	id: int64
+}`,
+	}))
+
+	tests := []struct {
+		name     string
+		file     string
+		expected string // file of the resolved declaration, "" if unresolved
+	}{
+		{"Role", "main.tg", "roles.tg"},                      // another file of the module
+		{"Session", "roles.tg", "main.tg"},                   // declared after use
+		{"Token", "auth/user.tg", "auth/token.tg"},           // within a submodule
+		{"auth.User", "main.tg", "auth/user.tg"},             // imported submodule
+		{"invoice.Invoice", "main.tg", "billing/invoice.tg"}, // imported file, prefixed with the module name
+		{"User", "main.tg", ""},                              // not in the same module
+		{"Role", "auth/user.tg", ""},                         // not in the submodule
+		{"auth.User", "roles.tg", ""},                        // auth is not imported
+		{"auth.Missing", "main.tg", ""},                      // not declared
+		{"Session", "missing.tg", ""},                        // not a file of the module
+	}
+	for _, tt := range tests {
+		decl, found := m.Resolve(tt.name, tt.file)
+		switch {
+		case tt.expected == "" && found:
+			t.Errorf("Resolve(%q, %q): expected no match, got %s", tt.name, tt.file, decl.File.Path)
+		case tt.expected != "" && (!found || decl.File.Path != tt.expected):
+			t.Errorf("Resolve(%q, %q): expected %s, got %v", tt.name, tt.file, tt.expected, decl)
+		}
+	}
+
+	if decl, _ := m.ResolveImport("auth", "Token"); decl == nil || decl.File.Path != "auth/token.tg" {
+		t.Errorf("ResolveImport(auth, Token): expected auth/token.tg, got %v", decl)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"main.tg": `import auth
+struct Session {
+	user: auth.User
+	owner: Owner
+	missing: Missing
+}`,
+		"owner.tg": `type Owner = auth.User`,
+		"auth/user.tg": `struct User {
+	id: int64
+}`,
+	}))
+
+	file, ok := m.File("main.tg")
+	if !ok {
+		t.Fatal("expected main.tg in the model")
+	}
+	if fileOf, _ := m.FileOf(file.Program); fileOf != file {
+		t.Errorf("FileOf should return the file of its program")
+	}
+
+	var got []string
+	for _, binding := range m.Bindings() {
+		if binding.File != file {
+			continue
+		}
+		target := "<unresolved>"
+		if binding.Resolved() {
+			target = binding.Decl.File.Path
+		}
+		got = append(got, binding.Ref.Name+"="+target)
+	}
+	expected := "auth.User=auth/user.tg Owner=owner.tg Missing=<unresolved>"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("expected bindings %q, got %q", expected, strings.Join(got, " "))
+	}
+
+	session := file.Program.Declarations[0].(*ast.StructNode)
+	binding, ok := m.Binding(session.Fields[0].Type.(*ast.NamedType))
+	if !ok || binding.Import == nil || binding.Import.Path != "auth" {
+		t.Errorf("expected auth.User to resolve through the auth import, got %+v", binding)
+	}
+	if binding, _ := m.Binding(session.Fields[1].Type.(*ast.NamedType)); binding.Import != nil {
+		t.Errorf("expected no import for an unqualified reference, got %v", binding.Import)
+	}
+	if _, ok := m.Lookup(&ast.NamedType{Name: "Owner"}); ok {
+		t.Error("Lookup should only find named types of the module")
+	}
+	if decl, ok := m.DeclOf(session); !ok || decl.Kind != "struct" || decl.QualifiedName() != "Session" {
+		t.Errorf("DeclOf: expected struct Session, got %+v", decl)
+	}
+}
+
+func TestAmbiguousReferences(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"main.tg": `import shared
+struct Holder {
+	local: Thing
+	remote: shared.Item
+	own: Own
+}
+struct Own {
+	id: int64
+}`,
+		"a.tg": `struct Thing {
+	id: int64
+}`,
+		"b.tg": `struct Thing {
+	id: int64
+}`,
+		"c.tg": `struct Own {
+	id: int64
+}`,
+		"shared/x.tg": `struct Item {
+	id: int64
+}`,
+		"shared/y.tg": `struct Item {
+	id: int64
+}`,
+		"shared/one.tg": `struct Single {
+	id: int64
+}`,
+	}))
+
+	holder := findDecl(t, m, "Holder").Node.(*ast.StructNode)
+	tests := []struct {
+		field      int
+		candidates string
+		decl       string
+	}{
+		{0, "Thing,Thing", "a.tg"},
+		{1, "shared.Item,shared.Item", "shared/x.tg"},
+		{2, "", "main.tg"}, // the file's own declaration wins
+	}
+	for _, tt := range tests {
+		binding, _ := m.Binding(holder.Fields[tt.field].Type.(*ast.NamedType))
+		if got := names(binding.Candidates); got != tt.candidates {
+			t.Errorf("field %d: expected candidates %q, got %q", tt.field, tt.candidates, got)
+		}
+		if binding.Ambiguous() != (tt.candidates != "") {
+			t.Errorf("field %d: expected Ambiguous() to be %v", tt.field, tt.candidates != "")
+		}
+		if binding.Decl == nil || binding.Decl.File.Path != tt.decl {
+			t.Errorf("field %d: expected the first candidate from %s, got %v", tt.field, tt.decl, binding.Decl)
+		}
+	}
+}
+
+func TestUnderlying(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"main.tg": `import auth
+struct Team {
+	manager: Manager
+	lead: Lead
+	ids: IDs
+	loop: LoopA
+	lost: Lost
+}
+type Manager = User
+type Lead = Manager
+type IDs = []UserID
+type UserID = int64
+type LoopA = LoopB
+type LoopB = LoopA
+type Lost = Missing
+type Remote = auth.Member`,
+		"user.tg": `struct User {
+	id: int64
+}`,
+		"auth/member.tg": `type Member = Person
+struct Person {
+	id: int64
+}`,
+	}))
+
+	team := findDecl(t, m, "Team").Node.(*ast.StructNode)
+	tests := []struct {
+		field    int
+		expected string
+	}{
+		{0, "User"},     // alias of a struct
+		{1, "User"},     // chain of aliases
+		{2, "[]UserID"}, // aliases of composite types aren't expanded inside
+		{3, "LoopA"},    // stops when the chain cycles
+		{4, "Missing"},  // stops at unresolved references
+	}
+	for _, tt := range tests {
+		if got := m.Underlying(team.Fields[tt.field].Type).String(); got != tt.expected {
+			t.Errorf("field %s: expected underlying type %s, got %s", team.Fields[tt.field].Name, tt.expected, got)
+		}
+	}
+
+	remote := findDecl(t, m, "Remote").Node.(*ast.TypeAliasNode)
+	underlying := m.Underlying(remote.Type)
+	if decl, ok := m.Lookup(underlying.(*ast.NamedType)); !ok || decl.QualifiedName() != "auth.Person" {
+		t.Errorf("expected auth.Member to resolve to auth.Person across modules, got %v", decl)
+	}
+	if primitive := m.Underlying(&ast.PrimitiveType{Name: "string"}); primitive.String() != "string" {
+		t.Errorf("expected a primitive type to be its own underlying type, got %s", primitive)
+	}
+}
+
+func TestDependenciesAndCycles(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"main.tg": `import auth
+struct User {
+	manager: ?Manager
+	reports: []User
+	team: Team
+	token: auth.Token
+	again: Team
+}
+type Manager = User
+struct Team {
+	members: [string]User
+}
+enum Result {
+	ok: Leaf
+	err: string
+}
+struct Leaf {
+	id: int64
+}
+struct Node {
+	next: ?Node
+}
+const LIMIT = 10`,
+		"auth/token.tg": `struct Token {
+	owner: Owner
+}
+struct Owner {
+	tokens: []Token
+}`,
+	}))
+
+	tests := []struct {
+		decl   string
+		deps   string
+		direct string
+		cyclic bool
+	}{
+		{"User", "Manager,User,Team,auth.Token", "Team,auth.Token", true},
+		{"Manager", "User", "User", true},
+		{"Team", "User", "", true},
+		{"Result", "Leaf", "Leaf", false},
+		{"Leaf", "", "", false},
+		{"Node", "Node", "", true},
+		{"LIMIT", "", "", false},
+		{"auth.Token", "auth.Owner", "auth.Owner", true},
+		{"auth.Owner", "auth.Token", "", true},
+	}
+	for _, tt := range tests {
+		decl := findDecl(t, m, tt.decl)
+		if got := names(m.DependenciesOf(decl)); got != tt.deps {
+			t.Errorf("DependenciesOf(%s): expected %q, got %q", tt.decl, tt.deps, got)
+		}
+		if got := names(m.DirectDependenciesOf(decl)); got != tt.direct {
+			t.Errorf("DirectDependenciesOf(%s): expected %q, got %q", tt.decl, tt.direct, got)
+		}
+		if got := m.IsCyclic(decl); got != tt.cyclic {
+			t.Errorf("IsCyclic(%s): expected %v, got %v", tt.decl, tt.cyclic, got)
+		}
+	}
+}
+
+func TestFilesAndDecls(t *testing.T) {
+	m := Build(buildModule(t, map[string]string{
+		"zeta.tg": `struct Zeta {
+	id: int64
+}`,
+		"alpha.tg": `import auth
+const MAX = 1
+struct Alpha {
+	id: int64
+}`,
+		"auth/token.tg": `struct Token {
+	value: string
+}`,
+		"auth/inner/x.tg": `enum X {
+	a
+}`,
+	}))
+
+	var paths []string
+	for _, file := range m.Files() {
+		paths = append(paths, file.Path+"@"+file.Dir)
+	}
+	expected := "alpha.tg@ auth/inner/x.tg@auth/inner auth/token.tg@auth zeta.tg@"
+	if strings.Join(paths, " ") != expected {
+		t.Errorf("expected files %q, got %q", expected, strings.Join(paths, " "))
+	}
+
+	if got := names(m.Decls()); got != "MAX,Alpha,auth.inner.X,auth.Token,Zeta" {
+		t.Errorf("unexpected declaration order %q", got)
+	}
+	alpha, _ := m.File("alpha.tg")
+	if alpha.Imports["auth"] == nil || alpha.ModulePath() != "alpha" {
+		t.Errorf("expected alpha.tg to import auth, got %v", alpha.Imports)
+	}
+	if x, _ := m.File("auth/inner/x.tg"); x.ModulePath() != "auth.inner.x" {
+		t.Errorf("expected module path auth.inner.x, got %s", x.ModulePath())
+	}
+
+	if empty := Build(nil); len(empty.Files()) != 0 || len(empty.Decls()) != 0 {
+		t.Error("expected an empty model for a nil module")
+	}
+}
@@ -0,0 +1,295 @@
+// Package stats summarizes a TypeGen module: how many declarations of each
+// kind it has, how fields are distributed over structs, how deeply types are
+// nested, and how declarations reference each other.
+package stats
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WhatsApp-Platform/typegen/graph"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Counts are the number of files and declarations of a module
+type Counts struct {
+	Files     int `json:"files"`
+	Structs   int `json:"structs"`
+	Enums     int `json:"enums"`
+	Aliases   int `json:"aliases"`
+	Constants int `json:"constants"`
+	Fields    int `json:"fields"`
+	Variants  int `json:"variants"`
+}
+
+func (c *Counts) add(other Counts) {
+	c.Files += other.Files
+	c.Structs += other.Structs
+	c.Enums += other.Enums
+	c.Aliases += other.Aliases
+	c.Constants += other.Constants
+	c.Fields += other.Fields
+	c.Variants += other.Variants
+}
+
+// Distribution summarizes the number of fields of the structs of a module
+type Distribution struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Median float64 `json:"median"`
+	Mean   float64 `json:"mean"`
+	// Buckets holds how many structs have each number of fields, by number of fields
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Bucket is the number of structs with a given number of fields
+type Bucket struct {
+	Fields  int `json:"fields"`
+	Structs int `json:"structs"`
+}
+
+// Nesting is the most deeply nested type expression of a module
+type Nesting struct {
+	// Depth counts the arrays, maps and optionals around the innermost type:
+	// int64 is 0 and []?User is 2
+	Depth int `json:"depth"`
+	// Declaration is the qualified name of the declaration holding the type,
+	// followed by the field or variant name, e.g. "auth.Session.roles"
+	Declaration string `json:"declaration,omitempty"`
+	// Type is the type as written, e.g. "[]map[string]?Item"
+	Type string `json:"type,omitempty"`
+}
+
+// Submodule is the breakdown of one module directory, without its submodules
+type Submodule struct {
+	// Module is the slash-separated submodule path, "" for the root module
+	Module string `json:"module"`
+	Counts
+}
+
+// Stats summarizes a module and its submodules
+type Stats struct {
+	Module string `json:"module"`
+	// Counts covers the module and all its submodules
+	Counts
+	FieldsPerStruct Distribution `json:"fields_per_struct"`
+	DeepestNesting  Nesting      `json:"deepest_nesting"`
+	// CrossFileReferences counts the references to a declaration of another file
+	CrossFileReferences int `json:"cross_file_references"`
+	// Cycles are the groups of declarations that reference each other,
+	// directly or indirectly; each is sorted by ID
+	Cycles [][]string `json:"cycles"`
+	// Submodules are ordered by path, starting with the root module
+	Submodules []Submodule `json:"submodules"`
+}
+
+// Compute returns the statistics of a module. The result only depends on the
+// module's content, so the same module always gives the same statistics.
+func Compute(module *ast.Module) *Stats {
+	s := &Stats{Module: module.Name, Cycles: [][]string{}}
+
+	var fieldCounts []int
+	var walk func(m *ast.Module, dir string)
+	walk = func(m *ast.Module, dir string) {
+		sub := Submodule{Module: dir}
+		for _, filename := range sortedKeys(m.Files) {
+			sub.Files++
+			for _, decl := range m.Files[filename].Declarations {
+				switch d := decl.(type) {
+				case *ast.StructNode:
+					sub.Structs++
+					sub.Fields += len(d.Fields)
+					fieldCounts = append(fieldCounts, len(d.Fields))
+					for _, field := range d.Fields {
+						s.nested(qualify(dir, d.Name)+"."+field.Name, field.Type, field.Optional)
+					}
+				case *ast.EnumNode:
+					sub.Enums++
+					sub.Variants += len(d.Variants)
+					for _, variant := range d.Variants {
+						if variant.Payload != nil {
+							s.nested(qualify(dir, d.Name)+"."+variant.Name, variant.Payload, false)
+						}
+					}
+				case *ast.TypeAliasNode:
+					sub.Aliases++
+					s.nested(qualify(dir, d.Name), d.Type, false)
+				case *ast.ConstantNode:
+					sub.Constants++
+				}
+			}
+		}
+		s.Counts.add(sub.Counts)
+		s.Submodules = append(s.Submodules, sub)
+
+		for _, name := range sortedKeys(m.SubModules) {
+			walk(m.SubModules[name], path.Join(dir, name))
+		}
+	}
+	walk(module, "")
+	sort.Slice(s.Submodules, func(i, j int) bool { return s.Submodules[i].Module < s.Submodules[j].Module })
+
+	s.FieldsPerStruct = distribution(fieldCounts)
+
+	g := graph.Build(module)
+	files := make(map[string]string, len(g.Nodes))
+	for _, node := range g.Nodes {
+		files[node.ID] = node.File
+	}
+	for _, edge := range g.Edges {
+		if files[edge.From] != files[edge.To] {
+			s.CrossFileReferences++
+		}
+	}
+	s.Cycles = append(s.Cycles, cycles(g)...)
+
+	return s
+}
+
+// nested records t as the deepest nesting if no earlier type is as deep
+func (s *Stats) nested(declaration string, t ast.Type, optional bool) {
+	depth := depth(t)
+	typeString := t.String()
+	if optional {
+		depth++
+		typeString = "?" + typeString
+	}
+	if depth > s.DeepestNesting.Depth {
+		s.DeepestNesting = Nesting{Depth: depth, Declaration: declaration, Type: typeString}
+	}
+}
+
+// depth counts the containers around the innermost type of t
+func depth(t ast.Type) int {
+	switch t := t.(type) {
+	case *ast.ArrayType:
+		return 1 + depth(t.ElementType)
+	case *ast.MapType:
+		return 1 + max(depth(t.KeyType), depth(t.ValueType))
+	case *ast.OptionalType:
+		return 1 + depth(t.ElementType)
+	}
+	return 0
+}
+
+func distribution(counts []int) Distribution {
+	d := Distribution{Buckets: []Bucket{}}
+	if len(counts) == 0 {
+		return d
+	}
+
+	sorted := append([]int(nil), counts...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, count := range sorted {
+		total += count
+		if n := len(d.Buckets); n > 0 && d.Buckets[n-1].Fields == count {
+			d.Buckets[n-1].Structs++
+		} else {
+			d.Buckets = append(d.Buckets, Bucket{Fields: count, Structs: 1})
+		}
+	}
+
+	d.Min, d.Max = sorted[0], sorted[len(sorted)-1]
+	d.Mean = float64(total) / float64(len(sorted))
+	if middle := len(sorted) / 2; len(sorted)%2 == 1 {
+		d.Median = float64(sorted[middle])
+	} else {
+		d.Median = float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+	return d
+}
+
+// cycles returns the strongly connected components of the graph that form a
+// cycle: those with more than one declaration, and declarations referencing
+// themselves. Members and cycles are ordered by ID.
+func cycles(g *graph.Graph) [][]string {
+	var result [][]string
+	for _, component := range g.TopologicalOrder() {
+		if !component.Cyclic {
+			continue
+		}
+		var ids []string
+		for _, node := range component.Nodes {
+			ids = append(ids, node.ID)
+		}
+		sort.Strings(ids)
+		result = append(result, ids)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+// qualify joins a slash-separated submodule path and a name, like graph node IDs
+func qualify(module, name string) string {
+	if module == "" {
+		return name
+	}
+	return strings.ReplaceAll(module, "/", ".") + "." + name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String renders the statistics as a report of aligned tables
+func (s *Stats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Module %s\n\n", s.Module)
+	b.WriteString(table(s.Counts, s.Submodules))
+
+	d := s.FieldsPerStruct
+	b.WriteString("\nFields per struct\n")
+	if len(d.Buckets) == 0 {
+		b.WriteString("  no structs\n")
+	} else {
+		fmt.Fprintf(&b, "  min %d, median %g, mean %.2f, max %d\n", d.Min, d.Median, d.Mean, d.Max)
+		for _, bucket := range d.Buckets {
+			fmt.Fprintf(&b, "  %3d fields  %s %d\n", bucket.Fields, strings.Repeat("#", bucket.Structs), bucket.Structs)
+		}
+	}
+
+	b.WriteString("\nReferences\n")
+	if s.DeepestNesting.Depth == 0 {
+		b.WriteString("  deepest nesting        0\n")
+	} else {
+		fmt.Fprintf(&b, "  deepest nesting        %d (%s: %s)\n", s.DeepestNesting.Depth, s.DeepestNesting.Declaration, s.DeepestNesting.Type)
+	}
+	fmt.Fprintf(&b, "  cross-file references  %d\n", s.CrossFileReferences)
+	fmt.Fprintf(&b, "  cycles                 %d\n", len(s.Cycles))
+	for _, cycle := range s.Cycles {
+		fmt.Fprintf(&b, "    %s\n", strings.Join(cycle, ", "))
+	}
+	return b.String()
+}
+
+// table renders the counts of every submodule and the total
+func table(total Counts, submodules []Submodule) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  module\tfiles\tstructs\tenums\taliases\tconstants\tfields\tvariants")
+	row := func(name string, c Counts) {
+		fmt.Fprintf(w, "  %s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", name, c.Files, c.Structs, c.Enums, c.Aliases, c.Constants, c.Fields, c.Variants)
+	}
+	for _, sub := range submodules {
+		name := sub.Module
+		if name == "" {
+			name = "(root)"
+		}
+		row(name, sub.Counts)
+	}
+	if len(submodules) > 1 {
+		row("total", total)
+	}
+	w.Flush()
+	return b.String()
+}
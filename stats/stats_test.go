@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/WhatsApp-Platform/typegen/parser"
+)
+
+func computeLibrary(t *testing.T) *Stats {
+	t.Helper()
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "library"))
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+	return Compute(module)
+}
+
+func TestCompute(t *testing.T) {
+	s := computeLibrary(t)
+
+	expected := Counts{Files: 3, Structs: 6, Enums: 1, Aliases: 1, Constants: 2, Fields: 14, Variants: 3}
+	if s.Counts != expected {
+		t.Errorf("expected counts %+v, got %+v", expected, s.Counts)
+	}
+
+	expectedSubmodules := []Submodule{
+		{"", Counts{Files: 1, Structs: 3, Enums: 1, Aliases: 1, Constants: 2, Fields: 8, Variants: 3}},
+		{"people", Counts{Files: 1, Structs: 2, Fields: 4}},
+		{"people/contact", Counts{Files: 1, Structs: 1, Fields: 2}},
+	}
+	if !reflect.DeepEqual(s.Submodules, expectedSubmodules) {
+		t.Errorf("expected submodules %+v, got %+v", expectedSubmodules, s.Submodules)
+	}
+
+	expectedDistribution := Distribution{
+		Min:     1,
+		Max:     5,
+		Median:  2,
+		Mean:    14.0 / 6,
+		Buckets: []Bucket{{Fields: 1, Structs: 1}, {Fields: 2, Structs: 4}, {Fields: 5, Structs: 1}},
+	}
+	if !reflect.DeepEqual(s.FieldsPerStruct, expectedDistribution) {
+		t.Errorf("expected distribution %+v, got %+v", expectedDistribution, s.FieldsPerStruct)
+	}
+
+	expectedNesting := Nesting{Depth: 3, Declaration: "Book.ratings", Type: "[string][][]int32"}
+	if s.DeepestNesting != expectedNesting {
+		t.Errorf("expected nesting %+v, got %+v", expectedNesting, s.DeepestNesting)
+	}
+
+	// Book -> people.Author and people.Author -> people.contact.Address
+	if s.CrossFileReferences != 2 {
+		t.Errorf("expected 2 cross-file references, got %d", s.CrossFileReferences)
+	}
+
+	expectedCycles := [][]string{{"Book", "Series"}, {"people.Category"}}
+	if !reflect.DeepEqual(s.Cycles, expectedCycles) {
+		t.Errorf("expected cycles %v, got %v", expectedCycles, s.Cycles)
+	}
+}
+
+func TestComputeIsDeterministic(t *testing.T) {
+	first, err := json.Marshal(computeLibrary(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		again, err := json.Marshal(computeLibrary(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("statistics differ between runs:\n%s\n%s", first, again)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	output := computeLibrary(t).String()
+	for _, expected := range []string{
+		"Module library",
+		"  people/contact  1      1        0      0        0          2       0",
+		"  total           3      6        1      1        2          14      3",
+		"min 1, median 2, mean 2.33, max 5",
+		"deepest nesting        3 (Book.ratings: [string][][]int32)",
+		"cross-file references  2",
+		"cycles                 2\n    Book, Series\n    people.Category\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// AttributeSpec describes an attribute that fields and enum variants may
+// carry, written @name or @name(value)
+type AttributeSpec struct {
+	// Name is the attribute's name, without the @
+	Name string
+	// Description says what the attribute does, for documentation
+	Description string
+	// Value is the kind of constant the attribute takes: "int", "float",
+	// "string" or "bool", or "" for an attribute without a value
+	Value string
+	// ValueOptional allows the attribute to be written without its value
+	ValueOptional bool
+}
+
+var (
+	attributesMu sync.RWMutex
+	attributes   = map[string]AttributeSpec{}
+)
+
+// RegisterAttribute makes an attribute known to the validator. Generators
+// register the attributes they understand from init functions; attributes
+// nobody registered are reported as unknown_attribute.
+func RegisterAttribute(spec AttributeSpec) {
+	attributesMu.Lock()
+	defer attributesMu.Unlock()
+	attributes[spec.Name] = spec
+}
+
+// LookupAttribute returns the registered attribute with a name
+func LookupAttribute(name string) (AttributeSpec, bool) {
+	attributesMu.RLock()
+	defer attributesMu.RUnlock()
+	spec, ok := attributes[name]
+	return spec, ok
+}
+
+// Attributes returns the registered attributes, ordered by name
+func Attributes() []AttributeSpec {
+	attributesMu.RLock()
+	defer attributesMu.RUnlock()
+	specs := make([]AttributeSpec, 0, len(attributes))
+	for _, spec := range attributes {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+func init() {
+	RegisterAttribute(AttributeSpec{
+		Name:          "deprecated",
+		Description:   "Marks a field or variant as deprecated, with an optional message",
+		Value:         "string",
+		ValueOptional: true,
+	})
+	RegisterAttribute(AttributeSpec{
+		Name:        "sensitive",
+		Description: "Marks a field holding secrets or personal data, which generated code keeps out of logs",
+	})
+}
+
+// validateAttributes validates the attributes of a field or variant; owner
+// names it in messages, e.g. "field 'email'"
+func (v *Validator) validateAttributes(attrs []*ast.AttributeNode, owner, filename string) {
+	seen := make(map[string]bool)
+	for _, attr := range attrs {
+		pos := attr.Pos()
+		if seen[attr.Name] {
+			v.addError(
+				InvalidAttributeError,
+				fmt.Sprintf("duplicate attribute '@%s' on %s", attr.Name, owner),
+				filename,
+				pos.Line, pos.Column,
+				"remove one of the attributes",
+			)
+			continue
+		}
+		seen[attr.Name] = true
+
+		spec, ok := LookupAttribute(attr.Name)
+		if !ok {
+			v.addError(
+				UnknownAttributeError,
+				fmt.Sprintf("unknown attribute '@%s' on %s", attr.Name, owner),
+				filename,
+				pos.Line, pos.Column,
+				"check the spelling, or use an attribute a generator registers",
+			)
+			continue
+		}
+
+		switch kind := constantKind(attr.Value); {
+		case kind == "" && spec.Value != "" && !spec.ValueOptional:
+			v.addError(
+				InvalidAttributeError,
+				fmt.Sprintf("attribute '@%s' on %s needs a %s value", attr.Name, owner, spec.Value),
+				filename,
+				pos.Line, pos.Column,
+				fmt.Sprintf("write @%s(value)", attr.Name),
+			)
+		case kind != "" && spec.Value == "":
+			v.addError(
+				InvalidAttributeError,
+				fmt.Sprintf("attribute '@%s' on %s takes no value", attr.Name, owner),
+				filename,
+				pos.Line, pos.Column,
+				fmt.Sprintf("write @%s", attr.Name),
+			)
+		case kind != "" && kind != spec.Value:
+			v.addError(
+				InvalidAttributeError,
+				fmt.Sprintf("attribute '@%s' on %s takes a %s value, not %s", attr.Name, owner, spec.Value, kind),
+				filename,
+				pos.Line, pos.Column,
+				fmt.Sprintf("use a %s value", spec.Value),
+			)
+		}
+	}
+}
+
+// constantKind returns the kind of a constant value as AttributeSpec.Value
+// names it, or "" for nil
+func constantKind(value ast.ConstantValue) string {
+	switch value.(type) {
+	case *ast.IntConstant:
+		return "int"
+	case *ast.FloatConstant:
+		return "float"
+	case *ast.StringConstant:
+		return "string"
+	case *ast.BoolConstant:
+		return "bool"
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+)
+
+// validateInfiniteTypes reports the structs and enums that contain
+// themselves without indirection, such as "struct A { b: B }" and
+// "struct B { a: A }": no value of them is finite, so none can be built.
+// Cycles through ?, [] or a map are allowed, and so are cycles through an
+// enum with a variant outside the cycle. Cycles of aliases alone are
+// reported by validateAliasCycle.
+func (v *Validator) validateInfiniteTypes() {
+	model := v.registry.Model()
+	finite := finiteDecls(model)
+
+	for _, decl := range model.Decls() {
+		if finite[decl] || (decl.Kind != "struct" && decl.Kind != "enum") {
+			continue
+		}
+		cycle := directCycle(model, decl, decl, finite, map[*semantic.Decl]bool{})
+		if cycle == nil {
+			continue
+		}
+
+		chain := []string{decl.QualifiedName()}
+		for _, member := range cycle {
+			chain = append(chain, member.QualifiedName())
+		}
+		pos := decl.Pos()
+		v.addError(
+			InfiniteTypeError,
+			fmt.Sprintf("%s '%s' contains itself without indirection, so its values would be infinite: %s", decl.Kind, decl.Name, strings.Join(chain, " -> ")),
+			decl.File.Path,
+			pos.Line, pos.Column,
+			"make one of the references of the cycle optional with ?, or wrap it in [] or a map",
+		)
+	}
+}
+
+// finiteDecls returns the declarations that have finite values: structs
+// whose direct dependencies all do, enums with a variant without payload or
+// whose payload does, and aliases of types that do. References that don't
+// resolve are taken as finite.
+func finiteDecls(model *semantic.Model) map[*semantic.Decl]bool {
+	finite := make(map[*semantic.Decl]bool)
+	isFinite := func(t ast.Type) bool {
+		decl, ok := model.Direct(t)
+		return !ok || finite[decl]
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, decl := range model.Decls() {
+			if finite[decl] {
+				continue
+			}
+			ok := true
+			switch node := decl.Node.(type) {
+			case *ast.StructNode:
+				for _, field := range node.Fields {
+					ok = ok && (field.Optional || isFinite(field.Type))
+				}
+				for _, embed := range node.Embeds {
+					ok = ok && isFinite(embed.Type)
+				}
+			case *ast.EnumNode:
+				ok = false
+				for _, variant := range node.Variants {
+					ok = ok || variant.Payload == nil || isFinite(variant.Payload)
+				}
+			case *ast.TypeAliasNode:
+				ok = isFinite(node.Type)
+			}
+			if ok {
+				finite[decl] = true
+				changed = true
+			}
+		}
+	}
+	return finite
+}
+
+// directCycle returns the infinite declarations through which decl
+// directly depends on target, ending with target, or nil if it doesn't
+func directCycle(model *semantic.Model, decl, target *semantic.Decl, finite, seen map[*semantic.Decl]bool) []*semantic.Decl {
+	seen[decl] = true
+	for _, dep := range model.DirectDependenciesOf(decl) {
+		if dep == target {
+			return []*semantic.Decl{dep}
+		}
+		if finite[dep] || seen[dep] {
+			continue
+		}
+		if cycle := directCycle(model, dep, target, finite, seen); cycle != nil {
+			return append([]*semantic.Decl{dep}, cycle...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
+)
+
+// validateEmbeds validates the embeddings of a struct: each must name a
+// struct that doesn't embed the struct in turn
+func (v *Validator) validateEmbeds(s *ast.StructNode, filename string) {
+	model := v.registry.Model()
+	for _, embed := range s.Embeds {
+		pos := embed.Pos()
+		v.validateNamedType(embed.Type, filename)
+
+		target, ok := model.Lookup(embed.Type)
+		if !ok {
+			continue
+		}
+		if target.Kind != "struct" {
+			v.addError(
+				InvalidEmbedError,
+				fmt.Sprintf("cannot embed %s '%s' in struct '%s'", kindName(target.Kind), embed.Type.Name, s.Name),
+				filename,
+				pos.Line, pos.Column,
+				"only structs can be embedded; declare a field instead",
+			)
+			continue
+		}
+		if decl, ok := model.DeclOf(s); ok && embeds(model, target, decl, map[*semantic.Decl]bool{}) {
+			v.addError(
+				InvalidEmbedError,
+				fmt.Sprintf("embedding '%s' in struct '%s' forms a cycle", embed.Type.Name, s.Name),
+				filename,
+				pos.Line, pos.Column,
+				"remove one of the embeddings of the cycle",
+			)
+		}
+	}
+}
+
+// validateExpandedFields reports the fields of a struct, with its embeddings
+// expanded, whose name or JSON name is taken by an earlier field. Fields
+// named twice by the same struct are reported by validateField, and
+// collisions within an embedded struct with that struct.
+func (v *Validator) validateExpandedFields(s *ast.StructNode, filename string) {
+	model := v.registry.Model()
+	decl, ok := model.DeclOf(s)
+	if !ok {
+		return
+	}
+
+	names := make(map[string]*semantic.Field)
+	jsonNames := make(map[string]*semantic.Field)
+	for _, field := range model.Fields(decl) {
+		pos := field.Node.Pos()
+		if field.Embed != nil {
+			pos = field.Embed.Pos()
+		}
+
+		if existing, exists := names[field.Node.Name]; exists {
+			if !sameOrigin(existing, field) {
+				v.addError(
+					DuplicateFieldError,
+					fmt.Sprintf("%s collides with %s in struct '%s'", describeField(field), describeField(existing), s.Name),
+					filename,
+					pos.Line, pos.Column,
+					"rename one of the fields",
+				)
+			}
+			continue
+		}
+		names[field.Node.Name] = field
+
+		jsonName := field.Node.JSONName()
+		if existing, exists := jsonNames[jsonName]; exists {
+			if !sameOrigin(existing, field) || field.Embed == nil {
+				v.addError(
+					DuplicateFieldError,
+					fmt.Sprintf("%s and %s are both named '%s' in JSON in struct '%s'", describeField(existing), describeField(field), jsonName, s.Name),
+					filename,
+					pos.Line, pos.Column,
+					"change one of the wire names",
+				)
+			}
+			continue
+		}
+		jsonNames[jsonName] = field
+	}
+}
+
+// sameOrigin reports whether two fields are declared by the same struct and
+// come through the same embedding
+func sameOrigin(a, b *semantic.Field) bool {
+	return a.Decl == b.Decl && a.Embed == b.Embed
+}
+
+// embeds reports whether a struct embeds target, directly or through the
+// structs it embeds
+func embeds(model *semantic.Model, decl, target *semantic.Decl, seen map[*semantic.Decl]bool) bool {
+	if decl == target {
+		return true
+	}
+	if seen[decl] {
+		return false
+	}
+	seen[decl] = true
+	for _, embed := range decl.Node.(*ast.StructNode).Embeds {
+		if embedded, ok := model.Embedded(embed); ok && embeds(model, embedded, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeField names a field in messages, with the struct it is embedded
+// from
+func describeField(field *semantic.Field) string {
+	if field.Embed == nil {
+		return fmt.Sprintf("field '%s'", field.Node.Name)
+	}
+	return fmt.Sprintf("field '%s' embedded from '%s'", field.Node.Name, field.Decl.Name)
+}
+
+// kindName returns the name of a kind of declaration used in messages
+func kindName(kind string) string {
+	if kind == "alias" {
+		return "type alias"
+	}
+	return kind
+}
@@ -26,10 +26,21 @@ const (
 	
 	// Import errors
 	InvalidImportError ValidationErrorType = "invalid_import"
+	UnusedImportError  ValidationErrorType = "unused_import"
 	
 	// Structure errors
 	InvalidOptionalError ValidationErrorType = "invalid_optional"
 	InvalidConstantError ValidationErrorType = "invalid_constant"
+	InvalidEmbedError    ValidationErrorType = "invalid_embed"
+	CyclicAliasError     ValidationErrorType = "cyclic_alias"
+	InfiniteTypeError    ValidationErrorType = "infinite_type"
+	InvalidWireNameError ValidationErrorType = "invalid_wire_name"
+	UnusedTypeError      ValidationErrorType = "unused_type"
+	ReservedWordError    ValidationErrorType = "reserved_word"
+
+	// Attribute errors
+	UnknownAttributeError ValidationErrorType = "unknown_attribute"
+	InvalidAttributeError ValidationErrorType = "invalid_attribute"
 )
 
 // ValidationError represents a single validation error with context
@@ -40,6 +51,9 @@ type ValidationError struct {
 	Line        int
 	Column      int
 	Suggestion  string // Optional suggestion for fixing
+	// Severity is SeverityError for the errors of a result and
+	// SeverityWarning for its warnings
+	Severity Severity
 }
 
 // Error implements the error interface
@@ -55,6 +69,8 @@ func (e ValidationError) Error() string {
 // ValidationResult holds the results of validation
 type ValidationResult struct {
 	Errors []ValidationError
+	// Warnings are the problems found by rules set to SeverityWarning
+	Warnings []ValidationError
 	Valid  bool
 }
 
@@ -77,14 +93,45 @@ func (r *ValidationResult) AddError(errorType ValidationErrorType, message, file
 		Line:       line,
 		Column:     column,
 		Suggestion: suggestion,
+		Severity:   SeverityError,
 	})
 	r.Valid = false
 }
 
-// SortErrors sorts validation errors by file, then by line, then by column
+// HasWarnings returns true if there are validation warnings
+func (r *ValidationResult) HasWarnings() bool {
+	return len(r.Warnings) > 0
+}
+
+// WarningCount returns the number of validation warnings
+func (r *ValidationResult) WarningCount() int {
+	return len(r.Warnings)
+}
+
+// AddWarning adds a validation warning to the result. Warnings don't make
+// the result invalid.
+func (r *ValidationResult) AddWarning(errorType ValidationErrorType, message, file string, line, column int, suggestion string) {
+	r.Warnings = append(r.Warnings, ValidationError{
+		Type:       errorType,
+		Message:    message,
+		File:       file,
+		Line:       line,
+		Column:     column,
+		Suggestion: suggestion,
+		Severity:   SeverityWarning,
+	})
+}
+
+// SortErrors sorts validation errors and warnings by file, then by line,
+// then by column
 func (r *ValidationResult) SortErrors() {
-	sort.Slice(r.Errors, func(i, j int) bool {
-		a, b := r.Errors[i], r.Errors[j]
+	sortValidationErrors(r.Errors)
+	sortValidationErrors(r.Warnings)
+}
+
+func sortValidationErrors(errors []ValidationError) {
+	sort.Slice(errors, func(i, j int) bool {
+		a, b := errors[i], errors[j]
 		
 		// Sort by file first
 		if a.File != b.File {
@@ -103,29 +150,52 @@ func (r *ValidationResult) SortErrors() {
 
 // GroupedErrors returns errors grouped by file for better readability
 func (r *ValidationResult) GroupedErrors() map[string][]ValidationError {
+	return groupByFile(r.Errors)
+}
+
+func groupByFile(errors []ValidationError) map[string][]ValidationError {
 	groups := make(map[string][]ValidationError)
 	
-	for _, err := range r.Errors {
+	for _, err := range errors {
 		groups[err.File] = append(groups[err.File], err)
 	}
 	
 	return groups
 }
 
-// String returns a formatted string representation of all validation errors
+// String returns a formatted string representation of all validation
+// errors, followed by the warnings
 func (r *ValidationResult) String() string {
-	if len(r.Errors) == 0 {
+	if len(r.Errors) == 0 && len(r.Warnings) == 0 {
 		return "No validation errors"
 	}
 	
 	r.SortErrors()
 	
+	var sections []string
+	if len(r.Errors) > 0 {
+		sections = append(sections, formatValidationErrors("Validation errors found", r.Errors))
+	}
+	if len(r.Warnings) > 0 {
+		sections = append(sections, formatValidationErrors("Validation warnings found", r.Warnings))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// WarningsString returns a formatted string representation of the warnings
+func (r *ValidationResult) WarningsString() string {
+	r.SortErrors()
+	return formatValidationErrors("Validation warnings found", r.Warnings)
+}
+
+// formatValidationErrors formats sorted errors under a title, by file
+func formatValidationErrors(title string, errors []ValidationError) string {
 	var parts []string
-	parts = append(parts, fmt.Sprintf("Validation errors found (%d):", len(r.Errors)))
+	parts = append(parts, fmt.Sprintf("%s (%d):", title, len(errors)))
 	parts = append(parts, "")
 	
 	// Group errors by file
-	groups := r.GroupedErrors()
+	groups := groupByFile(errors)
 	
 	// Sort file names
 	var files []string
@@ -158,7 +228,8 @@ func (r *ValidationResult) String() string {
 // NewValidationResult creates a new validation result
 func NewValidationResult() *ValidationResult {
 	return &ValidationResult{
-		Errors: make([]ValidationError, 0),
-		Valid:  true,
+		Errors:   make([]ValidationError, 0),
+		Warnings: make([]ValidationError, 0),
+		Valid:    true,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,37 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// validateUnusedImports reports the imports of a file that no type
+// reference is qualified with, wherever the reference is: field types,
+// enum payloads, embeddings, aliases, or nested in arrays, maps and
+// optionals. References to types the imported module doesn't declare
+// still use the import; they are reported as undefined instead.
+func (v *Validator) validateUnusedImports(program *ast.ProgramNode, filename string) {
+	used := make(map[string]bool)
+	ast.Inspect(program, func(named *ast.NamedType) {
+		if moduleName, _, qualified := strings.Cut(named.Name, "."); qualified {
+			used[moduleName] = true
+		}
+	})
+
+	for _, imp := range program.Imports {
+		parts := strings.Split(imp.Path, ".")
+		if used[parts[len(parts)-1]] {
+			continue
+		}
+		pos := imp.Pos()
+		v.addError(
+			UnusedImportError,
+			fmt.Sprintf("import '%s' is not used", imp.Path),
+			filename,
+			pos.Line, pos.Column,
+			"remove the import",
+		)
+	}
+}
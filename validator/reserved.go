@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/WhatsApp-Platform/typegen/naming"
+	"github.com/WhatsApp-Platform/typegen/parser/ast"
+)
+
+// Kinds of names in ReservedWords.Names
+const (
+	FieldNames    = "field"
+	VariantNames  = "variant"
+	TypeNames     = "type"
+	ConstantNames = "constant"
+	ModuleNames   = "module"
+)
+
+// ReservedWords are the words the target language of a generator reserves,
+// checked in the names the generator writes as they are declared
+type ReservedWords struct {
+	// Language names the target language in messages, e.g. "Python"
+	Language string
+	Words    naming.Reserved
+	// IgnoreCase matches names against the words in any case, for languages
+	// like Hack whose class names are case-insensitive. The words are then
+	// lowercase.
+	IgnoreCase bool
+	// Names are the kinds of names the generator writes unchanged:
+	// FieldNames, VariantNames, TypeNames for structs, enums and aliases,
+	// ConstantNames and ModuleNames for submodule directories
+	Names []string
+}
+
+var (
+	reservedMu sync.RWMutex
+	reserved   = map[string]ReservedWords{}
+)
+
+// RegisterReservedWords records the reserved words of a generator, by the
+// name it is registered under. Generators register them from init
+// functions; the validator checks them for the generators of SetGenerators.
+func RegisterReservedWords(generator string, words ReservedWords) {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+	reserved[generator] = words
+}
+
+// LookupReservedWords returns the reserved words registered for a generator
+func LookupReservedWords(generator string) (ReservedWords, bool) {
+	reservedMu.RLock()
+	defer reservedMu.RUnlock()
+	words, ok := reserved[generator]
+	return words, ok
+}
+
+// validateReservedWords reports the names of the module that are reserved
+// words in the target language of one of the generators, for each of them
+func (v *Validator) validateReservedWords(module *ast.Module) {
+	for _, generator := range v.generators {
+		words, ok := LookupReservedWords(generator)
+		if !ok {
+			continue
+		}
+		check := func(kind, name, what, filename string, pos ast.Position, suggestion string) {
+			if !slices.Contains(words.Names, kind) {
+				return
+			}
+			if !words.Words[name] && !(words.IgnoreCase && words.Words[strings.ToLower(name)]) {
+				return
+			}
+			v.addError(
+				ReservedWordError,
+				fmt.Sprintf("%s is a reserved word in %s, the target language of generator '%s'", what, words.Language, generator),
+				filename,
+				pos.Line, pos.Column,
+				suggestion,
+			)
+		}
+
+		for _, decl := range v.registry.Model().Decls() {
+			filename, pos := decl.File.Path, decl.Pos()
+			what := fmt.Sprintf("%s '%s'", kindName(decl.Kind), decl.Name)
+			switch node := decl.Node.(type) {
+			case *ast.StructNode:
+				check(TypeNames, node.Name, what, filename, pos, "rename the struct")
+				for _, field := range node.Fields {
+					check(FieldNames, field.Name, fmt.Sprintf("field '%s' of %s", field.Name, what), filename, field.Pos(),
+						renameSuggestion("field", field.Name, field.WireName))
+				}
+			case *ast.EnumNode:
+				check(TypeNames, node.Name, what, filename, pos, "rename the enum")
+				for _, variant := range node.Variants {
+					check(VariantNames, variant.Name, fmt.Sprintf("variant '%s' of %s", variant.Name, what), filename, variant.Pos(),
+						renameSuggestion("variant", variant.Name, variant.WireName))
+				}
+			case *ast.TypeAliasNode:
+				check(TypeNames, node.Name, what, filename, pos, "rename the type alias")
+			case *ast.ConstantNode:
+				check(ConstantNames, node.Name, what, filename, pos, "rename the constant")
+			}
+		}
+
+		checkModuleNames(module, "", func(name, dir string) {
+			check(ModuleNames, name, fmt.Sprintf("module '%s'", name), dir, ast.Position{}, "rename the directory")
+		})
+	}
+}
+
+// checkModuleNames calls check with the name and slash-separated path of
+// every submodule, in order
+func checkModuleNames(module *ast.Module, basePath string, check func(name, dir string)) {
+	names := make([]string, 0, len(module.SubModules))
+	for name := range module.SubModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dir := name
+		if basePath != "" {
+			dir = basePath + "/" + name
+		}
+		check(name, dir)
+		checkModuleNames(module.SubModules[name], dir, check)
+	}
+}
+
+// renameSuggestion suggests renaming a field or variant, with a wire name
+// to keep its JSON name unless it has one
+func renameSuggestion(kind, name, wireName string) string {
+	if wireName != "" {
+		return fmt.Sprintf("rename the %s; its wire name keeps its JSON name", kind)
+	}
+	return fmt.Sprintf("rename the %s, and give it the wire name %q to keep its JSON name", kind, name)
+}
@@ -5,222 +5,163 @@ import (
 	"strings"
 
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
 )
 
-// TypeRegistry keeps track of all type declarations in a module
+// TypeRegistry keeps track of all type declarations in a module. It is a
+// view of the module's semantic model.
 type TypeRegistry struct {
-	types       map[string]*TypeInfo     // Fully qualified name -> TypeInfo
-	moduleTypes map[string]*TypeInfo     // Module path qualified name -> TypeInfo
-	currentFile string                   // Current file being processed
+	model *semantic.Model
+	infos map[*semantic.Decl]*TypeInfo
+	// byName lists the types of each bare name, ordered by file and
+	// position
+	byName map[string][]*TypeInfo
 }
 
 // TypeInfo contains information about a declared type
 type TypeInfo struct {
 	Name     string
-	DeclType string // "struct", "enum", "alias", "constant"  
+	DeclType string // "struct", "enum", "alias", "constant"
 	File     string
 	Line     int
 	Column   int
+	Decl     ast.Declaration // The declaration node
 }
 
-// NewTypeRegistry creates a new type registry
+// NewTypeRegistry creates an empty type registry
 func NewTypeRegistry() *TypeRegistry {
-	return &TypeRegistry{
-		types:       make(map[string]*TypeInfo),
-		moduleTypes: make(map[string]*TypeInfo),
-	}
+	return newTypeRegistry(semantic.Build(nil))
 }
 
-// RegisterType registers a type declaration in the registry
-func (r *TypeRegistry) RegisterType(name, declType, file string, line, column int) {
-	qualifiedName := r.qualifyName(name, file)
-	typeInfo := &TypeInfo{
-		Name:     name,
-		DeclType: declType,
-		File:     file,
-		Line:     line,
-		Column:   column,
-	}
-	
-	r.types[qualifiedName] = typeInfo
-	
-	// Also register by module path for cross-module lookups
-	modulePath := r.fileToModulePath(file)
-	moduleQualifiedName := fmt.Sprintf("%s.%s", modulePath, name)
-	r.moduleTypes[moduleQualifiedName] = typeInfo
+// BuildTypeRegistry builds a type registry for the entire module
+func BuildTypeRegistry(module *ast.Module) *TypeRegistry {
+	return newTypeRegistry(semantic.Build(module))
 }
 
-// qualifyName creates a fully qualified type name based on file location
-func (r *TypeRegistry) qualifyName(name, file string) string {
-	// For now, we'll use file path as the qualifier
-	// In a full implementation, this would use module paths
-	return fmt.Sprintf("%s::%s", file, name)
+func newTypeRegistry(model *semantic.Model) *TypeRegistry {
+	r := &TypeRegistry{
+		model:  model,
+		infos:  make(map[*semantic.Decl]*TypeInfo),
+		byName: make(map[string][]*TypeInfo),
+	}
+	for _, decl := range model.Decls() {
+		pos := decl.Pos()
+		info := &TypeInfo{
+			Name:     decl.Name,
+			DeclType: decl.Kind,
+			File:     decl.File.Path,
+			Line:     pos.Line,
+			Column:   pos.Column,
+			Decl:     decl.Node,
+		}
+		r.infos[decl] = info
+		r.byName[decl.Name] = append(r.byName[decl.Name], info)
+	}
+	return r
 }
 
-// fileToModulePath converts a file path to a module path
-func (r *TypeRegistry) fileToModulePath(file string) string {
-	// Convert file path to module path by removing .tg extension and replacing / with .
-	// e.g., "auth/user.tg" -> "auth.user", "user.tg" -> "user"
-	path := strings.TrimSuffix(file, ".tg")
-	return strings.ReplaceAll(path, "/", ".")
+// Model returns the semantic model the registry is built on
+func (r *TypeRegistry) Model() *semantic.Model {
+	return r.model
 }
 
 // TypeExists checks if a type exists in the registry
 func (r *TypeRegistry) TypeExists(name, currentFile string) bool {
-	// Check primitive types first
 	if IsValidPrimitiveType(name) {
 		return true
 	}
-	
-	// Check qualified name in current file
-	qualifiedName := r.qualifyName(name, currentFile)
-	if _, exists := r.types[qualifiedName]; exists {
-		return true
-	}
-	
-	// For unqualified names, check within the same module (directory)
-	// Get the module (directory) for the current file
-	currentModule := r.getModuleFromFile(currentFile)
-	
-	// Check if type exists in the same module
-	for qualName, typeInfo := range r.types {
-		if strings.HasSuffix(qualName, "::"+name) {
-			// Check if this type belongs to the same module (directory)
-			typeModule := r.getModuleFromFile(typeInfo.File)
-			if typeModule == currentModule {
-				return true
-			}
-		}
-	}
-	
-	return false
+	_, found := r.model.Resolve(name, currentFile)
+	return found
 }
 
-// getModuleFromFile extracts the module (directory) from a file path
-func (r *TypeRegistry) getModuleFromFile(file string) string {
-	// Extract directory part of the file path
-	// e.g., "auth/token.tg" -> "auth", "main.tg" -> ""
-	if idx := strings.LastIndex(file, "/"); idx >= 0 {
-		return file[:idx]
-	}
-	return "" // Root module
-}
-
-// QualifiedTypeExists checks if a qualified type exists for a given module path
+// QualifiedTypeExists checks if a qualified type like "auth.Token" exists in
+// the module imported with a given path
 func (r *TypeRegistry) QualifiedTypeExists(qualifiedName, modulePath string) bool {
-	// qualifiedName is like "auth.Token", modulePath is like "some.auth.module"
-	// We need to match this against our registered module types
-	
-	// Try the exact qualified name first
-	if _, exists := r.moduleTypes[qualifiedName]; exists {
-		return true
-	}
-	
-	// Try matching the module path
 	parts := strings.SplitN(qualifiedName, ".", 2)
 	if len(parts) != 2 {
 		return false
 	}
-	
-	moduleAlias := parts[0]  // "auth"
-	typeName := parts[1]     // "Token"
-	
-	// Look for types that match the module path ending
-	for moduleTypeKey := range r.moduleTypes {
-		// Check if this is the right type and the module path matches
-		if strings.HasSuffix(moduleTypeKey, "."+typeName) {
-			// Extract the module part of the key
-			moduleKeyParts := strings.SplitN(moduleTypeKey, "."+typeName, 2)
-			if len(moduleKeyParts) > 0 {
-				keyModulePath := moduleKeyParts[0]
-				// Check if the import path ends with the module alias
-				if strings.HasSuffix(modulePath, "."+moduleAlias) || modulePath == moduleAlias {
-					return true
-				}
-				// Also check direct module path match
-				if keyModulePath == modulePath {
-					return true
-				}
-			}
-		}
-	}
-	
-	return false
+	_, found := r.model.ResolveImport(modulePath, parts[1])
+	return found
 }
 
-// FindType finds type information by name
+// FindType finds type information by name, as seen from currentFile first,
+// then anywhere in the module: among types of the same name in several
+// files, the first by file path
 func (r *TypeRegistry) FindType(name, currentFile string) (*TypeInfo, bool) {
-	// Check qualified name in current file first
-	qualifiedName := r.qualifyName(name, currentFile)
-	if info, exists := r.types[qualifiedName]; exists {
+	if info, found := r.Resolve(name, currentFile); found {
 		return info, true
 	}
-	
-	// Check cross-file references
-	for qualName, info := range r.types {
-		if strings.HasSuffix(qualName, "::"+name) {
-			return info, true
-		}
+	if infos := r.byName[name]; len(infos) > 0 {
+		return infos[0], true
 	}
-	
 	return nil, false
 }
 
-// GetAllTypes returns all registered types
+// GetAllTypes returns all registered types keyed by "file::name"
 func (r *TypeRegistry) GetAllTypes() map[string]*TypeInfo {
-	return r.types
+	types := make(map[string]*TypeInfo, len(r.infos))
+	for _, info := range r.infos {
+		types[fmt.Sprintf("%s::%s", info.File, info.Name)] = info
+	}
+	return types
 }
 
+// Types returns all registered types ordered by file and position
+func (r *TypeRegistry) Types() []*TypeInfo {
+	types := make([]*TypeInfo, 0, len(r.infos))
+	for _, decl := range r.model.Decls() {
+		types = append(types, r.infos[decl])
+	}
+	return types
+}
 
-// buildTypeRegistry builds a type registry for the entire module
-func buildTypeRegistry(module *ast.Module) *TypeRegistry {
-	registry := NewTypeRegistry()
-	
-	// Process all files in the module recursively
-	processModuleForRegistry(module, "", registry)
-	
-	return registry
+// Resolve returns the declaration a type reference in currentFile points to.
+// Unqualified names resolve within the file, then within its module (directory);
+// qualified names like "auth.Token" resolve through the file's imports.
+func (r *TypeRegistry) Resolve(name, currentFile string) (*TypeInfo, bool) {
+	if IsValidPrimitiveType(name) {
+		return nil, false
+	}
+	decl, found := r.model.Resolve(name, currentFile)
+	if !found {
+		return nil, false
+	}
+	return r.infos[decl], true
 }
 
-// processModuleForRegistry processes a module and its submodules for type registration
-func processModuleForRegistry(module *ast.Module, basePath string, registry *TypeRegistry) {
-	// Process files in this module
-	for filename, program := range module.Files {
-		fullPath := basePath
-		if fullPath != "" {
-			fullPath += "/"
+// SimilarTypes returns the names closest to an undefined type name among
+// the primitive types and the types visible from currentFile, up to three
+func (r *TypeRegistry) SimilarTypes(name, currentFile string) []string {
+	candidates := primitiveNames()
+	for _, decl := range r.model.Decls() {
+		if decl.Kind == "constant" {
+			continue
 		}
-		fullPath += filename
-		
-		registry.currentFile = fullPath
-		
-		// Register all type declarations
-		for _, decl := range program.Declarations {
-			pos := decl.Pos()
-			switch d := decl.(type) {
-			case *ast.StructNode:
-				registry.RegisterType(d.Name, "struct", fullPath, pos.Line, pos.Column)
-				
-			case *ast.EnumNode:
-				registry.RegisterType(d.Name, "enum", fullPath, pos.Line, pos.Column)
-				
-			case *ast.TypeAliasNode:
-				registry.RegisterType(d.Name, "alias", fullPath, pos.Line, pos.Column)
-				
-			case *ast.ConstantNode:
-				registry.RegisterType(d.Name, "constant", fullPath, pos.Line, pos.Column)
-			}
+		if visible, found := r.model.Resolve(decl.Name, currentFile); found && visible == decl {
+			candidates = append(candidates, decl.Name)
 		}
 	}
-	
-	// Process submodules recursively
-	for subModuleName, subModule := range module.SubModules {
-		subBasePath := basePath
-		if subBasePath != "" {
-			subBasePath += "/"
+	return closestNames(name, candidates)
+}
+
+// SimilarQualifiedTypes returns the qualified names closest to an undefined
+// qualified type like "auth.Tokn" among the types of the module imported
+// with a given path, up to three
+func (r *TypeRegistry) SimilarQualifiedTypes(qualifiedName, modulePath string) []string {
+	moduleName, name, _ := strings.Cut(qualifiedName, ".")
+	var candidates []string
+	for _, decl := range r.model.Decls() {
+		if decl.Kind == "constant" {
+			continue
 		}
-		subBasePath += subModuleName
-		processModuleForRegistry(subModule, subBasePath, registry)
+		if imported, found := r.model.ResolveImport(modulePath, decl.Name); found && imported == decl {
+			candidates = append(candidates, decl.Name)
+		}
+	}
+	names := closestNames(name, candidates)
+	for i := range names {
+		names[i] = moduleName + "." + names[i]
 	}
+	return names
 }
-
@@ -3,7 +3,8 @@ package validator
 import (
 	"regexp"
 	"strings"
-	"unicode"
+
+	"github.com/WhatsApp-Platform/typegen/naming"
 )
 
 // Naming convention regular expressions
@@ -23,26 +24,38 @@ var (
 
 // ValidPrimitiveTypes lists all valid primitive types in TypeGen
 var ValidPrimitiveTypes = map[string]bool{
-	// Integer types
+	// Integer types; int is at least 64 bits
 	"int8":  true,
 	"int16": true,
 	"int32": true,
 	"int64": true,
+	"int":   true,
 
-	// Natural number types
+	// Natural number types; nat is at least 64 bits
 	"nat8":  true,
 	"nat16": true,
 	"nat32": true,
 	"nat64": true,
+	"nat":   true,
+
+	// Arbitrary-precision integer types
+	"bigint": true,
+	"bignat": true,
 
 	// Float types
 	"float32": true,
 	"float64": true,
 
+	// Exact decimal type
+	"decimal": true,
+
 	// String and boolean
 	"string": true,
 	"bool":   true,
 
+	// Universally unique identifier
+	"uuid": true,
+
 	// JSON type
 	"json": true,
 
@@ -50,19 +63,29 @@ var ValidPrimitiveTypes = map[string]bool{
 	"datetime": true,
 	"date":     true,
 	"time":     true,
+
+	// Time types that keep a UTC offset
+	"datetimetz": true,
+	"datetz":     true,
+	"timetz":     true,
 }
 
-// ValidMapKeyTypes lists primitive types that can be used as map keys
+// ValidMapKeyTypes lists primitive types that can be used as map keys. The
+// arbitrary-precision types aren't, since generators represent them as
+// objects that can't be compared by value, such as *big.Int.
 var ValidMapKeyTypes = map[string]bool{
 	"string": true,
 	"int8":   true,
 	"int16":  true,
 	"int32":  true,
 	"int64":  true,
+	"int":    true,
 	"nat8":   true,
 	"nat16":  true,
 	"nat32":  true,
 	"nat64":  true,
+	"nat":    true,
+	"uuid":   true,
 }
 
 // IsValidSnakeCase checks if a string follows snake_case convention
@@ -100,18 +123,7 @@ func SuggestSnakeCase(s string) string {
 	if IsValidSnakeCase(s) {
 		return s
 	}
-
-	// Convert camelCase/PascalCase to snake_case
-	var result strings.Builder
-
-	for i, r := range s {
-		if i > 0 && unicode.IsUpper(r) {
-			result.WriteRune('_')
-		}
-		result.WriteRune(unicode.ToLower(r))
-	}
-
-	return result.String()
+	return naming.SnakeCase(s)
 }
 
 // SuggestPascalCase converts a string to PascalCase
@@ -119,21 +131,7 @@ func SuggestPascalCase(s string) string {
 	if IsValidPascalCase(s) {
 		return s
 	}
-
-	// Convert snake_case to PascalCase
-	parts := strings.Split(s, "_")
-	var result strings.Builder
-
-	for _, part := range parts {
-		if len(part) > 0 {
-			result.WriteRune(unicode.ToUpper(rune(part[0])))
-			if len(part) > 1 {
-				result.WriteString(strings.ToLower(part[1:]))
-			}
-		}
-	}
-
-	return result.String()
+	return naming.PascalCase(s)
 }
 
 // SuggestConstantCase converts a string to CONSTANT_CASE
@@ -141,10 +139,7 @@ func SuggestConstantCase(s string) string {
 	if IsValidConstantCase(s) {
 		return s
 	}
-
-	// Convert to snake_case first, then uppercase
-	snakeCase := SuggestSnakeCase(s)
-	return strings.ToUpper(snakeCase)
+	return naming.ScreamingSnakeCase(s)
 }
 
 // IsValidModuleName checks if a module name follows the correct convention
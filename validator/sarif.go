@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+)
+
+// SARIF export, for code review tools that show problems inline on diffs.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIFRootID is the uriBaseId of the files of a SARIF log: their paths are
+// relative to the root of the validated module
+const SARIFRootID = "SRCROOT"
+
+// ruleDescriptions describe the rules in SARIF logs
+var ruleDescriptions = map[ValidationErrorType]string{
+	UndefinedTypeError:     "Types must be declared or imported",
+	InvalidPrimitiveError:  "Primitive types must be known",
+	InvalidMapKeyError:     "Map keys must be strings, integers or UUIDs",
+	NamingConventionError:  "Names must follow the naming conventions",
+	DuplicateTypeError:     "Type names must be unique in a module",
+	DuplicateFieldError:    "Field names and wire names must be unique in a struct",
+	DuplicateVariantError:  "Variant names and wire names must be unique in an enum",
+	DuplicateConstantError: "Constant names must be unique in a module",
+	InvalidImportError:     "Import paths must be snake_case module names",
+	UnusedImportError:      "Imports must be used by a type reference",
+	InvalidOptionalError:   "Optional types must not be nested",
+	InvalidConstantError:   "Constants must have valid values",
+	InvalidEmbedError:      "Embedded types must be structs that don't embed each other",
+	CyclicAliasError:       "Type aliases must not refer to themselves through other aliases only",
+	InfiniteTypeError:      "Structs and enums must not contain themselves without ?, [] or a map",
+	InvalidWireNameError:   "Wire names must not contain quotes, backslashes or commas",
+	UnusedTypeError:        "Structs, enums and aliases should be referenced",
+	ReservedWordError:      "Names must not be reserved words of the target languages",
+	UnknownAttributeError:  "Attributes must be known",
+	InvalidAttributeError:  "Attributes must have valid values",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description sarifMessage `json:"description"`
+}
+
+// ToSARIF returns the errors and warnings of the result as a SARIF 2.1.0
+// log of one run of typegen at toolVersion. Every rule of AllRules is
+// listed, and each problem refers to its rule by ID, the name of its
+// ValidationErrorType. Files are relative to the module root, see
+// SARIFRootID, and positions are 1-based like those of the validator.
+// Suggestions become fixes with a description only: they are advice, not
+// edits a tool could apply.
+func (r *ValidationResult) ToSARIF(toolVersion string) ([]byte, error) {
+	r.SortErrors()
+
+	driver := sarifDriver{
+		Name:           "typegen",
+		Version:        toolVersion,
+		InformationURI: "https://github.com/WhatsApp-Platform/typegen",
+	}
+	ruleIndex := make(map[ValidationErrorType]int, len(AllRules))
+	for i, rule := range AllRules {
+		ruleIndex[rule] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   string(rule),
+			ShortDescription:     sarifMessage{Text: ruleDescriptions[rule]},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(DefaultSeverity(rule))},
+		})
+	}
+
+	results := []sarifResult{}
+	add := func(severity Severity, errs []ValidationError) {
+		for _, err := range errs {
+			result := sarifResult{
+				RuleID:    string(err.Type),
+				RuleIndex: ruleIndex[err.Type],
+				Level:     sarifLevel(severity),
+				Message:   sarifMessage{Text: err.Message},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{
+						URI:       (&url.URL{Path: filepath.ToSlash(err.File)}).String(),
+						URIBaseID: SARIFRootID,
+					},
+				}}},
+			}
+			if err.Line > 0 {
+				result.Locations[0].PhysicalLocation.Region = &sarifRegion{StartLine: err.Line, StartColumn: err.Column}
+			}
+			if err.Suggestion != "" {
+				result.Fixes = []sarifFix{{Description: sarifMessage{Text: err.Suggestion}}}
+			}
+			results = append(results, result)
+		}
+	}
+	add(SeverityError, r.Errors)
+	add(SeverityWarning, r.Warnings)
+
+	return json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+}
+
+// sarifLevel returns the SARIF level of a severity
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityOff:
+		return "none"
+	}
+	return "error"
+}
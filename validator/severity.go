@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity is how the problems found by a validation rule are reported
+type Severity string
+
+const (
+	// SeverityError reports problems as errors, the default
+	SeverityError Severity = "error"
+	// SeverityWarning reports problems as warnings, which don't make the
+	// module invalid
+	SeverityWarning Severity = "warning"
+	// SeverityOff ignores the problems
+	SeverityOff Severity = "off"
+)
+
+// ParseSeverity parses the name of a severity
+func ParseSeverity(name string) (Severity, error) {
+	switch severity := Severity(name); severity {
+	case SeverityError, SeverityWarning, SeverityOff:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q; severities are error, warning and off", name)
+}
+
+// Rules sets the severity of validation rules, named by the type of the
+// errors they report. Rules that are not listed have their default
+// severity, see DefaultSeverity.
+type Rules map[ValidationErrorType]Severity
+
+// DefaultSeverity returns the severity of a rule that Rules don't list:
+// warnings for unknown attributes, which a newer generator may understand,
+// and for unused imports, which are harmless, off for unused types, which
+// are opt-in, and errors for every other rule
+func DefaultSeverity(rule ValidationErrorType) Severity {
+	switch rule {
+	case UnknownAttributeError, UnusedImportError:
+		return SeverityWarning
+	case UnusedTypeError:
+		return SeverityOff
+	}
+	return SeverityError
+}
+
+// AllRules lists the validation rules
+var AllRules = []ValidationErrorType{
+	UndefinedTypeError,
+	InvalidPrimitiveError,
+	InvalidMapKeyError,
+	NamingConventionError,
+	DuplicateTypeError,
+	DuplicateFieldError,
+	DuplicateVariantError,
+	DuplicateConstantError,
+	InvalidImportError,
+	UnusedImportError,
+	InvalidOptionalError,
+	InvalidConstantError,
+	InvalidEmbedError,
+	CyclicAliasError,
+	InfiniteTypeError,
+	InvalidWireNameError,
+	UnusedTypeError,
+	ReservedWordError,
+	UnknownAttributeError,
+	InvalidAttributeError,
+}
+
+// ParseRules parses rule severities by rule and severity name
+func ParseRules(rules map[string]string) (Rules, error) {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make(Rules, len(rules))
+	for _, name := range names {
+		rule := ValidationErrorType(name)
+		if !isRule(rule) {
+			return nil, fmt.Errorf("unknown validation rule %q; rules are %v", name, AllRules)
+		}
+		severity, err := ParseSeverity(rules[name])
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		parsed[rule] = severity
+	}
+	return parsed, nil
+}
+
+// isRule reports whether rule is one of AllRules
+func isRule(rule ValidationErrorType) bool {
+	for _, known := range AllRules {
+		if known == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of names a "did you mean" suggestion lists
+// at most
+const maxSuggestions = 3
+
+// closestNames returns the candidates closest to a misspelled name by edit
+// distance ignoring case, up to maxSuggestions of them with ties in
+// alphabetical order. Names that differ in case only are surely the one
+// meant, so the others aren't suggested next to them. Candidates more than
+// a third of the name's length away, or one edit for short names, are too
+// far to suggest.
+func closestNames(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+	limit := max(1, len(name)/3)
+	seen := make(map[string]bool)
+	var matches []match
+	for _, candidate := range candidates {
+		if candidate == name || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance <= limit {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for _, m := range matches[:min(len(matches), maxSuggestions)] {
+		if m.distance > 0 && matches[0].distance == 0 {
+			break
+		}
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// primitiveNames returns the names of the valid primitive types
+func primitiveNames() []string {
+	names := make([]string, 0, len(ValidPrimitiveTypes))
+	for name := range ValidPrimitiveTypes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between two strings: the
+// number of rune insertions, deletions and substitutions that turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// didYouMean returns a suggestion listing names, such as "did you mean
+// 'Address'?", or fallback when there are none
+func didYouMean(names []string, fallback string) string {
+	if len(names) == 0 {
+		return fallback
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	if len(quoted) == 1 {
+		return fmt.Sprintf("did you mean %s?", quoted[0])
+	}
+	return fmt.Sprintf("did you mean %s or %s?", strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
+}
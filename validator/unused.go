@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/WhatsApp-Platform/typegen/semantic"
+)
+
+// UnusedTypeScope is where the unused_type rule looks for structs, enums and
+// aliases that nothing references
+type UnusedTypeScope string
+
+const (
+	// UnusedTypesInSubmodules reports the unreferenced types of submodules
+	// only, the default: the types of the root module are the entry points
+	// of the module
+	UnusedTypesInSubmodules UnusedTypeScope = "submodules"
+	// UnusedTypesEverywhere reports unreferenced types in the root module
+	// too
+	UnusedTypesEverywhere UnusedTypeScope = "all"
+)
+
+// ParseUnusedTypeScope parses the name of an UnusedTypeScope
+func ParseUnusedTypeScope(name string) (UnusedTypeScope, error) {
+	switch scope := UnusedTypeScope(name); scope {
+	case UnusedTypesInSubmodules, UnusedTypesEverywhere:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown unused type scope %q; scopes are %s and %s", name, UnusedTypesInSubmodules, UnusedTypesEverywhere)
+}
+
+// validateUnusedTypes reports the structs, enums and aliases of the module
+// that no field, variant payload, embedding or alias of another declaration
+// refers to. The rule is off unless Rules turn it on.
+func (v *Validator) validateUnusedTypes() {
+	model := v.registry.Model()
+	referenced := make(map[*semantic.Decl]bool)
+	for _, decl := range model.Decls() {
+		for _, dep := range model.DependenciesOf(decl) {
+			if dep != decl {
+				referenced[dep] = true
+			}
+		}
+	}
+
+	for _, decl := range model.Decls() {
+		if decl.Kind == "constant" || referenced[decl] {
+			continue
+		}
+		if decl.File.Dir == "" && v.unusedTypes != UnusedTypesEverywhere {
+			continue
+		}
+		pos := decl.Pos()
+		v.addError(
+			UnusedTypeError,
+			fmt.Sprintf("%s '%s' is never referenced", kindName(decl.Kind), decl.Name),
+			decl.File.Path,
+			pos.Line, pos.Column,
+			fmt.Sprintf("remove the %s, or reference it", kindName(decl.Kind)),
+		)
+	}
+}
@@ -2,9 +2,11 @@ package validator
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
+	"github.com/WhatsApp-Platform/typegen/semantic"
 )
 
 // Validator validates TypeGen modules for correctness
@@ -12,6 +14,11 @@ type Validator struct {
 	registry *TypeRegistry
 	result   *ValidationResult
 	imports  map[string]map[string]string // filename -> imported module -> module path
+	rules    Rules
+	// unusedTypes is where unused_type looks for unreferenced types
+	unusedTypes UnusedTypeScope
+	// generators are the generators whose reserved words are checked
+	generators []string
 }
 
 // NewValidator creates a new validator instance
@@ -22,14 +29,52 @@ func NewValidator() *Validator {
 	}
 }
 
+// SetRules sets the severity of validation rules; rules that are not listed
+// have their DefaultSeverity
+func (v *Validator) SetRules(rules Rules) {
+	v.rules = rules
+}
+
+// SetGenerators sets the generators the module is validated for: names
+// that are reserved words in their target languages are reported, see
+// RegisterReservedWords
+func (v *Validator) SetGenerators(generators []string) {
+	v.generators = generators
+}
+
+// SetUnusedTypeScope sets where the unused_type rule, when on, looks for
+// unreferenced types; by default only in submodules
+func (v *Validator) SetUnusedTypeScope(scope UnusedTypeScope) {
+	v.unusedTypes = scope
+}
+
+// addError reports a problem found by a rule with the rule's severity
+func (v *Validator) addError(errorType ValidationErrorType, message, file string, line, column int, suggestion string) {
+	severity, ok := v.rules[errorType]
+	if !ok {
+		severity = DefaultSeverity(errorType)
+	}
+	switch severity {
+	case SeverityOff:
+	case SeverityWarning:
+		v.result.AddWarning(errorType, message, file, line, column, suggestion)
+	default:
+		v.result.AddError(errorType, message, file, line, column, suggestion)
+	}
+}
+
 // Validate validates an entire module and returns validation results
 func (v *Validator) Validate(module *ast.Module) *ValidationResult {
 	v.result = NewValidationResult()
 	v.imports = make(map[string]map[string]string)
-	v.registry = buildTypeRegistry(module)
+	v.registry = BuildTypeRegistry(module)
 
 	// Validate all files in the module recursively
 	v.validateModule(module, "")
+	v.validateDuplicatesAcrossFiles()
+	v.validateReservedWords(module)
+	v.validateInfiniteTypes()
+	v.validateUnusedTypes()
 
 	return v.result
 }
@@ -37,7 +82,8 @@ func (v *Validator) Validate(module *ast.Module) *ValidationResult {
 // validateModule validates a module and its submodules recursively
 func (v *Validator) validateModule(module *ast.Module, basePath string) {
 	// Validate files in this module
-	for filename, program := range module.Files {
+	for _, filename := range module.FileNames() {
+		program := module.Files[filename]
 		fullPath := basePath
 		if fullPath != "" {
 			fullPath += "/"
@@ -48,10 +94,11 @@ func (v *Validator) validateModule(module *ast.Module, basePath string) {
 	}
 
 	// Validate submodules recursively
-	for subModuleName, subModule := range module.SubModules {
+	for _, subModuleName := range module.SubModuleNames() {
+		subModule := module.SubModules[subModuleName]
 		// Validate submodule name follows snake_case
 		if !IsValidSnakeCase(subModuleName) {
-			v.result.AddError(
+			v.addError(
 				NamingConventionError,
 				fmt.Sprintf("module name '%s' should follow snake_case convention", subModuleName),
 				basePath,
@@ -93,13 +140,15 @@ func (v *Validator) validateProgram(program *ast.ProgramNode, filename string) {
 	for _, decl := range program.Declarations {
 		v.validateDeclaration(decl, filename, declNames)
 	}
+
+	v.validateUnusedImports(program, filename)
 }
 
 // validateImport validates an import statement
 func (v *Validator) validateImport(imp *ast.ImportNode, filename string) {
 	pos := imp.Pos()
 	if !IsValidModuleName(imp.Path) {
-		v.result.AddError(
+		v.addError(
 			InvalidImportError,
 			fmt.Sprintf("import path '%s' should follow snake_case convention for module names", imp.Path),
 			filename,
@@ -140,7 +189,7 @@ func (v *Validator) validateDeclaration(decl ast.Declaration, filename string, d
 	if existing, exists := declNames[declName]; exists {
 		existingPos := existing.Pos()
 		declPos := decl.Pos()
-		v.result.AddError(
+		v.addError(
 			DuplicateTypeError,
 			fmt.Sprintf("duplicate %s '%s' (first declared at line %d)", declType, declName, existingPos.Line),
 			filename,
@@ -152,12 +201,43 @@ func (v *Validator) validateDeclaration(decl ast.Declaration, filename string, d
 	}
 }
 
+// validateDuplicatesAcrossFiles reports declarations whose name is declared
+// again by another file of the same module (directory), at each of them, since
+// generators put the files of a module in one package. Duplicates within a
+// file are reported by validateDeclaration.
+func (v *Validator) validateDuplicatesAcrossFiles() {
+	byName := make(map[string][]*TypeInfo)
+	for _, info := range v.registry.Types() {
+		key := path.Dir(info.File) + ":" + info.Name
+		byName[key] = append(byName[key], info)
+	}
+
+	for _, info := range v.registry.Types() {
+		var others []string
+		for _, other := range byName[path.Dir(info.File)+":"+info.Name] {
+			if other.File != info.File {
+				others = append(others, fmt.Sprintf("%s at line %d", other.File, other.Line))
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		v.addError(
+			DuplicateTypeError,
+			fmt.Sprintf("%s '%s' is also declared in %s", kindName(info.DeclType), info.Name, strings.Join(others, ", ")),
+			info.File,
+			info.Line, info.Column,
+			"rename one of the declarations, or move it to another module",
+		)
+	}
+}
+
 // validateStruct validates a struct declaration
 func (v *Validator) validateStruct(s *ast.StructNode, filename string) {
 	pos := s.Pos()
 	// Validate struct name (PascalCase)
 	if !IsValidPascalCase(s.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("struct name '%s' should follow PascalCase convention", s.Name),
 			filename,
@@ -171,6 +251,9 @@ func (v *Validator) validateStruct(s *ast.StructNode, filename string) {
 	for _, field := range s.Fields {
 		v.validateField(field, filename, fieldNames)
 	}
+
+	v.validateEmbeds(s, filename)
+	v.validateExpandedFields(s, filename)
 }
 
 // validateField validates a struct field
@@ -178,7 +261,7 @@ func (v *Validator) validateField(field *ast.FieldNode, filename string, fieldNa
 	pos := field.Pos()
 	// Validate field name (snake_case)
 	if !IsValidSnakeCase(field.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("field name '%s' should follow snake_case convention", field.Name),
 			filename,
@@ -190,7 +273,7 @@ func (v *Validator) validateField(field *ast.FieldNode, filename string, fieldNa
 	// Check for duplicate field names
 	if existing, exists := fieldNames[field.Name]; exists {
 		existingPos := existing.Pos()
-		v.result.AddError(
+		v.addError(
 			DuplicateFieldError,
 			fmt.Sprintf("duplicate field '%s' (first declared at line %d)", field.Name, existingPos.Line),
 			filename,
@@ -202,7 +285,11 @@ func (v *Validator) validateField(field *ast.FieldNode, filename string, fieldNa
 	}
 
 	// Validate field type
-	v.validateType(field.Type, filename, pos.Line, pos.Column)
+	v.validateType(field.Type, filename)
+
+	v.validateWireName(field.WireName, fmt.Sprintf("field '%s'", field.Name), filename, pos)
+
+	v.validateAttributes(field.Attributes, fmt.Sprintf("field '%s'", field.Name), filename)
 }
 
 // validateEnum validates an enum declaration
@@ -210,7 +297,7 @@ func (v *Validator) validateEnum(e *ast.EnumNode, filename string) {
 	pos := e.Pos()
 	// Validate enum name (PascalCase)
 	if !IsValidPascalCase(e.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("enum name '%s' should follow PascalCase convention", e.Name),
 			filename,
@@ -221,8 +308,26 @@ func (v *Validator) validateEnum(e *ast.EnumNode, filename string) {
 
 	// Validate variants
 	variantNames := make(map[string]*ast.EnumVariantNode)
+	jsonNames := make(map[string]*ast.EnumVariantNode)
 	for _, variant := range e.Variants {
 		v.validateEnumVariant(variant, filename, variantNames)
+
+		// Variants named twice are reported as duplicates already
+		if variantNames[variant.Name] != variant {
+			continue
+		}
+		if existing, exists := jsonNames[variant.JSONName()]; exists {
+			pos := variant.Pos()
+			v.addError(
+				DuplicateVariantError,
+				fmt.Sprintf("variant '%s' and variant '%s' both have the type '%s' in JSON in enum '%s'", existing.Name, variant.Name, variant.JSONName(), e.Name),
+				filename,
+				pos.Line, pos.Column,
+				"change one of the wire names",
+			)
+		} else {
+			jsonNames[variant.JSONName()] = variant
+		}
 	}
 }
 
@@ -231,7 +336,7 @@ func (v *Validator) validateEnumVariant(variant *ast.EnumVariantNode, filename s
 	pos := variant.Pos()
 	// Validate variant name (snake_case)
 	if !IsValidSnakeCase(variant.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("enum variant '%s' should follow snake_case convention", variant.Name),
 			filename,
@@ -243,7 +348,7 @@ func (v *Validator) validateEnumVariant(variant *ast.EnumVariantNode, filename s
 	// Check for duplicate variant names
 	if existing, exists := variantNames[variant.Name]; exists {
 		existingPos := existing.Pos()
-		v.result.AddError(
+		v.addError(
 			DuplicateVariantError,
 			fmt.Sprintf("duplicate variant '%s' (first declared at line %d)", variant.Name, existingPos.Line),
 			filename,
@@ -256,8 +361,28 @@ func (v *Validator) validateEnumVariant(variant *ast.EnumVariantNode, filename s
 
 	// Validate payload type if present
 	if variant.Payload != nil {
-		v.validateType(variant.Payload, filename, pos.Line, pos.Column)
+		v.validateType(variant.Payload, filename)
 	}
+
+	v.validateWireName(variant.WireName, fmt.Sprintf("variant '%s'", variant.Name), filename, pos)
+
+	v.validateAttributes(variant.Attributes, fmt.Sprintf("variant '%s'", variant.Name), filename)
+}
+
+// validateWireName validates the wire name of a field or variant, which
+// generators write into string literals and struct tags; owner names it in
+// messages, e.g. "field 'user_id'"
+func (v *Validator) validateWireName(wireName, owner, filename string, pos ast.Position) {
+	if !strings.ContainsAny(wireName, "\"\\`,") {
+		return
+	}
+	v.addError(
+		InvalidWireNameError,
+		fmt.Sprintf("wire name %q of %s contains quotes, backslashes or commas", wireName, owner),
+		filename,
+		pos.Line, pos.Column,
+		"use letters, digits, '_', '-' or '.' in wire names",
+	)
 }
 
 // validateTypeAlias validates a type alias declaration
@@ -265,7 +390,7 @@ func (v *Validator) validateTypeAlias(alias *ast.TypeAliasNode, filename string)
 	pos := alias.Pos()
 	// Validate alias name (PascalCase)
 	if !IsValidPascalCase(alias.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("type alias '%s' should follow PascalCase convention", alias.Name),
 			filename,
@@ -275,7 +400,55 @@ func (v *Validator) validateTypeAlias(alias *ast.TypeAliasNode, filename string)
 	}
 
 	// Validate aliased type
-	v.validateType(alias.Type, filename, pos.Line, pos.Column)
+	v.validateType(alias.Type, filename)
+
+	v.validateAliasCycle(alias, filename)
+}
+
+// validateAliasCycle reports a type alias that refers back to itself
+// through aliases only, which no generator can resolve. Cycles through a
+// struct or an enum are allowed.
+func (v *Validator) validateAliasCycle(alias *ast.TypeAliasNode, filename string) {
+	model := v.registry.Model()
+	decl, ok := model.DeclOf(alias)
+	if !ok {
+		return
+	}
+	cycle := aliasCycle(model, decl, decl, map[*semantic.Decl]bool{})
+	if cycle == nil {
+		return
+	}
+
+	chain := []string{decl.QualifiedName()}
+	for _, member := range cycle {
+		chain = append(chain, member.QualifiedName())
+	}
+	pos := alias.Pos()
+	v.addError(
+		CyclicAliasError,
+		fmt.Sprintf("type alias '%s' refers to itself: %s", alias.Name, strings.Join(chain, " -> ")),
+		filename,
+		pos.Line, pos.Column,
+		"declare one of the types of the cycle as a struct or an enum",
+	)
+}
+
+// aliasCycle returns the aliases through which an alias refers to target,
+// ending with target, or nil if it doesn't
+func aliasCycle(model *semantic.Model, decl, target *semantic.Decl, seen map[*semantic.Decl]bool) []*semantic.Decl {
+	seen[decl] = true
+	for _, dep := range model.DependenciesOf(decl) {
+		if dep == target {
+			return []*semantic.Decl{dep}
+		}
+		if dep.Kind != "alias" || seen[dep] {
+			continue
+		}
+		if cycle := aliasCycle(model, dep, target, seen); cycle != nil {
+			return append([]*semantic.Decl{dep}, cycle...)
+		}
+	}
+	return nil
 }
 
 // validateConstant validates a constant declaration
@@ -283,7 +456,7 @@ func (v *Validator) validateConstant(constant *ast.ConstantNode, filename string
 	pos := constant.Pos()
 	// Validate constant name (CONSTANT_CASE)
 	if !IsValidConstantCase(constant.Name) {
-		v.result.AddError(
+		v.addError(
 			NamingConventionError,
 			fmt.Sprintf("constant name '%s' should follow CONSTANT_CASE convention", constant.Name),
 			filename,
@@ -294,7 +467,7 @@ func (v *Validator) validateConstant(constant *ast.ConstantNode, filename string
 
 	// Validate constant value exists (basic check)
 	if constant.Value == nil {
-		v.result.AddError(
+		v.addError(
 			InvalidConstantError,
 			fmt.Sprintf("constant '%s' must have a value", constant.Name),
 			filename,
@@ -304,50 +477,53 @@ func (v *Validator) validateConstant(constant *ast.ConstantNode, filename string
 	}
 }
 
-// validateType validates a type reference
-func (v *Validator) validateType(typeNode ast.Type, filename string, line, column int) {
+// validateType validates a type reference. Errors are reported at the
+// type they are about, which may be nested in the type reference.
+func (v *Validator) validateType(typeNode ast.Type, filename string) {
 	switch t := typeNode.(type) {
 	case *ast.PrimitiveType:
-		v.validatePrimitiveType(t, filename, line, column)
+		v.validatePrimitiveType(t, filename)
 
 	case *ast.NamedType:
-		v.validateNamedType(t, filename, line, column)
+		v.validateNamedType(t, filename)
 
 	case *ast.ArrayType:
-		v.validateType(t.ElementType, filename, line, column)
+		v.validateType(t.ElementType, filename)
 
 	case *ast.MapType:
-		v.validateMapType(t, filename, line, column)
+		v.validateMapType(t, filename)
 
 	case *ast.OptionalType:
-		v.validateOptionalType(t, filename, line, column)
+		v.validateOptionalType(t, filename)
 	}
 }
 
 // validatePrimitiveType validates a primitive type
-func (v *Validator) validatePrimitiveType(primitive *ast.PrimitiveType, filename string, line, column int) {
+func (v *Validator) validatePrimitiveType(primitive *ast.PrimitiveType, filename string) {
+	pos := primitive.Pos()
 	if !IsValidPrimitiveType(primitive.Name) {
-		v.result.AddError(
+		v.addError(
 			InvalidPrimitiveError,
 			fmt.Sprintf("'%s' is not a valid primitive type", primitive.Name),
 			filename,
-			line, column,
+			pos.Line, pos.Column,
 			"use one of: int8, int16, int32, int64, nat8, nat16, nat32, nat64, float32, float64, string, bool, json, datetime, date, time",
 		)
 	}
 }
 
 // validateNamedType validates a named type reference
-func (v *Validator) validateNamedType(named *ast.NamedType, filename string, line, column int) {
+func (v *Validator) validateNamedType(named *ast.NamedType, filename string) {
+	pos := named.Pos()
 	// Check if it's a qualified type (contains a dot)
 	if strings.Contains(named.Name, ".") {
 		parts := strings.SplitN(named.Name, ".", 2)
 		if len(parts) != 2 {
-			v.result.AddError(
+			v.addError(
 				UndefinedTypeError,
 				fmt.Sprintf("invalid qualified type '%s'", named.Name),
 				filename,
-				line, column,
+				pos.Line, pos.Column,
 				"use module.Type format for qualified types",
 			)
 			return
@@ -359,11 +535,11 @@ func (v *Validator) validateNamedType(named *ast.NamedType, filename string, lin
 		// Check if the module is imported
 		fileImports, exists := v.imports[filename]
 		if !exists || fileImports[moduleName] == "" {
-			v.result.AddError(
+			v.addError(
 				UndefinedTypeError,
 				fmt.Sprintf("type '%s' refers to unimported module '%s'", named.Name, moduleName),
 				filename,
-				line, column,
+				pos.Line, pos.Column,
 				fmt.Sprintf("add 'import %s' or check module name", moduleName),
 			)
 			return
@@ -371,70 +547,73 @@ func (v *Validator) validateNamedType(named *ast.NamedType, filename string, lin
 
 		// Check if the qualified type exists
 		if !v.registry.QualifiedTypeExists(named.Name, fileImports[moduleName]) {
-			v.result.AddError(
+			v.addError(
 				UndefinedTypeError,
 				fmt.Sprintf("undefined type '%s' in module '%s'", typeName, moduleName),
 				filename,
-				line, column,
-				"define the type in the imported module or check the spelling",
+				pos.Line, pos.Column,
+				didYouMean(v.registry.SimilarQualifiedTypes(named.Name, fileImports[moduleName]),
+					"define the type in the imported module or check the spelling"),
 			)
 		}
 	} else {
 		// Regular type - check local scope first, then imported types
 		if !v.registry.TypeExists(named.Name, filename) {
-			v.result.AddError(
+			v.addError(
 				UndefinedTypeError,
 				fmt.Sprintf("undefined type '%s'", named.Name),
 				filename,
-				line, column,
-				"define the type or check the spelling",
+				pos.Line, pos.Column,
+				didYouMean(v.registry.SimilarTypes(named.Name, filename), "define the type or check the spelling"),
 			)
 		}
 	}
 }
 
 // validateMapType validates a map type
-func (v *Validator) validateMapType(mapType *ast.MapType, filename string, line, column int) {
+func (v *Validator) validateMapType(mapType *ast.MapType, filename string) {
 	// Validate key type - must be primitive and valid as map key
+	pos := mapType.KeyType.Pos()
 	if primitive, ok := mapType.KeyType.(*ast.PrimitiveType); ok {
 		if !IsValidMapKeyType(primitive.Name) {
-			v.result.AddError(
+			v.addError(
 				InvalidMapKeyError,
 				fmt.Sprintf("map key type '%s' is not valid", primitive.Name),
 				filename,
-				line, column,
+				pos.Line, pos.Column,
 				"use string or integer types for map keys",
 			)
 		}
 	} else {
-		v.result.AddError(
+		v.addError(
 			InvalidMapKeyError,
 			"map key must be a primitive type",
 			filename,
-			line, column,
+			pos.Line, pos.Column,
 			"use string or integer types for map keys",
 		)
 	}
 
 	// Validate key and value types
-	v.validateType(mapType.KeyType, filename, line, column)
-	v.validateType(mapType.ValueType, filename, line, column)
+	v.validateType(mapType.KeyType, filename)
+	v.validateType(mapType.ValueType, filename)
 }
 
 // validateOptionalType validates an optional type
-func (v *Validator) validateOptionalType(optional *ast.OptionalType, filename string, line, column int) {
+func (v *Validator) validateOptionalType(optional *ast.OptionalType, filename string) {
+	pos := optional.Pos()
 	// Check for double-wrapped optionals (??)
 	if _, isOptional := optional.ElementType.(*ast.OptionalType); isOptional {
-		v.result.AddError(
+		v.addError(
 			InvalidOptionalError,
 			"double-wrapped optional types are not allowed",
 			filename,
-			line, column,
+			pos.Line, pos.Column,
 			"use single optional marker ?Type",
 		)
 	}
 
 	// Validate the wrapped type
-	v.validateType(optional.ElementType, filename, line, column)
+	v.validateType(optional.ElementType, filename)
 }
 
@@ -1,13 +1,22 @@
 package validator
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/WhatsApp-Platform/typegen/naming"
 	"github.com/WhatsApp-Platform/typegen/parser"
 	"github.com/WhatsApp-Platform/typegen/parser/ast"
 )
 
+var update = flag.Bool("update", false, "update golden files")
+
 func TestValidator_ValidModule(t *testing.T) {
 	schema := `
 struct User {
@@ -25,6 +34,9 @@ enum Status {
 
 type UserID = int64
 const MAX_USERS = 1000
+const LOAD_FACTOR = 0.75
+const EPSILON = -1e-9
+const STRICT_MODE = false
 `
 
 	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
@@ -150,6 +162,61 @@ struct User {
 	}
 }
 
+func TestValidator_Rules(t *testing.T) {
+	schema := `
+struct User {
+	userID: int64
+	role: Role
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	validator := NewValidator()
+	validator.SetRules(Rules{NamingConventionError: SeverityWarning, UndefinedTypeError: SeverityOff})
+	result := validator.Validate(module)
+
+	if result.HasErrors() || !result.Valid {
+		t.Errorf("Expected no errors, got %v", result.Errors)
+	}
+	if result.WarningCount() != 1 || result.Warnings[0].Type != NamingConventionError || result.Warnings[0].Severity != SeverityWarning {
+		t.Fatalf("Expected a naming convention warning, got %v", result.Warnings)
+	}
+	if output := result.String(); !strings.Contains(output, "Validation warnings found (1):") || strings.Contains(output, "Validation errors") {
+		t.Errorf("Expected only warnings in the output, got:\n%s", output)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(map[string]string{"naming_convention": "warning", "invalid_import": "off"})
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if rules[NamingConventionError] != SeverityWarning || rules[InvalidImportError] != SeverityOff {
+		t.Errorf("Unexpected rules: %v", rules)
+	}
+
+	tests := []struct {
+		rules map[string]string
+		err   string
+	}{
+		{map[string]string{"naming": "warning"}, "unknown validation rule \"naming\""},
+		{map[string]string{"naming_convention": "warn"}, "rule naming_convention: unknown severity \"warn\""},
+	}
+	for _, tt := range tests {
+		if _, err := ParseRules(tt.rules); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected error containing %q, got %v", tt.err, err)
+		}
+	}
+}
+
 func TestValidator_DuplicateFields(t *testing.T) {
 	schema := `
 struct User {
@@ -187,6 +254,154 @@ struct User {
 	}
 }
 
+func TestValidator_DuplicateTypesAcrossFiles(t *testing.T) {
+	schemas := map[string]string{
+		"user.tg":        "struct User {\n\tid: int64\n}\n",
+		"legacy.tg":      "const VERSION = 1\n\nstruct User {\n\tname: string\n}\n",
+		"auth/user.tg":   "struct User {\n\ttoken: string\n}\n",
+		"auth/legacy.tg": "enum Role {\n\tadmin\n}\n",
+	}
+	root := make(map[string]*ast.ProgramNode)
+	auth := make(map[string]*ast.ProgramNode)
+	for filename, schema := range schemas {
+		program, err := parser.Parse(strings.NewReader(schema), filename)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", filename, err)
+		}
+		if dir, name, nested := strings.Cut(filename, "/"); nested && dir == "auth" {
+			auth[name] = program
+		} else {
+			root[filename] = program
+		}
+	}
+	module := ast.NewModule("test", root)
+	module.SubModules = map[string]*ast.Module{"auth": ast.NewModule("auth", auth)}
+
+	validator := NewValidator()
+	result := validator.Validate(module)
+	result.SortErrors()
+
+	// auth.User is in another module, and doesn't collide
+	var duplicates []string
+	for _, err := range result.Errors {
+		if err.Type == DuplicateTypeError {
+			duplicates = append(duplicates, fmt.Sprintf("%s:%d:%d %s", err.File, err.Line, err.Column, err.Message))
+		}
+	}
+	expected := []string{
+		"legacy.tg:3:1 struct 'User' is also declared in user.tg at line 1",
+		"user.tg:1:1 struct 'User' is also declared in legacy.tg at line 3",
+	}
+	if strings.Join(duplicates, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected duplicates:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(duplicates, "\n"))
+	}
+}
+
+// registerReservedWords registers the reserved words of a generator until the
+// end of the test, restoring any it replaces
+func registerReservedWords(t *testing.T, generator string, words ReservedWords) {
+	t.Helper()
+	previous, registered := LookupReservedWords(generator)
+	RegisterReservedWords(generator, words)
+	t.Cleanup(func() {
+		reservedMu.Lock()
+		defer reservedMu.Unlock()
+		if registered {
+			reserved[generator] = previous
+		} else {
+			delete(reserved, generator)
+		}
+	})
+}
+
+func TestValidator_ReservedWords(t *testing.T) {
+	registerReservedWords(t, "test-python", ReservedWords{
+		Language: "Python",
+		Words:    naming.PythonKeywords,
+		Names:    []string{FieldNames, TypeNames, ModuleNames},
+	})
+	registerReservedWords(t, "test-go", ReservedWords{
+		Language: "Go",
+		Words:    naming.GoKeywords,
+		Names:    []string{TypeNames, ModuleNames},
+	})
+
+	schema := `
+struct Request {
+	from: string
+	def: int64
+	func: bool
+	None: ?string
+	class: string = "kind"
+}
+`
+	program, err := parser.Parse(strings.NewReader(schema), "request.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"request.tg": program})
+	module.SubModules = map[string]*ast.Module{"func": ast.NewModule("func", nil)}
+
+	reserved := func(generators ...string) []string {
+		validator := NewValidator()
+		validator.SetGenerators(generators)
+		var messages []string
+		for _, err := range validator.Validate(module).Errors {
+			if err.Type == ReservedWordError {
+				messages = append(messages, fmt.Sprintf("%s:%d:%d %s (%s)", err.File, err.Line, err.Column, err.Message, err.Suggestion))
+			}
+		}
+		sort.Strings(messages)
+		return messages
+	}
+
+	if messages := reserved(); len(messages) != 0 {
+		t.Errorf("Expected no reserved words without generators, got %v", messages)
+	}
+
+	// import and type are keywords of TypeGen too, and can't name fields. Go
+	// fields are written in PascalCase, so only the package name is reserved.
+	expected := []string{
+		"func:0:0 module 'func' is a reserved word in Go, the target language of generator 'test-go' (rename the directory)",
+		"request.tg:3:2 field 'from' of struct 'Request' is a reserved word in Python, the target language of generator 'test-python' (rename the field, and give it the wire name \"from\" to keep its JSON name)",
+		"request.tg:4:2 field 'def' of struct 'Request' is a reserved word in Python, the target language of generator 'test-python' (rename the field, and give it the wire name \"def\" to keep its JSON name)",
+		"request.tg:6:2 field 'None' of struct 'Request' is a reserved word in Python, the target language of generator 'test-python' (rename the field, and give it the wire name \"None\" to keep its JSON name)",
+		"request.tg:7:2 field 'class' of struct 'Request' is a reserved word in Python, the target language of generator 'test-python' (rename the field; its wire name keeps its JSON name)",
+	}
+	if messages := reserved("test-python", "test-go", "unregistered"); strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected reserved words:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestValidator_ReservedWordsIgnoreCase(t *testing.T) {
+	registerReservedWords(t, "test-hack", ReservedWords{
+		Language:   "Hack",
+		Words:      naming.NewReserved("function"),
+		IgnoreCase: true,
+		Names:      []string{TypeNames},
+	})
+
+	program, err := parser.Parse(strings.NewReader("struct Function {\n\tid: int64\n}\n"), "function.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{"function.tg": program})
+
+	validator := NewValidator()
+	validator.SetGenerators([]string{"test-hack"})
+	var messages []string
+	for _, err := range validator.Validate(module).Errors {
+		if err.Type == ReservedWordError {
+			messages = append(messages, err.Message)
+		}
+	}
+
+	expected := "struct 'Function' is a reserved word in Hack, the target language of generator 'test-hack'"
+	if len(messages) != 1 || messages[0] != expected {
+		t.Errorf("Expected %q, got %v", expected, messages)
+	}
+}
+
 func TestValidator_InvalidPrimitiveType(t *testing.T) {
 	schema := `
 struct User {
@@ -225,6 +440,328 @@ struct User {
 	}
 }
 
+func TestValidator_Decimal(t *testing.T) {
+	schema := `
+struct Invoice {
+	total: decimal
+	discount: ?decimal
+	lines: []decimal
+	taxes: [string]decimal
+}
+
+type Price = decimal
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+	if result.HasErrors() {
+		t.Errorf("Expected decimal fields and aliases to be valid, got: %s", result.String())
+	}
+}
+
+func TestValidator_UUID(t *testing.T) {
+	schema := `
+struct User {
+	id: uuid
+	invited_by: ?uuid
+	sessions: []uuid
+	roles: [uuid]string
+}
+
+type UserID = uuid
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+	if result.HasErrors() {
+		t.Errorf("Expected uuid fields, aliases and map keys to be valid, got: %s", result.String())
+	}
+}
+
+func TestValidator_Attributes(t *testing.T) {
+	schema := `
+struct User {
+	email: string @sensitive @deprecated("use contact")
+	name: string @deprecated
+	nickname: ?string @searchable
+	phone: string @sensitive(true)
+	address: string @deprecated(3)
+	token: string @sensitive @sensitive
+}
+
+enum Status {
+	active
+	legacy @deprecated @obsolete
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+
+	// Unknown attributes are warnings, misused known ones errors
+	var warnings, invalid []string
+	for _, warning := range result.Warnings {
+		if warning.Type != UnknownAttributeError {
+			t.Errorf("Unexpected warning: %v", warning)
+		}
+		warnings = append(warnings, warning.Message)
+	}
+	for _, err := range result.Errors {
+		if err.Type != InvalidAttributeError {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		invalid = append(invalid, err.Message)
+	}
+	expectedWarnings := []string{
+		"unknown attribute '@searchable' on field 'nickname'",
+		"unknown attribute '@obsolete' on variant 'legacy'",
+	}
+	expectedErrors := []string{
+		"attribute '@sensitive' on field 'phone' takes no value",
+		"attribute '@deprecated' on field 'address' takes a string value, not int",
+		"duplicate attribute '@sensitive' on field 'token'",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Errorf("Expected warnings:\n%s\ngot:\n%s", strings.Join(expectedWarnings, "\n"), strings.Join(warnings, "\n"))
+	}
+	if strings.Join(invalid, "\n") != strings.Join(expectedErrors, "\n") {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(expectedErrors, "\n"), strings.Join(invalid, "\n"))
+	}
+
+	// Rules can still make unknown attributes errors, and registering an
+	// attribute makes it known
+	RegisterAttribute(AttributeSpec{Name: "searchable"})
+	defer func() {
+		attributesMu.Lock()
+		delete(attributes, "searchable")
+		attributesMu.Unlock()
+	}()
+	validator := NewValidator()
+	validator.SetRules(Rules{UnknownAttributeError: SeverityError})
+	result = validator.Validate(module)
+	unknown := 0
+	for _, err := range result.Errors {
+		if err.Type == UnknownAttributeError {
+			unknown++
+		}
+	}
+	if unknown != 1 || len(result.Warnings) != 0 {
+		t.Errorf("Expected @obsolete alone to be an unknown attribute error, got errors %v and warnings %v", result.Errors, result.Warnings)
+	}
+}
+
+func TestValidator_Embeds(t *testing.T) {
+	schema := `
+struct Audit {
+	created: datetime
+	updated: datetime
+}
+
+struct Order {
+	id: int64
+	...Audit
+	...Status
+	...Missing
+}
+
+struct Invoice {
+	...Audit
+	created: date
+}
+
+struct Shipment {
+	...Audit
+	...Stamped
+}
+
+struct Stamped {
+	...Audit
+}
+
+struct First {
+	...Second
+}
+
+struct Second {
+	...First
+}
+
+enum Status {
+	active
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+
+	var messages []string
+	for _, err := range result.Errors {
+		messages = append(messages, string(err.Type)+": "+err.Message)
+	}
+	expected := []string{
+		"invalid_embed: cannot embed enum 'Status' in struct 'Order'",
+		"undefined_type: undefined type 'Missing'",
+		"duplicate_field: field 'created' collides with field 'created' embedded from 'Audit' in struct 'Invoice'",
+		"duplicate_field: field 'created' embedded from 'Audit' collides with field 'created' embedded from 'Audit' in struct 'Shipment'",
+		"duplicate_field: field 'updated' embedded from 'Audit' collides with field 'updated' embedded from 'Audit' in struct 'Shipment'",
+		"invalid_embed: embedding 'Second' in struct 'First' forms a cycle",
+		"invalid_embed: embedding 'First' in struct 'Second' forms a cycle",
+		"infinite_type: struct 'First' contains itself without indirection, so its values would be infinite: First -> Second -> First",
+		"infinite_type: struct 'Second' contains itself without indirection, so its values would be infinite: Second -> First -> Second",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestValidator_WireNames(t *testing.T) {
+	schema := `
+struct Audit {
+	created_by: string = "createdBy"
+}
+
+struct User {
+	user_id: int64 = "userId"
+	uid: int64 = "userId"
+	name: string = "user_name"
+	user_name: string
+	note: string = "a,b"
+	...Audit
+	creator: string = "createdBy"
+}
+
+enum Status {
+	active = "ACTIVE"
+	enabled = "ACTIVE"
+	ACTIVE
+	paused = "PAUSED"
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+
+	var messages []string
+	for _, err := range result.Errors {
+		if err.Type == NamingConventionError {
+			continue
+		}
+		messages = append(messages, string(err.Type)+": "+err.Message)
+	}
+	expected := []string{
+		`invalid_wire_name: wire name "a,b" of field 'note' contains quotes, backslashes or commas`,
+		"duplicate_field: field 'user_id' and field 'uid' are both named 'userId' in JSON in struct 'User'",
+		"duplicate_field: field 'name' and field 'user_name' are both named 'user_name' in JSON in struct 'User'",
+		"duplicate_field: field 'created_by' embedded from 'Audit' and field 'creator' are both named 'createdBy' in JSON in struct 'User'",
+		"duplicate_variant: variant 'active' and variant 'enabled' both have the type 'ACTIVE' in JSON in enum 'Status'",
+		"duplicate_variant: variant 'active' and variant 'ACTIVE' both have the type 'ACTIVE' in JSON in enum 'Status'",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestValidator_BigIntegers(t *testing.T) {
+	schema := `
+struct Ledger {
+	balance: bigint
+	supply: ?bignat
+	history: []bigint
+	by_account: [string]bignat
+	by_amount: [bigint]string
+}
+
+type Supply = bignat
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	// Big integers are valid everywhere but as map keys
+	result := NewValidator().Validate(module)
+	if len(result.Errors) != 1 || result.Errors[0].Type != InvalidMapKeyError || !strings.Contains(result.Errors[0].Message, "bigint") {
+		t.Errorf("Expected a single invalid map key error for bigint, got: %s", result.String())
+	}
+}
+
+func TestValidator_PlatformIntegers(t *testing.T) {
+	schema := `
+struct Counter {
+	total: int
+	hits: ?nat
+	by_id: [int]nat
+	by_index: [nat][]int
+}
+
+type Count = nat
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	// int and nat are valid, and the error for other primitives is unchanged
+	counter := program.Declarations[0].(*ast.StructNode)
+	counter.Fields = append(counter.Fields, &ast.FieldNode{Name: "wide", Type: &ast.PrimitiveType{Name: "int128"}})
+	result := NewValidator().Validate(module)
+	expected := "'int128' is not a valid primitive type"
+	suggestion := "use one of: int8, int16, int32, int64, nat8, nat16, nat32, nat64, float32, float64, string, bool, json, datetime, date, time"
+	if len(result.Errors) != 1 || result.Errors[0].Message != expected || result.Errors[0].Suggestion != suggestion {
+		t.Errorf("Expected a single error for int128, got: %s", result.String())
+	}
+}
+
 func TestValidator_InvalidMapKey(t *testing.T) {
 	schema := `
 struct User {
@@ -252,6 +789,13 @@ struct User {
 	for _, err := range result.Errors {
 		if err.Type == InvalidMapKeyError {
 			foundMapKeyError = true
+			if err.Severity != SeverityError {
+				t.Errorf("Expected error severity, got %q", err.Severity)
+			}
+			// Reported at the key type rather than at the field
+			if err.Line != 3 || err.Column != 13 {
+				t.Errorf("Expected the error at 3:13, got %d:%d", err.Line, err.Column)
+			}
 			break
 		}
 	}
@@ -288,11 +832,12 @@ func TestNamingConventionRules(t *testing.T) {
 
 func TestPrimitiveTypeValidation(t *testing.T) {
 	validTypes := []string{
-		"int8", "int16", "int32", "int64",
-		"nat8", "nat16", "nat32", "nat64",
-		"float32", "float64",
+		"int8", "int16", "int32", "int64", "int",
+		"nat8", "nat16", "nat32", "nat64", "nat",
+		"bigint", "bignat",
+		"float32", "float64", "decimal",
 		"string", "bool", "json",
-		"datetime", "date", "time",
+		"datetime", "date", "time", "datetimetz", "datetz", "timetz", "uuid",
 	}
 
 	for _, typ := range validTypes {
@@ -310,14 +855,14 @@ func TestPrimitiveTypeValidation(t *testing.T) {
 }
 
 func TestMapKeyValidation(t *testing.T) {
-	validKeys := []string{"string", "int8", "int16", "int32", "int64", "nat8", "nat16", "nat32", "nat64"}
+	validKeys := []string{"string", "int8", "int16", "int32", "int64", "int", "nat8", "nat16", "nat32", "nat64", "nat", "uuid"}
 	for _, key := range validKeys {
 		if !IsValidMapKeyType(key) {
 			t.Errorf("Key type %s should be valid", key)
 		}
 	}
 
-	invalidKeys := []string{"float32", "float64", "bool", "json", "datetime"}
+	invalidKeys := []string{"bigint", "bignat", "float32", "float64", "decimal", "bool", "json", "datetime"}
 	for _, key := range invalidKeys {
 		if IsValidMapKeyType(key) {
 			t.Errorf("Key type %s should be invalid", key)
@@ -369,7 +914,7 @@ struct NodeA {
 	schemaB := `
 struct NodeB {
 	id: string
-	a_node: NodeA
+	a_node: ?NodeA
 }
 `
 
@@ -396,6 +941,120 @@ struct NodeB {
 	}
 }
 
+func TestValidator_AliasCycles(t *testing.T) {
+	schema := `
+type First = Second
+type Second = [string]First
+
+type Itself = []Itself
+
+type Children = []Tree
+struct Tree {
+	children: Children
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	validator := NewValidator()
+	result := validator.Validate(module)
+
+	// Children and Tree form a cycle through a struct, which is allowed
+	var cycles []string
+	for _, err := range result.Errors {
+		cycles = append(cycles, fmt.Sprintf("%s:%d:%d %s: %s", err.File, err.Line, err.Column, err.Type, err.Message))
+	}
+	expected := []string{
+		"test.tg:2:1 cyclic_alias: type alias 'First' refers to itself: First -> Second -> First",
+		"test.tg:3:1 cyclic_alias: type alias 'Second' refers to itself: Second -> First -> Second",
+		"test.tg:5:1 cyclic_alias: type alias 'Itself' refers to itself: Itself -> Itself",
+	}
+	if strings.Join(cycles, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(cycles, "\n"))
+	}
+}
+
+func TestValidator_InfiniteTypes(t *testing.T) {
+	schema := `
+struct Order {
+	id: int64
+	customer: Customer
+}
+
+struct Customer {
+	last_order: Order
+}
+
+struct Loop {
+	next: Loop
+}
+
+enum Chain {
+	link: Chain
+}
+
+type Inner = Outer
+struct Outer {
+	inner: Inner
+}
+
+struct Optional {
+	next: ?Optional
+}
+
+struct Listed {
+	children: []Listed
+	by_name: [string]Listed
+}
+
+enum Expr {
+	literal: int64
+	negate: Expr
+	sum: Sum
+}
+
+struct Sum {
+	left: Expr
+	right: Expr
+}
+`
+
+	program, err := parser.Parse(strings.NewReader(schema), "test.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"test.tg": program,
+	})
+
+	result := NewValidator().Validate(module)
+
+	// Optional, Listed, Expr and Sum refer to themselves through ?, [], a
+	// map or an enum with a finite variant, which is allowed
+	var cycles []string
+	for _, err := range result.Errors {
+		cycles = append(cycles, fmt.Sprintf("%s:%d:%d %s: %s", err.File, err.Line, err.Column, err.Type, err.Message))
+	}
+	expected := []string{
+		"test.tg:2:1 infinite_type: struct 'Order' contains itself without indirection, so its values would be infinite: Order -> Customer -> Order",
+		"test.tg:7:1 infinite_type: struct 'Customer' contains itself without indirection, so its values would be infinite: Customer -> Order -> Customer",
+		"test.tg:11:1 infinite_type: struct 'Loop' contains itself without indirection, so its values would be infinite: Loop -> Loop",
+		"test.tg:15:1 infinite_type: enum 'Chain' contains itself without indirection, so its values would be infinite: Chain -> Chain",
+		"test.tg:20:1 infinite_type: struct 'Outer' contains itself without indirection, so its values would be infinite: Outer -> Inner -> Outer",
+	}
+	if strings.Join(cycles, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(cycles, "\n"))
+	}
+}
+
 func TestValidator_SelfReference_Allowed(t *testing.T) {
 	schema := `
 struct TreeNode {
@@ -636,6 +1295,95 @@ struct Session {
 	}
 }
 
+func TestValidator_UndefinedTypeSuggestions(t *testing.T) {
+	mainSchema := `
+import auth
+
+struct Address {
+	street: string
+}
+
+struct UserId {
+	value: int64
+}
+
+struct Can {}
+struct Cap {}
+struct Car {}
+struct Cat {}
+
+struct Order {
+	shipping: Adress
+	placed: Datetime
+	note: String
+	owner: UserID
+	vehicle: Caz
+	lid: CAp
+	pet: Zebra
+	session: Tokn
+	token: auth.Tokn
+}
+`
+	tokenSchema := `
+struct Token {
+	value: string
+}
+`
+
+	mainProgram, err := parser.Parse(strings.NewReader(mainSchema), "main.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse main schema: %v", err)
+	}
+	tokenProgram, err := parser.Parse(strings.NewReader(tokenSchema), "token.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse token schema: %v", err)
+	}
+
+	module := ast.NewModule("test", map[string]*ast.ProgramNode{
+		"main.tg": mainProgram,
+	})
+	module.SubModules["auth"] = ast.NewModule("auth", map[string]*ast.ProgramNode{
+		"token.tg": tokenProgram,
+	})
+
+	result := NewValidator().Validate(module)
+
+	suggestions := make(map[string]string)
+	for _, err := range result.Errors {
+		if err.Type == UndefinedTypeError {
+			suggestions[err.Message] = err.Suggestion
+		}
+	}
+	tests := []struct {
+		message    string
+		suggestion string
+	}{
+		{"undefined type 'Adress'", "did you mean 'Address'?"},
+		// Case-only mismatches hide the other candidates, and primitives
+		// are among them
+		{"undefined type 'Datetime'", "did you mean 'datetime'?"},
+		{"undefined type 'String'", "did you mean 'string'?"},
+		{"undefined type 'UserID'", "did you mean 'UserId'?"},
+		{"undefined type 'CAp'", "did you mean 'Cap'?"},
+		// Ties are listed alphabetically, three at most
+		{"undefined type 'Caz'", "did you mean 'Can', 'Cap' or 'Car'?"},
+		// Nothing is close, and auth.Token isn't visible unqualified
+		{"undefined type 'Zebra'", "define the type or check the spelling"},
+		{"undefined type 'Tokn'", "define the type or check the spelling"},
+		{"undefined type 'Tokn' in module 'auth'", "did you mean 'auth.Token'?"},
+	}
+	for _, tt := range tests {
+		suggestion, ok := suggestions[tt.message]
+		if !ok {
+			t.Errorf("Expected error %q, got: %s", tt.message, result.String())
+			continue
+		}
+		if suggestion != tt.suggestion {
+			t.Errorf("Suggestion for %q: expected %q, got %q", tt.message, tt.suggestion, suggestion)
+		}
+	}
+}
+
 func TestValidator_CrossModuleReference_NestedModules(t *testing.T) {
 	// auth/user.tg
 	userSchema := `
@@ -700,3 +1448,384 @@ struct Session {
 		t.Errorf("Nested module reference should be valid, but got errors: %s", result.String())
 	}
 }
+
+func TestValidator_UnusedImports(t *testing.T) {
+	libSchema := `
+struct Money {
+	cents: int64
+}
+
+struct Audit {
+	created: datetime
+}
+`
+
+	mainSchema := `
+import billing
+import audit
+import shipping
+import tracking
+import payments
+import legacy
+
+struct Order {
+	...audit.Audit
+	totals: ?[string][]billing.Money
+}
+
+enum Event {
+	shipped: shipping.Money
+	tracked
+}
+
+type Amounts = [string]payments.Money
+
+struct Parcel {
+	note: string
+	label: tracking.Label
+}
+`
+
+	schemas := map[string]string{
+		"billing.tg":  libSchema,
+		"audit.tg":    libSchema,
+		"shipping.tg": libSchema,
+		"tracking.tg": libSchema,
+		"payments.tg": libSchema,
+		"legacy.tg":   libSchema,
+		"main.tg":     mainSchema,
+	}
+	programs := make(map[string]*ast.ProgramNode)
+	for filename, schema := range schemas {
+		program, err := parser.Parse(strings.NewReader(schema), filename)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", filename, err)
+		}
+		programs[filename] = program
+	}
+
+	validator := NewValidator()
+	result := validator.Validate(ast.NewModule("test", programs))
+
+	// tracking.Label is undefined, but still uses the import
+	var unused []string
+	for _, warning := range result.Warnings {
+		if warning.Type == UnusedImportError {
+			unused = append(unused, fmt.Sprintf("%s:%d:%d %s (%s)", warning.File, warning.Line, warning.Column, warning.Message, warning.Suggestion))
+		}
+	}
+	expected := []string{"main.tg:7:1 import 'legacy' is not used (remove the import)"}
+	if strings.Join(unused, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected unused imports:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(unused, "\n"))
+	}
+	for _, err := range result.Errors {
+		if err.Type == UnusedImportError {
+			t.Errorf("Expected unused imports to be warnings, got error %v", err)
+		}
+	}
+}
+
+func TestValidator_UnusedTypes(t *testing.T) {
+	mainSchema := `
+import billing
+
+struct Order {
+	total: billing.Money
+}
+`
+
+	billingSchema := `
+struct Money {
+	cents: int64
+	currency: Currency
+}
+
+enum Currency {
+	eur
+	usd
+}
+
+type Cents = int64
+
+struct Refund {
+	amount: Money
+	parent: ?Refund
+}
+
+enum Status {
+	paid
+	refunded: Refund
+}
+
+const MAX_CENTS = 100
+`
+
+	mainProgram, err := parser.Parse(strings.NewReader(mainSchema), "main.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse main schema: %v", err)
+	}
+	billingProgram, err := parser.Parse(strings.NewReader(billingSchema), "money.tg")
+	if err != nil {
+		t.Fatalf("Failed to parse billing schema: %v", err)
+	}
+	module := ast.NewModule("shop", map[string]*ast.ProgramNode{"main.tg": mainProgram})
+	module.SubModules = map[string]*ast.Module{
+		"billing": ast.NewModule("billing", map[string]*ast.ProgramNode{"money.tg": billingProgram}),
+	}
+
+	unused := func(result *ValidationResult) []string {
+		var messages []string
+		for _, warning := range result.Warnings {
+			if warning.Type == UnusedTypeError {
+				messages = append(messages, fmt.Sprintf("%s:%d:%d %s", warning.File, warning.Line, warning.Column, warning.Message))
+			}
+		}
+		return messages
+	}
+
+	if messages := unused(NewValidator().Validate(module)); len(messages) != 0 {
+		t.Errorf("Expected unused_type to be off by default, got %v", messages)
+	}
+
+	// Refund only refers to itself, Status refers to it but is unreferenced
+	tests := []struct {
+		scope    UnusedTypeScope
+		expected []string
+	}{
+		{"", []string{
+			"billing/money.tg:12:1 type alias 'Cents' is never referenced",
+			"billing/money.tg:19:1 enum 'Status' is never referenced",
+		}},
+		{UnusedTypesEverywhere, []string{
+			"billing/money.tg:12:1 type alias 'Cents' is never referenced",
+			"billing/money.tg:19:1 enum 'Status' is never referenced",
+			"main.tg:4:1 struct 'Order' is never referenced",
+		}},
+	}
+	for _, tt := range tests {
+		validator := NewValidator()
+		validator.SetRules(Rules{UnusedTypeError: SeverityWarning})
+		validator.SetUnusedTypeScope(tt.scope)
+		result := validator.Validate(module)
+		if result.HasErrors() {
+			t.Fatalf("Expected no errors, got: %s", result.String())
+		}
+		if messages := unused(result); strings.Join(messages, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("Scope %q: expected unused types:\n%s\ngot:\n%s", tt.scope, strings.Join(tt.expected, "\n"), strings.Join(messages, "\n"))
+		}
+	}
+
+	if _, err := ParseUnusedTypeScope("root"); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+}
+
+func TestTypeRegistry_Resolve(t *testing.T) {
+	parse := func(src, filename string) *ast.ProgramNode {
+		program, err := parser.Parse(strings.NewReader(src), filename)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", filename, err)
+		}
+		return program
+	}
+
+	authModule := ast.NewModule("auth", map[string]*ast.ProgramNode{
+		"user.tg": parse("struct User {\n\tid: int64\n}\n", "user.tg"),
+	})
+	mainModule := ast.NewModule("main", map[string]*ast.ProgramNode{
+		"main.tg":  parse("import auth\n\nstruct Session {\n\tuser: auth.User\n\trole: Role\n}\n", "main.tg"),
+		"roles.tg": parse("enum Role {\n\tadmin\n}\n", "roles.tg"),
+	})
+	mainModule.SubModules = map[string]*ast.Module{"auth": authModule}
+
+	registry := BuildTypeRegistry(mainModule)
+
+	tests := []struct {
+		name     string
+		file     string
+		expected string // file of the resolved declaration, "" if unresolved
+	}{
+		{"auth.User", "main.tg", "auth/user.tg"},
+		{"Role", "main.tg", "roles.tg"},
+		{"Session", "roles.tg", "main.tg"},
+		{"User", "main.tg", ""},      // not in the same module
+		{"auth.User", "roles.tg", ""}, // auth is not imported
+		{"string", "main.tg", ""},
+	}
+	for _, tt := range tests {
+		info, found := registry.Resolve(tt.name, tt.file)
+		switch {
+		case tt.expected == "" && found:
+			t.Errorf("Resolve(%q, %q): expected no match, got %s", tt.name, tt.file, info.File)
+		case tt.expected != "" && (!found || info.File != tt.expected):
+			t.Errorf("Resolve(%q, %q): expected %s, got %v", tt.name, tt.file, tt.expected, info)
+		}
+	}
+
+	info, _ := registry.Resolve("auth.User", "main.tg")
+	if _, ok := info.Decl.(*ast.StructNode); !ok {
+		t.Errorf("expected the resolved type to carry its declaration, got %T", info.Decl)
+	}
+	if types := registry.Types(); len(types) != 3 || types[0].File != "auth/user.tg" {
+		t.Errorf("expected 3 types ordered by file, got %v", types)
+	}
+}
+
+func TestTypeRegistry_FindType(t *testing.T) {
+	parse := func(src, filename string) *ast.ProgramNode {
+		program, err := parser.Parse(strings.NewReader(src), filename)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", filename, err)
+		}
+		return program
+	}
+
+	// Types of the same name in several files are found in file order,
+	// whatever the order the module's maps are built and iterated in
+	for range 20 {
+		module := ast.NewModule("main", map[string]*ast.ProgramNode{
+			"main.tg": parse("struct Session {\n\tid: int64\n}\n", "main.tg"),
+		})
+		module.SubModules = map[string]*ast.Module{
+			"billing": ast.NewModule("billing", map[string]*ast.ProgramNode{
+				"user.tg": parse("struct User {\n\tid: int64\n}\n", "user.tg"),
+			}),
+			"auth": ast.NewModule("auth", map[string]*ast.ProgramNode{
+				"user.tg":  parse("struct User {\n\tid: int64\n}\n", "user.tg"),
+				"roles.tg": parse("enum Role {\n\tadmin\n}\n", "roles.tg"),
+				"admin.tg": parse("enum Role {\n\troot\n}\n", "admin.tg"),
+			}),
+		}
+		registry := BuildTypeRegistry(module)
+
+		tests := []struct {
+			name     string
+			file     string
+			expected string
+		}{
+			{"User", "main.tg", "auth/user.tg"},
+			{"User", "billing/user.tg", "billing/user.tg"},
+			{"Role", "main.tg", "auth/admin.tg"},
+			{"Role", "auth/user.tg", "auth/admin.tg"},
+			{"Role", "auth/roles.tg", "auth/roles.tg"},
+		}
+		for _, tt := range tests {
+			if info, found := registry.FindType(tt.name, tt.file); !found || info.File != tt.expected {
+				t.Fatalf("FindType(%q, %q): expected %s, got %v", tt.name, tt.file, tt.expected, info)
+			}
+		}
+		if _, found := registry.FindType("Missing", "main.tg"); found {
+			t.Fatal("FindType(\"Missing\"): expected no match")
+		}
+	}
+}
+
+func TestToSARIF(t *testing.T) {
+	module, err := parser.ParseModuleToAST(filepath.Join("testdata", "sarif"))
+	if err != nil {
+		t.Fatalf("failed to parse module: %v", err)
+	}
+	result := NewValidator().Validate(module)
+	if !result.HasErrors() || !result.HasWarnings() {
+		t.Fatalf("expected errors and warnings, got:\n%s", result)
+	}
+
+	data, err := result.ToSARIF("v1.2.0")
+	if err != nil {
+		t.Fatalf("ToSARIF failed: %v", err)
+	}
+	data = append(data, '\n')
+	path := filepath.Join("testdata", "sarif.golden")
+	if *update {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(expected) != string(data) {
+		t.Errorf("output does not match %s (run with -update to refresh)\n--- expected ---\n%s\n--- actual ---\n%s", path, expected, data)
+	}
+
+	// Every rule is described, and results refer to their rule by index
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	rules := log.Runs[0].Tool.Driver.Rules
+	if len(rules) != len(AllRules) {
+		t.Fatalf("expected %d rules, got %d", len(AllRules), len(rules))
+	}
+	for _, rule := range rules {
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("rule %s has no description", rule.ID)
+		}
+	}
+	for _, result := range log.Runs[0].Results {
+		if rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("result of %s refers to rule %s", result.RuleID, rules[result.RuleIndex].ID)
+		}
+	}
+}
+
+// largeModule builds a module of 5000 structs: 10 submodules of 10 files of
+// 50 structs, each referring to a struct of another file of its submodule
+// and to one of the previous submodule
+func largeModule(b *testing.B) *ast.Module {
+	b.Helper()
+	root := ast.NewModule("large", map[string]*ast.ProgramNode{})
+	for m := range 10 {
+		files := make(map[string]*ast.ProgramNode)
+		for f := range 10 {
+			var source strings.Builder
+			if m > 0 {
+				fmt.Fprintf(&source, "import mod%d\n\n", m-1)
+			}
+			for i := range 50 {
+				fmt.Fprintf(&source, "struct Mod%dFile%dType%d {\n\tid: int64\n", m, f, i)
+				fmt.Fprintf(&source, "\tsibling: ?Mod%dFile%dType%d\n", m, (f+1)%10, i)
+				if m > 0 {
+					fmt.Fprintf(&source, "\tprevious: []mod%d.Mod%dFile%dType%d\n", m-1, m-1, f, i)
+				}
+				source.WriteString("}\n\n")
+			}
+			filename := fmt.Sprintf("file%d.tg", f)
+			program, err := parser.Parse(strings.NewReader(source.String()), filename)
+			if err != nil {
+				b.Fatalf("Failed to parse %s: %v", filename, err)
+			}
+			files[filename] = program
+		}
+		name := fmt.Sprintf("mod%d", m)
+		root.SubModules[name] = ast.NewModule(name, files)
+	}
+	return root
+}
+
+func BenchmarkValidate_LargeModule(b *testing.B) {
+	module := largeModule(b)
+	for b.Loop() {
+		if result := NewValidator().Validate(module); result.HasErrors() {
+			b.Fatalf("Expected a valid module, got: %s", result.String())
+		}
+	}
+}
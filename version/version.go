@@ -0,0 +1,81 @@
+// Package version reports which build of typegen is running. Release builds
+// set the variables with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/WhatsApp-Platform/typegen/version.Version=v1.2.0 \
+//	  -X github.com/WhatsApp-Platform/typegen/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/WhatsApp-Platform/typegen/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/typegen
+//
+// Otherwise they fall back to the module version and VCS stamps recorded by
+// the Go toolchain, and finally to "devel" and "unknown".
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Fallback values for builds without version information
+const (
+	Devel   = "devel"
+	Unknown = "unknown"
+)
+
+var (
+	// Version is the release version, e.g. "v1.2.0", or "devel"
+	Version string
+	// Commit is the git commit the binary was built from
+	Commit string
+	// Date is the build date, in RFC 3339
+	Date string
+)
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	Version, Commit, Date = resolve(Version, Commit, Date, info, ok)
+}
+
+// resolve fills in the values not set with -ldflags from the build info
+func resolve(version, commit, date string, info *debug.BuildInfo, ok bool) (string, string, string) {
+	if ok && info != nil {
+		if version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = setting.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = setting.Value
+				}
+			}
+		}
+	}
+
+	if version == "" {
+		version = Devel
+	}
+	if commit == "" {
+		commit = Unknown
+	}
+	if date == "" {
+		date = Unknown
+	}
+	return version, commit, date
+}
+
+// String describes the build on one line, e.g.
+// "typegen v1.2.0 (commit 1a2b3c4, built 2025-01-02T03:04:05Z)"
+func String() string {
+	return fmt.Sprintf("typegen %s (commit %s, built %s)", Version, shortCommit(Commit), Date)
+}
+
+// shortCommit abbreviates a full commit hash to 12 characters
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
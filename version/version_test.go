@@ -0,0 +1,60 @@
+package version
+
+import (
+	"regexp"
+	"runtime/debug"
+	"testing"
+)
+
+var versionLine = regexp.MustCompile(`^typegen (\S+) \(commit (\S+), built (\S+)\)$`)
+
+func TestResolveFallback(t *testing.T) {
+	version, commit, date := resolve("", "", "", nil, false)
+	if version != Devel || commit != Unknown || date != Unknown {
+		t.Errorf("expected devel fallbacks, got %q %q %q", version, commit, date)
+	}
+
+	// A local build of the main module has no version of its own
+	version, _, _ = resolve("", "", "", &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, true)
+	if version != Devel {
+		t.Errorf("expected %q for a (devel) build, got %q", Devel, version)
+	}
+}
+
+func TestResolveBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1a2b3c4d5e6f7a8b9c0d"},
+			{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+		},
+	}
+
+	version, commit, date := resolve("", "", "", info, true)
+	if version != "v1.2.0" || commit != "1a2b3c4d5e6f7a8b9c0d" || date != "2025-01-02T03:04:05Z" {
+		t.Errorf("unexpected values from build info: %q %q %q", version, commit, date)
+	}
+
+	// Values set with -ldflags take precedence
+	version, commit, date = resolve("v2.0.0", "abc", "today", info, true)
+	if version != "v2.0.0" || commit != "abc" || date != "today" {
+		t.Errorf("expected -ldflags values to win, got %q %q %q", version, commit, date)
+	}
+}
+
+func TestString(t *testing.T) {
+	match := versionLine.FindStringSubmatch(String())
+	if match == nil {
+		t.Fatalf("unexpected version line %q", String())
+	}
+	if match[1] != Version {
+		t.Errorf("expected version %q, got %q", Version, match[1])
+	}
+
+	saved := Commit
+	defer func() { Commit = saved }()
+	Commit = "1a2b3c4d5e6f7a8b9c0d"
+	if match := versionLine.FindStringSubmatch(String()); match == nil || match[2] != "1a2b3c4d5e6f" {
+		t.Errorf("expected an abbreviated commit, got %q", String())
+	}
+}